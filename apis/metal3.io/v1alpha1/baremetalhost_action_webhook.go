@@ -0,0 +1,237 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// RBAC verbs on baremetalhosts a user needs to request actions on a
+// host, with annotations or HostAction resources.
+const (
+	// ForceDeleteVerb is needed to set the ForceDeleteAnnotation.
+	ForceDeleteVerb = "force-delete"
+	// RebootVerb is needed to set reboot annotations.
+	RebootVerb = "reboot"
+	// ReinspectVerb is needed to set the InspectAnnotationPrefix
+	// annotation to request an inspection.
+	ReinspectVerb = "reinspect"
+	// MoveVerb is needed to set the MoveToAnnotation, together with
+	// the right to create hosts in the target namespace.
+	MoveVerb = "move"
+)
+
+// ActionVerbsRequired makes the admission webhook also require the
+// verbs of the actions that clients requested with annotations before
+// the verbs existed, reboots and inspections, on top of the right to
+// update hosts. It is off by default so that these clients, such as
+// remediation controllers, keep working.
+var ActionVerbsRequired bool
+
+// hostAction is an action requested with annotations.
+type hostAction struct {
+	verb      string
+	requested func(key, value string) bool
+	// optional actions only need their verb when ActionVerbsRequired
+	// is set.
+	optional bool
+}
+
+var hostActions = []hostAction{
+	{
+		verb:      ForceDeleteVerb,
+		requested: func(key, value string) bool { return key == ForceDeleteAnnotation },
+	},
+	{
+		verb: RebootVerb,
+		requested: func(key, value string) bool {
+			return key == RebootAnnotationPrefix || strings.HasPrefix(key, RebootAnnotationPrefix+"/")
+		},
+		optional: true,
+	},
+	{
+		verb:      ReinspectVerb,
+		requested: func(key, value string) bool { return key == InspectAnnotationPrefix && value != "disabled" },
+		optional:  true,
+	},
+	{
+		verb:      MoveVerb,
+		requested: func(key, value string) bool { return key == MoveToAnnotation },
+	},
+}
+
+// requestedActions returns the verbs of the actions requested by
+// annotations added or changed in the new host.
+func requestedActions(oldAnnotations, newAnnotations map[string]string) []string {
+	var verbs []string
+	for _, action := range hostActions {
+		if action.optional && !ActionVerbsRequired {
+			continue
+		}
+		for key, value := range newAnnotations {
+			if oldValue, ok := oldAnnotations[key]; ok && oldValue == value {
+				continue
+			}
+			if action.requested(key, value) {
+				verbs = append(verbs, action.verb)
+				break
+			}
+		}
+	}
+	return verbs
+}
+
+// +kubebuilder:webhook:path=/validate-metal3-io-v1alpha1-baremetalhost-actions,mutating=false,failurePolicy=fail,sideEffects=None,admissionReviewVersions=v1;v1beta1,groups=metal3.io,resources=baremetalhosts,verbs=create;update,versions=v1alpha1,name=vactions.baremetalhost.metal3.io
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// actionValidator only lets users allowed to use the verb of an
+// action on a host set the annotations requesting it, for instance
+// the ForceDeleteAnnotation, because force deleting a host leaves its
+// node behind in the provisioner.
+type actionValidator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &actionValidator{}
+
+// Handle implements admission.Handler.
+func (v *actionValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	host := &BareMetalHost{}
+	if err := v.decoder.Decode(req, host); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	old := &BareMetalHost{}
+	if len(req.OldObject.Raw) != 0 {
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	for _, verb := range requestedActions(old.Annotations, host.Annotations) {
+		checks := [][2]string{{req.Namespace, verb}}
+		if verb == MoveVerb {
+			checks = append(checks, [2]string{host.Annotations[MoveToAnnotation], "create"})
+		}
+		for _, check := range checks {
+			allowed, err := canRequest(ctx, v.client, req, check[0], check[1], req.Name)
+			if err != nil {
+				return admission.Errored(http.StatusInternalServerError, err)
+			}
+			if !allowed {
+				return admission.Denied(fmt.Sprintf("user %s is not allowed to %s baremetalhosts in namespace %s",
+					req.UserInfo.Username, check[1], check[0]))
+			}
+		}
+		if verb == ForceDeleteVerb {
+			baremetalhostlog.Info("force delete requested", "host", req.Name, "namespace", req.Namespace,
+				"user", req.UserInfo.Username, "reason", host.Annotations[ForceDeleteAnnotation])
+		}
+	}
+	return admission.Allowed("")
+}
+
+// +kubebuilder:webhook:path=/validate-metal3-io-v1alpha1-hostaction,mutating=false,failurePolicy=fail,sideEffects=None,admissionReviewVersions=v1;v1beta1,groups=metal3.io,resources=hostactions,verbs=create;update,versions=v1alpha1,name=vhostaction.metal3.io
+
+// hostActionVerbs are the verbs users need on a host to create a
+// HostAction for it.
+var hostActionVerbs = map[HostActionType]string{
+	HostActionReboot: RebootVerb,
+}
+
+// hostActionValidator only lets users allowed to use the verb of an
+// action on a host create a HostAction for it, and keeps the action
+// from being changed afterwards.
+type hostActionValidator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &hostActionValidator{}
+
+// Handle implements admission.Handler.
+func (v *hostActionValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	action := &HostAction{}
+	if err := v.decoder.Decode(req, action); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if len(req.OldObject.Raw) != 0 {
+		old := &HostAction{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if old.Spec != action.Spec {
+			return admission.Denied("the spec of a host action cannot be changed")
+		}
+		return admission.Allowed("")
+	}
+
+	if action.Spec.HostName == "" {
+		return admission.Denied("hostName is required")
+	}
+	verb, known := hostActionVerbs[action.Spec.Action]
+	if !known {
+		return admission.Denied(fmt.Sprintf("unknown action %q", action.Spec.Action))
+	}
+	allowed, err := canRequest(ctx, v.client, req, req.Namespace, verb, action.Spec.HostName)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if !allowed {
+		return admission.Denied(fmt.Sprintf("user %s is not allowed to %s baremetalhost %s in namespace %s",
+			req.UserInfo.Username, verb, action.Spec.HostName, req.Namespace))
+	}
+	baremetalhostlog.Info("host action requested", "host", action.Spec.HostName, "namespace", req.Namespace,
+		"user", req.UserInfo.Username, "action", action.Spec.Action)
+	return admission.Allowed("")
+}
+
+// canRequest asks the API server whether the user making the request
+// may use the verb on the named host in the namespace.
+func canRequest(ctx context.Context, c client.Client, req admission.Request, namespace, verb, name string) (bool, error) {
+	extra := make(map[string]authorizationv1.ExtraValue, len(req.UserInfo.Extra))
+	for key, value := range req.UserInfo.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   req.UserInfo.Username,
+			Groups: req.UserInfo.Groups,
+			UID:    req.UserInfo.UID,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      verb,
+				Group:     GroupVersion.Group,
+				Resource:  "baremetalhosts",
+				Name:      name,
+			},
+		},
+	}
+	if err := c.Create(ctx, review); err != nil {
+		return false, errors.Wrapf(err, "failed to review access to %s", verb)
+	}
+	return review.Status.Allowed, nil
+}
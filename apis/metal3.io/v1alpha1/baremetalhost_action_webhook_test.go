@@ -0,0 +1,274 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// reviewClient answers subject access reviews.
+type reviewClient struct {
+	client.Client
+	allowed bool
+	reviews []authorizationv1.SubjectAccessReviewSpec
+}
+
+func (c *reviewClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	review := obj.(*authorizationv1.SubjectAccessReview)
+	c.reviews = append(c.reviews, review.Spec)
+	review.Status.Allowed = c.allowed
+	return nil
+}
+
+func TestActionValidator(t *testing.T) {
+	makeHost := func(annotations map[string]string) runtime.RawExtension {
+		content, err := json.Marshal(&BareMetalHost{
+			TypeMeta:   metav1.TypeMeta{APIVersion: GroupVersion.String(), Kind: "BareMetalHost"},
+			ObjectMeta: metav1.ObjectMeta{Name: "myhost", Namespace: "myns", Annotations: annotations},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return runtime.RawExtension{Raw: content}
+	}
+	forced := map[string]string{ForceDeleteAnnotation: "ironic is gone"}
+
+	testCases := []struct {
+		Scenario      string
+		Old           map[string]string
+		New           map[string]string
+		Required      bool
+		Allowed       bool
+		ExpectAllowed bool
+		ExpectReviews []string
+	}{
+		{
+			Scenario:      "no annotation",
+			New:           map[string]string{PausedAnnotation: ""},
+			ExpectAllowed: true,
+		},
+		{
+			Scenario:      "force delete denied",
+			New:           forced,
+			ExpectReviews: []string{"myns/" + ForceDeleteVerb},
+		},
+		{
+			Scenario:      "force delete allowed",
+			New:           forced,
+			Allowed:       true,
+			ExpectAllowed: true,
+			ExpectReviews: []string{"myns/" + ForceDeleteVerb},
+		},
+		{
+			Scenario:      "unchanged annotation",
+			Old:           forced,
+			New:           forced,
+			ExpectAllowed: true,
+		},
+		{
+			Scenario:      "reboot without required verbs",
+			New:           map[string]string{RebootAnnotationPrefix: ""},
+			ExpectAllowed: true,
+		},
+		{
+			Scenario:      "reboot denied",
+			New:           map[string]string{RebootAnnotationPrefix: ""},
+			Required:      true,
+			ExpectReviews: []string{"myns/" + RebootVerb},
+		},
+		{
+			Scenario:      "suffixed reboot allowed",
+			New:           map[string]string{RebootAnnotationPrefix + "/remediation": `{"mode": "hard"}`},
+			Required:      true,
+			Allowed:       true,
+			ExpectAllowed: true,
+			ExpectReviews: []string{"myns/" + RebootVerb},
+		},
+		{
+			Scenario:      "reboot removed",
+			Old:           map[string]string{RebootAnnotationPrefix: ""},
+			ExpectAllowed: true,
+		},
+		{
+			Scenario:      "reinspect allowed",
+			New:           map[string]string{InspectAnnotationPrefix: ""},
+			Required:      true,
+			Allowed:       true,
+			ExpectAllowed: true,
+			ExpectReviews: []string{"myns/" + ReinspectVerb},
+		},
+		{
+			Scenario:      "inspection disabled",
+			New:           map[string]string{InspectAnnotationPrefix: "disabled"},
+			Required:      true,
+			ExpectAllowed: true,
+		},
+		{
+			Scenario:      "move",
+			New:           map[string]string{MoveToAnnotation: "otherns"},
+			Allowed:       true,
+			ExpectAllowed: true,
+			ExpectReviews: []string{"myns/" + MoveVerb, "otherns/create"},
+		},
+		{
+			Scenario: "several actions",
+			New: map[string]string{
+				ForceDeleteAnnotation:  "ironic is gone",
+				RebootAnnotationPrefix: "",
+			},
+			Required:      true,
+			Allowed:       true,
+			ExpectAllowed: true,
+			ExpectReviews: []string{"myns/" + ForceDeleteVerb, "myns/" + RebootVerb},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			ActionVerbsRequired = tc.Required
+			defer func() { ActionVerbsRequired = false }()
+			c := &reviewClient{allowed: tc.Allowed}
+			v := &actionValidator{client: c, decoder: decoder}
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Update,
+				Name:      "myhost",
+				Namespace: "myns",
+				Object:    makeHost(tc.New),
+				OldObject: makeHost(tc.Old),
+				UserInfo:  authenticationv1.UserInfo{Username: "jdoe", Groups: []string{"ops"}},
+			}}
+
+			response := v.Handle(context.TODO(), req)
+			assert.Equal(t, tc.ExpectAllowed, response.Allowed)
+			var reviews []string
+			for _, review := range c.reviews {
+				assert.Equal(t, "jdoe", review.User)
+				assert.Equal(t, &authorizationv1.ResourceAttributes{
+					Namespace: review.ResourceAttributes.Namespace,
+					Verb:      review.ResourceAttributes.Verb,
+					Group:     "metal3.io",
+					Resource:  "baremetalhosts",
+					Name:      "myhost",
+				}, review.ResourceAttributes)
+				reviews = append(reviews, review.ResourceAttributes.Namespace+"/"+review.ResourceAttributes.Verb)
+			}
+			assert.Equal(t, tc.ExpectReviews, reviews)
+		})
+	}
+}
+
+func TestHostActionValidator(t *testing.T) {
+	makeAction := func(spec HostActionSpec) runtime.RawExtension {
+		content, err := json.Marshal(&HostAction{
+			TypeMeta:   metav1.TypeMeta{APIVersion: GroupVersion.String(), Kind: "HostAction"},
+			ObjectMeta: metav1.ObjectMeta{Name: "reboot-myhost", Namespace: "myns"},
+			Spec:       spec,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return runtime.RawExtension{Raw: content}
+	}
+	reboot := HostActionSpec{HostName: "myhost", Action: HostActionReboot}
+	hardReboot := HostActionSpec{HostName: "myhost", Action: HostActionReboot, RebootMode: RebootModeHard}
+
+	testCases := []struct {
+		Scenario      string
+		Old           *HostActionSpec
+		New           HostActionSpec
+		Allowed       bool
+		ExpectAllowed bool
+		ExpectReviews []string
+	}{
+		{
+			Scenario:      "reboot denied",
+			New:           reboot,
+			ExpectReviews: []string{"myns/myhost/" + RebootVerb},
+		},
+		{
+			Scenario:      "reboot allowed",
+			New:           reboot,
+			Allowed:       true,
+			ExpectAllowed: true,
+			ExpectReviews: []string{"myns/myhost/" + RebootVerb},
+		},
+		{
+			Scenario: "unknown action",
+			New:      HostActionSpec{HostName: "myhost", Action: "detach"},
+			Allowed:  true,
+		},
+		{
+			Scenario: "no host",
+			New:      HostActionSpec{Action: HostActionReboot},
+			Allowed:  true,
+		},
+		{
+			Scenario:      "status update",
+			Old:           &reboot,
+			New:           reboot,
+			ExpectAllowed: true,
+		},
+		{
+			Scenario: "spec changed",
+			Old:      &reboot,
+			New:      hardReboot,
+			Allowed:  true,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			c := &reviewClient{allowed: tc.Allowed}
+			v := &hostActionValidator{client: c, decoder: decoder}
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				Name:      "reboot-myhost",
+				Namespace: "myns",
+				Object:    makeAction(tc.New),
+				UserInfo:  authenticationv1.UserInfo{Username: "jdoe"},
+			}}
+			if tc.Old != nil {
+				req.Operation = admissionv1.Update
+				req.OldObject = makeAction(*tc.Old)
+			}
+
+			response := v.Handle(context.TODO(), req)
+			assert.Equal(t, tc.ExpectAllowed, response.Allowed)
+			var reviews []string
+			for _, review := range c.reviews {
+				attributes := review.ResourceAttributes
+				assert.Equal(t, "baremetalhosts", attributes.Resource)
+				reviews = append(reviews, attributes.Namespace+"/"+attributes.Name+"/"+attributes.Verb)
+			}
+			assert.Equal(t, tc.ExpectReviews, reviews)
+		})
+	}
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"net"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -44,6 +46,85 @@ const (
 	// annotation is present and status is empty, BMO will reconstruct BMH Status
 	// from the status annotation.
 	StatusAnnotation = "baremetalhost.metal3.io/status"
+
+	// DryRunAnnotation is the annotation that makes the provisioner
+	// report the changes it would make to the host as events, instead
+	// of making them. The host status is not updated while the
+	// annotation is present.
+	DryRunAnnotation = "baremetalhost.metal3.io/dry-run"
+
+	// ExportBIOSSettingsAnnotation is the annotation that requests a
+	// copy of all of the current BIOS settings of the host in a
+	// ConfigMap. The value is the name of the ConfigMap, or empty to
+	// use the name of the host with a "-bios-settings" suffix. The
+	// annotation is removed once the settings have been exported.
+	ExportBIOSSettingsAnnotation = "baremetalhost.metal3.io/export-bios-settings"
+
+	// InspectAnnotationPrefix is the annotation that disables the
+	// inspection of a host when set to "disabled". Setting
+	// spec.inspection.disabled is preferred. Set to any other value,
+	// it requests the inspection of a ready host, and the admission
+	// webhook only lets users with the reinspect verb on the host set
+	// it.
+	InspectAnnotationPrefix = "inspect.metal3.io"
+
+	// RebootAnnotationPrefix is the annotation, with an optional
+	// suffix, that reboots a host or keeps it powered off. The
+	// admission webhook only lets users with the reboot verb on the
+	// host set it.
+	RebootAnnotationPrefix = "reboot.metal3.io"
+
+	// HardwareDetailsAnnotation is the annotation that provides the
+	// hardware details of a host as JSON, in the same schema as the
+	// hardware status field. Setting spec.inspection.hardwareDetails
+	// is preferred.
+	HardwareDetailsAnnotation = InspectAnnotationPrefix + "/hardwaredetails"
+
+	// SparePoolLabel is the label that adds a ready host to a pool of
+	// spares, named by its value.
+	SparePoolLabel = "baremetalhost.metal3.io/spare-pool"
+
+	// ReplacementPoolLabel is the label that makes a provisioned host
+	// replaced by a spare from the pool named by its value when it
+	// fails.
+	ReplacementPoolLabel = "baremetalhost.metal3.io/replacement-pool"
+
+	// UnhealthyAnnotation is the annotation health checks set on a
+	// provisioned host to have it replaced by a spare. The value is a
+	// free-form reason.
+	UnhealthyAnnotation = "baremetalhost.metal3.io/unhealthy"
+
+	// ReplacedByAnnotation and ReplacesAnnotation are set on a failed
+	// host and on the spare replacing it to the name of the other
+	// host.
+	ReplacedByAnnotation = "baremetalhost.metal3.io/replaced-by"
+	ReplacesAnnotation   = "baremetalhost.metal3.io/replaces"
+
+	// ForceDeleteAnnotation is the annotation that removes the
+	// finalizer of a host being deleted without deprovisioning it or
+	// deleting it from the provisioner, for when the provisioner is
+	// gone for good. The value is a free-form reason. The admission
+	// webhook only lets users with the force-delete verb on the host
+	// set it.
+	ForceDeleteAnnotation = "baremetalhost.metal3.io/force-delete"
+
+	// MoveToAnnotation is the annotation that moves a host to the
+	// namespace named by its value without deprovisioning it. The
+	// host is recreated there with its status and secrets, and then
+	// removed from its namespace. The admission webhook only lets
+	// users with the move verb on the host, who may create hosts in
+	// the target namespace, set it.
+	MoveToAnnotation = "baremetalhost.metal3.io/move-to"
+
+	// RefreshStatusAnnotation is the annotation that reads the power
+	// state and the driver of a host from the provisioner again, to
+	// refresh a status that may be stale. The annotation is removed
+	// once the status has been refreshed.
+	RefreshStatusAnnotation = "baremetalhost.metal3.io/refresh-status"
+
+	// MovedFromAnnotation is set on a host moved to another
+	// namespace to the namespace it was moved from.
+	MovedFromAnnotation = "baremetalhost.metal3.io/moved-from"
 )
 
 // RootDeviceHints holds the hints for specifying the storage location
@@ -87,6 +168,34 @@ type RootDeviceHints struct {
 
 	// True if the device should use spinning media, false otherwise.
 	Rotational *bool `json:"rotational,omitempty"`
+
+	// The WWID of a multipath device, as reported by multipath. The
+	// hint must match the actual value exactly. The image is written
+	// to the multipath device rather than to one of its paths.
+	WWID string `json:"wwid,omitempty"`
+
+	// A /dev/disk/by-path name of the device, such as
+	// "/dev/disk/by-path/pci-0000:41:00.0-fc-0x500a0981891b8dc5-lun-0".
+	// The hint must match the actual value exactly, unless it ends
+	// with "*" to match all the names starting with it.
+	// +kubebuilder:validation:Pattern=`^/dev/disk/by-path/[^*]+\*?$`
+	ByPath string `json:"byPath,omitempty"`
+}
+
+// Multipath reports whether the hints select a multipath device.
+func (hints *RootDeviceHints) Multipath() bool {
+	return hints != nil && hints.WWID != ""
+}
+
+// ConfigDriveEncryption holds the key the user data of the config
+// drive is encrypted with.
+type ConfigDriveEncryption struct {
+	// KeySecretName is the name of the Secret, in the namespace of the
+	// host, holding the 32 byte AES-256 key under "key". The Secret is
+	// created with a random key, owned by the host, when it does not
+	// exist. Defaults to <host name>-config-drive-key.
+	// +optional
+	KeySecretName string `json:"keySecretName,omitempty"`
 }
 
 // BootMode is the boot mode of the system
@@ -147,6 +256,21 @@ const (
 	// PowerManagementError is an error condition occurring when the
 	// controller is unable to modify the power state of the Host.
 	PowerManagementError ErrorType = "power management error"
+	// MACMismatchError is an error condition occurring when none of
+	// the NICs found during inspection has the BootMACAddress from
+	// the Host spec.
+	MACMismatchError ErrorType = "mac mismatch error"
+	// DecommissionError is an error condition occurring when the
+	// controller fails to erase the disks of a host being
+	// decommissioned or to record its certificate of erasure.
+	DecommissionError ErrorType = "decommission error"
+	// RetirementError is an error condition occurring when the
+	// controller fails to erase the disks of a host being retired or
+	// to remove it from the provisioner.
+	RetirementError ErrorType = "retirement error"
+	// TimeoutError is an error condition occurring when an operation
+	// on the Host takes longer than its timeout.
+	TimeoutError ErrorType = "timeout error"
 )
 
 // ProvisioningState defines the states the provisioner will report
@@ -200,6 +324,23 @@ const (
 	// StateDeleting means we are in the process of cleaning up the host
 	// ready for deletion
 	StateDeleting ProvisioningState = "deleting"
+
+	// StateDecommissioning means we are erasing the disks of the host
+	// and recording a certificate of erasure before retiring it
+	StateDecommissioning ProvisioningState = "decommissioning"
+
+	// StateDecommissioned means the disks of the host have been
+	// erased and it has been powered off for good
+	StateDecommissioned ProvisioningState = "decommissioned"
+
+	// StateRetiring means we are erasing the disks of the host,
+	// powering it off and removing its BMC credentials from the
+	// provisioner
+	StateRetiring ProvisioningState = "retiring"
+
+	// StateRetired means the host is powered off and left alone until
+	// it is brought back into service
+	StateRetired ProvisioningState = "retired"
 )
 
 // BMCDetails contains the information necessary to communicate with
@@ -222,6 +363,102 @@ type BMCDetails struct {
 	DisableCertificateVerification bool `json:"disableCertificateVerification,omitempty"`
 }
 
+// PowerEnforcement defines when the power state of a host is changed
+// back to match its online field after it was changed outside of the
+// operator.
+type PowerEnforcement string
+
+const (
+	// PowerEnforcementAlways reverts every power change made outside
+	// of the operator
+	PowerEnforcementAlways PowerEnforcement = "Always"
+	// PowerEnforcementUntilProvisioned reverts power changes made
+	// outside of the operator until the host is provisioned
+	PowerEnforcementUntilProvisioned PowerEnforcement = "UntilProvisioned"
+	// PowerEnforcementObserve only records power changes made outside
+	// of the operator
+	PowerEnforcementObserve PowerEnforcement = "Observe"
+)
+
+// PowerPolicy controls how the operator reacts to power changes made
+// outside of it, for example by an administrator powering a host off
+// for maintenance. Changes to the online field and reboot requests are
+// always applied.
+type PowerPolicy struct {
+	// Enforcement defines which power changes made outside of the
+	// operator are reverted. Defaults to Always.
+	// +kubebuilder:validation:Enum=Always;UntilProvisioned;Observe
+	// +optional
+	Enforcement PowerEnforcement `json:"enforcement,omitempty"`
+
+	// GracePeriod is how long the operator waits after a power change
+	// made outside of it before reverting it, as a duration like
+	// "30m". Defaults to reverting it immediately.
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+
+	// IdlePowerOffAfter powers the host off once it has been available
+	// without a consumer for longer than this, as a duration like
+	// "4h". It stays off until it is provisioned or claimed, which
+	// powers it on again.
+	// +optional
+	IdlePowerOffAfter *metav1.Duration `json:"idlePowerOffAfter,omitempty"`
+}
+
+// CustomDeploy holds the deploy steps to run when provisioning a host.
+type CustomDeploy struct {
+	// Steps are run by Ironic together with its default deploy steps,
+	// in the order of their priority. They require Ironic API version
+	// 1.69 or newer.
+	// +optional
+	Steps []DeployStep `json:"steps,omitempty"`
+}
+
+// DeployStep is an Ironic deploy step.
+type DeployStep struct {
+	// Interface is the driver interface implementing the step.
+	// +kubebuilder:validation:Enum=bios;deploy;management;power;raid;storage
+	Interface string `json:"interface"`
+
+	// Step is the name of the step, such as write_image.
+	// +kubebuilder:validation:MinLength=1
+	Step string `json:"step"`
+
+	// Args are the arguments of the step. Each value is passed to
+	// Ironic as the JSON value it holds, such as true, 42 or
+	// {"key": "value"}, or as a string if it is not valid JSON.
+	// +optional
+	Args map[string]string `json:"args,omitempty"`
+
+	// Priority orders the step among the deploy steps, the highest
+	// running first. The default write_image step has a priority of
+	// 80. A priority of 0 disables the step, which is how default
+	// steps are turned off.
+	// +kubebuilder:validation:Minimum=0
+	Priority int `json:"priority"`
+}
+
+// NodeInterfaces selects interfaces of the provisioner node other than
+// the defaults chosen for the BMC type. The values are checked against
+// the interfaces the BMC type supports.
+type NodeInterfaces struct {
+	// Boot is the interface used to boot the provisioning image from
+	// the network: "ipxe", "pxe", or "http-ipxe" and "http" to use
+	// UEFI HTTP boot instead of TFTP.
+	// +optional
+	Boot string `json:"boot,omitempty"`
+
+	// BIOS is the interface used to read and change BIOS settings,
+	// for example "redfish" or "no-bios".
+	// +optional
+	BIOS string `json:"bios,omitempty"`
+
+	// Deploy is the interface used to write the image, for example
+	// "direct" or "ansible". Live ISO images always use "ramdisk".
+	// +optional
+	Deploy string `json:"deploy,omitempty"`
+}
+
 // HardwareRAIDVolume defines the desired configuration of volume in hardware RAID
 type HardwareRAIDVolume struct {
 	// Size (Integer) of the logical disk to be created in GiB.
@@ -292,6 +529,11 @@ type BareMetalHostSpec struct {
 	// How do we connect to the BMC?
 	BMC BMCDetails `json:"bmc,omitempty"`
 
+	// NodeInterfaces overrides the interfaces of the provisioner node
+	// chosen for the BMC type.
+	// +optional
+	NodeInterfaces *NodeInterfaces `json:"nodeInterfaces,omitempty"`
+
 	// RAID configuration for bare metal server
 	RAID *RAIDConfig `json:"raid,omitempty"`
 
@@ -314,9 +556,71 @@ type BareMetalHostSpec struct {
 	// +kubebuilder:validation:Pattern=`[0-9a-fA-F]{2}(:[0-9a-fA-F]{2}){5}`
 	BootMACAddress string `json:"bootMACAddress,omitempty"`
 
+	// Other NICs to provision through when provisioning through
+	// BootMACAddress fails, tried in order.
+	// +optional
+	BootFallback *BootFallback `json:"bootFallback,omitempty"`
+
+	// ProvisioningNetwork is the name of the provisioning network,
+	// configured in the operator, the host boots from. It selects the
+	// image server and callback URLs reachable from the host. The
+	// default endpoints are used when it is not set.
+	// +optional
+	ProvisioningNetwork string `json:"provisioningNetwork,omitempty"`
+
+	// ImageDownloadLimitMbps limits the bandwidth, in megabits per
+	// second, the deployment agent uses to download the image, where
+	// the agent supports it. It replaces the limit configured in the
+	// operator, and zero removes it.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ImageDownloadLimitMbps *int `json:"imageDownloadLimitMbps,omitempty"`
+
+	// Priority orders the hosts waiting to be inspected or
+	// provisioned when the operator cannot handle all of them at
+	// once. Hosts with a higher priority go first, for example to
+	// bring up the control plane before the workers. Defaults to 0.
+	// +optional
+	Priority int `json:"priority,omitempty"`
+
+	// ProvisioningClass names the class of the host, such as
+	// "control-plane" or "worker". The operator may limit how many
+	// hosts of each class it inspects and provisions at the same
+	// time.
+	// +optional
+	ProvisioningClass string `json:"provisioningClass,omitempty"`
+
+	// AgentKernelArgs are appended to the kernel command line of the
+	// deployment agent ramdisk the host boots for inspection,
+	// cleaning and provisioning, such as console, ip= or proxy
+	// settings. Each item is a single argument.
+	// +optional
+	AgentKernelArgs []string `json:"agentKernelArgs,omitempty"`
+
+	// ProtectBootOrder makes the operator keep a provisioned host
+	// booting from its disks, through the BMC: one-time boot
+	// overrides are removed and network boot options are moved
+	// after the other ones, so a stray DHCP or PXE server cannot
+	// take the host over when it reboots. The boot order is checked
+	// again periodically.
+	// +optional
+	ProtectBootOrder bool `json:"protectBootOrder,omitempty"`
+
+	// Tags are set on the node of the host in the provisioner, so that
+	// external inventory tools can select hosts by them. They can be
+	// changed at any time, including while the host is provisioned.
+	// Tags set on the node by other tools are kept.
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
 	// Should the server be online?
 	Online bool `json:"online"`
 
+	// PowerPolicy controls whether power changes made outside of the
+	// operator are reverted to match Online.
+	// +optional
+	PowerPolicy *PowerPolicy `json:"powerPolicy,omitempty"`
+
 	// ConsumerRef can be used to store information about something
 	// that is using a host. When it is not empty, the host is
 	// considered "in use".
@@ -329,6 +633,13 @@ type BareMetalHostSpec struct {
 	// data to be passed to the host before it boots.
 	UserData *corev1.SecretReference `json:"userData,omitempty"`
 
+	// UserDataSecrets hold the references to Secrets containing user
+	// data, such as a base configuration, a role configuration and
+	// overrides for the host, merged in order into one cloud-init
+	// multi-part document. It cannot be used along with UserData.
+	// +optional
+	UserDataSecrets []corev1.SecretReference `json:"userDataSecrets,omitempty"`
+
 	// NetworkData holds the reference to the Secret containing network
 	// configuration (e.g content of network_data.json which is passed
 	// to Config Drive).
@@ -338,6 +649,30 @@ type BareMetalHostSpec struct {
 	// (e.g. meta_data.json which is passed to Config Drive).
 	MetaData *corev1.SecretReference `json:"metaData,omitempty"`
 
+	// MetaDataTemplate generates metadata for the config drive from
+	// the host itself, so that hosts do not need a metadata Secret
+	// each. Values in the MetaData Secret take precedence.
+	// +optional
+	MetaDataTemplate *MetaDataTemplate `json:"metaDataTemplate,omitempty"`
+
+	// SSHAuthorizedKeys are SSH public keys, in the authorized_keys
+	// format, added to the public keys of the config drive metadata
+	// so that they can log in to the provisioned host.
+	// +optional
+	SSHAuthorizedKeys []string `json:"sshAuthorizedKeys,omitempty"`
+
+	// ConfigDriveEncryption encrypts the user data of the config drive
+	// with a key of the host, for provisioning networks that are not
+	// trusted. The key has to be delivered to the host out-of-band.
+	// +optional
+	ConfigDriveEncryption *ConfigDriveEncryption `json:"configDriveEncryption,omitempty"`
+
+	// CustomDeploy runs Ironic deploy steps provided by the user
+	// while the image is written to the host, for deployment flows
+	// the default steps do not cover.
+	// +optional
+	CustomDeploy *CustomDeploy `json:"customDeploy,omitempty"`
+
 	// Description is a human-entered text used to help identify the host
 	Description string `json:"description,omitempty"`
 
@@ -346,6 +681,280 @@ type BareMetalHostSpec struct {
 	// the power status and hardware inventory inspection. If the
 	// Image field is filled in, this field is ignored.
 	ExternallyProvisioned bool `json:"externallyProvisioned,omitempty"`
+
+	// Reinspection inspects the host again periodically while it is
+	// ready, to keep the hardware details up to date. It overrides
+	// the interval set for all hosts by the operator.
+	// +optional
+	Reinspection *ReinspectionPolicy `json:"reinspection,omitempty"`
+
+	// MaintenanceWindow limits the disruptive operations on the host,
+	// its periodic reinspections and the reboots requested by
+	// annotation, to recurring periods. They wait for the next window
+	// otherwise.
+	// +optional
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+
+	// Inspection controls the hardware inspection of the host, and
+	// can provide its hardware details instead.
+	// +optional
+	Inspection *InspectionSettings `json:"inspection,omitempty"`
+
+	// Timeouts bounds how long the operations on the host may take.
+	// They override the timeouts set for all hosts by the operator.
+	// +optional
+	Timeouts *OperationTimeouts `json:"timeouts,omitempty"`
+
+	// SecureBootDatabases lists the updates to apply to the UEFI
+	// secure boot databases of the host, such as the latest dbx
+	// revocation list. Provisioned hosts wait for their maintenance
+	// window.
+	// +optional
+	SecureBootDatabases []SecureBootDatabaseUpdate `json:"secureBootDatabases,omitempty"`
+
+	// Decommission retires the host: its image is removed, all of its
+	// disks are erased, a certificate of erasure is recorded and the
+	// host is powered off for good. This cannot be undone.
+	// +optional
+	Decommission bool `json:"decommission,omitempty"`
+
+	// Retire takes the host out of service: its image is removed, its
+	// disks are erased, it is powered off and its BMC credentials are
+	// removed from the provisioner. The host is then left alone until
+	// the field is cleared, and it is registered again.
+	// +optional
+	Retire bool `json:"retire,omitempty"`
+
+	// SecureEraseBypass lists the serial numbers of the disks whose
+	// secure erase is skipped when the host is decommissioned, for
+	// drives whose broken self-encrypting firmware would keep the
+	// host cleaning forever. The partition tables and filesystem
+	// signatures of those disks are still wiped.
+	// +optional
+	SecureEraseBypass []string `json:"secureEraseBypass,omitempty"`
+
+	// Operational holds metadata about who runs the host and how it
+	// was bought, in place of free-form annotations.
+	// +optional
+	Operational *OperationalMetadata `json:"operational,omitempty"`
+}
+
+// OperationalMetadata describes the ownership of a host, for the
+// people and systems operating it.
+type OperationalMetadata struct {
+	// OwnerTeam is the team responsible for the host. It must be a
+	// valid label value.
+	// +optional
+	OwnerTeam string `json:"ownerTeam,omitempty"`
+
+	// TicketURL links to the ticket tracking the current work on the
+	// host.
+	// +optional
+	TicketURL string `json:"ticketURL,omitempty"`
+
+	// AssetTag is the purchase or asset tag of the host. It must be a
+	// valid label value.
+	// +optional
+	AssetTag string `json:"assetTag,omitempty"`
+
+	// WarrantyExpiry is the time the hardware warranty of the host
+	// ends.
+	// +optional
+	WarrantyExpiry *metav1.Time `json:"warrantyExpiry,omitempty"`
+
+	// Notes are free-form operational notes about the host.
+	// +optional
+	Notes string `json:"notes,omitempty"`
+}
+
+// MetaDataTemplate describes the metadata generated for the config
+// drive. The templates use the Go text/template syntax and are
+// executed with the BareMetalHost, for example
+// "{{ .Name }}.{{ index .Labels \"rack\" }}.example.com".
+type MetaDataTemplate struct {
+	// Hostname is a template of the hostname of the host. The name of
+	// the host is used when not set.
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+
+	// IncludeLabels copies the labels of the host to the metal3-labels
+	// key of the metadata.
+	// +optional
+	IncludeLabels bool `json:"includeLabels,omitempty"`
+
+	// Values are templates of extra metadata keys.
+	// +optional
+	Values map[string]string `json:"values,omitempty"`
+}
+
+// InspectionSettings controls the hardware inspection of the host.
+type InspectionSettings struct {
+	// Disabled skips the inspection of the host, like the
+	// inspect.metal3.io=disabled annotation.
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+
+	// HardwareDetails is the inventory of the host, copied to the
+	// status in place of the results of inspection. It can only be
+	// set when inspection is disabled.
+	// +optional
+	HardwareDetails *HardwareDetails `json:"hardwareDetails,omitempty"`
+
+	// Collectors are the inspection collectors run by the deployment
+	// agent in addition to the default one. More collectors make
+	// inspection slower, but report more data. When not set, the
+	// collectors configured for the operator are used.
+	// +optional
+	Collectors []InspectionCollector `json:"collectors,omitempty"`
+
+	// Benchmarks are the quick performance tests run by the
+	// deployment agent during inspection, with their results recorded
+	// in the hardware details. They need the extra-hardware collector,
+	// which is enabled along with them. When not set, the benchmarks
+	// configured for the operator are run.
+	// +optional
+	Benchmarks []InspectionBenchmark `json:"benchmarks,omitempty"`
+
+	// OutOfBandRequest reads the inventory of the host again from its
+	// BMC whenever it is set to a value other than the last one
+	// handled, such as the current date. The hardware details are
+	// updated without booting the host, so it can be done while the
+	// host is provisioned. Only Redfish BMCs support it.
+	// +optional
+	OutOfBandRequest string `json:"outOfBandRequest,omitempty"`
+
+	// HistoryLimit is the number of inspection results kept as
+	// HardwareDataSnapshot resources, the oldest being deleted first.
+	// When not set, no snapshot is recorded.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	HistoryLimit int `json:"historyLimit,omitempty"`
+}
+
+// InspectionCollector is the name of an inspection collector of the
+// deployment agent.
+// +kubebuilder:validation:Enum=extra-hardware;logs;pci-devices;lldp;numa-topology
+type InspectionCollector string
+
+// Inspection collectors that can be enabled
+const (
+	// ExtraHardwareCollector collects detailed hardware data, such as
+	// memory DIMMs and firmware versions
+	ExtraHardwareCollector InspectionCollector = "extra-hardware"
+
+	// LogsCollector sends the logs of the agent with the inspection
+	// data
+	LogsCollector InspectionCollector = "logs"
+
+	// PCIDevicesCollector lists the PCI devices of the host
+	PCIDevicesCollector InspectionCollector = "pci-devices"
+
+	// LLDPCollector collects the LLDP data received on each NIC
+	LLDPCollector InspectionCollector = "lldp"
+
+	// NUMATopologyCollector collects the NUMA node of each CPU, NIC
+	// and memory bank
+	NUMATopologyCollector InspectionCollector = "numa-topology"
+)
+
+// InspectionCollectors lists the collectors that can be enabled
+var InspectionCollectors = []InspectionCollector{
+	ExtraHardwareCollector,
+	LogsCollector,
+	PCIDevicesCollector,
+	LLDPCollector,
+	NUMATopologyCollector,
+}
+
+// InspectionBenchmark is the name of a benchmark run by the
+// deployment agent during inspection.
+// +kubebuilder:validation:Enum=disk;mem
+type InspectionBenchmark string
+
+// Inspection benchmarks that can be enabled
+const (
+	// DiskBenchmark measures the sequential read throughput of each
+	// disk, without writing to it
+	DiskBenchmark InspectionBenchmark = "disk"
+
+	// MemoryBenchmark measures the memory bandwidth
+	MemoryBenchmark InspectionBenchmark = "mem"
+)
+
+// InspectionBenchmarks lists the benchmarks that can be enabled
+var InspectionBenchmarks = []InspectionBenchmark{
+	DiskBenchmark,
+	MemoryBenchmark,
+}
+
+// ReinspectionPolicy controls the periodic inspection of ready hosts.
+type ReinspectionPolicy struct {
+	// Interval is the time between the end of an inspection and the
+	// start of the next one. A zero interval disables reinspection.
+	Interval metav1.Duration `json:"interval"`
+}
+
+// SecureBootDatabaseUpdate adds entries to a UEFI secure boot
+// database of a host. The entries already in the database are kept.
+type SecureBootDatabaseUpdate struct {
+	// Database is the database to update, "db" for the allowed
+	// signatures or "dbx" for the forbidden ones.
+	// +kubebuilder:validation:Enum=db;dbx
+	Database string `json:"database"`
+
+	// Revision identifies the content of the update, such as the
+	// version of a dbx revocation list. The update is applied again
+	// when it changes.
+	Revision string `json:"revision"`
+
+	// Certificates are PEM encoded X.509 certificates to add.
+	// +optional
+	Certificates []string `json:"certificates,omitempty"`
+
+	// Signatures are hex encoded SHA-256 hashes of the binaries to
+	// add.
+	// +optional
+	Signatures []string `json:"signatures,omitempty"`
+}
+
+// OperationTimeouts bounds how long the operations on a host may take
+// before they fail with a timeout error. A zero or unset timeout uses
+// the one set for all hosts by the operator.
+type OperationTimeouts struct {
+	// Inspection bounds the time spent in the inspecting state.
+	// +optional
+	Inspection *metav1.Duration `json:"inspection,omitempty"`
+
+	// Provisioning bounds the time spent in the provisioning state.
+	// +optional
+	Provisioning *metav1.Duration `json:"provisioning,omitempty"`
+
+	// Cleaning bounds the time spent in the preparing and
+	// deprovisioning states.
+	// +optional
+	Cleaning *metav1.Duration `json:"cleaning,omitempty"`
+
+	// PowerChange bounds the time the host takes to reach the power
+	// state requested.
+	// +optional
+	PowerChange *metav1.Duration `json:"powerChange,omitempty"`
+}
+
+// MaintenanceWindow is a recurring period during which disruptive
+// operations may run on a host.
+type MaintenanceWindow struct {
+	// Schedule is when each window starts, as a cron expression with
+	// the minute, hour, day of month, month and day of week fields,
+	// such as "0 2 * * 6" for 2:00 every Saturday.
+	Schedule string `json:"schedule"`
+
+	// Duration is how long each window lasts, such as "4h".
+	Duration metav1.Duration `json:"duration"`
+
+	// TimeZone is the IANA time zone of the schedule, such as
+	// "Europe/Paris". Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
 }
 
 // ChecksumType holds the algorithm name for the checksum
@@ -421,6 +1030,44 @@ type CPU struct {
 	ClockMegahertz ClockSpeed `json:"clockMegahertz,omitempty"`
 	Flags          []string   `json:"flags,omitempty"`
 	Count          int        `json:"count,omitempty"`
+
+	// Sockets is the number of physical CPUs, reported by the
+	// extra-hardware collector
+	// +optional
+	Sockets int `json:"sockets,omitempty"`
+
+	// ThreadsPerCore is the number of hardware threads of each core,
+	// reported by the numa-topology or extra-hardware collector
+	// +optional
+	ThreadsPerCore int `json:"threadsPerCore,omitempty"`
+
+	// HugepageSizes lists the sizes of the huge pages the CPUs
+	// support, such as "2Mi" and "1Gi"
+	// +optional
+	HugepageSizes []string `json:"hugepageSizes,omitempty"`
+
+	// NUMANodes describes the NUMA layout of the host, reported by
+	// the numa-topology collector
+	// +optional
+	NUMANodes []NUMANode `json:"numaNodes,omitempty"`
+}
+
+// NUMANode describes the resources local to a NUMA node of the host.
+type NUMANode struct {
+	// ID is the number of the NUMA node
+	ID int `json:"id"`
+
+	// CPUs lists the logical CPUs of the node
+	// +optional
+	CPUs []int `json:"cpus,omitempty"`
+
+	// RAMMebibytes is the memory of the node
+	// +optional
+	RAMMebibytes int `json:"ramMebibytes,omitempty"`
+
+	// NICs lists the names of the NICs attached to the node
+	// +optional
+	NICs []string `json:"nics,omitempty"`
 }
 
 // Storage describes one storage device (disk, SSD, etc.) on the host.
@@ -455,6 +1102,37 @@ type Storage struct {
 
 	// The SCSI location of the device
 	HCTL string `json:"hctl,omitempty"`
+
+	// The SMART health of the disk, when inspection reports it
+	Health *DiskHealth `json:"health,omitempty"`
+}
+
+// DiskHealthStatus is the overall health of a disk.
+type DiskHealthStatus string
+
+const (
+	// DiskHealthOK is a disk reporting no problem.
+	DiskHealthOK DiskHealthStatus = "OK"
+
+	// DiskHealthFailing is a disk that failed its SMART health check,
+	// raised a critical warning or used up its endurance.
+	DiskHealthFailing DiskHealthStatus = "Failing"
+)
+
+// DiskHealth is the SMART health of a disk.
+type DiskHealth struct {
+	// The overall health of the disk
+	Status DiskHealthStatus `json:"status"`
+
+	// Why the disk is failing
+	Message string `json:"message,omitempty"`
+
+	// The estimated part of the endurance of the disk used, in
+	// percent. NVMe disks may report more than 100.
+	WearPercent int `json:"wearPercent,omitempty"`
+
+	// The number of unrecovered data integrity errors
+	MediaErrors int `json:"mediaErrors,omitempty"`
 }
 
 // VLANID is a 12-bit 802.1Q VLAN identifier
@@ -498,6 +1176,37 @@ type NIC struct {
 
 	// Whether the NIC is PXE Bootable
 	PXE bool `json:"pxe,omitempty"`
+
+	// The version of the firmware running on the NIC
+	FirmwareVersion string `json:"firmwareVersion,omitempty"`
+
+	// The name and version of the kernel driver for the NIC, e.g.
+	// "i40e 2.8.20-k"
+	Driver string `json:"driver,omitempty"`
+
+	// The negotiated duplex mode of the link, "full" or "half"
+	Duplex string `json:"duplex,omitempty"`
+
+	// Whether link speed and duplex are auto-negotiated, "on" or "off"
+	AutoNegotiation string `json:"autoNegotiation,omitempty"`
+
+	// The ID of the link aggregation group the switch port is a
+	// member of, as reported by LLDP
+	LinkAggregationID int `json:"linkAggregationId,omitempty"`
+}
+
+// NICMismatch records a setting that differs between the NICs
+// connected to the same link aggregation group, which usually causes
+// the bond to be unreliable.
+type NICMismatch struct {
+	// The ID of the link aggregation group
+	LinkAggregationID int `json:"linkAggregationId"`
+
+	// The names of the NICs in the group
+	NICs []string `json:"nics"`
+
+	// The name of the NIC field that differs, e.g. "firmwareVersion"
+	Field string `json:"field"`
 }
 
 // Firmware describes the firmware on the host.
@@ -528,6 +1237,71 @@ type HardwareDetails struct {
 	Storage      []Storage            `json:"storage,omitempty"`
 	CPU          CPU                  `json:"cpu,omitempty"`
 	Hostname     string               `json:"hostname,omitempty"`
+
+	// Settings that differ between NICs in the same link aggregation
+	// group
+	NICMismatches []NICMismatch `json:"nicMismatches,omitempty"`
+
+	// Results of the benchmarks run during inspection
+	Benchmarks *BenchmarkResults `json:"benchmarks,omitempty"`
+
+	// GPUs and other accelerators of the host
+	Accelerators []Accelerator `json:"accelerators,omitempty"`
+}
+
+// AcceleratorType is the kind of an accelerator.
+type AcceleratorType string
+
+const (
+	// GPUAccelerator is a graphics processor, also used for compute.
+	GPUAccelerator AcceleratorType = "GPU"
+
+	// ProcessingAccelerator is another processing accelerator, such
+	// as an AI inference card.
+	ProcessingAccelerator AcceleratorType = "Processing"
+)
+
+// Accelerator describes a GPU or other accelerator of the host.
+type Accelerator struct {
+	// The kind of accelerator
+	Type AcceleratorType `json:"type,omitempty"`
+
+	// The name of the vendor
+	Vendor string `json:"vendor,omitempty"`
+
+	// The model of the device
+	Model string `json:"model,omitempty"`
+
+	// The PCI vendor and device IDs, e.g. "10de" and "20b0"
+	VendorID string `json:"vendorID,omitempty"`
+	DeviceID string `json:"deviceID,omitempty"`
+
+	// The PCI address of the device, e.g. "0000:3b:00.0"
+	PCIAddress string `json:"pciAddress,omitempty"`
+
+	// The memory of the device, when the inspection reports it
+	VRAMMebibytes int `json:"vramMebibytes,omitempty"`
+}
+
+// BenchmarkResults are the results of the benchmarks run by the
+// deployment agent during inspection.
+type BenchmarkResults struct {
+	// The bandwidth of the memory with all CPUs copying 1GiB blocks,
+	// in megabytes per second
+	MemoryBandwidthMBps int `json:"memoryBandwidthMBps,omitempty"`
+
+	// The throughput of each disk
+	Disks []DiskBenchmarkResult `json:"disks,omitempty"`
+}
+
+// DiskBenchmarkResult is the result of the benchmark of a disk.
+type DiskBenchmarkResult struct {
+	// The name of the disk, as in the storage details
+	Name string `json:"name"`
+
+	// The sequential read throughput with 1MiB blocks, in kilobytes
+	// per second
+	SequentialReadKBps int `json:"sequentialReadKBps,omitempty"`
 }
 
 // HardwareSystemVendor stores details about the whole hardware system.
@@ -598,10 +1372,24 @@ func (om OperationMetric) Duration() time.Duration {
 type OperationHistory struct {
 	Register    OperationMetric `json:"register,omitempty"`
 	Inspect     OperationMetric `json:"inspect,omitempty"`
+	Prepare     OperationMetric `json:"prepare,omitempty"`
 	Provision   OperationMetric `json:"provision,omitempty"`
 	Deprovision OperationMetric `json:"deprovision,omitempty"`
 }
 
+// ProvisionerTimeout records a call to the provisioner that took too
+// long.
+type ProvisionerTimeout struct {
+	// Method is the provisioner call that timed out.
+	Method string `json:"method"`
+
+	// Timeout is how long the call was allowed to take.
+	Timeout metav1.Duration `json:"timeout"`
+
+	// Time is when the call timed out.
+	Time metav1.Time `json:"time"`
+}
+
 // BareMetalHostStatus defines the observed state of BareMetalHost
 type BareMetalHostStatus struct {
 	// Important: Run "make generate manifests" to regenerate code
@@ -613,7 +1401,7 @@ type BareMetalHostStatus struct {
 
 	// ErrorType indicates the type of failure encountered when the
 	// OperationalStatus is OperationalStatusError
-	// +kubebuilder:validation:Enum=provisioned registration error;registration error;inspection error;preparation error;provisioning error;power management error
+	// +kubebuilder:validation:Enum=provisioned registration error;registration error;inspection error;preparation error;provisioning error;power management error;mac mismatch error;decommission error;retirement error;timeout error
 	ErrorType ErrorType `json:"errorType,omitempty"`
 
 	// LastUpdated identifies when this status was last observed.
@@ -641,6 +1429,28 @@ type BareMetalHostStatus struct {
 	// indicator for whether or not the host is powered on
 	PoweredOn bool `json:"poweredOn"`
 
+	// LastExternalPowerChange is when the host was last found in a
+	// power state the operator did not ask for. It is cleared once the
+	// power state matches the spec again.
+	// +optional
+	LastExternalPowerChange *metav1.Time `json:"lastExternalPowerChange,omitempty"`
+
+	// LastProvisionerTimeout is the last call to the provisioner that
+	// did not complete within the timeout of the operator.
+	// +optional
+	LastProvisionerTimeout *ProvisionerTimeout `json:"lastProvisionerTimeout,omitempty"`
+
+	// AvailableSince is when the host last became available to be
+	// provisioned.
+	// +optional
+	AvailableSince *metav1.Time `json:"availableSince,omitempty"`
+
+	// PowerChangeStarted is when the operator started changing the
+	// power state of the host. It is cleared once the power state
+	// matches the spec again.
+	// +optional
+	PowerChangeStarted *metav1.Time `json:"powerChangeStarted,omitempty"`
+
 	// OperationHistory holds information about operations performed
 	// on this host.
 	OperationHistory OperationHistory `json:"operationHistory,omitempty"`
@@ -648,6 +1458,496 @@ type BareMetalHostStatus struct {
 	// ErrorCount records how many times the host has encoutered an error since the last successful operation
 	// +kubebuilder:default:=0
 	ErrorCount int `json:"errorCount"`
+
+	// ObservedGeneration is the generation of the host spec that was
+	// reconciled when the status was last saved
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// AgentVersions records the versions of the deployment agent
+	// that last inspected and provisioned the host
+	// +optional
+	AgentVersions *AgentVersions `json:"agentVersions,omitempty"`
+
+	// Conditions summarize the provisioning status of the host. They
+	// are updated every time the status is saved.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// Maintenance reports the disruptive operations waiting for the
+	// maintenance window of the host
+	// +optional
+	Maintenance *MaintenanceStatus `json:"maintenance,omitempty"`
+
+	// OutOfBandInspection records the last time the hardware details
+	// were read from the BMC of the host
+	// +optional
+	OutOfBandInspection *OutOfBandInspectionStatus `json:"outOfBandInspection,omitempty"`
+
+	// SecureBootDatabases records the last update applied to each
+	// secure boot database of the host
+	// +optional
+	SecureBootDatabases []SecureBootDatabaseStatus `json:"secureBootDatabases,omitempty"`
+
+	// ServicingHistory lists the last servicing operations of the
+	// host, the oldest first
+	// +optional
+	ServicingHistory []ServicingRecord `json:"servicingHistory,omitempty"`
+
+	// AnnotationMigrations lists the legacy annotations of the host
+	// that were converted to their structured equivalents
+	// +optional
+	AnnotationMigrations []AnnotationMigration `json:"annotationMigrations,omitempty"`
+
+	// VirtualMedia records the virtual media features the BMC
+	// reported when the host was registered, for hosts booting from
+	// virtual media
+	// +optional
+	VirtualMedia *VirtualMediaSupport `json:"virtualMedia,omitempty"`
+
+	// BootCleanup records the check that the boot configuration of
+	// the host was cleaned up when it was last deprovisioned
+	// +optional
+	BootCleanup *BootCleanupStatus `json:"bootCleanup,omitempty"`
+
+	// BootProtection records the last check of the boot order of a
+	// provisioned host that protects its boot order
+	// +optional
+	BootProtection *BootProtectionStatus `json:"bootProtection,omitempty"`
+
+	// Tags are the tags of the spec last set on the node of the host
+	// in the provisioner
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// Refreshed records when the sections of the status were last
+	// confirmed by the provisioner or the BMC
+	// +optional
+	Refreshed *StatusRefreshTimes `json:"refreshed,omitempty"`
+
+	// ReinspectionPending is set while a periodic reinspection is
+	// waiting to be started
+	// +optional
+	ReinspectionPending bool `json:"reinspectionPending,omitempty"`
+
+	// Cleaning reports the progress of the last cleaning of the host
+	// +optional
+	Cleaning *CleaningStatus `json:"cleaning,omitempty"`
+
+	// Decommission reports the progress of decommissioning the host
+	// +optional
+	Decommission *DecommissionStatus `json:"decommission,omitempty"`
+
+	// Retirement reports the progress of retiring the host
+	// +optional
+	Retirement *RetirementStatus `json:"retirement,omitempty"`
+
+	// AcceptanceFailures lists the assertions of the acceptance tests
+	// that the hardware of the host fails. The host is not
+	// provisioned while it is set.
+	// +optional
+	AcceptanceFailures []string `json:"acceptanceFailures,omitempty"`
+
+	// FirmwareViolations lists the firmware of the host older than
+	// required by the firmware baselines of its namespace.
+	// +optional
+	FirmwareViolations []string `json:"firmwareViolations,omitempty"`
+}
+
+// RetirementStatus reports the progress of retiring a host.
+type RetirementStatus struct {
+	// EraseStarted is when the erasure of the disks started
+	// +optional
+	EraseStarted *metav1.Time `json:"eraseStarted,omitempty"`
+
+	// EraseFinished is when the erasure of the disks finished
+	// +optional
+	EraseFinished *metav1.Time `json:"eraseFinished,omitempty"`
+
+	// PoweredOff is when the host was powered off, before its BMC
+	// credentials were removed from the provisioner
+	// +optional
+	PoweredOff *metav1.Time `json:"poweredOff,omitempty"`
+}
+
+// DecommissionStatus reports the progress of decommissioning a host.
+type DecommissionStatus struct {
+	// EraseStarted is when the erasure of the disks started
+	// +optional
+	EraseStarted *metav1.Time `json:"eraseStarted,omitempty"`
+
+	// EraseFinished is when the erasure of the disks finished
+	// +optional
+	EraseFinished *metav1.Time `json:"eraseFinished,omitempty"`
+
+	// EraseSteps are the clean steps the provisioner ran to erase the
+	// disks
+	// +optional
+	EraseSteps []CompletedCleanStep `json:"eraseSteps,omitempty"`
+
+	// SecureEraseBypassed lists the serial numbers of the disks whose
+	// secure erase was skipped, and whose metadata is wiped instead
+	// +optional
+	SecureEraseBypassed []string `json:"secureEraseBypassed,omitempty"`
+
+	// MetadataWipeStarted is when the wipe of the metadata of the
+	// bypassed disks started
+	// +optional
+	MetadataWipeStarted *metav1.Time `json:"metadataWipeStarted,omitempty"`
+
+	// MetadataWipeFinished is when the wipe of the metadata of the
+	// bypassed disks finished
+	// +optional
+	MetadataWipeFinished *metav1.Time `json:"metadataWipeFinished,omitempty"`
+
+	// MetadataWipeSteps are the clean steps the provisioner ran to
+	// wipe the metadata of the bypassed disks
+	// +optional
+	MetadataWipeSteps []CompletedCleanStep `json:"metadataWipeSteps,omitempty"`
+
+	// Certificate is the name of the ConfigMap, in the namespace of
+	// the host, holding the certificate of erasure
+	// +optional
+	Certificate string `json:"certificate,omitempty"`
+}
+
+// CleaningStatus reports the progress of cleaning, which runs a
+// sequence of clean steps when a host is prepared, provisioned or
+// deprovisioned.
+type CleaningStatus struct {
+	// Step is the clean step running, as "<interface>.<step>", or
+	// empty once cleaning has finished
+	// +optional
+	Step string `json:"step,omitempty"`
+
+	// StepIndex is the position of the running step, starting at 1
+	// +optional
+	StepIndex int `json:"stepIndex,omitempty"`
+
+	// TotalSteps is the number of steps to run
+	// +optional
+	TotalSteps int `json:"totalSteps,omitempty"`
+
+	// StepStarted is when the running step was first seen
+	// +optional
+	StepStarted *metav1.Time `json:"stepStarted,omitempty"`
+
+	// CompletedSteps lists the steps that have finished, in order
+	// +optional
+	CompletedSteps []CompletedCleanStep `json:"completedSteps,omitempty"`
+
+	// EraseProgress is the estimated percentage of the disk erasure
+	// completed, while the deploy.erase_devices step runs
+	// +optional
+	EraseProgress int `json:"eraseProgress,omitempty"`
+
+	// EstimatedEraseCompletion is when the disk erasure is expected to
+	// finish
+	// +optional
+	EstimatedEraseCompletion *metav1.Time `json:"estimatedEraseCompletion,omitempty"`
+}
+
+// CompletedCleanStep records how long a clean step ran.
+type CompletedCleanStep struct {
+	// Step is the name of the step, as "<interface>.<step>"
+	Step string `json:"step"`
+
+	// Started is when the step was first seen
+	Started metav1.Time `json:"started"`
+
+	// Duration is how long the step ran, as observed by the operator
+	Duration metav1.Duration `json:"duration"`
+}
+
+// Condition types set on every host. Each one has a status of True or
+// False once the status of the host has been saved, so they can be
+// waited for with "kubectl wait --for=condition=<type>".
+const (
+	// ProvisionedCondition is True when an image has been written
+	// to the host, by the operator or by something else (reason
+	// ExternallyProvisioned). When False, the reason is the current
+	// provisioning state.
+	ProvisionedCondition = "Provisioned"
+
+	// AvailableCondition is True when the host is ready to be
+	// provisioned. When False, the reason is the current
+	// provisioning state, or Rejected.
+	AvailableCondition = "Available"
+
+	// RejectedCondition is True when the hardware of the host fails
+	// an acceptance test. The message lists the failed assertions.
+	RejectedCondition = "Rejected"
+
+	// FirmwareCompliantCondition is False when the host runs firmware
+	// older than required by a firmware baseline. The message lists
+	// the firmware that is too old.
+	FirmwareCompliantCondition = "FirmwareCompliant"
+
+	// FailedCondition is True when the last operation on the host
+	// failed. The reason is derived from the error type and the
+	// message is the error message.
+	FailedCondition = "Failed"
+
+	// ReadyCondition is True when the host has settled in the state
+	// it was asked for: it is ready, available or provisioned, with
+	// no error and in the requested power state. When False, the
+	// reason tells what the host is waiting for.
+	ReadyCondition = "Ready"
+
+	// PoweredOnCondition is True when the host was last seen powered
+	// on.
+	PoweredOnCondition = "PoweredOn"
+
+	// InspectedCondition is True once the hardware details of the
+	// host are known.
+	InspectedCondition = "Inspected"
+
+	// DegradedCondition is True when the host needs attention: it has
+	// an error, runs outdated firmware, fails an acceptance test or has
+	// a failing disk. The reason and message are those of the first
+	// problem found.
+	DegradedCondition = "Degraded"
+
+	// TimedOutCondition is True when the last error of the host is a
+	// timeout. The reason is the operation that timed out, such as
+	// InspectionTimeout.
+	TimedOutCondition = "TimedOut"
+
+	// DuplicateBMCCondition is True when other hosts use the same BMC
+	// address. The message names the other hosts. Only the host created
+	// first is registered until the conflict is resolved.
+	DuplicateBMCCondition = "DuplicateBMC"
+
+	// AnnotationsMigratedCondition is set by the annotation migration
+	// mode of the operator. It is True once the legacy annotations of
+	// the host have been converted to their structured equivalents.
+	// When False, the message lists the legacy annotations that have
+	// no structured equivalent and were left in place.
+	AnnotationsMigratedCondition = "AnnotationsMigrated"
+)
+
+// Disruptive operations that wait for the maintenance window of a
+// host.
+const (
+	// MaintenanceReinspection is a periodic reinspection.
+	MaintenanceReinspection = "Reinspection"
+
+	// MaintenanceReboot is a reboot requested by annotation.
+	MaintenanceReboot = "Reboot"
+
+	// MaintenanceSecureBootUpdate is an update of the secure boot
+	// databases of a provisioned host.
+	MaintenanceSecureBootUpdate = "SecureBootUpdate"
+)
+
+// MaintenanceStatus reports the disruptive operations waiting for the
+// maintenance window of a host.
+type MaintenanceStatus struct {
+	// Pending lists the operations waiting for the window.
+	// +optional
+	Pending []string `json:"pending,omitempty"`
+
+	// NextWindow is when the next window starts.
+	// +optional
+	NextWindow *metav1.Time `json:"nextWindow,omitempty"`
+}
+
+// OutOfBandInspectionStatus records the last inventory of a host read
+// from its BMC.
+type OutOfBandInspectionStatus struct {
+	// Request is the last outOfBandRequest of the spec handled.
+	Request string `json:"request"`
+
+	// Time is when the inventory was read.
+	// +optional
+	Time *metav1.Time `json:"time,omitempty"`
+
+	// ErrorMessage tells why the inventory could not be read. The
+	// request is not retried until it changes.
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// SecureBootDatabaseStatus records the last update applied to a
+// secure boot database of a host.
+type SecureBootDatabaseStatus struct {
+	// Database is the database updated.
+	Database string `json:"database"`
+
+	// Revision is the revision of the last update handled.
+	Revision string `json:"revision"`
+
+	// Time is when the update was applied.
+	// +optional
+	Time *metav1.Time `json:"time,omitempty"`
+
+	// ErrorMessage tells why the update could not be applied. It is
+	// not retried until its revision changes.
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// VirtualMediaSupport describes the virtual media features of the BMC
+// of a host.
+type VirtualMediaSupport struct {
+	// InsertEject is true when the BMC can insert and eject media.
+	InsertEject bool `json:"insertEject"`
+
+	// HTTPS tells whether the BMC can mount media served over HTTPS.
+	// It is not set when the BMC does not list the protocols it
+	// accepts.
+	// +optional
+	HTTPS *bool `json:"https,omitempty"`
+
+	// Slots is the number of virtual drives that can hold a CD or DVD
+	// image.
+	Slots int `json:"slots"`
+
+	// Detected is when the features were read from the BMC.
+	Detected metav1.Time `json:"detected"`
+
+	// ErrorMessage tells why the features could not be read. They are
+	// detected again when the host is registered with new
+	// credentials.
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// BootCleanupStatus records what deprovisioning left behind in the
+// boot configuration of a host.
+type BootCleanupStatus struct {
+	// Verified is when the boot configuration was checked.
+	Verified metav1.Time `json:"verified"`
+
+	// Residue lists the boot configuration found after
+	// deprovisioning, such as a virtual media image still inserted,
+	// which was removed.
+	// +optional
+	Residue []string `json:"residue,omitempty"`
+
+	// ErrorMessage tells why the boot configuration could not be
+	// checked or cleaned up.
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// AnnotationMigration records the conversion of a legacy annotation of
+// a host to its structured equivalent.
+type AnnotationMigration struct {
+	// Annotation is the name of the legacy annotation.
+	Annotation string `json:"annotation"`
+
+	// Replacement is the field that holds its value now, such as
+	// spec.inspection.disabled.
+	Replacement string `json:"replacement"`
+
+	// Time is when the annotation was converted.
+	Time metav1.Time `json:"time"`
+}
+
+// BootProtectionStatus records the last check that a provisioned host
+// boots from its disks.
+type BootProtectionStatus struct {
+	// Verified is when the boot configuration was checked.
+	Verified metav1.Time `json:"verified"`
+
+	// Corrected lists what was changed to keep the host booting from
+	// its disks, such as a boot override to PXE that was removed.
+	// +optional
+	Corrected []string `json:"corrected,omitempty"`
+
+	// ErrorMessage tells why the boot configuration could not be
+	// checked or corrected.
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// ServicingResult is the outcome of a servicing operation.
+type ServicingResult string
+
+const (
+	// ServicingSucceeded means the changes were applied.
+	ServicingSucceeded ServicingResult = "Succeeded"
+
+	// ServicingFailed means the changes could not be applied.
+	ServicingFailed ServicingResult = "Failed"
+)
+
+// ServicingRecord records an operation that changed a provisioned
+// host, such as a secure boot database update.
+type ServicingRecord struct {
+	// Operation is the kind of servicing, as one of the operations
+	// waiting for the maintenance window.
+	Operation string `json:"operation"`
+
+	// Changes describes what the operation changed.
+	// +optional
+	Changes []string `json:"changes,omitempty"`
+
+	// Started is when the operation started.
+	Started metav1.Time `json:"started"`
+
+	// Duration is how long the operation took.
+	Duration metav1.Duration `json:"duration"`
+
+	// Reboots is the number of times the operation rebooted the host.
+	// +optional
+	Reboots int `json:"reboots,omitempty"`
+
+	// Result is the outcome of the operation.
+	// +kubebuilder:validation:Enum=Succeeded;Failed
+	Result ServicingResult `json:"result"`
+
+	// ErrorMessage tells why the operation failed.
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// StatusRefreshTimes records when the sections of the status of a
+// host were last read from the provisioner or the BMC, to tell cached
+// data from current data.
+type StatusRefreshTimes struct {
+	// Power is when the power state was last read. It is saved at
+	// least every 10 minutes while the host is managed.
+	// +optional
+	Power *metav1.Time `json:"power,omitempty"`
+
+	// Provisioning is when the provisioner last confirmed the
+	// registration and the driver of the host.
+	// +optional
+	Provisioning *metav1.Time `json:"provisioning,omitempty"`
+
+	// Hardware is when the hardware details were last updated, by
+	// an inspection or from the spec.
+	// +optional
+	Hardware *metav1.Time `json:"hardware,omitempty"`
+}
+
+// AgentVersions holds the versions of the deployment agent (IPA)
+// used on a host, as named in the agent images configuration.
+type AgentVersions struct {
+	// The version that last inspected the host.
+	Inspection string `json:"inspection,omitempty"`
+
+	// The version that last provisioned the host.
+	Provisioning string `json:"provisioning,omitempty"`
+}
+
+// BootFallback lists the NICs to try, after BootMACAddress, when
+// provisioning the host fails.
+type BootFallback struct {
+	// MAC addresses of the NICs to try, in order.
+	// +optional
+	MACAddresses []string `json:"macAddresses,omitempty"`
+
+	// Also try any NIC that inspection found on this VLAN, after the
+	// MACAddresses.
+	// +optional
+	VLANID VLANID `json:"vlanId,omitempty"`
 }
 
 // ProvisionStatus holds the state information for a single target.
@@ -658,6 +1958,11 @@ type ProvisionStatus struct {
 	// The machine's UUID from the underlying provisioning tool
 	ID string `json:"ID"`
 
+	// Driver describes how the provisioning tool manages the host,
+	// refreshed when the host is registered
+	// +optional
+	Driver *DriverStatus `json:"driver,omitempty"`
+
 	// Image holds the details of the last image successfully
 	// provisioned to the host.
 	Image Image `json:"image,omitempty"`
@@ -668,8 +1973,48 @@ type ProvisionStatus struct {
 	// BootMode indicates the boot mode used to provision the node
 	BootMode BootMode `json:"bootMode,omitempty"`
 
+	// BootCertificate is the SHA-256 fingerprint of the CA certificate
+	// of the HTTPS boot server installed in the firmware of the host
+	// +optional
+	BootCertificate string `json:"bootCertificate,omitempty"`
+
 	// The Raid set by the user
 	RAID *RAIDConfig `json:"raid,omitempty"`
+
+	// BootMACAddress is the MAC address of the NIC the host is being
+	// (or was last successfully) provisioned through, when it
+	// differs from the one in the spec or a fallback was configured.
+	BootMACAddress string `json:"bootMACAddress,omitempty"`
+}
+
+// DriverStatus describes the driver the provisioning tool uses to
+// manage a host.
+type DriverStatus struct {
+	// Name is the hardware type of the driver, such as ipmi or
+	// redfish
+	Name string `json:"name"`
+
+	// Interfaces are the implementations of each interface of the
+	// driver selected for the host
+	// +optional
+	Interfaces DriverInterfaces `json:"interfaces,omitempty"`
+}
+
+// DriverInterfaces names the implementation of each interface of a
+// driver, such as pxe or redfish-virtual-media for the boot
+// interface. Interfaces that are not reported are left empty.
+type DriverInterfaces struct {
+	Boot       string `json:"boot,omitempty"`
+	Console    string `json:"console,omitempty"`
+	Deploy     string `json:"deploy,omitempty"`
+	Inspect    string `json:"inspect,omitempty"`
+	Management string `json:"management,omitempty"`
+	Network    string `json:"network,omitempty"`
+	Power      string `json:"power,omitempty"`
+	RAID       string `json:"raid,omitempty"`
+	Rescue     string `json:"rescue,omitempty"`
+	Storage    string `json:"storage,omitempty"`
+	Vendor     string `json:"vendor,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -682,9 +2027,14 @@ type ProvisionStatus struct {
 // +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.provisioning.state",description="Provisioning status"
 // +kubebuilder:printcolumn:name="Consumer",type="string",JSONPath=".spec.consumerRef.name",description="Consumer using this host"
 // +kubebuilder:printcolumn:name="BMC",type="string",JSONPath=".spec.bmc.address",description="Address of management controller",priority=1
+// +kubebuilder:printcolumn:name="Driver",type="string",JSONPath=".status.provisioning.driver.name",description="Hardware type of the provisioning driver",priority=1
 // +kubebuilder:printcolumn:name="Hardware_Profile",type="string",JSONPath=".status.hardwareProfile",description="The type of hardware detected",priority=1
 // +kubebuilder:printcolumn:name="Online",type="string",JSONPath=".spec.online",description="Whether the host is online or not"
 // +kubebuilder:printcolumn:name="Error",type="string",JSONPath=".status.errorType",description="Type of the most recent error"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Whether the host has settled in the state it was asked for",priority=1
+// +kubebuilder:printcolumn:name="Owner",type="string",JSONPath=".spec.operational.ownerTeam",description="Team responsible for the host",priority=1
+// +kubebuilder:printcolumn:name="Asset_Tag",type="string",JSONPath=".spec.operational.assetTag",description="Purchase or asset tag of the host",priority=1
+// +kubebuilder:printcolumn:name="Warranty",type="string",JSONPath=".spec.operational.warrantyExpiry",description="Time the hardware warranty ends",priority=1
 // +kubebuilder:object:root=true
 type BareMetalHost struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -730,6 +2080,12 @@ func (host *BareMetalHost) HasBMCDetails() bool {
 	return host.Spec.BMC.Address != "" || host.Spec.BMC.CredentialsName != ""
 }
 
+// HasDryRunAnnotation returns true if the host is in dry-run mode
+func (host *BareMetalHost) HasDryRunAnnotation() bool {
+	_, present := host.Annotations[DryRunAnnotation]
+	return present
+}
+
 // NeedsHardwareProfile returns true if the profile is not set
 func (host *BareMetalHost) NeedsHardwareProfile() bool {
 	return host.Status.HardwareProfile == ""
@@ -775,6 +2131,41 @@ func (host *BareMetalHost) CredentialsKey() types.NamespacedName {
 	}
 }
 
+// HasNICWithMAC reports whether one of the NICs in the hardware
+// details has the given MAC address. The comparison ignores case and
+// separator differences.
+func (details *HardwareDetails) HasNICWithMAC(mac string) bool {
+	for _, nic := range details.NIC {
+		if sameMACAddress(nic.MAC, mac) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeMACAddress returns the canonical form of a MAC address,
+// falling back to lower-casing it when it cannot be parsed.
+func normalizeMACAddress(mac string) string {
+	if hw, err := net.ParseMAC(mac); err == nil {
+		return hw.String()
+	}
+	return strings.ToLower(mac)
+}
+
+// sameMACAddress compares two MAC addresses, falling back to a case
+// insensitive comparison if either one cannot be parsed.
+func sameMACAddress(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	hwA, errA := net.ParseMAC(a)
+	hwB, errB := net.ParseMAC(b)
+	if errA != nil || errB != nil {
+		return strings.EqualFold(a, b)
+	}
+	return hwA.String() == hwB.String()
+}
+
 // NeedsHardwareInspection looks at the state of the host to determine
 // if hardware inspection should be run.
 func (host *BareMetalHost) NeedsHardwareInspection() bool {
@@ -888,6 +2279,8 @@ func (host *BareMetalHost) OperationMetricForState(operation ProvisioningState)
 		metric = &history.Register
 	case StateInspecting:
 		metric = &history.Inspect
+	case StatePreparing:
+		metric = &history.Prepare
 	case StateProvisioning:
 		metric = &history.Provision
 	case StateDeprovisioning:
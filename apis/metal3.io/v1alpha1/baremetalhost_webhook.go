@@ -0,0 +1,835 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/schedule"
+)
+
+// log is for logging in this package.
+var baremetalhostlog = logf.Log.WithName("baremetalhost-resource")
+
+var macAddressRegexp = regexp.MustCompile(`^[0-9a-fA-F]{2}(:[0-9a-fA-F]{2}){5}$`)
+
+// webhookClient is used by the validating webhook to look up the
+// other hosts in the cluster.
+var webhookClient client.Reader
+
+// SetupWebhookWithManager registers the BareMetalHost admission
+// webhooks with the manager.
+func (host *BareMetalHost) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	webhookClient = mgr.GetClient()
+	decoder, err := admission.NewDecoder(mgr.GetScheme())
+	if err != nil {
+		return err
+	}
+	mgr.GetWebhookServer().Register("/validate-metal3-io-v1alpha1-baremetalhost-actions",
+		&webhook.Admission{Handler: &actionValidator{client: mgr.GetClient(), decoder: decoder}})
+	mgr.GetWebhookServer().Register("/validate-metal3-io-v1alpha1-hostaction",
+		&webhook.Admission{Handler: &hostActionValidator{client: mgr.GetClient(), decoder: decoder}})
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(host).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-metal3-io-v1alpha1-baremetalhost,mutating=true,failurePolicy=fail,sideEffects=None,admissionReviewVersions=v1;v1beta1,groups=metal3.io,resources=baremetalhosts,verbs=create;update,versions=v1alpha1,name=mbaremetalhost.metal3.io
+
+var _ webhook.Defaulter = &BareMetalHost{}
+
+// Default implements webhook.Defaulter so a webhook will be registered
+// for the type. It stores the BMC address in its canonical form, so
+// that equivalent addresses are not treated as different hosts.
+func (host *BareMetalHost) Default() {
+	if host.Spec.BMC.Address == "" {
+		return
+	}
+	address, err := bmc.NormalizeAddress(host.Spec.BMC.Address)
+	if err != nil {
+		// Leave the address alone, the controller reports it as
+		// a registration error.
+		baremetalhostlog.Info("could not normalize BMC address",
+			"host", host.Name, "address", host.Spec.BMC.Address, "error", err.Error())
+		return
+	}
+	if address != host.Spec.BMC.Address {
+		baremetalhostlog.Info("normalized BMC address",
+			"host", host.Name, "from", host.Spec.BMC.Address, "to", address)
+		host.Spec.BMC.Address = address
+	}
+}
+
+// +kubebuilder:webhook:path=/validate-metal3-io-v1alpha1-baremetalhost,mutating=false,failurePolicy=fail,sideEffects=None,admissionReviewVersions=v1;v1beta1,groups=metal3.io,resources=baremetalhosts,verbs=create;update,versions=v1alpha1,name=vbaremetalhost.metal3.io
+
+var _ webhook.Validator = &BareMetalHost{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be
+// registered for the type.
+func (host *BareMetalHost) ValidateCreate() error {
+	if err := host.validateBootMACAddress(); err != nil {
+		return err
+	}
+	if err := host.validateBootFallback(); err != nil {
+		return err
+	}
+	if host.Spec.Retire && host.Spec.Decommission {
+		return errors.New("a host cannot be both retired and decommissioned")
+	}
+	if err := host.validateInspection(); err != nil {
+		return err
+	}
+	if err := host.validateNodeInterfaces(); err != nil {
+		return err
+	}
+	if err := host.validateOperational(); err != nil {
+		return err
+	}
+	if err := host.validateMetaDataTemplate(); err != nil {
+		return err
+	}
+	if err := host.validateSSHAuthorizedKeys(); err != nil {
+		return err
+	}
+	if err := host.validateCustomDeploy(); err != nil {
+		return err
+	}
+	if err := host.validateMaintenanceWindow(); err != nil {
+		return err
+	}
+	if err := host.validateSecureBootDatabases(); err != nil {
+		return err
+	}
+	if err := host.validateRootDeviceHints(); err != nil {
+		return err
+	}
+	if err := host.validateAgentKernelArgs(); err != nil {
+		return err
+	}
+	if err := host.validateTags(); err != nil {
+		return err
+	}
+	if err := host.validateUserDataSecrets(); err != nil {
+		return err
+	}
+	if err := host.validateMove(); err != nil {
+		return err
+	}
+	if err := host.validateQuota(nil); err != nil {
+		return err
+	}
+	return host.validateBMCAddressUnique()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be
+// registered for the type. Only changes to the BMC and boot MAC
+// addresses, to the provided hardware details, to the node interfaces,
+// to the operational metadata, to the metadata template, to the SSH
+// keys, to the custom deploy steps, to the maintenance window, to the
+// secure boot database updates, to the root device hints, to the agent
+// kernel arguments, to the tags, to the user data secrets, to the
+// target namespace of a move and to the use of host quotas are
+// checked, so that hosts that already conflict can still be updated
+// (for example to fix the address or remove a finalizer).
+func (host *BareMetalHost) ValidateUpdate(old runtime.Object) error {
+	oldHost, ok := old.(*BareMetalHost)
+	if !ok || oldHost.Spec.BootMACAddress != host.Spec.BootMACAddress {
+		if err := host.validateBootMACAddress(); err != nil {
+			return err
+		}
+	}
+	if err := host.validateBootFallback(); err != nil {
+		return err
+	}
+	if ok && oldHost.Spec.Decommission && !host.Spec.Decommission && oldHost.Status.Decommission != nil {
+		// The disks may already have been erased
+		return errors.New("decommissioning of the host has started and cannot be cancelled")
+	}
+	if host.Spec.Retire && host.Spec.Decommission {
+		return errors.New("a host cannot be both retired and decommissioned")
+	}
+	if ok && oldHost.Spec.Retire && !host.Spec.Retire && oldHost.Status.Provisioning.State == StateRetiring {
+		// The disks may be being erased
+		return errors.New("the host is being retired and cannot be brought back until it is retired")
+	}
+	if !ok || !reflect.DeepEqual(oldHost.Spec.Inspection, host.Spec.Inspection) ||
+		oldHost.Annotations[HardwareDetailsAnnotation] != host.Annotations[HardwareDetailsAnnotation] {
+		if err := host.validateInspection(); err != nil {
+			return err
+		}
+	}
+	if !ok || !reflect.DeepEqual(oldHost.Spec.NodeInterfaces, host.Spec.NodeInterfaces) ||
+		oldHost.Spec.BMC.Address != host.Spec.BMC.Address || oldHost.Spec.BootMode != host.Spec.BootMode {
+		if err := host.validateNodeInterfaces(); err != nil {
+			return err
+		}
+	}
+	if !ok || !reflect.DeepEqual(oldHost.Spec.Operational, host.Spec.Operational) {
+		if err := host.validateOperational(); err != nil {
+			return err
+		}
+	}
+	if !ok || !reflect.DeepEqual(oldHost.Spec.MetaDataTemplate, host.Spec.MetaDataTemplate) {
+		if err := host.validateMetaDataTemplate(); err != nil {
+			return err
+		}
+	}
+	if !ok || !reflect.DeepEqual(oldHost.Spec.SSHAuthorizedKeys, host.Spec.SSHAuthorizedKeys) {
+		if err := host.validateSSHAuthorizedKeys(); err != nil {
+			return err
+		}
+	}
+	if !ok || !reflect.DeepEqual(oldHost.Spec.CustomDeploy, host.Spec.CustomDeploy) {
+		if err := host.validateCustomDeploy(); err != nil {
+			return err
+		}
+	}
+	if !ok || !reflect.DeepEqual(oldHost.Spec.MaintenanceWindow, host.Spec.MaintenanceWindow) {
+		if err := host.validateMaintenanceWindow(); err != nil {
+			return err
+		}
+	}
+	if !ok || !reflect.DeepEqual(oldHost.Spec.SecureBootDatabases, host.Spec.SecureBootDatabases) {
+		if err := host.validateSecureBootDatabases(); err != nil {
+			return err
+		}
+	}
+	if !ok || !reflect.DeepEqual(oldHost.Spec.RootDeviceHints, host.Spec.RootDeviceHints) {
+		if err := host.validateRootDeviceHints(); err != nil {
+			return err
+		}
+	}
+	if !ok || !reflect.DeepEqual(oldHost.Spec.AgentKernelArgs, host.Spec.AgentKernelArgs) {
+		if err := host.validateAgentKernelArgs(); err != nil {
+			return err
+		}
+	}
+	if !ok || !reflect.DeepEqual(oldHost.Spec.Tags, host.Spec.Tags) {
+		if err := host.validateTags(); err != nil {
+			return err
+		}
+	}
+	if !ok || !reflect.DeepEqual(oldHost.Spec.UserData, host.Spec.UserData) ||
+		!reflect.DeepEqual(oldHost.Spec.UserDataSecrets, host.Spec.UserDataSecrets) {
+		if err := host.validateUserDataSecrets(); err != nil {
+			return err
+		}
+	}
+	if !ok || oldHost.Annotations[MoveToAnnotation] != host.Annotations[MoveToAnnotation] {
+		if err := host.validateMove(); err != nil {
+			return err
+		}
+	}
+	if !ok {
+		oldHost = nil
+	}
+	if err := host.validateQuota(oldHost); err != nil {
+		return err
+	}
+	if oldHost == nil || oldHost.Spec.BMC.Address != host.Spec.BMC.Address {
+		return host.validateBMCAddressUnique()
+	}
+	return nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be
+// registered for the type.
+func (host *BareMetalHost) ValidateDelete() error {
+	return nil
+}
+
+// validateMove checks the target namespace of the MoveToAnnotation.
+func (host *BareMetalHost) validateMove() error {
+	target, requested := host.Annotations[MoveToAnnotation]
+	if !requested {
+		return nil
+	}
+	if errs := validation.IsDNS1123Label(target); len(errs) != 0 {
+		return errors.Errorf("invalid namespace %q in %s: %s", target, MoveToAnnotation, strings.Join(errs, ", "))
+	}
+	if target == host.Namespace {
+		return errors.Errorf("host is already in namespace %s", target)
+	}
+	return nil
+}
+
+func (host *BareMetalHost) validateBMCAddressUnique() error {
+	if webhookClient == nil || host.Spec.BMC.Address == "" {
+		return nil
+	}
+	others, err := FindHostsWithSameBMC(context.TODO(), webhookClient, host)
+	if err != nil {
+		return err
+	}
+	if len(others) > 0 {
+		return errors.Errorf("BMC address %s is already used by host %s/%s",
+			host.Spec.BMC.Address, others[0].Namespace, others[0].Name)
+	}
+	return nil
+}
+
+// validateQuota rejects a host that starts using a quota of a namespace
+// that is already exhausted. Hosts that already use the quota are never
+// rejected, even when it has been lowered. The hosts are read from the
+// cache of the manager, so hosts admitted at the same time may not see
+// each other: the check is best-effort, and the controller enforces
+// maxProvisioned again before provisioning.
+func (host *BareMetalHost) validateQuota(old *BareMetalHost) error {
+	if webhookClient == nil {
+		return nil
+	}
+	provisioning := host.CountsAsProvisioned() && (old == nil || !old.CountsAsProvisioned())
+	consumerNamespace := host.ConsumerNamespace()
+	claiming := consumerNamespace != "" && (old == nil || old.ConsumerNamespace() != consumerNamespace)
+
+	if provisioning {
+		if err := checkQuota(host, host.Namespace, client.InNamespace(host.Namespace), func(quota *HostQuota, provisioned, _ int) error {
+			if quota.Spec.MaxProvisioned != nil && provisioned >= *quota.Spec.MaxProvisioned {
+				return errors.Errorf("host quota %s/%s allows %d provisioned hosts",
+					quota.Namespace, quota.Name, *quota.Spec.MaxProvisioned)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	if claiming {
+		return checkQuota(host, consumerNamespace, client.MatchingFields{ConsumerNamespaceField: consumerNamespace}, func(quota *HostQuota, _, claimed int) error {
+			if quota.Spec.MaxClaimed != nil && claimed >= *quota.Spec.MaxClaimed {
+				return errors.Errorf("host quota %s/%s allows %d claimed hosts",
+					quota.Namespace, quota.Name, *quota.Spec.MaxClaimed)
+			}
+			return nil
+		})
+	}
+	return nil
+}
+
+// checkQuota applies check to every quota of the namespace with the
+// usage of the hosts other than host. The hosts are only listed, with
+// the given option selecting those that may use the quota, when the
+// namespace has a quota.
+func checkQuota(host *BareMetalHost, namespace string, selectHosts client.ListOption, check func(quota *HostQuota, provisioned, claimed int) error) error {
+	quotas := &HostQuotaList{}
+	if err := webhookClient.List(context.TODO(), quotas, client.InNamespace(namespace)); err != nil {
+		return errors.Wrap(err, "failed to list host quotas")
+	}
+	if len(quotas.Items) == 0 {
+		return nil
+	}
+	hosts := &BareMetalHostList{}
+	if err := webhookClient.List(context.TODO(), hosts, selectHosts); err != nil {
+		return errors.Wrap(err, "failed to list hosts")
+	}
+	var others []BareMetalHost
+	for _, other := range hosts.Items {
+		if other.Namespace != host.Namespace || other.Name != host.Name {
+			others = append(others, other)
+		}
+	}
+	provisioned, claimed := HostQuotaUsage(namespace, others)
+	for i := range quotas.Items {
+		if err := check(&quotas.Items[i], provisioned, claimed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validMACAddress(mac string) bool {
+	hw, err := net.ParseMAC(mac)
+	return err == nil && len(hw) == 6 && macAddressRegexp.MatchString(mac)
+}
+
+func (host *BareMetalHost) validateBootFallback() error {
+	if host.Spec.BootFallback == nil {
+		return nil
+	}
+	for _, mac := range host.Spec.BootFallback.MACAddresses {
+		if !validMACAddress(mac) {
+			return errors.Errorf("bootFallback MAC address %q is not a valid MAC address, expected the form 00:11:22:33:44:55", mac)
+		}
+	}
+	return nil
+}
+
+func (host *BareMetalHost) validateOperational() error {
+	op := host.Spec.Operational
+	if op == nil {
+		return nil
+	}
+	if errs := validation.IsValidLabelValue(op.OwnerTeam); len(errs) != 0 {
+		return errors.Errorf("operational.ownerTeam %q is not a valid label value: %s", op.OwnerTeam, strings.Join(errs, ", "))
+	}
+	if errs := validation.IsValidLabelValue(op.AssetTag); len(errs) != 0 {
+		return errors.Errorf("operational.assetTag %q is not a valid label value: %s", op.AssetTag, strings.Join(errs, ", "))
+	}
+	if op.TicketURL != "" {
+		ticket, err := url.Parse(op.TicketURL)
+		if err != nil || (ticket.Scheme != "http" && ticket.Scheme != "https") || ticket.Host == "" {
+			return errors.Errorf("operational.ticketURL %q is not an http or https URL", op.TicketURL)
+		}
+	}
+	return nil
+}
+
+// validateMetaDataTemplate checks that the metadata templates parse,
+// so that mistakes are reported before the host is provisioned.
+func (host *BareMetalHost) validateMetaDataTemplate() error {
+	tmpl := host.Spec.MetaDataTemplate
+	if tmpl == nil {
+		return nil
+	}
+	if _, err := template.New("hostname").Parse(tmpl.Hostname); err != nil {
+		return errors.Wrap(err, "invalid metaDataTemplate.hostname")
+	}
+	for key, value := range tmpl.Values {
+		if _, err := template.New(key).Parse(value); err != nil {
+			return errors.Wrapf(err, "invalid metaDataTemplate.values[%s]", key)
+		}
+	}
+	return nil
+}
+
+// validSSHPublicKey checks that a key has the "type base64 [comment]"
+// form of authorized_keys, with the same type inside the encoded key.
+func validSSHPublicKey(key string) bool {
+	fields := strings.Fields(key)
+	if len(fields) < 2 || strings.ContainsAny(key, "\r\n") {
+		return false
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil || len(blob) < 4 {
+		return false
+	}
+	length := binary.BigEndian.Uint32(blob)
+	return uint64(len(blob)) >= 4+uint64(length) && string(blob[4:4+length]) == fields[0]
+}
+
+func (host *BareMetalHost) validateSSHAuthorizedKeys() error {
+	for i, key := range host.Spec.SSHAuthorizedKeys {
+		if !validSSHPublicKey(key) {
+			return errors.Errorf("sshAuthorizedKeys[%d] is not a valid SSH public key, expected the form \"ssh-ed25519 AAAA... comment\"", i)
+		}
+	}
+	return nil
+}
+
+// validateCustomDeploy checks that each deploy step is listed once,
+// since Ironic only runs one of them.
+func (host *BareMetalHost) validateCustomDeploy() error {
+	if host.Spec.CustomDeploy == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for i, step := range host.Spec.CustomDeploy.Steps {
+		name := step.Interface + "." + step.Step
+		if seen[name] {
+			return errors.Errorf("customDeploy.steps[%d]: deploy step %s is listed more than once", i, name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+func (host *BareMetalHost) validateMaintenanceWindow() error {
+	window := host.Spec.MaintenanceWindow
+	if window == nil {
+		return nil
+	}
+	if _, err := schedule.Parse(window.Schedule); err != nil {
+		return errors.Wrap(err, "invalid maintenanceWindow.schedule")
+	}
+	if window.Duration.Duration <= 0 {
+		return errors.New("maintenanceWindow.duration must be positive")
+	}
+	if window.TimeZone != "" {
+		if _, err := time.LoadLocation(window.TimeZone); err != nil {
+			return errors.Wrap(err, "invalid maintenanceWindow.timeZone")
+		}
+	}
+	return nil
+}
+
+// validateRootDeviceHints rejects the multipath hints that would be
+// sent to the provisioner as the same hint as another one.
+func (host *BareMetalHost) validateRootDeviceHints() error {
+	hints := host.Spec.RootDeviceHints
+	if hints == nil {
+		return nil
+	}
+	if hints.WWID != "" && hints.SerialNumber != "" {
+		return errors.New("rootDeviceHints.wwid and rootDeviceHints.serialNumber cannot both be set")
+	}
+	if hints.ByPath != "" && hints.DeviceName != "" {
+		return errors.New("rootDeviceHints.byPath and rootDeviceHints.deviceName cannot both be set")
+	}
+	return nil
+}
+
+// operatorKernelArgs are the agent kernel arguments the operator sets
+// from other settings of the host.
+var operatorKernelArgs = []string{
+	"ipa-api-url=",
+	"ipa-inspection-callback-url=",
+	"ipa-inspection-collectors=",
+	"ipa-inspection-benchmarks=",
+	"ipa-image-download-limit-mbps=",
+}
+
+// validateAgentKernelArgs checks that each agent kernel argument is a
+// single argument, and does not replace one the operator sets.
+func (host *BareMetalHost) validateAgentKernelArgs() error {
+	for i, arg := range host.Spec.AgentKernelArgs {
+		if arg == "" || strings.ContainsAny(arg, " \t\r\n") {
+			return errors.Errorf("agentKernelArgs[%d] must be a single argument without spaces", i)
+		}
+		if strings.Contains(arg, "%") {
+			return errors.Errorf("agentKernelArgs[%d] cannot contain %%, which Ironic expands", i)
+		}
+		for _, reserved := range operatorKernelArgs {
+			if strings.HasPrefix(arg, reserved) {
+				return errors.Errorf("agentKernelArgs[%d]: %s is set by the operator", i, strings.TrimSuffix(reserved, "="))
+			}
+		}
+	}
+	return nil
+}
+
+// maxTagLength is the longest tag Ironic accepts on a node.
+const maxTagLength = 255
+
+// validateTags checks that the tags can be set on an Ironic node.
+func (host *BareMetalHost) validateTags() error {
+	seen := make(map[string]bool, len(host.Spec.Tags))
+	for i, tag := range host.Spec.Tags {
+		if tag == "" || len(tag) > maxTagLength {
+			return errors.Errorf("tags[%d] must be between 1 and %d characters long", i, maxTagLength)
+		}
+		if strings.ContainsAny(tag, "\r\n") {
+			return errors.Errorf("tags[%d] cannot contain line breaks", i)
+		}
+		if seen[tag] {
+			return errors.Errorf("tags[%d]: %s is repeated", i, tag)
+		}
+		seen[tag] = true
+	}
+	return nil
+}
+
+// validateUserDataSecrets checks that the user data secrets name
+// their secrets, and are not mixed with a single user data secret.
+func (host *BareMetalHost) validateUserDataSecrets() error {
+	if len(host.Spec.UserDataSecrets) == 0 {
+		return nil
+	}
+	if host.Spec.UserData != nil {
+		return errors.New("userData and userDataSecrets cannot both be set")
+	}
+	seen := make(map[string]bool, len(host.Spec.UserDataSecrets))
+	for i, ref := range host.Spec.UserDataSecrets {
+		if ref.Name == "" {
+			return errors.Errorf("userDataSecrets[%d]: name is required", i)
+		}
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = host.Namespace
+		}
+		key := namespace + "/" + ref.Name
+		if seen[key] {
+			return errors.Errorf("userDataSecrets[%d]: secret %s is listed more than once", i, ref.Name)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+var sha256Signature = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+func (host *BareMetalHost) validateSecureBootDatabases() error {
+	seen := map[string]bool{}
+	for i, update := range host.Spec.SecureBootDatabases {
+		if seen[update.Database] {
+			return errors.Errorf("secureBootDatabases[%d]: database %s is listed more than once", i, update.Database)
+		}
+		seen[update.Database] = true
+		if update.Revision == "" {
+			return errors.Errorf("secureBootDatabases[%d]: revision is required", i)
+		}
+		if len(update.Certificates) == 0 && len(update.Signatures) == 0 {
+			return errors.Errorf("secureBootDatabases[%d]: no certificates or signatures to add", i)
+		}
+		for j, certificate := range update.Certificates {
+			block, _ := pem.Decode([]byte(certificate))
+			if block == nil || block.Type != "CERTIFICATE" {
+				return errors.Errorf("secureBootDatabases[%d].certificates[%d]: not a PEM encoded certificate", i, j)
+			}
+			if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+				return errors.Wrapf(err, "secureBootDatabases[%d].certificates[%d]", i, j)
+			}
+		}
+		for j, signature := range update.Signatures {
+			if !sha256Signature.MatchString(signature) {
+				return errors.Errorf("secureBootDatabases[%d].signatures[%d]: not a hex encoded SHA-256 hash", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+func (host *BareMetalHost) validateInspection() error {
+	if host.Spec.Inspection != nil && host.Spec.Inspection.HardwareDetails != nil {
+		if !host.Spec.Inspection.Disabled && host.Annotations[InspectAnnotationPrefix] != "disabled" {
+			return errors.New("inspection.hardwareDetails can only be set when inspection.disabled is true")
+		}
+		if err := validateHardwareDetails(host.Spec.Inspection.HardwareDetails); err != nil {
+			return errors.Wrap(err, "invalid inspection.hardwareDetails")
+		}
+	}
+
+	content, present := host.Annotations[HardwareDetailsAnnotation]
+	if !present || content == "" {
+		return nil
+	}
+	// Reject unknown fields, which are otherwise silently dropped
+	decoder := json.NewDecoder(bytes.NewReader([]byte(content)))
+	decoder.DisallowUnknownFields()
+	details := &HardwareDetails{}
+	if err := decoder.Decode(details); err != nil {
+		return errors.Wrapf(err, "invalid %s annotation", HardwareDetailsAnnotation)
+	}
+	if err := validateHardwareDetails(details); err != nil {
+		return errors.Wrapf(err, "invalid %s annotation", HardwareDetailsAnnotation)
+	}
+	return nil
+}
+
+// validateHardwareDetails checks the hardware details provided by a
+// user, which the controller copies to the status without further
+// checks.
+func validateHardwareDetails(details *HardwareDetails) error {
+	if details.RAMMebibytes < 0 {
+		return errors.Errorf("ramMebibytes %d must not be negative", details.RAMMebibytes)
+	}
+	if details.CPU.Count < 0 {
+		return errors.Errorf("cpu.count %d must not be negative", details.CPU.Count)
+	}
+	if details.CPU.ClockMegahertz < 0 {
+		return errors.Errorf("cpu.clockMegahertz %v must not be negative", details.CPU.ClockMegahertz)
+	}
+
+	validVLAN := func(id VLANID) bool {
+		return id >= 0 && id <= 4094
+	}
+	for i, nic := range details.NIC {
+		field := fmt.Sprintf("nics[%d]", i)
+		if nic.MAC != "" && !validMACAddress(nic.MAC) {
+			return errors.Errorf("%s.mac %q is not a valid MAC address, expected the form 00:11:22:33:44:55", field, nic.MAC)
+		}
+		if nic.IP != "" && net.ParseIP(nic.IP) == nil {
+			return errors.Errorf("%s.ip %q is not a valid IP address", field, nic.IP)
+		}
+		if nic.SpeedGbps < 0 {
+			return errors.Errorf("%s.speedGbps %d must not be negative", field, nic.SpeedGbps)
+		}
+		if !validVLAN(nic.VLANID) {
+			return errors.Errorf("%s.vlanId %d must be between 0 and 4094", field, nic.VLANID)
+		}
+		for _, vlan := range nic.VLANs {
+			if !validVLAN(vlan.ID) {
+				return errors.Errorf("%s.vlans id %d must be between 0 and 4094", field, vlan.ID)
+			}
+		}
+	}
+
+	names := map[string]bool{}
+	for i, disk := range details.Storage {
+		field := fmt.Sprintf("storage[%d]", i)
+		if disk.Name == "" {
+			return errors.Errorf("%s.name must be set", field)
+		}
+		if names[disk.Name] {
+			return errors.Errorf("%s.name %q is used by another device", field, disk.Name)
+		}
+		names[disk.Name] = true
+		if disk.SizeBytes < 0 {
+			return errors.Errorf("%s.sizeBytes %d must not be negative", field, disk.SizeBytes)
+		}
+		if health := disk.Health; health != nil {
+			if health.Status != DiskHealthOK && health.Status != DiskHealthFailing {
+				return errors.Errorf("%s.health.status %q must be %s or %s", field, health.Status, DiskHealthOK, DiskHealthFailing)
+			}
+			if health.WearPercent < 0 || health.MediaErrors < 0 {
+				return errors.Errorf("%s.health counters must not be negative", field)
+			}
+		}
+	}
+
+	if details.Benchmarks != nil {
+		if details.Benchmarks.MemoryBandwidthMBps < 0 {
+			return errors.Errorf("benchmarks.memoryBandwidthMBps %d must not be negative",
+				details.Benchmarks.MemoryBandwidthMBps)
+		}
+		for i, disk := range details.Benchmarks.Disks {
+			if disk.SequentialReadKBps < 0 {
+				return errors.Errorf("benchmarks.disks[%d].sequentialReadKBps %d must not be negative",
+					i, disk.SequentialReadKBps)
+			}
+		}
+	}
+
+	for i, accelerator := range details.Accelerators {
+		if accelerator.VRAMMebibytes < 0 {
+			return errors.Errorf("accelerators[%d].vramMebibytes %d must not be negative",
+				i, accelerator.VRAMMebibytes)
+		}
+	}
+	return nil
+}
+
+// validateNodeInterfaces checks the node interfaces against those
+// supported by the BMC type.
+func (host *BareMetalHost) validateNodeInterfaces() error {
+	if host.Spec.NodeInterfaces == nil || host.Spec.BMC.Address == "" {
+		return nil
+	}
+	accessDetails, err := bmc.NewAccessDetails(host.Spec.BMC.Address, host.Spec.BMC.DisableCertificateVerification)
+	if err != nil {
+		// The controller reports invalid addresses as registration
+		// errors
+		return nil
+	}
+	interfaces := host.Spec.NodeInterfaces
+	if err := bmc.ValidateInterfaces(accessDetails, interfaces.Boot, interfaces.BIOS, interfaces.Deploy); err != nil {
+		return errors.Wrap(err, "invalid nodeInterfaces")
+	}
+	if bmc.IsHTTPBootInterface(interfaces.Boot) && host.Spec.BootMode == Legacy {
+		return errors.Errorf("invalid nodeInterfaces: boot interface %q requires UEFI boot mode", interfaces.Boot)
+	}
+	return nil
+}
+
+func (host *BareMetalHost) validateBootMACAddress() error {
+	mac := host.Spec.BootMACAddress
+	if mac == "" {
+		return nil
+	}
+	if !validMACAddress(mac) {
+		return errors.Errorf("bootMACAddress %q is not a valid MAC address, expected the form 00:11:22:33:44:55", mac)
+	}
+	if webhookClient == nil {
+		return nil
+	}
+	hosts := &BareMetalHostList{}
+	if err := webhookClient.List(context.TODO(), hosts, client.MatchingFields{BootMACAddressField: normalizeMACAddress(mac)}); err != nil {
+		return errors.Wrap(err, "failed to list hosts")
+	}
+	for _, other := range hosts.Items {
+		if other.Namespace == host.Namespace && other.Name == host.Name {
+			continue
+		}
+		if other.Name == host.Name && other.Annotations[MoveToAnnotation] == host.Namespace {
+			// The host is being moved to this namespace
+			continue
+		}
+		if sameMACAddress(mac, other.Spec.BootMACAddress) {
+			return errors.Errorf("bootMACAddress %s is already used by host %s/%s",
+				mac, other.Namespace, other.Name)
+		}
+	}
+	return nil
+}
+
+// BootMACAddressField is the name of the cache index of the hosts by
+// their normalized boot MAC address, used to find the hosts sharing a
+// boot MAC address without listing every host.
+const BootMACAddressField = "spec.bootMACAddress"
+
+// IndexBootMACAddress returns the value of BootMACAddressField for a
+// host.
+func IndexBootMACAddress(obj client.Object) []string {
+	host, ok := obj.(*BareMetalHost)
+	if !ok || host.Spec.BootMACAddress == "" {
+		return nil
+	}
+	return []string{normalizeMACAddress(host.Spec.BootMACAddress)}
+}
+
+// BMCAddressField is the name of the cache index of the hosts by the
+// machine their BMC address points to, used to find the hosts sharing
+// a BMC without listing every host.
+const BMCAddressField = "spec.bmc.address"
+
+// IndexBMCAddress returns the value of BMCAddressField for a host.
+func IndexBMCAddress(obj client.Object) []string {
+	host, ok := obj.(*BareMetalHost)
+	if !ok || host.Spec.BMC.Address == "" {
+		return nil
+	}
+	return []string{bmc.MachineKey(host.Spec.BMC.Address)}
+}
+
+// FindHostsWithSameBMC returns the other hosts, from any namespace,
+// that use the same BMC address as host. The client must have the
+// BMCAddressField index.
+func FindHostsWithSameBMC(ctx context.Context, c client.Reader, host *BareMetalHost) ([]BareMetalHost, error) {
+	if host.Spec.BMC.Address == "" {
+		return nil, nil
+	}
+	hosts := &BareMetalHostList{}
+	if err := c.List(ctx, hosts, client.MatchingFields{BMCAddressField: bmc.MachineKey(host.Spec.BMC.Address)}); err != nil {
+		return nil, errors.Wrap(err, "failed to list hosts")
+	}
+	var others []BareMetalHost
+	for _, other := range hosts.Items {
+		if other.Namespace == host.Namespace && other.Name == host.Name {
+			continue
+		}
+		if other.Name == host.Name && other.Annotations[MoveToAnnotation] == host.Namespace {
+			// The host is being moved to this namespace
+			continue
+		}
+		if bmc.SameAddress(host.Spec.BMC.Address, other.Spec.BMC.Address) {
+			others = append(others, other)
+		}
+	}
+	return others, nil
+}
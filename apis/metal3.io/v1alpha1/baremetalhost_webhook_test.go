@@ -0,0 +1,788 @@
+package v1alpha1
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDefaultNormalizesBMCAddress(t *testing.T) {
+	testCases := []struct {
+		Scenario string
+		Address  string
+		Expected string
+	}{
+		{
+			Scenario: "equivalent address",
+			Address:  "IPMI://192.168.122.1:623/",
+			Expected: "ipmi://192.168.122.1",
+		},
+		{
+			Scenario: "invalid address left alone",
+			Address:  "foo://192.168.122.1",
+			Expected: "foo://192.168.122.1",
+		},
+		{
+			Scenario: "no address",
+			Address:  "",
+			Expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := &BareMetalHost{
+				Spec: BareMetalHostSpec{
+					BMC: BMCDetails{Address: tc.Address},
+				},
+			}
+			host.Default()
+			assert.Equal(t, tc.Expected, host.Spec.BMC.Address)
+		})
+	}
+}
+
+func TestValidateBMCAddressUnique(t *testing.T) {
+	existing := &BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "existing",
+			Namespace: "other-ns",
+		},
+		Spec: BareMetalHostSpec{
+			BMC: BMCDetails{Address: "ipmi://192.168.122.1"},
+		},
+	}
+	scheme := runtime.NewScheme()
+	if err := AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	webhookClient = fakeclient.NewFakeClientWithScheme(scheme, existing)
+	defer func() { webhookClient = nil }()
+
+	host := &BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myhost",
+			Namespace: "myns",
+		},
+		Spec: BareMetalHostSpec{
+			BMC: BMCDetails{Address: "192.168.122.1:623"},
+		},
+	}
+	err := host.ValidateCreate()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "other-ns/existing")
+	}
+
+	// Updates that do not change the address are allowed
+	assert.NoError(t, host.ValidateUpdate(host.DeepCopy()))
+
+	host.Spec.BMC.Address = "ipmi://192.168.122.2"
+	assert.NoError(t, host.ValidateCreate())
+
+	// The existing host does not conflict with itself
+	assert.NoError(t, existing.ValidateUpdate(&BareMetalHost{}))
+}
+
+func TestValidateInspection(t *testing.T) {
+	testCases := []struct {
+		Scenario    string
+		Inspection  *InspectionSettings
+		Annotations map[string]string
+		ExpectError string
+	}{
+		{
+			Scenario: "no inspection settings",
+		},
+		{
+			Scenario: "valid details",
+			Inspection: &InspectionSettings{
+				Disabled: true,
+				HardwareDetails: &HardwareDetails{
+					RAMMebibytes: 4096,
+					NIC:          []NIC{{Name: "eth0", MAC: "00:11:22:33:44:55", IP: "192.168.0.10"}},
+					Storage:      []Storage{{Name: "/dev/sda"}, {Name: "/dev/sdb"}},
+				},
+			},
+		},
+		{
+			Scenario: "disabled by annotation",
+			Inspection: &InspectionSettings{
+				HardwareDetails: &HardwareDetails{},
+			},
+			Annotations: map[string]string{InspectAnnotationPrefix: "disabled"},
+		},
+		{
+			Scenario: "inspection enabled",
+			Inspection: &InspectionSettings{
+				HardwareDetails: &HardwareDetails{},
+			},
+			ExpectError: "only be set when inspection.disabled is true",
+		},
+		{
+			Scenario: "bad MAC",
+			Inspection: &InspectionSettings{
+				Disabled: true,
+				HardwareDetails: &HardwareDetails{
+					NIC: []NIC{{Name: "eth0", MAC: "00-11-22-33-44-55"}},
+				},
+			},
+			ExpectError: "nics[0].mac",
+		},
+		{
+			Scenario: "bad IP",
+			Inspection: &InspectionSettings{
+				Disabled: true,
+				HardwareDetails: &HardwareDetails{
+					NIC: []NIC{{Name: "eth0", IP: "192.168.0"}},
+				},
+			},
+			ExpectError: "nics[0].ip",
+		},
+		{
+			Scenario: "duplicate disk",
+			Inspection: &InspectionSettings{
+				Disabled: true,
+				HardwareDetails: &HardwareDetails{
+					Storage: []Storage{{Name: "/dev/sda"}, {Name: "/dev/sda"}},
+				},
+			},
+			ExpectError: "storage[1].name",
+		},
+		{
+			Scenario: "negative RAM",
+			Inspection: &InspectionSettings{
+				Disabled:        true,
+				HardwareDetails: &HardwareDetails{RAMMebibytes: -1},
+			},
+			ExpectError: "ramMebibytes",
+		},
+		{
+			Scenario: "negative disk throughput",
+			Inspection: &InspectionSettings{
+				Disabled: true,
+				HardwareDetails: &HardwareDetails{
+					Benchmarks: &BenchmarkResults{
+						Disks: []DiskBenchmarkResult{{Name: "sda", SequentialReadKBps: -1}},
+					},
+				},
+			},
+			ExpectError: "benchmarks.disks[0].sequentialReadKBps",
+		},
+		{
+			Scenario: "unknown disk health",
+			Inspection: &InspectionSettings{
+				Disabled: true,
+				HardwareDetails: &HardwareDetails{
+					Storage: []Storage{{Name: "/dev/sda", Health: &DiskHealth{Status: "Bad"}}},
+				},
+			},
+			ExpectError: "storage[0].health.status",
+		},
+		{
+			Scenario: "negative GPU memory",
+			Inspection: &InspectionSettings{
+				Disabled: true,
+				HardwareDetails: &HardwareDetails{
+					Accelerators: []Accelerator{{Type: GPUAccelerator, VRAMMebibytes: -1}},
+				},
+			},
+			ExpectError: "accelerators[0].vramMebibytes",
+		},
+		{
+			Scenario:    "valid annotation",
+			Annotations: map[string]string{HardwareDetailsAnnotation: `{"ramMebibytes":4096,"nics":[{"mac":"00:11:22:33:44:55"}]}`},
+		},
+		{
+			Scenario:    "annotation not JSON",
+			Annotations: map[string]string{HardwareDetailsAnnotation: `ramMebibytes: 4096`},
+			ExpectError: "invalid inspect.metal3.io/hardwaredetails annotation",
+		},
+		{
+			Scenario:    "annotation with unknown field",
+			Annotations: map[string]string{HardwareDetailsAnnotation: `{"ramMebibyte":4096}`},
+			ExpectError: "unknown field",
+		},
+		{
+			Scenario:    "annotation with bad MAC",
+			Annotations: map[string]string{HardwareDetailsAnnotation: `{"nics":[{"mac":"00:11:22:33:44"}]}`},
+			ExpectError: "nics[0].mac",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := &BareMetalHost{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "myhost",
+					Namespace:   "myns",
+					Annotations: tc.Annotations,
+				},
+				Spec: BareMetalHostSpec{
+					Inspection: tc.Inspection,
+				},
+			}
+			err := host.ValidateCreate()
+			if tc.ExpectError == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.ExpectError)
+			}
+
+			// Unchanged settings are not checked again on update
+			assert.NoError(t, host.ValidateUpdate(host.DeepCopy()))
+		})
+	}
+}
+
+func TestValidateDecommission(t *testing.T) {
+	old := &BareMetalHost{
+		Spec: BareMetalHostSpec{Decommission: true},
+	}
+	host := &BareMetalHost{}
+
+	// Decommissioning can be cancelled until it starts
+	assert.NoError(t, host.ValidateUpdate(old))
+
+	old.Status.Decommission = &DecommissionStatus{}
+	assert.Error(t, host.ValidateUpdate(old))
+}
+
+func TestValidateRetire(t *testing.T) {
+	old := &BareMetalHost{
+		Spec: BareMetalHostSpec{Retire: true},
+	}
+	host := &BareMetalHost{}
+
+	// Retirement can be cancelled until it starts, and undone once
+	// the host is retired
+	assert.NoError(t, host.ValidateUpdate(old))
+	old.Status.Provisioning.State = StateRetired
+	assert.NoError(t, host.ValidateUpdate(old))
+
+	old.Status.Provisioning.State = StateRetiring
+	assert.Error(t, host.ValidateUpdate(old))
+
+	host.Spec.Retire = true
+	host.Spec.Decommission = true
+	assert.Error(t, host.ValidateUpdate(old))
+}
+
+func TestValidateRootDeviceHints(t *testing.T) {
+	host := &BareMetalHost{Spec: BareMetalHostSpec{
+		RootDeviceHints: &RootDeviceHints{
+			WWID:   "3600a098038304437415d4b6a59684a52",
+			ByPath: "/dev/disk/by-path/pci-0000:41:00.0-fc-0x500a0981891b8dc5*",
+		},
+	}}
+	assert.NoError(t, host.validateRootDeviceHints())
+
+	host.Spec.RootDeviceHints.SerialNumber = "abc"
+	assert.Error(t, host.validateRootDeviceHints())
+
+	host.Spec.RootDeviceHints.SerialNumber = ""
+	host.Spec.RootDeviceHints.DeviceName = "/dev/sda"
+	assert.Error(t, host.validateRootDeviceHints())
+}
+
+func TestValidateAgentKernelArgs(t *testing.T) {
+	host := &BareMetalHost{Spec: BareMetalHostSpec{
+		AgentKernelArgs: []string{"console=ttyS0,115200", "ip=dhcp6", "nomodeset"},
+	}}
+	assert.NoError(t, host.validateAgentKernelArgs())
+
+	for _, arg := range []string{"", "console=tty0 console=ttyS0", "ipa-api-url=https://ironic.test:6385", "%default%"} {
+		host.Spec.AgentKernelArgs = []string{"nomodeset", arg}
+		assert.Error(t, host.validateAgentKernelArgs(), arg)
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	host := &BareMetalHost{Spec: BareMetalHostSpec{
+		Tags: []string{"rack-12", "team:storage", "gpu"},
+	}}
+	assert.NoError(t, host.validateTags())
+
+	for _, tag := range []string{"", strings.Repeat("x", 256), "two\nlines", "gpu"} {
+		host.Spec.Tags = []string{"gpu", tag}
+		assert.Error(t, host.validateTags(), tag)
+	}
+}
+
+func TestValidateUserDataSecrets(t *testing.T) {
+	host := &BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "metal3"},
+		Spec: BareMetalHostSpec{
+			UserDataSecrets: []corev1.SecretReference{
+				{Name: "base"}, {Name: "role"}, {Name: "base", Namespace: "shared"},
+			},
+		},
+	}
+	assert.NoError(t, host.validateUserDataSecrets())
+
+	host.Spec.UserDataSecrets = append(host.Spec.UserDataSecrets, corev1.SecretReference{Name: "base", Namespace: "metal3"})
+	assert.Error(t, host.validateUserDataSecrets(), "repeated secret")
+
+	host.Spec.UserDataSecrets = []corev1.SecretReference{{Namespace: "metal3"}}
+	assert.Error(t, host.validateUserDataSecrets(), "no name")
+
+	host.Spec.UserDataSecrets = []corev1.SecretReference{{Name: "base"}}
+	host.Spec.UserData = &corev1.SecretReference{Name: "user-data"}
+	assert.Error(t, host.validateUserDataSecrets(), "both set")
+}
+
+func TestValidateNodeInterfaces(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario    string
+		Address     string
+		Interfaces  NodeInterfaces
+		BootMode    BootMode
+		ExpectError bool
+	}{
+		{
+			Scenario:   "redfish bios",
+			Address:    "redfish://192.168.122.1/redfish/v1/Systems/1",
+			Interfaces: NodeInterfaces{BIOS: "redfish", Deploy: "ansible"},
+		},
+		{
+			Scenario:   "idrac bios",
+			Address:    "idrac-redfish://192.168.122.1/redfish/v1/Systems/1",
+			Interfaces: NodeInterfaces{BIOS: "idrac-redfish"},
+		},
+		{
+			Scenario:    "ipmi redfish bios",
+			Address:     "ipmi://192.168.122.1",
+			Interfaces:  NodeInterfaces{BIOS: "redfish"},
+			ExpectError: true,
+		},
+		{
+			Scenario:    "unknown deploy",
+			Address:     "ipmi://192.168.122.1",
+			Interfaces:  NodeInterfaces{Deploy: "iscsi-magic"},
+			ExpectError: true,
+		},
+		{
+			Scenario:   "http boot",
+			Address:    "redfish://192.168.122.1/redfish/v1/Systems/1",
+			Interfaces: NodeInterfaces{Boot: "http"},
+		},
+		{
+			Scenario:    "http boot legacy",
+			Address:     "ipmi://192.168.122.1",
+			Interfaces:  NodeInterfaces{Boot: "http-ipxe"},
+			BootMode:    Legacy,
+			ExpectError: true,
+		},
+		{
+			Scenario:    "virtual media http boot",
+			Address:     "redfish-virtualmedia://192.168.122.1/redfish/v1/Systems/1",
+			Interfaces:  NodeInterfaces{Boot: "http"},
+			ExpectError: true,
+		},
+		{
+			Scenario:   "libvirt bios",
+			Address:    "libvirt://192.168.122.1",
+			Interfaces: NodeInterfaces{BIOS: "no-bios"},
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			interfaces := tc.Interfaces
+			host := &BareMetalHost{
+				Spec: BareMetalHostSpec{
+					BMC:            BMCDetails{Address: tc.Address},
+					NodeInterfaces: &interfaces,
+					BootMode:       tc.BootMode,
+				},
+			}
+			err := host.ValidateCreate()
+			if tc.ExpectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateOperational(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario    string
+		Operational OperationalMetadata
+		ExpectError string
+	}{
+		{
+			Scenario: "valid",
+			Operational: OperationalMetadata{
+				OwnerTeam: "storage-ops",
+				TicketURL: "https://tickets.example.com/browse/OPS-1234",
+				AssetTag:  "A0012345",
+				Notes:     "Replaced the PSU, keep an eye on fan 2.",
+			},
+		},
+		{
+			Scenario:    "owner team with spaces",
+			Operational: OperationalMetadata{OwnerTeam: "storage ops"},
+			ExpectError: "operational.ownerTeam",
+		},
+		{
+			Scenario:    "asset tag too long",
+			Operational: OperationalMetadata{AssetTag: strings.Repeat("a", 64)},
+			ExpectError: "operational.assetTag",
+		},
+		{
+			Scenario:    "relative ticket URL",
+			Operational: OperationalMetadata{TicketURL: "OPS-1234"},
+			ExpectError: "operational.ticketURL",
+		},
+		{
+			Scenario:    "ticket URL with another scheme",
+			Operational: OperationalMetadata{TicketURL: "ftp://tickets.example.com/OPS-1234"},
+			ExpectError: "operational.ticketURL",
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			operational := tc.Operational
+			host := &BareMetalHost{Spec: BareMetalHostSpec{Operational: &operational}}
+			err := host.validateOperational()
+			if tc.ExpectError == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.ExpectError)
+			}
+		})
+	}
+
+	// Hosts with invalid metadata can still be updated
+	host := &BareMetalHost{Spec: BareMetalHostSpec{
+		Operational: &OperationalMetadata{OwnerTeam: "storage ops"},
+	}}
+	assert.NoError(t, host.ValidateUpdate(host.DeepCopy()))
+}
+
+func TestValidateMetaDataTemplate(t *testing.T) {
+	host := &BareMetalHost{Spec: BareMetalHostSpec{
+		MetaDataTemplate: &MetaDataTemplate{
+			Hostname: `{{ .Name }}.{{ index .Labels "rack" }}.example.com`,
+			Values:   map[string]string{"asset": "{{ .Spec.Operational.AssetTag }}"},
+		},
+	}}
+	assert.NoError(t, host.validateMetaDataTemplate())
+
+	host.Spec.MetaDataTemplate.Hostname = "{{ .Name"
+	assert.Error(t, host.validateMetaDataTemplate())
+
+	host.Spec.MetaDataTemplate.Hostname = ""
+	host.Spec.MetaDataTemplate.Values["broken"] = "{{ end }}"
+	err := host.validateMetaDataTemplate()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "metaDataTemplate.values[broken]")
+	}
+}
+
+func TestValidateSSHAuthorizedKeys(t *testing.T) {
+	key := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIK6LgaiBc8xOJkhJLU5luOxazQ87vOSXt3vq1UF7ZIAQ ops@example.com"
+	for _, tc := range []struct {
+		Scenario string
+		Key      string
+		Valid    bool
+	}{
+		{Scenario: "valid", Key: key, Valid: true},
+		{Scenario: "no comment", Key: strings.TrimSuffix(key, " ops@example.com"), Valid: true},
+		{Scenario: "type only", Key: "ssh-ed25519"},
+		{Scenario: "not base64", Key: "ssh-ed25519 not-a-key"},
+		{Scenario: "wrong type", Key: strings.Replace(key, "ssh-ed25519", "ssh-rsa", 1)},
+		{Scenario: "several lines", Key: key + "\n" + key},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := &BareMetalHost{Spec: BareMetalHostSpec{SSHAuthorizedKeys: []string{tc.Key}}}
+			err := host.validateSSHAuthorizedKeys()
+			if tc.Valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateCustomDeploy(t *testing.T) {
+	host := &BareMetalHost{Spec: BareMetalHostSpec{CustomDeploy: &CustomDeploy{
+		Steps: []DeployStep{
+			{Interface: "deploy", Step: "write_image", Priority: 0},
+			{Interface: "deploy", Step: "install_coreos", Priority: 80},
+		},
+	}}}
+	assert.NoError(t, host.validateCustomDeploy())
+
+	host.Spec.CustomDeploy.Steps = append(host.Spec.CustomDeploy.Steps,
+		DeployStep{Interface: "deploy", Step: "write_image", Priority: 80})
+	assert.Error(t, host.validateCustomDeploy())
+}
+
+func TestValidateMaintenanceWindow(t *testing.T) {
+	window := &MaintenanceWindow{
+		Schedule: "0 2 * * 6",
+		Duration: metav1.Duration{Duration: 4 * time.Hour},
+		TimeZone: "Europe/Paris",
+	}
+	host := &BareMetalHost{Spec: BareMetalHostSpec{MaintenanceWindow: window}}
+	assert.NoError(t, host.validateMaintenanceWindow())
+
+	window.TimeZone = "Mars/Olympus"
+	assert.Error(t, host.validateMaintenanceWindow())
+
+	window.TimeZone = ""
+	window.Duration.Duration = 0
+	assert.Error(t, host.validateMaintenanceWindow())
+
+	window.Duration.Duration = time.Hour
+	window.Schedule = "0 2 * *"
+	assert.Error(t, host.validateMaintenanceWindow())
+}
+
+func TestValidateSecureBootDatabases(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "db"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	host := &BareMetalHost{Spec: BareMetalHostSpec{SecureBootDatabases: []SecureBootDatabaseUpdate{
+		{Database: "db", Revision: "1", Certificates: []string{certificate}},
+		{Database: "dbx", Revision: "2023-05-09", Signatures: []string{strings.Repeat("ab", 32)}},
+	}}}
+	assert.NoError(t, host.validateSecureBootDatabases())
+
+	host.Spec.SecureBootDatabases[1].Signatures = []string{"abcd"}
+	assert.Error(t, host.validateSecureBootDatabases())
+
+	host.Spec.SecureBootDatabases[1].Signatures = nil
+	assert.Error(t, host.validateSecureBootDatabases())
+
+	host.Spec.SecureBootDatabases = []SecureBootDatabaseUpdate{
+		{Database: "db", Revision: "1", Certificates: []string{"not a certificate"}},
+	}
+	assert.Error(t, host.validateSecureBootDatabases())
+
+	host.Spec.SecureBootDatabases = []SecureBootDatabaseUpdate{
+		{Database: "db", Certificates: []string{certificate}},
+	}
+	assert.Error(t, host.validateSecureBootDatabases())
+
+	host.Spec.SecureBootDatabases = []SecureBootDatabaseUpdate{
+		{Database: "db", Revision: "1", Certificates: []string{certificate}},
+		{Database: "db", Revision: "2", Certificates: []string{certificate}},
+	}
+	assert.Error(t, host.validateSecureBootDatabases())
+}
+
+func TestValidateQuota(t *testing.T) {
+	two := 2
+	one := 1
+	quota := &HostQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: "tenant"},
+		Spec:       HostQuotaSpec{MaxProvisioned: &two, MaxClaimed: &one},
+	}
+	image := &Image{URL: "http://example.test/image.qcow2"}
+	provisioned := &BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{Name: "provisioned", Namespace: "tenant"},
+		Spec:       BareMetalHostSpec{Image: image},
+	}
+	claimed := &BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{Name: "claimed", Namespace: "shared"},
+		Spec: BareMetalHostSpec{
+			ConsumerRef: &corev1.ObjectReference{Name: "machine", Namespace: "tenant"},
+		},
+	}
+	decommissioned := &BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{Name: "decommissioned", Namespace: "tenant"},
+		Spec:       BareMetalHostSpec{Image: image, Decommission: true},
+	}
+	scheme := runtime.NewScheme()
+	if err := AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	webhookClient = fakeclient.NewFakeClientWithScheme(scheme, quota, provisioned, claimed, decommissioned)
+	defer func() { webhookClient = nil }()
+
+	host := &BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{Name: "myhost", Namespace: "tenant"},
+	}
+	assert.NoError(t, host.ValidateCreate())
+
+	// One more host may be provisioned
+	withImage := host.DeepCopy()
+	withImage.Spec.Image = image
+	assert.NoError(t, withImage.ValidateUpdate(host))
+
+	// But no more host may be claimed
+	shared := &BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{Name: "spare", Namespace: "shared"},
+	}
+	withConsumer := shared.DeepCopy()
+	withConsumer.Spec.ConsumerRef = &corev1.ObjectReference{Name: "other", Namespace: "tenant"}
+	err := withConsumer.ValidateUpdate(shared)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "tenant/quota allows 1 claimed hosts")
+	}
+
+	// Hosts already using the quota are not rejected
+	assert.NoError(t, claimed.ValidateUpdate(claimed.DeepCopy()))
+
+	// Consumers in other namespaces are not limited
+	withConsumer.Spec.ConsumerRef.Namespace = "other"
+	assert.NoError(t, withConsumer.ValidateUpdate(shared))
+
+	quota.Spec.MaxProvisioned = &one
+	assert.NoError(t, webhookClient.(client.Client).Update(context.TODO(), quota))
+	err = withImage.ValidateUpdate(host)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "tenant/quota allows 1 provisioned hosts")
+	}
+}
+
+func TestValidateBootMACAddress(t *testing.T) {
+	existing := &BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "existing",
+			Namespace: "other-ns",
+		},
+		Spec: BareMetalHostSpec{
+			BootMACAddress: "00:11:22:aa:bb:cc",
+		},
+	}
+	scheme := runtime.NewScheme()
+	if err := AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	webhookClient = fakeclient.NewFakeClientWithScheme(scheme, existing)
+	defer func() { webhookClient = nil }()
+
+	testCases := []struct {
+		Scenario    string
+		MAC         string
+		ExpectError string
+	}{
+		{
+			Scenario: "no MAC",
+		},
+		{
+			Scenario: "unique MAC",
+			MAC:      "00:11:22:33:44:66",
+		},
+		{
+			Scenario:    "duplicate MAC in other case",
+			MAC:         "00:11:22:AA:BB:CC",
+			ExpectError: "other-ns/existing",
+		},
+		{
+			Scenario:    "duplicate MAC",
+			MAC:         "00:11:22:aa:bb:cc",
+			ExpectError: "other-ns/existing",
+		},
+		{
+			Scenario:    "malformed",
+			MAC:         "00:11:22:33:44",
+			ExpectError: "not a valid MAC address",
+		},
+		{
+			Scenario:    "wrong separator",
+			MAC:         "00-11-22-33-44-66",
+			ExpectError: "not a valid MAC address",
+		},
+		{
+			Scenario:    "infiniband",
+			MAC:         "00:00:00:00:fe:80:00:00:00:00:00:00:02:00:5e:10:00:00:00:01",
+			ExpectError: "not a valid MAC address",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := &BareMetalHost{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "myhost",
+					Namespace: "myns",
+				},
+				Spec: BareMetalHostSpec{
+					BootMACAddress: tc.MAC,
+				},
+			}
+			err := host.ValidateCreate()
+			if tc.ExpectError == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.ExpectError)
+			}
+		})
+	}
+}
+
+func TestIndexBootMACAddress(t *testing.T) {
+	lower := &BareMetalHost{Spec: BareMetalHostSpec{BootMACAddress: "00:11:22:aa:bb:cc"}}
+	upper := &BareMetalHost{Spec: BareMetalHostSpec{BootMACAddress: "00:11:22:AA:BB:CC"}}
+	assert.Equal(t, IndexBootMACAddress(lower), IndexBootMACAddress(upper))
+	assert.Nil(t, IndexBootMACAddress(&BareMetalHost{}))
+}
+
+func TestValidateMove(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario    string
+		Target      string
+		ExpectError string
+	}{
+		{
+			Scenario: "valid",
+			Target:   "team-b",
+		},
+		{
+			Scenario:    "invalid namespace",
+			Target:      "Team B",
+			ExpectError: `invalid namespace "Team B"`,
+		},
+		{
+			Scenario:    "same namespace",
+			Target:      "team-a",
+			ExpectError: "host is already in namespace team-a",
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := &BareMetalHost{ObjectMeta: metav1.ObjectMeta{
+				Name:        "myhost",
+				Namespace:   "team-a",
+				Annotations: map[string]string{MoveToAnnotation: tc.Target},
+			}}
+			err := host.validateMove()
+			if tc.ExpectError == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.ExpectError)
+			}
+		})
+	}
+}
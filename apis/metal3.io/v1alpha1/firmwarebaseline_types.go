@@ -0,0 +1,168 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NOTE(dhellmann): Update docs/api.md when changing these data structure.
+
+// NICFirmwareBaseline is the minimum firmware version of a model of
+// NIC.
+type NICFirmwareBaseline struct {
+	// Model is the vendor and product IDs of the NIC, as in the
+	// hardware details, e.g. "0x8086 0x1572"
+	Model string `json:"model"`
+
+	// MinVersion is the oldest firmware version accepted
+	MinVersion string `json:"minVersion"`
+}
+
+// ModelFirmwareBaseline is the minimum firmware versions of a
+// hardware model.
+type ModelFirmwareBaseline struct {
+	// Manufacturer of the hosts, as in the system vendor of the
+	// hardware details. Any manufacturer matches when it is not set.
+	// +optional
+	Manufacturer string `json:"manufacturer,omitempty"`
+
+	// ProductName of the hosts, as in the system vendor of the
+	// hardware details.
+	ProductName string `json:"productName"`
+
+	// MinBIOSVersion is the oldest BIOS version accepted.
+	// +optional
+	MinBIOSVersion string `json:"minBIOSVersion,omitempty"`
+
+	// NICs are the minimum firmware versions of the NICs of the
+	// hosts, by model of NIC.
+	// +optional
+	NICs []NICFirmwareBaseline `json:"nics,omitempty"`
+}
+
+// FirmwareBaselineSpec lists the minimum firmware versions of the
+// hardware models.
+type FirmwareBaselineSpec struct {
+	Models []ModelFirmwareBaseline `json:"models"`
+}
+
+// FirmwareBaselineStatus reports the compliance of the hosts.
+type FirmwareBaselineStatus struct {
+	// Compliant is the number of inspected hosts of the models of the
+	// baseline running the firmware versions required
+	Compliant int `json:"compliant"`
+
+	// NonCompliant is the number of inspected hosts of the models of
+	// the baseline running older firmware
+	NonCompliant int `json:"nonCompliant"`
+
+	// ObservedGeneration is the generation of the baseline spec that
+	// the compliance was last computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastUpdated identifies when the compliance was last computed
+	// +optional
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FirmwareBaseline defines the minimum firmware versions of hardware
+// models. The inspected hosts of its namespace running older firmware
+// are reported as not compliant.
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:path=firmwarebaselines,shortName=fwb
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Compliant",type="integer",JSONPath=".status.compliant",description="Hosts running the firmware required"
+// +kubebuilder:printcolumn:name="Non_Compliant",type="integer",JSONPath=".status.nonCompliant",description="Hosts running older firmware"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type FirmwareBaseline struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FirmwareBaselineSpec   `json:"spec,omitempty"`
+	Status FirmwareBaselineStatus `json:"status,omitempty"`
+}
+
+// modelFor returns the baseline of the model of the host, or nil if
+// the baseline does not cover it.
+func (baseline *FirmwareBaseline) modelFor(hw *HardwareDetails) *ModelFirmwareBaseline {
+	for i, model := range baseline.Spec.Models {
+		if model.ProductName == hw.SystemVendor.ProductName &&
+			(model.Manufacturer == "" || model.Manufacturer == hw.SystemVendor.Manufacturer) {
+			return &baseline.Spec.Models[i]
+		}
+	}
+	return nil
+}
+
+// Check compares the firmware of the host with the baseline. It
+// reports whether the baseline covers the model of the host, and the
+// firmware that is older than required. Hosts that have not been
+// inspected are not covered.
+func (baseline *FirmwareBaseline) Check(host *BareMetalHost) (covered bool, violations []string) {
+	hw := host.Status.HardwareDetails
+	if hw == nil || host.Namespace != baseline.Namespace {
+		return false, nil
+	}
+	model := baseline.modelFor(hw)
+	if model == nil {
+		return false, nil
+	}
+
+	violation := func(format string, args ...interface{}) {
+		violations = append(violations, baseline.Name+": "+fmt.Sprintf(format, args...))
+	}
+	if model.MinBIOSVersion != "" {
+		version := hw.Firmware.BIOS.Version
+		if version == "" {
+			violation("BIOS version unknown, expected %s or newer", model.MinBIOSVersion)
+		} else if CompareVersions(version, model.MinBIOSVersion) < 0 {
+			violation("BIOS version %s, expected %s or newer", version, model.MinBIOSVersion)
+		}
+	}
+	for _, nicBaseline := range model.NICs {
+		for _, nic := range hw.NIC {
+			if nic.Model != nicBaseline.Model {
+				continue
+			}
+			if nic.FirmwareVersion == "" {
+				violation("NIC %s firmware version unknown, expected %s or newer", nic.Name, nicBaseline.MinVersion)
+			} else if CompareVersions(nic.FirmwareVersion, nicBaseline.MinVersion) < 0 {
+				violation("NIC %s firmware version %s, expected %s or newer", nic.Name, nic.FirmwareVersion, nicBaseline.MinVersion)
+			}
+		}
+	}
+	return true, violations
+}
+
+// +kubebuilder:object:root=true
+
+// FirmwareBaselineList contains a list of FirmwareBaseline
+type FirmwareBaselineList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FirmwareBaseline `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FirmwareBaseline{}, &FirmwareBaselineList{})
+}
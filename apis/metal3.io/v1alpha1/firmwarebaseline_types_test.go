@@ -0,0 +1,54 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFirmwareBaselineCheck(t *testing.T) {
+	baseline := &FirmwareBaseline{
+		ObjectMeta: metav1.ObjectMeta{Name: "q4", Namespace: "myns"},
+		Spec: FirmwareBaselineSpec{
+			Models: []ModelFirmwareBaseline{
+				{
+					Manufacturer:   "Dell Inc.",
+					ProductName:    "PowerEdge R640",
+					MinBIOSVersion: "2.10.2",
+					NICs:           []NICFirmwareBaseline{{Model: "0x8086 0x1572", MinVersion: "8.30"}},
+				},
+			},
+		},
+	}
+	host := &BareMetalHost{ObjectMeta: metav1.ObjectMeta{Name: "myhost", Namespace: "myns"}}
+
+	covered, _ := baseline.Check(host)
+	assert.False(t, covered, "hosts that were not inspected are not covered")
+
+	host.Status.HardwareDetails = &HardwareDetails{
+		SystemVendor: HardwareSystemVendor{Manufacturer: "Dell Inc.", ProductName: "PowerEdge R740"},
+	}
+	covered, _ = baseline.Check(host)
+	assert.False(t, covered, "other models are not covered")
+
+	host.Status.HardwareDetails = &HardwareDetails{
+		SystemVendor: HardwareSystemVendor{Manufacturer: "Dell Inc.", ProductName: "PowerEdge R640"},
+		Firmware:     Firmware{BIOS: BIOS{Version: "2.11.2"}},
+		NIC: []NIC{
+			{Name: "eno1", Model: "0x14e4 0x165f", FirmwareVersion: "1.0"},
+			{Name: "ens1f0", Model: "0x8086 0x1572", FirmwareVersion: "8.30 0x8000a4a1"},
+		},
+	}
+	covered, violations := baseline.Check(host)
+	assert.True(t, covered)
+	assert.Empty(t, violations)
+
+	host.Status.HardwareDetails.Firmware.BIOS.Version = "2.9.4"
+	host.Status.HardwareDetails.NIC[1].FirmwareVersion = "7.10"
+	_, violations = baseline.Check(host)
+	assert.Equal(t, []string{
+		"q4: BIOS version 2.9.4, expected 2.10.2 or newer",
+		"q4: NIC ens1f0 firmware version 7.10, expected 8.30 or newer",
+	}, violations)
+}
@@ -0,0 +1,89 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NOTE(dhellmann): Update docs/api.md when changing these data structure.
+
+// HardwareSnapshotSource is how the hardware details of a snapshot
+// were collected.
+type HardwareSnapshotSource string
+
+const (
+	// HardwareSnapshotInspection is the inspection of the host by the
+	// deployment agent.
+	HardwareSnapshotInspection HardwareSnapshotSource = "inspection"
+
+	// HardwareSnapshotOutOfBand is the out-of-band inspection of the
+	// host through its BMC.
+	HardwareSnapshotOutOfBand HardwareSnapshotSource = "out-of-band"
+)
+
+// HardwareDataSnapshotSpec is the result of one inspection of a host.
+type HardwareDataSnapshotSpec struct {
+	// HostName is the name of the host, in the namespace of the
+	// snapshot.
+	HostName string `json:"hostName"`
+
+	// Inspected is when the hardware details were recorded.
+	Inspected metav1.Time `json:"inspected"`
+
+	// Source is how the hardware details were collected.
+	Source HardwareSnapshotSource `json:"source"`
+
+	// HardwareDetails are the hardware details of the host.
+	HardwareDetails HardwareDetails `json:"hardwareDetails"`
+
+	// Changes describe how the hardware details differ from those
+	// the host had before this inspection.
+	// +optional
+	Changes []string `json:"changes,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// HardwareDataSnapshot is the hardware details of a host as found by
+// one inspection. The operator keeps the number of snapshots set by
+// the historyLimit inspection setting of the host, so that hardware
+// changes can be compared across inspections.
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:path=hardwaredatasnapshots,shortName=hds
+// +kubebuilder:printcolumn:name="Host",type="string",JSONPath=".spec.hostName",description="Name of the host"
+// +kubebuilder:printcolumn:name="Source",type="string",JSONPath=".spec.source",description="How the hardware details were collected"
+// +kubebuilder:printcolumn:name="Inspected",type="date",JSONPath=".spec.inspected",description="Time of the inspection"
+type HardwareDataSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HardwareDataSnapshotSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// HardwareDataSnapshotList contains a list of HardwareDataSnapshot
+type HardwareDataSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HardwareDataSnapshot `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HardwareDataSnapshot{}, &HardwareDataSnapshotList{})
+}
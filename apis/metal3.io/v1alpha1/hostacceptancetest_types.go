@@ -0,0 +1,235 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// NOTE(dhellmann): Update docs/api.md when changing these data structure.
+
+// VersionRange bounds a version. Versions are compared by their
+// numeric and alphabetic parts, so that 1.10 is newer than 1.9.
+type VersionRange struct {
+	// Min is the oldest version accepted. No lower bound is applied
+	// when it is not set.
+	// +optional
+	Min string `json:"min,omitempty"`
+
+	// Max is the newest version accepted. No upper bound is applied
+	// when it is not set.
+	// +optional
+	Max string `json:"max,omitempty"`
+}
+
+// HostAcceptanceTestSpec defines the assertions on the hardware of the
+// hosts. Assertions that are not set are not checked.
+type HostAcceptanceTestSpec struct {
+	// HostSelector matches the labels of the hosts of the namespace
+	// the test applies to. Every host of the namespace is tested when
+	// it is not set.
+	// +optional
+	HostSelector *metav1.LabelSelector `json:"hostSelector,omitempty"`
+
+	// MinRAMMebibytes is the least amount of memory of the hosts.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinRAMMebibytes *int `json:"minRAMMebibytes,omitempty"`
+
+	// DiskCount is the exact number of storage devices of the hosts.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	DiskCount *int `json:"diskCount,omitempty"`
+
+	// MinNICSpeedGbps is the least speed of the fastest NIC of the
+	// hosts.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinNICSpeedGbps *int `json:"minNICSpeedGbps,omitempty"`
+
+	// BIOSVersion is the range of BIOS versions accepted.
+	// +optional
+	BIOSVersion *VersionRange `json:"biosVersion,omitempty"`
+}
+
+// HostAcceptanceTestStatus reports the results of the test.
+type HostAcceptanceTestStatus struct {
+	// Accepted is the number of inspected hosts that pass the test
+	Accepted int `json:"accepted"`
+
+	// Rejected is the number of inspected hosts that fail the test
+	Rejected int `json:"rejected"`
+
+	// LastUpdated identifies when the results were last computed
+	// +optional
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// HostAcceptanceTest defines assertions on the inspected hardware of
+// hosts, to check new hardware deliveries before it is used. Hosts
+// failing the assertions of any test of their namespace are rejected
+// and not provisioned.
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:path=hostacceptancetests,shortName=hat
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Accepted",type="integer",JSONPath=".status.accepted",description="Hosts passing the test"
+// +kubebuilder:printcolumn:name="Rejected",type="integer",JSONPath=".status.rejected",description="Hosts failing the test"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type HostAcceptanceTest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HostAcceptanceTestSpec   `json:"spec,omitempty"`
+	Status HostAcceptanceTestStatus `json:"status,omitempty"`
+}
+
+// Applies reports whether the test applies to the host.
+func (test *HostAcceptanceTest) Applies(host *BareMetalHost) (bool, error) {
+	if host.Namespace != test.Namespace {
+		return false, nil
+	}
+	if test.Spec.HostSelector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(test.Spec.HostSelector)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid host selector in acceptance test %s", test.Name)
+	}
+	return selector.Matches(labels.Set(host.Labels)), nil
+}
+
+// Check returns the assertions of the test that the hardware details
+// of the host fail, or nil if it passes. Hosts that have not been
+// inspected pass.
+func (test *HostAcceptanceTest) Check(host *BareMetalHost) []string {
+	hw := host.Status.HardwareDetails
+	if hw == nil {
+		return nil
+	}
+	spec := test.Spec
+
+	var failures []string
+	fail := func(format string, args ...interface{}) {
+		failures = append(failures, test.Name+": "+fmt.Sprintf(format, args...))
+	}
+	if spec.MinRAMMebibytes != nil && hw.RAMMebibytes < *spec.MinRAMMebibytes {
+		fail("%d MiB of RAM, expected at least %d", hw.RAMMebibytes, *spec.MinRAMMebibytes)
+	}
+	if spec.DiskCount != nil && len(hw.Storage) != *spec.DiskCount {
+		fail("%d disks, expected %d", len(hw.Storage), *spec.DiskCount)
+	}
+	if spec.MinNICSpeedGbps != nil {
+		fastest := 0
+		for _, nic := range hw.NIC {
+			if nic.SpeedGbps > fastest {
+				fastest = nic.SpeedGbps
+			}
+		}
+		if fastest < *spec.MinNICSpeedGbps {
+			fail("fastest NIC at %d Gbps, expected at least %d", fastest, *spec.MinNICSpeedGbps)
+		}
+	}
+	if r := spec.BIOSVersion; r != nil {
+		version := hw.Firmware.BIOS.Version
+		switch {
+		case version == "":
+			fail("BIOS version unknown")
+		case r.Min != "" && CompareVersions(version, r.Min) < 0:
+			fail("BIOS version %s, expected %s or newer", version, r.Min)
+		case r.Max != "" && CompareVersions(version, r.Max) > 0:
+			fail("BIOS version %s, expected %s or older", version, r.Max)
+		}
+	}
+	return failures
+}
+
+// versionParts splits a version into its runs of digits and of
+// letters, ignoring separators.
+func versionParts(version string) []string {
+	var parts []string
+	current := ""
+	for _, c := range version {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			if current != "" {
+				parts = append(parts, current)
+			}
+			current = ""
+			continue
+		}
+		if current != "" && unicode.IsDigit(c) != unicode.IsDigit(rune(current[len(current)-1])) {
+			parts = append(parts, current)
+			current = ""
+		}
+		current += string(c)
+	}
+	if current != "" {
+		parts = append(parts, current)
+	}
+	return parts
+}
+
+// CompareVersions returns -1, 0 or 1 when version a is older than,
+// the same as or newer than version b. Numeric parts are compared as
+// numbers and the others alphabetically.
+func CompareVersions(a, b string) int {
+	partsA, partsB := versionParts(a), versionParts(b)
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		numA, errA := strconv.Atoi(partsA[i])
+		numB, errB := strconv.Atoi(partsB[i])
+		switch {
+		case errA == nil && errB == nil:
+			if numA != numB {
+				if numA < numB {
+					return -1
+				}
+				return 1
+			}
+		case partsA[i] < partsB[i]:
+			return -1
+		case partsA[i] > partsB[i]:
+			return 1
+		}
+	}
+	switch {
+	case len(partsA) < len(partsB):
+		return -1
+	case len(partsA) > len(partsB):
+		return 1
+	}
+	return 0
+}
+
+// +kubebuilder:object:root=true
+
+// HostAcceptanceTestList contains a list of HostAcceptanceTest
+type HostAcceptanceTestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HostAcceptanceTest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HostAcceptanceTest{}, &HostAcceptanceTestList{})
+}
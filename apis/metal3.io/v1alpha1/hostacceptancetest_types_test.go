@@ -0,0 +1,77 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCompareVersions(t *testing.T) {
+	for _, tc := range []struct {
+		A, B     string
+		Expected int
+	}{
+		{A: "1.10", B: "1.9", Expected: 1},
+		{A: "1.2.3", B: "1.2.3", Expected: 0},
+		{A: "2.1", B: "2.1.1", Expected: -1},
+		{A: "U30 v2.42", B: "U30 v2.8", Expected: 1},
+		{A: "1.0a", B: "1.0b", Expected: -1},
+	} {
+		assert.Equal(t, tc.Expected, CompareVersions(tc.A, tc.B), "%s <=> %s", tc.A, tc.B)
+	}
+}
+
+func TestHostAcceptanceTestCheck(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+	test := &HostAcceptanceTest{
+		ObjectMeta: metav1.ObjectMeta{Name: "intake", Namespace: "myns"},
+		Spec: HostAcceptanceTestSpec{
+			HostSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"delivery": "2026-10"},
+			},
+			MinRAMMebibytes: intPtr(262144),
+			DiskCount:       intPtr(2),
+			MinNICSpeedGbps: intPtr(25),
+			BIOSVersion:     &VersionRange{Min: "2.10", Max: "2.x"},
+		},
+	}
+	host := &BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myhost",
+			Namespace: "myns",
+			Labels:    map[string]string{"delivery": "2026-10"},
+		},
+	}
+
+	applies, err := test.Applies(host)
+	assert.NoError(t, err)
+	assert.True(t, applies)
+	host.Namespace = "other"
+	applies, _ = test.Applies(host)
+	assert.False(t, applies)
+
+	// Hosts are only checked once inspected
+	assert.Empty(t, test.Check(host))
+
+	host.Status.HardwareDetails = &HardwareDetails{
+		RAMMebibytes: 262144,
+		Storage:      []Storage{{Name: "sda"}, {Name: "sdb"}},
+		NIC:          []NIC{{Name: "eno1", SpeedGbps: 1}, {Name: "ens1f0", SpeedGbps: 25}},
+		Firmware:     Firmware{BIOS: BIOS{Version: "2.12.1"}},
+	}
+	assert.Empty(t, test.Check(host))
+
+	host.Status.HardwareDetails = &HardwareDetails{
+		RAMMebibytes: 131072,
+		Storage:      []Storage{{Name: "sda"}},
+		NIC:          []NIC{{Name: "eno1", SpeedGbps: 10}},
+		Firmware:     Firmware{BIOS: BIOS{Version: "2.9.0"}},
+	}
+	assert.Equal(t, []string{
+		"intake: 131072 MiB of RAM, expected at least 262144",
+		"intake: 1 disks, expected 2",
+		"intake: fastest NIC at 10 Gbps, expected at least 25",
+		"intake: BIOS version 2.9.0, expected 2.10 or newer",
+	}, test.Check(host))
+}
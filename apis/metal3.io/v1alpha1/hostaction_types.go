@@ -0,0 +1,95 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NOTE(dhellmann): Update docs/api.md when changing these data structure.
+
+// HostActionType is an action that can be requested on a host.
+type HostActionType string
+
+const (
+	// HostActionReboot reboots the host once, like the suffixless
+	// reboot annotation.
+	HostActionReboot HostActionType = "reboot"
+)
+
+// HostActionSpec is the action requested on a host.
+type HostActionSpec struct {
+	// HostName is the name of the host, in the namespace of the
+	// action.
+	HostName string `json:"hostName"`
+
+	// Action is the action to take on the host.
+	// +kubebuilder:validation:Enum=reboot
+	Action HostActionType `json:"action"`
+
+	// RebootMode is how the host is rebooted. Soft by default.
+	// +kubebuilder:validation:Enum=soft;hard
+	// +optional
+	RebootMode RebootMode `json:"rebootMode,omitempty"`
+}
+
+// HostActionStatus records whether the action was passed on to the
+// host.
+type HostActionStatus struct {
+	// Applied is when the action was passed on to the host.
+	// +optional
+	Applied *metav1.Time `json:"applied,omitempty"`
+
+	// ErrorMessage is why the action could not be passed on to the
+	// host yet.
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// HostAction requests an action on a host, such as a reboot. Creating
+// it only needs the right to create host actions, so that users can be
+// allowed to act on hosts without the right to edit them. When the
+// admission webhooks are enabled they also need the verb of the action
+// on the host.
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:path=hostactions
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Host",type="string",JSONPath=".spec.hostName",description="Name of the host"
+// +kubebuilder:printcolumn:name="Action",type="string",JSONPath=".spec.action",description="Action requested"
+// +kubebuilder:printcolumn:name="Applied",type="date",JSONPath=".status.applied",description="Time the action was passed on to the host"
+type HostAction struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HostActionSpec   `json:"spec,omitempty"`
+	Status HostActionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// HostActionList contains a list of HostAction
+type HostActionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HostAction `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HostAction{}, &HostActionList{})
+}
@@ -0,0 +1,137 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NOTE(dhellmann): Update docs/api.md when changing these data structure.
+
+// HostQuotaSpec defines the limits of a namespace.
+type HostQuotaSpec struct {
+	// MaxProvisioned is the number of hosts of the namespace that may
+	// have an image to provision. No limit is applied when it is not
+	// set.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxProvisioned *int `json:"maxProvisioned,omitempty"`
+
+	// MaxClaimed is the number of hosts, in any namespace, that
+	// consumers in the namespace may claim. No limit is applied when
+	// it is not set.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxClaimed *int `json:"maxClaimed,omitempty"`
+}
+
+// HostQuotaStatus reports the usage of a namespace.
+type HostQuotaStatus struct {
+	// Provisioned is the number of hosts of the namespace that have
+	// an image to provision
+	Provisioned int `json:"provisioned"`
+
+	// Claimed is the number of hosts claimed by consumers in the
+	// namespace
+	Claimed int `json:"claimed"`
+
+	// LastUpdated identifies when the usage was last computed
+	// +optional
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// HostQuota limits the number of hosts a namespace may provision or
+// claim from shared pools. Every quota of a namespace is enforced.
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:path=hostquotas,shortName=hq
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Provisioned",type="integer",JSONPath=".status.provisioned",description="Hosts with an image"
+// +kubebuilder:printcolumn:name="Max Provisioned",type="integer",JSONPath=".spec.maxProvisioned",description="Limit of hosts with an image"
+// +kubebuilder:printcolumn:name="Claimed",type="integer",JSONPath=".status.claimed",description="Hosts claimed by consumers"
+// +kubebuilder:printcolumn:name="Max Claimed",type="integer",JSONPath=".spec.maxClaimed",description="Limit of hosts claimed by consumers"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type HostQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HostQuotaSpec   `json:"spec,omitempty"`
+	Status HostQuotaStatus `json:"status,omitempty"`
+}
+
+// CountsAsProvisioned reports whether the host has an image to
+// provision, and so uses the provisioned quota of its namespace.
+// Hosts being decommissioned or retired do not count.
+func (host *BareMetalHost) CountsAsProvisioned() bool {
+	return host.Spec.Image != nil && host.Spec.Image.URL != "" && !host.Spec.Decommission && !host.Spec.Retire
+}
+
+// ConsumerNamespace returns the namespace of the consumer of the host,
+// whose claimed quota it uses, or an empty string if the host is not
+// claimed.
+func (host *BareMetalHost) ConsumerNamespace() string {
+	if host.Spec.ConsumerRef == nil {
+		return ""
+	}
+	if host.Spec.ConsumerRef.Namespace != "" {
+		return host.Spec.ConsumerRef.Namespace
+	}
+	return host.Namespace
+}
+
+// ConsumerNamespaceField is the name of the cache index of the hosts
+// by the namespace of their consumer, used to count the hosts claimed
+// by a namespace without listing every host.
+const ConsumerNamespaceField = "spec.consumerRef.namespace"
+
+// IndexConsumerNamespace returns the value of ConsumerNamespaceField
+// for a host.
+func IndexConsumerNamespace(obj client.Object) []string {
+	host, ok := obj.(*BareMetalHost)
+	if !ok || host.ConsumerNamespace() == "" {
+		return nil
+	}
+	return []string{host.ConsumerNamespace()}
+}
+
+// HostQuotaUsage counts the hosts using the quotas of the namespace.
+func HostQuotaUsage(namespace string, hosts []BareMetalHost) (provisioned, claimed int) {
+	for i := range hosts {
+		if hosts[i].Namespace == namespace && hosts[i].CountsAsProvisioned() {
+			provisioned++
+		}
+		if hosts[i].ConsumerNamespace() == namespace {
+			claimed++
+		}
+	}
+	return
+}
+
+// +kubebuilder:object:root=true
+
+// HostQuotaList contains a list of HostQuota
+type HostQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HostQuota `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HostQuota{}, &HostQuotaList{})
+}
@@ -0,0 +1,125 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NOTE(dhellmann): Update docs/api.md when changing these data structure.
+
+const (
+	// HostReportClusterLabel and HostReportNamespaceLabel are set on
+	// host reports to the cluster and namespace of the host.
+	HostReportClusterLabel   = "metal3.io/report-cluster"
+	HostReportNamespaceLabel = "metal3.io/report-namespace"
+)
+
+// HostReportSpec is a condensed copy of the status of a host.
+type HostReportSpec struct {
+	// Cluster is the name of the cluster of the host.
+	Cluster string `json:"cluster"`
+
+	// HostNamespace and HostName identify the host in its cluster.
+	HostNamespace string `json:"hostNamespace"`
+	HostName      string `json:"hostName"`
+
+	// ProvisioningState is the provisioning state of the host.
+	// +optional
+	ProvisioningState ProvisioningState `json:"provisioningState,omitempty"`
+
+	// OperationalStatus is the operational status of the host.
+	// +optional
+	OperationalStatus OperationalStatus `json:"operationalStatus,omitempty"`
+
+	// ErrorType and ErrorMessage are the last error of the host.
+	// +optional
+	ErrorType ErrorType `json:"errorType,omitempty"`
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+
+	// Online is whether the host should be powered on, and PoweredOn
+	// whether it is.
+	Online    bool `json:"online"`
+	PoweredOn bool `json:"poweredOn"`
+
+	// Consumer names the object using the host, as
+	// kind/namespace/name.
+	// +optional
+	Consumer string `json:"consumer,omitempty"`
+
+	// Image is the URL of the image provisioned on the host.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Manufacturer, ProductName and SerialNumber are the system
+	// vendor of the inspected hardware.
+	// +optional
+	Manufacturer string `json:"manufacturer,omitempty"`
+	// +optional
+	ProductName string `json:"productName,omitempty"`
+	// +optional
+	SerialNumber string `json:"serialNumber,omitempty"`
+
+	// CPUCount and RAMMebibytes summarize the inspected hardware.
+	// +optional
+	CPUCount int `json:"cpuCount,omitempty"`
+	// +optional
+	RAMMebibytes int `json:"ramMebibytes,omitempty"`
+
+	// Conditions are the conditions of the host.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// LastUpdated is the last time the report was written, that is
+	// the last time one of the other fields changed.
+	// +optional
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// HostReport is a read-only summary of a host of another cluster,
+// written by the operator of that cluster so that the hosts of many
+// clusters can be watched from a central reporting cluster.
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:path=hostreports,shortName=hr
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.cluster",description="Cluster of the host"
+// +kubebuilder:printcolumn:name="Namespace",type="string",JSONPath=".spec.hostNamespace",description="Namespace of the host"
+// +kubebuilder:printcolumn:name="Host",type="string",JSONPath=".spec.hostName",description="Name of the host"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".spec.provisioningState",description="Provisioning state of the host"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".spec.operationalStatus",description="Operational status of the host"
+// +kubebuilder:printcolumn:name="Error",type="string",JSONPath=".spec.errorType",description="Type of the last error"
+type HostReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HostReportSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// HostReportList contains a list of HostReport
+type HostReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HostReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HostReport{}, &HostReportList{})
+}
@@ -21,10 +21,57 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Accelerator) DeepCopyInto(out *Accelerator) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Accelerator.
+func (in *Accelerator) DeepCopy() *Accelerator {
+	if in == nil {
+		return nil
+	}
+	out := new(Accelerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentVersions) DeepCopyInto(out *AgentVersions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentVersions.
+func (in *AgentVersions) DeepCopy() *AgentVersions {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentVersions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnnotationMigration) DeepCopyInto(out *AnnotationMigration) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnnotationMigration.
+func (in *AnnotationMigration) DeepCopy() *AnnotationMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(AnnotationMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BIOS) DeepCopyInto(out *BIOS) {
 	*out = *in
@@ -119,12 +166,17 @@ func (in *BareMetalHostSpec) DeepCopyInto(out *BareMetalHostSpec) {
 	*out = *in
 	if in.Taints != nil {
 		in, out := &in.Taints, &out.Taints
-		*out = make([]v1.Taint, len(*in))
+		*out = make([]corev1.Taint, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	out.BMC = in.BMC
+	if in.NodeInterfaces != nil {
+		in, out := &in.NodeInterfaces, &out.NodeInterfaces
+		*out = new(NodeInterfaces)
+		**out = **in
+	}
 	if in.RAID != nil {
 		in, out := &in.RAID, &out.RAID
 		*out = new(RAIDConfig)
@@ -135,9 +187,34 @@ func (in *BareMetalHostSpec) DeepCopyInto(out *BareMetalHostSpec) {
 		*out = new(RootDeviceHints)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.BootFallback != nil {
+		in, out := &in.BootFallback, &out.BootFallback
+		*out = new(BootFallback)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImageDownloadLimitMbps != nil {
+		in, out := &in.ImageDownloadLimitMbps, &out.ImageDownloadLimitMbps
+		*out = new(int)
+		**out = **in
+	}
+	if in.AgentKernelArgs != nil {
+		in, out := &in.AgentKernelArgs, &out.AgentKernelArgs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PowerPolicy != nil {
+		in, out := &in.PowerPolicy, &out.PowerPolicy
+		*out = new(PowerPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ConsumerRef != nil {
 		in, out := &in.ConsumerRef, &out.ConsumerRef
-		*out = new(v1.ObjectReference)
+		*out = new(corev1.ObjectReference)
 		**out = **in
 	}
 	if in.Image != nil {
@@ -147,19 +224,81 @@ func (in *BareMetalHostSpec) DeepCopyInto(out *BareMetalHostSpec) {
 	}
 	if in.UserData != nil {
 		in, out := &in.UserData, &out.UserData
-		*out = new(v1.SecretReference)
+		*out = new(corev1.SecretReference)
 		**out = **in
 	}
+	if in.UserDataSecrets != nil {
+		in, out := &in.UserDataSecrets, &out.UserDataSecrets
+		*out = make([]corev1.SecretReference, len(*in))
+		copy(*out, *in)
+	}
 	if in.NetworkData != nil {
 		in, out := &in.NetworkData, &out.NetworkData
-		*out = new(v1.SecretReference)
+		*out = new(corev1.SecretReference)
 		**out = **in
 	}
 	if in.MetaData != nil {
 		in, out := &in.MetaData, &out.MetaData
-		*out = new(v1.SecretReference)
+		*out = new(corev1.SecretReference)
+		**out = **in
+	}
+	if in.MetaDataTemplate != nil {
+		in, out := &in.MetaDataTemplate, &out.MetaDataTemplate
+		*out = new(MetaDataTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SSHAuthorizedKeys != nil {
+		in, out := &in.SSHAuthorizedKeys, &out.SSHAuthorizedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConfigDriveEncryption != nil {
+		in, out := &in.ConfigDriveEncryption, &out.ConfigDriveEncryption
+		*out = new(ConfigDriveEncryption)
+		**out = **in
+	}
+	if in.CustomDeploy != nil {
+		in, out := &in.CustomDeploy, &out.CustomDeploy
+		*out = new(CustomDeploy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Reinspection != nil {
+		in, out := &in.Reinspection, &out.Reinspection
+		*out = new(ReinspectionPolicy)
+		**out = **in
+	}
+	if in.MaintenanceWindow != nil {
+		in, out := &in.MaintenanceWindow, &out.MaintenanceWindow
+		*out = new(MaintenanceWindow)
 		**out = **in
 	}
+	if in.Inspection != nil {
+		in, out := &in.Inspection, &out.Inspection
+		*out = new(InspectionSettings)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Timeouts != nil {
+		in, out := &in.Timeouts, &out.Timeouts
+		*out = new(OperationTimeouts)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecureBootDatabases != nil {
+		in, out := &in.SecureBootDatabases, &out.SecureBootDatabases
+		*out = make([]SecureBootDatabaseUpdate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SecureEraseBypass != nil {
+		in, out := &in.SecureEraseBypass, &out.SecureEraseBypass
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Operational != nil {
+		in, out := &in.Operational, &out.Operational
+		*out = new(OperationalMetadata)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalHostSpec.
@@ -187,7 +326,117 @@ func (in *BareMetalHostStatus) DeepCopyInto(out *BareMetalHostStatus) {
 	in.Provisioning.DeepCopyInto(&out.Provisioning)
 	in.GoodCredentials.DeepCopyInto(&out.GoodCredentials)
 	in.TriedCredentials.DeepCopyInto(&out.TriedCredentials)
+	if in.LastExternalPowerChange != nil {
+		in, out := &in.LastExternalPowerChange, &out.LastExternalPowerChange
+		*out = (*in).DeepCopy()
+	}
+	if in.LastProvisionerTimeout != nil {
+		in, out := &in.LastProvisionerTimeout, &out.LastProvisionerTimeout
+		*out = new(ProvisionerTimeout)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AvailableSince != nil {
+		in, out := &in.AvailableSince, &out.AvailableSince
+		*out = (*in).DeepCopy()
+	}
+	if in.PowerChangeStarted != nil {
+		in, out := &in.PowerChangeStarted, &out.PowerChangeStarted
+		*out = (*in).DeepCopy()
+	}
 	in.OperationHistory.DeepCopyInto(&out.OperationHistory)
+	if in.AgentVersions != nil {
+		in, out := &in.AgentVersions, &out.AgentVersions
+		*out = new(AgentVersions)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(MaintenanceStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OutOfBandInspection != nil {
+		in, out := &in.OutOfBandInspection, &out.OutOfBandInspection
+		*out = new(OutOfBandInspectionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecureBootDatabases != nil {
+		in, out := &in.SecureBootDatabases, &out.SecureBootDatabases
+		*out = make([]SecureBootDatabaseStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ServicingHistory != nil {
+		in, out := &in.ServicingHistory, &out.ServicingHistory
+		*out = make([]ServicingRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AnnotationMigrations != nil {
+		in, out := &in.AnnotationMigrations, &out.AnnotationMigrations
+		*out = make([]AnnotationMigration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VirtualMedia != nil {
+		in, out := &in.VirtualMedia, &out.VirtualMedia
+		*out = new(VirtualMediaSupport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BootCleanup != nil {
+		in, out := &in.BootCleanup, &out.BootCleanup
+		*out = new(BootCleanupStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BootProtection != nil {
+		in, out := &in.BootProtection, &out.BootProtection
+		*out = new(BootProtectionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Refreshed != nil {
+		in, out := &in.Refreshed, &out.Refreshed
+		*out = new(StatusRefreshTimes)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Cleaning != nil {
+		in, out := &in.Cleaning, &out.Cleaning
+		*out = new(CleaningStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Decommission != nil {
+		in, out := &in.Decommission, &out.Decommission
+		*out = new(DecommissionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Retirement != nil {
+		in, out := &in.Retirement, &out.Retirement
+		*out = new(RetirementStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AcceptanceFailures != nil {
+		in, out := &in.AcceptanceFailures, &out.AcceptanceFailures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FirmwareViolations != nil {
+		in, out := &in.FirmwareViolations, &out.FirmwareViolations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalHostStatus.
@@ -201,208 +450,1402 @@ func (in *BareMetalHostStatus) DeepCopy() *BareMetalHostStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CPU) DeepCopyInto(out *CPU) {
+func (in *BenchmarkResults) DeepCopyInto(out *BenchmarkResults) {
 	*out = *in
-	if in.Flags != nil {
-		in, out := &in.Flags, &out.Flags
-		*out = make([]string, len(*in))
+	if in.Disks != nil {
+		in, out := &in.Disks, &out.Disks
+		*out = make([]DiskBenchmarkResult, len(*in))
 		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CPU.
-func (in *CPU) DeepCopy() *CPU {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BenchmarkResults.
+func (in *BenchmarkResults) DeepCopy() *BenchmarkResults {
 	if in == nil {
 		return nil
 	}
-	out := new(CPU)
+	out := new(BenchmarkResults)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CredentialsStatus) DeepCopyInto(out *CredentialsStatus) {
+func (in *BootCleanupStatus) DeepCopyInto(out *BootCleanupStatus) {
 	*out = *in
-	if in.Reference != nil {
-		in, out := &in.Reference, &out.Reference
-		*out = new(v1.SecretReference)
-		**out = **in
+	in.Verified.DeepCopyInto(&out.Verified)
+	if in.Residue != nil {
+		in, out := &in.Residue, &out.Residue
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsStatus.
-func (in *CredentialsStatus) DeepCopy() *CredentialsStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootCleanupStatus.
+func (in *BootCleanupStatus) DeepCopy() *BootCleanupStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(CredentialsStatus)
+	out := new(BootCleanupStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Firmware) DeepCopyInto(out *Firmware) {
+func (in *BootFallback) DeepCopyInto(out *BootFallback) {
 	*out = *in
-	out.BIOS = in.BIOS
+	if in.MACAddresses != nil {
+		in, out := &in.MACAddresses, &out.MACAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Firmware.
-func (in *Firmware) DeepCopy() *Firmware {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootFallback.
+func (in *BootFallback) DeepCopy() *BootFallback {
 	if in == nil {
 		return nil
 	}
-	out := new(Firmware)
+	out := new(BootFallback)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HardwareDetails) DeepCopyInto(out *HardwareDetails) {
+func (in *BootProtectionStatus) DeepCopyInto(out *BootProtectionStatus) {
 	*out = *in
-	out.SystemVendor = in.SystemVendor
-	out.Firmware = in.Firmware
-	if in.NIC != nil {
-		in, out := &in.NIC, &out.NIC
-		*out = make([]NIC, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.Storage != nil {
-		in, out := &in.Storage, &out.Storage
-		*out = make([]Storage, len(*in))
+	in.Verified.DeepCopyInto(&out.Verified)
+	if in.Corrected != nil {
+		in, out := &in.Corrected, &out.Corrected
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	in.CPU.DeepCopyInto(&out.CPU)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareDetails.
-func (in *HardwareDetails) DeepCopy() *HardwareDetails {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootProtectionStatus.
+func (in *BootProtectionStatus) DeepCopy() *BootProtectionStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(HardwareDetails)
+	out := new(BootProtectionStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HardwareRAIDVolume) DeepCopyInto(out *HardwareRAIDVolume) {
+func (in *CPU) DeepCopyInto(out *CPU) {
 	*out = *in
-	if in.SizeGibibytes != nil {
-		in, out := &in.SizeGibibytes, &out.SizeGibibytes
-		*out = new(int)
-		**out = **in
+	if in.Flags != nil {
+		in, out := &in.Flags, &out.Flags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	if in.Rotational != nil {
-		in, out := &in.Rotational, &out.Rotational
-		*out = new(bool)
-		**out = **in
+	if in.HugepageSizes != nil {
+		in, out := &in.HugepageSizes, &out.HugepageSizes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	if in.NumberOfPhysicalDisks != nil {
-		in, out := &in.NumberOfPhysicalDisks, &out.NumberOfPhysicalDisks
-		*out = new(int)
-		**out = **in
+	if in.NUMANodes != nil {
+		in, out := &in.NUMANodes, &out.NUMANodes
+		*out = make([]NUMANode, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareRAIDVolume.
-func (in *HardwareRAIDVolume) DeepCopy() *HardwareRAIDVolume {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CPU.
+func (in *CPU) DeepCopy() *CPU {
 	if in == nil {
 		return nil
 	}
-	out := new(HardwareRAIDVolume)
+	out := new(CPU)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HardwareSystemVendor) DeepCopyInto(out *HardwareSystemVendor) {
+func (in *CleaningStatus) DeepCopyInto(out *CleaningStatus) {
 	*out = *in
+	if in.StepStarted != nil {
+		in, out := &in.StepStarted, &out.StepStarted
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletedSteps != nil {
+		in, out := &in.CompletedSteps, &out.CompletedSteps
+		*out = make([]CompletedCleanStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EstimatedEraseCompletion != nil {
+		in, out := &in.EstimatedEraseCompletion, &out.EstimatedEraseCompletion
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareSystemVendor.
-func (in *HardwareSystemVendor) DeepCopy() *HardwareSystemVendor {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CleaningStatus.
+func (in *CleaningStatus) DeepCopy() *CleaningStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(HardwareSystemVendor)
+	out := new(CleaningStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Image) DeepCopyInto(out *Image) {
+func (in *CompletedCleanStep) DeepCopyInto(out *CompletedCleanStep) {
 	*out = *in
-	if in.DiskFormat != nil {
-		in, out := &in.DiskFormat, &out.DiskFormat
-		*out = new(string)
-		**out = **in
-	}
+	in.Started.DeepCopyInto(&out.Started)
+	out.Duration = in.Duration
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Image.
-func (in *Image) DeepCopy() *Image {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompletedCleanStep.
+func (in *CompletedCleanStep) DeepCopy() *CompletedCleanStep {
 	if in == nil {
 		return nil
 	}
-	out := new(Image)
+	out := new(CompletedCleanStep)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NIC) DeepCopyInto(out *NIC) {
+func (in *ConfigDriveEncryption) DeepCopyInto(out *ConfigDriveEncryption) {
 	*out = *in
-	if in.VLANs != nil {
-		in, out := &in.VLANs, &out.VLANs
-		*out = make([]VLAN, len(*in))
-		copy(*out, *in)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NIC.
-func (in *NIC) DeepCopy() *NIC {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigDriveEncryption.
+func (in *ConfigDriveEncryption) DeepCopy() *ConfigDriveEncryption {
 	if in == nil {
 		return nil
 	}
-	out := new(NIC)
+	out := new(ConfigDriveEncryption)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OperationHistory) DeepCopyInto(out *OperationHistory) {
+func (in *CredentialsStatus) DeepCopyInto(out *CredentialsStatus) {
 	*out = *in
-	in.Register.DeepCopyInto(&out.Register)
-	in.Inspect.DeepCopyInto(&out.Inspect)
-	in.Provision.DeepCopyInto(&out.Provision)
-	in.Deprovision.DeepCopyInto(&out.Deprovision)
+	if in.Reference != nil {
+		in, out := &in.Reference, &out.Reference
+		*out = new(corev1.SecretReference)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationHistory.
-func (in *OperationHistory) DeepCopy() *OperationHistory {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsStatus.
+func (in *CredentialsStatus) DeepCopy() *CredentialsStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(OperationHistory)
+	out := new(CredentialsStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OperationMetric) DeepCopyInto(out *OperationMetric) {
+func (in *CustomDeploy) DeepCopyInto(out *CustomDeploy) {
 	*out = *in
-	in.Start.DeepCopyInto(&out.Start)
-	in.End.DeepCopyInto(&out.End)
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]DeployStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationMetric.
-func (in *OperationMetric) DeepCopy() *OperationMetric {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDeploy.
+func (in *CustomDeploy) DeepCopy() *CustomDeploy {
 	if in == nil {
 		return nil
 	}
-	out := new(OperationMetric)
+	out := new(CustomDeploy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DecommissionStatus) DeepCopyInto(out *DecommissionStatus) {
+	*out = *in
+	if in.EraseStarted != nil {
+		in, out := &in.EraseStarted, &out.EraseStarted
+		*out = (*in).DeepCopy()
+	}
+	if in.EraseFinished != nil {
+		in, out := &in.EraseFinished, &out.EraseFinished
+		*out = (*in).DeepCopy()
+	}
+	if in.EraseSteps != nil {
+		in, out := &in.EraseSteps, &out.EraseSteps
+		*out = make([]CompletedCleanStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SecureEraseBypassed != nil {
+		in, out := &in.SecureEraseBypassed, &out.SecureEraseBypassed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MetadataWipeStarted != nil {
+		in, out := &in.MetadataWipeStarted, &out.MetadataWipeStarted
+		*out = (*in).DeepCopy()
+	}
+	if in.MetadataWipeFinished != nil {
+		in, out := &in.MetadataWipeFinished, &out.MetadataWipeFinished
+		*out = (*in).DeepCopy()
+	}
+	if in.MetadataWipeSteps != nil {
+		in, out := &in.MetadataWipeSteps, &out.MetadataWipeSteps
+		*out = make([]CompletedCleanStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DecommissionStatus.
+func (in *DecommissionStatus) DeepCopy() *DecommissionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DecommissionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeployStep) DeepCopyInto(out *DeployStep) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeployStep.
+func (in *DeployStep) DeepCopy() *DeployStep {
+	if in == nil {
+		return nil
+	}
+	out := new(DeployStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskBenchmarkResult) DeepCopyInto(out *DiskBenchmarkResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskBenchmarkResult.
+func (in *DiskBenchmarkResult) DeepCopy() *DiskBenchmarkResult {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskBenchmarkResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskHealth) DeepCopyInto(out *DiskHealth) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskHealth.
+func (in *DiskHealth) DeepCopy() *DiskHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriverInterfaces) DeepCopyInto(out *DriverInterfaces) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriverInterfaces.
+func (in *DriverInterfaces) DeepCopy() *DriverInterfaces {
+	if in == nil {
+		return nil
+	}
+	out := new(DriverInterfaces)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriverStatus) DeepCopyInto(out *DriverStatus) {
+	*out = *in
+	out.Interfaces = in.Interfaces
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriverStatus.
+func (in *DriverStatus) DeepCopy() *DriverStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DriverStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Firmware) DeepCopyInto(out *Firmware) {
+	*out = *in
+	out.BIOS = in.BIOS
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Firmware.
+func (in *Firmware) DeepCopy() *Firmware {
+	if in == nil {
+		return nil
+	}
+	out := new(Firmware)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirmwareBaseline) DeepCopyInto(out *FirmwareBaseline) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirmwareBaseline.
+func (in *FirmwareBaseline) DeepCopy() *FirmwareBaseline {
+	if in == nil {
+		return nil
+	}
+	out := new(FirmwareBaseline)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FirmwareBaseline) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirmwareBaselineList) DeepCopyInto(out *FirmwareBaselineList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FirmwareBaseline, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirmwareBaselineList.
+func (in *FirmwareBaselineList) DeepCopy() *FirmwareBaselineList {
+	if in == nil {
+		return nil
+	}
+	out := new(FirmwareBaselineList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FirmwareBaselineList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirmwareBaselineSpec) DeepCopyInto(out *FirmwareBaselineSpec) {
+	*out = *in
+	if in.Models != nil {
+		in, out := &in.Models, &out.Models
+		*out = make([]ModelFirmwareBaseline, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirmwareBaselineSpec.
+func (in *FirmwareBaselineSpec) DeepCopy() *FirmwareBaselineSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FirmwareBaselineSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirmwareBaselineStatus) DeepCopyInto(out *FirmwareBaselineStatus) {
+	*out = *in
+	if in.LastUpdated != nil {
+		in, out := &in.LastUpdated, &out.LastUpdated
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirmwareBaselineStatus.
+func (in *FirmwareBaselineStatus) DeepCopy() *FirmwareBaselineStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FirmwareBaselineStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareDataSnapshot) DeepCopyInto(out *HardwareDataSnapshot) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareDataSnapshot.
+func (in *HardwareDataSnapshot) DeepCopy() *HardwareDataSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareDataSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HardwareDataSnapshot) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareDataSnapshotList) DeepCopyInto(out *HardwareDataSnapshotList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HardwareDataSnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareDataSnapshotList.
+func (in *HardwareDataSnapshotList) DeepCopy() *HardwareDataSnapshotList {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareDataSnapshotList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HardwareDataSnapshotList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareDataSnapshotSpec) DeepCopyInto(out *HardwareDataSnapshotSpec) {
+	*out = *in
+	in.Inspected.DeepCopyInto(&out.Inspected)
+	in.HardwareDetails.DeepCopyInto(&out.HardwareDetails)
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareDataSnapshotSpec.
+func (in *HardwareDataSnapshotSpec) DeepCopy() *HardwareDataSnapshotSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareDataSnapshotSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareDetails) DeepCopyInto(out *HardwareDetails) {
+	*out = *in
+	out.SystemVendor = in.SystemVendor
+	out.Firmware = in.Firmware
+	if in.NIC != nil {
+		in, out := &in.NIC, &out.NIC
+		*out = make([]NIC, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = make([]Storage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.CPU.DeepCopyInto(&out.CPU)
+	if in.NICMismatches != nil {
+		in, out := &in.NICMismatches, &out.NICMismatches
+		*out = make([]NICMismatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Benchmarks != nil {
+		in, out := &in.Benchmarks, &out.Benchmarks
+		*out = new(BenchmarkResults)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Accelerators != nil {
+		in, out := &in.Accelerators, &out.Accelerators
+		*out = make([]Accelerator, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareDetails.
+func (in *HardwareDetails) DeepCopy() *HardwareDetails {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareDetails)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareRAIDVolume) DeepCopyInto(out *HardwareRAIDVolume) {
+	*out = *in
+	if in.SizeGibibytes != nil {
+		in, out := &in.SizeGibibytes, &out.SizeGibibytes
+		*out = new(int)
+		**out = **in
+	}
+	if in.Rotational != nil {
+		in, out := &in.Rotational, &out.Rotational
+		*out = new(bool)
+		**out = **in
+	}
+	if in.NumberOfPhysicalDisks != nil {
+		in, out := &in.NumberOfPhysicalDisks, &out.NumberOfPhysicalDisks
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareRAIDVolume.
+func (in *HardwareRAIDVolume) DeepCopy() *HardwareRAIDVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareRAIDVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareSystemVendor) DeepCopyInto(out *HardwareSystemVendor) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareSystemVendor.
+func (in *HardwareSystemVendor) DeepCopy() *HardwareSystemVendor {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareSystemVendor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostAcceptanceTest) DeepCopyInto(out *HostAcceptanceTest) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostAcceptanceTest.
+func (in *HostAcceptanceTest) DeepCopy() *HostAcceptanceTest {
+	if in == nil {
+		return nil
+	}
+	out := new(HostAcceptanceTest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HostAcceptanceTest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostAcceptanceTestList) DeepCopyInto(out *HostAcceptanceTestList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HostAcceptanceTest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostAcceptanceTestList.
+func (in *HostAcceptanceTestList) DeepCopy() *HostAcceptanceTestList {
+	if in == nil {
+		return nil
+	}
+	out := new(HostAcceptanceTestList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HostAcceptanceTestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostAcceptanceTestSpec) DeepCopyInto(out *HostAcceptanceTestSpec) {
+	*out = *in
+	if in.HostSelector != nil {
+		in, out := &in.HostSelector, &out.HostSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MinRAMMebibytes != nil {
+		in, out := &in.MinRAMMebibytes, &out.MinRAMMebibytes
+		*out = new(int)
+		**out = **in
+	}
+	if in.DiskCount != nil {
+		in, out := &in.DiskCount, &out.DiskCount
+		*out = new(int)
+		**out = **in
+	}
+	if in.MinNICSpeedGbps != nil {
+		in, out := &in.MinNICSpeedGbps, &out.MinNICSpeedGbps
+		*out = new(int)
+		**out = **in
+	}
+	if in.BIOSVersion != nil {
+		in, out := &in.BIOSVersion, &out.BIOSVersion
+		*out = new(VersionRange)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostAcceptanceTestSpec.
+func (in *HostAcceptanceTestSpec) DeepCopy() *HostAcceptanceTestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HostAcceptanceTestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostAcceptanceTestStatus) DeepCopyInto(out *HostAcceptanceTestStatus) {
+	*out = *in
+	if in.LastUpdated != nil {
+		in, out := &in.LastUpdated, &out.LastUpdated
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostAcceptanceTestStatus.
+func (in *HostAcceptanceTestStatus) DeepCopy() *HostAcceptanceTestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HostAcceptanceTestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostAction) DeepCopyInto(out *HostAction) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostAction.
+func (in *HostAction) DeepCopy() *HostAction {
+	if in == nil {
+		return nil
+	}
+	out := new(HostAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HostAction) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostActionList) DeepCopyInto(out *HostActionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HostAction, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostActionList.
+func (in *HostActionList) DeepCopy() *HostActionList {
+	if in == nil {
+		return nil
+	}
+	out := new(HostActionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HostActionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostActionSpec) DeepCopyInto(out *HostActionSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostActionSpec.
+func (in *HostActionSpec) DeepCopy() *HostActionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HostActionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostActionStatus) DeepCopyInto(out *HostActionStatus) {
+	*out = *in
+	if in.Applied != nil {
+		in, out := &in.Applied, &out.Applied
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostActionStatus.
+func (in *HostActionStatus) DeepCopy() *HostActionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HostActionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostQuota) DeepCopyInto(out *HostQuota) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostQuota.
+func (in *HostQuota) DeepCopy() *HostQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(HostQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HostQuota) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostQuotaList) DeepCopyInto(out *HostQuotaList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HostQuota, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostQuotaList.
+func (in *HostQuotaList) DeepCopy() *HostQuotaList {
+	if in == nil {
+		return nil
+	}
+	out := new(HostQuotaList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HostQuotaList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostQuotaSpec) DeepCopyInto(out *HostQuotaSpec) {
+	*out = *in
+	if in.MaxProvisioned != nil {
+		in, out := &in.MaxProvisioned, &out.MaxProvisioned
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxClaimed != nil {
+		in, out := &in.MaxClaimed, &out.MaxClaimed
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostQuotaSpec.
+func (in *HostQuotaSpec) DeepCopy() *HostQuotaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HostQuotaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostQuotaStatus) DeepCopyInto(out *HostQuotaStatus) {
+	*out = *in
+	if in.LastUpdated != nil {
+		in, out := &in.LastUpdated, &out.LastUpdated
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostQuotaStatus.
+func (in *HostQuotaStatus) DeepCopy() *HostQuotaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HostQuotaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostReport) DeepCopyInto(out *HostReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostReport.
+func (in *HostReport) DeepCopy() *HostReport {
+	if in == nil {
+		return nil
+	}
+	out := new(HostReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HostReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostReportList) DeepCopyInto(out *HostReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HostReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostReportList.
+func (in *HostReportList) DeepCopy() *HostReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(HostReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HostReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostReportSpec) DeepCopyInto(out *HostReportSpec) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastUpdated != nil {
+		in, out := &in.LastUpdated, &out.LastUpdated
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostReportSpec.
+func (in *HostReportSpec) DeepCopy() *HostReportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HostReportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Image) DeepCopyInto(out *Image) {
+	*out = *in
+	if in.DiskFormat != nil {
+		in, out := &in.DiskFormat, &out.DiskFormat
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Image.
+func (in *Image) DeepCopy() *Image {
+	if in == nil {
+		return nil
+	}
+	out := new(Image)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InspectionSettings) DeepCopyInto(out *InspectionSettings) {
+	*out = *in
+	if in.HardwareDetails != nil {
+		in, out := &in.HardwareDetails, &out.HardwareDetails
+		*out = new(HardwareDetails)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Collectors != nil {
+		in, out := &in.Collectors, &out.Collectors
+		*out = make([]InspectionCollector, len(*in))
+		copy(*out, *in)
+	}
+	if in.Benchmarks != nil {
+		in, out := &in.Benchmarks, &out.Benchmarks
+		*out = make([]InspectionBenchmark, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InspectionSettings.
+func (in *InspectionSettings) DeepCopy() *InspectionSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(InspectionSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceStatus) DeepCopyInto(out *MaintenanceStatus) {
+	*out = *in
+	if in.Pending != nil {
+		in, out := &in.Pending, &out.Pending
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NextWindow != nil {
+		in, out := &in.NextWindow, &out.NextWindow
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceStatus.
+func (in *MaintenanceStatus) DeepCopy() *MaintenanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetaDataTemplate) DeepCopyInto(out *MetaDataTemplate) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetaDataTemplate.
+func (in *MetaDataTemplate) DeepCopy() *MetaDataTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(MetaDataTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelFirmwareBaseline) DeepCopyInto(out *ModelFirmwareBaseline) {
+	*out = *in
+	if in.NICs != nil {
+		in, out := &in.NICs, &out.NICs
+		*out = make([]NICFirmwareBaseline, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelFirmwareBaseline.
+func (in *ModelFirmwareBaseline) DeepCopy() *ModelFirmwareBaseline {
+	if in == nil {
+		return nil
+	}
+	out := new(ModelFirmwareBaseline)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NIC) DeepCopyInto(out *NIC) {
+	*out = *in
+	if in.VLANs != nil {
+		in, out := &in.VLANs, &out.VLANs
+		*out = make([]VLAN, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NIC.
+func (in *NIC) DeepCopy() *NIC {
+	if in == nil {
+		return nil
+	}
+	out := new(NIC)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NICFirmwareBaseline) DeepCopyInto(out *NICFirmwareBaseline) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NICFirmwareBaseline.
+func (in *NICFirmwareBaseline) DeepCopy() *NICFirmwareBaseline {
+	if in == nil {
+		return nil
+	}
+	out := new(NICFirmwareBaseline)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NICMismatch) DeepCopyInto(out *NICMismatch) {
+	*out = *in
+	if in.NICs != nil {
+		in, out := &in.NICs, &out.NICs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NICMismatch.
+func (in *NICMismatch) DeepCopy() *NICMismatch {
+	if in == nil {
+		return nil
+	}
+	out := new(NICMismatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NUMANode) DeepCopyInto(out *NUMANode) {
+	*out = *in
+	if in.CPUs != nil {
+		in, out := &in.CPUs, &out.CPUs
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.NICs != nil {
+		in, out := &in.NICs, &out.NICs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NUMANode.
+func (in *NUMANode) DeepCopy() *NUMANode {
+	if in == nil {
+		return nil
+	}
+	out := new(NUMANode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeInterfaces) DeepCopyInto(out *NodeInterfaces) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeInterfaces.
+func (in *NodeInterfaces) DeepCopy() *NodeInterfaces {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeInterfaces)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperationHistory) DeepCopyInto(out *OperationHistory) {
+	*out = *in
+	in.Register.DeepCopyInto(&out.Register)
+	in.Inspect.DeepCopyInto(&out.Inspect)
+	in.Prepare.DeepCopyInto(&out.Prepare)
+	in.Provision.DeepCopyInto(&out.Provision)
+	in.Deprovision.DeepCopyInto(&out.Deprovision)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationHistory.
+func (in *OperationHistory) DeepCopy() *OperationHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(OperationHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperationMetric) DeepCopyInto(out *OperationMetric) {
+	*out = *in
+	in.Start.DeepCopyInto(&out.Start)
+	in.End.DeepCopyInto(&out.End)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationMetric.
+func (in *OperationMetric) DeepCopy() *OperationMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(OperationMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperationTimeouts) DeepCopyInto(out *OperationTimeouts) {
+	*out = *in
+	if in.Inspection != nil {
+		in, out := &in.Inspection, &out.Inspection
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Provisioning != nil {
+		in, out := &in.Provisioning, &out.Provisioning
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Cleaning != nil {
+		in, out := &in.Cleaning, &out.Cleaning
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.PowerChange != nil {
+		in, out := &in.PowerChange, &out.PowerChange
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationTimeouts.
+func (in *OperationTimeouts) DeepCopy() *OperationTimeouts {
+	if in == nil {
+		return nil
+	}
+	out := new(OperationTimeouts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperationalMetadata) DeepCopyInto(out *OperationalMetadata) {
+	*out = *in
+	if in.WarrantyExpiry != nil {
+		in, out := &in.WarrantyExpiry, &out.WarrantyExpiry
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationalMetadata.
+func (in *OperationalMetadata) DeepCopy() *OperationalMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(OperationalMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutOfBandInspectionStatus) DeepCopyInto(out *OutOfBandInspectionStatus) {
+	*out = *in
+	if in.Time != nil {
+		in, out := &in.Time, &out.Time
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutOfBandInspectionStatus.
+func (in *OutOfBandInspectionStatus) DeepCopy() *OutOfBandInspectionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OutOfBandInspectionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PowerPolicy) DeepCopyInto(out *PowerPolicy) {
+	*out = *in
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.IdlePowerOffAfter != nil {
+		in, out := &in.IdlePowerOffAfter, &out.IdlePowerOffAfter
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PowerPolicy.
+func (in *PowerPolicy) DeepCopy() *PowerPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PowerPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -410,6 +1853,11 @@ func (in *OperationMetric) DeepCopy() *OperationMetric {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProvisionStatus) DeepCopyInto(out *ProvisionStatus) {
 	*out = *in
+	if in.Driver != nil {
+		in, out := &in.Driver, &out.Driver
+		*out = new(DriverStatus)
+		**out = **in
+	}
 	in.Image.DeepCopyInto(&out.Image)
 	if in.RootDeviceHints != nil {
 		in, out := &in.RootDeviceHints, &out.RootDeviceHints
@@ -433,6 +1881,23 @@ func (in *ProvisionStatus) DeepCopy() *ProvisionStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProvisionerTimeout) DeepCopyInto(out *ProvisionerTimeout) {
+	*out = *in
+	out.Timeout = in.Timeout
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisionerTimeout.
+func (in *ProvisionerTimeout) DeepCopy() *ProvisionerTimeout {
+	if in == nil {
+		return nil
+	}
+	out := new(ProvisionerTimeout)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RAIDConfig) DeepCopyInto(out *RAIDConfig) {
 	*out = *in
@@ -477,6 +1942,49 @@ func (in *RebootAnnotationArguments) DeepCopy() *RebootAnnotationArguments {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReinspectionPolicy) DeepCopyInto(out *ReinspectionPolicy) {
+	*out = *in
+	out.Interval = in.Interval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReinspectionPolicy.
+func (in *ReinspectionPolicy) DeepCopy() *ReinspectionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ReinspectionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetirementStatus) DeepCopyInto(out *RetirementStatus) {
+	*out = *in
+	if in.EraseStarted != nil {
+		in, out := &in.EraseStarted, &out.EraseStarted
+		*out = (*in).DeepCopy()
+	}
+	if in.EraseFinished != nil {
+		in, out := &in.EraseFinished, &out.EraseFinished
+		*out = (*in).DeepCopy()
+	}
+	if in.PoweredOff != nil {
+		in, out := &in.PoweredOff, &out.PoweredOff
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetirementStatus.
+func (in *RetirementStatus) DeepCopy() *RetirementStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RetirementStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RootDeviceHints) DeepCopyInto(out *RootDeviceHints) {
 	*out = *in
@@ -497,6 +2005,72 @@ func (in *RootDeviceHints) DeepCopy() *RootDeviceHints {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecureBootDatabaseStatus) DeepCopyInto(out *SecureBootDatabaseStatus) {
+	*out = *in
+	if in.Time != nil {
+		in, out := &in.Time, &out.Time
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecureBootDatabaseStatus.
+func (in *SecureBootDatabaseStatus) DeepCopy() *SecureBootDatabaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SecureBootDatabaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecureBootDatabaseUpdate) DeepCopyInto(out *SecureBootDatabaseUpdate) {
+	*out = *in
+	if in.Certificates != nil {
+		in, out := &in.Certificates, &out.Certificates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Signatures != nil {
+		in, out := &in.Signatures, &out.Signatures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecureBootDatabaseUpdate.
+func (in *SecureBootDatabaseUpdate) DeepCopy() *SecureBootDatabaseUpdate {
+	if in == nil {
+		return nil
+	}
+	out := new(SecureBootDatabaseUpdate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServicingRecord) DeepCopyInto(out *ServicingRecord) {
+	*out = *in
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Started.DeepCopyInto(&out.Started)
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServicingRecord.
+func (in *ServicingRecord) DeepCopy() *ServicingRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(ServicingRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SoftwareRAIDVolume) DeepCopyInto(out *SoftwareRAIDVolume) {
 	*out = *in
@@ -524,9 +2098,41 @@ func (in *SoftwareRAIDVolume) DeepCopy() *SoftwareRAIDVolume {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatusRefreshTimes) DeepCopyInto(out *StatusRefreshTimes) {
+	*out = *in
+	if in.Power != nil {
+		in, out := &in.Power, &out.Power
+		*out = (*in).DeepCopy()
+	}
+	if in.Provisioning != nil {
+		in, out := &in.Provisioning, &out.Provisioning
+		*out = (*in).DeepCopy()
+	}
+	if in.Hardware != nil {
+		in, out := &in.Hardware, &out.Hardware
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatusRefreshTimes.
+func (in *StatusRefreshTimes) DeepCopy() *StatusRefreshTimes {
+	if in == nil {
+		return nil
+	}
+	out := new(StatusRefreshTimes)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Storage) DeepCopyInto(out *Storage) {
 	*out = *in
+	if in.Health != nil {
+		in, out := &in.Health, &out.Health
+		*out = new(DiskHealth)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Storage.
@@ -553,3 +2159,39 @@ func (in *VLAN) DeepCopy() *VLAN {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VersionRange) DeepCopyInto(out *VersionRange) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VersionRange.
+func (in *VersionRange) DeepCopy() *VersionRange {
+	if in == nil {
+		return nil
+	}
+	out := new(VersionRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualMediaSupport) DeepCopyInto(out *VirtualMediaSupport) {
+	*out = *in
+	if in.HTTPS != nil {
+		in, out := &in.HTTPS, &out.HTTPS
+		*out = new(bool)
+		**out = **in
+	}
+	in.Detected.DeepCopyInto(&out.Detected)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualMediaSupport.
+func (in *VirtualMediaSupport) DeepCopy() *VirtualMediaSupport {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualMediaSupport)
+	in.DeepCopyInto(out)
+	return out
+}
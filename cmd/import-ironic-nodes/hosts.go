@@ -0,0 +1,356 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+)
+
+// bmcDetails is the BMC access of a node, as the BareMetalHost
+// describes it.
+type bmcDetails struct {
+	Address                        string
+	Username                       string
+	Password                       string
+	DisableCertificateVerification bool
+}
+
+// maskedPassword is the value Ironic returns instead of the passwords
+// in driver_info, unless its policy allows showing them.
+const maskedPassword = "******"
+
+// NodeCredentials are the BMC credentials given for a node.
+type NodeCredentials struct {
+	// Username replaces the user name in the driver_info of the
+	// node, when set.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password"`
+}
+
+// Credentials are the BMC credentials of the nodes. The password of a
+// node is taken from Nodes, then from its driver_info when Ironic
+// shows it, then from DefaultPassword.
+type Credentials struct {
+	// Nodes holds the credentials of nodes by name or UUID.
+	Nodes map[string]NodeCredentials
+	// DefaultPassword is the password of the nodes with no other.
+	DefaultPassword string
+}
+
+// forNode returns the credentials given for the node, if any.
+func (c Credentials) forNode(node nodes.Node) (NodeCredentials, bool) {
+	if node.Name != "" {
+		if creds, found := c.Nodes[node.Name]; found {
+			return creds, true
+		}
+	}
+	creds, found := c.Nodes[node.UUID]
+	return creds, found
+}
+
+// driverInfoString returns a driver_info value, which Ironic may hold
+// as a string or a number.
+func driverInfoString(node nodes.Node, key string) string {
+	value, found := node.DriverInfo[key]
+	if !found || value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// driverInfoPassword returns a password in driver_info, unless Ironic
+// masked it.
+func driverInfoPassword(node nodes.Node, key string) string {
+	if password := driverInfoString(node, key); password != maskedPassword {
+		return password
+	}
+	return ""
+}
+
+// verifyCADisabled reports whether certificate verification is turned
+// off by the driver_info key, which holds a boolean or the path of a
+// CA bundle.
+func verifyCADisabled(node nodes.Node, key string) bool {
+	verify, err := strconv.ParseBool(driverInfoString(node, key))
+	return err == nil && !verify
+}
+
+// hostPort joins a host and an optional port.
+func hostPort(host, port string) string {
+	if port != "" {
+		return net.JoinHostPort(host, port)
+	}
+	if strings.Contains(host, ":") {
+		return "[" + host + "]"
+	}
+	return host
+}
+
+// parseHTTPAddress parses an address that may have no scheme, in which
+// case HTTPS is used.
+func parseHTTPAddress(address string) (*url.URL, error) {
+	if !strings.Contains(address, "://") {
+		address = "https://" + address
+	}
+	parsedURL, err := url.Parse(address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse address %q", address)
+	}
+	if parsedURL.Host == "" {
+		return nil, errors.Errorf("no host in address %q", address)
+	}
+	return parsedURL, nil
+}
+
+// bmcType returns the BMC type for the scheme of the address, which
+// is implied for HTTPS.
+func bmcType(base string, parsedURL *url.URL) string {
+	if parsedURL.Scheme == "http" {
+		return base + "+http"
+	}
+	return base
+}
+
+func redfishBMC(node nodes.Node, base string) (bmcDetails, error) {
+	parsedURL, err := parseHTTPAddress(driverInfoString(node, "redfish_address"))
+	if err != nil {
+		return bmcDetails{}, err
+	}
+	return bmcDetails{
+		Address:                        bmcType(base, parsedURL) + "://" + parsedURL.Host + driverInfoString(node, "redfish_system_id"),
+		Username:                       driverInfoString(node, "redfish_username"),
+		Password:                       driverInfoPassword(node, "redfish_password"),
+		DisableCertificateVerification: verifyCADisabled(node, "redfish_verify_ca"),
+	}, nil
+}
+
+// nodeBMC maps the driver and the driver_info of a node to its BMC
+// access, the reverse of the DriverInfo methods of the BMC types.
+func nodeBMC(node nodes.Node) (details bmcDetails, err error) {
+	switch node.Driver {
+	case "ipmi":
+		details = bmcDetails{
+			Address: "ipmi://" + hostPort(driverInfoString(node, "ipmi_address"),
+				driverInfoString(node, "ipmi_port")),
+			Username:                       driverInfoString(node, "ipmi_username"),
+			Password:                       driverInfoPassword(node, "ipmi_password"),
+			DisableCertificateVerification: verifyCADisabled(node, "ipmi_verify_ca"),
+		}
+
+	case "redfish":
+		base := "redfish"
+		if node.BootInterface == "redfish-virtual-media" {
+			base = "redfish-virtualmedia"
+		}
+		details, err = redfishBMC(node, base)
+
+	case "idrac":
+		if driverInfoString(node, "redfish_address") != "" {
+			base := "idrac-redfish"
+			if node.BootInterface == "idrac-redfish-virtual-media" {
+				base = "idrac-virtualmedia"
+			}
+			details, err = redfishBMC(node, base)
+			break
+		}
+		scheme := "idrac"
+		if protocol := driverInfoString(node, "drac_protocol"); protocol != "" && protocol != "https" {
+			scheme += "+" + protocol
+		}
+		details = bmcDetails{
+			Address: scheme + "://" + hostPort(driverInfoString(node, "drac_address"),
+				driverInfoString(node, "drac_port")) + driverInfoString(node, "drac_path"),
+			Username:                       driverInfoString(node, "drac_username"),
+			Password:                       driverInfoPassword(node, "drac_password"),
+			DisableCertificateVerification: verifyCADisabled(node, "drac_verify_ca"),
+		}
+
+	case "ilo", "ilo5":
+		scheme := "ilo4"
+		if node.Driver == "ilo5" {
+			scheme = "ilo5"
+		}
+		details = bmcDetails{
+			Address: scheme + "://" + hostPort(driverInfoString(node, "ilo_address"),
+				driverInfoString(node, "client_port")),
+			Username:                       driverInfoString(node, "ilo_username"),
+			Password:                       driverInfoPassword(node, "ilo_password"),
+			DisableCertificateVerification: verifyCADisabled(node, "ilo_verify_ca"),
+		}
+
+	case "irmc":
+		details = bmcDetails{
+			Address: "irmc://" + hostPort(driverInfoString(node, "irmc_address"),
+				driverInfoString(node, "irmc_port")),
+			Username:                       driverInfoString(node, "irmc_username"),
+			Password:                       driverInfoPassword(node, "irmc_password"),
+			DisableCertificateVerification: verifyCADisabled(node, "irmc_verify_ca"),
+		}
+
+	case "ibmc":
+		var parsedURL *url.URL
+		parsedURL, err = parseHTTPAddress(driverInfoString(node, "ibmc_address"))
+		if err != nil {
+			break
+		}
+		details = bmcDetails{
+			Address:                        bmcType("ibmc", parsedURL) + "://" + parsedURL.Host + parsedURL.Path,
+			Username:                       driverInfoString(node, "ibmc_username"),
+			Password:                       driverInfoPassword(node, "ibmc_password"),
+			DisableCertificateVerification: verifyCADisabled(node, "ibmc_verify_ca"),
+		}
+
+	default:
+		return bmcDetails{}, errors.Errorf("unsupported driver %q", node.Driver)
+	}
+	if err != nil {
+		return bmcDetails{}, err
+	}
+
+	// Make sure the operator accepts the address and registers the
+	// node with the driver it already has.
+	access, err := bmc.NewAccessDetails(details.Address, details.DisableCertificateVerification)
+	if err != nil {
+		return bmcDetails{}, errors.Wrapf(err, "invalid BMC address %q", details.Address)
+	}
+	if access.Driver() != node.Driver {
+		return bmcDetails{}, errors.Errorf("BMC address %q uses driver %q instead of %q",
+			details.Address, access.Driver(), node.Driver)
+	}
+	return details, nil
+}
+
+// nodeBootMode returns the boot mode in the capabilities of a node.
+func nodeBootMode(node nodes.Node) metal3v1alpha1.BootMode {
+	capabilities, _ := node.Properties["capabilities"].(string)
+	secureBoot := false
+	var bootMode metal3v1alpha1.BootMode
+	for _, capability := range strings.Split(capabilities, ",") {
+		switch strings.TrimSpace(capability) {
+		case "boot_mode:uefi":
+			bootMode = metal3v1alpha1.UEFI
+		case "boot_mode:bios":
+			bootMode = metal3v1alpha1.Legacy
+		case "secure_boot:true":
+			secureBoot = true
+		}
+	}
+	if bootMode == metal3v1alpha1.UEFI && secureBoot {
+		return metal3v1alpha1.UEFISecureBoot
+	}
+	return bootMode
+}
+
+// bootMACAddress returns the MAC address of the port the node boots
+// from.
+func bootMACAddress(nodePorts []ports.Port) string {
+	for _, port := range nodePorts {
+		if port.PXEEnabled {
+			return port.Address
+		}
+	}
+	if len(nodePorts) != 0 {
+		return nodePorts[0].Address
+	}
+	return ""
+}
+
+// hostName returns the name of the BareMetalHost of a node. The
+// operator finds the node of a host by its name, so named nodes keep
+// their name. Unnamed nodes are found by the MAC address of their
+// port, and named after the host then.
+func hostName(node nodes.Node) (string, error) {
+	if node.Name == "" {
+		return "node-" + node.UUID, nil
+	}
+	if msgs := validation.IsDNS1123Subdomain(node.Name); len(msgs) != 0 {
+		return "", errors.Errorf("node name %q is not a valid host name: %s; rename the node in Ironic",
+			node.Name, strings.Join(msgs, ", "))
+	}
+	return node.Name, nil
+}
+
+// Host returns the BareMetalHost adopting the node, and the Secret of
+// its BMC credentials. Active nodes are adopted as externally
+// provisioned, so that the operator leaves their instance alone.
+func Host(node nodes.Node, nodePorts []ports.Port, namespace string, creds Credentials) (*metal3v1alpha1.BareMetalHost, *corev1.Secret, error) {
+	name, err := hostName(node)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch nodes.ProvisionState(node.ProvisionState) {
+	case nodes.Active:
+	case nodes.Available, nodes.Manageable, nodes.Enroll:
+	default:
+		return nil, nil, errors.Errorf("node %s is %s, wait for it to be active, available, manageable or enroll",
+			name, node.ProvisionState)
+	}
+
+	details, err := nodeBMC(node)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to map the BMC of node %s", name)
+	}
+	if node.Name == "" && len(nodePorts) == 0 {
+		return nil, nil, errors.Errorf("node %s has neither a name nor a port to be found by", name)
+	}
+
+	username, password := details.Username, details.Password
+	if given, found := creds.forNode(node); found {
+		if given.Username != "" {
+			username = given.Username
+		}
+		password = given.Password
+	}
+	if password == "" {
+		password = creds.DefaultPassword
+	}
+	if password == "" {
+		return nil, nil, errors.Errorf("no BMC password for node %s, give it in the credentials file or with -password", name)
+	}
+
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + "-bmc-secret",
+			Namespace: namespace,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"username": []byte(username),
+			"password": []byte(password),
+		},
+	}
+
+	host := &metal3v1alpha1.BareMetalHost{
+		TypeMeta: metav1.TypeMeta{APIVersion: metal3v1alpha1.GroupVersion.String(), Kind: "BareMetalHost"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: metal3v1alpha1.BareMetalHostSpec{
+			Online: node.PowerState == "power on",
+			BMC: metal3v1alpha1.BMCDetails{
+				Address:                        details.Address,
+				CredentialsName:                secret.Name,
+				DisableCertificateVerification: details.DisableCertificateVerification,
+			},
+			BootMACAddress:        bootMACAddress(nodePorts),
+			BootMode:              nodeBootMode(node),
+			ExternallyProvisioned: node.ProvisionState == string(nodes.Active),
+		},
+	}
+	return host, secret, nil
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestNodeBMC(t *testing.T) {
+	cases := []struct {
+		name     string
+		node     nodes.Node
+		expected bmcDetails
+	}{
+		{
+			name: "ipmi",
+			node: nodes.Node{
+				Driver: "ipmi",
+				DriverInfo: map[string]interface{}{
+					"ipmi_address":  "192.168.122.1",
+					"ipmi_port":     6230,
+					"ipmi_username": "admin",
+				},
+			},
+			expected: bmcDetails{Address: "ipmi://192.168.122.1:6230", Username: "admin"},
+		},
+		{
+			name: "ipmi-ipv6",
+			node: nodes.Node{
+				Driver:     "ipmi",
+				DriverInfo: map[string]interface{}{"ipmi_address": "fd2e:6f44:5dd8::1"},
+			},
+			expected: bmcDetails{Address: "ipmi://[fd2e:6f44:5dd8::1]"},
+		},
+		{
+			name: "redfish",
+			node: nodes.Node{
+				Driver:        "redfish",
+				BootInterface: "ipxe",
+				DriverInfo: map[string]interface{}{
+					"redfish_address":   "https://192.168.122.1:8000",
+					"redfish_system_id": "/redfish/v1/Systems/1",
+					"redfish_username":  "admin",
+					"redfish_verify_ca": "False",
+				},
+			},
+			expected: bmcDetails{
+				Address:                        "redfish://192.168.122.1:8000/redfish/v1/Systems/1",
+				Username:                       "admin",
+				DisableCertificateVerification: true,
+			},
+		},
+		{
+			name: "redfish-virtualmedia-http",
+			node: nodes.Node{
+				Driver:        "redfish",
+				BootInterface: "redfish-virtual-media",
+				DriverInfo: map[string]interface{}{
+					"redfish_address":   "http://192.168.122.1",
+					"redfish_system_id": "/redfish/v1/Systems/1",
+					"redfish_verify_ca": "/etc/ssl/bmc-ca.crt",
+				},
+			},
+			expected: bmcDetails{Address: "redfish-virtualmedia+http://192.168.122.1/redfish/v1/Systems/1"},
+		},
+		{
+			name: "idrac",
+			node: nodes.Node{
+				Driver: "idrac",
+				DriverInfo: map[string]interface{}{
+					"drac_address":  "192.168.122.1",
+					"drac_port":     "8443",
+					"drac_path":     "/wsman",
+					"drac_username": "root",
+				},
+			},
+			expected: bmcDetails{Address: "idrac://192.168.122.1:8443/wsman", Username: "root"},
+		},
+		{
+			name: "idrac-virtualmedia",
+			node: nodes.Node{
+				Driver:        "idrac",
+				BootInterface: "idrac-redfish-virtual-media",
+				DriverInfo: map[string]interface{}{
+					"redfish_address":   "192.168.122.1",
+					"redfish_system_id": "/redfish/v1/Systems/System.Embedded.1",
+				},
+			},
+			expected: bmcDetails{Address: "idrac-virtualmedia://192.168.122.1/redfish/v1/Systems/System.Embedded.1"},
+		},
+		{
+			name: "ilo5",
+			node: nodes.Node{
+				Driver:     "ilo5",
+				DriverInfo: map[string]interface{}{"ilo_address": "192.168.122.1", "ilo_verify_ca": false},
+			},
+			expected: bmcDetails{Address: "ilo5://192.168.122.1", DisableCertificateVerification: true},
+		},
+		{
+			name: "irmc",
+			node: nodes.Node{
+				Driver:     "irmc",
+				DriverInfo: map[string]interface{}{"irmc_address": "192.168.122.1", "irmc_port": 443},
+			},
+			expected: bmcDetails{Address: "irmc://192.168.122.1:443"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			details, err := nodeBMC(tc.node)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, details)
+		})
+	}
+}
+
+func TestNodeBMCUnsupported(t *testing.T) {
+	_, err := nodeBMC(nodes.Node{Driver: "fake-hardware"})
+	assert.Error(t, err)
+}
+
+func TestHostActive(t *testing.T) {
+	node := nodes.Node{
+		UUID:           "27720611-e5d1-45d3-ba3a-222dcfaa4ca2",
+		Name:           "worker-0",
+		Driver:         "ipmi",
+		DriverInfo:     map[string]interface{}{"ipmi_address": "192.168.122.1", "ipmi_username": "admin"},
+		ProvisionState: string(nodes.Active),
+		PowerState:     "power on",
+		Properties:     map[string]interface{}{"capabilities": "cpu_vt:true,boot_mode:uefi"},
+	}
+	nodePorts := []ports.Port{
+		{Address: "00:5c:52:31:3a:9c", PXEEnabled: false},
+		{Address: "00:5c:52:31:3a:9d", PXEEnabled: true},
+	}
+
+	host, secret, err := Host(node, nodePorts, "metal3", Credentials{DefaultPassword: "password"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "worker-0", host.Name)
+	assert.Equal(t, "metal3", host.Namespace)
+	assert.True(t, host.Spec.ExternallyProvisioned)
+	assert.True(t, host.Spec.Online)
+	assert.Equal(t, metal3v1alpha1.UEFI, host.Spec.BootMode)
+	assert.Equal(t, "00:5c:52:31:3a:9d", host.Spec.BootMACAddress)
+	assert.Equal(t, secret.Name, host.Spec.BMC.CredentialsName)
+	assert.Equal(t, "admin", string(secret.Data["username"]))
+	assert.Equal(t, "password", string(secret.Data["password"]))
+}
+
+func TestHostAvailable(t *testing.T) {
+	node := nodes.Node{
+		UUID:           "27720611-e5d1-45d3-ba3a-222dcfaa4ca2",
+		Driver:         "ipmi",
+		DriverInfo:     map[string]interface{}{"ipmi_address": "192.168.122.1"},
+		ProvisionState: string(nodes.Available),
+		PowerState:     "power off",
+	}
+	nodePorts := []ports.Port{{Address: "00:5c:52:31:3a:9c"}}
+
+	host, _, err := Host(node, nodePorts, "", Credentials{DefaultPassword: "password"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "node-27720611-e5d1-45d3-ba3a-222dcfaa4ca2", host.Name)
+	assert.False(t, host.Spec.ExternallyProvisioned)
+	assert.False(t, host.Spec.Online)
+	assert.Equal(t, "00:5c:52:31:3a:9c", host.Spec.BootMACAddress)
+
+	// Without a name or a port the operator could not find the node
+	_, _, err = Host(node, nil, "", Credentials{DefaultPassword: "password"})
+	assert.Error(t, err)
+}
+
+func TestHostSkipped(t *testing.T) {
+	cases := []struct {
+		name string
+		node nodes.Node
+	}{
+		{
+			name: "busy",
+			node: nodes.Node{Name: "worker-0", Driver: "ipmi", ProvisionState: string(nodes.Deploying)},
+		},
+		{
+			name: "invalid-name",
+			node: nodes.Node{Name: "Worker_0", Driver: "ipmi", ProvisionState: string(nodes.Active)},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.node.DriverInfo = map[string]interface{}{"ipmi_address": "192.168.122.1"}
+			_, _, err := Host(tc.node, nil, "", Credentials{DefaultPassword: "password"})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestHostCredentials(t *testing.T) {
+	node := nodes.Node{
+		UUID:   "27720611-e5d1-45d3-ba3a-222dcfaa4ca2",
+		Name:   "worker-0",
+		Driver: "ipmi",
+		DriverInfo: map[string]interface{}{
+			"ipmi_address":  "192.168.122.1",
+			"ipmi_username": "admin",
+			"ipmi_password": maskedPassword,
+		},
+		ProvisionState: string(nodes.Active),
+	}
+
+	cases := []struct {
+		name             string
+		driverPassword   string
+		creds            Credentials
+		expectedUsername string
+		expectedPassword string
+	}{
+		{
+			name:             "default",
+			creds:            Credentials{DefaultPassword: "password"},
+			expectedUsername: "admin",
+			expectedPassword: "password",
+		},
+		{
+			name:             "driver-info",
+			driverPassword:   "secret",
+			creds:            Credentials{DefaultPassword: "password"},
+			expectedUsername: "admin",
+			expectedPassword: "secret",
+		},
+		{
+			name:           "by-name",
+			driverPassword: "secret",
+			creds: Credentials{
+				Nodes:           map[string]NodeCredentials{"worker-0": {Username: "root", Password: "calvin"}},
+				DefaultPassword: "password",
+			},
+			expectedUsername: "root",
+			expectedPassword: "calvin",
+		},
+		{
+			name: "by-uuid",
+			creds: Credentials{
+				Nodes: map[string]NodeCredentials{node.UUID: {Password: "calvin"}},
+			},
+			expectedUsername: "admin",
+			expectedPassword: "calvin",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			node := node
+			if tc.driverPassword != "" {
+				node.DriverInfo = map[string]interface{}{
+					"ipmi_address":  "192.168.122.1",
+					"ipmi_username": "admin",
+					"ipmi_password": tc.driverPassword,
+				}
+			}
+			_, secret, err := Host(node, nil, "", tc.creds)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tc.expectedUsername, string(secret.Data["username"]))
+			assert.Equal(t, tc.expectedPassword, string(secret.Data["password"]))
+		})
+	}
+
+	// The masked password is not used
+	_, _, err := Host(node, nil, "", Credentials{})
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	node := nodes.Node{
+		Name:           "worker-0",
+		Driver:         "ipmi",
+		DriverInfo:     map[string]interface{}{"ipmi_address": "192.168.122.1", "ipmi_username": "admin"},
+		ProvisionState: string(nodes.Active),
+	}
+	host, secret, err := Host(node, nil, "", Credentials{DefaultPassword: "password"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	out, err := render(secret)
+	assert.NoError(t, err)
+	assert.Contains(t, out, "kind: Secret\n")
+	assert.Contains(t, out, "username: YWRtaW4=\n")
+	assert.Contains(t, out, "password: cGFzc3dvcmQ=\n")
+
+	out, err = render(host)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "---\n"))
+	assert.Contains(t, out, "kind: BareMetalHost\n")
+	assert.Contains(t, out, "address: ipmi://192.168.122.1\n")
+	assert.Contains(t, out, "externallyProvisioned: true\n")
+	assert.NotContains(t, out, "status:")
+	assert.NotContains(t, out, "creationTimestamp")
+}
@@ -0,0 +1,141 @@
+// import-ironic-nodes is a tool that can be used to migrate the nodes
+// of an existing standalone Ironic to Metal3. It generates the
+// BareMetalHost of each node, with the Secret of its BMC credentials,
+// for a baremetal-operator using the same Ironic to adopt the nodes.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+)
+
+// render returns the YAML of an object to create, without the fields
+// set by the API server.
+func render(obj interface{}) (string, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return "", err
+	}
+	delete(content, "status")
+	if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "creationTimestamp")
+	}
+	out, err := yaml.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	return "---\n" + string(out), nil
+}
+
+// loadCredentials reads the YAML file mapping the name or UUID of
+// nodes to their BMC credentials.
+func loadCredentials(path string) (map[string]NodeCredentials, error) {
+	content, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	var credentials map[string]NodeCredentials
+	if err := yaml.UnmarshalStrict(content, &credentials); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+func main() {
+	var namespace = flag.String("namespace", "", "namespace of the hosts")
+	var password = flag.String("password", "", "password for the BMCs with no other")
+	var credentialsFile = flag.String("credentials", "", "YAML file mapping node names or UUIDs to their BMC username and password")
+	var verbose = flag.Bool("v", false, "turn on verbose output")
+
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: import-ironic-nodes [-password <password>] [-credentials <file>] [-namespace <namespace>] <ironic URI>\n")
+		os.Exit(1)
+	}
+	credentials := Credentials{DefaultPassword: *password}
+	if *credentialsFile != "" {
+		var err error
+		credentials.Nodes, err = loadCredentials(*credentialsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not load credentials: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	endpoint, auth, err := clients.ConfigFromEndpointURL(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+	tlsConf := clients.TLSConfig{
+		TrustedCAFile:      os.Getenv("IRONIC_CACERT_FILE"),
+		InsecureSkipVerify: strings.ToLower(os.Getenv("IRONIC_INSECURE")) == "true",
+	}
+	ironic, err := clients.IronicClient(endpoint, auth, tlsConf)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not get ironic client: %s\n", err)
+		os.Exit(1)
+	}
+
+	pages, err := nodes.ListDetail(ironic, nodes.ListOpts{}).AllPages()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not list nodes: %s\n", err)
+		os.Exit(1)
+	}
+	allNodes, err := nodes.ExtractNodes(pages)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not list nodes: %s\n", err)
+		os.Exit(1)
+	}
+
+	pages, err = ports.ListDetail(ironic, ports.ListOpts{}).AllPages()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not list ports: %s\n", err)
+		os.Exit(1)
+	}
+	allPorts, err := ports.ExtractPorts(pages)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not list ports: %s\n", err)
+		os.Exit(1)
+	}
+	nodePorts := make(map[string][]ports.Port)
+	for _, port := range allPorts {
+		nodePorts[port.NodeUUID] = append(nodePorts[port.NodeUUID], port)
+	}
+
+	failed := false
+	for _, node := range allNodes {
+		host, secret, err := Host(node, nodePorts[node.UUID], *namespace, credentials)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping node %s: %s\n", node.UUID, err)
+			failed = true
+			continue
+		}
+		if *verbose {
+			fmt.Fprintf(os.Stderr, "Node %s (%s) is host %s\n", node.UUID, node.ProvisionState, host.Name)
+		}
+		for _, obj := range []interface{}{secret, host} {
+			result, err := render(obj)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprint(os.Stdout, result)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// rejectedRetryDelay is how often a ready host with an image is
+// checked again while it is rejected. Changes to the host or to the
+// acceptance tests are handled right away.
+const rejectedRetryDelay = time.Minute * 10
+
+// acceptanceFailures runs the acceptance tests of the namespace of the
+// host and returns the assertions it fails.
+func acceptanceFailures(ctx context.Context, c client.Reader, host *metal3v1alpha1.BareMetalHost) ([]string, error) {
+	tests := &metal3v1alpha1.HostAcceptanceTestList{}
+	if err := c.List(ctx, tests, client.InNamespace(host.Namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list host acceptance tests")
+	}
+	var failures []string
+	for i := range tests.Items {
+		applies, err := tests.Items[i].Applies(host)
+		if err != nil {
+			return nil, err
+		}
+		if applies {
+			failures = append(failures, tests.Items[i].Check(host)...)
+		}
+	}
+	return failures, nil
+}
+
+// updateAcceptance records the acceptance test failures of the host in
+// its status when they change.
+func (r *BareMetalHostReconciler) updateAcceptance(request ctrl.Request, host *metal3v1alpha1.BareMetalHost) (bool, error) {
+	failures, err := acceptanceFailures(context.TODO(), r, host)
+	if err != nil {
+		return false, err
+	}
+	if reflect.DeepEqual(failures, host.Status.AcceptanceFailures) {
+		return false, nil
+	}
+
+	host.Status.AcceptanceFailures = failures
+	if err := r.saveHostStatus(host); err != nil {
+		return false, errors.Wrap(err, "failed to save acceptance test results")
+	}
+	if len(failures) > 0 {
+		r.publishEvent(request, host.NewEvent("HostRejected",
+			"Hardware failed acceptance tests: "+strings.Join(failures, "; ")))
+	} else {
+		r.publishEvent(request, host.NewEvent("HostAccepted", "Hardware passed acceptance tests"))
+	}
+	return true, nil
+}
+
+// hostsInNamespace returns the hosts of the namespace of an
+// acceptance test or firmware baseline, whose results may change with
+// it.
+func (r *BareMetalHostReconciler) hostsInNamespace(obj client.Object) []reconcile.Request {
+	hosts := &metal3v1alpha1.BareMetalHostList{}
+	if err := r.List(context.TODO(), hosts, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Info("failed to list hosts", "namespace", obj.GetNamespace(), "error", err)
+		return nil
+	}
+	requests := make([]reconcile.Request, len(hosts.Items))
+	for i, host := range hosts.Items {
+		requests[i] = reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: host.Namespace, Name: host.Name},
+		}
+	}
+	return requests
+}
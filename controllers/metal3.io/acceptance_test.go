@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	goctx "context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func newAcceptanceTest(minRAM int) *metal3v1alpha1.HostAcceptanceTest {
+	return &metal3v1alpha1.HostAcceptanceTest{
+		ObjectMeta: metav1.ObjectMeta{Name: "intake", Namespace: namespace},
+		Spec:       metal3v1alpha1.HostAcceptanceTestSpec{MinRAMMebibytes: &minRAM},
+	}
+}
+
+// TestAcceptanceRejectsHost ensures that a host failing an acceptance
+// test is rejected and not provisioned until the test passes.
+func TestAcceptanceRejectsHost(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Spec.Online = true
+	host.Spec.Image = &metal3v1alpha1.Image{
+		URL:      "https://example.com/image-name",
+		Checksum: "12345",
+	}
+	test := newAcceptanceTest(1 << 30)
+	r := newTestReconciler(host, test)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.Provisioning.State == metal3v1alpha1.StateReady &&
+				len(host.Status.AcceptanceFailures) > 0
+		},
+	)
+	rejected := meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.RejectedCondition)
+	if assert.NotNil(t, rejected) {
+		assert.Equal(t, metav1.ConditionTrue, rejected.Status)
+		assert.Contains(t, rejected.Message, "intake: ")
+	}
+
+	result, err := r.Reconcile(goctx.TODO(), newRequest(host))
+	assert.NoError(t, err)
+	assert.Equal(t, rejectedRetryDelay, result.RequeueAfter)
+	updated := &metal3v1alpha1.BareMetalHost{}
+	assert.NoError(t, r.Get(goctx.TODO(), newRequest(host).NamespacedName, updated))
+	assert.Equal(t, metal3v1alpha1.StateReady, updated.Status.Provisioning.State)
+
+	// Relaxing the test lets the host be provisioned
+	assert.NoError(t, r.Delete(goctx.TODO(), test))
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.Provisioning.Image.URL != ""
+		},
+	)
+	assert.Empty(t, host.Status.AcceptanceFailures)
+	assert.Len(t, r.hostsInNamespace(test), 1)
+}
+
+func TestHostAcceptanceTestResults(t *testing.T) {
+	test := newAcceptanceTest(4096)
+	accepted := newHost("accepted", &metal3v1alpha1.BareMetalHostSpec{})
+	accepted.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{RAMMebibytes: 8192}
+	rejected := newHost("rejected", &metal3v1alpha1.BareMetalHostSpec{})
+	rejected.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{RAMMebibytes: 2048}
+	uninspected := newHost("uninspected", &metal3v1alpha1.BareMetalHostSpec{})
+	r := &HostAcceptanceTestReconciler{
+		Client: fakeclient.NewFakeClient(test, accepted, rejected, uninspected),
+		Log:    ctrl.Log.WithName("controllers").WithName("HostAcceptanceTest"),
+	}
+
+	name := types.NamespacedName{Namespace: namespace, Name: "intake"}
+	result, err := r.Reconcile(goctx.TODO(), ctrl.Request{NamespacedName: name})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, hostAcceptanceTestResyncInterval, result.RequeueAfter)
+
+	updated := &metal3v1alpha1.HostAcceptanceTest{}
+	if assert.NoError(t, r.Get(goctx.TODO(), name, updated)) {
+		assert.Equal(t, 1, updated.Status.Accepted)
+		assert.Equal(t, 1, updated.Status.Rejected)
+		assert.NotNil(t, updated.Status.LastUpdated)
+	}
+	assert.Len(t, r.testsForHost(uninspected), 1)
+}
+
+func TestAcceptanceHostUpdateEventHandler(t *testing.T) {
+	oldHost := newDefaultHost(t)
+
+	newHost := oldHost.DeepCopy()
+	newHost.Status.PoweredOn = true
+	newHost.Status.LastUpdated = &metav1.Time{}
+	assert.False(t, acceptanceHostUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+
+	newHost = oldHost.DeepCopy()
+	newHost.Labels = map[string]string{"rack": "r1"}
+	assert.True(t, acceptanceHostUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+
+	newHost = oldHost.DeepCopy()
+	newHost.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{RAMMebibytes: 1024}
+	assert.True(t, acceptanceHostUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+
+	newHost = oldHost.DeepCopy()
+	now := metav1.Now()
+	newHost.DeletionTimestamp = &now
+	assert.True(t, acceptanceHostUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+}
@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// AnnotationMigrationReconciler converts the legacy annotations of
+// existing hosts to their structured equivalents when the operator is
+// upgraded: the status annotation to the status subresource and the
+// inspect.metal3.io=disabled and hardware details annotations to the
+// inspection settings in the spec. Every conversion is recorded in the
+// status of the host and as an event. Reboot annotations have no
+// structured equivalent; they are left in place and reported in the
+// AnnotationsMigrated condition.
+type AnnotationMigrationReconciler struct {
+	client.Client
+	Log logr.Logger
+
+	lock    sync.Mutex
+	pending map[string]bool
+
+	recorder *eventRecorder
+}
+
+// annotationConversion is the conversion of a legacy annotation to
+// the field that replaces it.
+type annotationConversion struct {
+	annotation  string
+	replacement string
+}
+
+// Reconcile converts the legacy annotations of one host.
+func (r *AnnotationMigrationReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("baremetalhost", request.NamespacedName)
+
+	host := &metal3v1alpha1.BareMetalHost{}
+	if err := r.Get(ctx, request.NamespacedName, host); err != nil {
+		if k8serrors.IsNotFound(err) {
+			r.setPending(request.NamespacedName.String(), false)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, errors.Wrap(err, "could not load host data")
+	}
+	if !host.DeletionTimestamp.IsZero() {
+		r.setPending(request.NamespacedName.String(), false)
+		return ctrl.Result{}, nil
+	}
+
+	status := host.Status.DeepCopy()
+	conversions, err := convertLegacyAnnotations(host, status)
+	if err != nil {
+		r.setPending(request.NamespacedName.String(), true)
+		r.publishEvent(ctx, host, "AnnotationMigrationFailed", err.Error())
+		return ctrl.Result{}, err
+	}
+	remaining := remainingLegacyAnnotations(host)
+
+	now := metav1.Now()
+	for _, conversion := range conversions {
+		recordAnnotationMigration(status, conversion, now)
+	}
+	condition := metav1.Condition{
+		Type:               metal3v1alpha1.AnnotationsMigratedCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Migrated",
+		ObservedGeneration: host.Generation,
+	}
+	if len(remaining) != 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "NoStructuredEquivalent"
+		condition.Message = fmt.Sprintf("Left in place: %s", strings.Join(remaining, ", "))
+	}
+	existing := meta.FindStatusCondition(status.Conditions, condition.Type)
+	conditionChanged := existing == nil || existing.Status != condition.Status || existing.Message != condition.Message
+	meta.SetStatusCondition(&status.Conditions, condition)
+
+	// The status goes first, so that a status restored from its
+	// annotation is saved before the annotation is removed
+	if len(conversions) != 0 || conditionChanged {
+		spec, annotations := host.Spec.DeepCopy(), host.Annotations
+		host.Status = *status
+		if err := r.Status().Update(ctx, host); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to record the annotation migration")
+		}
+		host.Spec, host.Annotations = *spec, annotations
+	}
+	if len(conversions) != 0 {
+		if err := r.Update(ctx, host); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to remove the migrated annotations")
+		}
+		for _, conversion := range conversions {
+			reqLogger.Info("migrated legacy annotation",
+				"annotation", conversion.annotation, "replacement", conversion.replacement)
+			annotationsMigrated.WithLabelValues(conversion.annotation).Inc()
+			r.publishEvent(ctx, host, "AnnotationMigrated",
+				fmt.Sprintf("Converted annotation %s to %s", conversion.annotation, conversion.replacement))
+		}
+	}
+	if len(remaining) != 0 && conditionChanged {
+		reqLogger.Info("legacy annotations without structured equivalent left in place", "annotations", remaining)
+	}
+
+	r.setPending(request.NamespacedName.String(), false)
+	return ctrl.Result{}, nil
+}
+
+// convertLegacyAnnotations moves the values of the legacy annotations
+// of the host to the spec, or to the status passed in, and removes the
+// annotations from the host.
+func convertLegacyAnnotations(host *metal3v1alpha1.BareMetalHost, status *metal3v1alpha1.BareMetalHostStatus) (conversions []annotationConversion, err error) {
+	if content, found := host.Annotations[metal3v1alpha1.StatusAnnotation]; found {
+		// A status that is already set is more recent than its copy
+		if status.LastUpdated.IsZero() && content != "" {
+			restored, err := unmarshalStatusAnnotation([]byte(content))
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to convert the status annotation")
+			}
+			if restored.LastUpdated.IsZero() {
+				now := metav1.Now()
+				restored.LastUpdated = &now
+			}
+			restored.Conditions = status.Conditions
+			restored.AnnotationMigrations = status.AnnotationMigrations
+			*status = *restored
+		}
+		delete(host.Annotations, metal3v1alpha1.StatusAnnotation)
+		conversions = append(conversions, annotationConversion{metal3v1alpha1.StatusAnnotation, "status"})
+	}
+
+	if host.Annotations[inspectAnnotationPrefix] == "disabled" {
+		if host.Spec.Inspection == nil {
+			host.Spec.Inspection = &metal3v1alpha1.InspectionSettings{}
+		}
+		host.Spec.Inspection.Disabled = true
+		delete(host.Annotations, inspectAnnotationPrefix)
+		conversions = append(conversions, annotationConversion{inspectAnnotationPrefix, "spec.inspection.disabled"})
+	}
+
+	// Hardware details only replace the results of inspection when it
+	// is disabled. Otherwise the host controller copies them to the
+	// status once, as it always did.
+	if content, found := host.Annotations[hardwareDetailsAnnotation]; found &&
+		inspectionDisabled(host) && host.Spec.Inspection.HardwareDetails == nil {
+		details := &metal3v1alpha1.HardwareDetails{}
+		if err := json.Unmarshal([]byte(content), details); err != nil {
+			return conversions, errors.Wrap(err, "failed to convert the hardware details annotation")
+		}
+		host.Spec.Inspection.HardwareDetails = details
+		delete(host.Annotations, hardwareDetailsAnnotation)
+		conversions = append(conversions, annotationConversion{hardwareDetailsAnnotation, "spec.inspection.hardwareDetails"})
+	}
+	return conversions, nil
+}
+
+// remainingLegacyAnnotations lists the legacy annotations of the host
+// that have no structured equivalent.
+func remainingLegacyAnnotations(host *metal3v1alpha1.BareMetalHost) (remaining []string) {
+	for annotation := range host.Annotations {
+		if isRebootAnnotation(annotation) {
+			remaining = append(remaining, annotation)
+		}
+	}
+	sort.Strings(remaining)
+	return remaining
+}
+
+// recordAnnotationMigration adds the conversion to the status, or
+// updates the time of an earlier conversion of the same annotation.
+func recordAnnotationMigration(status *metal3v1alpha1.BareMetalHostStatus, conversion annotationConversion, now metav1.Time) {
+	record := metal3v1alpha1.AnnotationMigration{
+		Annotation:  conversion.annotation,
+		Replacement: conversion.replacement,
+		Time:        now,
+	}
+	for i := range status.AnnotationMigrations {
+		if status.AnnotationMigrations[i].Annotation == conversion.annotation {
+			status.AnnotationMigrations[i] = record
+			return
+		}
+	}
+	status.AnnotationMigrations = append(status.AnnotationMigrations, record)
+}
+
+// setPending keeps track of the hosts whose migration failed, and
+// reports when none is left.
+func (r *AnnotationMigrationReconciler) setPending(name string, pending bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.pending == nil {
+		r.pending = make(map[string]bool)
+	}
+	_, wasPending := r.pending[name]
+	if pending {
+		r.pending[name] = true
+	} else {
+		delete(r.pending, name)
+	}
+	annotationMigrationPending.Set(float64(len(r.pending)))
+	if wasPending && len(r.pending) == 0 {
+		r.Log.Info("the legacy annotations of every host have been migrated")
+	}
+}
+
+func (r *AnnotationMigrationReconciler) publishEvent(ctx context.Context, host *metal3v1alpha1.BareMetalHost, reason, message string) {
+	event := host.NewEvent(reason, message)
+	if err := r.recorder.record(ctx, r.Client, event); err != nil {
+		r.Log.Info("failed to record event, ignoring",
+			"reason", reason, "message", message, "error", err)
+	}
+}
+
+// SetupWithManager registers the reconciler to be run by the manager
+func (r *AnnotationMigrationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.recorder == nil {
+		r.recorder = newEventRecorder(clock.RealClock{})
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("annotation-migration").
+		For(&metal3v1alpha1.BareMetalHost{}).
+		Complete(r)
+}
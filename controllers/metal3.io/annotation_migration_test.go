@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	goctx "context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func newAnnotationMigrationTestReconciler(objs ...runtime.Object) *AnnotationMigrationReconciler {
+	return &AnnotationMigrationReconciler{
+		Client:   fakeclient.NewFakeClient(objs...),
+		Log:      ctrl.Log.WithName("controllers").WithName("AnnotationMigration"),
+		recorder: newEventRecorder(clock.RealClock{}),
+	}
+}
+
+func migrateHost(t *testing.T, host *metal3v1alpha1.BareMetalHost) *metal3v1alpha1.BareMetalHost {
+	r := newAnnotationMigrationTestReconciler(host)
+	_, err := r.Reconcile(goctx.TODO(), newRequest(host))
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	updated := &metal3v1alpha1.BareMetalHost{}
+	if !assert.NoError(t, r.Get(goctx.TODO(), newRequest(host).NamespacedName, updated)) {
+		return nil
+	}
+	return updated
+}
+
+func TestMigrateInspectAnnotations(t *testing.T) {
+	details, _ := json.Marshal(&metal3v1alpha1.HardwareDetails{Hostname: "worker-0"})
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{
+		inspectAnnotationPrefix:   "disabled",
+		hardwareDetailsAnnotation: string(details),
+	}
+
+	updated := migrateHost(t, host)
+	if updated == nil {
+		return
+	}
+	assert.Empty(t, updated.Annotations)
+	if assert.NotNil(t, updated.Spec.Inspection) {
+		assert.True(t, updated.Spec.Inspection.Disabled)
+		if assert.NotNil(t, updated.Spec.Inspection.HardwareDetails) {
+			assert.Equal(t, "worker-0", updated.Spec.Inspection.HardwareDetails.Hostname)
+		}
+	}
+	if assert.Len(t, updated.Status.AnnotationMigrations, 2) {
+		assert.Equal(t, "spec.inspection.disabled", updated.Status.AnnotationMigrations[0].Replacement)
+		assert.Equal(t, "spec.inspection.hardwareDetails", updated.Status.AnnotationMigrations[1].Replacement)
+	}
+	assert.True(t, meta.IsStatusConditionTrue(updated.Status.Conditions, metal3v1alpha1.AnnotationsMigratedCondition))
+}
+
+func TestMigrateHardwareDetailsWithInspection(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{hardwareDetailsAnnotation: `{"hostname": "worker-0"}`}
+
+	updated := migrateHost(t, host)
+	if updated == nil {
+		return
+	}
+	// The host controller still consumes it
+	assert.Contains(t, updated.Annotations, hardwareDetailsAnnotation)
+	assert.Nil(t, updated.Spec.Inspection)
+	assert.Empty(t, updated.Status.AnnotationMigrations)
+}
+
+func TestMigrateStatusAnnotation(t *testing.T) {
+	saved, _ := json.Marshal(&metal3v1alpha1.BareMetalHostStatus{
+		Provisioning: metal3v1alpha1.ProvisionStatus{State: metal3v1alpha1.StateProvisioned},
+	})
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{metal3v1alpha1.StatusAnnotation: string(saved)}
+
+	updated := migrateHost(t, host)
+	if updated == nil {
+		return
+	}
+	assert.NotContains(t, updated.Annotations, metal3v1alpha1.StatusAnnotation)
+	assert.Equal(t, metal3v1alpha1.StateProvisioned, updated.Status.Provisioning.State)
+	assert.False(t, updated.Status.LastUpdated.IsZero())
+	if assert.Len(t, updated.Status.AnnotationMigrations, 1) {
+		assert.Equal(t, "status", updated.Status.AnnotationMigrations[0].Replacement)
+	}
+}
+
+func TestMigrateStatusAnnotationWithStatus(t *testing.T) {
+	saved, _ := json.Marshal(&metal3v1alpha1.BareMetalHostStatus{
+		Provisioning: metal3v1alpha1.ProvisionStatus{State: metal3v1alpha1.StateProvisioned},
+	})
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{metal3v1alpha1.StatusAnnotation: string(saved)}
+	now := metav1.Now()
+	host.Status.LastUpdated = &now
+	host.Status.Provisioning.State = metal3v1alpha1.StateReady
+
+	updated := migrateHost(t, host)
+	if updated == nil {
+		return
+	}
+	assert.NotContains(t, updated.Annotations, metal3v1alpha1.StatusAnnotation)
+	assert.Equal(t, metal3v1alpha1.StateReady, updated.Status.Provisioning.State)
+}
+
+func TestMigrateRebootAnnotationsLeftInPlace(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{rebootAnnotationPrefix + "/remediation": ""}
+
+	updated := migrateHost(t, host)
+	if updated == nil {
+		return
+	}
+	assert.Contains(t, updated.Annotations, rebootAnnotationPrefix+"/remediation")
+	condition := meta.FindStatusCondition(updated.Status.Conditions, metal3v1alpha1.AnnotationsMigratedCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, "NoStructuredEquivalent", condition.Reason)
+		assert.Contains(t, condition.Message, rebootAnnotationPrefix+"/remediation")
+	}
+}
+
+func TestMigrateInvalidAnnotation(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{metal3v1alpha1.StatusAnnotation: "{"}
+	r := newAnnotationMigrationTestReconciler(host)
+
+	_, err := r.Reconcile(goctx.TODO(), newRequest(host))
+	assert.Error(t, err)
+	assert.Len(t, r.pending, 1)
+}
@@ -19,8 +19,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -29,18 +31,26 @@ import (
 	"github.com/pkg/errors"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 	"github.com/metal3-io/baremetal-operator/pkg/bmc"
 	"github.com/metal3-io/baremetal-operator/pkg/hardware"
+	"github.com/metal3-io/baremetal-operator/pkg/notify"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
 	"github.com/metal3-io/baremetal-operator/pkg/utils"
 )
@@ -49,9 +59,10 @@ const (
 	hostErrorRetryDelay           = time.Second * 10
 	unmanagedRetryDelay           = time.Minute * 10
 	provisionerNotReadyRetryDelay = time.Second * 30
-	rebootAnnotationPrefix        = "reboot.metal3.io"
-	inspectAnnotationPrefix       = "inspect.metal3.io"
-	hardwareDetailsAnnotation     = inspectAnnotationPrefix + "/hardwaredetails"
+	duplicateBMCRetryDelay        = time.Minute
+	rebootAnnotationPrefix        = metal3v1alpha1.RebootAnnotationPrefix
+	inspectAnnotationPrefix       = metal3v1alpha1.InspectAnnotationPrefix
+	hardwareDetailsAnnotation     = metal3v1alpha1.HardwareDetailsAnnotation
 )
 
 // BareMetalHostReconciler reconciles a BareMetalHost object
@@ -59,6 +70,65 @@ type BareMetalHostReconciler struct {
 	client.Client
 	Log                logr.Logger
 	ProvisionerFactory provisioner.Factory
+
+	// ReinspectionInterval is the time between inspections of ready
+	// hosts that do not set their own interval. Zero disables
+	// reinspection.
+	ReinspectionInterval time.Duration
+
+	// BootProtectionInterval is the time between checks of the boot
+	// order of provisioned hosts that protect it. Zero uses a
+	// default of one hour.
+	BootProtectionInterval time.Duration
+
+	// EraseThroughput is the rate at which disks are expected to be
+	// erased, in bytes per second, to estimate the progress of
+	// cleaning. Zero uses a default for spinning disks.
+	EraseThroughput int64
+
+	// CertificateKeySecret names the Secret holding the key that
+	// signs the certificates of erasure written when hosts are
+	// decommissioned. Hosts cannot be decommissioned without it.
+	CertificateKeySecret types.NamespacedName
+
+	// PowerOnStagger spreads powering on many hosts at once over
+	// time. A nil value powers on every host immediately.
+	PowerOnStagger *PowerOnStagger
+
+	// ProvisioningQueue orders the hosts waiting to be inspected or
+	// provisioned by priority and limits how many of each class are
+	// handled at the same time. A nil value leaves the order to the
+	// provisioner.
+	ProvisioningQueue *ProvisioningQueue
+
+	// BMCProber retries the registration of hosts as soon as their
+	// unreachable BMC answers again. A nil value waits for the
+	// backoff of the registration error.
+	BMCProber *BMCProber
+
+	// Notifier sends the lifecycle milestones of hosts to external
+	// systems. A nil value sends nothing.
+	Notifier *notify.Notifier
+
+	// ProvisionerTimeout bounds the time each call to the provisioner
+	// may take. Zero lets calls take as long as they need.
+	ProvisionerTimeout time.Duration
+
+	// Timeouts bounds how long the operations on hosts that do not
+	// set their own timeouts may take.
+	Timeouts OperationTimeouts
+
+	// Journal keeps the reboot and cleanup intents that could not be
+	// saved while the API server was unreachable. A nil value drops
+	// them.
+	Journal *Journal
+
+	// Pause stops the operator from acting on any host while it is
+	// set. A nil value never pauses.
+	Pause *OperatorPause
+
+	recorder          *eventRecorder
+	quotaReservations *quotaReservations
 }
 
 // Instead of passing a zillion arguments to the action of a phase,
@@ -80,8 +150,12 @@ func (info *reconcileInfo) publishEvent(reason, message string) {
 
 // +kubebuilder:rbac:groups=metal3.io,resources=baremetalhosts,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=metal3.io,resources=baremetalhosts/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update
 // +kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups=metal3.io,resources=hostacceptancetests,verbs=get;list;watch
+// +kubebuilder:rbac:groups=metal3.io,resources=firmwarebaselines,verbs=get;list;watch
+// +kubebuilder:rbac:groups=metal3.io,resources=hardwaredatasnapshots,verbs=get;list;watch;create;delete
 
 // Reconcile handles changes to BareMetalHost resources
 func (r *BareMetalHostReconciler) Reconcile(ctx context.Context, request ctrl.Request) (result ctrl.Result, err error) {
@@ -105,11 +179,18 @@ func (r *BareMetalHostReconciler) Reconcile(ctx context.Context, request ctrl.Re
 			// reconcile request.  Owned objects are automatically
 			// garbage collected. For additional cleanup logic use
 			// finalizers.  Return and don't requeue
+			updateOperationalMetrics(request.NamespacedName, nil)
+			firmwareViolations.Delete(hostMetricLabels(request))
+			r.BMCProber.forget(request.NamespacedName.String())
+			r.ProvisioningQueue.Done(request.NamespacedName.String())
+			r.PowerOnStagger.Done(request.NamespacedName.String())
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
 		return ctrl.Result{}, errors.Wrap(err, "could not load host data")
 	}
+	updateOperationalMetrics(request.NamespacedName, host)
+	operatorPaused := r.Pause.paused()
 
 	// If the reconciliation is paused, requeue
 	annotations := host.GetAnnotations()
@@ -120,6 +201,12 @@ func (r *BareMetalHostReconciler) Reconcile(ctx context.Context, request ctrl.Re
 		}
 	}
 
+	// Replay the intents of a previous reconcile that could not be
+	// saved, before doing the work again.
+	if replayed, err := r.replayJournal(ctx, request, host); err != nil || replayed {
+		return ctrl.Result{Requeue: replayed}, err
+	}
+
 	// Check if Status is empty and status annotation is present
 	// Manually restore data.
 	if !r.hostHasStatus(host) {
@@ -154,12 +241,37 @@ func (r *BareMetalHostReconciler) Reconcile(ctx context.Context, request ctrl.Re
 		}
 	}
 
-	// Consume hardwaredetails from annotation if present
-	hwdUpdated, err := r.updateHardwareDetails(request, host)
-	if err != nil {
-		return ctrl.Result{}, errors.Wrap(err, "Could not update Hardware Details")
-	} else if hwdUpdated {
-		return ctrl.Result{Requeue: true}, nil
+	if !host.HasDryRunAnnotation() {
+		// Consume hardwaredetails from annotation if present
+		hwdUpdated, err := r.updateHardwareDetails(request, host)
+		if err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "Could not update Hardware Details")
+		} else if hwdUpdated {
+			return ctrl.Result{Requeue: true}, nil
+		}
+
+		accUpdated, err := r.updateAcceptance(request, host)
+		if err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "Could not run acceptance tests")
+		} else if accUpdated {
+			return ctrl.Result{Requeue: true}, nil
+		}
+
+		fwUpdated, err := r.updateFirmwareCompliance(request, host)
+		if err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "Could not check firmware compliance")
+		} else if fwUpdated {
+			return ctrl.Result{Requeue: true}, nil
+		}
+
+		if target, ok := host.Annotations[metal3v1alpha1.MoveToAnnotation]; ok && host.DeletionTimestamp.IsZero() && !operatorPaused {
+			moved, err := r.moveHost(ctx, request, host, target)
+			if err != nil {
+				return ctrl.Result{}, errors.Wrap(err, "Could not move host")
+			} else if moved {
+				return ctrl.Result{}, nil
+			}
+		}
 	}
 
 	// NOTE(dhellmann): Handle a few steps outside of the phase
@@ -183,6 +295,14 @@ func (r *BareMetalHostReconciler) Reconcile(ctx context.Context, request ctrl.Re
 		return ctrl.Result{Requeue: true}, nil
 	}
 
+	// Let the host go without talking to the provisioner, which may
+	// be gone for good.
+	if !host.DeletionTimestamp.IsZero() && hostHasFinalizer(host) {
+		if reason, ok := host.Annotations[metal3v1alpha1.ForceDeleteAnnotation]; ok {
+			return r.forceDelete(request, host, reason)
+		}
+	}
+
 	// Retrieve the BMC details from the host spec and validate host
 	// BMC details and build the credentials for talking to the
 	// management controller.
@@ -190,7 +310,8 @@ func (r *BareMetalHostReconciler) Reconcile(ctx context.Context, request ctrl.Re
 	var bmcCredsSecret *corev1.Secret
 	haveCreds := false
 	switch host.Status.Provisioning.State {
-	case metal3v1alpha1.StateNone, metal3v1alpha1.StateUnmanaged:
+	case metal3v1alpha1.StateNone, metal3v1alpha1.StateUnmanaged, metal3v1alpha1.StateRetired:
+		// Retired hosts have no credentials in the provisioner
 		bmcCreds = &bmc.Credentials{}
 	default:
 		bmcCreds, bmcCredsSecret, err = r.buildAndValidateBMCCredentials(request, host)
@@ -214,10 +335,23 @@ func (r *BareMetalHostReconciler) Reconcile(ctx context.Context, request ctrl.Re
 		request:        request,
 		bmcCredsSecret: bmcCredsSecret,
 	}
-	prov, err := r.ProvisionerFactory(*host, *bmcCreds, info.publishEvent)
+	factory := r.ProvisionerFactory
+	if r.ProvisionerTimeout > 0 {
+		factory = withProvisionerTimeout(factory, r.ProvisionerTimeout)
+	}
+	prov, err := factory(*host, *bmcCreds, info.publishEvent)
 	if err != nil {
 		return ctrl.Result{}, errors.Wrap(err, "failed to create provisioner")
 	}
+	defer func() {
+		var timeoutErr ProvisionerTimeoutError
+		if errors.As(err, &timeoutErr) {
+			r.publishEvent(request, host.NewEvent("ProvisionerTimeout", timeoutErr.Error()))
+			if !host.HasDryRunAnnotation() {
+				r.recordProvisionerTimeout(request, timeoutErr)
+			}
+		}
+	}()
 
 	ready, err := prov.IsReady()
 	if err != nil {
@@ -228,9 +362,29 @@ func (r *BareMetalHostReconciler) Reconcile(ctx context.Context, request ctrl.Re
 		return ctrl.Result{Requeue: true, RequeueAfter: provisionerNotReadyRetryDelay}, nil
 	}
 
-	stateMachine := newHostStateMachine(host, r, prov, haveCreds)
+	if result, handled, err := r.handleHostRequests(ctx, prov, info, operatorPaused); handled {
+		return result, err
+	}
+
+	if operatorPaused {
+		return r.reconcilePaused(prov, info)
+	}
+
+	// In dry-run mode every change to the cluster made while handling
+	// the host is only validated, so neither the status nor the
+	// metadata of the host is modified.
+	hostReconciler := r
+	dryRun := host.HasDryRunAnnotation()
+	if dryRun {
+		hostReconciler = r.dryRunReconciler()
+	}
+
+	stateMachine := newHostStateMachine(host, hostReconciler, prov, haveCreds)
 	actResult := stateMachine.ReconcileState(info)
 	result, err = actResult.Result()
+	if host.Status.ErrorType == metal3v1alpha1.RegistrationError {
+		result.RequeueAfter = r.BMCProber.requeueAfter(request.NamespacedName.String(), result.RequeueAfter)
+	}
 
 	if err != nil {
 		err = errors.Wrap(err, fmt.Sprintf("action %q failed", initialState))
@@ -246,38 +400,79 @@ func (r *BareMetalHostReconciler) Reconcile(ctx context.Context, request ctrl.Re
 		// Save Host
 		info.log.Info("saving host status",
 			"operational status", host.OperationalStatus(),
-			"provisioning state", host.Status.Provisioning.State)
-		err = r.saveHostStatus(host)
+			"provisioning state", host.Status.Provisioning.State,
+			"dryRun", dryRun)
+		err = hostReconciler.saveHostStatus(host)
 		if err != nil {
+			if !dryRun && apiUnreachable(err) && initialState != metal3v1alpha1.StateDeprovisioning &&
+				host.Status.Provisioning.State == metal3v1alpha1.StateDeprovisioning {
+				r.journalClean(info, initialState)
+			}
 			return ctrl.Result{}, errors.Wrap(err,
 				fmt.Sprintf("failed to save host status after %q", initialState))
 		}
 
-		for _, cb := range info.postSaveCallbacks {
-			cb()
+		if !dryRun {
+			for _, cb := range info.postSaveCallbacks {
+				cb()
+			}
 		}
 	}
 
 	for _, e := range info.events {
+		if dryRun {
+			e.Message = fmt.Sprintf("(dry run) %s", e.Message)
+		} else {
+			r.notifyMilestone(host, e)
+		}
 		r.publishEvent(request, e)
 	}
 
+	if dryRun {
+		// Nothing changed, so repeating the reconcile would produce
+		// the same plan again. Wait for the host to be updated.
+		info.log.Info("dry-run complete, not requeueing")
+		result = ctrl.Result{}
+	}
+
 	logResult(info, result)
 
 	return
 }
 
-// Consume inspect.metal3.io/hardwaredetails when either
+// dryRunReconciler returns a copy of the reconciler that validates
+// changes with the API server without persisting them.
+func (r *BareMetalHostReconciler) dryRunReconciler() *BareMetalHostReconciler {
+	dryRun := *r
+	dryRun.Client = client.NewDryRunClient(r.Client)
+	return &dryRun
+}
+
+// Copy spec.inspection.hardwareDetails when inspection is disabled, or
+// consume inspect.metal3.io/hardwaredetails when either
 // inspect.metal3.io=disabled or there are no existing HardwareDetails
 func (r *BareMetalHostReconciler) updateHardwareDetails(request ctrl.Request, host *metal3v1alpha1.BareMetalHost) (bool, error) {
 	updated := false
-	if host.Status.HardwareDetails == nil || inspectionDisabled(host) {
+	specDetails := specHardwareDetails(host)
+	if specDetails != nil {
+		if !equality.Semantic.DeepEqual(host.Status.HardwareDetails, specDetails) {
+			host.Status.HardwareDetails = specDetails.DeepCopy()
+			markRefreshed(&refreshTimes(host).Hardware)
+			err := r.saveHostStatus(host)
+			if err != nil {
+				return updated, errors.Wrap(err, "Could not update hardwaredetails from spec")
+			}
+			r.publishEvent(request, host.NewEvent("UpdateHardwareDetails", "Set HardwareDetails from spec"))
+			updated = true
+		}
+	} else if host.Status.HardwareDetails == nil || inspectionDisabled(host) {
 		objHardwareDetails, err := r.getHardwareDetailsFromAnnotation(host)
 		if err != nil {
 			return updated, errors.Wrap(err, "Error getting HardwareDetails from annotation")
 		}
 		if objHardwareDetails != nil {
 			host.Status.HardwareDetails = objHardwareDetails
+			markRefreshed(&refreshTimes(host).Hardware)
 			err = r.saveHostStatus(host)
 			if err != nil {
 				return updated, errors.Wrap(err, "Could not update hardwaredetails from annotation")
@@ -296,7 +491,9 @@ func (r *BareMetalHostReconciler) updateHardwareDetails(request ctrl.Request, ho
 			return updated, errors.Wrap(err, "Could not update removing hardwaredetails annotation")
 		}
 		// In the case where the value was not just consumed, generate an event
-		if updated != true {
+		if specDetails != nil {
+			r.publishEvent(request, host.NewEvent("RemoveAnnotation", "HardwareDetails annotation ignored, the details are set in the spec"))
+		} else if updated != true {
 			r.publishEvent(request, host.NewEvent("RemoveAnnotation", "HardwareDetails annotation ignored, status already set and inspection is not disabled"))
 		}
 	}
@@ -326,6 +523,10 @@ func recordActionFailure(info *reconcileInfo, errorType metal3v1alpha1.ErrorType
 		metal3v1alpha1.InspectionError:              "InspectionError",
 		metal3v1alpha1.ProvisioningError:            "ProvisioningError",
 		metal3v1alpha1.PowerManagementError:         "PowerManagementError",
+		metal3v1alpha1.MACMismatchError:             "MACMismatch",
+		metal3v1alpha1.DecommissionError:            "DecommissionError",
+		metal3v1alpha1.RetirementError:              "RetirementError",
+		metal3v1alpha1.TimeoutError:                 "TimeoutError",
 	}[errorType]
 
 	counter := actionFailureCounters.WithLabelValues(eventType)
@@ -437,8 +638,12 @@ func clearRebootAnnotations(host *metal3v1alpha1.BareMetalHost) (dirty bool) {
 }
 
 // inspectionDisabled checks for existence of inspect.metal3.io=disabled
-// which means we don't inspect even in Inspecting state
+// or spec.inspection.disabled which means we don't inspect even in
+// Inspecting state
 func inspectionDisabled(host *metal3v1alpha1.BareMetalHost) bool {
+	if host.Spec.Inspection != nil && host.Spec.Inspection.Disabled {
+		return true
+	}
 	annotations := host.GetAnnotations()
 	if annotations[inspectAnnotationPrefix] == "disabled" {
 		return true
@@ -446,6 +651,15 @@ func inspectionDisabled(host *metal3v1alpha1.BareMetalHost) bool {
 	return false
 }
 
+// specHardwareDetails returns the hardware details provided in the
+// spec, which are only used when inspection is disabled.
+func specHardwareDetails(host *metal3v1alpha1.BareMetalHost) *metal3v1alpha1.HardwareDetails {
+	if host.Spec.Inspection == nil || !inspectionDisabled(host) {
+		return nil
+	}
+	return host.Spec.Inspection.HardwareDetails
+}
+
 // clearError removes any existing error message.
 func clearError(host *metal3v1alpha1.BareMetalHost) (dirty bool) {
 	dirty = host.SetOperationalStatus(metal3v1alpha1.OperationalStatusOK)
@@ -503,6 +717,29 @@ func (r *BareMetalHostReconciler) actionDeleting(prov provisioner.Provisioner, i
 	return deleteComplete{}
 }
 
+// forceDelete removes the finalizer of a host without deprovisioning
+// it, recording that its node is left behind in the provisioner.
+func (r *BareMetalHostReconciler) forceDelete(request ctrl.Request, host *metal3v1alpha1.BareMetalHost, reason string) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("baremetalhost", request.NamespacedName)
+	message := "Host deleted without deprovisioning"
+	if host.Status.Provisioning.ID != "" {
+		message = fmt.Sprintf("%s, node %s orphaned in the provisioner", message, host.Status.Provisioning.ID)
+	}
+	if reason != "" {
+		message = fmt.Sprintf("%s: %s", message, reason)
+	}
+	reqLogger.Info("force deleting host", "node", host.Status.Provisioning.ID, "reason", reason)
+	r.publishEvent(request, host.NewEvent("ForceDeleted", message))
+
+	host.Finalizers = utils.FilterStringFromList(
+		host.Finalizers, metal3v1alpha1.BareMetalHostFinalizer)
+	if err := r.Update(context.Background(), host); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to remove finalizer")
+	}
+	forceDeleted.Inc()
+	return ctrl.Result{}, nil
+}
+
 func (r *BareMetalHostReconciler) actionUnmanaged(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
 	if info.host.HasBMCDetails() {
 		return actionComplete{}
@@ -516,6 +753,22 @@ func (r *BareMetalHostReconciler) registerHost(prov provisioner.Provisioner, inf
 		"credentials", info.host.Status.TriedCredentials)
 	dirty := false
 
+	others, err := metal3v1alpha1.FindHostsWithSameBMC(context.TODO(), r, info.host)
+	if err != nil {
+		return actionError{err}
+	}
+	if setDuplicateBMCCondition(info.host, others) {
+		dirty = true
+	}
+	if other := findOlderHost(info.host, others); other != nil {
+		info.log.Info("BMC address is used by another host, not registering",
+			"host", other.Namespace+"/"+other.Name)
+		if dirty {
+			return actionUpdate{actionContinue{duplicateBMCRetryDelay}}
+		}
+		return actionContinue{duplicateBMCRetryDelay}
+	}
+
 	credsChanged := !info.host.Status.TriedCredentials.Match(*info.bmcCredsSecret)
 	if credsChanged {
 		info.log.Info("new credentials")
@@ -524,6 +777,14 @@ func (r *BareMetalHostReconciler) registerHost(prov provisioner.Provisioner, inf
 		dirty = true
 	}
 
+	hostName := info.request.NamespacedName.String()
+	if !credsChanged && info.host.Status.ErrorType == metal3v1alpha1.RegistrationError {
+		if wait := r.BMCProber.delay(hostName, info.host.Spec.BMC.Address, info.host.Labels, time.Now()); wait > 0 {
+			info.log.Info("BMC is still unreachable, waiting to retry registration", "delay", wait)
+			return actionContinue{wait}
+		}
+	}
+
 	provResult, provID, err := prov.ValidateManagementAccess(credsChanged, info.host.Status.ErrorType == metal3v1alpha1.RegistrationError)
 	if err != nil {
 		noManagementAccess.Inc()
@@ -531,18 +792,31 @@ func (r *BareMetalHostReconciler) registerHost(prov provisioner.Provisioner, inf
 	}
 
 	if provResult.ErrorMessage != "" {
-		return recordActionFailure(info, metal3v1alpha1.RegistrationError, provResult.ErrorMessage)
+		result := recordActionFailure(info, metal3v1alpha1.RegistrationError, provResult.ErrorMessage)
+		r.BMCProber.registrationFailed(hostName, info.host.Spec.BMC.Address, info.host.Labels, info.host.Status.ErrorCount, time.Now())
+		return result
 	}
 
-	if provID != "" && info.host.Status.Provisioning.ID != provID {
+	provIDChanged := provID != "" && info.host.Status.Provisioning.ID != provID
+	if provIDChanged {
 		info.log.Info("setting provisioning id", "ID", provID)
 		info.host.Status.Provisioning.ID = provID
+		// A new node has none of the tags set on the old one
+		info.host.Status.Tags = nil
 		if info.host.Status.Provisioning.State == metal3v1alpha1.StatePreparing {
 			clearHostProvisioningSettings(info.host)
 		}
 		dirty = true
 	}
 
+	if provIDChanged || credsChanged || info.host.Status.Provisioning.Driver == nil {
+		driverChanged, err := updateDriverStatus(prov, info.host)
+		if err != nil {
+			return actionError{errors.Wrap(err, "failed to get the driver status")}
+		}
+		dirty = dirty || driverChanged
+	}
+
 	if provResult.Dirty {
 		info.log.Info("host not ready", "wait", provResult.RequeueAfter)
 		result := actionContinue{provResult.RequeueAfter}
@@ -568,9 +842,38 @@ func (r *BareMetalHostReconciler) registerHost(prov provisioner.Provisioner, inf
 		info.log.Info("verified access to the BMC")
 	}
 
+	certResult, fingerprint, err := prov.InstallBootCertificate(info.host.Status.Provisioning.BootCertificate)
+	if err != nil {
+		return actionError{errors.Wrap(err, "failed to install the boot certificate")}
+	}
+	if certResult.ErrorMessage != "" {
+		return recordActionFailure(info, metal3v1alpha1.RegistrationError, certResult.ErrorMessage)
+	}
+	if fingerprint != info.host.Status.Provisioning.BootCertificate {
+		info.host.Status.Provisioning.BootCertificate = fingerprint
+		if fingerprint != "" {
+			info.publishEvent("BootCertificateInstalled", "Installed the CA certificate of the HTTPS boot server")
+		}
+		dirty = true
+	}
+	if certResult.Dirty {
+		if dirty {
+			return actionUpdate{actionContinue{certResult.RequeueAfter}}
+		}
+		return actionContinue{certResult.RequeueAfter}
+	}
+
+	if discoversBootMAC(info.host) && (info.host.Status.VirtualMedia == nil || registeredNewCreds || provIDChanged) {
+		if detectVirtualMedia(prov, info) {
+			dirty = true
+		}
+	}
+
 	if info.host.Status.ErrorType == metal3v1alpha1.RegistrationError || registeredNewCreds {
 		info.log.Info("clearing previous error message")
-		dirty = clearError(info.host)
+		if clearError(info.host) {
+			dirty = true
+		}
 	}
 
 	if dirty {
@@ -579,6 +882,73 @@ func (r *BareMetalHostReconciler) registerHost(prov provisioner.Provisioner, inf
 	return nil
 }
 
+// updateDriverStatus records the driver the provisioner uses for the
+// host, returning true if it changed.
+func updateDriverStatus(prov provisioner.Provisioner, host *metal3v1alpha1.BareMetalHost) (bool, error) {
+	driver, err := prov.GetDriverStatus()
+	if errors.Is(err, provisioner.NeedsRegistration) {
+		// Refreshed once the host is registered
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	markRefreshed(&refreshTimes(host).Provisioning)
+	if reflect.DeepEqual(driver, host.Status.Provisioning.Driver) {
+		return false, nil
+	}
+	host.Status.Provisioning.Driver = driver
+	return true, nil
+}
+
+// setDuplicateBMCCondition records whether other hosts use the same
+// BMC as this one, and reports whether the status has to be saved for
+// it. A host that never had a duplicate gets the False condition with
+// its next save.
+func setDuplicateBMCCondition(host *metal3v1alpha1.BareMetalHost, others []metal3v1alpha1.BareMetalHost) bool {
+	condition := metav1.Condition{
+		Type:               metal3v1alpha1.DuplicateBMCCondition,
+		Status:             metav1.ConditionFalse,
+		Reason:             "UniqueBMC",
+		ObservedGeneration: host.Generation,
+	}
+	if len(others) > 0 {
+		names := make([]string, 0, len(others))
+		for i := range others {
+			names = append(names, others[i].Namespace+"/"+others[i].Name)
+		}
+		sort.Strings(names)
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "BMCAddressInUse"
+		condition.Message = fmt.Sprintf("BMC address %s is also used by %s",
+			host.Spec.BMC.Address, strings.Join(names, ", "))
+	}
+	previous := meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.DuplicateBMCCondition)
+	wasDuplicate := previous != nil && previous.Status == metav1.ConditionTrue
+	changed := previous == nil || previous.Status != condition.Status ||
+		previous.Message != condition.Message
+	meta.SetStatusCondition(&host.Status.Conditions, condition)
+	return changed && (wasDuplicate || len(others) > 0)
+}
+
+// findOlderHost returns one of the hosts sharing the BMC of this one
+// that was created before it. Only the newer host is kept from
+// registering, so that the one already managing the machine carries
+// on undisturbed.
+func findOlderHost(host *metal3v1alpha1.BareMetalHost, others []metal3v1alpha1.BareMetalHost) *metal3v1alpha1.BareMetalHost {
+	for i := range others {
+		other := &others[i]
+		if other.CreationTimestamp.Equal(&host.CreationTimestamp) {
+			if other.Namespace+"/"+other.Name < host.Namespace+"/"+host.Name {
+				return other
+			}
+		} else if other.CreationTimestamp.Before(&host.CreationTimestamp) {
+			return other
+		}
+	}
+	return nil
+}
+
 // Ensure we have the information about the hardware on the host.
 func (r *BareMetalHostReconciler) actionInspecting(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
 
@@ -590,7 +960,9 @@ func (r *BareMetalHostReconciler) actionInspecting(prov provisioner.Provisioner,
 
 	info.log.Info("inspecting hardware")
 
-	provResult, details, err := prov.InspectHardware(info.host.Status.ErrorType == metal3v1alpha1.InspectionError)
+	refresh := info.host.Status.ReinspectionPending
+	provResult, started, details, err := prov.InspectHardware(
+		info.host.Status.ErrorType == metal3v1alpha1.InspectionError, refresh)
 	if err != nil {
 		return actionError{errors.Wrap(err, "hardware inspection failed")}
 	}
@@ -599,19 +971,127 @@ func (r *BareMetalHostReconciler) actionInspecting(prov provisioner.Provisioner,
 		return recordActionFailure(info, metal3v1alpha1.InspectionError, provResult.ErrorMessage)
 	}
 
+	// Once the new inspection has been started, wait for its results
+	// like for the first one.
+	dirty := false
+	if refresh && started {
+		info.host.Status.ReinspectionPending = false
+		dirty = true
+	}
+
 	if provResult.Dirty || details == nil {
 		result := actionContinue{provResult.RequeueAfter}
-		if clearError(info.host) {
+		if clearError(info.host) || dirty {
 			return actionUpdate{result}
 		}
 		return result
 	}
 
+	if mac := info.host.Spec.BootMACAddress; mac != "" && len(details.NIC) > 0 && !details.HasNICWithMAC(mac) {
+		var found []string
+		for _, nic := range details.NIC {
+			found = append(found, nic.MAC)
+		}
+		return recordActionFailure(info, metal3v1alpha1.MACMismatchError,
+			fmt.Sprintf("bootMACAddress %s does not match any NIC found during inspection (%s)",
+				mac, strings.Join(found, ", ")))
+	}
+
+	if info.host.Spec.BootMACAddress == "" && discoversBootMAC(info.host) {
+		if mac := chooseBootMACAddress(details); mac != "" {
+			info.log.Info("recording discovered boot MAC address", "MAC", mac)
+			info.host.Spec.BootMACAddress = mac
+			if err := r.Update(context.TODO(), info.host); err != nil {
+				return actionError{errors.Wrap(err, "failed to save discovered boot MAC address")}
+			}
+			info.publishEvent("BootMACAddressDiscovered",
+				fmt.Sprintf("Using %s as the boot MAC address", mac))
+			// The details are saved on the next pass, once the
+			// spec change has been written.
+			return actionContinue{}
+		}
+	}
+
+	version, err := prov.GetAgentVersion()
+	if err != nil {
+		return actionError{errors.Wrap(err, "failed to get the agent version")}
+	}
+
 	clearError(info.host)
+	changes := hardwareChanges(info.host.Status.HardwareDetails, details)
+	for _, change := range changes {
+		info.publishEvent("HardwareChanged", change)
+	}
+	r.recordHardwareSnapshot(info, details, metal3v1alpha1.HardwareSnapshotInspection, changes)
 	info.host.Status.HardwareDetails = details
+	markRefreshed(&refreshTimes(info.host).Hardware)
+	for _, mismatch := range details.NICMismatches {
+		info.publishEvent("NICMismatch",
+			fmt.Sprintf("NICs %s in link aggregation group %d have a different %s",
+				strings.Join(mismatch.NICs, ", "), mismatch.LinkAggregationID, mismatch.Field))
+	}
+	if versions := agentVersions(info.host, version); versions != nil {
+		versions.Inspection = version
+	}
 	return actionComplete{}
 }
 
+// agentVersions returns the agent versions in the status of the host,
+// to record the version of the agent applied to it. It returns nil when
+// the host has never used an agent image and still does not.
+func agentVersions(host *metal3v1alpha1.BareMetalHost, version string) *metal3v1alpha1.AgentVersions {
+	if host.Status.AgentVersions == nil && version != "" {
+		host.Status.AgentVersions = &metal3v1alpha1.AgentVersions{}
+	}
+	return host.Status.AgentVersions
+}
+
+// usesVirtualMedia reports whether the host boots from virtual media
+// rather than PXE.
+func usesVirtualMedia(host *metal3v1alpha1.BareMetalHost) bool {
+	accessDetails, err := bmc.NewAccessDetails(host.Spec.BMC.Address, host.Spec.BMC.DisableCertificateVerification)
+	if err != nil {
+		return false
+	}
+	return strings.HasSuffix(accessDetails.BootInterface(), "virtual-media")
+}
+
+// discoversBootMAC reports whether the boot MAC address of the host
+// can be discovered during inspection instead of being given in
+// advance.
+func discoversBootMAC(host *metal3v1alpha1.BareMetalHost) bool {
+	accessDetails, err := bmc.NewAccessDetails(host.Spec.BMC.Address, host.Spec.BMC.DisableCertificateVerification)
+	if err != nil {
+		return false
+	}
+	return accessDetails.DiscoversBootMAC()
+}
+
+// chooseBootMACAddress picks the NIC to provision through from the
+// inspection results, preferring one that is PXE capable and then
+// one that has an IP address.
+func chooseBootMACAddress(details *metal3v1alpha1.HardwareDetails) string {
+	var withIP, first string
+	for _, nic := range details.NIC {
+		if _, err := net.ParseMAC(nic.MAC); err != nil {
+			continue
+		}
+		if nic.PXE {
+			return nic.MAC
+		}
+		if withIP == "" && nic.IP != "" {
+			withIP = nic.MAC
+		}
+		if first == "" {
+			first = nic.MAC
+		}
+	}
+	if withIP != "" {
+		return withIP
+	}
+	return first
+}
+
 func (r *BareMetalHostReconciler) actionMatchProfile(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
 
 	var hardwareProfile string
@@ -671,6 +1151,7 @@ func (r *BareMetalHostReconciler) actionPreparing(prov provisioner.Provisioner,
 	if err != nil {
 		return actionError{errors.Wrap(err, "error preparing host")}
 	}
+	cleaningChanged := r.updateCleaningStatus(info, provResult.CleanStep)
 
 	if provResult.ErrorMessage != "" {
 		info.log.Info("handling cleaning error in controller")
@@ -680,7 +1161,7 @@ func (r *BareMetalHostReconciler) actionPreparing(prov provisioner.Provisioner,
 
 	if provResult.Dirty {
 		result := actionContinue{provResult.RequeueAfter}
-		if clearError(info.host) || (dirty && started) {
+		if clearError(info.host) || (dirty && started) || cleaningChanged {
 			// If clearError return true, but started is false, restore provisioningSettings.
 			if dirty && !started {
 				info.host.Status.Provisioning = *provisioningSettings
@@ -710,12 +1191,26 @@ func (r *BareMetalHostReconciler) actionProvisioning(prov provisioner.Provisione
 		return actionContinue{}
 	}
 
-	provResult, err := prov.Provision(hostConf)
+	if message := virtualMediaUnsupported(info.host); message != "" {
+		return recordActionFailure(info, metal3v1alpha1.ProvisioningError, message)
+	}
+
+	provResult, err := prov.Provision(hostConf,
+		operationRequestID(info.host, metal3v1alpha1.StateProvisioning,
+			info.host.Status.Provisioning.Image.URL, info.host.Status.Provisioning.BootMACAddress))
 	if err != nil {
 		return actionError{errors.Wrap(err, "failed to provision")}
 	}
+	cleaningChanged := r.updateCleaningStatus(info, provResult.CleanStep)
 
 	if provResult.ErrorMessage != "" {
+		if next := nextBootMACAddress(info.host); next != "" {
+			info.log.Info("provisioning failed, trying another NIC", "MAC", next)
+			info.publishEvent("BootMACFallback",
+				fmt.Sprintf("Retrying provisioning through %s: %s", next, provResult.ErrorMessage))
+			info.host.Status.Provisioning.BootMACAddress = next
+			return actionUpdate{actionContinue{provResult.RequeueAfter}}
+		}
 		info.log.Info("handling provisioning error in controller")
 		return recordActionFailure(info, metal3v1alpha1.ProvisioningError, provResult.ErrorMessage)
 	}
@@ -725,17 +1220,28 @@ func (r *BareMetalHostReconciler) actionProvisioning(prov provisioner.Provisione
 		// to return false, indicating that it has no more work to
 		// do.
 		result := actionContinue{provResult.RequeueAfter}
-		if clearError(info.host) {
+		if clearError(info.host) || cleaningChanged {
 			return actionUpdate{result}
 		}
 		return result
 	}
 
+	version, err := prov.GetAgentVersion()
+	if err != nil {
+		return actionError{errors.Wrap(err, "failed to get the agent version")}
+	}
+
 	// If the provisioner had no work, ensure the image settings match.
 	if info.host.Status.Provisioning.Image != *(info.host.Spec.Image) {
 		info.log.Info("updating deployed image in status")
 		info.host.Status.Provisioning.Image = *(info.host.Spec.Image)
 	}
+	if info.host.Spec.BootFallback != nil && info.host.Status.Provisioning.BootMACAddress == "" {
+		info.host.Status.Provisioning.BootMACAddress = info.host.Spec.BootMACAddress
+	}
+	if versions := agentVersions(info.host, version); versions != nil {
+		versions.Provisioning = version
+	}
 
 	// After provisioning we always requeue to ensure we enter the
 	// "provisioned" state and start monitoring power status.
@@ -772,10 +1278,12 @@ func (r *BareMetalHostReconciler) actionDeprovisioning(prov provisioner.Provisio
 
 	info.log.Info("deprovisioning")
 
-	provResult, err := prov.Deprovision(info.host.Status.ErrorType == metal3v1alpha1.ProvisioningError)
+	provResult, err := prov.Deprovision(info.host.Status.ErrorType == metal3v1alpha1.ProvisioningError,
+		operationRequestID(info.host, metal3v1alpha1.StateDeprovisioning))
 	if err != nil {
 		return actionError{errors.Wrap(err, "failed to deprovision")}
 	}
+	cleaningChanged := r.updateCleaningStatus(info, provResult.CleanStep)
 
 	if provResult.ErrorMessage != "" {
 		return recordActionFailure(info, metal3v1alpha1.ProvisioningError, provResult.ErrorMessage)
@@ -783,7 +1291,7 @@ func (r *BareMetalHostReconciler) actionDeprovisioning(prov provisioner.Provisio
 
 	if provResult.Dirty {
 		result := actionContinue{provResult.RequeueAfter}
-		if clearError(info.host) {
+		if clearError(info.host) || cleaningChanged {
 			return actionUpdate{result}
 		}
 		return result
@@ -796,9 +1304,13 @@ func (r *BareMetalHostReconciler) actionDeprovisioning(prov provisioner.Provisio
 		return actionContinue{}
 	}
 
+	verifyBootCleanup(prov, info)
+
 	// After the provisioner is done, clear the provisioning settings
-	// so we transition to the next state.
+	// so we transition to the next state. The next provisioning
+	// starts again from the first boot NIC.
 	info.host.Status.Provisioning.Image = metal3v1alpha1.Image{}
+	info.host.Status.Provisioning.BootMACAddress = ""
 	clearHostProvisioningSettings(info.host)
 
 	return actionComplete{}
@@ -814,20 +1326,34 @@ func (r *BareMetalHostReconciler) manageHostPower(prov provisioner.Provisioner,
 		return actionError{errors.Wrap(err, "failed to update the host power status")}
 	}
 
-	if hwState.PoweredOn != nil && *hwState.PoweredOn != info.host.Status.PoweredOn {
-		info.log.Info("updating power status", "discovered", *hwState.PoweredOn)
-		info.host.Status.PoweredOn = *hwState.PoweredOn
+	provState := info.host.Status.Provisioning.State
+	isProvisioned := provState == metal3v1alpha1.StateProvisioned || provState == metal3v1alpha1.StateExternallyProvisioned
+
+	idle := idlePowerOffDue(info.host, time.Now())
+	desiredPowerOnState := desiredPowerState(info.host, time.Now())
+	if r.rebootJournaled(info.host) {
+		// The reboot was done while the API server was unreachable
+		delete(info.host.Annotations, rebootAnnotationPrefix)
+	}
+	desiredReboot, desiredRebootMode := hasRebootAnnotation(info)
+	rebootNeeded := desiredReboot && isProvisioned && info.host.Status.PoweredOn
+	rebootWait, maintenanceChanged := waitForMaintenance(info, metal3v1alpha1.MaintenanceReboot, rebootNeeded, time.Now())
+	if desiredReboot && isProvisioned && rebootWait == 0 {
+		desiredPowerOnState = false
+	}
+
+	refreshDue := hwState.PoweredOn != nil && powerRefreshDue(info.host, time.Now())
+	if hwState.PoweredOn != nil && recordPowerState(info, *hwState.PoweredOn, desiredPowerOnState) {
 		clearError(info.host)
 		return actionUpdate{}
 	}
 
-	desiredPowerOnState := info.host.Spec.Online
-
 	if !info.host.Status.PoweredOn {
 		if _, suffixlessAnnotationExists := info.host.Annotations[rebootAnnotationPrefix]; suffixlessAnnotationExists {
 			delete(info.host.Annotations, rebootAnnotationPrefix)
 
-			if err = r.Update(context.TODO(), info.host); err != nil {
+			err = r.Update(context.TODO(), info.host)
+			if err != nil && (!apiUnreachable(err) || !r.journalRebootDone(info)) {
 				return actionError{errors.Wrap(err, "failed to remove reboot annotation from host")}
 			}
 
@@ -835,20 +1361,32 @@ func (r *BareMetalHostReconciler) manageHostPower(prov provisioner.Provisioner,
 		}
 	}
 
-	provState := info.host.Status.Provisioning.State
-	isProvisioned := provState == metal3v1alpha1.StateProvisioned || provState == metal3v1alpha1.StateExternallyProvisioned
-
-	desiredReboot, desiredRebootMode := hasRebootAnnotation(info)
-	if desiredReboot && isProvisioned {
-		desiredPowerOnState = false
-	}
-
 	// Power state needs to be monitored regularly, so if we leave
 	// this function without an error we always want to requeue after
 	// a delay.
 	steadyStateResult := actionContinue{time.Second * 60}
 	if info.host.Status.PoweredOn == desiredPowerOnState {
+		r.PowerOnStagger.Done(info.request.NamespacedName.String())
+		if info.host.Status.LastExternalPowerChange != nil || info.host.Status.PowerChangeStarted != nil {
+			info.host.Status.LastExternalPowerChange = nil
+			info.host.Status.PowerChangeStarted = nil
+			return actionUpdate{steadyStateResult}
+		}
+		if refreshDue || maintenanceChanged {
+			// Save when the power state was read, so that a stale
+			// status can be told apart.
+			return actionUpdate{steadyStateResult}
+		}
+		return steadyStateResult
+	}
+
+	if delay, revert := externalPowerChangeDelay(info.host, isProvisioned, time.Now()); !revert {
+		info.log.Info("not reverting power change made outside of the operator",
+			"enforcement", info.host.Spec.PowerPolicy.Enforcement)
 		return steadyStateResult
+	} else if delay > 0 {
+		info.log.Info("waiting before reverting power change made outside of the operator", "delay", delay)
+		return actionContinue{delay}
 	}
 
 	info.log.Info("power state change needed",
@@ -857,10 +1395,28 @@ func (r *BareMetalHostReconciler) manageHostPower(prov provisioner.Provisioner,
 		"reboot mode", desiredRebootMode,
 		"reboot process", desiredPowerOnState != info.host.Spec.Online)
 
+	if started := info.host.Status.PowerChangeStarted; started != nil {
+		if timedOut := r.checkOperationTimeout(info, powerChangeOperation, *started, time.Now()); timedOut != nil {
+			// Start over once the failure has backed off
+			info.host.Status.PowerChangeStarted = nil
+			return timedOut
+		}
+	}
+
 	if desiredPowerOnState {
-		provResult, err = prov.PowerOn()
+		if wait := r.PowerOnStagger.Admit(info.request.NamespacedName.String(), time.Now()); wait > 0 {
+			info.log.Info("waiting for the next batch of hosts to power on", "delay", wait)
+			delayedPowerOnHostCounters.With(hostMetricLabels(info.request)).Inc()
+			return actionContinue{wait}
+		}
+		provResult, err = prov.PowerOn(powerRequestID(info.host, true))
 	} else {
-		provResult, err = prov.PowerOff(desiredRebootMode)
+		if idle && info.host.Status.PowerChangeStarted == nil {
+			info.log.Info("powering off idle host", "available since", info.host.Status.AvailableSince)
+			info.publishEvent("IdlePowerOff",
+				fmt.Sprintf("Powering off host available since %s", info.host.Status.AvailableSince.Format(time.RFC3339)))
+		}
+		provResult, err = prov.PowerOff(desiredRebootMode, powerRequestID(info.host, false))
 	}
 	if err != nil {
 		return actionError{errors.Wrap(err, "failed to manage power state of host")}
@@ -881,7 +1437,12 @@ func (r *BareMetalHostReconciler) manageHostPower(prov provisioner.Provisioner,
 			powerChangeAttempts.With(metricLabels).Inc()
 		})
 		result := actionContinue{provResult.RequeueAfter}
-		if clearError(info.host) {
+		started := info.host.Status.PowerChangeStarted == nil
+		if started {
+			now := metav1.Now()
+			info.host.Status.PowerChangeStarted = &now
+		}
+		if clearError(info.host) || started {
 			return actionUpdate{result}
 		}
 		return result
@@ -890,7 +1451,8 @@ func (r *BareMetalHostReconciler) manageHostPower(prov provisioner.Provisioner,
 	// The provisioner did not have to do anything to change the power
 	// state and there were no errors, so reflect the new state in the
 	// host status field.
-	info.host.Status.PoweredOn = info.host.Spec.Online
+	info.host.Status.PoweredOn = desiredPowerOnState
+	info.host.Status.PowerChangeStarted = nil
 	info.host.Status.ErrorCount = 0
 	return actionUpdate{steadyStateResult}
 }
@@ -915,6 +1477,15 @@ func (r *BareMetalHostReconciler) actionManageSteadyState(prov provisioner.Provi
 		return result
 	}
 
+	if bootProtectionDue(info.host, r.BootProtectionInterval, time.Now()) {
+		protectBootOrder(prov, info)
+		return actionUpdate{}
+	}
+	if !info.host.Spec.ProtectBootOrder && info.host.Status.BootProtection != nil {
+		info.host.Status.BootProtection = nil
+		return actionUpdate{}
+	}
+
 	return r.manageHostPower(prov, info)
 }
 
@@ -937,6 +1508,13 @@ func (r *BareMetalHostReconciler) actionManageReady(prov provisioner.Provisioner
 		clearError(info.host)
 		return actionComplete{}
 	}
+	if info.host.Spec.PowerPolicy != nil && info.host.Spec.PowerPolicy.IdlePowerOffAfter != nil &&
+		info.host.Status.AvailableSince == nil {
+		// The host was already available when the policy was set
+		now := metav1.Now()
+		info.host.Status.AvailableSince = &now
+		return actionUpdate{}
+	}
 	return r.manageHostPower(prov, info)
 }
 
@@ -1006,6 +1584,8 @@ func saveHostProvisioningSettings(host *metal3v1alpha1.BareMetalHost) (dirty boo
 func (r *BareMetalHostReconciler) saveHostStatus(host *metal3v1alpha1.BareMetalHost) error {
 	t := metav1.Now()
 	host.Status.LastUpdated = &t
+	host.Status.ObservedGeneration = host.Generation
+	setHostConditions(host)
 
 	return r.Status().Update(context.TODO(), host)
 }
@@ -1151,7 +1731,7 @@ func (r *BareMetalHostReconciler) setBMCCredentialsSecretOwner(request ctrl.Requ
 func (r *BareMetalHostReconciler) publishEvent(request ctrl.Request, event corev1.Event) {
 	reqLogger := r.Log.WithValues("baremetalhost", request.NamespacedName)
 	reqLogger.Info("publishing event", "reason", event.Reason, "message", event.Message)
-	err := r.Create(context.TODO(), &event)
+	err := r.recorder.record(context.TODO(), r.Client, event)
 	if err != nil {
 		reqLogger.Info("failed to record event, ignoring",
 			"reason", event.Reason, "message", event.Message, "error", err)
@@ -1210,10 +1790,126 @@ func (r *BareMetalHostReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		ctrl.Log.Info(fmt.Sprintf("Operator Concurrency will be set to a default value of %d", maxConcurrentReconciles))
 	}
 
+	if r.recorder == nil {
+		r.recorder = newEventRecorder(clock.RealClock{})
+	}
+
+	if intervalEnv, ok := os.LookupEnv("REINSPECTION_INTERVAL"); ok && r.ReinspectionInterval == 0 {
+		interval, err := time.ParseDuration(intervalEnv)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("REINSPECTION_INTERVAL value: %s is invalid", intervalEnv))
+		}
+		ctrl.Log.Info(fmt.Sprintf("Ready hosts will be inspected again every %s", interval))
+		r.ReinspectionInterval = interval
+	}
+
+	if intervalEnv, ok := os.LookupEnv("BOOT_PROTECTION_INTERVAL"); ok && r.BootProtectionInterval == 0 {
+		interval, err := time.ParseDuration(intervalEnv)
+		if err != nil || interval <= 0 {
+			return errors.New(fmt.Sprintf("BOOT_PROTECTION_INTERVAL value: %s is invalid", intervalEnv))
+		}
+		r.BootProtectionInterval = interval
+	}
+
+	if throughputEnv, ok := os.LookupEnv("ERASE_THROUGHPUT_MIB"); ok && r.EraseThroughput == 0 {
+		throughput, err := strconv.Atoi(throughputEnv)
+		if err != nil || throughput <= 0 {
+			return errors.New(fmt.Sprintf("ERASE_THROUGHPUT_MIB value: %s is invalid", throughputEnv))
+		}
+		r.EraseThroughput = int64(throughput) * 1024 * 1024
+	}
+
+	if secretEnv, ok := os.LookupEnv("ERASURE_CERTIFICATE_SECRET"); ok && r.CertificateKeySecret.Name == "" {
+		parts := strings.Split(secretEnv, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return errors.New(fmt.Sprintf("ERASURE_CERTIFICATE_SECRET value: %s is invalid, expected namespace/name", secretEnv))
+		}
+		r.CertificateKeySecret = types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+	}
+
+	if batchEnv, ok := os.LookupEnv("POWER_ON_BATCH_SIZE"); ok && r.PowerOnStagger == nil {
+		batchSize, err := strconv.Atoi(batchEnv)
+		if err != nil || batchSize < 0 {
+			return errors.New(fmt.Sprintf("POWER_ON_BATCH_SIZE value: %s is invalid", batchEnv))
+		}
+		interval := time.Minute
+		if intervalEnv, ok := os.LookupEnv("POWER_ON_BATCH_INTERVAL"); ok {
+			interval, err = time.ParseDuration(intervalEnv)
+			if err != nil || interval <= 0 {
+				return errors.New(fmt.Sprintf("POWER_ON_BATCH_INTERVAL value: %s is invalid", intervalEnv))
+			}
+		}
+		if batchSize > 0 {
+			ctrl.Log.Info(fmt.Sprintf("Hosts will be powered on in batches of %d every %s", batchSize, interval))
+			r.PowerOnStagger = &PowerOnStagger{BatchSize: batchSize, Interval: interval}
+		}
+	}
+
+	r.quotaReservations = &quotaReservations{}
+
+	if r.ProvisioningQueue == nil {
+		r.ProvisioningQueue = &ProvisioningQueue{}
+		if limitsEnv, ok := os.LookupEnv("PROVISIONING_CLASS_LIMITS"); ok {
+			limits, err := parseClassLimits(limitsEnv)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("PROVISIONING_CLASS_LIMITS value: %s is invalid", limitsEnv))
+			}
+			ctrl.Log.Info(fmt.Sprintf("Hosts of each class will be inspected and provisioned at most %v at a time", limits))
+			r.ProvisioningQueue.ClassLimits = limits
+		}
+	}
+
+	if intervalEnv, ok := os.LookupEnv("BMC_PROBE_INTERVAL"); ok && r.BMCProber == nil {
+		interval, err := time.ParseDuration(intervalEnv)
+		if err != nil || interval < 0 {
+			return errors.New(fmt.Sprintf("BMC_PROBE_INTERVAL value: %s is invalid", intervalEnv))
+		}
+		if interval > 0 {
+			ctrl.Log.Info(fmt.Sprintf("Unreachable BMCs will be probed every %s", interval))
+			r.BMCProber = &BMCProber{Interval: interval}
+		}
+	}
+
+	if r.ProvisionerTimeout == 0 {
+		r.ProvisionerTimeout = defaultProvisionerTimeout
+		if timeoutEnv, ok := os.LookupEnv("PROVISIONER_TIMEOUT"); ok {
+			timeout, err := time.ParseDuration(timeoutEnv)
+			if err != nil || timeout < 0 {
+				return errors.New(fmt.Sprintf("PROVISIONER_TIMEOUT value: %s is invalid", timeoutEnv))
+			}
+			r.ProvisionerTimeout = timeout
+		}
+	}
+
+	if err := r.Timeouts.loadFromEnv(); err != nil {
+		return err
+	}
+
+	if r.Journal == nil {
+		path := os.Getenv("JOURNAL_FILE")
+		journal, err := NewJournal(path)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("JOURNAL_FILE %s cannot be loaded", path))
+		}
+		if path != "" {
+			ctrl.Log.Info(fmt.Sprintf("Intents that cannot be saved will be journaled in %s", path))
+		}
+		r.Journal = journal
+	}
+
 	opts := controller.Options{
 		MaxConcurrentReconciles: maxConcurrentReconciles,
 	}
 
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &metal3v1alpha1.BareMetalHost{},
+		metal3v1alpha1.BMCAddressField, metal3v1alpha1.IndexBMCAddress); err != nil {
+		return errors.Wrap(err, "failed to index hosts by BMC address")
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &metal3v1alpha1.BareMetalHost{},
+		metal3v1alpha1.BootMACAddressField, metal3v1alpha1.IndexBootMACAddress); err != nil {
+		return errors.Wrap(err, "failed to index hosts by boot MAC address")
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&metal3v1alpha1.BareMetalHost{}).
 		WithEventFilter(
@@ -1222,5 +1918,11 @@ func (r *BareMetalHostReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			}).
 		WithOptions(opts).
 		Owns(&corev1.Secret{}).
+		Watches(&source.Kind{Type: &metal3v1alpha1.HostAcceptanceTest{}},
+			handler.EnqueueRequestsFromMapFunc(r.hostsInNamespace),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &metal3v1alpha1.FirmwareBaseline{}},
+			handler.EnqueueRequestsFromMapFunc(r.hostsInNamespace),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Complete(r)
 }
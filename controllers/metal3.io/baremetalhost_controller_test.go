@@ -12,9 +12,11 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -99,6 +101,7 @@ func newTestReconcilerWithFixture(fix *fixture.Fixture, initObjs ...runtime.Obje
 		Client:             c,
 		ProvisionerFactory: fix.New,
 		Log:                ctrl.Log.WithName("controllers").WithName("BareMetalHost"),
+		recorder:           newEventRecorder(clock.RealClock{}),
 	}
 }
 
@@ -267,6 +270,58 @@ func TestHardwareDetails_StatusPresentInspectDisabled(t *testing.T) {
 	)
 }
 
+// TestHardwareDetails_Spec ensures that hardware details provided in
+// the spec replace the status, and that the annotation is then ignored
+func TestHardwareDetails_Spec(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{
+		hardwareDetailsAnnotation: hwdAnnotation,
+	}
+	host.Spec.Inspection = &metal3v1alpha1.InspectionSettings{
+		Disabled: true,
+		HardwareDetails: &metal3v1alpha1.HardwareDetails{
+			Hostname:     "spechost",
+			RAMMebibytes: 4096,
+		},
+	}
+	time := metav1.Now()
+	host.Status.LastUpdated = &time
+	host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{Hostname: "existinghost"}
+
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			_, found := host.Annotations[hardwareDetailsAnnotation]
+			if host.Status.HardwareDetails != nil && host.Status.HardwareDetails.Hostname == "spechost" && !found {
+				return true
+			}
+			return false
+		},
+	)
+}
+
+// TestHardwareDetails_SpecUnchanged ensures that the status is not
+// saved again when it only differs from the hardware details of the
+// spec by empty lists
+func TestHardwareDetails_SpecUnchanged(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Spec.Inspection = &metal3v1alpha1.InspectionSettings{
+		Disabled: true,
+		HardwareDetails: &metal3v1alpha1.HardwareDetails{
+			Hostname: "spechost",
+			NIC:      []metal3v1alpha1.NIC{},
+			Storage:  []metal3v1alpha1.Storage{},
+		},
+	}
+	host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{Hostname: "spechost"}
+	r := newTestReconciler(host)
+
+	updated, err := r.updateHardwareDetails(newRequest(host), host)
+	assert.NoError(t, err)
+	assert.False(t, updated)
+}
+
 // TestStatusAnnotation_EmptyStatus ensures that status is manually populated
 // when status annotation is present and status field is empty.
 func TestStatusAnnotation_EmptyStatus(t *testing.T) {
@@ -372,6 +427,172 @@ func TestPause(t *testing.T) {
 	)
 }
 
+// TestDryRun ensures that a host in dry-run mode does not change
+// state and reports the events it would have produced.
+func TestDryRun(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{
+		metal3v1alpha1.DryRunAnnotation: "",
+	}
+	r := newTestReconciler(host)
+	request := newRequest(host)
+
+	for i := 0; i < 5; i++ {
+		result, err := r.Reconcile(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 {
+			// Only the first pass, which adds the finalizer,
+			// requeues.
+			assert.Equal(t, reconcile.Result{}, result)
+		}
+	}
+
+	updatedHost := &metal3v1alpha1.BareMetalHost{}
+	if err := r.Get(goctx.TODO(), request.NamespacedName, updatedHost); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, metal3v1alpha1.StateNone, updatedHost.Status.Provisioning.State)
+	assert.Nil(t, updatedHost.Status.LastUpdated)
+
+	events := &corev1.EventList{}
+	if err := r.List(goctx.TODO(), events); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range events.Items {
+		assert.Contains(t, e.Message, "(dry run)")
+	}
+}
+
+// TestDryRunHardwareDetails ensures that hardware details from the spec
+// and the annotation are not persisted for a host in dry-run mode.
+func TestDryRunHardwareDetails(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{
+		metal3v1alpha1.DryRunAnnotation: "",
+		hardwareDetailsAnnotation:       hwdAnnotation,
+	}
+	host.Spec.Inspection = &metal3v1alpha1.InspectionSettings{
+		Disabled: true,
+		HardwareDetails: &metal3v1alpha1.HardwareDetails{
+			Hostname: "spechost",
+		},
+	}
+	r := newTestReconciler(host)
+	request := newRequest(host)
+
+	for i := 0; i < 5; i++ {
+		if _, err := r.Reconcile(context.Background(), request); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	updatedHost := &metal3v1alpha1.BareMetalHost{}
+	if err := r.Get(goctx.TODO(), request.NamespacedName, updatedHost); err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, updatedHost.Status.HardwareDetails)
+	assert.Contains(t, updatedHost.Annotations, hardwareDetailsAnnotation)
+}
+
+// TestDuplicateBMCAddress ensures that only the older of two hosts
+// using the same BMC is registered, and that both report the conflict.
+func TestDuplicateBMCAddress(t *testing.T) {
+	older := newDefaultNamedHost("host-a", t)
+	newer := newDefaultNamedHost("host-b", t)
+	newer.Spec.BMC.Address = "IPMI://192.168.122.1:6233/"
+	r := newTestReconciler(older, newer)
+
+	tryReconcile(t, r, newer,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return meta.IsStatusConditionTrue(host.Status.Conditions, metal3v1alpha1.DuplicateBMCCondition)
+		},
+	)
+	condition := meta.FindStatusCondition(newer.Status.Conditions, metal3v1alpha1.DuplicateBMCCondition)
+	assert.Contains(t, condition.Message, "host-a")
+	assert.Empty(t, newer.Status.ErrorType)
+	assert.Empty(t, newer.Status.Provisioning.ID)
+
+	waitForProvisioningState(t, r, older, metal3v1alpha1.StateInspecting)
+	assert.Empty(t, older.Status.ErrorMessage)
+	condition = meta.FindStatusCondition(older.Status.Conditions, metal3v1alpha1.DuplicateBMCCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Contains(t, condition.Message, "host-b")
+	}
+}
+
+// TestBootMACMismatch ensures that inspection fails when the boot MAC
+// address is not one of the NICs that were found.
+func TestBootMACMismatch(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Spec.BootMACAddress = "00:11:22:33:44:55"
+	r := newTestReconciler(host)
+
+	waitForError(t, r, host)
+	assert.Equal(t, metal3v1alpha1.MACMismatchError, host.Status.ErrorType)
+	assert.Contains(t, host.Status.ErrorMessage, "00:11:22:33:44:55")
+	assert.Equal(t, metal3v1alpha1.StateInspecting, host.Status.Provisioning.State)
+	assert.Nil(t, host.Status.HardwareDetails)
+}
+
+// TestBootMACDiscovery ensures that the boot MAC address of a virtual
+// media host is taken from the inspection results.
+func TestBootMACDiscovery(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Spec.BMC.Address = "redfish-virtualmedia://192.168.122.1/redfish/v1/Systems/1"
+	r := newTestReconciler(host)
+
+	waitForProvisioningState(t, r, host, metal3v1alpha1.StateMatchProfile)
+	assert.Equal(t, "00:5c:52:31:3a:9c", host.Spec.BootMACAddress)
+	assert.NotNil(t, host.Status.HardwareDetails)
+}
+
+func TestChooseBootMACAddress(t *testing.T) {
+	testCases := []struct {
+		Scenario string
+		NICs     []metal3v1alpha1.NIC
+		Expected string
+	}{
+		{
+			Scenario: "no nics",
+		},
+		{
+			Scenario: "pxe nic",
+			NICs: []metal3v1alpha1.NIC{
+				{MAC: "00:00:00:00:00:01", IP: "192.168.100.1"},
+				{MAC: "00:00:00:00:00:02", PXE: true},
+			},
+			Expected: "00:00:00:00:00:02",
+		},
+		{
+			Scenario: "nic with ip",
+			NICs: []metal3v1alpha1.NIC{
+				{MAC: "00:00:00:00:00:01"},
+				{MAC: "00:00:00:00:00:02", IP: "192.168.100.2"},
+			},
+			Expected: "00:00:00:00:00:02",
+		},
+		{
+			Scenario: "first nic",
+			NICs: []metal3v1alpha1.NIC{
+				{MAC: "not-a-mac", PXE: true},
+				{MAC: "00:00:00:00:00:01"},
+				{MAC: "00:00:00:00:00:02"},
+			},
+			Expected: "00:00:00:00:00:01",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			details := &metal3v1alpha1.HardwareDetails{NIC: tc.NICs}
+			assert.Equal(t, tc.Expected, chooseBootMACAddress(details))
+		})
+	}
+}
+
 // TestInspectDisabled ensures that Inspection is skipped when disabled
 func TestInspectDisabled(t *testing.T) {
 	host := newDefaultHost(t)
@@ -425,6 +646,37 @@ func TestSetLastUpdated(t *testing.T) {
 	)
 }
 
+// TestSetObservedGeneration ensures that the generation of the host
+// is recorded in the status when it is saved.
+func TestSetObservedGeneration(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Generation = 3
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			t.Logf("ObservedGeneration: %v", host.Status.ObservedGeneration)
+			return host.Status.ObservedGeneration == host.Generation
+		},
+	)
+}
+
+// TestAvailableCondition ensures that the Available condition is set
+// once the host is ready to be provisioned.
+func TestAvailableCondition(t *testing.T) {
+	host := newDefaultHost(t)
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			t.Logf("Conditions: %v", host.Status.Conditions)
+			return meta.IsStatusConditionTrue(host.Status.Conditions, metal3v1alpha1.AvailableCondition)
+		},
+	)
+	assert.True(t, meta.IsStatusConditionFalse(host.Status.Conditions, metal3v1alpha1.ProvisionedCondition))
+	assert.True(t, meta.IsStatusConditionFalse(host.Status.Conditions, metal3v1alpha1.FailedCondition))
+}
+
 func TestInspectionDisabledAnnotation(t *testing.T) {
 	host := newDefaultHost(t)
 	host.Annotations = make(map[string]string)
@@ -1202,6 +1454,39 @@ func TestDeleteHost(t *testing.T) {
 	}
 }
 
+// TestForceDeleteHost verifies that the finalizer of a host being
+// deleted with the force-delete annotation is removed without
+// deleting the host from the provisioner.
+func TestForceDeleteHost(t *testing.T) {
+	now := metav1.Now()
+	host := newDefaultHost(t)
+	host.Finalizers = append(host.Finalizers, metal3v1alpha1.BareMetalHostFinalizer)
+	host.Annotations = map[string]string{metal3v1alpha1.ForceDeleteAnnotation: "ironic database lost"}
+	host.DeletionTimestamp = &now
+	host.Status.Provisioning.ID = "made-up-id"
+	host.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	fix := fixture.Fixture{}
+	r := newTestReconcilerWithFixture(&fix, host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return !hostHasFinalizer(host)
+		},
+	)
+	assert.False(t, fix.Deleted)
+
+	events := &corev1.EventList{}
+	if assert.NoError(t, r.List(goctx.TODO(), events)) {
+		var messages []string
+		for _, e := range events.Items {
+			if e.Reason == "ForceDeleted" {
+				messages = append(messages, e.Message)
+			}
+		}
+		assert.Equal(t, []string{"Host deleted without deprovisioning, node made-up-id orphaned in the provisioner: ironic database lost"}, messages)
+	}
+}
+
 // TestUpdateRootDeviceHints verifies that we apply the correct
 // precedence rules to the root device hints settings for a host.
 func TestUpdateRootDeviceHints(t *testing.T) {
@@ -1854,3 +2139,98 @@ func TestUpdateRAID(t *testing.T) {
 		})
 	}
 }
+
+// TestDriverStatus ensures that the driver used by the provisioner is
+// recorded when the host is registered.
+func TestDriverStatus(t *testing.T) {
+	host := newDefaultHost(t)
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.Provisioning.Driver != nil
+		},
+	)
+
+	assert.Equal(t, "fake-hardware", host.Status.Provisioning.Driver.Name)
+	assert.Equal(t, "fake", host.Status.Provisioning.Driver.Interfaces.Power)
+}
+
+// TestExportBIOSSettings ensures that the BIOS settings are written to
+// a ConfigMap when the export annotation is present.
+func TestExportBIOSSettings(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{
+		metal3v1alpha1.ExportBIOSSettingsAnnotation: "",
+	}
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			_, found := host.Annotations[metal3v1alpha1.ExportBIOSSettingsAnnotation]
+			return !found
+		},
+	)
+
+	configMap := &corev1.ConfigMap{}
+	err := r.Get(goctx.TODO(), types.NamespacedName{
+		Namespace: host.Namespace,
+		Name:      host.Name + "-bios-settings",
+	}, configMap)
+	if assert.NoError(t, err) {
+		assert.Len(t, configMap.Data, 1)
+		settings := map[string]string{}
+		assert.NoError(t, json.Unmarshal([]byte(configMap.Data[biosSettingsKey]), &settings))
+		assert.Equal(t, "Enabled", settings["LogicalProc"])
+		assert.Equal(t, "Enabled", settings["Boot Mode (UEFI)"])
+		assert.Len(t, settings, 4)
+		assert.Len(t, configMap.OwnerReferences, 1)
+	}
+}
+
+// TestExportBIOSSettingsExistingConfigMap ensures that a ConfigMap not
+// created for the host is not overwritten.
+func TestExportBIOSSettingsExistingConfigMap(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{
+		metal3v1alpha1.ExportBIOSSettingsAnnotation: "settings",
+	}
+	other := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "settings",
+			Namespace: namespace,
+		},
+		Data: map[string]string{"foo": "bar"},
+	}
+	r := newTestReconciler(host, other)
+
+	var err error
+	for i := 0; i < 5 && err == nil; i++ {
+		_, err = r.Reconcile(context.Background(), newRequest(host))
+	}
+	assert.Error(t, err)
+
+	configMap := &corev1.ConfigMap{}
+	err = r.Get(goctx.TODO(), types.NamespacedName{Namespace: namespace, Name: "settings"}, configMap)
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]string{"foo": "bar"}, configMap.Data)
+	}
+}
+
+func TestAgentVersionRecorded(t *testing.T) {
+	host := host(metal3v1alpha1.StateInspecting).build()
+	prov := newMockProvisioner()
+	prov.agentVersion = "8.1"
+	r := &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}
+
+	result := r.actionInspecting(prov, makeDefaultReconcileInfo(host))
+	assert.Equal(t, actionComplete{}, result)
+	if assert.NotNil(t, host.Status.AgentVersions) {
+		assert.Equal(t, "8.1", host.Status.AgentVersions.Inspection)
+	}
+
+	// The host no longer uses an agent image
+	prov.agentVersion = ""
+	r.actionInspecting(prov, makeDefaultReconcileInfo(host))
+	assert.Empty(t, host.Status.AgentVersions.Inspection)
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// biosSettingsKey is the ConfigMap key holding the exported BIOS
+// settings. The settings are stored as a single JSON object, since
+// their names often contain characters, such as spaces, that are not
+// allowed in ConfigMap keys.
+const biosSettingsKey = "settings.json"
+
+// biosSettingsConfigMapName returns the name of the ConfigMap the
+// BIOS settings of the host are exported to.
+func biosSettingsConfigMapName(host *metal3v1alpha1.BareMetalHost) string {
+	if name := host.Annotations[metal3v1alpha1.ExportBIOSSettingsAnnotation]; name != "" {
+		return name
+	}
+	return host.Name + "-bios-settings"
+}
+
+// exportBIOSSettings writes all of the current BIOS settings of the
+// host, as a JSON object, to a ConfigMap owned by the host and removes the annotation
+// requesting it. It returns false without doing anything if the host
+// is not registered with the provisioner yet, so the export is
+// retried later.
+func (r *BareMetalHostReconciler) exportBIOSSettings(ctx context.Context, prov provisioner.Provisioner, info *reconcileInfo) (bool, error) {
+	settings, err := prov.GetBIOSSettings()
+	if errors.Is(err, provisioner.NeedsRegistration) {
+		info.log.Info("waiting for registration to export BIOS settings")
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	encoded, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return false, errors.Wrap(err, "failed to encode BIOS settings")
+	}
+	data := map[string]string{biosSettingsKey: string(encoded)}
+
+	host := info.host
+	name := types.NamespacedName{
+		Name:      biosSettingsConfigMapName(host),
+		Namespace: host.Namespace,
+	}
+	configMap := &corev1.ConfigMap{}
+	err = r.Get(ctx, name, configMap)
+	switch {
+	case k8serrors.IsNotFound(err):
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name.Name,
+				Namespace: name.Namespace,
+			},
+			Data: data,
+		}
+		if err := controllerutil.SetOwnerReference(host, configMap, r.Scheme()); err != nil {
+			return false, err
+		}
+		err = r.Create(ctx, configMap)
+	case err != nil:
+	case !ownedBy(configMap, host):
+		// Do not overwrite data the host did not write
+		return false, errors.Errorf("ConfigMap %s exists and is not owned by the host", name.Name)
+	default:
+		configMap.Data = data
+		err = r.Update(ctx, configMap)
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to save ConfigMap %s", name.Name)
+	}
+
+	delete(host.Annotations, metal3v1alpha1.ExportBIOSSettingsAnnotation)
+	if err := r.Update(ctx, host); err != nil {
+		return false, errors.Wrap(err, "failed to remove export annotation")
+	}
+
+	info.log.Info("exported BIOS settings", "configMap", configMap.Name, "count", len(settings))
+	info.publishEvent("BIOSSettingsExported",
+		fmt.Sprintf("Exported %d BIOS settings to ConfigMap %s", len(settings), configMap.Name))
+	return true, nil
+}
+
+func ownedBy(obj metav1.Object, host *metal3v1alpha1.BareMetalHost) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID == host.UID {
+			return true
+		}
+	}
+	return false
+}
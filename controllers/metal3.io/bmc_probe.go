@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/bmcproxy"
+)
+
+// bmcProbeTimeout bounds the time a BMC has to answer a probe.
+const bmcProbeTimeout = 5 * time.Second
+
+// BMCProber retries the registration of a host whose BMC does not
+// answer on the network as soon as it does again, instead of waiting
+// for the backoff of the registration error. The BMC is probed
+// cheaply, without logging in, from the operator, which may not see
+// the network like the provisioner does, so the registration is
+// still retried when the backoff is over. Probes run in the
+// background, at most one per host at a time, and reconciles only
+// read their last result.
+type BMCProber struct {
+	// Interval is the time between two probes of an unreachable BMC.
+	Interval time.Duration
+	// Probe checks whether the BMC at address answers, through the
+	// proxy chosen for the host with the given labels. A nil value
+	// uses bmc.Probe with the proxy from bmcproxy.
+	Probe func(address string, hostLabels map[string]string) error
+
+	lock sync.Mutex
+	// unreachableHosts holds the hosts whose BMC was unreachable when
+	// their registration failed.
+	unreachableHosts map[string]*unreachableBMC
+	// probes tracks the probes running in the background.
+	probes sync.WaitGroup
+}
+
+type unreachableBMC struct {
+	// retryAt is the time the backoff is over.
+	retryAt time.Time
+	// probedAt is the time the last probe started.
+	probedAt time.Time
+	probing  bool
+}
+
+func (p *BMCProber) probe(address string, hostLabels map[string]string) error {
+	if p.Probe != nil {
+		return p.Probe(address, hostLabels)
+	}
+	proxy, err := bmcproxy.ForHost(hostLabels)
+	if err != nil {
+		return err
+	}
+	return bmc.Probe(address, proxy, bmcProbeTimeout)
+}
+
+// startProbe probes the BMC of the host in the background, unless a
+// probe is already running. The caller must hold the lock.
+func (p *BMCProber) startProbe(host, address string, hostLabels map[string]string, state *unreachableBMC, now time.Time) {
+	if state.probing {
+		return
+	}
+	state.probing = true
+	state.probedAt = now
+	p.probes.Add(1)
+	go func() {
+		defer p.probes.Done()
+		err := p.probe(address, hostLabels)
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		state.probing = false
+		if p.unreachableHosts[host] != state {
+			// Forgotten, or failed again, while probing
+			return
+		}
+		if err == nil || errors.Is(err, bmc.ErrProbeUnsupported) {
+			// The registration may be retried now, or with the
+			// backoff of the registration error as usual when the
+			// BMC cannot be probed
+			delete(p.unreachableHosts, host)
+		}
+	}()
+}
+
+// registrationFailed probes the BMC of a host whose registration just
+// failed, to remember it if the BMC is unreachable.
+func (p *BMCProber) registrationFailed(host, address string, hostLabels map[string]string, errorCount int, now time.Time) {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.unreachableHosts == nil {
+		p.unreachableHosts = make(map[string]*unreachableBMC)
+	}
+	state := &unreachableBMC{retryAt: now.Add(calculateBackoff(errorCount))}
+	p.unreachableHosts[host] = state
+	p.startProbe(host, address, hostLabels, state, now)
+}
+
+func (p *BMCProber) unreachable(host string) (retryAt time.Time, found bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	state, found := p.unreachableHosts[host]
+	if found {
+		retryAt = state.retryAt
+	}
+	return
+}
+
+// forget stops probing the BMC of the host.
+func (p *BMCProber) forget(host string) {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.unreachableHosts, host)
+}
+
+// delay reports how long the registration of the host must wait for
+// its BMC to answer. Zero means the registration may be retried now,
+// because the BMC answered the last probe or the backoff is over. The
+// BMC is probed again in the background when the last probe is older
+// than the interval.
+func (p *BMCProber) delay(host, address string, hostLabels map[string]string, now time.Time) time.Duration {
+	if p == nil {
+		return 0
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	state, found := p.unreachableHosts[host]
+	if !found {
+		return 0
+	}
+	if !now.Before(state.retryAt) {
+		delete(p.unreachableHosts, host)
+		return 0
+	}
+	if now.Sub(state.probedAt) >= p.Interval {
+		p.startProbe(host, address, hostLabels, state, now)
+	}
+	if wait := state.retryAt.Sub(now); wait < p.Interval {
+		return wait
+	}
+	return p.Interval
+}
+
+// requeueAfter shortens the delay before the next reconcile of a host
+// whose BMC is unreachable, to probe it again in time.
+func (p *BMCProber) requeueAfter(host string, requeueAfter time.Duration) time.Duration {
+	if p == nil {
+		return requeueAfter
+	}
+	if _, found := p.unreachable(host); found && requeueAfter > p.Interval {
+		return p.Interval
+	}
+	return requeueAfter
+}
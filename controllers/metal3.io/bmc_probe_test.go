@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+)
+
+func TestBMCProber(t *testing.T) {
+	var lock sync.Mutex
+	reachable := false
+	probes := 0
+	p := &BMCProber{
+		Interval: 10 * time.Second,
+		Probe: func(address string, hostLabels map[string]string) error {
+			lock.Lock()
+			defer lock.Unlock()
+			probes++
+			if reachable {
+				return nil
+			}
+			return errors.New("connection refused")
+		},
+	}
+	now := time.Now()
+	const host = "metal3/worker-0"
+	const address = "ipmi://192.168.122.1:6233"
+
+	// Hosts with no registration failure are not delayed
+	assert.Zero(t, p.delay(host, address, nil, now))
+	assert.Equal(t, time.Hour, p.requeueAfter(host, time.Hour))
+
+	p.registrationFailed(host, address, nil, 3, now)
+	p.probes.Wait()
+	assert.Equal(t, 10*time.Second, p.requeueAfter(host, time.Hour))
+	assert.Equal(t, 10*time.Second, p.delay(host, address, nil, now.Add(time.Second)))
+	p.probes.Wait()
+	assert.Equal(t, 1, probes, "the BMC was probed again before the interval")
+
+	// The registration is retried once the BMC answered again
+	lock.Lock()
+	reachable = true
+	lock.Unlock()
+	assert.Equal(t, 10*time.Second, p.delay(host, address, nil, now.Add(20*time.Second)))
+	p.probes.Wait()
+	assert.Equal(t, 2, probes)
+	assert.Zero(t, p.delay(host, address, nil, now.Add(21*time.Second)))
+	assert.Equal(t, time.Hour, p.requeueAfter(host, time.Hour))
+
+	// A reachable BMC keeps the backoff
+	p.registrationFailed(host, address, nil, 3, now)
+	p.probes.Wait()
+	assert.Equal(t, time.Hour, p.requeueAfter(host, time.Hour))
+}
+
+func TestBMCProberBackoffOver(t *testing.T) {
+	p := &BMCProber{
+		Interval: time.Minute,
+		Probe:    func(address string, hostLabels map[string]string) error { return errors.New("no route to host") },
+	}
+	now := time.Now()
+	const host = "metal3/worker-0"
+	const address = "redfish://192.168.122.1/redfish/v1/Systems/1"
+
+	// The first backoff is between 1 and 2 minutes
+	p.registrationFailed(host, address, nil, 1, now)
+	p.probes.Wait()
+	wait := p.delay(host, address, nil, now.Add(30*time.Second))
+	assert.True(t, wait > 0 && wait <= time.Minute, "unexpected delay %s", wait)
+	assert.Zero(t, p.delay(host, address, nil, now.Add(2*time.Minute)))
+	p.probes.Wait()
+	_, found := p.unreachable(host)
+	assert.False(t, found)
+}
+
+func TestBMCProberUnsupported(t *testing.T) {
+	p := &BMCProber{
+		Interval: time.Minute,
+		Probe:    func(address string, hostLabels map[string]string) error { return bmc.ErrProbeUnsupported },
+	}
+	const host = "metal3/worker-0"
+	p.registrationFailed(host, "ipmi://192.168.122.1", nil, 3, time.Now())
+	p.probes.Wait()
+	_, found := p.unreachable(host)
+	assert.False(t, found)
+}
+
+func TestNilBMCProber(t *testing.T) {
+	var p *BMCProber
+	p.registrationFailed("metal3/worker-0", "ipmi://192.168.122.1", nil, 1, time.Now())
+	assert.Zero(t, p.delay("metal3/worker-0", "ipmi://192.168.122.1", nil, time.Now()))
+	assert.Equal(t, time.Hour, p.requeueAfter("metal3/worker-0", time.Hour))
+	p.forget("metal3/worker-0")
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// verifyBootCleanup checks that deprovisioning left no boot
+// configuration behind that could boot the old image again, has it
+// removed and records what was found. A failure is reported without
+// holding up deprovisioning, since the host no longer has an image.
+func verifyBootCleanup(prov provisioner.Provisioner, info *reconcileInfo) {
+	residue, err := prov.CleanBootArtifacts()
+	status := &metal3v1alpha1.BootCleanupStatus{
+		Verified: metav1.Now(),
+		Residue:  residue,
+	}
+	switch {
+	case err != nil:
+		info.log.Info("could not verify the boot configuration cleanup", "error", err.Error())
+		status.ErrorMessage = err.Error()
+		info.publishEvent("BootCleanupFailed", err.Error())
+	case len(residue) != 0:
+		info.log.Info("removed boot configuration left by deprovisioning", "residue", residue)
+		info.publishEvent("BootResidueRemoved",
+			fmt.Sprintf("Removed boot configuration left by deprovisioning: %s", strings.Join(residue, ", ")))
+	}
+	info.host.Status.BootCleanup = status
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestVerifyBootCleanupOnDeprovisioning(t *testing.T) {
+	host := host(metal3v1alpha1.StateDeprovisioning).build()
+	prov := newMockProvisioner()
+	prov.bootResidue = []string{"virtual media /vm/1 holding http://example.test/live.iso"}
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+	host.UpdateGoodCredentials(*info.bmcCredsSecret)
+	host.UpdateTriedCredentials(*info.bmcCredsSecret)
+
+	hsm.ReconcileState(info)
+	assert.NotEqual(t, metal3v1alpha1.StateDeprovisioning, host.Status.Provisioning.State)
+	if assert.NotNil(t, host.Status.BootCleanup) {
+		assert.Equal(t, prov.bootResidue, host.Status.BootCleanup.Residue)
+		assert.Empty(t, host.Status.BootCleanup.ErrorMessage)
+		assert.False(t, host.Status.BootCleanup.Verified.IsZero())
+	}
+	if assert.Len(t, info.events, 1) {
+		assert.Equal(t, "BootResidueRemoved", info.events[0].Reason)
+	}
+}
+
+func TestVerifyBootCleanupFailure(t *testing.T) {
+	host := host(metal3v1alpha1.StateDeprovisioning).build()
+	prov := newMockProvisioner()
+	prov.bootCleanupError = errors.New("BMC unreachable")
+	info := makeDefaultReconcileInfo(host)
+
+	verifyBootCleanup(prov, info)
+	if assert.NotNil(t, host.Status.BootCleanup) {
+		assert.Equal(t, "BMC unreachable", host.Status.BootCleanup.ErrorMessage)
+	}
+	if assert.Len(t, info.events, 1) {
+		assert.Equal(t, "BootCleanupFailed", info.events[0].Reason)
+	}
+}
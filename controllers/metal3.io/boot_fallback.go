@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"strings"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// bootMACCandidates lists the NICs a host may be provisioned
+// through, in the order they are tried.
+func bootMACCandidates(host *metal3v1alpha1.BareMetalHost) (candidates []string) {
+	add := func(mac string) {
+		if mac == "" {
+			return
+		}
+		for _, c := range candidates {
+			if strings.EqualFold(c, mac) {
+				return
+			}
+		}
+		candidates = append(candidates, mac)
+	}
+
+	add(host.Spec.BootMACAddress)
+
+	fallback := host.Spec.BootFallback
+	if fallback == nil {
+		return
+	}
+	for _, mac := range fallback.MACAddresses {
+		add(mac)
+	}
+	if fallback.VLANID != 0 && host.Status.HardwareDetails != nil {
+		for _, nic := range host.Status.HardwareDetails.NIC {
+			if nicOnVLAN(nic, fallback.VLANID) {
+				add(nic.MAC)
+			}
+		}
+	}
+	return
+}
+
+func nicOnVLAN(nic metal3v1alpha1.NIC, vlanID metal3v1alpha1.VLANID) bool {
+	if nic.VLANID == vlanID {
+		return true
+	}
+	for _, vlan := range nic.VLANs {
+		if vlan.ID == vlanID {
+			return true
+		}
+	}
+	return false
+}
+
+// nextBootMACAddress returns the NIC to retry provisioning through
+// after it failed through the current one, or "" when there are no
+// candidates left.
+func nextBootMACAddress(host *metal3v1alpha1.BareMetalHost) string {
+	if host.Spec.BootFallback == nil {
+		return ""
+	}
+
+	current := host.Status.Provisioning.BootMACAddress
+	if current == "" {
+		current = host.Spec.BootMACAddress
+	}
+
+	candidates := bootMACCandidates(host)
+	for i, mac := range candidates {
+		if strings.EqualFold(mac, current) {
+			if i+1 < len(candidates) {
+				return candidates[i+1]
+			}
+			return ""
+		}
+	}
+	// The NIC that failed is not one of the candidates (for example
+	// because the spec was changed), so start again from the first.
+	if len(candidates) > 0 {
+		return candidates[0]
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+func TestNextBootMACAddress(t *testing.T) {
+	details := &metal3v1alpha1.HardwareDetails{
+		NIC: []metal3v1alpha1.NIC{
+			{MAC: "00:00:00:00:00:01", VLANID: 10},
+			{MAC: "00:00:00:00:00:03", VLANs: []metal3v1alpha1.VLAN{{ID: 20}}},
+			{MAC: "00:00:00:00:00:04", VLANID: 20},
+		},
+	}
+
+	testCases := []struct {
+		Scenario string
+		Fallback *metal3v1alpha1.BootFallback
+		Current  string
+		Expected string
+	}{
+		{
+			Scenario: "no fallback",
+		},
+		{
+			Scenario: "first fallback",
+			Fallback: &metal3v1alpha1.BootFallback{
+				MACAddresses: []string{"00:00:00:00:00:02"},
+			},
+			Expected: "00:00:00:00:00:02",
+		},
+		{
+			Scenario: "fallbacks exhausted",
+			Fallback: &metal3v1alpha1.BootFallback{
+				MACAddresses: []string{"00:00:00:00:00:02"},
+			},
+			Current: "00:00:00:00:00:02",
+		},
+		{
+			Scenario: "vlan after addresses",
+			Fallback: &metal3v1alpha1.BootFallback{
+				MACAddresses: []string{"00:00:00:00:00:02"},
+				VLANID:       20,
+			},
+			Current:  "00:00:00:00:00:02",
+			Expected: "00:00:00:00:00:03",
+		},
+		{
+			Scenario: "vlan skips duplicates",
+			Fallback: &metal3v1alpha1.BootFallback{
+				MACAddresses: []string{"00:00:00:00:00:03"},
+				VLANID:       20,
+			},
+			Current:  "00:00:00:00:00:03",
+			Expected: "00:00:00:00:00:04",
+		},
+		{
+			Scenario: "unknown current restarts",
+			Fallback: &metal3v1alpha1.BootFallback{
+				MACAddresses: []string{"00:00:00:00:00:02"},
+			},
+			Current:  "00:00:00:00:00:99",
+			Expected: "00:00:00:00:00:01",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := newDefaultHost(t)
+			host.Spec.BootMACAddress = "00:00:00:00:00:01"
+			host.Spec.BootFallback = tc.Fallback
+			host.Status.HardwareDetails = details
+			host.Status.Provisioning.BootMACAddress = tc.Current
+			assert.Equal(t, tc.Expected, nextBootMACAddress(host))
+		})
+	}
+}
+
+// TestBootFallbackAfterDeprovisioning ensures that provisioning starts
+// again from the first NIC once a host provisioned through a fallback
+// NIC has been deprovisioned.
+func TestBootFallbackAfterDeprovisioning(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Spec.Image = &metal3v1alpha1.Image{URL: "http://example.test/image"}
+	host.Spec.BootMACAddress = "00:00:00:00:00:01"
+	host.Spec.BootFallback = &metal3v1alpha1.BootFallback{
+		MACAddresses: []string{"00:00:00:00:00:02", "00:00:00:00:00:03"},
+	}
+	r := newTestReconciler(host)
+	info := &reconcileInfo{log: r.Log, host: host, request: newRequest(host)}
+	prov := newMockProvisioner()
+
+	// The first NIC fails, the first fallback NIC works
+	prov.setNextError("Provision", "deploy failed")
+	r.actionProvisioning(prov, info)
+	assert.Equal(t, "00:00:00:00:00:02", host.Status.Provisioning.BootMACAddress)
+	prov.nextResults["Provision"] = provisioner.Result{}
+	r.actionProvisioning(prov, info)
+	assert.Equal(t, "00:00:00:00:00:02", host.Status.Provisioning.BootMACAddress)
+
+	r.actionDeprovisioning(prov, info)
+	assert.Empty(t, host.Status.Provisioning.BootMACAddress)
+
+	// Provisioning starts again from the first NIC, so its failure
+	// moves on to the first fallback NIC again
+	prov.setNextError("Provision", "deploy failed")
+	r.actionProvisioning(prov, info)
+	assert.Equal(t, "00:00:00:00:00:02", host.Status.Provisioning.BootMACAddress)
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// defaultBootProtectionInterval is the time between checks of the
+// boot order of provisioned hosts when the operator does not set one.
+const defaultBootProtectionInterval = time.Hour
+
+// bootProtectionDue reports whether the boot order of a provisioned
+// host that protects it was never checked, or longer ago than the
+// interval.
+func bootProtectionDue(host *metal3v1alpha1.BareMetalHost, interval time.Duration, now time.Time) bool {
+	if !host.Spec.ProtectBootOrder || host.Status.Provisioning.State != metal3v1alpha1.StateProvisioned {
+		return false
+	}
+	if interval <= 0 {
+		interval = defaultBootProtectionInterval
+	}
+	status := host.Status.BootProtection
+	return status == nil || now.Sub(status.Verified.Time) >= interval
+}
+
+// protectBootOrder has the host boot from its disks and records the
+// check. A failure is reported and retried at the next check, without
+// affecting the provisioned host.
+func protectBootOrder(prov provisioner.Provisioner, info *reconcileInfo) {
+	corrected, err := prov.ProtectBootOrder()
+	status := &metal3v1alpha1.BootProtectionStatus{
+		Verified:  metav1.Now(),
+		Corrected: corrected,
+	}
+	switch {
+	case err != nil:
+		info.log.Info("could not protect the boot order", "error", err.Error())
+		status.ErrorMessage = err.Error()
+		info.publishEvent("BootProtectionFailed", err.Error())
+	case len(corrected) != 0:
+		info.log.Info("restored booting from disk", "corrected", corrected)
+		info.publishEvent("BootOrderCorrected",
+			fmt.Sprintf("Restored booting from disk: %s", strings.Join(corrected, ", ")))
+	}
+	info.host.Status.BootProtection = status
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestBootProtectionDue(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		Scenario string
+		State    metal3v1alpha1.ProvisioningState
+		Protect  bool
+		Age      time.Duration
+		Expected bool
+	}{
+		{Scenario: "not protected", State: metal3v1alpha1.StateProvisioned},
+		{Scenario: "not provisioned", State: metal3v1alpha1.StateReady, Protect: true},
+		{Scenario: "never checked", State: metal3v1alpha1.StateProvisioned, Protect: true, Expected: true},
+		{Scenario: "checked recently", State: metal3v1alpha1.StateProvisioned, Protect: true,
+			Age: time.Minute},
+		{Scenario: "checked long ago", State: metal3v1alpha1.StateProvisioned, Protect: true,
+			Age: 2 * time.Hour, Expected: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := host(tc.State).build()
+			host.Spec.ProtectBootOrder = tc.Protect
+			if tc.Age != 0 {
+				host.Status.BootProtection = &metal3v1alpha1.BootProtectionStatus{Verified: metav1.NewTime(now.Add(-tc.Age))}
+			}
+			assert.Equal(t, tc.Expected, bootProtectionDue(host, 0, now))
+		})
+	}
+}
+
+func TestProtectBootOrderInSteadyState(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).build()
+	host.Spec.ProtectBootOrder = true
+	prov := newMockProvisioner()
+	prov.bootCorrections = []string{"Once boot override to Pxe"}
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+	host.UpdateGoodCredentials(*info.bmcCredsSecret)
+	host.UpdateTriedCredentials(*info.bmcCredsSecret)
+
+	result := hsm.ReconcileState(info)
+	assert.True(t, result.Dirty())
+	assert.Equal(t, 1, prov.bootOrderChecks)
+	if assert.NotNil(t, host.Status.BootProtection) {
+		assert.Equal(t, prov.bootCorrections, host.Status.BootProtection.Corrected)
+		assert.False(t, host.Status.BootProtection.Verified.IsZero())
+	}
+	if assert.Len(t, info.events, 1) {
+		assert.Equal(t, "BootOrderCorrected", info.events[0].Reason)
+	}
+
+	// The next check waits for the interval
+	info = makeDefaultReconcileInfo(host)
+	hsm.ReconcileState(info)
+	assert.Equal(t, 1, prov.bootOrderChecks)
+
+	// Turning the protection off forgets the last check
+	host.Spec.ProtectBootOrder = false
+	hsm.ReconcileState(info)
+	assert.Nil(t, host.Status.BootProtection)
+}
+
+func TestProtectBootOrderFailure(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).build()
+	prov := newMockProvisioner()
+	prov.bootProtectionError = errors.New("BMC unreachable")
+	info := makeDefaultReconcileInfo(host)
+
+	protectBootOrder(prov, info)
+	if assert.NotNil(t, host.Status.BootProtection) {
+		assert.Equal(t, "BMC unreachable", host.Status.BootProtection.ErrorMessage)
+	}
+	if assert.Len(t, info.events, 1) {
+		assert.Equal(t, "BootProtectionFailed", info.events[0].Reason)
+	}
+}
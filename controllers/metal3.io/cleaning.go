@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// finishCleanStep moves the running clean step to the completed
+// steps.
+func finishCleanStep(info *reconcileInfo, status *metal3v1alpha1.CleaningStatus, now metav1.Time) {
+	if status.Step == "" {
+		return
+	}
+	completed := metal3v1alpha1.CompletedCleanStep{Step: status.Step}
+	if status.StepStarted != nil {
+		completed.Started = *status.StepStarted
+		completed.Duration = metav1.Duration{Duration: now.Sub(status.StepStarted.Time).Round(time.Second)}
+	}
+	status.CompletedSteps = append(status.CompletedSteps, completed)
+	info.publishEvent("CleanStepFinished",
+		fmt.Sprintf("Clean step %s finished after %s", completed.Step, completed.Duration.Duration))
+	if firmwareSteps[completed.Step] {
+		info.publishEvent("FirmwareUpdated", fmt.Sprintf("Firmware updated by clean step %s", completed.Step))
+	}
+
+	status.Step = ""
+	status.StepIndex = 0
+	status.StepStarted = nil
+}
+
+// updateCleaningStatus records the clean step reported by the
+// provisioner, which is nil when the host is not being cleaned, and
+// the estimated progress of erasing the disks. It returns true when
+// the status of the host has changed.
+func (r *BareMetalHostReconciler) updateCleaningStatus(info *reconcileInfo, step *provisioner.CleanStep) bool {
+	now := metav1.Now()
+	stepChanged := trackCleanStep(info, step, now)
+	throughput := r.EraseThroughput
+	if throughput == 0 {
+		throughput = defaultEraseThroughput
+	}
+	return updateEraseProgress(info, throughput, now) || stepChanged
+}
+
+// trackCleanStep records the clean step reported by the provisioner,
+// and returns true when it has changed.
+func trackCleanStep(info *reconcileInfo, step *provisioner.CleanStep, now metav1.Time) bool {
+	status := info.host.Status.Cleaning
+
+	if step == nil {
+		if status == nil || status.Step == "" {
+			return false
+		}
+		finishCleanStep(info, status, now)
+		return true
+	}
+
+	if status == nil || status.Step == "" {
+		if step.Name == "" {
+			// Cleaning has not started running steps yet
+			return false
+		}
+		// A new cleaning replaces the results of the previous one
+		status = &metal3v1alpha1.CleaningStatus{}
+		info.host.Status.Cleaning = status
+	} else if step.Name == "" || (step.Name == status.Step && step.Index == status.StepIndex) {
+		if step.Total == status.TotalSteps {
+			return false
+		}
+		status.TotalSteps = step.Total
+		return true
+	} else {
+		finishCleanStep(info, status, now)
+	}
+
+	status.Step = step.Name
+	status.StepIndex = step.Index
+	status.TotalSteps = step.Total
+	status.StepStarted = &now
+	if step.Index > 0 && step.Total > 0 {
+		info.publishEvent("CleanStepStarted",
+			fmt.Sprintf("Clean step %s started (%d of %d)", step.Name, step.Index, step.Total))
+	} else {
+		info.publishEvent("CleanStepStarted", fmt.Sprintf("Clean step %s started", step.Name))
+	}
+	return true
+}
+
+const (
+	eraseDevicesStep = "deploy.erase_devices"
+
+	// The agent overwrites spinning disks with one pass of random
+	// data and one pass of zeros by default.
+	erasePasses = 2
+
+	defaultEraseThroughput = 100 * 1024 * 1024
+)
+
+// eraseDuration estimates how long the agent takes to erase the disks
+// of the host. Only spinning disks are counted, the others are erased
+// with firmware commands that complete quickly.
+func eraseDuration(details *metal3v1alpha1.HardwareDetails, throughput int64) time.Duration {
+	if details == nil || throughput <= 0 {
+		return 0
+	}
+	var size int64
+	for _, disk := range details.Storage {
+		if disk.Rotational {
+			size += int64(disk.SizeBytes)
+		}
+	}
+	return time.Duration(erasePasses * size / throughput * int64(time.Second))
+}
+
+// updateEraseProgress estimates the progress of the erase_devices
+// clean step from the time it has been running, since the agent does
+// not report it. It returns true when the status has changed.
+func updateEraseProgress(info *reconcileInfo, throughput int64, now metav1.Time) bool {
+	status := info.host.Status.Cleaning
+	labels := hostMetricLabels(info.request)
+
+	if status == nil || status.Step != eraseDevicesStep || status.StepStarted == nil {
+		eraseProgress.Delete(labels)
+		if status == nil || status.EstimatedEraseCompletion == nil {
+			return false
+		}
+		// The erasure has finished
+		status.EstimatedEraseCompletion = nil
+		status.EraseProgress = 100
+		return true
+	}
+
+	duration := eraseDuration(info.host.Status.HardwareDetails, throughput)
+	if duration <= 0 {
+		return false
+	}
+
+	percent := int(now.Sub(status.StepStarted.Time) * 100 / duration)
+	if percent > 99 {
+		// Running late, the step is not done until the agent says so
+		percent = 99
+	}
+	eraseProgress.With(labels).Set(float64(percent))
+
+	completion := metav1.NewTime(status.StepStarted.Add(duration))
+	if status.EraseProgress == percent && status.EstimatedEraseCompletion != nil &&
+		status.EstimatedEraseCompletion.Equal(&completion) {
+		return false
+	}
+	if status.EstimatedEraseCompletion == nil {
+		info.publishEvent("EraseEstimated",
+			fmt.Sprintf("Erasing the disks is expected to finish at %s", completion.UTC().Format(time.RFC3339)))
+	}
+	status.EraseProgress = percent
+	status.EstimatedEraseCompletion = &completion
+	return true
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+func TestTrackCleanStep(t *testing.T) {
+	host := host(metal3v1alpha1.StateDeprovisioning).build()
+	info := makeDefaultReconcileInfo(host)
+
+	// Not cleaning
+	assert.False(t, trackCleanStep(info, nil, metav1.Now()))
+	assert.Nil(t, host.Status.Cleaning)
+
+	// Cleaning without a step yet
+	assert.False(t, trackCleanStep(info, &provisioner.CleanStep{}, metav1.Now()))
+	assert.Nil(t, host.Status.Cleaning)
+
+	assert.True(t, trackCleanStep(info, &provisioner.CleanStep{Name: "deploy.erase_devices_metadata", Index: 1, Total: 2}, metav1.Now()))
+	assert.Equal(t, "deploy.erase_devices_metadata", host.Status.Cleaning.Step)
+	assert.Equal(t, 1, host.Status.Cleaning.StepIndex)
+	assert.Equal(t, 2, host.Status.Cleaning.TotalSteps)
+	assert.NotNil(t, host.Status.Cleaning.StepStarted)
+
+	// Same step, or between steps
+	assert.False(t, trackCleanStep(info, &provisioner.CleanStep{Name: "deploy.erase_devices_metadata", Index: 1, Total: 2}, metav1.Now()))
+	assert.False(t, trackCleanStep(info, &provisioner.CleanStep{Total: 2}, metav1.Now()))
+
+	assert.True(t, trackCleanStep(info, &provisioner.CleanStep{Name: "deploy.erase_devices", Index: 2, Total: 2}, metav1.Now()))
+	assert.Equal(t, "deploy.erase_devices", host.Status.Cleaning.Step)
+	if assert.Len(t, host.Status.Cleaning.CompletedSteps, 1) {
+		assert.Equal(t, "deploy.erase_devices_metadata", host.Status.Cleaning.CompletedSteps[0].Step)
+	}
+
+	// Cleaning finished
+	assert.True(t, trackCleanStep(info, nil, metav1.Now()))
+	assert.Empty(t, host.Status.Cleaning.Step)
+	assert.Nil(t, host.Status.Cleaning.StepStarted)
+	assert.Len(t, host.Status.Cleaning.CompletedSteps, 2)
+	assert.False(t, trackCleanStep(info, nil, metav1.Now()))
+
+	var reasons []string
+	for _, event := range info.events {
+		reasons = append(reasons, event.Reason)
+	}
+	assert.Equal(t, []string{"CleanStepStarted", "CleanStepFinished", "CleanStepStarted", "CleanStepFinished"}, reasons)
+	assert.Equal(t, "Clean step deploy.erase_devices started (2 of 2)", info.events[2].Message)
+
+	// The next cleaning starts over
+	assert.True(t, trackCleanStep(info, &provisioner.CleanStep{Name: "raid.delete_configuration", Index: 1, Total: 1}, metav1.Now()))
+	assert.Empty(t, host.Status.Cleaning.CompletedSteps)
+}
+
+func TestUpdateEraseProgress(t *testing.T) {
+	host := host(metal3v1alpha1.StateDeprovisioning).build()
+	host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{
+		Storage: []metal3v1alpha1.Storage{
+			{Name: "/dev/sda", Rotational: true, SizeBytes: 500 * 1000 * 1000},
+			{Name: "/dev/sdb", Rotational: true, SizeBytes: 500 * 1000 * 1000},
+			{Name: "/dev/nvme0n1", SizeBytes: 1000 * 1000 * 1000 * 1000},
+		},
+	}
+	info := makeDefaultReconcileInfo(host)
+	// 2 passes over 1GB at 1MB/s
+	throughput := int64(1000 * 1000)
+	assert.Equal(t, 2000*time.Second, eraseDuration(host.Status.HardwareDetails, throughput))
+
+	started := time.Now()
+	trackCleanStep(info, &provisioner.CleanStep{Name: "deploy.erase_devices_metadata"}, metav1.NewTime(started))
+	assert.False(t, updateEraseProgress(info, throughput, metav1.NewTime(started)))
+
+	trackCleanStep(info, &provisioner.CleanStep{Name: "deploy.erase_devices"}, metav1.NewTime(started))
+	assert.True(t, updateEraseProgress(info, throughput, metav1.NewTime(started.Add(500*time.Second))))
+	assert.Equal(t, 25, host.Status.Cleaning.EraseProgress)
+	assert.Equal(t, started.Add(2000*time.Second).Unix(), host.Status.Cleaning.EstimatedEraseCompletion.Unix())
+	assert.False(t, updateEraseProgress(info, throughput, metav1.NewTime(started.Add(505*time.Second))))
+
+	// Never reports completion before the step ends
+	assert.True(t, updateEraseProgress(info, throughput, metav1.NewTime(started.Add(3000*time.Second))))
+	assert.Equal(t, 99, host.Status.Cleaning.EraseProgress)
+
+	trackCleanStep(info, nil, metav1.NewTime(started.Add(3100*time.Second)))
+	assert.True(t, updateEraseProgress(info, throughput, metav1.NewTime(started.Add(3100*time.Second))))
+	assert.Equal(t, 100, host.Status.Cleaning.EraseProgress)
+	assert.Nil(t, host.Status.Cleaning.EstimatedEraseCompletion)
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+const (
+	// configDriveKeySize is the size of the AES-256 key of the host
+	configDriveKeySize = 32
+
+	// encryptedUserDataHeader is the first line of encrypted user
+	// data, followed by the base64 encoding of the nonce and the
+	// sealed data.
+	encryptedUserDataHeader = "#metal3-encrypted-user-data v1 aes-256-gcm"
+)
+
+// configDriveKeySecretName returns the name of the Secret holding the
+// key the config drive of the host is encrypted with.
+func configDriveKeySecretName(host *metal3v1alpha1.BareMetalHost) string {
+	if encryption := host.Spec.ConfigDriveEncryption; encryption != nil && encryption.KeySecretName != "" {
+		return encryption.KeySecretName
+	}
+	return host.Name + "-config-drive-key"
+}
+
+// configDriveKey returns the key of the host, creating the Secret
+// holding it with a random key owned by the host if it does not exist.
+func (hcd *hostConfigData) configDriveKey() ([]byte, error) {
+	name := types.NamespacedName{
+		Name:      configDriveKeySecretName(hcd.host),
+		Namespace: hcd.host.Namespace,
+	}
+	secret := &corev1.Secret{}
+	err := hcd.client.Get(context.TODO(), name, secret)
+	if err == nil {
+		key := secret.Data["key"]
+		if len(key) != configDriveKeySize {
+			return nil, errors.Errorf("the key in secret %s must be %d bytes long", name.Name, configDriveKeySize)
+		}
+		return key, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "failed to fetch the config drive key from secret %s", name.Name)
+	}
+
+	key := make([]byte, configDriveKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, errors.Wrap(err, "failed to generate the config drive key")
+	}
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+		},
+		Data: map[string][]byte{"key": key},
+	}
+	if err := controllerutil.SetOwnerReference(hcd.host, secret, hcd.client.Scheme()); err != nil {
+		return nil, err
+	}
+	if err := hcd.client.Create(context.TODO(), secret); err != nil {
+		return nil, errors.Wrapf(err, "failed to save the config drive key in secret %s", name.Name)
+	}
+	hcd.log.Info("generated config drive key", "secret", name.Name)
+	return key, nil
+}
+
+// encryptUserData seals the user data with the key, so that only the
+// host holding the key can read it from the config drive.
+func encryptUserData(key []byte, userData string) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid config drive key")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid config drive key")
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.Wrap(err, "failed to encrypt the user data")
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(userData), []byte(encryptedUserDataHeader))
+	return encryptedUserDataHeader + "\n" + base64.StdEncoding.EncodeToString(sealed) + "\n", nil
+}
+
+// decryptUserData opens user data sealed by encryptUserData.
+func decryptUserData(key []byte, encrypted string) (string, error) {
+	lines := strings.SplitN(strings.TrimSpace(encrypted), "\n", 2)
+	if len(lines) != 2 || lines[0] != encryptedUserDataHeader {
+		return "", errors.New("the user data is not encrypted")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return "", errors.Wrap(err, "invalid encrypted user data")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid config drive key")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid config drive key")
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted user data")
+	}
+	data, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(encryptedUserDataHeader))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decrypt the user data")
+	}
+	return string(data), nil
+}
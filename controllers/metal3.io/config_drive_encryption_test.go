@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	goctx "context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestEncryptUserData(t *testing.T) {
+	key := []byte(strings.Repeat("k", configDriveKeySize))
+	encrypted, err := encryptUserData(key, "#cloud-config\npassword: secret\n")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, encryptedUserDataHeader+"\n"))
+	assert.NotContains(t, encrypted, "secret")
+
+	decrypted, err := decryptUserData(key, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "#cloud-config\npassword: secret\n", decrypted)
+
+	_, err = decryptUserData([]byte(strings.Repeat("x", configDriveKeySize)), encrypted)
+	assert.Error(t, err, "wrong key")
+}
+
+func TestEncryptedUserData(t *testing.T) {
+	host := newDefaultHost(t)
+	host.UID = "27720611-e5d1-45d3-ba3a-222dcfaa4ca2"
+	host.Spec.UserData = &corev1.SecretReference{Name: "user-data"}
+	host.Spec.ConfigDriveEncryption = &metal3v1alpha1.ConfigDriveEncryption{}
+	userData := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "user-data", Namespace: namespace},
+		Data:       map[string][]byte{"userData": []byte("#cloud-config\npassword: secret\n")},
+	}
+	c := fakeclient.NewFakeClient(host, userData)
+	hcd := &hostConfigData{
+		host:   host,
+		log:    ctrl.Log.WithName("controllers").WithName("BareMetalHost").WithName("host_config_data"),
+		client: c,
+	}
+
+	encrypted, err := hcd.UserData()
+	assert.NoError(t, err)
+
+	keySecret := &corev1.Secret{}
+	err = c.Get(goctx.TODO(), types.NamespacedName{Name: host.Name + "-config-drive-key", Namespace: namespace}, keySecret)
+	if assert.NoError(t, err) {
+		assert.True(t, ownedBy(keySecret, host))
+		decrypted, err := decryptUserData(keySecret.Data["key"], encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, "#cloud-config\npassword: secret\n", decrypted)
+	}
+
+	// The key is reused
+	again, err := hcd.UserData()
+	assert.NoError(t, err)
+	_, err = decryptUserData(keySecret.Data["key"], again)
+	assert.NoError(t, err)
+
+	keySecret.Data["key"] = []byte("short")
+	assert.NoError(t, c.Update(goctx.TODO(), keySecret))
+	_, err = hcd.UserData()
+	assert.Error(t, err)
+}
@@ -0,0 +1,355 @@
+package controllers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+const (
+	// erasedHostLabel and erasedHostUIDLabel identify the host a
+	// certificate of erasure was written for.
+	erasedHostLabel    = "metal3.io/erased-host"
+	erasedHostUIDLabel = "metal3.io/erased-host-uid"
+
+	certificateKey = "certificate.json"
+	digestKey      = "sha256"
+	signatureKey   = "signature"
+
+	// certificateKeySecretKey holds the signing key in its Secret.
+	certificateKeySecretKey = "key"
+)
+
+// erasureCertificate records the erasure of the disks of a
+// decommissioned host.
+type erasureCertificate struct {
+	Host         string    `json:"host"`
+	Namespace    string    `json:"namespace"`
+	UID          types.UID `json:"uid"`
+	SystemSerial string    `json:"systemSerialNumber,omitempty"`
+	// Erasure and MetadataWipe are the runs of the provisioner that
+	// erased the disks and wiped the metadata of the bypassed ones.
+	Erasure      erasureRun     `json:"erasure"`
+	MetadataWipe *erasureRun    `json:"metadataWipe,omitempty"`
+	Devices      []erasedDevice `json:"devices"`
+}
+
+type erasureRun struct {
+	Started  metav1.Time `json:"started"`
+	Finished metav1.Time `json:"finished"`
+	// Steps are the clean steps that ran, as reported by the
+	// provisioner.
+	Steps []metal3v1alpha1.CompletedCleanStep `json:"steps"`
+}
+
+type erasedDevice struct {
+	Name         string `json:"name"`
+	Model        string `json:"model,omitempty"`
+	SerialNumber string `json:"serialNumber,omitempty"`
+	WWN          string `json:"wwn,omitempty"`
+	SizeBytes    int64  `json:"sizeBytes"`
+	Rotational   bool   `json:"rotational"`
+	// Methods are the clean steps that erased the disk
+	Methods []string `json:"methods"`
+	// SecureEraseBypassed is set when the secure erase of the disk
+	// was skipped, and only its metadata wiped
+	SecureEraseBypassed bool `json:"secureEraseBypassed,omitempty"`
+}
+
+// erasureCertificateName returns the name of the ConfigMap the
+// certificate of erasure of the host is written to.
+func erasureCertificateName(host *metal3v1alpha1.BareMetalHost) string {
+	return host.Name + "-erasure-certificate"
+}
+
+func newErasureRun(started, finished *metav1.Time, steps []metal3v1alpha1.CompletedCleanStep) erasureRun {
+	run := erasureRun{Steps: steps}
+	if started != nil {
+		run.Started = *started
+	}
+	if finished != nil {
+		run.Finished = *finished
+	}
+	return run
+}
+
+func stepNames(steps []metal3v1alpha1.CompletedCleanStep) []string {
+	names := make([]string, 0, len(steps))
+	for _, step := range steps {
+		names = append(names, step.Step)
+	}
+	return names
+}
+
+// newErasureCertificate builds the certificate of erasure of the host
+// from the clean steps recorded while decommissioning it. The disks
+// are those found by the last inspection, which the agent erases
+// unless they are bypassed.
+func newErasureCertificate(host *metal3v1alpha1.BareMetalHost) erasureCertificate {
+	cert := erasureCertificate{
+		Host:      host.Name,
+		Namespace: host.Namespace,
+		UID:       host.UID,
+		Devices:   []erasedDevice{},
+	}
+	bypassed := make(map[string]bool)
+	var eraseMethods, wipeMethods []string
+	if status := host.Status.Decommission; status != nil {
+		for _, serial := range status.SecureEraseBypassed {
+			bypassed[serial] = true
+		}
+		cert.Erasure = newErasureRun(status.EraseStarted, status.EraseFinished, status.EraseSteps)
+		eraseMethods = stepNames(status.EraseSteps)
+		if status.MetadataWipeFinished != nil {
+			run := newErasureRun(status.MetadataWipeStarted, status.MetadataWipeFinished, status.MetadataWipeSteps)
+			cert.MetadataWipe = &run
+			wipeMethods = stepNames(status.MetadataWipeSteps)
+		}
+	}
+	if details := host.Status.HardwareDetails; details != nil {
+		cert.SystemSerial = details.SystemVendor.SerialNumber
+		for _, disk := range details.Storage {
+			// A disk without a serial number cannot be bypassed
+			diskBypassed := disk.SerialNumber != "" && bypassed[disk.SerialNumber]
+			methods := eraseMethods
+			if diskBypassed {
+				methods = wipeMethods
+			}
+			cert.Devices = append(cert.Devices, erasedDevice{
+				Name:                disk.Name,
+				Model:               disk.Model,
+				SerialNumber:        disk.SerialNumber,
+				WWN:                 disk.WWN,
+				SizeBytes:           int64(disk.SizeBytes),
+				Rotational:          disk.Rotational,
+				Methods:             methods,
+				SecureEraseBypassed: diskBypassed,
+			})
+		}
+	}
+	return cert
+}
+
+// erasureCertificateData returns the contents of the ConfigMap holding
+// the certificate, with its digest and its HMAC-SHA256 signature.
+func erasureCertificateData(cert erasureCertificate, key []byte) (map[string]string, error) {
+	if len(key) == 0 {
+		return nil, errors.New("no key to sign the certificate of erasure")
+	}
+	document, err := json.MarshalIndent(cert, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode the certificate of erasure")
+	}
+	digest := sha256.Sum256(document)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(document)
+	return map[string]string{
+		certificateKey: string(document),
+		digestKey:      hex.EncodeToString(digest[:]),
+		signatureKey:   hex.EncodeToString(mac.Sum(nil)),
+	}, nil
+}
+
+// certificateSigningKey reads the key signing the certificates of
+// erasure from its Secret, every time so that it can be rotated.
+func (r *BareMetalHostReconciler) certificateSigningKey(ctx context.Context) ([]byte, error) {
+	if r.CertificateKeySecret.Name == "" {
+		return nil, errors.New("ERASURE_CERTIFICATE_SECRET is not set, the certificate of erasure cannot be signed")
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, r.CertificateKeySecret, secret); err != nil {
+		return nil, errors.Wrapf(err, "failed to read the key of the certificates of erasure from Secret %s", r.CertificateKeySecret)
+	}
+	key := secret.Data[certificateKeySecretKey]
+	if len(key) == 0 {
+		return nil, errors.Errorf("Secret %s has no %q key to sign the certificates of erasure",
+			r.CertificateKeySecret, certificateKeySecretKey)
+	}
+	return key, nil
+}
+
+// writeErasureCertificate saves the certificate of erasure of the host
+// to a ConfigMap. The ConfigMap is not owned by the host so that it is
+// kept once the host is deleted.
+func (r *BareMetalHostReconciler) writeErasureCertificate(ctx context.Context, host *metal3v1alpha1.BareMetalHost) (string, error) {
+	key, err := r.certificateSigningKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	data, err := erasureCertificateData(newErasureCertificate(host), key)
+	if err != nil {
+		return "", err
+	}
+
+	name := types.NamespacedName{
+		Name:      erasureCertificateName(host),
+		Namespace: host.Namespace,
+	}
+	configMap := &corev1.ConfigMap{}
+	err = r.Get(ctx, name, configMap)
+	switch {
+	case k8serrors.IsNotFound(err):
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name.Name,
+				Namespace: name.Namespace,
+				Labels: map[string]string{
+					erasedHostLabel:    host.Name,
+					erasedHostUIDLabel: string(host.UID),
+				},
+			},
+			Data: data,
+		}
+		err = r.Create(ctx, configMap)
+	case err != nil:
+	case configMap.Labels[erasedHostUIDLabel] != string(host.UID):
+		// Never overwrite the certificate of another host
+		return "", errors.Errorf("ConfigMap %s exists and is not the certificate of the host", name.Name)
+	default:
+		configMap.Data = data
+		err = r.Update(ctx, configMap)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to save ConfigMap %s", name.Name)
+	}
+	return name.Name, nil
+}
+
+// eraseDisks runs an erasure of the disks of the host, recording when
+// it started and finished and, when steps is given, the clean steps
+// that ran. A failed erasure is recorded as errType and started over.
+func (r *BareMetalHostReconciler) eraseDisks(prov provisioner.Provisioner, info *reconcileInfo, opts provisioner.EraseOptions, errType metal3v1alpha1.ErrorType, started, finished **metav1.Time, steps *[]metal3v1alpha1.CompletedCleanStep) actionResult {
+	provResult, startedNow, err := prov.Erase(*started == nil, opts)
+	if err != nil {
+		return actionError{errors.Wrap(err, "failed to erase the host")}
+	}
+	if startedNow {
+		now := metav1.Now()
+		*started = &now
+	}
+	cleaningChanged := r.updateCleaningStatus(info, provResult.CleanStep)
+
+	if provResult.ErrorMessage != "" {
+		// Start over on the next attempt
+		*started = nil
+		return recordActionFailure(info, errType, provResult.ErrorMessage)
+	}
+
+	if provResult.Dirty {
+		result := actionContinue{provResult.RequeueAfter}
+		if clearError(info.host) || startedNow || cleaningChanged {
+			return actionUpdate{result}
+		}
+		return result
+	}
+
+	if steps != nil {
+		*steps = completedStepsSince(info.host, *started)
+		if len(*steps) == 0 {
+			// Nothing shows the disks were erased
+			*started = nil
+			return recordActionFailure(info, errType, "the provisioner reported no clean step for the erasure")
+		}
+	}
+
+	now := metav1.Now()
+	*finished = &now
+	clearError(info.host)
+	return actionUpdate{}
+}
+
+// completedStepsSince returns the clean steps of the host that
+// started after the time.
+func completedStepsSince(host *metal3v1alpha1.BareMetalHost, since *metav1.Time) []metal3v1alpha1.CompletedCleanStep {
+	if host.Status.Cleaning == nil || since == nil {
+		return nil
+	}
+	var steps []metal3v1alpha1.CompletedCleanStep
+	for _, step := range host.Status.Cleaning.CompletedSteps {
+		if !step.Started.Time.Before(since.Time) {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// Erase the disks of a host being retired, record a certificate of
+// erasure and power the host off.
+func (r *BareMetalHostReconciler) actionDecommissioning(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
+	status := info.host.Status.Decommission
+	if status == nil {
+		info.host.Status.Decommission = &metal3v1alpha1.DecommissionStatus{}
+		info.publishEvent("DecommissionStarted", "Decommissioning the host")
+		return actionUpdate{}
+	}
+
+	if status.EraseFinished == nil {
+		if status.EraseStarted == nil {
+			status.SecureEraseBypassed = append([]string(nil), info.host.Spec.SecureEraseBypass...)
+		}
+		info.log.Info("erasing disks", "bypassed", status.SecureEraseBypassed)
+		return r.eraseDisks(prov, info, provisioner.EraseOptions{SkipSerials: status.SecureEraseBypassed},
+			metal3v1alpha1.DecommissionError, &status.EraseStarted, &status.EraseFinished, &status.EraseSteps)
+	}
+
+	// The disks whose secure erase was bypassed still have their
+	// metadata wiped
+	if len(status.SecureEraseBypassed) != 0 && status.MetadataWipeFinished == nil {
+		info.log.Info("wiping the metadata of the bypassed disks", "bypassed", status.SecureEraseBypassed)
+		return r.eraseDisks(prov, info, provisioner.EraseOptions{MetadataOnly: true},
+			metal3v1alpha1.DecommissionError, &status.MetadataWipeStarted, &status.MetadataWipeFinished, &status.MetadataWipeSteps)
+	}
+
+	if status.Certificate == "" {
+		name, err := r.writeErasureCertificate(context.TODO(), info.host)
+		if err != nil {
+			return recordActionFailure(info, metal3v1alpha1.DecommissionError, err.Error())
+		}
+		status.Certificate = name
+		info.log.Info("wrote certificate of erasure", "configMap", name)
+		info.publishEvent("ErasureCertified",
+			fmt.Sprintf("Wrote the certificate of erasure to ConfigMap %s", name))
+		return actionUpdate{}
+	}
+
+	if result := powerOffForGood(prov, info); result != nil {
+		return result
+	}
+	info.publishEvent("Decommissioned", "The host has been erased and powered off")
+	return actionComplete{}
+}
+
+// powerOffForGood powers off a host being taken out of service, and
+// returns nil once it is off.
+func powerOffForGood(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
+	provResult, err := prov.PowerOff(metal3v1alpha1.RebootModeHard, powerRequestID(info.host, false))
+	if err != nil {
+		return actionError{errors.Wrap(err, "failed to power off the host")}
+	}
+	if provResult.ErrorMessage != "" {
+		return recordActionFailure(info, metal3v1alpha1.PowerManagementError, provResult.ErrorMessage)
+	}
+	if provResult.Dirty {
+		result := actionContinue{provResult.RequeueAfter}
+		if clearError(info.host) {
+			return actionUpdate{result}
+		}
+		return result
+	}
+
+	info.host.Status.PoweredOn = false
+	clearError(info.host)
+	return nil
+}
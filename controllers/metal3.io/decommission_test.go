@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	goctx "context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+func newDecommissionReconciler() *BareMetalHostReconciler {
+	key := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "metal3", Name: "erasure-certificate-key"},
+		Data:       map[string][]byte{"key": []byte("secret")},
+	}
+	return &BareMetalHostReconciler{
+		Client:               fakeclient.NewFakeClient(key),
+		CertificateKeySecret: types.NamespacedName{Namespace: key.Namespace, Name: key.Name},
+	}
+}
+
+func TestDecommission(t *testing.T) {
+	host := host(metal3v1alpha1.StateReady).SetStatusPoweredOn(true).build()
+	host.UID = "27720611-e5d1-45d3-ba3a-222dcfaa4ca2"
+	host.Spec.Decommission = true
+	host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{
+		SystemVendor: metal3v1alpha1.HardwareSystemVendor{SerialNumber: "CZ1234"},
+		Storage: []metal3v1alpha1.Storage{
+			{Name: "/dev/sda", SerialNumber: "abc", SizeBytes: 1000, Rotational: true},
+			{Name: "/dev/nvme0n1", SerialNumber: "def", SizeBytes: 2000},
+		},
+	}
+
+	prov := newMockProvisioner()
+	r := newDecommissionReconciler()
+	hsm := newHostStateMachine(host, r, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	hsm.ReconcileState(info)
+	assert.Equal(t, metal3v1alpha1.StateDecommissioning, host.Status.Provisioning.State)
+
+	prov.nextResults["Erase"] = provisioner.Result{Dirty: true, CleanStep: &provisioner.CleanStep{Name: "deploy.erase_devices"}}
+	for i := 0; i < 3; i++ {
+		hsm.ReconcileState(info)
+	}
+	if assert.NotNil(t, host.Status.Decommission) {
+		assert.NotNil(t, host.Status.Decommission.EraseStarted)
+		assert.Nil(t, host.Status.Decommission.EraseFinished)
+	}
+
+	delete(prov.nextResults, "Erase")
+	for i := 0; i < 3; i++ {
+		hsm.ReconcileState(info)
+	}
+	assert.Equal(t, metal3v1alpha1.StateDecommissioned, host.Status.Provisioning.State)
+	assert.False(t, host.Status.PoweredOn)
+	assert.Equal(t, host.Name+"-erasure-certificate", host.Status.Decommission.Certificate)
+
+	configMap := &corev1.ConfigMap{}
+	err := r.Get(goctx.TODO(), types.NamespacedName{
+		Namespace: host.Namespace,
+		Name:      host.Status.Decommission.Certificate,
+	}, configMap)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, configMap.OwnerReferences)
+	assert.Equal(t, string(host.UID), configMap.Labels["metal3.io/erased-host-uid"])
+
+	document := []byte(configMap.Data["certificate.json"])
+	digest := sha256.Sum256(document)
+	assert.Equal(t, hex.EncodeToString(digest[:]), configMap.Data["sha256"])
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(document)
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), configMap.Data["signature"])
+
+	var cert erasureCertificate
+	if assert.NoError(t, json.Unmarshal(document, &cert)) {
+		assert.Equal(t, "CZ1234", cert.SystemSerial)
+		if assert.Len(t, cert.Erasure.Steps, 1) {
+			assert.Equal(t, "deploy.erase_devices", cert.Erasure.Steps[0].Step)
+		}
+		assert.False(t, cert.Erasure.Finished.IsZero())
+		assert.Nil(t, cert.MetadataWipe)
+		if assert.Len(t, cert.Devices, 2) {
+			assert.Equal(t, "def", cert.Devices[1].SerialNumber)
+			assert.Equal(t, []string{"deploy.erase_devices"}, cert.Devices[1].Methods)
+		}
+	}
+
+	// The host stays decommissioned
+	hsm.ReconcileState(info)
+	assert.Equal(t, metal3v1alpha1.StateDecommissioned, host.Status.Provisioning.State)
+}
+
+func TestDecommissionSecureEraseBypass(t *testing.T) {
+	host := host(metal3v1alpha1.StateReady).build()
+	host.Spec.Decommission = true
+	host.Spec.SecureEraseBypass = []string{"abc"}
+	host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{
+		Storage: []metal3v1alpha1.Storage{
+			{Name: "/dev/sda", SerialNumber: "abc", SizeBytes: 1000},
+			{Name: "/dev/sdb", SerialNumber: "def", SizeBytes: 1000},
+		},
+	}
+
+	prov := newMockProvisioner()
+	r := newDecommissionReconciler()
+	hsm := newHostStateMachine(host, r, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	hsm.ReconcileState(info)
+	prov.nextResults["Erase"] = provisioner.Result{Dirty: true, CleanStep: &provisioner.CleanStep{Name: "deploy.erase_devices"}}
+	for i := 0; i < 3; i++ {
+		hsm.ReconcileState(info)
+	}
+	assert.Equal(t, provisioner.EraseOptions{SkipSerials: []string{"abc"}}, prov.eraseOptions)
+	assert.Equal(t, []string{"abc"}, host.Status.Decommission.SecureEraseBypassed)
+
+	delete(prov.nextResults, "Erase")
+	hsm.ReconcileState(info)
+	if assert.NotNil(t, host.Status.Decommission.EraseFinished) {
+		assert.Nil(t, host.Status.Decommission.MetadataWipeStarted)
+	}
+
+	// The metadata of the bypassed disk is wiped next
+	prov.nextResults["Erase"] = provisioner.Result{Dirty: true, CleanStep: &provisioner.CleanStep{Name: "deploy.erase_devices_metadata"}}
+	hsm.ReconcileState(info)
+	assert.Equal(t, provisioner.EraseOptions{MetadataOnly: true}, prov.eraseOptions)
+	delete(prov.nextResults, "Erase")
+	hsm.ReconcileState(info)
+	assert.NotNil(t, host.Status.Decommission.MetadataWipeFinished)
+
+	for i := 0; i < 3; i++ {
+		hsm.ReconcileState(info)
+	}
+	assert.Equal(t, metal3v1alpha1.StateDecommissioned, host.Status.Provisioning.State)
+
+	configMap := &corev1.ConfigMap{}
+	err := r.Get(goctx.TODO(), types.NamespacedName{
+		Namespace: host.Namespace,
+		Name:      host.Status.Decommission.Certificate,
+	}, configMap)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var cert erasureCertificate
+	if assert.NoError(t, json.Unmarshal([]byte(configMap.Data["certificate.json"]), &cert)) && assert.Len(t, cert.Devices, 2) {
+		assert.True(t, cert.Devices[0].SecureEraseBypassed)
+		assert.Equal(t, []string{"deploy.erase_devices_metadata"}, cert.Devices[0].Methods)
+		assert.False(t, cert.Devices[1].SecureEraseBypassed)
+		assert.Equal(t, []string{"deploy.erase_devices"}, cert.Devices[1].Methods)
+		assert.NotNil(t, cert.MetadataWipe)
+	}
+}
+
+func TestDecommissionEraseUnconfirmed(t *testing.T) {
+	host := host(metal3v1alpha1.StateDecommissioning).build()
+	now := metav1.Now()
+	host.Status.Decommission = &metal3v1alpha1.DecommissionStatus{EraseStarted: &now}
+
+	// The provisioner completes without running any clean step
+	hsm := newHostStateMachine(host, newDecommissionReconciler(), newMockProvisioner(), true)
+	info := makeDefaultReconcileInfo(host)
+
+	result := hsm.ReconcileState(info)
+	assert.IsType(t, actionFailed{}, result)
+	assert.Equal(t, metal3v1alpha1.DecommissionError, host.Status.ErrorType)
+	assert.Nil(t, host.Status.Decommission.EraseStarted)
+	assert.Nil(t, host.Status.Decommission.EraseFinished)
+}
+
+func TestDecommissionWithoutKey(t *testing.T) {
+	host := host(metal3v1alpha1.StateDecommissioning).build()
+	now := metav1.Now()
+	host.Status.Decommission = &metal3v1alpha1.DecommissionStatus{
+		EraseStarted:  &now,
+		EraseFinished: &now,
+		EraseSteps:    []metal3v1alpha1.CompletedCleanStep{{Step: "deploy.erase_devices", Started: now}},
+	}
+
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()},
+		newMockProvisioner(), true)
+	info := makeDefaultReconcileInfo(host)
+
+	result := hsm.ReconcileState(info)
+	assert.IsType(t, actionFailed{}, result)
+	assert.Equal(t, metal3v1alpha1.DecommissionError, host.Status.ErrorType)
+	assert.Empty(t, host.Status.Decommission.Certificate)
+}
+
+func TestDecommissionEraseFailed(t *testing.T) {
+	host := host(metal3v1alpha1.StateDecommissioning).build()
+	now := metav1.Now()
+	host.Status.Decommission = &metal3v1alpha1.DecommissionStatus{EraseStarted: &now}
+
+	prov := newMockProvisioner()
+	prov.setNextError("Erase", "erase_devices failed")
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	result := hsm.ReconcileState(info)
+	assert.IsType(t, actionFailed{}, result)
+	assert.Equal(t, metal3v1alpha1.DecommissionError, host.Status.ErrorType)
+	// The next attempt erases the disks again
+	assert.Nil(t, host.Status.Decommission.EraseStarted)
+	assert.Equal(t, metal3v1alpha1.StateDecommissioning, host.Status.Provisioning.State)
+}
+
+func TestDecommissionProvisioned(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).build()
+	host.Spec.Decommission = true
+
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{}, newMockProvisioner(), true)
+	info := makeDefaultReconcileInfo(host)
+
+	hsm.ReconcileState(info)
+	assert.Equal(t, metal3v1alpha1.StateDeprovisioning, host.Status.Provisioning.State)
+}
+
+func TestWriteErasureCertificateConflict(t *testing.T) {
+	host := host(metal3v1alpha1.StateDecommissioning).build()
+	host.UID = "27720611-e5d1-45d3-ba3a-222dcfaa4ca2"
+	other := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      erasureCertificateName(host),
+			Namespace: host.Namespace,
+			Labels:    map[string]string{"metal3.io/erased-host-uid": "another-host"},
+		},
+		Data: map[string]string{"certificate.json": "{}"},
+	}
+	r := &BareMetalHostReconciler{Client: fakeclient.NewFakeClient(other)}
+
+	_, err := r.writeErasureCertificate(goctx.TODO(), host)
+	assert.Error(t, err)
+}
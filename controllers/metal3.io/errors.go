@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"fmt"
+	"time"
 )
 
 // EmptyBMCAddressError is returned when the BMC address field
@@ -58,3 +59,14 @@ type NoDataInSecretError struct {
 func (e NoDataInSecretError) Error() string {
 	return fmt.Sprintf("Secret %s does not contain key %s", e.secret, e.key)
 }
+
+// ProvisionerTimeoutError is returned when a call to the provisioner
+// does not complete within the timeout of the reconciler
+type ProvisionerTimeoutError struct {
+	Method  string
+	Timeout time.Duration
+}
+
+func (e ProvisionerTimeoutError) Error() string {
+	return fmt.Sprintf("provisioner call %s did not complete within %s", e.Method, e.Timeout)
+}
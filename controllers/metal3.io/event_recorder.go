@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// eventRecorder publishes events the way the event recorders of
+// client-go do, so that a flapping error does not flood the API with
+// a new object per occurrence: an event identical to a recent one
+// increments the count and the series of the existing object, similar
+// events that only differ by their message are combined, and the
+// events of a host that keeps spamming are dropped. Unlike the
+// recorders of client-go, events are written before returning.
+type eventRecorder struct {
+	correlator *record.EventCorrelator
+}
+
+func newEventRecorder(clock clock.Clock) *eventRecorder {
+	return &eventRecorder{
+		correlator: record.NewEventCorrelatorWithOptions(record.CorrelatorOptions{Clock: clock}),
+	}
+}
+
+// record creates the event, or updates the existing one it repeats.
+// A nil recorder creates every event.
+func (r *eventRecorder) record(ctx context.Context, c client.Client, event corev1.Event) error {
+	if r == nil {
+		return c.Create(ctx, &event)
+	}
+	correlator := r.correlator
+	result, err := correlator.EventCorrelate(&event)
+	if err != nil {
+		return errors.Wrap(err, "failed to correlate event")
+	}
+	if result.Skip {
+		return nil
+	}
+
+	correlated := result.Event
+	if correlated.Count > 1 {
+		correlated.Series = &corev1.EventSeries{
+			Count:            correlated.Count,
+			LastObservedTime: metav1.NewMicroTime(correlated.LastTimestamp.Time),
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"count":         correlated.Count,
+			"lastTimestamp": correlated.LastTimestamp,
+			"message":       correlated.Message,
+			"series":        correlated.Series,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to build event patch")
+		}
+		err = c.Patch(ctx, correlated, client.RawPatch(types.MergePatchType, patch))
+		if err == nil {
+			correlator.UpdateState(correlated)
+			return nil
+		}
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+		// The event expired, start over with a new one
+		correlated.Name = ""
+	}
+
+	correlated.ResourceVersion = ""
+	if err := c.Create(ctx, correlated); err != nil {
+		return err
+	}
+	correlator.UpdateState(correlated)
+	return nil
+}
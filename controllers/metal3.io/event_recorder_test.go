@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	goctx "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func listEvents(t *testing.T, c client.Client) []corev1.Event {
+	events := &corev1.EventList{}
+	if err := c.List(goctx.TODO(), events); err != nil {
+		t.Fatal(err)
+	}
+	return events.Items
+}
+
+// TestEventRecorderDeduplicates ensures that repeated identical events
+// collapse into a single event with a count.
+func TestEventRecorderDeduplicates(t *testing.T) {
+	recorder := newEventRecorder(clock.NewFakeClock(time.Now()))
+	c := fakeclient.NewFakeClient()
+	host := newDefaultHost(t)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, recorder.record(goctx.TODO(), c, host.NewEvent("RegistrationError", "BMC unreachable")))
+	}
+	assert.NoError(t, recorder.record(goctx.TODO(), c, host.NewEvent("Registered", "Registered new host")))
+
+	events := listEvents(t, c)
+	if !assert.Len(t, events, 2) {
+		return
+	}
+	for _, e := range events {
+		switch e.Reason {
+		case "RegistrationError":
+			assert.Equal(t, int32(3), e.Count)
+			if assert.NotNil(t, e.Series) {
+				assert.Equal(t, int32(3), e.Series.Count)
+			}
+		case "Registered":
+			assert.Equal(t, int32(1), e.Count)
+			assert.Nil(t, e.Series)
+		}
+	}
+}
+
+// TestEventRecorderExpired ensures that an event repeating one that
+// has been deleted is created again.
+func TestEventRecorderExpired(t *testing.T) {
+	recorder := newEventRecorder(clock.NewFakeClock(time.Now()))
+	c := fakeclient.NewFakeClient()
+	host := newDefaultHost(t)
+
+	assert.NoError(t, recorder.record(goctx.TODO(), c, host.NewEvent("RegistrationError", "BMC unreachable")))
+	events := listEvents(t, c)
+	if !assert.Len(t, events, 1) {
+		return
+	}
+	assert.NoError(t, c.Delete(goctx.TODO(), &events[0]))
+
+	assert.NoError(t, recorder.record(goctx.TODO(), c, host.NewEvent("RegistrationError", "BMC unreachable")))
+	assert.Len(t, listEvents(t, c), 1)
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// firmwareBaselineViolations compares the firmware of the host with
+// the firmware baselines of its namespace.
+func firmwareBaselineViolations(ctx context.Context, c client.Reader, host *metal3v1alpha1.BareMetalHost) ([]string, error) {
+	baselines := &metal3v1alpha1.FirmwareBaselineList{}
+	if err := c.List(ctx, baselines, client.InNamespace(host.Namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list firmware baselines")
+	}
+	var violations []string
+	for i := range baselines.Items {
+		_, found := baselines.Items[i].Check(host)
+		violations = append(violations, found...)
+	}
+	return violations, nil
+}
+
+// updateFirmwareCompliance records the firmware baseline violations of
+// the host in its status when they change.
+func (r *BareMetalHostReconciler) updateFirmwareCompliance(request ctrl.Request, host *metal3v1alpha1.BareMetalHost) (bool, error) {
+	violations, err := firmwareBaselineViolations(context.TODO(), r, host)
+	if err != nil {
+		return false, err
+	}
+	firmwareViolations.With(hostMetricLabels(request)).Set(float64(len(violations)))
+	if reflect.DeepEqual(violations, host.Status.FirmwareViolations) {
+		return false, nil
+	}
+
+	host.Status.FirmwareViolations = violations
+	if err := r.saveHostStatus(host); err != nil {
+		return false, errors.Wrap(err, "failed to save firmware compliance")
+	}
+	if len(violations) > 0 {
+		r.publishEvent(request, host.NewEvent("FirmwareNonCompliant",
+			"Firmware older than the baseline: "+strings.Join(violations, "; ")))
+	} else {
+		r.publishEvent(request, host.NewEvent("FirmwareCompliant", "Firmware meets the baselines"))
+	}
+	return true, nil
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	goctx "context"
+	"testing"
+
+	promutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func newFirmwareBaseline(minBIOSVersion string) *metal3v1alpha1.FirmwareBaseline {
+	return &metal3v1alpha1.FirmwareBaseline{
+		ObjectMeta: metav1.ObjectMeta{Name: "q4", Namespace: namespace},
+		Spec: metal3v1alpha1.FirmwareBaselineSpec{
+			Models: []metal3v1alpha1.ModelFirmwareBaseline{
+				{ProductName: "PowerEdge R640", MinBIOSVersion: minBIOSVersion},
+			},
+		},
+	}
+}
+
+func newInspectedHost(name, biosVersion string) *metal3v1alpha1.BareMetalHost {
+	host := newHost(name, &metal3v1alpha1.BareMetalHostSpec{})
+	host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{
+		SystemVendor: metal3v1alpha1.HardwareSystemVendor{ProductName: "PowerEdge R640"},
+		Firmware:     metal3v1alpha1.Firmware{BIOS: metal3v1alpha1.BIOS{Version: biosVersion}},
+	}
+	return host
+}
+
+func TestUpdateFirmwareCompliance(t *testing.T) {
+	host := newInspectedHost("myhost", "2.9.4")
+	r := newTestReconciler(host, newFirmwareBaseline("2.10.2"))
+	request := newRequest(host)
+
+	updated, err := r.updateFirmwareCompliance(request, host)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, []string{"q4: BIOS version 2.9.4, expected 2.10.2 or newer"}, host.Status.FirmwareViolations)
+	compliant := meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.FirmwareCompliantCondition)
+	if assert.NotNil(t, compliant) {
+		assert.Equal(t, metav1.ConditionFalse, compliant.Status)
+	}
+	assert.Equal(t, 1.0, promutil.ToFloat64(firmwareViolations.With(hostMetricLabels(request))))
+
+	updated, err = r.updateFirmwareCompliance(request, host)
+	assert.NoError(t, err)
+	assert.False(t, updated, "unchanged violations are not saved again")
+
+	host.Status.HardwareDetails.Firmware.BIOS.Version = "2.10.2"
+	updated, err = r.updateFirmwareCompliance(request, host)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	assert.Empty(t, host.Status.FirmwareViolations)
+	assert.Equal(t, 0.0, promutil.ToFloat64(firmwareViolations.With(hostMetricLabels(request))))
+}
+
+func TestFirmwareBaselineResults(t *testing.T) {
+	baseline := newFirmwareBaseline("2.10.2")
+	baseline.Generation = 2
+	compliant := newInspectedHost("compliant", "2.11.2")
+	outdated := newInspectedHost("outdated", "2.9.4")
+	other := newHost("other", &metal3v1alpha1.BareMetalHostSpec{})
+	r := &FirmwareBaselineReconciler{
+		Client: fakeclient.NewFakeClient(baseline, compliant, outdated, other),
+		Log:    ctrl.Log.WithName("controllers").WithName("FirmwareBaseline"),
+	}
+
+	name := types.NamespacedName{Namespace: namespace, Name: "q4"}
+	result, err := r.Reconcile(goctx.TODO(), ctrl.Request{NamespacedName: name})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, firmwareBaselineResyncInterval, result.RequeueAfter)
+
+	updated := &metal3v1alpha1.FirmwareBaseline{}
+	if assert.NoError(t, r.Get(goctx.TODO(), name, updated)) {
+		assert.Equal(t, 1, updated.Status.Compliant)
+		assert.Equal(t, 1, updated.Status.NonCompliant)
+		assert.Equal(t, int64(2), updated.Status.ObservedGeneration)
+		assert.NotNil(t, updated.Status.LastUpdated)
+	}
+	assert.Len(t, r.baselinesForHost(other), 1)
+}
+
+func TestFirmwareHostUpdateEventHandler(t *testing.T) {
+	oldHost := newInspectedHost("host", "2.9.4")
+
+	newHost := oldHost.DeepCopy()
+	newHost.Status.PoweredOn = true
+	newHost.Status.LastUpdated = &metav1.Time{}
+	assert.False(t, firmwareHostUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+
+	newHost = oldHost.DeepCopy()
+	newHost.Status.HardwareDetails.Firmware.BIOS.Version = "2.11.2"
+	assert.True(t, firmwareHostUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+}
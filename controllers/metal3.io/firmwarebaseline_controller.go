@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+const firmwareBaselineResyncInterval = 10 * time.Minute
+
+// FirmwareBaselineReconciler reports the number of compliant and
+// non-compliant hosts covered by each firmware baseline in its
+// status. The violations of each host are recorded by the
+// BareMetalHost controller.
+type FirmwareBaselineReconciler struct {
+	client.Client
+	Log logr.Logger
+}
+
+// +kubebuilder:rbac:groups=metal3.io,resources=firmwarebaselines,verbs=get;list;watch
+// +kubebuilder:rbac:groups=metal3.io,resources=firmwarebaselines/status,verbs=get;update;patch
+
+// Reconcile updates the compliance of one baseline.
+func (r *FirmwareBaselineReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("firmwarebaseline", request.NamespacedName)
+
+	baseline := &metal3v1alpha1.FirmwareBaseline{}
+	if err := r.Get(ctx, request.NamespacedName, baseline); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, errors.Wrap(err, "could not load firmware baseline")
+	}
+
+	hosts := &metal3v1alpha1.BareMetalHostList{}
+	if err := r.List(ctx, hosts, client.InNamespace(baseline.Namespace)); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list hosts")
+	}
+	compliant, nonCompliant := 0, 0
+	for i := range hosts.Items {
+		covered, violations := baseline.Check(&hosts.Items[i])
+		switch {
+		case !covered:
+		case len(violations) > 0:
+			nonCompliant++
+		default:
+			compliant++
+		}
+	}
+
+	if baseline.Status.LastUpdated != nil && baseline.Status.ObservedGeneration == baseline.Generation &&
+		baseline.Status.Compliant == compliant && baseline.Status.NonCompliant == nonCompliant {
+		return ctrl.Result{RequeueAfter: firmwareBaselineResyncInterval}, nil
+	}
+
+	now := metav1.Now()
+	baseline.Status.Compliant = compliant
+	baseline.Status.NonCompliant = nonCompliant
+	baseline.Status.ObservedGeneration = baseline.Generation
+	baseline.Status.LastUpdated = &now
+	if err := r.Status().Update(ctx, baseline); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to update firmware baseline status")
+	}
+	reqLogger.Info("updated firmware baseline compliance", "compliant", compliant, "nonCompliant", nonCompliant)
+	return ctrl.Result{RequeueAfter: firmwareBaselineResyncInterval}, nil
+}
+
+// baselinesForHost returns the baselines of the namespace of the host.
+func (r *FirmwareBaselineReconciler) baselinesForHost(obj client.Object) []reconcile.Request {
+	baselines := &metal3v1alpha1.FirmwareBaselineList{}
+	if err := r.List(context.TODO(), baselines, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Info("failed to list firmware baselines", "namespace", obj.GetNamespace(), "error", err)
+		return nil
+	}
+	requests := make([]reconcile.Request, len(baselines.Items))
+	for i, baseline := range baselines.Items {
+		requests[i] = reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: baseline.Namespace, Name: baseline.Name},
+		}
+	}
+	return requests
+}
+
+// firmwareHostUpdateEventHandler discards the updates of hosts that
+// cannot change their compliance, such as the power state polls and
+// most other status saves.
+func firmwareHostUpdateEventHandler(e event.UpdateEvent) bool {
+	oldHost, oldOK := e.ObjectOld.(*metal3v1alpha1.BareMetalHost)
+	newHost, newOK := e.ObjectNew.(*metal3v1alpha1.BareMetalHost)
+	if !(oldOK && newOK) {
+		return true
+	}
+	return !equality.Semantic.DeepEqual(oldHost.Status.HardwareDetails, newHost.Status.HardwareDetails)
+}
+
+// SetupWithManager registers the reconciler to be run by the manager
+func (r *FirmwareBaselineReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&metal3v1alpha1.FirmwareBaseline{}).
+		Watches(&source.Kind{Type: &metal3v1alpha1.BareMetalHost{}},
+			handler.EnqueueRequestsFromMapFunc(r.baselinesForHost),
+			builder.WithPredicates(predicate.Funcs{
+				UpdateFunc: firmwareHostUpdateEventHandler,
+			})).
+		Complete(r)
+}
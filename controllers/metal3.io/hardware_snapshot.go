@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// hardwareHistoryLimit returns how many hardware data snapshots are
+// kept for the host.
+func hardwareHistoryLimit(host *metal3v1alpha1.BareMetalHost) int {
+	if host.Spec.Inspection == nil {
+		return 0
+	}
+	return host.Spec.Inspection.HistoryLimit
+}
+
+// hardwareSnapshotName returns the name of the snapshot of the host
+// taken at the given time, shortening the name of the host if needed.
+func hardwareSnapshotName(host *metal3v1alpha1.BareMetalHost, inspected metav1.Time) string {
+	suffix := fmt.Sprintf("-hw-%d", inspected.Unix())
+	name := host.Name
+	if len(name)+len(suffix) > validation.DNS1123SubdomainMaxLength {
+		name = name[:validation.DNS1123SubdomainMaxLength-len(suffix)]
+	}
+	return name + suffix
+}
+
+// recordHardwareSnapshot keeps the hardware details found by an
+// inspection of the host as a snapshot owned by the host, then deletes
+// the oldest snapshots beyond the history limit of the host. A failure
+// is reported as an event and does not fail the inspection.
+func (r *BareMetalHostReconciler) recordHardwareSnapshot(info *reconcileInfo, details *metal3v1alpha1.HardwareDetails, source metal3v1alpha1.HardwareSnapshotSource, changes []string) {
+	limit := hardwareHistoryLimit(info.host)
+	if limit <= 0 {
+		return
+	}
+	if err := r.saveHardwareSnapshot(context.TODO(), info.host, details, source, changes, limit); err != nil {
+		info.log.Info("failed to record the hardware data snapshot", "error", err.Error())
+		info.publishEvent("HardwareSnapshotFailed", err.Error())
+	}
+}
+
+func (r *BareMetalHostReconciler) saveHardwareSnapshot(ctx context.Context, host *metal3v1alpha1.BareMetalHost, details *metal3v1alpha1.HardwareDetails, source metal3v1alpha1.HardwareSnapshotSource, changes []string, limit int) error {
+	now := metav1.Now()
+	snapshot := &metal3v1alpha1.HardwareDataSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      hardwareSnapshotName(host, now),
+			Namespace: host.Namespace,
+		},
+		Spec: metal3v1alpha1.HardwareDataSnapshotSpec{
+			HostName:        host.Name,
+			Inspected:       now,
+			Source:          source,
+			HardwareDetails: *details.DeepCopy(),
+			Changes:         changes,
+		},
+	}
+	if err := controllerutil.SetOwnerReference(host, snapshot, r.Scheme()); err != nil {
+		return err
+	}
+	if err := r.Create(ctx, snapshot); err != nil {
+		return errors.Wrapf(err, "failed to save hardware data snapshot %s", snapshot.Name)
+	}
+
+	snapshots, err := r.hardwareSnapshots(ctx, host)
+	if err != nil {
+		return err
+	}
+	for i := limit; i < len(snapshots); i++ {
+		if err := r.Delete(ctx, &snapshots[i]); client.IgnoreNotFound(err) != nil {
+			return errors.Wrapf(err, "failed to delete hardware data snapshot %s", snapshots[i].Name)
+		}
+	}
+	return nil
+}
+
+// hardwareSnapshots returns the snapshots of the host, the most recent
+// first.
+func (r *BareMetalHostReconciler) hardwareSnapshots(ctx context.Context, host *metal3v1alpha1.BareMetalHost) ([]metal3v1alpha1.HardwareDataSnapshot, error) {
+	list := &metal3v1alpha1.HardwareDataSnapshotList{}
+	if err := r.List(ctx, list, client.InNamespace(host.Namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list hardware data snapshots")
+	}
+	var snapshots []metal3v1alpha1.HardwareDataSnapshot
+	for _, snapshot := range list.Items {
+		if snapshot.Spec.HostName == host.Name && ownedBy(&snapshot, host) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		ti, tj := snapshots[i].Spec.Inspected, snapshots[j].Spec.Inspected
+		if !ti.Equal(&tj) {
+			return tj.Before(&ti)
+		}
+		return snapshots[i].Name > snapshots[j].Name
+	})
+	return snapshots, nil
+}
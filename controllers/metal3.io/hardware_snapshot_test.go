@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	goctx "context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func newHardwareSnapshot(host *metal3v1alpha1.BareMetalHost, name string, age time.Duration) *metal3v1alpha1.HardwareDataSnapshot {
+	return &metal3v1alpha1.HardwareDataSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: host.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "metal3.io/v1alpha1", Kind: "BareMetalHost", Name: host.Name, UID: host.UID},
+			},
+		},
+		Spec: metal3v1alpha1.HardwareDataSnapshotSpec{
+			HostName:  host.Name,
+			Inspected: metav1.NewTime(time.Now().Add(-age)),
+			Source:    metal3v1alpha1.HardwareSnapshotInspection,
+		},
+	}
+}
+
+func TestHardwareSnapshotName(t *testing.T) {
+	host := &metal3v1alpha1.BareMetalHost{}
+	inspected := metav1.NewTime(time.Unix(1792576800, 0))
+
+	host.Name = "worker-0"
+	assert.Equal(t, "worker-0-hw-1792576800", hardwareSnapshotName(host, inspected))
+
+	host.Name = strings.Repeat("a", 253)
+	name := hardwareSnapshotName(host, inspected)
+	assert.Len(t, name, 253)
+	assert.True(t, strings.HasSuffix(name, "-hw-1792576800"))
+}
+
+func TestRecordHardwareSnapshot(t *testing.T) {
+	host := newDefaultHost(t)
+	host.UID = "27720611-e5d1-45d3-ba3a-222dcfaa4ca2"
+	host.Spec.Inspection = &metal3v1alpha1.InspectionSettings{HistoryLimit: 2}
+	oldest := newHardwareSnapshot(host, "oldest", 2*time.Hour)
+	older := newHardwareSnapshot(host, "older", time.Hour)
+	other := newHardwareSnapshot(host, "other-host", 3*time.Hour)
+	other.Spec.HostName = "worker-1"
+	r := newTestReconciler(host, oldest, older, other)
+	info := makeReconcileInfo(host)
+
+	details := &metal3v1alpha1.HardwareDetails{RAMMebibytes: 49152}
+	changes := []string{"RAM changed from 65536 to 49152 MiB"}
+	r.recordHardwareSnapshot(info, details, metal3v1alpha1.HardwareSnapshotOutOfBand, changes)
+	assert.Empty(t, info.events)
+
+	snapshots, err := r.hardwareSnapshots(goctx.TODO(), host)
+	assert.NoError(t, err)
+	if assert.Len(t, snapshots, 2) {
+		latest := snapshots[0]
+		assert.Equal(t, host.Name, latest.Spec.HostName)
+		assert.Equal(t, metal3v1alpha1.HardwareSnapshotOutOfBand, latest.Spec.Source)
+		assert.Equal(t, 49152, latest.Spec.HardwareDetails.RAMMebibytes)
+		assert.Equal(t, changes, latest.Spec.Changes)
+		assert.True(t, ownedBy(&latest, host))
+		assert.Equal(t, "older", snapshots[1].Name)
+	}
+
+	all := &metal3v1alpha1.HardwareDataSnapshotList{}
+	assert.NoError(t, r.List(goctx.TODO(), all, client.InNamespace(host.Namespace)))
+	assert.Len(t, all.Items, 3, "the snapshot of the other host is kept")
+}
+
+func TestRecordHardwareSnapshotDisabled(t *testing.T) {
+	host := newDefaultHost(t)
+	r := newTestReconciler(host)
+	info := makeReconcileInfo(host)
+
+	r.recordHardwareSnapshot(info, &metal3v1alpha1.HardwareDetails{}, metal3v1alpha1.HardwareSnapshotInspection, nil)
+
+	all := &metal3v1alpha1.HardwareDataSnapshotList{}
+	assert.NoError(t, r.List(goctx.TODO(), all, client.InNamespace(host.Namespace)))
+	assert.Empty(t, all.Items)
+}
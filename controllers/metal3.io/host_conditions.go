@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// conditionReason turns a state or error type such as "externally
+// provisioned" into a condition reason such as "ExternallyProvisioned".
+func conditionReason(value string) string {
+	reason := ""
+	for _, word := range strings.Fields(value) {
+		reason += strings.ToUpper(word[:1]) + word[1:]
+	}
+	if reason == "" {
+		return "Unknown"
+	}
+	return reason
+}
+
+func conditionStatus(value bool) metav1.ConditionStatus {
+	if value {
+		return metav1.ConditionTrue
+	}
+	return metav1.ConditionFalse
+}
+
+// setHostConditions derives the conditions of the host from its
+// provisioning state and error.
+func setHostConditions(host *metal3v1alpha1.BareMetalHost) {
+	state := host.Status.Provisioning.State
+	stateReason := conditionReason(string(state))
+
+	provisioned := state == metal3v1alpha1.StateProvisioned ||
+		state == metal3v1alpha1.StateExternallyProvisioned
+	meta.SetStatusCondition(&host.Status.Conditions, metav1.Condition{
+		Type:               metal3v1alpha1.ProvisionedCondition,
+		Status:             conditionStatus(provisioned),
+		Reason:             stateReason,
+		ObservedGeneration: host.Generation,
+	})
+
+	rejected := len(host.Status.AcceptanceFailures) > 0
+	ready := state == metal3v1alpha1.StateReady ||
+		state == metal3v1alpha1.StateAvailable
+	available := ready && !rejected
+	availableReason := stateReason
+	if ready && rejected {
+		availableReason = "Rejected"
+	}
+	meta.SetStatusCondition(&host.Status.Conditions, metav1.Condition{
+		Type:               metal3v1alpha1.AvailableCondition,
+		Status:             conditionStatus(available),
+		Reason:             availableReason,
+		ObservedGeneration: host.Generation,
+	})
+
+	acceptance := metav1.Condition{
+		Type:               metal3v1alpha1.RejectedCondition,
+		Status:             metav1.ConditionFalse,
+		Reason:             "Accepted",
+		ObservedGeneration: host.Generation,
+	}
+	if rejected {
+		acceptance.Status = metav1.ConditionTrue
+		acceptance.Reason = "AcceptanceTestFailed"
+		acceptance.Message = strings.Join(host.Status.AcceptanceFailures, "; ")
+	}
+	meta.SetStatusCondition(&host.Status.Conditions, acceptance)
+
+	compliance := metav1.Condition{
+		Type:               metal3v1alpha1.FirmwareCompliantCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Compliant",
+		ObservedGeneration: host.Generation,
+	}
+	if len(host.Status.FirmwareViolations) > 0 {
+		compliance.Status = metav1.ConditionFalse
+		compliance.Reason = "FirmwareOutdated"
+		compliance.Message = strings.Join(host.Status.FirmwareViolations, "; ")
+	}
+	meta.SetStatusCondition(&host.Status.Conditions, compliance)
+
+	failed := metav1.Condition{
+		Type:               metal3v1alpha1.FailedCondition,
+		Status:             metav1.ConditionFalse,
+		Reason:             "NoError",
+		ObservedGeneration: host.Generation,
+	}
+	if host.Status.ErrorType != "" {
+		failed.Status = metav1.ConditionTrue
+		failed.Reason = conditionReason(string(host.Status.ErrorType))
+		failed.Message = host.Status.ErrorMessage
+	}
+	meta.SetStatusCondition(&host.Status.Conditions, failed)
+
+	// The reason of a timeout is set when the operation times out
+	timedOut := meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.TimedOutCondition)
+	if host.Status.ErrorType != metal3v1alpha1.TimeoutError {
+		meta.SetStatusCondition(&host.Status.Conditions, metav1.Condition{
+			Type:               metal3v1alpha1.TimedOutCondition,
+			Status:             metav1.ConditionFalse,
+			Reason:             "NoTimeout",
+			ObservedGeneration: host.Generation,
+		})
+	} else if timedOut == nil || timedOut.Status != metav1.ConditionTrue {
+		meta.SetStatusCondition(&host.Status.Conditions, metav1.Condition{
+			Type:               metal3v1alpha1.TimedOutCondition,
+			Status:             metav1.ConditionTrue,
+			Reason:             "Timeout",
+			Message:            host.Status.ErrorMessage,
+			ObservedGeneration: host.Generation,
+		})
+	}
+
+	meta.SetStatusCondition(&host.Status.Conditions, readyCondition(host, stateReason))
+
+	poweredOn := metav1.Condition{
+		Type:               metal3v1alpha1.PoweredOnCondition,
+		Status:             conditionStatus(host.Status.PoweredOn),
+		Reason:             "PoweredOff",
+		ObservedGeneration: host.Generation,
+	}
+	if host.Status.PoweredOn {
+		poweredOn.Reason = "PoweredOn"
+	}
+	meta.SetStatusCondition(&host.Status.Conditions, poweredOn)
+
+	inspected := metav1.Condition{
+		Type:               metal3v1alpha1.InspectedCondition,
+		Status:             conditionStatus(host.Status.HardwareDetails != nil),
+		Reason:             "NotInspected",
+		ObservedGeneration: host.Generation,
+	}
+	if host.Status.HardwareDetails != nil {
+		inspected.Reason = "Inspected"
+	} else if state == metal3v1alpha1.StateInspecting {
+		inspected.Reason = stateReason
+	}
+	meta.SetStatusCondition(&host.Status.Conditions, inspected)
+
+	degraded := metav1.Condition{
+		Type:               metal3v1alpha1.DegradedCondition,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: host.Generation,
+	}
+	disks := failingDisks(host)
+	switch {
+	case host.Status.ErrorType != "":
+		degraded.Reason = failed.Reason
+		degraded.Message = failed.Message
+	case compliance.Status == metav1.ConditionFalse:
+		degraded.Reason = compliance.Reason
+		degraded.Message = compliance.Message
+	case rejected:
+		degraded.Reason = "Rejected"
+		degraded.Message = acceptance.Message
+	case len(disks) != 0:
+		degraded.Reason = "DiskFailing"
+		degraded.Message = strings.Join(disks, "; ")
+	default:
+		degraded.Status = metav1.ConditionFalse
+		degraded.Reason = "AsExpected"
+	}
+	meta.SetStatusCondition(&host.Status.Conditions, degraded)
+}
+
+// failingDisks describes the disks of the host whose last inspection
+// reported failing health.
+func failingDisks(host *metal3v1alpha1.BareMetalHost) (failing []string) {
+	if host.Status.HardwareDetails == nil {
+		return nil
+	}
+	for _, disk := range host.Status.HardwareDetails.Storage {
+		if diskFailing(disk) {
+			failing = append(failing, fmt.Sprintf("disk %s is failing: %s", disk.Name, disk.Health.Message))
+		}
+	}
+	return failing
+}
+
+// readyCondition returns the Ready condition of the host, whose reason
+// tells what an unready host is waiting for.
+func readyCondition(host *metal3v1alpha1.BareMetalHost, stateReason string) metav1.Condition {
+	ready := metav1.Condition{
+		Type:               metal3v1alpha1.ReadyCondition,
+		Status:             metav1.ConditionFalse,
+		Reason:             stateReason,
+		ObservedGeneration: host.Generation,
+	}
+	switch host.Status.Provisioning.State {
+	case metal3v1alpha1.StateReady, metal3v1alpha1.StateAvailable,
+		metal3v1alpha1.StateProvisioned, metal3v1alpha1.StateExternallyProvisioned:
+	default:
+		return ready
+	}
+
+	switch {
+	case host.Status.ErrorType != "":
+		ready.Reason = conditionReason(string(host.Status.ErrorType))
+		ready.Message = host.Status.ErrorMessage
+	case host.Status.OperationalStatus != "" && host.Status.OperationalStatus != metal3v1alpha1.OperationalStatusOK:
+		ready.Reason = conditionReason(string(host.Status.OperationalStatus))
+	case host.Status.PoweredOn != desiredPowerState(host, time.Now()):
+		ready.Reason = "PowerChanging"
+	default:
+		ready.Status = metav1.ConditionTrue
+	}
+	return ready
+}
@@ -0,0 +1,313 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestSetHostConditions(t *testing.T) {
+	testCases := []struct {
+		Scenario          string
+		State             metal3v1alpha1.ProvisioningState
+		ErrorType         metal3v1alpha1.ErrorType
+		ExpectProvisioned metav1.ConditionStatus
+		ExpectAvailable   metav1.ConditionStatus
+		ExpectFailed      metav1.ConditionStatus
+		ExpectReason      string
+		ExpectFailReason  string
+	}{
+		{
+			Scenario:          "new host",
+			State:             metal3v1alpha1.StateNone,
+			ExpectProvisioned: metav1.ConditionFalse,
+			ExpectAvailable:   metav1.ConditionFalse,
+			ExpectFailed:      metav1.ConditionFalse,
+			ExpectReason:      "Unknown",
+			ExpectFailReason:  "NoError",
+		},
+		{
+			Scenario:          "ready",
+			State:             metal3v1alpha1.StateReady,
+			ExpectProvisioned: metav1.ConditionFalse,
+			ExpectAvailable:   metav1.ConditionTrue,
+			ExpectFailed:      metav1.ConditionFalse,
+			ExpectReason:      "Ready",
+			ExpectFailReason:  "NoError",
+		},
+		{
+			Scenario:          "provisioned",
+			State:             metal3v1alpha1.StateProvisioned,
+			ExpectProvisioned: metav1.ConditionTrue,
+			ExpectAvailable:   metav1.ConditionFalse,
+			ExpectFailed:      metav1.ConditionFalse,
+			ExpectReason:      "Provisioned",
+			ExpectFailReason:  "NoError",
+		},
+		{
+			Scenario:          "externally provisioned",
+			State:             metal3v1alpha1.StateExternallyProvisioned,
+			ExpectProvisioned: metav1.ConditionTrue,
+			ExpectAvailable:   metav1.ConditionFalse,
+			ExpectFailed:      metav1.ConditionFalse,
+			ExpectReason:      "ExternallyProvisioned",
+			ExpectFailReason:  "NoError",
+		},
+		{
+			Scenario:          "provisioning failed",
+			State:             metal3v1alpha1.StateProvisioning,
+			ErrorType:         metal3v1alpha1.ProvisioningError,
+			ExpectProvisioned: metav1.ConditionFalse,
+			ExpectAvailable:   metav1.ConditionFalse,
+			ExpectFailed:      metav1.ConditionTrue,
+			ExpectReason:      "Provisioning",
+			ExpectFailReason:  "ProvisioningError",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := &metal3v1alpha1.BareMetalHost{}
+			host.Generation = 3
+			host.Status.Provisioning.State = tc.State
+			host.Status.ErrorType = tc.ErrorType
+			host.Status.ErrorMessage = "oops"
+
+			setHostConditions(host)
+
+			provisioned := meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.ProvisionedCondition)
+			available := meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.AvailableCondition)
+			failed := meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.FailedCondition)
+			if !assert.NotNil(t, provisioned) || !assert.NotNil(t, available) || !assert.NotNil(t, failed) {
+				return
+			}
+			assert.Equal(t, tc.ExpectProvisioned, provisioned.Status)
+			assert.Equal(t, tc.ExpectAvailable, available.Status)
+			assert.Equal(t, tc.ExpectFailed, failed.Status)
+			assert.Equal(t, tc.ExpectReason, provisioned.Reason)
+			assert.Equal(t, tc.ExpectReason, available.Reason)
+			assert.Equal(t, tc.ExpectFailReason, failed.Reason)
+			assert.Equal(t, int64(3), failed.ObservedGeneration)
+			if tc.ErrorType != "" {
+				assert.Equal(t, "oops", failed.Message)
+			} else {
+				assert.Empty(t, failed.Message)
+			}
+		})
+	}
+}
+
+func TestSetHostConditionsRejected(t *testing.T) {
+	host := &metal3v1alpha1.BareMetalHost{}
+	host.Status.Provisioning.State = metal3v1alpha1.StateReady
+
+	setHostConditions(host)
+	rejected := meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.RejectedCondition)
+	if assert.NotNil(t, rejected) {
+		assert.Equal(t, metav1.ConditionFalse, rejected.Status)
+		assert.Equal(t, "Accepted", rejected.Reason)
+	}
+
+	host.Status.AcceptanceFailures = []string{"intake: 1 disks, expected 2", "intake: BIOS version unknown"}
+	setHostConditions(host)
+	rejected = meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.RejectedCondition)
+	if assert.NotNil(t, rejected) {
+		assert.Equal(t, metav1.ConditionTrue, rejected.Status)
+		assert.Equal(t, "intake: 1 disks, expected 2; intake: BIOS version unknown", rejected.Message)
+	}
+	available := meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.AvailableCondition)
+	if assert.NotNil(t, available) {
+		assert.Equal(t, metav1.ConditionFalse, available.Status)
+		assert.Equal(t, "Rejected", available.Reason)
+	}
+}
+
+func TestSetHostConditionsFirmwareCompliant(t *testing.T) {
+	host := &metal3v1alpha1.BareMetalHost{}
+	setHostConditions(host)
+	compliant := meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.FirmwareCompliantCondition)
+	if assert.NotNil(t, compliant) {
+		assert.Equal(t, metav1.ConditionTrue, compliant.Status)
+	}
+
+	host.Status.FirmwareViolations = []string{"q4: BIOS version 2.9.4, expected 2.10.2 or newer"}
+	setHostConditions(host)
+	compliant = meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.FirmwareCompliantCondition)
+	if assert.NotNil(t, compliant) {
+		assert.Equal(t, metav1.ConditionFalse, compliant.Status)
+		assert.Equal(t, "FirmwareOutdated", compliant.Reason)
+		assert.Equal(t, "q4: BIOS version 2.9.4, expected 2.10.2 or newer", compliant.Message)
+	}
+}
+
+func TestSetHostConditionsReady(t *testing.T) {
+	testCases := []struct {
+		Scenario          string
+		State             metal3v1alpha1.ProvisioningState
+		ErrorType         metal3v1alpha1.ErrorType
+		OperationalStatus metal3v1alpha1.OperationalStatus
+		Online            bool
+		PoweredOn         bool
+		Idle              bool
+		ExpectReady       metav1.ConditionStatus
+		ExpectReason      string
+	}{
+		{
+			Scenario:     "inspecting",
+			State:        metal3v1alpha1.StateInspecting,
+			ExpectReady:  metav1.ConditionFalse,
+			ExpectReason: "Inspecting",
+		},
+		{
+			Scenario:          "ready",
+			State:             metal3v1alpha1.StateReady,
+			OperationalStatus: metal3v1alpha1.OperationalStatusOK,
+			ExpectReady:       metav1.ConditionTrue,
+			ExpectReason:      "Ready",
+		},
+		{
+			Scenario:          "provisioned and powered on",
+			State:             metal3v1alpha1.StateProvisioned,
+			OperationalStatus: metal3v1alpha1.OperationalStatusOK,
+			Online:            true,
+			PoweredOn:         true,
+			ExpectReady:       metav1.ConditionTrue,
+			ExpectReason:      "Provisioned",
+		},
+		{
+			Scenario:          "powering on",
+			State:             metal3v1alpha1.StateProvisioned,
+			OperationalStatus: metal3v1alpha1.OperationalStatusOK,
+			Online:            true,
+			ExpectReady:       metav1.ConditionFalse,
+			ExpectReason:      "PowerChanging",
+		},
+		{
+			Scenario:          "powered off while idle",
+			State:             metal3v1alpha1.StateAvailable,
+			OperationalStatus: metal3v1alpha1.OperationalStatusOK,
+			Online:            true,
+			Idle:              true,
+			ExpectReady:       metav1.ConditionTrue,
+			ExpectReason:      "Available",
+		},
+		{
+			Scenario:          "power management failed",
+			State:             metal3v1alpha1.StateProvisioned,
+			ErrorType:         metal3v1alpha1.PowerManagementError,
+			OperationalStatus: metal3v1alpha1.OperationalStatusError,
+			ExpectReady:       metav1.ConditionFalse,
+			ExpectReason:      "PowerManagementError",
+		},
+		{
+			Scenario:          "delayed",
+			State:             metal3v1alpha1.StateReady,
+			OperationalStatus: metal3v1alpha1.OperationalStatusDelayed,
+			ExpectReady:       metav1.ConditionFalse,
+			ExpectReason:      "Delayed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := &metal3v1alpha1.BareMetalHost{}
+			host.Spec.Online = tc.Online
+			host.Status.Provisioning.State = tc.State
+			host.Status.ErrorType = tc.ErrorType
+			host.Status.OperationalStatus = tc.OperationalStatus
+			host.Status.PoweredOn = tc.PoweredOn
+			if tc.Idle {
+				host.Spec.PowerPolicy = &metal3v1alpha1.PowerPolicy{IdlePowerOffAfter: &metav1.Duration{Duration: time.Hour}}
+				availableSince := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+				host.Status.AvailableSince = &availableSince
+			}
+
+			setHostConditions(host)
+
+			ready := meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.ReadyCondition)
+			if assert.NotNil(t, ready) {
+				assert.Equal(t, tc.ExpectReady, ready.Status)
+				assert.Equal(t, tc.ExpectReason, ready.Reason)
+			}
+		})
+	}
+}
+
+func TestSetHostConditionsHardware(t *testing.T) {
+	host := &metal3v1alpha1.BareMetalHost{}
+	host.Status.Provisioning.State = metal3v1alpha1.StateInspecting
+
+	setHostConditions(host)
+	poweredOn := meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.PoweredOnCondition)
+	if assert.NotNil(t, poweredOn) {
+		assert.Equal(t, metav1.ConditionFalse, poweredOn.Status)
+		assert.Equal(t, "PoweredOff", poweredOn.Reason)
+	}
+	inspected := meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.InspectedCondition)
+	if assert.NotNil(t, inspected) {
+		assert.Equal(t, metav1.ConditionFalse, inspected.Status)
+		assert.Equal(t, "Inspecting", inspected.Reason)
+	}
+
+	host.Status.PoweredOn = true
+	host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{}
+	setHostConditions(host)
+	poweredOn = meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.PoweredOnCondition)
+	if assert.NotNil(t, poweredOn) {
+		assert.Equal(t, metav1.ConditionTrue, poweredOn.Status)
+	}
+	inspected = meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.InspectedCondition)
+	if assert.NotNil(t, inspected) {
+		assert.Equal(t, metav1.ConditionTrue, inspected.Status)
+		assert.Equal(t, "Inspected", inspected.Reason)
+	}
+}
+
+func TestSetHostConditionsDegraded(t *testing.T) {
+	host := &metal3v1alpha1.BareMetalHost{}
+	setHostConditions(host)
+	degraded := meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.DegradedCondition)
+	if assert.NotNil(t, degraded) {
+		assert.Equal(t, metav1.ConditionFalse, degraded.Status)
+		assert.Equal(t, "AsExpected", degraded.Reason)
+	}
+
+	host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{
+		Storage: []metal3v1alpha1.Storage{
+			{Name: "/dev/sda", Health: &metal3v1alpha1.DiskHealth{Status: metal3v1alpha1.DiskHealthOK}},
+			{Name: "/dev/nvme0n1", Health: &metal3v1alpha1.DiskHealth{
+				Status: metal3v1alpha1.DiskHealthFailing, Message: "critical warning 0x4"}},
+		},
+	}
+	setHostConditions(host)
+	degraded = meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.DegradedCondition)
+	if assert.NotNil(t, degraded) {
+		assert.Equal(t, metav1.ConditionTrue, degraded.Status)
+		assert.Equal(t, "DiskFailing", degraded.Reason)
+		assert.Equal(t, "disk /dev/nvme0n1 is failing: critical warning 0x4", degraded.Message)
+	}
+
+	host.Status.AcceptanceFailures = []string{"intake: 1 disks, expected 2"}
+	setHostConditions(host)
+	degraded = meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.DegradedCondition)
+	if assert.NotNil(t, degraded) {
+		assert.Equal(t, metav1.ConditionTrue, degraded.Status)
+		assert.Equal(t, "Rejected", degraded.Reason)
+	}
+
+	// An error comes first
+	host.Status.ErrorType = metal3v1alpha1.RegistrationError
+	host.Status.ErrorMessage = "BMC unreachable"
+	setHostConditions(host)
+	degraded = meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.DegradedCondition)
+	if assert.NotNil(t, degraded) {
+		assert.Equal(t, metav1.ConditionTrue, degraded.Status)
+		assert.Equal(t, "RegistrationError", degraded.Reason)
+		assert.Equal(t, "BMC unreachable", degraded.Message)
+	}
+}
@@ -1,8 +1,13 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
@@ -49,8 +54,24 @@ func (hcd *hostConfigData) getSecretData(name, namespace, dataKey string) (strin
 	return string(data), nil
 }
 
-// UserData get Operating System configuration data
+// UserData get Operating System configuration data, encrypted with the
+// key of the host when config drive encryption is enabled
 func (hcd *hostConfigData) UserData() (string, error) {
+	userData, err := hcd.plainUserData()
+	if err != nil || userData == "" || hcd.host.Spec.ConfigDriveEncryption == nil {
+		return userData, err
+	}
+	key, err := hcd.configDriveKey()
+	if err != nil {
+		return "", err
+	}
+	return encryptUserData(key, userData)
+}
+
+func (hcd *hostConfigData) plainUserData() (string, error) {
+	if len(hcd.host.Spec.UserDataSecrets) != 0 {
+		return hcd.mergedUserData()
+	}
 	if hcd.host.Spec.UserData == nil {
 		hcd.log.Info("UserData is not set return empty string")
 		return "", nil
@@ -67,6 +88,115 @@ func (hcd *hostConfigData) UserData() (string, error) {
 
 }
 
+// userDataMergeType makes cloud-init merge the cloud-config parts in
+// order: lists are appended to, and values of later parts replace
+// those of earlier ones.
+const userDataMergeType = "list(append)+dict(recurse_array)+str()"
+
+// userDataContentTypes are the MIME types of the user data formats
+// cloud-init recognizes, by the first line of the data.
+var userDataContentTypes = []struct {
+	prefix      string
+	contentType string
+}{
+	{"#cloud-config", "text/cloud-config"},
+	{"#cloud-boothook", "text/cloud-boothook"},
+	{"#include", "text/x-include-url"},
+	{"#part-handler", "text/part-handler"},
+	{"## template: jinja", "text/jinja2"},
+	{"#!", "text/x-shellscript"},
+}
+
+// mergedUserData reads the user data secrets of the host and merges
+// them in order.
+func (hcd *hostConfigData) mergedUserData() (string, error) {
+	var parts []userDataPart
+	for _, ref := range hcd.host.Spec.UserDataSecrets {
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = hcd.host.Namespace
+		}
+		data, err := hcd.getSecretData(ref.Name, namespace, "userData")
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, userDataPart{secret: ref.Name, data: data})
+	}
+	return mergeUserData(parts)
+}
+
+// userDataPart is the user data of one secret.
+type userDataPart struct {
+	secret string
+	data   string
+}
+
+// mergeUserData combines the user data of several secrets into a
+// cloud-init multi-part MIME document, keeping their order. The data of
+// a single secret is returned as it is. The boundary is derived from
+// the data, so that the same secrets always give the same document.
+func mergeUserData(parts []userDataPart) (string, error) {
+	var nonEmpty []userDataPart
+	for _, part := range parts {
+		if strings.TrimSpace(part.data) != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	switch len(nonEmpty) {
+	case 0:
+		return "", nil
+	case 1:
+		return nonEmpty[0].data, nil
+	}
+
+	hash := sha256.New()
+	for _, part := range nonEmpty {
+		hash.Write([]byte(part.data))
+	}
+	out := &bytes.Buffer{}
+	writer := multipart.NewWriter(out)
+	if err := writer.SetBoundary(fmt.Sprintf("metal3-%x", hash.Sum(nil)[:16])); err != nil {
+		return "", errors.Wrap(err, "failed to merge user data")
+	}
+	fmt.Fprintf(out, "Content-Type: multipart/mixed; boundary=\"%s\"\r\nMIME-Version: 1.0\r\n\r\n", writer.Boundary())
+
+	for i, part := range nonEmpty {
+		contentType := userDataContentType(part.data)
+		if contentType == "" {
+			return "", errors.Errorf("the user data of secret %s is not in a format cloud-init recognizes", part.secret)
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", fmt.Sprintf("%s; charset=\"utf-8\"", contentType))
+		header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%02d-%s\"", i, part.secret))
+		if contentType == "text/cloud-config" {
+			header.Set("Merge-Type", userDataMergeType)
+		}
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to merge user data")
+		}
+		if _, err := w.Write([]byte(part.data)); err != nil {
+			return "", errors.Wrap(err, "failed to merge user data")
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return "", errors.Wrap(err, "failed to merge user data")
+	}
+	return out.String(), nil
+}
+
+// userDataContentType returns the MIME type of the user data, or an
+// empty string if cloud-init would not recognize it.
+func userDataContentType(data string) string {
+	data = strings.TrimLeft(data, " \t\r\n")
+	for _, format := range userDataContentTypes {
+		if strings.HasPrefix(data, format.prefix) {
+			return format.contentType
+		}
+	}
+	return ""
+}
+
 // NetworkData get network configuration
 func (hcd *hostConfigData) NetworkData() (string, error) {
 	if hcd.host.Spec.NetworkData == nil {
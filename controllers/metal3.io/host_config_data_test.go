@@ -4,9 +4,17 @@ import (
 	goctx "context"
 	"encoding/base64"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -260,3 +268,74 @@ func TestProvisionWithHostConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeUserData(t *testing.T) {
+	single, err := mergeUserData([]userDataPart{{secret: "base", data: "#cloud-config\n"}, {secret: "empty"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "#cloud-config\n", single)
+
+	_, err = mergeUserData([]userDataPart{{secret: "base", data: "#cloud-config\n"}, {secret: "role", data: "packages: [git]\n"}})
+	assert.Error(t, err, "data without a format")
+
+	parts := []userDataPart{
+		{secret: "base", data: "#cloud-config\npackages: [git]\n"},
+		{secret: "role", data: "#!/bin/sh\necho worker\n"},
+		{secret: "host", data: "#cloud-config\nhostname: worker-0\n"},
+	}
+	merged, err := mergeUserData(parts)
+	assert.NoError(t, err)
+	again, _ := mergeUserData(parts)
+	assert.Equal(t, merged, again, "merging is deterministic")
+
+	message, err := mail.ReadMessage(strings.NewReader(merged))
+	if !assert.NoError(t, err) {
+		return
+	}
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	expectedTypes := []string{"text/cloud-config", "text/x-shellscript", "text/cloud-config"}
+	for i, expected := range parts {
+		part, err := reader.NextPart()
+		if !assert.NoError(t, err) {
+			return
+		}
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		assert.Equal(t, expectedTypes[i], contentType)
+		if contentType == "text/cloud-config" {
+			assert.Equal(t, userDataMergeType, part.Header.Get("Merge-Type"))
+		}
+		data, _ := ioutil.ReadAll(part)
+		assert.Equal(t, expected.data, string(data))
+	}
+	_, err = reader.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestUserDataSecrets(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Spec.UserDataSecrets = []corev1.SecretReference{{Name: "base"}, {Name: "host", Namespace: namespace}}
+	base := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "base", Namespace: namespace},
+		Data:       map[string][]byte{"userData": []byte("#cloud-config\npackages: [git]\n")},
+	}
+	override := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "host", Namespace: namespace},
+		Data:       map[string][]byte{"value": []byte("#cloud-config\nhostname: worker-0\n")},
+	}
+	hcd := &hostConfigData{
+		host:   host,
+		log:    ctrl.Log.WithName("controllers").WithName("BareMetalHost").WithName("host_config_data"),
+		client: fakeclient.NewFakeClient(base, override),
+	}
+
+	userData, err := hcd.UserData()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(userData, "Content-Type: multipart/mixed"))
+	assert.True(t, strings.Index(userData, "packages: [git]") < strings.Index(userData, "hostname: worker-0"))
+
+	host.Spec.UserDataSecrets = append(host.Spec.UserDataSecrets, corev1.SecretReference{Name: "missing"})
+	_, err = hcd.UserData()
+	assert.Error(t, err)
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// quotaReservationExpiry is how long a host admitted to provisioning
+// is counted against the quota of its namespace before the cache is
+// expected to show it in the provisioning state.
+const quotaReservationExpiry = 5 * time.Minute
+
+// quotaReservations counts the hosts admitted to provisioning whose
+// new state may not be in the cache yet, so that hosts reconciled at
+// the same time cannot all take the last place of a quota.
+type quotaReservations struct {
+	lock  sync.Mutex
+	hosts map[string]time.Time
+}
+
+// provisioningQuotaHost reports whether the host uses the provisioned
+// quota of its namespace as far as the controller is concerned: it is
+// being provisioned or was provisioned by the operator, and still
+// counts as provisioned.
+func provisioningQuotaHost(host *metal3v1alpha1.BareMetalHost) bool {
+	switch host.Status.Provisioning.State {
+	case metal3v1alpha1.StateProvisioning, metal3v1alpha1.StateProvisioned:
+		return host.CountsAsProvisioned()
+	}
+	return false
+}
+
+// ensureProvisioningQuota keeps the host from starting to provision if
+// its namespace has as many hosts provisioned, or being provisioned, as
+// one of its quotas allows. The validating webhook rejects such hosts
+// earlier, but it is not always enabled and it does not see hosts
+// admitted at the same time.
+func (r *BareMetalHostReconciler) ensureProvisioningQuota(info *reconcileInfo) actionResult {
+	reservations := r.quotaReservations
+	if reservations == nil {
+		// Only reconcilers set up with a manager share reservations
+		reservations = &quotaReservations{}
+	}
+	reservations.lock.Lock()
+	defer reservations.lock.Unlock()
+
+	quotas := &metal3v1alpha1.HostQuotaList{}
+	if err := r.List(context.TODO(), quotas, client.InNamespace(info.host.Namespace)); err != nil {
+		return actionError{errors.Wrap(err, "failed to list host quotas")}
+	}
+	if len(quotas.Items) == 0 {
+		return nil
+	}
+	hosts := &metal3v1alpha1.BareMetalHostList{}
+	if err := r.List(context.TODO(), hosts, client.InNamespace(info.host.Namespace)); err != nil {
+		return actionError{errors.Wrap(err, "failed to list hosts")}
+	}
+
+	now := time.Now()
+	name := info.request.NamespacedName.String()
+	counted := make(map[string]bool)
+	for i := range hosts.Items {
+		if provisioningQuotaHost(&hosts.Items[i]) {
+			counted[fmt.Sprintf("%s/%s", hosts.Items[i].Namespace, hosts.Items[i].Name)] = true
+		}
+	}
+	for reserved, since := range reservations.hosts {
+		if now.Sub(since) > quotaReservationExpiry {
+			delete(reservations.hosts, reserved)
+		} else {
+			counted[reserved] = true
+		}
+	}
+	delete(counted, name)
+
+	for _, quota := range quotas.Items {
+		if quota.Spec.MaxProvisioned != nil && len(counted) >= *quota.Spec.MaxProvisioned {
+			info.log.Info("not provisioning host past its namespace quota",
+				"quota", quota.Name, "maxProvisioned", *quota.Spec.MaxProvisioned, "provisioned", len(counted))
+			if info.host.OperationalStatus() != metal3v1alpha1.OperationalStatusDelayed {
+				info.publishEvent("QuotaExceeded",
+					fmt.Sprintf("Host quota %s allows %d provisioned hosts", quota.Name, *quota.Spec.MaxProvisioned))
+			}
+			return recordActionDelayed(info)
+		}
+	}
+
+	if info.host.HasDryRunAnnotation() {
+		return nil
+	}
+	if reservations.hosts == nil {
+		reservations.hosts = make(map[string]time.Time)
+	}
+	reservations.hosts[name] = now
+	return nil
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func quotaInfo(host *metal3v1alpha1.BareMetalHost) *reconcileInfo {
+	return &reconcileInfo{
+		log:     ctrl.Log.WithName("controllers").WithName("BareMetalHost"),
+		host:    host,
+		request: newRequest(host),
+	}
+}
+
+func TestEnsureProvisioningQuota(t *testing.T) {
+	one := 1
+	quota := &metal3v1alpha1.HostQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: namespace},
+		Spec:       metal3v1alpha1.HostQuotaSpec{MaxProvisioned: &one},
+	}
+	image := &metal3v1alpha1.Image{URL: "http://example.test/image.qcow2"}
+	provisioned := newDefaultNamedHost("provisioned", t)
+	provisioned.Spec.Image = image
+	provisioned.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	waiting := newDefaultNamedHost("waiting", t)
+	waiting.Spec.Image = image
+	waiting.Status.Provisioning.State = metal3v1alpha1.StateReady
+
+	r := newTestReconciler(quota, provisioned, waiting)
+	r.quotaReservations = &quotaReservations{}
+	info := quotaInfo(waiting)
+	assert.IsType(t, actionDelayed{}, r.ensureProvisioningQuota(info))
+	assert.Equal(t, metal3v1alpha1.OperationalStatus(metal3v1alpha1.OperationalStatusDelayed), waiting.Status.OperationalStatus)
+	assert.Len(t, info.events, 1)
+
+	// A host that no longer counts frees its place
+	provisioned.Spec.Decommission = true
+	r = newTestReconciler(quota, provisioned, waiting)
+	r.quotaReservations = &quotaReservations{}
+	assert.Nil(t, r.ensureProvisioningQuota(quotaInfo(waiting)))
+}
+
+func TestEnsureProvisioningQuotaReservation(t *testing.T) {
+	one := 1
+	quota := &metal3v1alpha1.HostQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: namespace},
+		Spec:       metal3v1alpha1.HostQuotaSpec{MaxProvisioned: &one},
+	}
+	first := newDefaultNamedHost("first", t)
+	second := newDefaultNamedHost("second", t)
+	r := newTestReconciler(quota, first, second)
+	r.quotaReservations = &quotaReservations{}
+
+	// The first host is admitted before its new state is saved, so
+	// the second one must not take the same place
+	assert.Nil(t, r.ensureProvisioningQuota(quotaInfo(first)))
+	assert.IsType(t, actionDelayed{}, r.ensureProvisioningQuota(quotaInfo(second)))
+
+	// The first host does not count against itself
+	assert.Nil(t, r.ensureProvisioningQuota(quotaInfo(first)))
+}
+
+func TestEnsureProvisioningQuotaWithoutQuota(t *testing.T) {
+	host := newDefaultHost(t)
+	r := newTestReconciler(host)
+	assert.Nil(t, r.ensureProvisioningQuota(quotaInfo(host)))
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// hostRequest is a change requested through the host, by an annotation
+// or a field of the spec, that is handled by calling the provisioner
+// directly instead of going through the state machine.
+type hostRequest struct {
+	// description is used to wrap the error of the handler.
+	description string
+	// requested reports whether the host asks for the change.
+	requested func(host *metal3v1alpha1.BareMetalHost) bool
+	// handle calls the provisioner and returns true when the request
+	// was handled and the host should be reconciled again.
+	handle func(ctx context.Context, r *BareMetalHostReconciler, prov provisioner.Provisioner, info *reconcileInfo) (bool, error)
+}
+
+func annotationRequested(annotation string) func(host *metal3v1alpha1.BareMetalHost) bool {
+	return func(host *metal3v1alpha1.BareMetalHost) bool {
+		_, requested := host.Annotations[annotation]
+		return requested
+	}
+}
+
+// hostRequests are handled in order, one per reconcile.
+var hostRequests = []hostRequest{
+	{
+		description: "export BIOS settings",
+		requested:   annotationRequested(metal3v1alpha1.ExportBIOSSettingsAnnotation),
+		handle: func(ctx context.Context, r *BareMetalHostReconciler, prov provisioner.Provisioner, info *reconcileInfo) (bool, error) {
+			return r.exportBIOSSettings(ctx, prov, info)
+		},
+	},
+	{
+		description: "inspect host out-of-band",
+		requested:   outOfBandInspectionRequested,
+		handle: func(ctx context.Context, r *BareMetalHostReconciler, prov provisioner.Provisioner, info *reconcileInfo) (bool, error) {
+			return r.inspectOutOfBand(prov, info)
+		},
+	},
+	{
+		description: "update secure boot databases",
+		requested:   secureBootUpdateRequested,
+		handle: func(ctx context.Context, r *BareMetalHostReconciler, prov provisioner.Provisioner, info *reconcileInfo) (bool, error) {
+			return r.updateSecureBootDatabases(prov, info)
+		},
+	},
+	{
+		description: "refresh status",
+		requested:   annotationRequested(metal3v1alpha1.RefreshStatusAnnotation),
+		handle: func(ctx context.Context, r *BareMetalHostReconciler, prov provisioner.Provisioner, info *reconcileInfo) (bool, error) {
+			return r.refreshStatus(ctx, prov, info)
+		},
+	},
+	{
+		description: "set the tags of the node",
+		requested:   nodeTagsChanged,
+		handle: func(ctx context.Context, r *BareMetalHostReconciler, prov provisioner.Provisioner, info *reconcileInfo) (bool, error) {
+			return r.updateNodeTags(prov, info)
+		},
+	},
+}
+
+// handleHostRequests handles the first pending request of the host
+// and returns true when the host should be reconciled again. Nothing
+// is handled while the host is in dry-run mode, the operator is paused
+// or the host is retired, since every request reaches the BMC. The
+// events of a handled request are published even if it failed.
+func (r *BareMetalHostReconciler) handleHostRequests(ctx context.Context, prov provisioner.Provisioner, info *reconcileInfo, paused bool) (ctrl.Result, bool, error) {
+	host := info.host
+	if host.HasDryRunAnnotation() || paused || host.Status.Provisioning.State == metal3v1alpha1.StateRetired {
+		return ctrl.Result{}, false, nil
+	}
+
+	for _, req := range hostRequests {
+		if !req.requested(host) {
+			continue
+		}
+		handled, err := req.handle(ctx, r, prov, info)
+		if handled {
+			for _, e := range info.events {
+				r.publishEvent(info.request, e)
+			}
+		}
+		if err != nil {
+			return ctrl.Result{}, true, errors.Wrapf(err, "failed to %s", req.description)
+		}
+		if handled {
+			return ctrl.Result{Requeue: true}, true, nil
+		}
+	}
+	return ctrl.Result{}, false, nil
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"fmt"
+	"time"
 
 	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
@@ -52,6 +53,10 @@ func (hsm *hostStateMachine) handlers() map[metal3v1alpha1.ProvisioningState]sta
 		metal3v1alpha1.StateProvisioned:           hsm.handleProvisioned,
 		metal3v1alpha1.StateDeprovisioning:        hsm.handleDeprovisioning,
 		metal3v1alpha1.StateDeleting:              hsm.handleDeleting,
+		metal3v1alpha1.StateDecommissioning:       hsm.handleDecommissioning,
+		metal3v1alpha1.StateDecommissioned:        hsm.handleDecommissioned,
+		metal3v1alpha1.StateRetiring:              hsm.handleRetiring,
+		metal3v1alpha1.StateRetired:               hsm.handleRetired,
 	}
 }
 
@@ -80,11 +85,27 @@ func recordStateEnd(info *reconcileInfo, host *metal3v1alpha1.BareMetalHost, sta
 }
 
 func (hsm *hostStateMachine) ensureProvisioningCapacity(info *reconcileInfo) actionResult {
+	// Hosts already being inspected or provisioned keep their place
+	queue := hsm.Reconciler.ProvisioningQueue
+	name := info.request.NamespacedName.String()
+	running := false
+	switch hsm.Host.Status.Provisioning.State {
+	case metal3v1alpha1.StateInspecting, metal3v1alpha1.StateProvisioning:
+		running = true
+	}
+	if !queue.Admit(name, hsm.Host.Spec.Priority, hsm.Host.Spec.ProvisioningClass, running, time.Now()) {
+		info.log.Info("waiting for hosts ahead in the provisioning queue",
+			"priority", hsm.Host.Spec.Priority, "class", hsm.Host.Spec.ProvisioningClass)
+		return recordActionDelayed(info)
+	}
+
 	hasCapacity, err := hsm.Provisioner.HasProvisioningCapacity()
 	if err != nil {
+		queue.Defer(name)
 		return actionError{errors.Wrap(err, "failed to get hosts currently being provisioned")}
 	}
 	if !hasCapacity {
+		queue.Defer(name)
 		return recordActionDelayed(info)
 	}
 
@@ -105,6 +126,17 @@ func (hsm *hostStateMachine) updateHostStateFrom(initialState metal3v1alpha1.Pro
 				return actionRes
 			}
 		}
+		if hsm.NextState == metal3v1alpha1.StateProvisioning {
+			if actionRes := hsm.Reconciler.ensureProvisioningQuota(info); actionRes != nil {
+				hsm.Reconciler.ProvisioningQueue.Defer(info.request.NamespacedName.String())
+				return actionRes
+			}
+		}
+
+		switch initialState {
+		case metal3v1alpha1.StateInspecting, metal3v1alpha1.StateProvisioning:
+			hsm.Reconciler.ProvisioningQueue.Done(info.request.NamespacedName.String())
+		}
 
 		info.log.Info("changing provisioning state",
 			"old", initialState,
@@ -116,6 +148,13 @@ func (hsm *hostStateMachine) updateHostStateFrom(initialState metal3v1alpha1.Pro
 			stateChanges.With(stateChangeMetricLabels(initialState, hsm.NextState)).Inc()
 		})
 		hsm.Host.Status.Provisioning.State = hsm.NextState
+		// The operations waiting for the maintenance window are
+		// checked again in the new state.
+		hsm.Host.Status.Maintenance = nil
+		hsm.Host.Status.AvailableSince = nil
+		if hsm.NextState == metal3v1alpha1.StateReady || hsm.NextState == metal3v1alpha1.StateAvailable {
+			hsm.Host.Status.AvailableSince = &now
+		}
 		// Here we assume that if we're being asked to change the
 		// state, the return value of ReconcileState (our caller) is
 		// set up to ensure the change in the host is written back to
@@ -187,6 +226,10 @@ func (hsm *hostStateMachine) ReconcileState(info *reconcileInfo) (actionRes acti
 		return registerResult
 	}
 
+	if hsm.checkJournaledClean(info) {
+		return actionComplete{}
+	}
+
 	if stateHandler, found := hsm.handlers()[initialState]; found {
 		return stateHandler(info)
 	}
@@ -242,6 +285,11 @@ func (hsm *hostStateMachine) ensureRegistered(info *reconcileInfo) (result actio
 	case metal3v1alpha1.StateDeleting:
 		// In the deleting state the whole idea is to de-register the host
 		return
+	case metal3v1alpha1.StateRetiring:
+		if retirementScrubbing(hsm.Host) {
+			// The node is being removed with the BMC credentials
+			return
+		}
 	case metal3v1alpha1.StateRegistering:
 	default:
 		if hsm.Host.Status.ErrorType == metal3v1alpha1.RegistrationError ||
@@ -301,6 +349,11 @@ func (hsm *hostStateMachine) handleRegistering(info *reconcileInfo) actionResult
 }
 
 func (hsm *hostStateMachine) handleInspecting(info *reconcileInfo) actionResult {
+	if timedOut := hsm.Reconciler.checkOperationTimeout(info, inspectionOperation,
+		hsm.Host.Status.OperationHistory.Inspect.Start, time.Now()); timedOut != nil {
+		return timedOut
+	}
+
 	actResult := hsm.Reconciler.actionInspecting(hsm.Provisioner, info)
 	if _, complete := actResult.(actionComplete); complete {
 		hsm.NextState = metal3v1alpha1.StateMatchProfile
@@ -319,6 +372,11 @@ func (hsm *hostStateMachine) handleMatchProfile(info *reconcileInfo) actionResul
 }
 
 func (hsm *hostStateMachine) handleExternallyProvisioned(info *reconcileInfo) actionResult {
+	if hsm.Host.Spec.Retire {
+		hsm.NextState = metal3v1alpha1.StateDeprovisioning
+		return actionComplete{}
+	}
+
 	if hsm.Host.Spec.ExternallyProvisioned {
 		// ErrorCount is cleared when appropriate inside actionManageSteadyState
 		return hsm.Reconciler.actionManageSteadyState(hsm.Provisioner, info)
@@ -336,6 +394,11 @@ func (hsm *hostStateMachine) handleExternallyProvisioned(info *reconcileInfo) ac
 }
 
 func (hsm *hostStateMachine) handlePreparing(info *reconcileInfo) actionResult {
+	if timedOut := hsm.Reconciler.checkOperationTimeout(info, cleaningOperation,
+		hsm.Host.Status.OperationHistory.Prepare.Start, time.Now()); timedOut != nil {
+		return timedOut
+	}
+
 	actResult := hsm.Reconciler.actionPreparing(hsm.Provisioner, info)
 	if _, complete := actResult.(actionComplete); complete {
 		hsm.Host.Status.ErrorCount = 0
@@ -345,15 +408,50 @@ func (hsm *hostStateMachine) handlePreparing(info *reconcileInfo) actionResult {
 }
 
 func (hsm *hostStateMachine) handleReady(info *reconcileInfo) actionResult {
+	// Externally provisioned hosts come back here once deprovisioned
+	if hsm.Host.Spec.Retire {
+		hsm.NextState = metal3v1alpha1.StateRetiring
+		return actionComplete{}
+	}
+
 	if hsm.Host.Spec.ExternallyProvisioned {
 		hsm.NextState = metal3v1alpha1.StateExternallyProvisioned
 		clearHostProvisioningSettings(info.host)
 		return actionComplete{}
 	}
 
+	if hsm.Host.Spec.Decommission {
+		hsm.NextState = metal3v1alpha1.StateDecommissioning
+		return actionComplete{}
+	}
+
+	reinspect := !hsm.Host.NeedsProvisioning() &&
+		reinspectionDue(hsm.Host, hsm.Reconciler.ReinspectionInterval, time.Now())
+	wait, maintenanceChanged := waitForMaintenance(info, metal3v1alpha1.MaintenanceReinspection, reinspect, time.Now())
+	if reinspect && wait == 0 {
+		info.log.Info("starting periodic reinspection")
+		info.publishEvent("ReinspectionScheduled", "Inspecting the hardware again")
+		hsm.Host.Status.ReinspectionPending = true
+		hsm.NextState = metal3v1alpha1.StateInspecting
+		return actionComplete{}
+	}
+
+	if len(hsm.Host.Status.AcceptanceFailures) > 0 && hsm.Host.NeedsProvisioning() {
+		info.log.Info("not provisioning host rejected by acceptance tests",
+			"failures", hsm.Host.Status.AcceptanceFailures)
+		return actionContinue{rejectedRetryDelay}
+	}
+
+	if maintenanceChanged {
+		return actionUpdate{}
+	}
+
 	// ErrorCount is cleared when appropriate inside actionManageReady
 	actResult := hsm.Reconciler.actionManageReady(hsm.Provisioner, info)
-	if _, update := actResult.(actionUpdate); update {
+	if _, update := actResult.(actionUpdate); update && hsm.Host.NeedsProvisioning() {
+		// The provisioning settings changed. Power state updates of
+		// an idle host do not leave the state, so that the time it
+		// has been available is kept.
 		hsm.NextState = metal3v1alpha1.StatePreparing
 	} else if _, complete := actResult.(actionComplete); complete {
 		hsm.NextState = metal3v1alpha1.StateProvisioning
@@ -365,6 +463,9 @@ func (hsm *hostStateMachine) provisioningCancelled() bool {
 	if hsm.Host.Status.ErrorMessage != "" {
 		return true
 	}
+	if hsm.Host.Spec.Decommission || hsm.Host.Spec.Retire {
+		return true
+	}
 	if hsm.Host.Spec.Image == nil {
 		return true
 	}
@@ -386,6 +487,11 @@ func (hsm *hostStateMachine) handleProvisioning(info *reconcileInfo) actionResul
 		return actionComplete{}
 	}
 
+	if timedOut := hsm.Reconciler.checkOperationTimeout(info, provisioningOperation,
+		hsm.Host.Status.OperationHistory.Provision.Start, time.Now()); timedOut != nil {
+		return timedOut
+	}
+
 	actResult := hsm.Reconciler.actionProvisioning(hsm.Provisioner, info)
 	if _, complete := actResult.(actionComplete); complete {
 		hsm.NextState = metal3v1alpha1.StateProvisioned
@@ -405,7 +511,11 @@ func (hsm *hostStateMachine) handleProvisioned(info *reconcileInfo) actionResult
 }
 
 func (hsm *hostStateMachine) handleDeprovisioning(info *reconcileInfo) actionResult {
-	actResult := hsm.Reconciler.actionDeprovisioning(hsm.Provisioner, info)
+	actResult := hsm.Reconciler.checkOperationTimeout(info, cleaningOperation,
+		hsm.Host.Status.OperationHistory.Deprovision.Start, time.Now())
+	if actResult == nil {
+		actResult = hsm.Reconciler.actionDeprovisioning(hsm.Provisioner, info)
+	}
 
 	if hsm.Host.DeletionTimestamp.IsZero() {
 		if _, complete := actResult.(actionComplete); complete {
@@ -441,6 +551,43 @@ func (hsm *hostStateMachine) handleDeprovisioning(info *reconcileInfo) actionRes
 	return actResult
 }
 
+func (hsm *hostStateMachine) handleDecommissioning(info *reconcileInfo) actionResult {
+	actResult := hsm.Reconciler.actionDecommissioning(hsm.Provisioner, info)
+	if _, complete := actResult.(actionComplete); complete {
+		hsm.NextState = metal3v1alpha1.StateDecommissioned
+		hsm.Host.Status.ErrorCount = 0
+	}
+	return actResult
+}
+
+func (hsm *hostStateMachine) handleDecommissioned(info *reconcileInfo) actionResult {
+	// The host stays powered off until it is deleted
+	return actionContinue{unmanagedRetryDelay}
+}
+
+func (hsm *hostStateMachine) handleRetiring(info *reconcileInfo) actionResult {
+	actResult := hsm.Reconciler.actionRetiring(hsm.Provisioner, info)
+	if _, complete := actResult.(actionComplete); complete {
+		hsm.NextState = metal3v1alpha1.StateRetired
+		hsm.Host.Status.ErrorCount = 0
+	}
+	return actResult
+}
+
+func (hsm *hostStateMachine) handleRetired(info *reconcileInfo) actionResult {
+	if hsm.Host.Spec.Retire {
+		// The host stays powered off and unmanaged
+		return actionContinue{unmanagedRetryDelay}
+	}
+
+	// The host is brought back into service with the credentials of
+	// its spec
+	info.publishEvent("Unretired", "Registering the host again")
+	hsm.Host.Status.Retirement = nil
+	hsm.NextState = metal3v1alpha1.StateRegistering
+	return actionComplete{}
+}
+
 func (hsm *hostStateMachine) handleDeleting(info *reconcileInfo) actionResult {
 	return hsm.Reconciler.actionDeleting(hsm.Provisioner, info)
 }
@@ -15,6 +15,7 @@ import (
 	promutil "github.com/prometheus/client_golang/prometheus/testutil"
 	corev1 "k8s.io/api/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -123,7 +124,7 @@ func TestProvisioningCapacity(t *testing.T) {
 		t.Run(tc.Scenario, func(t *testing.T) {
 			prov := newMockProvisioner()
 			prov.setHasProvisioningCapacity(tc.HasProvisioningCapacity)
-			hsm := newHostStateMachine(tc.Host, &BareMetalHostReconciler{}, prov, true)
+			hsm := newHostStateMachine(tc.Host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
 			info := makeDefaultReconcileInfo(tc.Host)
 			delayedProvisioningHostCounters.Reset()
 
@@ -348,7 +349,7 @@ func TestErrorCountIncreasedOnActionFailure(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.Scenario, func(t *testing.T) {
 			prov := newMockProvisioner()
-			hsm := newHostStateMachine(tt.Host, &BareMetalHostReconciler{}, prov, true)
+			hsm := newHostStateMachine(tt.Host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
 			info := makeDefaultReconcileInfo(tt.Host)
 
 			prov.setNextError(tt.ProvisionerErrorOn, "some error")
@@ -407,7 +408,7 @@ func TestErrorCountClearedOnStateTransition(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.Scenario, func(t *testing.T) {
 			prov := newMockProvisioner()
-			hsm := newHostStateMachine(tt.Host, &BareMetalHostReconciler{}, prov, true)
+			hsm := newHostStateMachine(tt.Host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
 			info := makeDefaultReconcileInfo(tt.Host)
 
 			info.host.Status.ErrorCount = 1
@@ -444,7 +445,7 @@ func TestErrorClean(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.Scenario, func(t *testing.T) {
 			prov := newMockProvisioner()
-			hsm := newHostStateMachine(tt.Host, &BareMetalHostReconciler{}, prov, true)
+			hsm := newHostStateMachine(tt.Host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
 
 			info := makeDefaultReconcileInfo(tt.Host)
 			if tt.SecretName != "" {
@@ -591,6 +592,19 @@ func newMockProvisioner() *mockProvisioner {
 type mockProvisioner struct {
 	hasProvisioningCapacity bool
 	nextResults             map[string]provisioner.Result
+	hwState                 provisioner.HardwareState
+	eraseOptions            provisioner.EraseOptions
+	virtualMedia            *metal3v1alpha1.VirtualMediaSupport
+	bootResidue             []string
+	bootCleanupError        error
+	bootCorrections         []string
+	bootProtectionError     error
+	bootOrderChecks         int
+	secureBootError         error
+	agentVersion            string
+	tags                    []string
+	tagConflicts            []string
+	tagsError               error
 }
 
 func (m *mockProvisioner) getNextResultByMethod(name string) (result provisioner.Result) {
@@ -618,28 +632,77 @@ func (m *mockProvisioner) ValidateManagementAccess(credentialsChanged, force boo
 	return m.getNextResultByMethod("ValidateManagementAccess"), "", err
 }
 
-func (m *mockProvisioner) InspectHardware(force bool) (result provisioner.Result, details *metal3v1alpha1.HardwareDetails, err error) {
+func (m *mockProvisioner) InspectHardware(force, refresh bool) (result provisioner.Result, started bool, details *metal3v1alpha1.HardwareDetails, err error) {
 	details = &metal3v1alpha1.HardwareDetails{}
-	return m.getNextResultByMethod("InspectHardware"), details, err
+	return m.getNextResultByMethod("InspectHardware"), refresh, details, err
 }
 
 func (m *mockProvisioner) UpdateHardwareState() (hwState provisioner.HardwareState, err error) {
+	return m.hwState, nil
+}
+
+func (m *mockProvisioner) GetBIOSSettings() (settings map[string]string, err error) {
 	return
 }
 
+func (m *mockProvisioner) InspectOutOfBand() (details *metal3v1alpha1.HardwareDetails, err error) {
+	return
+}
+
+func (m *mockProvisioner) GetDriverStatus() (driver *metal3v1alpha1.DriverStatus, err error) {
+	return
+}
+
+func (m *mockProvisioner) GetAgentVersion() (version string, err error) {
+	return m.agentVersion, nil
+}
+
+func (m *mockProvisioner) InstallBootCertificate(installed string) (result provisioner.Result, fingerprint string, err error) {
+	return result, installed, nil
+}
+
+func (m *mockProvisioner) UpdateSecureBootDatabase(update metal3v1alpha1.SecureBootDatabaseUpdate) (err error) {
+	return m.secureBootError
+}
+
+func (m *mockProvisioner) DetectVirtualMedia() (support *metal3v1alpha1.VirtualMediaSupport, err error) {
+	return m.virtualMedia.DeepCopy(), nil
+}
+
+func (m *mockProvisioner) CleanBootArtifacts() (residue []string, err error) {
+	return m.bootResidue, m.bootCleanupError
+}
+
+func (m *mockProvisioner) ProtectBootOrder() (corrected []string, err error) {
+	m.bootOrderChecks++
+	return m.bootCorrections, m.bootProtectionError
+}
+
+func (m *mockProvisioner) SetTags(tags, previous []string) (conflicts []string, err error) {
+	if m.tagsError == nil {
+		m.tags = tags
+	}
+	return m.tagConflicts, m.tagsError
+}
+
 func (m *mockProvisioner) Prepare(unprepared bool) (result provisioner.Result, started bool, err error) {
 	return m.getNextResultByMethod("Prepare"), m.nextResults["Prepare"].Dirty, err
 }
 
+func (m *mockProvisioner) Erase(start bool, opts provisioner.EraseOptions) (result provisioner.Result, started bool, err error) {
+	m.eraseOptions = opts
+	return m.getNextResultByMethod("Erase"), start, err
+}
+
 func (m *mockProvisioner) Adopt(force bool) (result provisioner.Result, err error) {
 	return m.getNextResultByMethod("Adopt"), err
 }
 
-func (m *mockProvisioner) Provision(configData provisioner.HostConfigData) (result provisioner.Result, err error) {
+func (m *mockProvisioner) Provision(configData provisioner.HostConfigData, requestID string) (result provisioner.Result, err error) {
 	return m.getNextResultByMethod("Provision"), err
 }
 
-func (m *mockProvisioner) Deprovision(force bool) (result provisioner.Result, err error) {
+func (m *mockProvisioner) Deprovision(force bool, requestID string) (result provisioner.Result, err error) {
 	return m.getNextResultByMethod("Deprovision"), err
 }
 
@@ -647,11 +710,11 @@ func (m *mockProvisioner) Delete() (result provisioner.Result, err error) {
 	return m.getNextResultByMethod("Delete"), err
 }
 
-func (m *mockProvisioner) PowerOn() (result provisioner.Result, err error) {
+func (m *mockProvisioner) PowerOn(requestID string) (result provisioner.Result, err error) {
 	return m.getNextResultByMethod("PowerOn"), err
 }
 
-func (m *mockProvisioner) PowerOff(rebootMode metal3v1alpha1.RebootMode) (result provisioner.Result, err error) {
+func (m *mockProvisioner) PowerOff(rebootMode metal3v1alpha1.RebootMode, requestID string) (result provisioner.Result, err error) {
 	return m.getNextResultByMethod("PowerOff"), err
 }
 
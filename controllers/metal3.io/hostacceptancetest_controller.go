@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+const hostAcceptanceTestResyncInterval = 10 * time.Minute
+
+// HostAcceptanceTestReconciler reports the number of hosts passing
+// and failing each acceptance test in its status. The hosts are
+// rejected by the BareMetalHost controller.
+type HostAcceptanceTestReconciler struct {
+	client.Client
+	Log logr.Logger
+}
+
+// +kubebuilder:rbac:groups=metal3.io,resources=hostacceptancetests,verbs=get;list;watch
+// +kubebuilder:rbac:groups=metal3.io,resources=hostacceptancetests/status,verbs=get;update;patch
+
+// Reconcile updates the results of one test.
+func (r *HostAcceptanceTestReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("hostacceptancetest", request.NamespacedName)
+
+	test := &metal3v1alpha1.HostAcceptanceTest{}
+	if err := r.Get(ctx, request.NamespacedName, test); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, errors.Wrap(err, "could not load host acceptance test")
+	}
+
+	hosts := &metal3v1alpha1.BareMetalHostList{}
+	if err := r.List(ctx, hosts, client.InNamespace(test.Namespace)); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list hosts")
+	}
+	accepted, rejected := 0, 0
+	for i := range hosts.Items {
+		host := &hosts.Items[i]
+		applies, err := test.Applies(host)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !applies || host.Status.HardwareDetails == nil {
+			continue
+		}
+		if len(test.Check(host)) > 0 {
+			rejected++
+		} else {
+			accepted++
+		}
+	}
+
+	if test.Status.LastUpdated != nil &&
+		test.Status.Accepted == accepted && test.Status.Rejected == rejected {
+		return ctrl.Result{RequeueAfter: hostAcceptanceTestResyncInterval}, nil
+	}
+
+	now := metav1.Now()
+	test.Status.Accepted = accepted
+	test.Status.Rejected = rejected
+	test.Status.LastUpdated = &now
+	if err := r.Status().Update(ctx, test); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to update host acceptance test status")
+	}
+	reqLogger.Info("updated host acceptance test results", "accepted", accepted, "rejected", rejected)
+	return ctrl.Result{RequeueAfter: hostAcceptanceTestResyncInterval}, nil
+}
+
+// testsForHost returns the tests of the namespace of the host.
+func (r *HostAcceptanceTestReconciler) testsForHost(obj client.Object) []reconcile.Request {
+	tests := &metal3v1alpha1.HostAcceptanceTestList{}
+	if err := r.List(context.TODO(), tests, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Info("failed to list host acceptance tests", "namespace", obj.GetNamespace(), "error", err)
+		return nil
+	}
+	requests := make([]reconcile.Request, len(tests.Items))
+	for i, test := range tests.Items {
+		requests[i] = reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: test.Namespace, Name: test.Name},
+		}
+	}
+	return requests
+}
+
+// acceptanceHostUpdateEventHandler discards the updates of hosts that
+// cannot change the results of the tests, such as the power state
+// polls and most other status saves.
+func acceptanceHostUpdateEventHandler(e event.UpdateEvent) bool {
+	oldHost, oldOK := e.ObjectOld.(*metal3v1alpha1.BareMetalHost)
+	newHost, newOK := e.ObjectNew.(*metal3v1alpha1.BareMetalHost)
+	if !(oldOK && newOK) {
+		return true
+	}
+	return !reflect.DeepEqual(oldHost.Labels, newHost.Labels) ||
+		!oldHost.DeletionTimestamp.Equal(newHost.DeletionTimestamp) ||
+		!equality.Semantic.DeepEqual(oldHost.Status.HardwareDetails, newHost.Status.HardwareDetails)
+}
+
+// SetupWithManager registers the reconciler to be run by the manager
+func (r *HostAcceptanceTestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&metal3v1alpha1.HostAcceptanceTest{}).
+		Watches(&source.Kind{Type: &metal3v1alpha1.BareMetalHost{}},
+			handler.EnqueueRequestsFromMapFunc(r.testsForHost),
+			builder.WithPredicates(predicate.Funcs{
+				UpdateFunc: acceptanceHostUpdateEventHandler,
+			})).
+		Complete(r)
+}
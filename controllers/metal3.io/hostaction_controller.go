@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+const hostActionRetryDelay = time.Minute
+
+// HostActionReconciler passes the actions requested with HostAction
+// resources on to their host, by setting the annotation that requests
+// the same action.
+type HostActionReconciler struct {
+	client.Client
+	Log logr.Logger
+
+	recorder *eventRecorder
+}
+
+// +kubebuilder:rbac:groups=metal3.io,resources=hostactions,verbs=get;list;watch
+// +kubebuilder:rbac:groups=metal3.io,resources=hostactions/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=metal3.io,resources=baremetalhosts,verbs=reboot
+
+// Reconcile passes one action on to its host.
+func (r *HostActionReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("hostaction", request.NamespacedName)
+
+	action := &metal3v1alpha1.HostAction{}
+	if err := r.Get(ctx, request.NamespacedName, action); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, errors.Wrap(err, "could not load host action")
+	}
+	if action.Status.Applied != nil || !action.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	host := &metal3v1alpha1.BareMetalHost{}
+	hostName := types.NamespacedName{Namespace: action.Namespace, Name: action.Spec.HostName}
+	err := r.Get(ctx, hostName, host)
+	if err == nil {
+		err = r.applyAction(ctx, action, host)
+	}
+	if err != nil {
+		// The host may not have been created yet
+		reqLogger.Info("could not apply host action", "error", err.Error())
+		if action.Status.ErrorMessage != err.Error() {
+			action.Status.ErrorMessage = err.Error()
+			if updateErr := r.Status().Update(ctx, action); updateErr != nil {
+				return ctrl.Result{}, errors.Wrap(updateErr, "failed to update host action status")
+			}
+		}
+		return ctrl.Result{RequeueAfter: hostActionRetryDelay}, nil
+	}
+
+	now := metav1.Now()
+	action.Status.Applied = &now
+	action.Status.ErrorMessage = ""
+	if err := r.Status().Update(ctx, action); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to update host action status")
+	}
+	reqLogger.Info("applied host action", "host", action.Spec.HostName, "action", action.Spec.Action)
+	r.publishEvent(ctx, host, "HostActionApplied",
+		fmt.Sprintf("Applied %s action %s", action.Spec.Action, action.Name))
+	return ctrl.Result{}, nil
+}
+
+// applyAction sets the annotation requesting the action on the host.
+func (r *HostActionReconciler) applyAction(ctx context.Context, action *metal3v1alpha1.HostAction, host *metal3v1alpha1.BareMetalHost) error {
+	switch action.Spec.Action {
+	case metal3v1alpha1.HostActionReboot:
+		mode := action.Spec.RebootMode
+		if mode == "" {
+			mode = metal3v1alpha1.RebootModeSoft
+		}
+		value, err := json.Marshal(metal3v1alpha1.RebootAnnotationArguments{Mode: mode})
+		if err != nil {
+			return errors.Wrap(err, "failed to encode reboot mode")
+		}
+		if host.Annotations == nil {
+			host.Annotations = map[string]string{}
+		}
+		host.Annotations[metal3v1alpha1.RebootAnnotationPrefix] = string(value)
+	default:
+		return errors.Errorf("unknown action %q", action.Spec.Action)
+	}
+	return errors.Wrap(r.Update(ctx, host), "failed to update host")
+}
+
+func (r *HostActionReconciler) publishEvent(ctx context.Context, host *metal3v1alpha1.BareMetalHost, reason, message string) {
+	event := host.NewEvent(reason, message)
+	if err := r.recorder.record(ctx, r.Client, event); err != nil {
+		r.Log.Info("failed to record event, ignoring",
+			"reason", reason, "message", message, "error", err)
+	}
+}
+
+// SetupWithManager registers the reconciler to be run by the manager
+func (r *HostActionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.recorder == nil {
+		r.recorder = newEventRecorder(clock.RealClock{})
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&metal3v1alpha1.HostAction{}).
+		Complete(r)
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	goctx "context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestHostActionReboot(t *testing.T) {
+	action := &metal3v1alpha1.HostAction{
+		ObjectMeta: metav1.ObjectMeta{Name: "reboot", Namespace: namespace},
+		Spec: metal3v1alpha1.HostActionSpec{
+			HostName:   "myhost",
+			Action:     metal3v1alpha1.HostActionReboot,
+			RebootMode: metal3v1alpha1.RebootModeHard,
+		},
+	}
+	r := &HostActionReconciler{
+		Client:   fakeclient.NewFakeClient(action),
+		Log:      ctrl.Log.WithName("controllers").WithName("HostAction"),
+		recorder: newEventRecorder(clock.RealClock{}),
+	}
+	name := types.NamespacedName{Namespace: namespace, Name: "reboot"}
+
+	// The host does not exist yet
+	result, err := r.Reconcile(goctx.TODO(), ctrl.Request{NamespacedName: name})
+	assert.NoError(t, err)
+	assert.Equal(t, hostActionRetryDelay, result.RequeueAfter)
+	assert.NoError(t, r.Get(goctx.TODO(), name, action))
+	assert.Nil(t, action.Status.Applied)
+	assert.NotEmpty(t, action.Status.ErrorMessage)
+
+	host := newDefaultNamedHost("myhost", t)
+	assert.NoError(t, r.Create(goctx.TODO(), host))
+	_, err = r.Reconcile(goctx.TODO(), ctrl.Request{NamespacedName: name})
+	assert.NoError(t, err)
+
+	action = &metal3v1alpha1.HostAction{}
+	assert.NoError(t, r.Get(goctx.TODO(), name, action))
+	assert.NotNil(t, action.Status.Applied)
+	assert.Empty(t, action.Status.ErrorMessage)
+	assert.NoError(t, r.Get(goctx.TODO(), newRequest(host).NamespacedName, host))
+	assert.Equal(t, `{"mode":"hard"}`, host.Annotations[metal3v1alpha1.RebootAnnotationPrefix])
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+const hostQuotaResyncInterval = 10 * time.Minute
+
+// HostQuotaReconciler reports the number of hosts using each host
+// quota in its status. The quotas are enforced by the BareMetalHost
+// validating webhook and, for maxProvisioned, by the BareMetalHost
+// controller before provisioning.
+type HostQuotaReconciler struct {
+	client.Client
+	Log logr.Logger
+}
+
+// +kubebuilder:rbac:groups=metal3.io,resources=hostquotas,verbs=get;list;watch
+// +kubebuilder:rbac:groups=metal3.io,resources=hostquotas/status,verbs=get;update;patch
+
+// Reconcile updates the usage of one quota.
+func (r *HostQuotaReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("hostquota", request.NamespacedName)
+
+	quota := &metal3v1alpha1.HostQuota{}
+	if err := r.Get(ctx, request.NamespacedName, quota); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, errors.Wrap(err, "could not load host quota")
+	}
+
+	hosts := &metal3v1alpha1.BareMetalHostList{}
+	if err := r.List(ctx, hosts, client.InNamespace(quota.Namespace)); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list hosts")
+	}
+	provisioned, _ := metal3v1alpha1.HostQuotaUsage(quota.Namespace, hosts.Items)
+	if err := r.List(ctx, hosts, client.MatchingFields{metal3v1alpha1.ConsumerNamespaceField: quota.Namespace}); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list claimed hosts")
+	}
+	_, claimed := metal3v1alpha1.HostQuotaUsage(quota.Namespace, hosts.Items)
+
+	if quota.Status.LastUpdated != nil &&
+		quota.Status.Provisioned == provisioned && quota.Status.Claimed == claimed {
+		return ctrl.Result{RequeueAfter: hostQuotaResyncInterval}, nil
+	}
+
+	now := metav1.Now()
+	quota.Status.Provisioned = provisioned
+	quota.Status.Claimed = claimed
+	quota.Status.LastUpdated = &now
+	if err := r.Status().Update(ctx, quota); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to update host quota status")
+	}
+	reqLogger.Info("updated host quota usage", "provisioned", provisioned, "claimed", claimed)
+	return ctrl.Result{RequeueAfter: hostQuotaResyncInterval}, nil
+}
+
+// quotasForHost returns the quotas whose usage may change with the
+// host: those of its namespace and of the namespace of its consumer.
+func (r *HostQuotaReconciler) quotasForHost(obj client.Object) []reconcile.Request {
+	host, ok := obj.(*metal3v1alpha1.BareMetalHost)
+	if !ok {
+		return nil
+	}
+	namespaces := []string{host.Namespace}
+	if consumer := host.ConsumerNamespace(); consumer != "" && consumer != host.Namespace {
+		namespaces = append(namespaces, consumer)
+	}
+
+	var requests []reconcile.Request
+	for _, namespace := range namespaces {
+		quotas := &metal3v1alpha1.HostQuotaList{}
+		if err := r.List(context.TODO(), quotas, client.InNamespace(namespace)); err != nil {
+			r.Log.Info("failed to list host quotas", "namespace", namespace, "error", err)
+			continue
+		}
+		for _, quota := range quotas.Items {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: quota.Namespace, Name: quota.Name},
+			})
+		}
+	}
+	return requests
+}
+
+// quotaHostUpdateEventHandler discards the updates of hosts that
+// cannot change the usage of a quota, such as the power state polls
+// and most other status saves.
+func quotaHostUpdateEventHandler(e event.UpdateEvent) bool {
+	oldHost, oldOK := e.ObjectOld.(*metal3v1alpha1.BareMetalHost)
+	newHost, newOK := e.ObjectNew.(*metal3v1alpha1.BareMetalHost)
+	if !(oldOK && newOK) {
+		return true
+	}
+	return !equality.Semantic.DeepEqual(oldHost.Spec.Image, newHost.Spec.Image) ||
+		!equality.Semantic.DeepEqual(oldHost.Spec.ConsumerRef, newHost.Spec.ConsumerRef) ||
+		oldHost.Spec.Decommission != newHost.Spec.Decommission ||
+		oldHost.Spec.Retire != newHost.Spec.Retire ||
+		!oldHost.DeletionTimestamp.Equal(newHost.DeletionTimestamp) ||
+		oldHost.Status.Provisioning.State != newHost.Status.Provisioning.State
+}
+
+// SetupWithManager registers the reconciler to be run by the manager,
+// along with the index of the hosts by consumer namespace that the
+// BareMetalHost webhook also uses.
+func (r *HostQuotaReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &metal3v1alpha1.BareMetalHost{},
+		metal3v1alpha1.ConsumerNamespaceField, metal3v1alpha1.IndexConsumerNamespace); err != nil {
+		return errors.Wrap(err, "failed to index hosts by consumer namespace")
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&metal3v1alpha1.HostQuota{}).
+		Watches(&source.Kind{Type: &metal3v1alpha1.BareMetalHost{}},
+			handler.EnqueueRequestsFromMapFunc(r.quotasForHost),
+			builder.WithPredicates(predicate.Funcs{
+				UpdateFunc: quotaHostUpdateEventHandler,
+			})).
+		Complete(r)
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	goctx "context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestHostQuotaUsage(t *testing.T) {
+	quota := &metal3v1alpha1.HostQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: namespace},
+	}
+	provisioned := newDefaultHost(t)
+	provisioned.Spec.Image = &metal3v1alpha1.Image{URL: "http://example.test/image.qcow2"}
+	claimed := newHost("claimed", &metal3v1alpha1.BareMetalHostSpec{
+		ConsumerRef: &corev1.ObjectReference{Name: "machine", Namespace: namespace},
+	})
+	claimed.Namespace = "shared"
+	other := newHost("other", &metal3v1alpha1.BareMetalHostSpec{})
+	other.Namespace = "other"
+	r := &HostQuotaReconciler{
+		Client: fakeclient.NewFakeClient(quota, provisioned, claimed, other),
+		Log:    ctrl.Log.WithName("controllers").WithName("HostQuota"),
+	}
+
+	name := types.NamespacedName{Namespace: namespace, Name: "quota"}
+	result, err := r.Reconcile(goctx.TODO(), ctrl.Request{NamespacedName: name})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, hostQuotaResyncInterval, result.RequeueAfter)
+
+	updated := &metal3v1alpha1.HostQuota{}
+	if assert.NoError(t, r.Get(goctx.TODO(), name, updated)) {
+		assert.Equal(t, 1, updated.Status.Provisioned)
+		assert.Equal(t, 1, updated.Status.Claimed)
+		assert.NotNil(t, updated.Status.LastUpdated)
+	}
+
+	// The quota of the consumer is updated along with the quotas of
+	// the namespace of the host
+	assert.Len(t, r.quotasForHost(claimed), 1)
+	assert.Empty(t, r.quotasForHost(other))
+}
+
+func TestQuotaHostUpdateEventHandler(t *testing.T) {
+	oldHost := newDefaultHost(t)
+
+	newHost := oldHost.DeepCopy()
+	newHost.Status.PoweredOn = true
+	newHost.Status.LastUpdated = &metav1.Time{}
+	assert.False(t, quotaHostUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+
+	newHost = oldHost.DeepCopy()
+	newHost.Spec.Image = &metal3v1alpha1.Image{URL: "http://example.test/image.qcow2"}
+	assert.True(t, quotaHostUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+
+	newHost = oldHost.DeepCopy()
+	newHost.Spec.ConsumerRef = &corev1.ObjectReference{Name: "machine", Namespace: namespace}
+	assert.True(t, quotaHostUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+
+	newHost = oldHost.DeepCopy()
+	newHost.Spec.Decommission = true
+	assert.True(t, quotaHostUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+
+	newHost = oldHost.DeepCopy()
+	newHost.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	assert.True(t, quotaHostUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+
+	newHost = oldHost.DeepCopy()
+	now := metav1.Now()
+	newHost.DeletionTimestamp = &now
+	assert.True(t, quotaHostUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+}
@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+const (
+	// reportingKubeconfigKey is the key of the kubeconfig of the
+	// reporting cluster in its secret.
+	reportingKubeconfigKey = "kubeconfig"
+
+	// reportingKubeconfigCheckInterval is how often the secret of the
+	// reporting cluster is read again, to pick up a new kubeconfig.
+	reportingKubeconfigCheckInterval = time.Minute
+
+	hostReportResyncInterval = 10 * time.Minute
+)
+
+// RemoteClient gives a client for a remote cluster.
+type RemoteClient interface {
+	Client(ctx context.Context) (client.Client, error)
+}
+
+// ReportingClient gives a client for the reporting cluster, from the
+// kubeconfig in a secret. The secret is read again at most every
+// reportingKubeconfigCheckInterval, and a new client is made when the
+// kubeconfig has changed, so that its credentials can be rotated
+// without restarting the operator.
+type ReportingClient struct {
+	reader     client.Reader
+	scheme     *runtime.Scheme
+	secretName types.NamespacedName
+
+	lock       sync.Mutex
+	kubeconfig []byte
+	client     client.Client
+	checkedAt  time.Time
+}
+
+// NewReportingClient returns a client for the reporting cluster, from
+// the kubeconfig in the secret, which must be readable already.
+func NewReportingClient(ctx context.Context, reader client.Reader, scheme *runtime.Scheme, secretName types.NamespacedName) (*ReportingClient, error) {
+	c := &ReportingClient{
+		reader:     reader,
+		scheme:     scheme,
+		secretName: secretName,
+	}
+	if err := c.load(ctx, time.Now()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// load reads the kubeconfig from the secret, and makes a new client if
+// it has changed. The caller must hold the lock, except before the
+// client is shared.
+func (c *ReportingClient) load(ctx context.Context, now time.Time) error {
+	c.checkedAt = now
+	secret := &corev1.Secret{}
+	if err := c.reader.Get(ctx, c.secretName, secret); err != nil {
+		return errors.Wrapf(err, "failed to read reporting cluster secret %s", c.secretName)
+	}
+	kubeconfig, ok := secret.Data[reportingKubeconfigKey]
+	if !ok {
+		return errors.Errorf("reporting cluster secret %s has no %s key", c.secretName, reportingKubeconfigKey)
+	}
+	if c.client != nil && bytes.Equal(kubeconfig, c.kubeconfig) {
+		return nil
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return errors.Wrap(err, "invalid kubeconfig of the reporting cluster")
+	}
+	remote, err := client.New(config, client.Options{Scheme: c.scheme})
+	if err != nil {
+		return errors.Wrap(err, "failed to create reporting cluster client")
+	}
+	c.kubeconfig = kubeconfig
+	c.client = remote
+	return nil
+}
+
+// Client returns the client for the reporting cluster, made from the
+// latest kubeconfig. The previous client is kept when the secret
+// cannot be read or holds an invalid kubeconfig.
+func (c *ReportingClient) Client(ctx context.Context) (client.Client, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if now := time.Now(); now.Sub(c.checkedAt) >= reportingKubeconfigCheckInterval {
+		if err := c.load(ctx, now); err != nil {
+			ctrl.Log.WithName("reporting").Info("could not reload reporting cluster kubeconfig, using the previous one",
+				"error", err.Error())
+		}
+	}
+	return c.client, nil
+}
+
+// HostReportReconciler keeps a HostReport in a reporting cluster for
+// every host, so that central dashboards can watch the hosts of many
+// clusters without access to each of them.
+type HostReportReconciler struct {
+	client.Client
+	Log logr.Logger
+
+	// Remote gives the client for the reporting cluster.
+	Remote RemoteClient
+	// Cluster is the name of this cluster in the reports.
+	Cluster string
+	// Namespace is the namespace of the reports in the reporting
+	// cluster.
+	Namespace string
+}
+
+// hostReportName returns the name of the report of a host, unique
+// across the clusters sharing the reporting namespace. Host and
+// namespace names may hold any character allowed in a report name, so
+// the name is a hash of the cluster, namespace and host names joined
+// with a character none of the last two may hold.
+func hostReportName(cluster string, host types.NamespacedName) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{cluster, host.Namespace, host.Name}, "/")))
+	return fmt.Sprintf("host-%x", sum[:16])
+}
+
+// hostReportSpec summarizes the host. LastUpdated is left for the
+// caller to set when the report is written.
+func hostReportSpec(cluster string, host *metal3v1alpha1.BareMetalHost) metal3v1alpha1.HostReportSpec {
+	spec := metal3v1alpha1.HostReportSpec{
+		Cluster:           cluster,
+		HostNamespace:     host.Namespace,
+		HostName:          host.Name,
+		ProvisioningState: host.Status.Provisioning.State,
+		OperationalStatus: host.Status.OperationalStatus,
+		ErrorType:         host.Status.ErrorType,
+		ErrorMessage:      host.Status.ErrorMessage,
+		Online:            host.Spec.Online,
+		PoweredOn:         host.Status.PoweredOn,
+		Image:             host.Status.Provisioning.Image.URL,
+		Conditions:        host.Status.Conditions,
+	}
+	if consumer := host.Spec.ConsumerRef; consumer != nil {
+		spec.Consumer = fmt.Sprintf("%s/%s/%s", consumer.Kind, consumer.Namespace, consumer.Name)
+	}
+	if hw := host.Status.HardwareDetails; hw != nil {
+		spec.Manufacturer = hw.SystemVendor.Manufacturer
+		spec.ProductName = hw.SystemVendor.ProductName
+		spec.SerialNumber = hw.SystemVendor.SerialNumber
+		spec.CPUCount = hw.CPU.Count
+		spec.RAMMebibytes = hw.RAMMebibytes
+	}
+	return spec
+}
+
+// sameHostReportSpec compares two reports, ignoring when they were
+// written.
+func sameHostReportSpec(a, b metal3v1alpha1.HostReportSpec) bool {
+	a.LastUpdated = nil
+	b.LastUpdated = nil
+	return reflect.DeepEqual(a, b)
+}
+
+// hostReportUpdateEventHandler discards the updates of hosts that do
+// not change their report, such as the power state polls, so that they
+// do not reach the reporting cluster.
+func hostReportUpdateEventHandler(e event.UpdateEvent) bool {
+	oldHost, oldOK := e.ObjectOld.(*metal3v1alpha1.BareMetalHost)
+	newHost, newOK := e.ObjectNew.(*metal3v1alpha1.BareMetalHost)
+	if !(oldOK && newOK) {
+		return true
+	}
+	return !sameHostReportSpec(hostReportSpec("", oldHost), hostReportSpec("", newHost))
+}
+
+// Reconcile updates the report of one host in the reporting cluster.
+func (r *HostReportReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("baremetalhost", request.NamespacedName)
+	name := types.NamespacedName{Namespace: r.Namespace, Name: hostReportName(r.Cluster, request.NamespacedName)}
+
+	remote, err := r.Remote.Client(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	host := &metal3v1alpha1.BareMetalHost{}
+	if err := r.Get(ctx, request.NamespacedName, host); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, errors.Wrap(err, "could not load host data")
+		}
+		report := &metal3v1alpha1.HostReport{
+			ObjectMeta: metav1.ObjectMeta{Namespace: name.Namespace, Name: name.Name},
+		}
+		if err := remote.Delete(ctx, report); err != nil && !k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, errors.Wrap(err, "failed to delete host report")
+		}
+		reqLogger.Info("deleted host report", "report", name)
+		return ctrl.Result{}, nil
+	}
+
+	spec := hostReportSpec(r.Cluster, host)
+	now := metav1.Now()
+	report := &metal3v1alpha1.HostReport{}
+	err = remote.Get(ctx, name, report)
+	switch {
+	case k8serrors.IsNotFound(err):
+		report = &metal3v1alpha1.HostReport{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: name.Namespace,
+				Name:      name.Name,
+				Labels: map[string]string{
+					metal3v1alpha1.HostReportClusterLabel:   r.Cluster,
+					metal3v1alpha1.HostReportNamespaceLabel: host.Namespace,
+				},
+			},
+			Spec: spec,
+		}
+		report.Spec.LastUpdated = &now
+		if err := remote.Create(ctx, report); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to create host report")
+		}
+		reqLogger.Info("created host report", "report", name)
+	case err != nil:
+		return ctrl.Result{}, errors.Wrap(err, "failed to read host report")
+	case !sameHostReportSpec(report.Spec, spec):
+		report.Spec = spec
+		report.Spec.LastUpdated = &now
+		if err := remote.Update(ctx, report); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to update host report")
+		}
+		reqLogger.Info("updated host report", "report", name)
+	}
+	return ctrl.Result{RequeueAfter: hostReportResyncInterval}, nil
+}
+
+// SetupWithManager registers the reconciler to be run by the manager
+func (r *HostReportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("hostreport").
+		For(&metal3v1alpha1.BareMetalHost{},
+			builder.WithPredicates(predicate.Funcs{
+				UpdateFunc: hostReportUpdateEventHandler,
+			})).
+		Complete(r)
+}
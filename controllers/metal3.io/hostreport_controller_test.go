@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	goctx "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// fixedRemote always gives the same client for the reporting cluster.
+type fixedRemote struct {
+	client client.Client
+}
+
+func (f fixedRemote) Client(ctx goctx.Context) (client.Client, error) {
+	return f.client, nil
+}
+
+func TestHostReport(t *testing.T) {
+	host := newHost("worker-0", &metal3v1alpha1.BareMetalHostSpec{
+		Online:      true,
+		ConsumerRef: &corev1.ObjectReference{Kind: "Metal3Machine", Namespace: namespace, Name: "machine-0"},
+	})
+	host.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	host.Status.OperationalStatus = metal3v1alpha1.OperationalStatusOK
+	host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{
+		SystemVendor: metal3v1alpha1.HardwareSystemVendor{Manufacturer: "Dell Inc.", SerialNumber: "ABC123"},
+		CPU:          metal3v1alpha1.CPU{Count: 32},
+		RAMMebibytes: 65536,
+	}
+	remote := fakeclient.NewFakeClient()
+	r := &HostReportReconciler{
+		Client:    fakeclient.NewFakeClient(host),
+		Log:       ctrl.Log.WithName("controllers").WithName("HostReport"),
+		Remote:    fixedRemote{remote},
+		Cluster:   "edge-042",
+		Namespace: "metal3-reports",
+	}
+	request := newRequest(host)
+	name := types.NamespacedName{Namespace: "metal3-reports", Name: hostReportName("edge-042", request.NamespacedName)}
+
+	result, err := r.Reconcile(goctx.TODO(), request)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, hostReportResyncInterval, result.RequeueAfter)
+	report := &metal3v1alpha1.HostReport{}
+	if assert.NoError(t, remote.Get(goctx.TODO(), name, report)) {
+		assert.Equal(t, "edge-042", report.Labels[metal3v1alpha1.HostReportClusterLabel])
+		assert.Equal(t, "worker-0", report.Spec.HostName)
+		assert.Equal(t, metal3v1alpha1.StateProvisioned, report.Spec.ProvisioningState)
+		assert.Equal(t, "Metal3Machine/"+namespace+"/machine-0", report.Spec.Consumer)
+		assert.Equal(t, "ABC123", report.Spec.SerialNumber)
+		assert.Equal(t, 32, report.Spec.CPUCount)
+	}
+
+	host.Status.OperationalStatus = metal3v1alpha1.OperationalStatusError
+	host.Status.ErrorType = metal3v1alpha1.PowerManagementError
+	assert.NoError(t, r.Update(goctx.TODO(), host))
+	_, err = r.Reconcile(goctx.TODO(), request)
+	assert.NoError(t, err)
+	if assert.NoError(t, remote.Get(goctx.TODO(), name, report)) {
+		assert.Equal(t, metal3v1alpha1.PowerManagementError, report.Spec.ErrorType)
+		assert.NotNil(t, report.Spec.LastUpdated)
+	}
+
+	// Saving the status without changing the summary leaves the
+	// report alone
+	resourceVersion := report.ResourceVersion
+	host.Status.LastUpdated = &metav1.Time{Time: time.Now().Add(time.Hour)}
+	assert.NoError(t, r.Update(goctx.TODO(), host))
+	_, err = r.Reconcile(goctx.TODO(), request)
+	assert.NoError(t, err)
+	if assert.NoError(t, remote.Get(goctx.TODO(), name, report)) {
+		assert.Equal(t, resourceVersion, report.ResourceVersion)
+	}
+
+	assert.NoError(t, r.Delete(goctx.TODO(), host))
+	_, err = r.Reconcile(goctx.TODO(), request)
+	assert.NoError(t, err)
+	err = remote.Get(goctx.TODO(), name, report)
+	assert.True(t, k8serrors.IsNotFound(err))
+
+	// Deleting a missing report is not an error
+	_, err = r.Reconcile(goctx.TODO(), request)
+	assert.NoError(t, err)
+}
+
+func TestHostReportName(t *testing.T) {
+	name := hostReportName("edge", types.NamespacedName{Namespace: "a.b", Name: "c"})
+	assert.Len(t, name, 37)
+	assert.Equal(t, name, hostReportName("edge", types.NamespacedName{Namespace: "a.b", Name: "c"}))
+	assert.NotEqual(t, name, hostReportName("edge", types.NamespacedName{Namespace: "a", Name: "b.c"}))
+	assert.NotEqual(t, name, hostReportName("edge.a", types.NamespacedName{Namespace: "b", Name: "c"}))
+}
+
+func TestHostReportUpdateEventHandler(t *testing.T) {
+	oldHost := newDefaultHost(t)
+
+	newHost := oldHost.DeepCopy()
+	newHost.Status.LastUpdated = &metav1.Time{}
+	assert.False(t, hostReportUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+
+	newHost = oldHost.DeepCopy()
+	newHost.Status.PoweredOn = !oldHost.Status.PoweredOn
+	assert.True(t, hostReportUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+
+	newHost = oldHost.DeepCopy()
+	newHost.Status.ErrorType = metal3v1alpha1.PowerManagementError
+	assert.True(t, hostReportUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+}
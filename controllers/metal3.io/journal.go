@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// maxJournalEntries bounds the size of the journal. There is at most
+// one entry per intent and host, so it is only reached with more hosts
+// than the operator is meant to manage.
+const maxJournalEntries = 5000
+
+// journalIntent is an action the operator has committed to but could
+// not record in the API server yet.
+type journalIntent string
+
+const (
+	// journalReboot records that a reboot requested with the
+	// reboot.metal3.io annotation has powered the host off, so the
+	// annotation has to be removed rather than acted on again.
+	journalReboot journalIntent = "reboot"
+	// journalClean records that the host has to be deprovisioned, and
+	// so cleaned, even if its spec changes before the decision is
+	// saved.
+	journalClean journalIntent = "clean"
+)
+
+// journalEntry is an intent on a host. It only holds what is needed
+// to check the intent still applies and to record it.
+type journalEntry struct {
+	Namespace string        `json:"namespace"`
+	Name      string        `json:"name"`
+	Intent    journalIntent `json:"intent"`
+	// ResourceVersion is the version of the host the intent was
+	// recorded from.
+	ResourceVersion string `json:"resourceVersion"`
+	// State and Image are the provisioning state and image of the
+	// host when it was decided to clean it.
+	State metal3v1alpha1.ProvisioningState `json:"state,omitempty"`
+	Image string                           `json:"image,omitempty"`
+	Time  metav1.Time                      `json:"time"`
+}
+
+func (e journalEntry) key() string {
+	return e.Namespace + "/" + e.Name + "/" + string(e.Intent)
+}
+
+// Journal keeps the reboot and cleanup intents that could not be
+// saved because the API server was unreachable, to replay them when it
+// is reachable again. The journal is kept in memory and, when a path
+// is given, in a local file so that it survives restarts of the
+// operator without depending on the API server.
+type Journal struct {
+	path string
+
+	lock    sync.Mutex
+	entries map[string]journalEntry
+}
+
+// NewJournal returns a journal saved to the file, or kept in memory
+// only when the path is empty. The intents saved by a previous run of
+// the operator are loaded, to be replayed when their host is first
+// reconciled.
+func NewJournal(path string) (*Journal, error) {
+	j := &Journal{
+		path:    path,
+		entries: make(map[string]journalEntry),
+	}
+	if path == "" {
+		return j, nil
+	}
+	content, err := ioutil.ReadFile(filepath.Clean(path))
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read journal")
+	}
+	var entries []journalEntry
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, errors.Wrap(err, "could not parse journal")
+	}
+	for _, entry := range entries {
+		j.entries[entry.key()] = entry
+	}
+	journaledIntents.Set(float64(len(j.entries)))
+	return j, nil
+}
+
+// record journals an intent, replacing an earlier one of the same kind
+// for the host.
+func (j *Journal) record(entry journalEntry) error {
+	if j == nil {
+		return errors.New("no journal")
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	key := entry.key()
+	if _, found := j.entries[key]; !found && len(j.entries) >= maxJournalEntries {
+		return errors.Errorf("journal is full with %d entries", len(j.entries))
+	}
+	entry.Time = metav1.Now()
+	previous, hadPrevious := j.entries[key]
+	j.entries[key] = entry
+	if err := j.save(); err != nil {
+		if hadPrevious {
+			j.entries[key] = previous
+		} else {
+			delete(j.entries, key)
+		}
+		return err
+	}
+	journaledIntents.Set(float64(len(j.entries)))
+	return nil
+}
+
+// pending returns the intent of the host, if any.
+func (j *Journal) pending(host types.NamespacedName, intent journalIntent) (entry journalEntry, found bool) {
+	if j == nil {
+		return
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	entry, found = j.entries[journalEntry{Namespace: host.Namespace, Name: host.Name, Intent: intent}.key()]
+	return
+}
+
+// forget removes the intent of the host, once it has been replayed or
+// discarded.
+func (j *Journal) forget(host types.NamespacedName, intent journalIntent) {
+	if j == nil {
+		return
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	key := journalEntry{Namespace: host.Namespace, Name: host.Name, Intent: intent}.key()
+	if _, found := j.entries[key]; !found {
+		return
+	}
+	delete(j.entries, key)
+	if err := j.save(); err != nil {
+		ctrl.Log.WithName("journal").Info("could not save journal, the intent may be replayed again",
+			"key", key, "error", err.Error())
+	}
+	journaledIntents.Set(float64(len(j.entries)))
+}
+
+// save writes the journal to its file, replacing it atomically so that
+// a crash never leaves a partial journal. The caller must hold the
+// lock.
+func (j *Journal) save() error {
+	if j.path == "" {
+		return nil
+	}
+	entries := make([]journalEntry, 0, len(j.entries))
+	for _, entry := range j.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, k int) bool { return entries[i].key() < entries[k].key() })
+	content, err := json.Marshal(entries)
+	if err != nil {
+		return errors.Wrap(err, "could not encode journal")
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(j.path), filepath.Base(j.path)+".*")
+	if err != nil {
+		return errors.Wrap(err, "could not save journal")
+	}
+	_, err = tmp.Write(content)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), j.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, "could not save journal")
+	}
+	return nil
+}
+
+// apiUnreachable reports whether an update failed because the API
+// server could not be reached or could not handle it, rather than
+// because it refused the update, which would be refused again when
+// replayed.
+func apiUnreachable(err error) bool {
+	switch k8serrors.ReasonForError(err) {
+	case metav1.StatusReasonUnknown:
+		var status k8serrors.APIStatus
+		return !errors.As(err, &status)
+	case metav1.StatusReasonTimeout, metav1.StatusReasonServerTimeout,
+		metav1.StatusReasonServiceUnavailable, metav1.StatusReasonTooManyRequests,
+		metav1.StatusReasonInternalError:
+		return true
+	}
+	return false
+}
+
+// journalRebootDone records that the reboot requested by the
+// suffixless reboot annotation has powered the host off, when the
+// annotation cannot be removed because the API server is unreachable.
+// The reboot then completes without waiting for the API server.
+func (r *BareMetalHostReconciler) journalRebootDone(info *reconcileInfo) bool {
+	err := r.Journal.record(journalEntry{
+		Namespace:       info.host.Namespace,
+		Name:            info.host.Name,
+		Intent:          journalReboot,
+		ResourceVersion: info.host.ResourceVersion,
+	})
+	if err != nil {
+		info.log.Info("could not journal reboot", "error", err.Error())
+		return false
+	}
+	info.log.Info("journaled reboot until the API server is reachable")
+	return true
+}
+
+// rebootJournaled reports whether the suffixless reboot annotation of
+// the host has already been acted on.
+func (r *BareMetalHostReconciler) rebootJournaled(host *metal3v1alpha1.BareMetalHost) bool {
+	entry, found := r.Journal.pending(types.NamespacedName{Namespace: host.Namespace, Name: host.Name}, journalReboot)
+	return found && entry.ResourceVersion == host.ResourceVersion
+}
+
+// journalClean records that the host has to be cleaned, when its move
+// to the deprovisioning state cannot be saved because the API server is
+// unreachable.
+func (r *BareMetalHostReconciler) journalClean(info *reconcileInfo, initialState metal3v1alpha1.ProvisioningState) {
+	err := r.Journal.record(journalEntry{
+		Namespace:       info.host.Namespace,
+		Name:            info.host.Name,
+		Intent:          journalClean,
+		ResourceVersion: info.host.ResourceVersion,
+		State:           initialState,
+		Image:           info.host.Status.Provisioning.Image.URL,
+	})
+	if err != nil {
+		info.log.Info("could not journal cleaning", "error", err.Error())
+		return
+	}
+	info.log.Info("journaled cleaning until the API server is reachable")
+}
+
+// replayJournal saves the reboot journaled for the host, if any. The
+// reboot is discarded if the host has changed since, since the reboot
+// annotation may then be a new request. Journaled cleaning is replayed
+// by the state machine, in checkJournaledClean.
+func (r *BareMetalHostReconciler) replayJournal(ctx context.Context, request ctrl.Request, host *metal3v1alpha1.BareMetalHost) (replayed bool, err error) {
+	reqLogger := r.Log.WithValues("baremetalhost", request.NamespacedName)
+
+	if entry, found := r.Journal.pending(request.NamespacedName, journalReboot); found {
+		if _, exists := host.Annotations[rebootAnnotationPrefix]; exists && entry.ResourceVersion == host.ResourceVersion {
+			reqLogger.Info("replaying journaled reboot")
+			delete(host.Annotations, rebootAnnotationPrefix)
+			if err := r.Update(ctx, host); err != nil {
+				return false, errors.Wrap(err, "failed to remove reboot annotation from host")
+			}
+			replayed = true
+		} else if exists {
+			reqLogger.Info("host changed since its reboot was journaled, discarding it",
+				"journaled", entry.ResourceVersion, "current", host.ResourceVersion)
+		}
+		r.Journal.forget(request.NamespacedName, journalReboot)
+	}
+	return replayed, nil
+}
+
+// checkJournaledClean moves the host to the deprovisioning state when
+// its cleaning was journaled, as long as the host has not left the
+// state it was decided in, even if its image has been set back since.
+// The intent is forgotten once the new state is saved.
+func (hsm *hostStateMachine) checkJournaledClean(info *reconcileInfo) bool {
+	journal := hsm.Reconciler.Journal
+	name := types.NamespacedName{Namespace: hsm.Host.Namespace, Name: hsm.Host.Name}
+	entry, found := journal.pending(name, journalClean)
+	if !found {
+		return false
+	}
+	provisioning := hsm.Host.Status.Provisioning
+	if provisioning.State != entry.State || provisioning.Image.URL != entry.Image {
+		journal.forget(name, journalClean)
+		return false
+	}
+	info.log.Info("replaying journaled cleaning", "image", entry.Image)
+	hsm.NextState = metal3v1alpha1.StateDeprovisioning
+	info.postSaveCallbacks = append(info.postSaveCallbacks, func() {
+		journal.forget(name, journalClean)
+	})
+	return true
+}
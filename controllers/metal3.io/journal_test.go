@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	goctx "context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+func TestAPIUnreachable(t *testing.T) {
+	resource := schema.GroupResource{Group: "metal3.io", Resource: "baremetalhosts"}
+	cases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"connection", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{"wrapped", errors.Wrap(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("no route to host")}, "failed"), true},
+		{"timeout", k8serrors.NewServerTimeout(resource, "update", 1), true},
+		{"unavailable", k8serrors.NewServiceUnavailable("etcd is down"), true},
+		{"conflict", k8serrors.NewConflict(resource, "host", errors.New("modified")), false},
+		{"not found", k8serrors.NewNotFound(resource, "host"), false},
+		{"invalid", k8serrors.NewBadRequest("invalid status"), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, apiUnreachable(tc.err))
+		})
+	}
+}
+
+// unreachableClient fails every update as if the API server could not
+// be reached.
+type unreachableClient struct {
+	client.Client
+}
+
+func (c unreachableClient) Update(ctx goctx.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+}
+
+func journaledHost(t *testing.T, r *BareMetalHostReconciler) *metal3v1alpha1.BareMetalHost {
+	host := &metal3v1alpha1.BareMetalHost{}
+	if err := r.Get(goctx.TODO(), types.NamespacedName{Namespace: namespace, Name: t.Name()}, host); err != nil {
+		t.Fatal(err)
+	}
+	return host
+}
+
+func newJournal(t *testing.T) *Journal {
+	j, err := NewJournal("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return j
+}
+
+func TestJournalRebootWhileUnreachable(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).SetStatusPoweredOn(false).build()
+	host.Annotations = map[string]string{rebootAnnotationPrefix: ""}
+	host.ResourceVersion = "42"
+	poweredOn := false
+	prov := newMockProvisioner()
+	prov.hwState.PoweredOn = &poweredOn
+	prov.nextResults["PowerOn"] = provisioner.Result{Dirty: true}
+	r := &BareMetalHostReconciler{
+		Client:  unreachableClient{fakeclient.NewFakeClient()},
+		Journal: newJournal(t),
+	}
+
+	result := r.manageHostPower(prov, makeDefaultReconcileInfo(host.DeepCopy()))
+	assert.Equal(t, actionContinue{}, result)
+	_, found := r.Journal.pending(newRequest(host).NamespacedName, journalReboot)
+	assert.True(t, found)
+
+	// The host is powered back on although the annotation is still
+	// there
+	r.manageHostPower(prov, makeDefaultReconcileInfo(host))
+	assert.NotNil(t, host.Status.PowerChangeStarted)
+}
+
+func TestJournalReplayReboot(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{rebootAnnotationPrefix: ""}
+	r := newTestReconciler(host)
+	r.Journal = newJournal(t)
+	host = journaledHost(t, r)
+	assert.NoError(t, r.Journal.record(journalEntry{
+		Namespace: host.Namespace, Name: host.Name, Intent: journalReboot, ResourceVersion: host.ResourceVersion,
+	}))
+
+	replayed, err := r.replayJournal(goctx.TODO(), newRequest(host), host)
+	assert.NoError(t, err)
+	assert.True(t, replayed)
+	assert.NotContains(t, journaledHost(t, r).Annotations, rebootAnnotationPrefix)
+	_, found := r.Journal.pending(newRequest(host).NamespacedName, journalReboot)
+	assert.False(t, found)
+}
+
+func TestJournalDiscardsChangedReboot(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{rebootAnnotationPrefix: ""}
+	r := newTestReconciler(host)
+	r.Journal = newJournal(t)
+	host = journaledHost(t, r)
+	assert.NoError(t, r.Journal.record(journalEntry{
+		Namespace: host.Namespace, Name: host.Name, Intent: journalReboot, ResourceVersion: host.ResourceVersion,
+	}))
+
+	// A new reboot request
+	host.Spec.Online = false
+	assert.NoError(t, r.Update(goctx.TODO(), host))
+
+	replayed, err := r.replayJournal(goctx.TODO(), newRequest(host), journaledHost(t, r))
+	assert.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Contains(t, journaledHost(t, r).Annotations, rebootAnnotationPrefix)
+	_, found := r.Journal.pending(newRequest(host).NamespacedName, journalReboot)
+	assert.False(t, found)
+}
+
+func TestJournalReplayClean(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).SetImageURL("foo").SetStatusImageURL("foo").build()
+	host.Name = t.Name()
+	host.Namespace = namespace
+	r := &BareMetalHostReconciler{
+		Client:  fakeclient.NewFakeClient(),
+		Journal: newJournal(t),
+	}
+	name := types.NamespacedName{Namespace: host.Namespace, Name: host.Name}
+	assert.NoError(t, r.Journal.record(journalEntry{
+		Namespace: host.Namespace, Name: host.Name, Intent: journalClean,
+		State: metal3v1alpha1.StateProvisioned, Image: "foo",
+	}))
+
+	// The image was set back before the API server was reachable, but
+	// the host is cleaned through the state machine
+	hsm := newHostStateMachine(host, r, newMockProvisioner(), true)
+	info := makeDefaultReconcileInfo(host)
+	hsm.ReconcileState(info)
+	assert.Equal(t, metal3v1alpha1.StateDeprovisioning, host.Status.Provisioning.State)
+	assert.False(t, host.Status.OperationHistory.Deprovision.Start.IsZero())
+
+	// The intent is kept until the new state is saved
+	_, found := r.Journal.pending(name, journalClean)
+	assert.True(t, found)
+	for _, cb := range info.postSaveCallbacks {
+		cb()
+	}
+	_, found = r.Journal.pending(name, journalClean)
+	assert.False(t, found)
+}
+
+func TestJournalDiscardsChangedClean(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).SetImageURL("bar").SetStatusImageURL("bar").build()
+	host.Name = t.Name()
+	host.Namespace = namespace
+	r := &BareMetalHostReconciler{
+		Client:  fakeclient.NewFakeClient(),
+		Journal: newJournal(t),
+	}
+	name := types.NamespacedName{Namespace: host.Namespace, Name: host.Name}
+
+	// Cleaning does not apply to a host provisioned since
+	assert.NoError(t, r.Journal.record(journalEntry{
+		Namespace: host.Namespace, Name: host.Name, Intent: journalClean,
+		State: metal3v1alpha1.StateProvisioned, Image: "foo",
+	}))
+	hsm := newHostStateMachine(host, r, newMockProvisioner(), true)
+	hsm.ReconcileState(makeDefaultReconcileInfo(host))
+	assert.Equal(t, metal3v1alpha1.StateProvisioned, host.Status.Provisioning.State)
+	_, found := r.Journal.pending(name, journalClean)
+	assert.False(t, found)
+}
+
+func TestJournalPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	name := types.NamespacedName{Namespace: namespace, Name: "worker-0"}
+
+	j, err := NewJournal(path)
+	assert.NoError(t, err)
+	assert.NoError(t, j.record(journalEntry{
+		Namespace: name.Namespace, Name: name.Name, Intent: journalClean, ResourceVersion: "42",
+		State: metal3v1alpha1.StateProvisioned, Image: "foo",
+	}))
+
+	// A new run of the operator finds the entry
+	j, err = NewJournal(path)
+	assert.NoError(t, err)
+	entry, found := j.pending(name, journalClean)
+	if assert.True(t, found) {
+		assert.Equal(t, "42", entry.ResourceVersion)
+		assert.Equal(t, "foo", entry.Image)
+	}
+
+	j.forget(name, journalClean)
+	j, err = NewJournal(path)
+	assert.NoError(t, err)
+	_, found = j.pending(name, journalClean)
+	assert.False(t, found)
+}
+
+func TestNilJournal(t *testing.T) {
+	var j *Journal
+	name := types.NamespacedName{Namespace: namespace, Name: "worker-0"}
+	assert.Error(t, j.record(journalEntry{Namespace: name.Namespace, Name: name.Name, Intent: journalReboot}))
+	_, found := j.pending(name, journalReboot)
+	assert.False(t, found)
+	j.forget(name, journalReboot)
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/schedule"
+	"github.com/metal3-io/baremetal-operator/pkg/utils"
+)
+
+// invalidWindowRetryDelay is how long disruptive operations wait when
+// the maintenance window of the host cannot be parsed, until it is
+// fixed.
+const invalidWindowRetryDelay = time.Hour
+
+// maintenanceWindowWait returns how long a disruptive operation must
+// wait for the maintenance window, zero when the window is open, with
+// the start of the next window.
+func maintenanceWindowWait(window *metal3v1alpha1.MaintenanceWindow, now time.Time) (time.Duration, time.Time, error) {
+	if window == nil {
+		return 0, time.Time{}, nil
+	}
+	sched, err := schedule.Parse(window.Schedule)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	location := time.UTC
+	if window.TimeZone != "" {
+		if location, err = time.LoadLocation(window.TimeZone); err != nil {
+			return 0, time.Time{}, errors.Wrap(err, "invalid time zone")
+		}
+	}
+
+	now = now.In(location)
+	// A window started within its duration is still open
+	if start := sched.Next(now.Add(-window.Duration.Duration)); !start.IsZero() && !start.After(now) {
+		return 0, time.Time{}, nil
+	}
+	next := sched.Next(now)
+	if next.IsZero() {
+		return 0, time.Time{}, errors.Errorf("schedule %q never starts a window", window.Schedule)
+	}
+	return next.Sub(now), next, nil
+}
+
+// waitForMaintenance records in the status of the host whether the
+// disruptive operation, when needed, waits for the maintenance window.
+// It returns how long the operation waits, zero when it may run now,
+// and whether the status changed.
+func waitForMaintenance(info *reconcileInfo, operation string, needed bool, now time.Time) (wait time.Duration, dirty bool) {
+	host := info.host
+	var next time.Time
+	if needed {
+		var err error
+		wait, next, err = maintenanceWindowWait(host.Spec.MaintenanceWindow, now)
+		if err != nil {
+			info.log.Info("invalid maintenance window", "error", err.Error())
+			wait = invalidWindowRetryDelay
+		}
+	}
+
+	status := host.Status.Maintenance
+	pending := status != nil && utils.StringInList(status.Pending, operation)
+	switch {
+	case wait == 0 && pending:
+		status.Pending = utils.FilterStringFromList(status.Pending, operation)
+		if len(status.Pending) == 0 {
+			host.Status.Maintenance = nil
+		}
+		return 0, true
+	case wait == 0:
+		return 0, false
+	}
+
+	if status == nil {
+		status = &metal3v1alpha1.MaintenanceStatus{}
+		host.Status.Maintenance = status
+	}
+	if !pending {
+		info.log.Info("waiting for the maintenance window", "operation", operation, "wait", wait)
+		info.publishEvent("MaintenanceWindowWait",
+			fmt.Sprintf("%s waiting for the maintenance window", operation))
+		status.Pending = append(status.Pending, operation)
+		dirty = true
+	}
+	if !next.IsZero() && (status.NextWindow == nil || !status.NextWindow.Time.Equal(next)) {
+		nextWindow := metav1.NewTime(next)
+		status.NextWindow = &nextWindow
+		dirty = true
+	}
+	return wait, dirty
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+func TestMaintenanceWindowWait(t *testing.T) {
+	// Saturday 2am to 6am in Paris
+	window := &metal3v1alpha1.MaintenanceWindow{
+		Schedule: "0 2 * * 6",
+		Duration: metav1.Duration{Duration: 4 * time.Hour},
+		TimeZone: "Europe/Paris",
+	}
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	saturday := time.Date(2021, 3, 6, 2, 0, 0, 0, paris)
+
+	testCases := []struct {
+		Scenario     string
+		Now          time.Time
+		ExpectedWait time.Duration
+	}{
+		{
+			Scenario:     "before",
+			Now:          saturday.Add(-time.Hour),
+			ExpectedWait: time.Hour,
+		},
+		{
+			Scenario: "start",
+			Now:      saturday,
+		},
+		{
+			Scenario: "open",
+			Now:      saturday.Add(3 * time.Hour),
+		},
+		{
+			Scenario:     "after",
+			Now:          saturday.Add(4 * time.Hour),
+			ExpectedWait: 164 * time.Hour,
+		},
+		{
+			Scenario:     "other time zone",
+			Now:          saturday.Add(-time.Hour).UTC(),
+			ExpectedWait: time.Hour,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			wait, next, err := maintenanceWindowWait(window, tc.Now)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.ExpectedWait, wait)
+			if tc.ExpectedWait != 0 {
+				assert.True(t, next.Equal(tc.Now.Add(tc.ExpectedWait)))
+			}
+		})
+	}
+
+	wait, _, err := maintenanceWindowWait(nil, saturday)
+	assert.NoError(t, err)
+	assert.Zero(t, wait)
+
+	_, _, err = maintenanceWindowWait(&metal3v1alpha1.MaintenanceWindow{Schedule: "0 2 *"}, saturday)
+	assert.Error(t, err)
+}
+
+func TestWaitForMaintenance(t *testing.T) {
+	host := host(metal3v1alpha1.StateReady).build()
+	host.Spec.MaintenanceWindow = &metal3v1alpha1.MaintenanceWindow{
+		Schedule: "0 2 * * 6",
+		Duration: metav1.Duration{Duration: 4 * time.Hour},
+	}
+	friday := time.Date(2021, 3, 5, 12, 0, 0, 0, time.UTC)
+
+	info := makeDefaultReconcileInfo(host)
+	wait, dirty := waitForMaintenance(info, metal3v1alpha1.MaintenanceReinspection, true, friday)
+	assert.Equal(t, 14*time.Hour, wait)
+	assert.True(t, dirty)
+	if assert.NotNil(t, host.Status.Maintenance) {
+		assert.Equal(t, []string{metal3v1alpha1.MaintenanceReinspection}, host.Status.Maintenance.Pending)
+		assert.True(t, host.Status.Maintenance.NextWindow.Time.Equal(friday.Add(14*time.Hour)))
+	}
+	if assert.Len(t, info.events, 1) {
+		assert.Equal(t, "MaintenanceWindowWait", info.events[0].Reason)
+	}
+
+	// Waiting again changes nothing
+	info = makeDefaultReconcileInfo(host)
+	_, dirty = waitForMaintenance(info, metal3v1alpha1.MaintenanceReinspection, true, friday.Add(time.Hour))
+	assert.False(t, dirty)
+	assert.Empty(t, info.events)
+
+	// An operation that is not needed does not wait
+	wait, dirty = waitForMaintenance(info, metal3v1alpha1.MaintenanceReboot, false, friday)
+	assert.Zero(t, wait)
+	assert.False(t, dirty)
+
+	// The window opens
+	wait, dirty = waitForMaintenance(info, metal3v1alpha1.MaintenanceReinspection, true, friday.Add(15*time.Hour))
+	assert.Zero(t, wait)
+	assert.True(t, dirty)
+	assert.Nil(t, host.Status.Maintenance)
+}
+
+// TestManageHostPowerMaintenanceWindow ensures that a reboot requested
+// with the annotation waits for the maintenance window of a
+// provisioned host.
+func TestManageHostPowerMaintenanceWindow(t *testing.T) {
+	r := &BareMetalHostReconciler{}
+	host := host(metal3v1alpha1.StateProvisioned).SetStatusPoweredOn(true).build()
+	host.Spec.Online = true
+	host.Annotations = map[string]string{rebootAnnotationPrefix: ""}
+	// Closed all year but the first minute
+	host.Spec.MaintenanceWindow = &metal3v1alpha1.MaintenanceWindow{
+		Schedule: "0 0 1 1 *",
+		Duration: metav1.Duration{Duration: time.Minute},
+	}
+	poweredOn := true
+	prov := newMockProvisioner()
+	prov.hwState.PoweredOn = &poweredOn
+	prov.nextResults["PowerOff"] = provisioner.Result{Dirty: true}
+
+	result := r.manageHostPower(prov, makeDefaultReconcileInfo(host))
+	assert.IsType(t, actionUpdate{}, result)
+	assert.True(t, host.Status.PoweredOn)
+	if assert.NotNil(t, host.Status.Maintenance) {
+		assert.Equal(t, []string{metal3v1alpha1.MaintenanceReboot}, host.Status.Maintenance.Pending)
+	}
+
+	// Always open
+	host.Spec.MaintenanceWindow.Schedule = "* * * * *"
+	result = r.manageHostPower(prov, makeDefaultReconcileInfo(host))
+	assert.Nil(t, host.Status.Maintenance)
+	assert.IsType(t, actionUpdate{}, result)
+	assert.NotNil(t, host.Status.PowerChangeStarted)
+}
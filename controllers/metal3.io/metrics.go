@@ -17,6 +17,15 @@ const (
 	labelPrevState     = "prev_state"
 	labelNewState      = "new_state"
 	labelHostDataType  = "host_data_type"
+	labelOwnerTeam     = "owner_team"
+	labelAssetTag      = "asset_tag"
+
+	labelServicingOperation = "operation"
+	labelServicingResult    = "result"
+	labelManufacturer       = "manufacturer"
+	labelProductName        = "product_name"
+
+	labelProvisioningClass = "class"
 )
 
 var reconcileCounters = prometheus.NewCounterVec(prometheus.CounterOpts{
@@ -66,6 +75,44 @@ var delayedProvisioningHostCounters = prometheus.NewCounterVec(prometheus.Counte
 	Help: "The number of times hosts have been delayed while provisioning due a busy provisioner",
 }, []string{labelHostNamespace, labelHostName})
 
+var eraseProgress = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "metal3_host_erase_progress_percent",
+	Help: "Estimated percentage of the disk erasure completed while a host is cleaned",
+}, []string{labelHostNamespace, labelHostName})
+
+var delayedPowerOnHostCounters = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "metal3_delayed_power_on_total",
+	Help: "The number of times hosts have waited for their batch before being powered on",
+}, []string{labelHostNamespace, labelHostName})
+var powerOnWaiting = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "metal3_host_power_on_waiting",
+	Help: "Number of hosts waiting for their batch to be powered on",
+})
+var provisioningQueueWaiting = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "metal3_provisioning_queue_waiting",
+	Help: "Number of hosts of each provisioning class waiting to be inspected or provisioned",
+}, []string{labelProvisioningClass})
+var provisionerTimeouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "metal3_provisioner_call_timeouts_total",
+	Help: "Number of provisioner calls abandoned after the timeout",
+}, []string{"method"})
+var journaledIntents = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "metal3_host_journaled_intents",
+	Help: "Number of reboot and cleanup intents waiting for the API server to be saved",
+})
+var operatorPaused = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "metal3_operator_paused",
+	Help: "Whether the operator is paused and does not act on any host",
+})
+var annotationsMigrated = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "metal3_annotations_migrated_total",
+	Help: "Number of legacy annotations converted to their structured equivalents",
+}, []string{"annotation"})
+var annotationMigrationPending = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "metal3_annotation_migration_pending_hosts",
+	Help: "Number of hosts whose legacy annotations could not be converted yet",
+})
+
 var slowOperationBuckets = []float64{30, 90, 180, 360, 720, 1440}
 
 var stateTime = map[metal3v1alpha1.ProvisioningState]*prometheus.HistogramVec{
@@ -78,6 +125,11 @@ var stateTime = map[metal3v1alpha1.ProvisioningState]*prometheus.HistogramVec{
 		Help:    "Length of time per hardware inspection per host",
 		Buckets: slowOperationBuckets,
 	}, []string{labelHostNamespace, labelHostName}),
+	metal3v1alpha1.StatePreparing: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "metal3_operation_prepare_duration_seconds",
+		Help:    "Length of time per hardware preparation per host",
+		Buckets: slowOperationBuckets,
+	}, []string{labelHostNamespace, labelHostName}),
 	metal3v1alpha1.StateProvisioning: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Name:    "metal3_operation_provision_duration_seconds",
 		Help:    "Length of time per hardware provision operation per host",
@@ -110,13 +162,50 @@ var deleteWithoutDeprov = prometheus.NewCounter(prometheus.CounterOpts{
 	Help: "Number of times a host is deleted despite deprovisioning failing",
 })
 
+var forceDeleted = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "metal3_host_force_deleted_total",
+	Help: "Number of times a host is force deleted, leaving its node in the provisioner",
+})
+
+var hostOperationalInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "metal3_host_operational_info",
+	Help: "Operational metadata of a host, always 1",
+}, []string{labelHostNamespace, labelHostName, labelOwnerTeam, labelAssetTag})
+var warrantyExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "metal3_host_warranty_expiry_timestamp_seconds",
+	Help: "Time the hardware warranty of a host ends, in seconds since the epoch",
+}, []string{labelHostNamespace, labelHostName, labelOwnerTeam, labelAssetTag})
+
+var firmwareViolations = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "metal3_host_firmware_violations",
+	Help: "Number of firmware of a host older than required by its firmware baselines",
+}, []string{labelHostNamespace, labelHostName})
+
+var servicingDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name: "metal3_servicing_duration_seconds",
+	Help: "Length of time per servicing operation on provisioned hosts",
+}, []string{labelServicingOperation, labelServicingResult, labelManufacturer, labelProductName})
+var servicingReboots = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "metal3_servicing_reboots_total",
+	Help: "Number of reboots of provisioned hosts caused by servicing operations",
+}, []string{labelServicingOperation, labelManufacturer, labelProductName})
+
 func init() {
 	metrics.Registry.MustRegister(
 		reconcileCounters,
 		reconcileErrorCounter,
 		actionFailureCounters,
 		powerChangeAttempts,
-		delayedProvisioningHostCounters)
+		delayedProvisioningHostCounters,
+		delayedPowerOnHostCounters,
+		powerOnWaiting,
+		provisioningQueueWaiting,
+		journaledIntents,
+		operatorPaused,
+		annotationsMigrated,
+		annotationMigrationPending,
+		provisionerTimeouts,
+		eraseProgress)
 
 	for _, collector := range stateTime {
 		metrics.Registry.MustRegister(collector)
@@ -134,7 +223,13 @@ func init() {
 		stateChanges,
 		hostRegistrationRequired,
 		hostUnmanaged,
-		deleteWithoutDeprov)
+		deleteWithoutDeprov,
+		forceDeleted,
+		hostOperationalInfo,
+		warrantyExpiry,
+		firmwareViolations,
+		servicingDuration,
+		servicingReboots)
 }
 
 func hostMetricLabels(request ctrl.Request) prometheus.Labels {
@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/utils"
+)
+
+// moveBlockedError explains why a host cannot be moved yet.
+type moveBlockedError struct {
+	message string
+}
+
+func (e moveBlockedError) Error() string {
+	return e.message
+}
+
+// movableStates are the stable provisioning states a host can be
+// moved to another namespace in, without interrupting an operation.
+var movableStates = map[metal3v1alpha1.ProvisioningState]bool{
+	metal3v1alpha1.StateUnmanaged:             true,
+	metal3v1alpha1.StateReady:                 true,
+	metal3v1alpha1.StateAvailable:             true,
+	metal3v1alpha1.StateProvisioned:           true,
+	metal3v1alpha1.StateExternallyProvisioned: true,
+}
+
+// checkMove returns why the host cannot be moved to the target
+// namespace, if it cannot.
+func checkMove(host *metal3v1alpha1.BareMetalHost, target string) error {
+	if target == "" || target == host.Namespace {
+		return moveBlockedError{fmt.Sprintf("invalid target namespace %q", target)}
+	}
+	if consumer := host.Spec.ConsumerRef; consumer != nil && consumer.Namespace != target {
+		return moveBlockedError{fmt.Sprintf("host is consumed by %s %s/%s, which must be moved first",
+			consumer.Kind, consumer.Namespace, consumer.Name)}
+	}
+	return nil
+}
+
+// copySecret creates a copy of a secret of the source namespace in
+// the target namespace. An existing secret with the same data is
+// reused.
+func (r *BareMetalHostReconciler) copySecret(ctx context.Context, name, source, target string) error {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: source, Name: name}, secret); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return moveBlockedError{fmt.Sprintf("secret %s not found", name)}
+		}
+		return errors.Wrapf(err, "failed to read secret %s/%s", source, name)
+	}
+	copied := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: target,
+			Labels:    secret.Labels,
+		},
+		Type: secret.Type,
+		Data: secret.Data,
+	}
+	err := r.Create(ctx, copied)
+	if !k8serrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to copy secret %s to namespace %s", name, target)
+	}
+	existing := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: target, Name: name}, existing); err != nil {
+		return errors.Wrapf(err, "failed to read secret %s/%s", target, name)
+	}
+	if !reflect.DeepEqual(existing.Data, secret.Data) {
+		return moveBlockedError{fmt.Sprintf("secret %s already exists in namespace %s with other data", name, target)}
+	}
+	return nil
+}
+
+// movedHost returns the copy of the host to create in the target
+// namespace, pointing to copies of its secrets and carrying its status
+// in the status annotation.
+func movedHost(host *metal3v1alpha1.BareMetalHost, target string) (*metal3v1alpha1.BareMetalHost, error) {
+	status, err := json.Marshal(host.Status)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal host status")
+	}
+	annotations := map[string]string{}
+	for key, value := range host.Annotations {
+		annotations[key] = value
+	}
+	delete(annotations, metal3v1alpha1.MoveToAnnotation)
+	annotations[metal3v1alpha1.MovedFromAnnotation] = host.Namespace
+	annotations[metal3v1alpha1.StatusAnnotation] = string(status)
+
+	moved := &metal3v1alpha1.BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        host.Name,
+			Namespace:   target,
+			Labels:      host.Labels,
+			Annotations: annotations,
+		},
+		Spec: *host.Spec.DeepCopy(),
+	}
+	for _, ref := range hostSecretReferences(&moved.Spec) {
+		if ref.Namespace != "" && ref.Namespace == host.Namespace {
+			ref.Namespace = target
+		}
+	}
+	return moved, nil
+}
+
+// hostSecretReferences returns the references to the user data,
+// network data and metadata secrets of the host.
+func hostSecretReferences(spec *metal3v1alpha1.BareMetalHostSpec) (refs []*corev1.SecretReference) {
+	for _, ref := range []*corev1.SecretReference{spec.UserData, spec.NetworkData, spec.MetaData} {
+		if ref != nil {
+			refs = append(refs, ref)
+		}
+	}
+	for i := range spec.UserDataSecrets {
+		refs = append(refs, &spec.UserDataSecrets[i])
+	}
+	return refs
+}
+
+// moveSecrets copies the secrets of the host in its namespace to the
+// target namespace.
+func (r *BareMetalHostReconciler) moveSecrets(ctx context.Context, host *metal3v1alpha1.BareMetalHost, target string) error {
+	names := []string{}
+	if host.Spec.BMC.CredentialsName != "" {
+		names = append(names, host.Spec.BMC.CredentialsName)
+	}
+	for _, ref := range hostSecretReferences(&host.Spec) {
+		if ref.Name != "" && (ref.Namespace == "" || ref.Namespace == host.Namespace) {
+			names = append(names, ref.Name)
+		}
+	}
+	if host.Spec.ConfigDriveEncryption != nil {
+		// The key only exists once the host was provisioned with it
+		name := configDriveKeySecretName(host)
+		err := r.Get(ctx, types.NamespacedName{Namespace: host.Namespace, Name: name}, &corev1.Secret{})
+		switch {
+		case err == nil:
+			names = append(names, name)
+		case !k8serrors.IsNotFound(err):
+			return errors.Wrapf(err, "failed to read secret %s/%s", host.Namespace, name)
+		}
+	}
+	for _, name := range names {
+		if err := r.copySecret(ctx, name, host.Namespace, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createMovedHost creates the copy of the host and of its secrets in
+// the target namespace, unless it was already created.
+func (r *BareMetalHostReconciler) createMovedHost(ctx context.Context, host *metal3v1alpha1.BareMetalHost, target string) error {
+	if err := checkMove(host, target); err != nil {
+		return err
+	}
+	if err := r.moveSecrets(ctx, host, target); err != nil {
+		return err
+	}
+	moved, err := movedHost(host, target)
+	if err != nil {
+		return err
+	}
+	err = r.Create(ctx, moved)
+	if !k8serrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to create host in namespace %s", target)
+	}
+	existing := &metal3v1alpha1.BareMetalHost{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: target, Name: host.Name}, existing); err != nil {
+		return errors.Wrapf(err, "failed to read host %s/%s", target, host.Name)
+	}
+	if existing.Annotations[metal3v1alpha1.MovedFromAnnotation] != host.Namespace {
+		return moveBlockedError{fmt.Sprintf("host %s already exists in namespace %s", host.Name, target)}
+	}
+	return nil
+}
+
+// moveHost moves the host to the namespace of its MoveToAnnotation,
+// without deprovisioning it: the host is recreated there with its
+// status and a copy of its secrets, so that it keeps its node in the
+// provisioner, and is then removed from its namespace. It returns
+// false while the host cannot be moved, in which case it is
+// reconciled as usual.
+func (r *BareMetalHostReconciler) moveHost(ctx context.Context, request ctrl.Request, host *metal3v1alpha1.BareMetalHost, target string) (bool, error) {
+	reqLogger := r.Log.WithValues("baremetalhost", request.NamespacedName)
+	if !movableStates[host.Status.Provisioning.State] {
+		reqLogger.Info("waiting for a stable state to move host", "target", target)
+		return false, nil
+	}
+
+	err := r.createMovedHost(ctx, host, target)
+	if blocked, ok := err.(moveBlockedError); ok {
+		reqLogger.Info("cannot move host", "target", target, "reason", blocked.message)
+		r.publishEvent(request, host.NewEvent("MoveBlocked",
+			fmt.Sprintf("Cannot move host to namespace %s: %s", target, blocked.message)))
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	reqLogger.Info("moved host", "target", target, "node", host.Status.Provisioning.ID)
+	r.publishEvent(request, host.NewEvent("Moved", fmt.Sprintf("Host moved to namespace %s", target)))
+	host.Finalizers = utils.FilterStringFromList(
+		host.Finalizers, metal3v1alpha1.BareMetalHostFinalizer)
+	if err := r.Update(ctx, host); err != nil {
+		return false, errors.Wrap(err, "failed to remove finalizer")
+	}
+	if err := r.Delete(ctx, host); err != nil && !k8serrors.IsNotFound(err) {
+		return false, errors.Wrap(err, "failed to delete moved host")
+	}
+	return true, nil
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	goctx "context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func newMovingHost(t *testing.T, target string) *metal3v1alpha1.BareMetalHost {
+	host := newDefaultHost(t)
+	host.Finalizers = []string{metal3v1alpha1.BareMetalHostFinalizer}
+	host.Annotations = map[string]string{metal3v1alpha1.MoveToAnnotation: target}
+	host.Labels = map[string]string{"rack": "r12"}
+	host.Spec.UserData = &corev1.SecretReference{Name: "user-data", Namespace: namespace}
+	host.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	host.Status.Provisioning.ID = "node-uuid"
+	host.Status.OperationalStatus = metal3v1alpha1.OperationalStatusOK
+	now := metav1.Now()
+	host.Status.LastUpdated = &now
+	return host
+}
+
+// TestMoveHost ensures that a host is moved to another namespace with
+// its status and secrets.
+func TestMoveHost(t *testing.T) {
+	host := newMovingHost(t, "team-b")
+	r := newTestReconciler(host, newSecret("user-data", map[string]string{"userData": "#cloud-config"}))
+
+	_, err := r.Reconcile(goctx.TODO(), newRequest(host))
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = r.Get(goctx.TODO(), newRequest(host).NamespacedName, &metal3v1alpha1.BareMetalHost{})
+	assert.True(t, k8serrors.IsNotFound(err), "the host was not removed from its namespace")
+
+	for _, name := range []string{defaultSecretName, "user-data"} {
+		assert.NoError(t, r.Get(goctx.TODO(), types.NamespacedName{Namespace: "team-b", Name: name}, &corev1.Secret{}),
+			"secret %s was not copied", name)
+	}
+
+	moved := &metal3v1alpha1.BareMetalHost{}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "team-b", Name: host.Name}}
+	if !assert.NoError(t, r.Get(goctx.TODO(), request.NamespacedName, moved)) {
+		return
+	}
+	assert.Equal(t, namespace, moved.Annotations[metal3v1alpha1.MovedFromAnnotation])
+	assert.NotContains(t, moved.Annotations, metal3v1alpha1.MoveToAnnotation)
+	assert.Equal(t, "r12", moved.Labels["rack"])
+	assert.Equal(t, "team-b", moved.Spec.UserData.Namespace)
+
+	// The status is restored from the annotation
+	_, err = r.Reconcile(goctx.TODO(), request)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Get(goctx.TODO(), request.NamespacedName, moved))
+	assert.Equal(t, metal3v1alpha1.StateProvisioned, moved.Status.Provisioning.State)
+	assert.Equal(t, "node-uuid", moved.Status.Provisioning.ID)
+}
+
+func TestMoveHostBlocked(t *testing.T) {
+	host := newMovingHost(t, "team-b")
+	host.Spec.ConsumerRef = &corev1.ObjectReference{Kind: "Metal3Machine", Namespace: namespace, Name: "worker-0"}
+	r := newTestReconciler(host, newSecret("user-data", map[string]string{"userData": "#cloud-config"}))
+
+	moved, err := r.moveHost(goctx.TODO(), newRequest(host), host, "team-b")
+	assert.NoError(t, err)
+	assert.False(t, moved)
+	assert.NoError(t, r.Get(goctx.TODO(), newRequest(host).NamespacedName, &metal3v1alpha1.BareMetalHost{}))
+
+	// Once the consumer is in the target namespace, the host follows
+	host.Spec.ConsumerRef.Namespace = "team-b"
+	moved, err = r.moveHost(goctx.TODO(), newRequest(host), host, "team-b")
+	assert.NoError(t, err)
+	assert.True(t, moved)
+}
+
+func TestMoveHostWaitsForStableState(t *testing.T) {
+	host := newMovingHost(t, "team-b")
+	host.Status.Provisioning.State = metal3v1alpha1.StateProvisioning
+	r := newTestReconciler(host)
+
+	moved, err := r.moveHost(goctx.TODO(), newRequest(host), host, "team-b")
+	assert.NoError(t, err)
+	assert.False(t, moved)
+	err = r.Get(goctx.TODO(), types.NamespacedName{Namespace: "team-b", Name: host.Name}, &metal3v1alpha1.BareMetalHost{})
+	assert.True(t, k8serrors.IsNotFound(err))
+}
+
+func TestMoveHostExistingTarget(t *testing.T) {
+	host := newMovingHost(t, "team-b")
+	other := newHost(host.Name, &metal3v1alpha1.BareMetalHostSpec{})
+	other.Namespace = "team-b"
+	r := newTestReconciler(host, other, newSecret("user-data", map[string]string{"userData": "#cloud-config"}))
+
+	moved, err := r.moveHost(goctx.TODO(), newRequest(host), host, "team-b")
+	assert.NoError(t, err)
+	assert.False(t, moved)
+}
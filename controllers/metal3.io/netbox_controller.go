@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/netbox"
+)
+
+const (
+	// Labels set on hosts from the NetBox device with the same
+	// serial number.
+	netboxSiteLabel     = "netbox.metal3.io/site"
+	netboxRackLabel     = "netbox.metal3.io/rack"
+	netboxPositionLabel = "netbox.metal3.io/position"
+
+	// Custom fields of NetBox devices set from the host.
+	netboxHostField  = "metal3_host"
+	netboxStateField = "metal3_provisioning_state"
+
+	netboxSyncInterval = 10 * time.Minute
+)
+
+// NetBoxSyncReconciler keeps the devices in NetBox aligned with the
+// hosts, matching them by the serial number found during inspection.
+// The state and NICs of each host are pushed to its device, and the
+// site, rack and position of the device are copied to labels on the
+// host. Hosts without a matching device are left alone.
+type NetBoxSyncReconciler struct {
+	client.Client
+	Log    logr.Logger
+	NetBox *netbox.Client
+}
+
+// Reconcile synchronizes one host with NetBox.
+func (r *NetBoxSyncReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("baremetalhost", request.NamespacedName)
+
+	host := &metal3v1alpha1.BareMetalHost{}
+	if err := r.Get(ctx, request.NamespacedName, host); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, errors.Wrap(err, "could not load host data")
+	}
+
+	if host.Status.HardwareDetails == nil || host.Status.HardwareDetails.SystemVendor.SerialNumber == "" {
+		// Synchronized again once inspection has found the serial
+		// number.
+		return ctrl.Result{}, nil
+	}
+	serial := host.Status.HardwareDetails.SystemVendor.SerialNumber
+
+	device, err := r.NetBox.FindDeviceBySerial(serial)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to find NetBox device")
+	}
+	if device == nil {
+		reqLogger.Info("no NetBox device with serial number", "serial", serial)
+		return ctrl.Result{RequeueAfter: netboxSyncInterval}, nil
+	}
+
+	if err := r.pushDevice(host, device); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to update NetBox device")
+	}
+	if err := r.pushInterfaces(host, device); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to update NetBox interfaces")
+	}
+	patch := client.MergeFrom(host.DeepCopy())
+	if updated := r.pullLabels(reqLogger, host, device); updated {
+		if err := r.Patch(ctx, host, patch); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to save NetBox labels")
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: netboxSyncInterval}, nil
+}
+
+func (r *NetBoxSyncReconciler) pushDevice(host *metal3v1alpha1.BareMetalHost, device *netbox.Device) error {
+	wanted := map[string]interface{}{
+		netboxHostField:  host.Namespace + "/" + host.Name,
+		netboxStateField: string(host.Status.Provisioning.State),
+	}
+	changed := map[string]interface{}{}
+	for field, value := range wanted {
+		if device.CustomFields[field] != value {
+			changed[field] = value
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	return r.NetBox.UpdateDevice(device.ID, map[string]interface{}{
+		"custom_fields": changed,
+	})
+}
+
+func (r *NetBoxSyncReconciler) pushInterfaces(host *metal3v1alpha1.BareMetalHost, device *netbox.Device) error {
+	existing, err := r.NetBox.ListInterfaces(device.ID)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]netbox.Interface, len(existing))
+	for _, intf := range existing {
+		byName[intf.Name] = intf
+	}
+
+	seen := map[string]bool{}
+	for _, nic := range host.Status.HardwareDetails.NIC {
+		// Dual-stack interfaces are listed once per address
+		if nic.Name == "" || seen[nic.Name] {
+			continue
+		}
+		seen[nic.Name] = true
+
+		intf, ok := byName[nic.Name]
+		switch {
+		case !ok:
+			err = r.NetBox.CreateInterface(device.ID, nic.Name, nic.MAC)
+		case !strings.EqualFold(intf.MACAddress, nic.MAC):
+			err = r.NetBox.UpdateInterface(intf.ID, nic.MAC)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pullLabels copies the location of the device to the labels of the
+// host, returning true if any label changed.
+func (r *NetBoxSyncReconciler) pullLabels(log logr.Logger, host *metal3v1alpha1.BareMetalHost, device *netbox.Device) bool {
+	wanted := map[string]string{}
+	if device.Site != nil {
+		wanted[netboxSiteLabel] = device.Site.Name
+	}
+	if device.Rack != nil {
+		wanted[netboxRackLabel] = device.Rack.Name
+	}
+	if device.Position != nil {
+		wanted[netboxPositionLabel] = fmt.Sprintf("%g", *device.Position)
+	}
+
+	updated := false
+	for _, label := range []string{netboxSiteLabel, netboxRackLabel, netboxPositionLabel} {
+		value, ok := wanted[label]
+		if ok && len(validation.IsValidLabelValue(value)) > 0 {
+			log.Info("NetBox value is not a valid label value, skipping", "label", label, "value", value)
+			continue
+		}
+		current, present := host.Labels[label]
+		switch {
+		case ok && current != value:
+			if host.Labels == nil {
+				host.Labels = map[string]string{}
+			}
+			host.Labels[label] = value
+			updated = true
+		case !ok && present:
+			delete(host.Labels, label)
+			updated = true
+		}
+	}
+	return updated
+}
+
+// netboxUpdateEventHandler discards the updates of hosts that change
+// nothing synchronized with NetBox, such as most status saves. Changes
+// made in NetBox are picked up every netboxSyncInterval.
+func netboxUpdateEventHandler(e event.UpdateEvent) bool {
+	oldHost, oldOK := e.ObjectOld.(*metal3v1alpha1.BareMetalHost)
+	newHost, newOK := e.ObjectNew.(*metal3v1alpha1.BareMetalHost)
+	if !(oldOK && newOK) {
+		return true
+	}
+	return !reflect.DeepEqual(oldHost.Labels, newHost.Labels) ||
+		oldHost.Status.Provisioning.State != newHost.Status.Provisioning.State ||
+		!equality.Semantic.DeepEqual(oldHost.Status.HardwareDetails, newHost.Status.HardwareDetails)
+}
+
+// SetupWithManager registers the reconciler to be run by the manager
+func (r *NetBoxSyncReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("netbox").
+		For(&metal3v1alpha1.BareMetalHost{}).
+		WithEventFilter(
+			predicate.Funcs{
+				UpdateFunc: netboxUpdateEventHandler,
+			}).
+		Complete(r)
+}
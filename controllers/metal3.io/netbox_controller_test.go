@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	goctx "context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/netbox"
+)
+
+type fakeNetBox struct {
+	lock     sync.Mutex
+	requests map[string][]map[string]interface{}
+}
+
+func (f *fakeNetBox) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	key := r.Method + " " + r.URL.Path
+	var body map[string]interface{}
+	if content, _ := ioutil.ReadAll(r.Body); len(content) > 0 {
+		json.Unmarshal(content, &body)
+	}
+	f.requests[key] = append(f.requests[key], body)
+
+	switch key {
+	case "GET /api/dcim/devices/":
+		if r.URL.Query().Get("serial") != "ABC123" {
+			w.Write([]byte(`{"count": 0, "results": []}`))
+			return
+		}
+		w.Write([]byte(`{"count": 1, "results": [{"id": 7, "serial": "ABC123",
+			"site": {"id": 1, "name": "dc1"}, "rack": {"id": 2, "name": "r12"},
+			"position": 30, "custom_fields": {"metal3_host": null}}]}`))
+	case "GET /api/dcim/interfaces/":
+		w.Write([]byte(`{"count": 1, "results": [
+			{"id": 20, "name": "eth0", "mac_address": "00:B7:8B:BB:3D:F6"},
+			{"id": 21, "name": "eth1", "mac_address": "00:00:00:00:00:01"}]}`))
+	default:
+		w.Write([]byte(`{}`))
+	}
+}
+
+func newNetBoxTestReconciler(t *testing.T, host *metal3v1alpha1.BareMetalHost) (*NetBoxSyncReconciler, *fakeNetBox) {
+	nb := &fakeNetBox{requests: map[string][]map[string]interface{}{}}
+	server := httptest.NewServer(nb)
+	t.Cleanup(server.Close)
+
+	return &NetBoxSyncReconciler{
+		Client: fakeclient.NewFakeClient(host),
+		Log:    ctrl.Log.WithName("controllers").WithName("NetBox"),
+		NetBox: netbox.New(server.URL, "secret"),
+	}, nb
+}
+
+func TestNetBoxSync(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{
+		SystemVendor: metal3v1alpha1.HardwareSystemVendor{SerialNumber: "ABC123"},
+		NIC: []metal3v1alpha1.NIC{
+			{Name: "eth0", MAC: "00:b7:8b:bb:3d:f6", IP: "192.0.2.1"},
+			{Name: "eth0", MAC: "00:b7:8b:bb:3d:f6", IP: "2001:db8::1"},
+			{Name: "eth1", MAC: "00:b7:8b:bb:3d:f8"},
+			{Name: "eth2", MAC: "00:b7:8b:bb:3d:fa"},
+		},
+	}
+	r, nb := newNetBoxTestReconciler(t, host)
+
+	result, err := r.Reconcile(goctx.TODO(), newRequest(host))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, netboxSyncInterval, result.RequeueAfter)
+
+	assert.Equal(t, []map[string]interface{}{{
+		"custom_fields": map[string]interface{}{
+			"metal3_host":               host.Namespace + "/" + host.Name,
+			"metal3_provisioning_state": "provisioned",
+		},
+	}}, nb.requests["PATCH /api/dcim/devices/7/"])
+	// eth0 already matches, eth1 has the wrong MAC and eth2 is missing
+	assert.Len(t, nb.requests["PATCH /api/dcim/interfaces/20/"], 0)
+	assert.Equal(t, []map[string]interface{}{{"mac_address": "00:b7:8b:bb:3d:f8"}},
+		nb.requests["PATCH /api/dcim/interfaces/21/"])
+	if assert.Len(t, nb.requests["POST /api/dcim/interfaces/"], 1) {
+		assert.Equal(t, "eth2", nb.requests["POST /api/dcim/interfaces/"][0]["name"])
+	}
+
+	updated := &metal3v1alpha1.BareMetalHost{}
+	if assert.NoError(t, r.Get(goctx.TODO(), newRequest(host).NamespacedName, updated)) {
+		assert.Equal(t, "dc1", updated.Labels[netboxSiteLabel])
+		assert.Equal(t, "r12", updated.Labels[netboxRackLabel])
+		assert.Equal(t, "30", updated.Labels[netboxPositionLabel])
+	}
+}
+
+func TestNetBoxSyncNoDevice(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{
+		SystemVendor: metal3v1alpha1.HardwareSystemVendor{SerialNumber: "unknown"},
+	}
+	r, nb := newNetBoxTestReconciler(t, host)
+
+	_, err := r.Reconcile(goctx.TODO(), newRequest(host))
+	assert.NoError(t, err)
+	assert.Len(t, nb.requests, 1)
+}
+
+func TestNetBoxSyncNoSerial(t *testing.T) {
+	host := newDefaultHost(t)
+	r, nb := newNetBoxTestReconciler(t, host)
+
+	result, err := r.Reconcile(goctx.TODO(), newRequest(host))
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.Len(t, nb.requests, 0)
+}
+
+func TestNetBoxUpdateEventHandler(t *testing.T) {
+	oldHost := newDefaultHost(t)
+	oldHost.Status.Provisioning.State = metal3v1alpha1.StateReady
+
+	newHost := oldHost.DeepCopy()
+	newHost.Status.LastUpdated = &metav1.Time{}
+	newHost.Status.ErrorCount = 1
+	assert.False(t, netboxUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+
+	newHost = oldHost.DeepCopy()
+	newHost.Status.Provisioning.State = metal3v1alpha1.StateProvisioning
+	assert.True(t, netboxUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+
+	newHost = oldHost.DeepCopy()
+	newHost.Labels = map[string]string{netboxSiteLabel: "dc2"}
+	assert.True(t, netboxUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+
+	newHost = oldHost.DeepCopy()
+	newHost.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{
+		SystemVendor: metal3v1alpha1.HardwareSystemVendor{SerialNumber: "abc"},
+	}
+	assert.True(t, netboxUpdateEventHandler(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost}))
+}
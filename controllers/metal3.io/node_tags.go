@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// nodeTagsChanged reports whether the tags in the spec of a registered
+// host differ from those last set on its node, in any order.
+func nodeTagsChanged(host *metal3v1alpha1.BareMetalHost) bool {
+	if host.Status.Provisioning.ID == "" {
+		return false
+	}
+	if len(host.Spec.Tags) != len(host.Status.Tags) {
+		return true
+	}
+	set := make(map[string]bool, len(host.Status.Tags))
+	for _, tag := range host.Status.Tags {
+		set[tag] = true
+	}
+	for _, tag := range host.Spec.Tags {
+		if !set[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+// updateNodeTags sets the tags of the spec on the node of the host and
+// returns true when the status was saved. Tags that were set before
+// and have been removed from the node by another tool since, while the
+// spec still asks for them, are set again: the spec wins, and the
+// conflict is reported in an event.
+func (r *BareMetalHostReconciler) updateNodeTags(prov provisioner.Provisioner, info *reconcileInfo) (bool, error) {
+	host := info.host
+	conflicts, err := prov.SetTags(host.Spec.Tags, host.Status.Tags)
+	if errors.Is(err, provisioner.NeedsRegistration) {
+		info.log.Info("waiting for registration to set the tags of the node")
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if len(conflicts) != 0 {
+		info.log.Info("tags removed from the node by someone else were set again", "tags", conflicts)
+		info.publishEvent("TagConflict",
+			fmt.Sprintf("Set tags removed from the node again: %s", strings.Join(conflicts, ", ")))
+	}
+
+	host.Status.Tags = append([]string(nil), host.Spec.Tags...)
+	if len(host.Status.Tags) == 0 {
+		host.Status.Tags = nil
+	}
+	if err := r.saveHostStatus(host); err != nil {
+		return false, errors.Wrap(err, "failed to save the tags of the node")
+	}
+	info.log.Info("set the tags of the node", "tags", host.Spec.Tags)
+	return true, nil
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+func TestNodeTagsChanged(t *testing.T) {
+	testCases := []struct {
+		Scenario   string
+		Registered bool
+		Spec       []string
+		Status     []string
+		Expected   bool
+	}{
+		{Scenario: "no tags", Registered: true},
+		{Scenario: "not registered", Spec: []string{"gpu"}},
+		{Scenario: "new tags", Registered: true, Spec: []string{"gpu"}, Expected: true},
+		{Scenario: "same tags in another order", Registered: true,
+			Spec: []string{"gpu", "rack-12"}, Status: []string{"rack-12", "gpu"}},
+		{Scenario: "tag replaced", Registered: true,
+			Spec: []string{"gpu", "rack-14"}, Status: []string{"rack-12", "gpu"}, Expected: true},
+		{Scenario: "tags removed", Registered: true, Status: []string{"gpu"}, Expected: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := &metal3v1alpha1.BareMetalHost{}
+			if tc.Registered {
+				host.Status.Provisioning.ID = "node-uuid"
+			}
+			host.Spec.Tags = tc.Spec
+			host.Status.Tags = tc.Status
+			assert.Equal(t, tc.Expected, nodeTagsChanged(host))
+		})
+	}
+}
+
+func TestUpdateNodeTags(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Spec.Tags = []string{"gpu", "rack-12"}
+	host.Status.Tags = []string{"rack-12"}
+	r := newTestReconciler(host)
+	prov := newMockProvisioner()
+	prov.tagConflicts = []string{"rack-12"}
+	info := makeReconcileInfo(host)
+
+	updated, err := r.updateNodeTags(prov, info)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, host.Spec.Tags, prov.tags)
+	assert.Equal(t, host.Spec.Tags, host.Status.Tags)
+	if assert.Len(t, info.events, 1) {
+		assert.Equal(t, "TagConflict", info.events[0].Reason)
+	}
+
+	host.Spec.Tags = nil
+	updated, err = r.updateNodeTags(prov, makeReconcileInfo(host))
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	assert.Nil(t, host.Status.Tags)
+}
+
+func TestUpdateNodeTagsFailure(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Spec.Tags = []string{"gpu"}
+	r := newTestReconciler(host)
+	prov := newMockProvisioner()
+	info := makeReconcileInfo(host)
+
+	prov.tagsError = provisioner.NeedsRegistration
+	updated, err := r.updateNodeTags(prov, info)
+	assert.NoError(t, err)
+	assert.False(t, updated)
+
+	prov.tagsError = errors.New("ironic unreachable")
+	_, err = r.updateNodeTags(prov, info)
+	assert.Error(t, err)
+	assert.Nil(t, host.Status.Tags)
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/notify"
+)
+
+// eventMilestones maps the reasons of the events recorded on hosts to
+// the lifecycle milestones external systems are notified of.
+var eventMilestones = map[string]notify.Milestone{
+	"ProvisioningComplete":         notify.HostProvisioned,
+	"FirmwareUpdated":              notify.FirmwareUpdated,
+	"ProvisionedRegistrationError": notify.HostFailed,
+	"RegistrationError":            notify.HostFailed,
+	"InspectionError":              notify.HostFailed,
+	"ProvisioningError":            notify.HostFailed,
+	"PowerManagementError":         notify.HostFailed,
+	"MACMismatch":                  notify.HostFailed,
+	"DecommissionError":            notify.HostFailed,
+	"RetirementError":              notify.HostFailed,
+	"TimeoutError":                 notify.HostFailed,
+}
+
+// firmwareSteps are the clean steps of the Ironic hardware types that
+// update the firmware of a host.
+var firmwareSteps = map[string]bool{
+	"management.update_firmware":     true,
+	"management.update_firmware_sum": true,
+	"management.flash_firmware":      true,
+	"firmware.update":                true,
+}
+
+// notifyMilestone sends a notification when the event marks a
+// lifecycle milestone of the host.
+func (r *BareMetalHostReconciler) notifyMilestone(host *metal3v1alpha1.BareMetalHost, event corev1.Event) {
+	milestone, ok := eventMilestones[event.Reason]
+	if !ok {
+		return
+	}
+	r.Notifier.Notify(notify.Notification{
+		Milestone: milestone,
+		Namespace: host.Namespace,
+		Host:      host.Name,
+		Labels:    host.Labels,
+		State:     string(host.Status.Provisioning.State),
+		Reason:    event.Reason,
+		Message:   event.Message,
+		Time:      event.FirstTimestamp.Time,
+	})
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+func TestFirmwareUpdatedEvent(t *testing.T) {
+	host := host(metal3v1alpha1.StatePreparing).build()
+	info := makeDefaultReconcileInfo(host)
+
+	trackCleanStep(info, &provisioner.CleanStep{Name: "management.update_firmware", Index: 1, Total: 2}, metav1.Now())
+	trackCleanStep(info, &provisioner.CleanStep{Name: "bios.apply_configuration", Index: 2, Total: 2}, metav1.Now())
+	trackCleanStep(info, nil, metav1.Now())
+
+	var reasons []string
+	for _, event := range info.events {
+		reasons = append(reasons, event.Reason)
+	}
+	assert.Equal(t, []string{"CleanStepStarted", "CleanStepFinished", "FirmwareUpdated",
+		"CleanStepStarted", "CleanStepFinished"}, reasons)
+}
+
+func TestEventMilestones(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioning).build()
+	info := makeDefaultReconcileInfo(host)
+
+	// Every error type reported as an event is a failure
+	for _, errorType := range []metal3v1alpha1.ErrorType{
+		metal3v1alpha1.ProvisionedRegistrationError,
+		metal3v1alpha1.RegistrationError,
+		metal3v1alpha1.InspectionError,
+		metal3v1alpha1.ProvisioningError,
+		metal3v1alpha1.PowerManagementError,
+		metal3v1alpha1.MACMismatchError,
+		metal3v1alpha1.DecommissionError,
+	} {
+		info.events = nil
+		recordActionFailure(info, errorType, "failed")
+		if assert.Len(t, info.events, 1) {
+			assert.Contains(t, eventMilestones, info.events[0].Reason, "error type %s", errorType)
+		}
+	}
+
+	// Sending without a notifier does nothing
+	r := &BareMetalHostReconciler{}
+	r.notifyMilestone(host, host.NewEvent("ProvisioningComplete", "done"))
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// The operations that can time out, as used in the reason of the
+// TimedOut condition.
+const (
+	inspectionOperation   = "Inspection"
+	provisioningOperation = "Provisioning"
+	cleaningOperation     = "Cleaning"
+	powerChangeOperation  = "PowerChange"
+)
+
+// OperationTimeouts bounds how long the operations on hosts may take,
+// for the hosts that do not set their own timeouts. Zero lets an
+// operation take as long as it needs.
+type OperationTimeouts struct {
+	Inspection   time.Duration
+	Provisioning time.Duration
+	Cleaning     time.Duration
+	PowerChange  time.Duration
+}
+
+// loadFromEnv sets the timeouts not set yet from the environment.
+func (t *OperationTimeouts) loadFromEnv() error {
+	for name, timeout := range map[string]*time.Duration{
+		"INSPECTION_TIMEOUT":   &t.Inspection,
+		"PROVISIONING_TIMEOUT": &t.Provisioning,
+		"CLEANING_TIMEOUT":     &t.Cleaning,
+		"POWER_CHANGE_TIMEOUT": &t.PowerChange,
+	} {
+		value, ok := os.LookupEnv(name)
+		if !ok || *timeout != 0 {
+			continue
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			return errors.New(fmt.Sprintf("%s value: %s is invalid", name, value))
+		}
+		*timeout = parsed
+	}
+	return nil
+}
+
+// operationTimeout returns the timeout of the operation on the host,
+// from its spec or else from the operator defaults.
+func (r *BareMetalHostReconciler) operationTimeout(host *metal3v1alpha1.BareMetalHost, operation string) time.Duration {
+	var hostTimeout *metav1.Duration
+	var timeout time.Duration
+	spec := host.Spec.Timeouts
+	if spec == nil {
+		spec = &metal3v1alpha1.OperationTimeouts{}
+	}
+	switch operation {
+	case inspectionOperation:
+		hostTimeout, timeout = spec.Inspection, r.Timeouts.Inspection
+	case provisioningOperation:
+		hostTimeout, timeout = spec.Provisioning, r.Timeouts.Provisioning
+	case cleaningOperation:
+		hostTimeout, timeout = spec.Cleaning, r.Timeouts.Cleaning
+	case powerChangeOperation:
+		hostTimeout, timeout = spec.PowerChange, r.Timeouts.PowerChange
+	}
+	if hostTimeout != nil && hostTimeout.Duration > 0 {
+		return hostTimeout.Duration
+	}
+	return timeout
+}
+
+// checkOperationTimeout fails the operation started at the given time
+// if it has been running for longer than its timeout, and returns nil
+// otherwise. The failure is recorded again at every reconcile, backing
+// off, until the operation ends or its timeout is raised.
+func (r *BareMetalHostReconciler) checkOperationTimeout(info *reconcileInfo, operation string, started metav1.Time, now time.Time) actionResult {
+	timeout := r.operationTimeout(info.host, operation)
+	if timeout == 0 || started.IsZero() || now.Sub(started.Time) < timeout {
+		return nil
+	}
+
+	message := fmt.Sprintf("%s did not complete within %s", operation, timeout)
+	info.log.Info("operation timed out", "operation", operation, "timeout", timeout)
+	meta.SetStatusCondition(&info.host.Status.Conditions, metav1.Condition{
+		Type:               metal3v1alpha1.TimedOutCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             operation + "Timeout",
+		Message:            message,
+		ObservedGeneration: info.host.Generation,
+	})
+	return recordActionFailure(info, metal3v1alpha1.TimeoutError, message)
+}
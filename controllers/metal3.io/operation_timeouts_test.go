@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+func TestOperationTimeout(t *testing.T) {
+	r := &BareMetalHostReconciler{Timeouts: OperationTimeouts{Inspection: time.Hour}}
+	host := host(metal3v1alpha1.StateInspecting).build()
+
+	assert.Equal(t, time.Hour, r.operationTimeout(host, inspectionOperation))
+	assert.Zero(t, r.operationTimeout(host, provisioningOperation))
+
+	host.Spec.Timeouts = &metal3v1alpha1.OperationTimeouts{
+		Inspection:  &metav1.Duration{Duration: time.Minute * 10},
+		PowerChange: &metav1.Duration{},
+	}
+	assert.Equal(t, time.Minute*10, r.operationTimeout(host, inspectionOperation))
+	assert.Zero(t, r.operationTimeout(host, powerChangeOperation))
+}
+
+func TestOperationTimeoutsFromEnv(t *testing.T) {
+	os.Setenv("CLEANING_TIMEOUT", "3h")
+	defer os.Unsetenv("CLEANING_TIMEOUT")
+
+	timeouts := OperationTimeouts{Inspection: time.Hour}
+	assert.NoError(t, timeouts.loadFromEnv())
+	assert.Equal(t, OperationTimeouts{Inspection: time.Hour, Cleaning: time.Hour * 3}, timeouts)
+
+	os.Setenv("CLEANING_TIMEOUT", "never")
+	timeouts = OperationTimeouts{}
+	assert.Error(t, timeouts.loadFromEnv())
+}
+
+func TestCheckOperationTimeout(t *testing.T) {
+	r := &BareMetalHostReconciler{Timeouts: OperationTimeouts{Inspection: time.Hour}}
+	host := host(metal3v1alpha1.StateInspecting).build()
+	info := makeDefaultReconcileInfo(host)
+	now := time.Now()
+	started := metav1.NewTime(now.Add(-time.Minute * 30))
+
+	assert.Nil(t, r.checkOperationTimeout(info, inspectionOperation, started, now))
+	assert.Nil(t, r.checkOperationTimeout(info, inspectionOperation, metav1.Time{}, now))
+	assert.Nil(t, r.checkOperationTimeout(info, provisioningOperation, metav1.NewTime(now.Add(-time.Hour*24)), now))
+
+	result := r.checkOperationTimeout(info, inspectionOperation, started, now.Add(time.Hour))
+	assert.IsType(t, actionFailed{}, result)
+	assert.Equal(t, metal3v1alpha1.TimeoutError, host.Status.ErrorType)
+	assert.Equal(t, 1, host.Status.ErrorCount)
+
+	setHostConditions(host)
+	condition := meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.TimedOutCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, "InspectionTimeout", condition.Reason)
+	}
+	assert.Equal(t, "TimeoutError",
+		meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.FailedCondition).Reason)
+
+	clearError(host)
+	setHostConditions(host)
+	condition = meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.TimedOutCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, "NoTimeout", condition.Reason)
+	}
+}
+
+// TestManageHostPowerTimeout ensures that a power change that does not
+// complete in time fails, and starts over once the failure has backed
+// off.
+func TestManageHostPowerTimeout(t *testing.T) {
+	r := &BareMetalHostReconciler{Timeouts: OperationTimeouts{PowerChange: time.Minute * 5}}
+	host := host(metal3v1alpha1.StateProvisioned).SetStatusPoweredOn(false).build()
+	host.Spec.Online = true
+	poweredOn := false
+	prov := newMockProvisioner()
+	prov.hwState.PoweredOn = &poweredOn
+	prov.nextResults["PowerOn"] = provisioner.Result{Dirty: true}
+
+	result := r.manageHostPower(prov, makeDefaultReconcileInfo(host))
+	assert.IsType(t, actionUpdate{}, result)
+	if !assert.NotNil(t, host.Status.PowerChangeStarted) {
+		return
+	}
+
+	started := metav1.NewTime(time.Now().Add(-time.Minute * 10))
+	host.Status.PowerChangeStarted = &started
+	result = r.manageHostPower(prov, makeDefaultReconcileInfo(host))
+	assert.IsType(t, actionFailed{}, result)
+	assert.Equal(t, metal3v1alpha1.TimeoutError, host.Status.ErrorType)
+	assert.Nil(t, host.Status.PowerChangeStarted)
+
+	result = r.manageHostPower(prov, makeDefaultReconcileInfo(host))
+	assert.IsType(t, actionUpdate{}, result)
+	assert.NotNil(t, host.Status.PowerChangeStarted)
+	assert.Empty(t, host.Status.ErrorType)
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// operationalLabels remembers the labels of the operational metrics
+// of each host, so that the old series are dropped when the metadata
+// changes or the host goes away.
+var operationalLabels = struct {
+	sync.Mutex
+	hosts map[types.NamespacedName]prometheus.Labels
+}{hosts: map[types.NamespacedName]prometheus.Labels{}}
+
+// updateOperationalMetrics exports the operational metadata of the
+// host. A nil host removes the metrics of a deleted host.
+func updateOperationalMetrics(name types.NamespacedName, host *metal3v1alpha1.BareMetalHost) {
+	var labels prometheus.Labels
+	if host != nil && host.Spec.Operational != nil {
+		labels = prometheus.Labels{
+			labelHostNamespace: name.Namespace,
+			labelHostName:      name.Name,
+			labelOwnerTeam:     host.Spec.Operational.OwnerTeam,
+			labelAssetTag:      host.Spec.Operational.AssetTag,
+		}
+	}
+
+	operationalLabels.Lock()
+	defer operationalLabels.Unlock()
+	if old, ok := operationalLabels.hosts[name]; ok {
+		hostOperationalInfo.Delete(old)
+		warrantyExpiry.Delete(old)
+		delete(operationalLabels.hosts, name)
+	}
+	if labels == nil {
+		return
+	}
+	operationalLabels.hosts[name] = labels
+	hostOperationalInfo.With(labels).Set(1)
+	if expiry := host.Spec.Operational.WarrantyExpiry; expiry != nil {
+		warrantyExpiry.With(labels).Set(float64(expiry.Unix()))
+	}
+}
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	promutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestUpdateOperationalMetrics(t *testing.T) {
+	name := types.NamespacedName{Namespace: "myns", Name: "myhost"}
+	expiry := metav1.NewTime(time.Date(2027, 3, 31, 0, 0, 0, 0, time.UTC))
+	host := newDefaultHost(t)
+	host.Spec.Operational = &metal3v1alpha1.OperationalMetadata{
+		OwnerTeam:      "storage-ops",
+		AssetTag:       "A0012345",
+		WarrantyExpiry: &expiry,
+	}
+	labels := func(team string) []string {
+		return []string{name.Namespace, name.Name, team, "A0012345"}
+	}
+
+	updateOperationalMetrics(name, host)
+	assert.Equal(t, 1.0, promutil.ToFloat64(hostOperationalInfo.WithLabelValues(labels("storage-ops")...)))
+	assert.Equal(t, float64(expiry.Unix()), promutil.ToFloat64(warrantyExpiry.WithLabelValues(labels("storage-ops")...)))
+
+	// Changing the owner replaces the series
+	host.Spec.Operational.OwnerTeam = "compute-ops"
+	host.Spec.Operational.WarrantyExpiry = nil
+	updateOperationalMetrics(name, host)
+	assert.False(t, hostOperationalInfo.DeleteLabelValues(labels("storage-ops")...))
+	assert.False(t, warrantyExpiry.DeleteLabelValues(labels("compute-ops")...))
+	assert.Equal(t, 1.0, promutil.ToFloat64(hostOperationalInfo.WithLabelValues(labels("compute-ops")...)))
+
+	// Deleted hosts are not reported
+	updateOperationalMetrics(name, nil)
+	assert.False(t, hostOperationalInfo.DeleteLabelValues(labels("compute-ops")...))
+}
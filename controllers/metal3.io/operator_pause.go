@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// pausedRecheckDelay is the time between two reconciles of a host
+// while the operator is paused, to refresh its power state and to
+// resume once the pause is lifted.
+const pausedRecheckDelay = time.Minute
+
+// OperatorPause stops the operator from acting on any host while a
+// flag file exists, for emergency change freezes: no host is powered,
+// registered, inspected, provisioned, replaced or moved, and only the
+// power state of the hosts is kept up to date. The operations already
+// running in the provisioner are not interrupted. The file is meant
+// to be mounted from an optional ConfigMap, so that creating the
+// ConfigMap pauses the whole operator.
+type OperatorPause struct {
+	// File is the path of the flag file. The operator is never
+	// paused when it is empty.
+	File string
+}
+
+// paused reports whether the operator is paused.
+func (p *OperatorPause) paused() bool {
+	if p == nil || p.File == "" {
+		return false
+	}
+	_, err := os.Stat(p.File)
+	if err == nil {
+		operatorPaused.Set(1)
+		return true
+	}
+	operatorPaused.Set(0)
+	return false
+}
+
+// reconcilePaused only refreshes the power state of a host while the
+// operator is paused. A power change is handled like one made outside
+// of the operator once the pause is lifted.
+func (r *BareMetalHostReconciler) reconcilePaused(prov provisioner.Provisioner, info *reconcileInfo) (ctrl.Result, error) {
+	info.log.Info("operator is paused, only refreshing the power state")
+	result := ctrl.Result{RequeueAfter: pausedRecheckDelay}
+	if info.host.Status.Provisioning.ID == "" || info.host.HasDryRunAnnotation() {
+		return result, nil
+	}
+
+	hwState, err := prov.UpdateHardwareState()
+	if err != nil {
+		if errors.Is(err, provisioner.NeedsRegistration) {
+			return result, nil
+		}
+		return ctrl.Result{}, errors.Wrap(err, "failed to update the host power status")
+	}
+	if hwState.PoweredOn == nil {
+		return result, nil
+	}
+	refreshDue := powerRefreshDue(info.host, time.Now())
+	if !recordPowerState(info, *hwState.PoweredOn, info.host.Spec.Online) && !refreshDue {
+		return result, nil
+	}
+	if err := r.saveHostStatus(info.host); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to save host status while paused")
+	}
+	for _, e := range info.events {
+		r.publishEvent(info.request, e)
+	}
+	return result, nil
+}
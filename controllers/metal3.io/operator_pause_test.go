@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestOperatorPause(t *testing.T) {
+	dir, err := ioutil.TempDir("", "operator-pause")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "paused")
+
+	var nilPause *OperatorPause
+	assert.False(t, nilPause.paused())
+	assert.False(t, (&OperatorPause{}).paused())
+
+	p := &OperatorPause{File: file}
+	assert.False(t, p.paused())
+	if err := ioutil.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, p.paused())
+	os.Remove(file)
+	assert.False(t, p.paused())
+}
+
+// TestOperatorPauseReconcile ensures that a host does not progress
+// while the operator is paused, and resumes once the pause is lifted.
+func TestOperatorPauseReconcile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "operator-pause")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "paused")
+	if err := ioutil.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	host := newDefaultHost(t)
+	r := newTestReconciler(host)
+	r.Pause = &OperatorPause{File: file}
+	request := newRequest(host)
+
+	for i := 0; i < 5; i++ {
+		result, err := r.Reconcile(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 {
+			// Only the first pass, which adds the finalizer,
+			// requeues immediately.
+			assert.Equal(t, pausedRecheckDelay, result.RequeueAfter)
+		}
+	}
+	if err := r.Get(context.Background(), request.NamespacedName, host); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, metal3v1alpha1.StateNone, host.Status.Provisioning.State)
+
+	os.Remove(file)
+	waitForProvisioningState(t, r, host, metal3v1alpha1.StateRegistering)
+}
+
+// TestOperatorPauseHostRequests ensures that the requests handled
+// outside of the state machine do not reach the BMC while the operator
+// is paused.
+func TestOperatorPauseHostRequests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "operator-pause")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "paused")
+	if err := ioutil.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	host := newDefaultHost(t)
+	host.Finalizers = []string{metal3v1alpha1.BareMetalHostFinalizer}
+	host.Status.Provisioning.State = metal3v1alpha1.StateReady
+	host.Status.Provisioning.ID = "node-id"
+	host.Spec.Inspection = &metal3v1alpha1.InspectionSettings{OutOfBandRequest: "1"}
+	host.Spec.Tags = []string{"rack-1"}
+	host.Annotations = map[string]string{
+		metal3v1alpha1.ExportBIOSSettingsAnnotation: "",
+		metal3v1alpha1.RefreshStatusAnnotation:      "",
+	}
+	r := newTestReconciler(host)
+	r.Pause = &OperatorPause{File: file}
+	request := newRequest(host)
+
+	result, err := r.Reconcile(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, pausedRecheckDelay, result.RequeueAfter)
+	if err := r.Get(context.Background(), request.NamespacedName, host); err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, host.Status.OutOfBandInspection)
+	assert.Empty(t, host.Status.Tags)
+	assert.Contains(t, host.Annotations, metal3v1alpha1.ExportBIOSSettingsAnnotation)
+	assert.Contains(t, host.Annotations, metal3v1alpha1.RefreshStatusAnnotation)
+}
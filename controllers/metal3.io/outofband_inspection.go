@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// outOfBandInspectionRequested reports whether the spec of the host
+// asks for its inventory to be read from the BMC again.
+func outOfBandInspectionRequested(host *metal3v1alpha1.BareMetalHost) bool {
+	if host.Spec.Inspection == nil || host.Spec.Inspection.OutOfBandRequest == "" {
+		return false
+	}
+	status := host.Status.OutOfBandInspection
+	return status == nil || status.Request != host.Spec.Inspection.OutOfBandRequest
+}
+
+// inspectOutOfBand updates the hardware details of the host from the
+// inventory its BMC reports, leaving the host alone. It waits for the
+// host to be registered, and for a running inspection to finish. A
+// failure is recorded in the status instead of being retried, until
+// the request changes.
+func (r *BareMetalHostReconciler) inspectOutOfBand(prov provisioner.Provisioner, info *reconcileInfo) (bool, error) {
+	host := info.host
+	if host.Status.Provisioning.ID == "" || host.Status.Provisioning.State == metal3v1alpha1.StateInspecting {
+		info.log.Info("waiting for the host to be registered and inspected to inspect it out-of-band")
+		return false, nil
+	}
+
+	status := &metal3v1alpha1.OutOfBandInspectionStatus{Request: host.Spec.Inspection.OutOfBandRequest}
+	var details *metal3v1alpha1.HardwareDetails
+	var err error
+	if specHardwareDetails(host) != nil {
+		err = errors.New("the hardware details are set in the spec")
+	} else {
+		details, err = prov.InspectOutOfBand()
+	}
+	if err != nil {
+		info.log.Info("out-of-band inspection failed", "error", err.Error())
+		status.ErrorMessage = err.Error()
+		info.publishEvent("OutOfBandInspectionFailed", err.Error())
+	} else {
+		now := metav1.Now()
+		status.Time = &now
+		if details != nil {
+			changes := hardwareChanges(host.Status.HardwareDetails, details)
+			for _, change := range changes {
+				info.publishEvent("HardwareChanged", change)
+			}
+			r.recordHardwareSnapshot(info, details, metal3v1alpha1.HardwareSnapshotOutOfBand, changes)
+			host.Status.HardwareDetails = details
+			markRefreshed(&refreshTimes(host).Hardware)
+		}
+	}
+
+	host.Status.OutOfBandInspection = status
+	if err := r.saveHostStatus(host); err != nil {
+		return false, errors.Wrap(err, "failed to save the out-of-band inspection")
+	}
+	return true, nil
+}
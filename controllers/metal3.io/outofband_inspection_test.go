@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestOutOfBandInspectionRequested(t *testing.T) {
+	host := &metal3v1alpha1.BareMetalHost{}
+	assert.False(t, outOfBandInspectionRequested(host))
+
+	host.Spec.Inspection = &metal3v1alpha1.InspectionSettings{OutOfBandRequest: "2021-03-05"}
+	assert.True(t, outOfBandInspectionRequested(host))
+
+	host.Status.OutOfBandInspection = &metal3v1alpha1.OutOfBandInspectionStatus{Request: "2021-03-05"}
+	assert.False(t, outOfBandInspectionRequested(host))
+
+	host.Spec.Inspection.OutOfBandRequest = "2021-03-06"
+	assert.True(t, outOfBandInspectionRequested(host))
+}
+
+// TestInspectOutOfBand ensures that the hardware details of a
+// registered host are read from the BMC when requested.
+func TestInspectOutOfBand(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Spec.Inspection = &metal3v1alpha1.InspectionSettings{OutOfBandRequest: "1"}
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.OutOfBandInspection != nil
+		},
+	)
+
+	assert.Equal(t, "1", host.Status.OutOfBandInspection.Request)
+	assert.NotNil(t, host.Status.OutOfBandInspection.Time)
+	assert.Empty(t, host.Status.OutOfBandInspection.ErrorMessage)
+	if assert.NotNil(t, host.Status.HardwareDetails) {
+		assert.Equal(t, 128*1024, host.Status.HardwareDetails.RAMMebibytes)
+	}
+}
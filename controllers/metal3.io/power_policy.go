@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"time"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func powerStateName(poweredOn bool) string {
+	if poweredOn {
+		return "on"
+	}
+	return "off"
+}
+
+// externalPowerChangeDelay applies the power policy of the host to a
+// power state that does not match the spec. It returns false if the
+// state must be left alone because it was changed outside of the
+// operator, or how long to wait before changing it.
+func externalPowerChangeDelay(host *metal3v1alpha1.BareMetalHost, provisioned bool, now time.Time) (delay time.Duration, revert bool) {
+	if host.Status.LastExternalPowerChange == nil || host.Spec.PowerPolicy == nil {
+		return 0, true
+	}
+	policy := host.Spec.PowerPolicy
+
+	switch policy.Enforcement {
+	case metal3v1alpha1.PowerEnforcementObserve:
+		return 0, false
+	case metal3v1alpha1.PowerEnforcementUntilProvisioned:
+		if provisioned {
+			return 0, false
+		}
+	}
+
+	if policy.GracePeriod == nil {
+		return 0, true
+	}
+	if remaining := host.Status.LastExternalPowerChange.Add(policy.GracePeriod.Duration).Sub(now); remaining > 0 {
+		return remaining, true
+	}
+	return 0, true
+}
+
+// idlePowerOffDue reports whether the host has been available without
+// a consumer for longer than its power policy allows, so that it is
+// kept powered off until it is claimed or provisioned.
+func idlePowerOffDue(host *metal3v1alpha1.BareMetalHost, now time.Time) bool {
+	policy := host.Spec.PowerPolicy
+	if policy == nil || policy.IdlePowerOffAfter == nil || host.Status.AvailableSince == nil {
+		return false
+	}
+	switch host.Status.Provisioning.State {
+	case metal3v1alpha1.StateReady, metal3v1alpha1.StateAvailable:
+	default:
+		return false
+	}
+	if host.Spec.ConsumerRef != nil || host.NeedsProvisioning() {
+		return false
+	}
+	return !now.Before(host.Status.AvailableSince.Add(policy.IdlePowerOffAfter.Duration))
+}
+
+// desiredPowerState returns whether the host should be powered on,
+// which is the online field of its spec unless the host is idle.
+func desiredPowerState(host *metal3v1alpha1.BareMetalHost, now time.Time) bool {
+	return host.Spec.Online && !idlePowerOffDue(host, now)
+}
@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+func TestExternalPowerChangeDelay(t *testing.T) {
+	now := time.Now()
+	changed := metav1.NewTime(now.Add(-10 * time.Minute))
+
+	testCases := []struct {
+		Scenario      string
+		Policy        *metal3v1alpha1.PowerPolicy
+		Changed       *metav1.Time
+		Provisioned   bool
+		ExpectedDelay time.Duration
+		ExpectRevert  bool
+	}{
+		{
+			Scenario:     "no policy",
+			Changed:      &changed,
+			ExpectRevert: true,
+		},
+		{
+			Scenario:     "not external",
+			Policy:       &metal3v1alpha1.PowerPolicy{Enforcement: metal3v1alpha1.PowerEnforcementObserve},
+			ExpectRevert: true,
+		},
+		{
+			Scenario: "observe",
+			Policy:   &metal3v1alpha1.PowerPolicy{Enforcement: metal3v1alpha1.PowerEnforcementObserve},
+			Changed:  &changed,
+		},
+		{
+			Scenario:    "until provisioned, provisioned",
+			Policy:      &metal3v1alpha1.PowerPolicy{Enforcement: metal3v1alpha1.PowerEnforcementUntilProvisioned},
+			Changed:     &changed,
+			Provisioned: true,
+		},
+		{
+			Scenario:     "until provisioned, ready",
+			Policy:       &metal3v1alpha1.PowerPolicy{Enforcement: metal3v1alpha1.PowerEnforcementUntilProvisioned},
+			Changed:      &changed,
+			ExpectRevert: true,
+		},
+		{
+			Scenario:      "within grace period",
+			Policy:        &metal3v1alpha1.PowerPolicy{GracePeriod: &metav1.Duration{Duration: 30 * time.Minute}},
+			Changed:       &changed,
+			ExpectedDelay: 20 * time.Minute,
+			ExpectRevert:  true,
+		},
+		{
+			Scenario:     "after grace period",
+			Policy:       &metal3v1alpha1.PowerPolicy{GracePeriod: &metav1.Duration{Duration: 5 * time.Minute}},
+			Changed:      &changed,
+			ExpectRevert: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := host(metal3v1alpha1.StateProvisioned).build()
+			host.Spec.PowerPolicy = tc.Policy
+			host.Status.LastExternalPowerChange = tc.Changed
+
+			delay, revert := externalPowerChangeDelay(host, tc.Provisioned, now)
+			assert.Equal(t, tc.ExpectRevert, revert)
+			assert.InDelta(t, float64(tc.ExpectedDelay), float64(delay), float64(time.Second))
+		})
+	}
+}
+
+func TestManageHostPowerExternalChange(t *testing.T) {
+	testCases := []struct {
+		Scenario        string
+		Enforcement     metal3v1alpha1.PowerEnforcement
+		ExpectPoweredOn bool
+	}{
+		{
+			Scenario:        "always",
+			Enforcement:     metal3v1alpha1.PowerEnforcementAlways,
+			ExpectPoweredOn: true,
+		},
+		{
+			Scenario:    "observe",
+			Enforcement: metal3v1alpha1.PowerEnforcementObserve,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := host(metal3v1alpha1.StateProvisioned).SetStatusPoweredOn(true).build()
+			host.Spec.Online = true
+			host.Spec.PowerPolicy = &metal3v1alpha1.PowerPolicy{Enforcement: tc.Enforcement}
+			poweredOff := false
+			prov := newMockProvisioner()
+			prov.hwState.PoweredOn = &poweredOff
+			r := &BareMetalHostReconciler{}
+
+			// The host is found powered off without being asked to
+			info := makeDefaultReconcileInfo(host)
+			assert.Equal(t, actionUpdate{}, r.manageHostPower(prov, info))
+			assert.False(t, host.Status.PoweredOn)
+			assert.NotNil(t, host.Status.LastExternalPowerChange)
+			if assert.Len(t, info.events, 1) {
+				assert.Equal(t, "PowerChangedExternally", info.events[0].Reason)
+			}
+
+			r.manageHostPower(prov, makeDefaultReconcileInfo(host))
+			assert.Equal(t, tc.ExpectPoweredOn, host.Status.PoweredOn)
+		})
+	}
+}
+
+func TestIdlePowerOffDue(t *testing.T) {
+	now := time.Now()
+	hourAgo := metav1.NewTime(now.Add(-time.Hour))
+	minuteAgo := metav1.NewTime(now.Add(-time.Minute))
+	policy := &metal3v1alpha1.PowerPolicy{IdlePowerOffAfter: &metav1.Duration{Duration: 30 * time.Minute}}
+
+	testCases := []struct {
+		Scenario       string
+		State          metal3v1alpha1.ProvisioningState
+		Policy         *metal3v1alpha1.PowerPolicy
+		AvailableSince *metav1.Time
+		Consumer       bool
+		Expected       bool
+	}{
+		{
+			Scenario:       "no policy",
+			State:          metal3v1alpha1.StateAvailable,
+			AvailableSince: &hourAgo,
+		},
+		{
+			Scenario:       "not yet due",
+			State:          metal3v1alpha1.StateAvailable,
+			Policy:         policy,
+			AvailableSince: &minuteAgo,
+		},
+		{
+			Scenario:       "due",
+			State:          metal3v1alpha1.StateAvailable,
+			Policy:         policy,
+			AvailableSince: &hourAgo,
+			Expected:       true,
+		},
+		{
+			Scenario:       "ready",
+			State:          metal3v1alpha1.StateReady,
+			Policy:         policy,
+			AvailableSince: &hourAgo,
+			Expected:       true,
+		},
+		{
+			Scenario:       "claimed",
+			State:          metal3v1alpha1.StateAvailable,
+			Policy:         policy,
+			AvailableSince: &hourAgo,
+			Consumer:       true,
+		},
+		{
+			Scenario:       "provisioned",
+			State:          metal3v1alpha1.StateProvisioned,
+			Policy:         policy,
+			AvailableSince: &hourAgo,
+		},
+		{
+			Scenario: "available time unknown",
+			State:    metal3v1alpha1.StateAvailable,
+			Policy:   policy,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := host(tc.State).build()
+			host.Spec.Image = nil
+			host.Spec.Online = true
+			host.Spec.PowerPolicy = tc.Policy
+			host.Status.AvailableSince = tc.AvailableSince
+			if tc.Consumer {
+				host.Spec.ConsumerRef = &corev1.ObjectReference{Name: "consumer"}
+			}
+			assert.Equal(t, tc.Expected, idlePowerOffDue(host, now))
+			assert.Equal(t, !tc.Expected, desiredPowerState(host, now))
+		})
+	}
+}
+
+func TestManageHostPowerIdle(t *testing.T) {
+	host := host(metal3v1alpha1.StateAvailable).SetStatusPoweredOn(true).build()
+	host.Spec.Image = nil
+	host.Spec.Online = true
+	host.Spec.PowerPolicy = &metal3v1alpha1.PowerPolicy{IdlePowerOffAfter: &metav1.Duration{Duration: time.Hour}}
+	availableSince := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	host.Status.AvailableSince = &availableSince
+	poweredOn := true
+	prov := newMockProvisioner()
+	prov.hwState.PoweredOn = &poweredOn
+	r := &BareMetalHostReconciler{}
+
+	info := makeDefaultReconcileInfo(host)
+	r.manageHostPower(prov, info)
+	if assert.Len(t, info.events, 1) {
+		assert.Equal(t, "IdlePowerOff", info.events[0].Reason)
+	}
+
+	// Being powered off is what the policy asked for, not an external
+	// change to revert
+	poweredOn = false
+	info = makeDefaultReconcileInfo(host)
+	r.manageHostPower(prov, info)
+	assert.False(t, host.Status.PoweredOn)
+	assert.Nil(t, host.Status.LastExternalPowerChange)
+	assert.Empty(t, info.events)
+
+	// Claiming the host lets it be powered on again
+	host.Spec.ConsumerRef = &corev1.ObjectReference{Name: "consumer"}
+	prov.nextResults["PowerOn"] = provisioner.Result{Dirty: true}
+	r.manageHostPower(prov, makeDefaultReconcileInfo(host))
+	assert.NotNil(t, host.Status.PowerChangeStarted)
+}
+
+func TestIdlePowerOffStateMachine(t *testing.T) {
+	host := host(metal3v1alpha1.StateAvailable).SetStatusPoweredOn(true).build()
+	host.Spec.Image = nil
+	host.Spec.Online = true
+	host.Spec.PowerPolicy = &metal3v1alpha1.PowerPolicy{IdlePowerOffAfter: &metav1.Duration{Duration: time.Hour}}
+	availableSince := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	host.Status.AvailableSince = &availableSince
+	poweredOn := true
+	prov := newMockProvisioner()
+	prov.hwState.PoweredOn = &poweredOn
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+
+	// The power off is requested
+	prov.nextResults["PowerOff"] = provisioner.Result{Dirty: true}
+	hsm.ReconcileState(makeDefaultReconcileInfo(host))
+	assert.NotNil(t, host.Status.PowerChangeStarted)
+
+	assert.Equal(t, metal3v1alpha1.StateAvailable, host.Status.Provisioning.State)
+	assert.Equal(t, &availableSince, host.Status.AvailableSince)
+
+	// The provisioner finds the power off done before the new power
+	// state has been read
+	delete(prov.nextResults, "PowerOff")
+	hsm.ReconcileState(makeDefaultReconcileInfo(host))
+	assert.False(t, host.Status.PoweredOn)
+
+	// The host stays off without the status flipping back
+	poweredOn = false
+	info := makeDefaultReconcileInfo(host)
+	hsm.ReconcileState(info)
+	assert.False(t, host.Status.PoweredOn)
+	assert.Nil(t, host.Status.PowerChangeStarted)
+	assert.Nil(t, host.Status.LastExternalPowerChange)
+	assert.Empty(t, info.events)
+	assert.Equal(t, metal3v1alpha1.StateAvailable, host.Status.Provisioning.State)
+	assert.Equal(t, &availableSince, host.Status.AvailableSince)
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"sync"
+	"time"
+)
+
+// PowerOnStagger limits how many hosts the operator powers on at the
+// same time, so that hosts found powered off together, for example
+// after a power outage of the data center, are powered on in batches
+// rather than all at once.
+type PowerOnStagger struct {
+	// BatchSize is the number of hosts powered on in each
+	// interval. Zero disables staggering.
+	BatchSize int
+	// Interval is the time between two batches.
+	Interval time.Duration
+
+	lock        sync.Mutex
+	windowStart time.Time
+	inWindow    int
+	admitted    map[string]bool
+	waiting     map[string]bool
+}
+
+// Admit reports how long the host must wait before it is powered
+// on. Zero means the host may be powered on now. A host stays admitted
+// until Done is called for it, so retrying a power on that is still in
+// progress does not use a place in the next batch.
+func (s *PowerOnStagger) Admit(host string, now time.Time) time.Duration {
+	if s == nil || s.BatchSize <= 0 {
+		return 0
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.admitted == nil {
+		s.admitted = make(map[string]bool)
+		s.waiting = make(map[string]bool)
+	}
+	if s.admitted[host] {
+		return 0
+	}
+
+	if !now.Before(s.windowStart.Add(s.Interval)) {
+		s.windowStart = now
+		s.inWindow = 0
+	}
+	if s.inWindow < s.BatchSize {
+		s.inWindow++
+		s.admitted[host] = true
+		delete(s.waiting, host)
+		powerOnWaiting.Set(float64(len(s.waiting)))
+		return 0
+	}
+
+	s.waiting[host] = true
+	powerOnWaiting.Set(float64(len(s.waiting)))
+	return s.windowStart.Add(s.Interval).Sub(now)
+}
+
+// Done forgets the host once it has reached its desired power state.
+func (s *PowerOnStagger) Done(host string) {
+	if s == nil || s.BatchSize <= 0 {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.admitted[host] && !s.waiting[host] {
+		return
+	}
+	delete(s.admitted, host)
+	delete(s.waiting, host)
+	powerOnWaiting.Set(float64(len(s.waiting)))
+}
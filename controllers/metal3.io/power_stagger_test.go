@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestPowerOnStaggerAdmit(t *testing.T) {
+	now := time.Now()
+	s := &PowerOnStagger{BatchSize: 2, Interval: time.Minute}
+
+	assert.Zero(t, s.Admit("ns/host-0", now))
+	assert.Zero(t, s.Admit("ns/host-1", now.Add(time.Second)))
+	assert.Equal(t, 50*time.Second, s.Admit("ns/host-2", now.Add(10*time.Second)))
+
+	// Retrying a host that was already admitted does not wait
+	assert.Zero(t, s.Admit("ns/host-0", now.Add(20*time.Second)))
+
+	// The next batch starts once the interval has passed
+	assert.Zero(t, s.Admit("ns/host-2", now.Add(time.Minute)))
+	assert.Zero(t, s.Admit("ns/host-3", now.Add(time.Minute)))
+	assert.Equal(t, time.Minute, s.Admit("ns/host-4", now.Add(time.Minute)))
+	assert.Len(t, s.waiting, 1)
+
+	s.Done("ns/host-4")
+	assert.Empty(t, s.waiting)
+	s.Done("ns/host-0")
+	assert.NotContains(t, s.admitted, "ns/host-0")
+}
+
+func TestPowerOnStaggerDisabled(t *testing.T) {
+	var s *PowerOnStagger
+	assert.Zero(t, s.Admit("ns/host-0", time.Now()))
+	s.Done("ns/host-0")
+
+	s = &PowerOnStagger{}
+	for i := 0; i < 10; i++ {
+		assert.Zero(t, s.Admit("ns/host-0", time.Now()))
+	}
+}
+
+func TestManageHostPowerStaggered(t *testing.T) {
+	r := &BareMetalHostReconciler{
+		PowerOnStagger: &PowerOnStagger{BatchSize: 1, Interval: time.Hour},
+	}
+	r.PowerOnStagger.Admit("other/host", time.Now())
+
+	host := host("").SetStatusPoweredOn(false).build()
+	host.Spec.Online = true
+	poweredOff := false
+	prov := newMockProvisioner()
+	prov.hwState.PoweredOn = &poweredOff
+
+	result := r.manageHostPower(prov, makeDefaultReconcileInfo(host))
+	if assert.IsType(t, actionContinue{}, result) {
+		assert.Greater(t, int64(result.(actionContinue).delay), int64(59*time.Minute))
+	}
+	assert.False(t, host.Status.PoweredOn)
+}
+
+func TestPowerOnStaggerForgetsDeletedHost(t *testing.T) {
+	host := newDefaultHost(t)
+	r := newTestReconciler()
+	r.PowerOnStagger = &PowerOnStagger{BatchSize: 1, Interval: time.Hour}
+	r.PowerOnStagger.Admit("other/host", time.Now())
+	name := types.NamespacedName{Namespace: host.Namespace, Name: host.Name}.String()
+	assert.NotZero(t, r.PowerOnStagger.Admit(name, time.Now()))
+
+	_, err := r.Reconcile(context.Background(), newRequest(host))
+	assert.NoError(t, err)
+	assert.False(t, r.PowerOnStagger.waiting[name])
+}
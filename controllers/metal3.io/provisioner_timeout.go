@@ -0,0 +1,374 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// defaultProvisionerTimeout bounds the time a single provisioner call
+// may take when PROVISIONER_TIMEOUT is not set.
+const defaultProvisionerTimeout = 5 * time.Minute
+
+// timeoutProvisioner bounds the time the calls to a provisioner take,
+// so that a hung backend, such as an Ironic API that stopped
+// answering, cannot stall a reconcile worker and starve the other
+// hosts. The requests of provisioners that can be canceled are given
+// the deadline, and a call that times out is waited for until they
+// are canceled, so that it does not overlap with the next reconcile of
+// the host. Other provisioners are left running in the background.
+// Either way the provisioner is then abandoned: its later calls fail
+// immediately, and the events it still publishes are dropped.
+type timeoutProvisioner struct {
+	prov    provisioner.Provisioner
+	timeout time.Duration
+
+	lock      sync.Mutex
+	abandoned string
+}
+
+// withProvisionerTimeout returns a factory of provisioners whose calls
+// time out after timeout.
+func withProvisionerTimeout(factory provisioner.Factory, timeout time.Duration) provisioner.Factory {
+	return func(host metal3v1alpha1.BareMetalHost, bmcCreds bmc.Credentials, publish provisioner.EventPublisher) (provisioner.Provisioner, error) {
+		p := &timeoutProvisioner{timeout: timeout}
+		prov, err := factory(host, bmcCreds, p.publisher(publish))
+		if err != nil {
+			return nil, err
+		}
+		p.prov = prov
+		return p, nil
+	}
+}
+
+// publisher drops the events published once the provisioner has been
+// abandoned, since they come from a call running in the background.
+func (p *timeoutProvisioner) publisher(publish provisioner.EventPublisher) provisioner.EventPublisher {
+	return func(reason, message string) {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		if p.abandoned == "" {
+			publish(reason, message)
+		}
+	}
+}
+
+// call runs fn, which calls the provisioner method, with a deadline.
+func (p *timeoutProvisioner) call(method string, fn func()) error {
+	p.lock.Lock()
+	if p.abandoned != "" {
+		p.lock.Unlock()
+		return ProvisionerTimeoutError{Method: p.abandoned, Timeout: p.timeout}
+	}
+	p.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	cancelable, canCancel := p.prov.(provisioner.Cancelable)
+	if canCancel {
+		cancelable.SetContext(ctx)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.lock.Lock()
+		p.abandoned = method
+		p.lock.Unlock()
+		if canCancel {
+			<-done
+		}
+		provisionerTimeouts.WithLabelValues(method).Inc()
+		return ProvisionerTimeoutError{Method: method, Timeout: p.timeout}
+	}
+}
+
+// recordProvisionerTimeout records the timeout in the status of the
+// host. The host is read again, since the changes made to it before
+// the timeout were not meant to be saved.
+func (r *BareMetalHostReconciler) recordProvisionerTimeout(request ctrl.Request, timeoutErr ProvisionerTimeoutError) {
+	host := &metal3v1alpha1.BareMetalHost{}
+	if err := r.Get(context.TODO(), request.NamespacedName, host); err != nil {
+		r.Log.Info("failed to record provisioner timeout", "baremetalhost", request.NamespacedName, "error", err.Error())
+		return
+	}
+	host.Status.LastProvisionerTimeout = &metal3v1alpha1.ProvisionerTimeout{
+		Method:  timeoutErr.Method,
+		Timeout: metav1.Duration{Duration: timeoutErr.Timeout},
+		Time:    metav1.Now(),
+	}
+	if err := r.Status().Update(context.TODO(), host); err != nil {
+		r.Log.Info("failed to record provisioner timeout", "baremetalhost", request.NamespacedName, "error", err.Error())
+	}
+}
+
+func (p *timeoutProvisioner) ValidateManagementAccess(credentialsChanged, force bool) (provisioner.Result, string, error) {
+	var result provisioner.Result
+	var provID string
+	var err error
+	if timeoutErr := p.call("ValidateManagementAccess", func() {
+		result, provID, err = p.prov.ValidateManagementAccess(credentialsChanged, force)
+	}); timeoutErr != nil {
+		return provisioner.Result{}, "", timeoutErr
+	}
+	return result, provID, err
+}
+
+func (p *timeoutProvisioner) InspectHardware(force, refresh bool) (provisioner.Result, bool, *metal3v1alpha1.HardwareDetails, error) {
+	var result provisioner.Result
+	var started bool
+	var details *metal3v1alpha1.HardwareDetails
+	var err error
+	if timeoutErr := p.call("InspectHardware", func() {
+		result, started, details, err = p.prov.InspectHardware(force, refresh)
+	}); timeoutErr != nil {
+		return provisioner.Result{}, false, nil, timeoutErr
+	}
+	return result, started, details, err
+}
+
+func (p *timeoutProvisioner) UpdateHardwareState() (provisioner.HardwareState, error) {
+	var hwState provisioner.HardwareState
+	var err error
+	if timeoutErr := p.call("UpdateHardwareState", func() {
+		hwState, err = p.prov.UpdateHardwareState()
+	}); timeoutErr != nil {
+		return provisioner.HardwareState{}, timeoutErr
+	}
+	return hwState, err
+}
+
+func (p *timeoutProvisioner) GetBIOSSettings() (map[string]string, error) {
+	var settings map[string]string
+	var err error
+	if timeoutErr := p.call("GetBIOSSettings", func() {
+		settings, err = p.prov.GetBIOSSettings()
+	}); timeoutErr != nil {
+		return nil, timeoutErr
+	}
+	return settings, err
+}
+
+func (p *timeoutProvisioner) InspectOutOfBand() (*metal3v1alpha1.HardwareDetails, error) {
+	var details *metal3v1alpha1.HardwareDetails
+	var err error
+	if timeoutErr := p.call("InspectOutOfBand", func() {
+		details, err = p.prov.InspectOutOfBand()
+	}); timeoutErr != nil {
+		return nil, timeoutErr
+	}
+	return details, err
+}
+
+func (p *timeoutProvisioner) GetDriverStatus() (*metal3v1alpha1.DriverStatus, error) {
+	var driver *metal3v1alpha1.DriverStatus
+	var err error
+	if timeoutErr := p.call("GetDriverStatus", func() {
+		driver, err = p.prov.GetDriverStatus()
+	}); timeoutErr != nil {
+		return nil, timeoutErr
+	}
+	return driver, err
+}
+
+func (p *timeoutProvisioner) GetAgentVersion() (string, error) {
+	var version string
+	var err error
+	if timeoutErr := p.call("GetAgentVersion", func() {
+		version, err = p.prov.GetAgentVersion()
+	}); timeoutErr != nil {
+		return "", timeoutErr
+	}
+	return version, err
+}
+
+func (p *timeoutProvisioner) InstallBootCertificate(installed string) (provisioner.Result, string, error) {
+	var result provisioner.Result
+	var fingerprint string
+	var err error
+	if timeoutErr := p.call("InstallBootCertificate", func() {
+		result, fingerprint, err = p.prov.InstallBootCertificate(installed)
+	}); timeoutErr != nil {
+		return provisioner.Result{}, "", timeoutErr
+	}
+	return result, fingerprint, err
+}
+
+func (p *timeoutProvisioner) UpdateSecureBootDatabase(update metal3v1alpha1.SecureBootDatabaseUpdate) error {
+	var err error
+	if timeoutErr := p.call("UpdateSecureBootDatabase", func() {
+		err = p.prov.UpdateSecureBootDatabase(update)
+	}); timeoutErr != nil {
+		return timeoutErr
+	}
+	return err
+}
+
+func (p *timeoutProvisioner) DetectVirtualMedia() (*metal3v1alpha1.VirtualMediaSupport, error) {
+	var support *metal3v1alpha1.VirtualMediaSupport
+	var err error
+	if timeoutErr := p.call("DetectVirtualMedia", func() {
+		support, err = p.prov.DetectVirtualMedia()
+	}); timeoutErr != nil {
+		return nil, timeoutErr
+	}
+	return support, err
+}
+
+func (p *timeoutProvisioner) CleanBootArtifacts() ([]string, error) {
+	var residue []string
+	var err error
+	if timeoutErr := p.call("CleanBootArtifacts", func() {
+		residue, err = p.prov.CleanBootArtifacts()
+	}); timeoutErr != nil {
+		return nil, timeoutErr
+	}
+	return residue, err
+}
+
+func (p *timeoutProvisioner) ProtectBootOrder() ([]string, error) {
+	var corrected []string
+	var err error
+	if timeoutErr := p.call("ProtectBootOrder", func() {
+		corrected, err = p.prov.ProtectBootOrder()
+	}); timeoutErr != nil {
+		return nil, timeoutErr
+	}
+	return corrected, err
+}
+
+func (p *timeoutProvisioner) SetTags(tags, previous []string) ([]string, error) {
+	var conflicts []string
+	var err error
+	if timeoutErr := p.call("SetTags", func() {
+		conflicts, err = p.prov.SetTags(tags, previous)
+	}); timeoutErr != nil {
+		return nil, timeoutErr
+	}
+	return conflicts, err
+}
+
+func (p *timeoutProvisioner) Adopt(force bool) (provisioner.Result, error) {
+	var result provisioner.Result
+	var err error
+	if timeoutErr := p.call("Adopt", func() {
+		result, err = p.prov.Adopt(force)
+	}); timeoutErr != nil {
+		return provisioner.Result{}, timeoutErr
+	}
+	return result, err
+}
+
+func (p *timeoutProvisioner) Prepare(unprepared bool) (provisioner.Result, bool, error) {
+	var result provisioner.Result
+	var started bool
+	var err error
+	if timeoutErr := p.call("Prepare", func() {
+		result, started, err = p.prov.Prepare(unprepared)
+	}); timeoutErr != nil {
+		return provisioner.Result{}, false, timeoutErr
+	}
+	return result, started, err
+}
+
+func (p *timeoutProvisioner) Erase(start bool, opts provisioner.EraseOptions) (provisioner.Result, bool, error) {
+	var result provisioner.Result
+	var started bool
+	var err error
+	if timeoutErr := p.call("Erase", func() {
+		result, started, err = p.prov.Erase(start, opts)
+	}); timeoutErr != nil {
+		return provisioner.Result{}, false, timeoutErr
+	}
+	return result, started, err
+}
+
+func (p *timeoutProvisioner) Provision(configData provisioner.HostConfigData, requestID string) (provisioner.Result, error) {
+	var result provisioner.Result
+	var err error
+	if timeoutErr := p.call("Provision", func() {
+		result, err = p.prov.Provision(configData, requestID)
+	}); timeoutErr != nil {
+		return provisioner.Result{}, timeoutErr
+	}
+	return result, err
+}
+
+func (p *timeoutProvisioner) Deprovision(force bool, requestID string) (provisioner.Result, error) {
+	var result provisioner.Result
+	var err error
+	if timeoutErr := p.call("Deprovision", func() {
+		result, err = p.prov.Deprovision(force, requestID)
+	}); timeoutErr != nil {
+		return provisioner.Result{}, timeoutErr
+	}
+	return result, err
+}
+
+func (p *timeoutProvisioner) Delete() (provisioner.Result, error) {
+	var result provisioner.Result
+	var err error
+	if timeoutErr := p.call("Delete", func() {
+		result, err = p.prov.Delete()
+	}); timeoutErr != nil {
+		return provisioner.Result{}, timeoutErr
+	}
+	return result, err
+}
+
+func (p *timeoutProvisioner) PowerOn(requestID string) (provisioner.Result, error) {
+	var result provisioner.Result
+	var err error
+	if timeoutErr := p.call("PowerOn", func() {
+		result, err = p.prov.PowerOn(requestID)
+	}); timeoutErr != nil {
+		return provisioner.Result{}, timeoutErr
+	}
+	return result, err
+}
+
+func (p *timeoutProvisioner) PowerOff(rebootMode metal3v1alpha1.RebootMode, requestID string) (provisioner.Result, error) {
+	var result provisioner.Result
+	var err error
+	if timeoutErr := p.call("PowerOff", func() {
+		result, err = p.prov.PowerOff(rebootMode, requestID)
+	}); timeoutErr != nil {
+		return provisioner.Result{}, timeoutErr
+	}
+	return result, err
+}
+
+func (p *timeoutProvisioner) IsReady() (bool, error) {
+	var result bool
+	var err error
+	if timeoutErr := p.call("IsReady", func() {
+		result, err = p.prov.IsReady()
+	}); timeoutErr != nil {
+		return false, timeoutErr
+	}
+	return result, err
+}
+
+func (p *timeoutProvisioner) HasProvisioningCapacity() (bool, error) {
+	var result bool
+	var err error
+	if timeoutErr := p.call("HasProvisioningCapacity", func() {
+		result, err = p.prov.HasProvisioningCapacity()
+	}); timeoutErr != nil {
+		return false, timeoutErr
+	}
+	return result, err
+}
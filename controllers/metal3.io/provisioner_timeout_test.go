@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	goctx "context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// slowProvisioner takes delay to answer IsReady, and publishes an
+// event once it does.
+type slowProvisioner struct {
+	provisioner.Provisioner
+	delay   time.Duration
+	publish provisioner.EventPublisher
+}
+
+func (p *slowProvisioner) IsReady() (bool, error) {
+	time.Sleep(p.delay)
+	p.publish("Ready", "the provisioner is ready")
+	return true, nil
+}
+
+func slowFactory(delay time.Duration) provisioner.Factory {
+	return func(host metal3v1alpha1.BareMetalHost, bmcCreds bmc.Credentials, publish provisioner.EventPublisher) (provisioner.Provisioner, error) {
+		return &slowProvisioner{delay: delay, publish: publish}, nil
+	}
+}
+
+func TestProvisionerTimeout(t *testing.T) {
+	var events []string
+	publish := func(reason, message string) { events = append(events, reason) }
+
+	prov, err := withProvisionerTimeout(slowFactory(0), time.Second)(metal3v1alpha1.BareMetalHost{}, bmc.Credentials{}, publish)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ready, err := prov.IsReady()
+	assert.NoError(t, err)
+	assert.True(t, ready)
+	assert.Equal(t, []string{"Ready"}, events)
+
+	events = nil
+	prov, err = withProvisionerTimeout(slowFactory(100*time.Millisecond), 10*time.Millisecond)(metal3v1alpha1.BareMetalHost{}, bmc.Credentials{}, publish)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ready, err = prov.IsReady()
+	assert.False(t, ready)
+	assert.Equal(t, ProvisionerTimeoutError{Method: "IsReady", Timeout: 10 * time.Millisecond}, err)
+
+	// The provisioner is abandoned
+	_, err = prov.IsReady()
+	assert.Equal(t, ProvisionerTimeoutError{Method: "IsReady", Timeout: 10 * time.Millisecond}, err)
+	time.Sleep(200 * time.Millisecond)
+	assert.Empty(t, events)
+}
+
+// cancelableProvisioner answers IsReady once its context is done.
+type cancelableProvisioner struct {
+	provisioner.Provisioner
+	ctx      goctx.Context
+	finished bool
+}
+
+func (p *cancelableProvisioner) SetContext(ctx goctx.Context) {
+	p.ctx = ctx
+}
+
+func (p *cancelableProvisioner) IsReady() (bool, error) {
+	<-p.ctx.Done()
+	p.finished = true
+	return false, p.ctx.Err()
+}
+
+// TestProvisionerTimeoutCancel ensures that a call that can be
+// canceled is not left running once it times out.
+func TestProvisionerTimeoutCancel(t *testing.T) {
+	slow := &cancelableProvisioner{}
+	factory := func(host metal3v1alpha1.BareMetalHost, bmcCreds bmc.Credentials, publish provisioner.EventPublisher) (provisioner.Provisioner, error) {
+		return slow, nil
+	}
+	prov, err := withProvisionerTimeout(factory, 10*time.Millisecond)(metal3v1alpha1.BareMetalHost{}, bmc.Credentials{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = prov.IsReady()
+	assert.Equal(t, ProvisionerTimeoutError{Method: "IsReady", Timeout: 10 * time.Millisecond}, err)
+	assert.True(t, slow.finished)
+}
+
+func TestReconcileProvisionerTimeout(t *testing.T) {
+	host := newDefaultHost(t)
+	r := newTestReconciler(host)
+	r.ProvisionerFactory = slowFactory(time.Second)
+	r.ProvisionerTimeout = 10 * time.Millisecond
+
+	// The first reconciles only add the finalizer and the owner of the
+	// BMC secret
+	var err error
+	for i := 0; i < 5 && err == nil; i++ {
+		_, err = r.Reconcile(goctx.TODO(), newRequest(host))
+	}
+	var timeoutErr ProvisionerTimeoutError
+	assert.True(t, errors.As(err, &timeoutErr))
+
+	events := &corev1.EventList{}
+	assert.NoError(t, r.List(goctx.TODO(), events))
+	if assert.Len(t, events.Items, 1) {
+		assert.Equal(t, "ProvisionerTimeout", events.Items[0].Reason)
+	}
+
+	if err := r.Get(goctx.TODO(), newRequest(host).NamespacedName, host); err != nil {
+		t.Fatal(err)
+	}
+	if assert.NotNil(t, host.Status.LastProvisionerTimeout) {
+		assert.Equal(t, "IsReady", host.Status.LastProvisionerTimeout.Method)
+		assert.Equal(t, 10*time.Millisecond, host.Status.LastProvisionerTimeout.Timeout.Duration)
+	}
+}
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// provisioningQueueExpiry is how long a waiting host keeps its place
+// in the queue, and an admitted host its place in its class, without
+// being seen again. Delayed, inspecting and provisioning hosts are
+// checked well within it.
+const provisioningQueueExpiry = 5 * time.Minute
+
+// ProvisioningQueue orders the hosts waiting to be inspected or
+// provisioned by their priority, then by how long they have been
+// waiting, and limits how many hosts of each provisioning class are
+// inspected or provisioned at the same time. The limit of the
+// provisioner applies on top of it.
+type ProvisioningQueue struct {
+	// ClassLimits is the number of hosts of each class that may be
+	// inspected or provisioned at the same time. Classes without a
+	// limit are only bound by the provisioner.
+	ClassLimits map[string]int
+
+	lock    sync.Mutex
+	waiting map[string]*queuedHost
+	active  map[string]*queuedHost
+}
+
+type queuedHost struct {
+	priority int
+	class    string
+	since    time.Time
+	seen     time.Time
+	running  bool
+}
+
+// ahead reports whether the host goes before the other one.
+func (h *queuedHost) ahead(name string, other *queuedHost, otherName string) bool {
+	if h.priority != other.priority {
+		return h.priority > other.priority
+	}
+	if !h.since.Equal(other.since) {
+		return h.since.Before(other.since)
+	}
+	return name < otherName
+}
+
+// parseClassLimits parses a comma-separated list of class=limit
+// pairs.
+func parseClassLimits(value string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, errors.Errorf("invalid class limit %q, expected class=limit", item)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || limit <= 0 {
+			return nil, errors.Errorf("invalid limit of class %q", parts[0])
+		}
+		limits[strings.TrimSpace(parts[0])] = limit
+	}
+	return limits, nil
+}
+
+// expire forgets the hosts that have not been seen for a while, such
+// as waiting hosts that were deleted or no longer need provisioning.
+func (q *ProvisioningQueue) expire(now time.Time) {
+	for name, host := range q.waiting {
+		if now.Sub(host.seen) > provisioningQueueExpiry {
+			delete(q.waiting, name)
+		}
+	}
+	for name, host := range q.active {
+		if now.Sub(host.seen) > provisioningQueueExpiry {
+			delete(q.active, name)
+		}
+	}
+}
+
+// classFull reports whether the class has as many active hosts as its
+// limit allows.
+func (q *ProvisioningQueue) classFull(class string) bool {
+	limit, found := q.ClassLimits[class]
+	if !found {
+		return false
+	}
+	count := 0
+	for _, host := range q.active {
+		if host.class == class {
+			count++
+		}
+	}
+	return count >= limit
+}
+
+// Admit reports whether the host may start to be inspected or
+// provisioned now. A host that is already running, for example before
+// the operator was restarted, is always admitted and keeps its place in
+// its class until Done is called for it or it is no longer seen.
+// Otherwise the host waits while its class is full or a host ahead of
+// it in the queue waits for a free place.
+func (q *ProvisioningQueue) Admit(name string, priority int, class string, running bool, now time.Time) bool {
+	if q == nil {
+		return true
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.waiting == nil {
+		q.waiting = make(map[string]*queuedHost)
+		q.active = make(map[string]*queuedHost)
+	}
+	q.expire(now)
+	defer q.updateMetrics()
+
+	if host, found := q.active[name]; found {
+		host.seen = now
+		host.running = host.running || running
+		return true
+	}
+
+	host, found := q.waiting[name]
+	if !found {
+		host = &queuedHost{since: now}
+	}
+	host.priority, host.class, host.seen = priority, class, now
+	if running {
+		host.running = true
+		delete(q.waiting, name)
+		q.active[name] = host
+		return true
+	}
+
+	blocked := q.classFull(class)
+	for otherName, other := range q.waiting {
+		if blocked {
+			break
+		}
+		if otherName != name && other.ahead(otherName, host, name) && !q.classFull(other.class) {
+			blocked = true
+		}
+	}
+	if blocked {
+		q.waiting[name] = host
+		return false
+	}
+	delete(q.waiting, name)
+	q.active[name] = host
+	return true
+}
+
+// Defer puts an admitted host that could not start back in the queue,
+// in its place, for example when the provisioner has no capacity left.
+func (q *ProvisioningQueue) Defer(name string) {
+	if q == nil {
+		return
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	host, found := q.active[name]
+	if !found || host.running {
+		return
+	}
+	delete(q.active, name)
+	q.waiting[name] = host
+	q.updateMetrics()
+}
+
+// Done frees the place of the host once it has been inspected or
+// provisioned, or was deleted.
+func (q *ProvisioningQueue) Done(name string) {
+	if q == nil {
+		return
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	_, active := q.active[name]
+	_, waiting := q.waiting[name]
+	if !active && !waiting {
+		return
+	}
+	delete(q.active, name)
+	delete(q.waiting, name)
+	q.updateMetrics()
+}
+
+func (q *ProvisioningQueue) updateMetrics() {
+	provisioningQueueWaiting.Reset()
+	for _, host := range q.waiting {
+		provisioningQueueWaiting.WithLabelValues(host.class).Inc()
+	}
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestProvisioningQueuePriority(t *testing.T) {
+	now := time.Now()
+	q := &ProvisioningQueue{}
+
+	// Hosts the provisioner had no room for wait in their place
+	assert.True(t, q.Admit("ns/worker-0", 0, "", false, now))
+	q.Defer("ns/worker-0")
+	assert.True(t, q.Admit("ns/master-0", 10, "", false, now.Add(time.Second)))
+	q.Defer("ns/master-0")
+
+	// A host with a higher priority goes first, then the one waiting
+	// the longest
+	assert.False(t, q.Admit("ns/worker-1", 0, "", false, now.Add(2*time.Second)))
+	assert.False(t, q.Admit("ns/worker-0", 0, "", false, now.Add(3*time.Second)))
+	assert.True(t, q.Admit("ns/master-0", 10, "", false, now.Add(4*time.Second)))
+	assert.False(t, q.Admit("ns/worker-1", 0, "", false, now.Add(5*time.Second)))
+	assert.True(t, q.Admit("ns/worker-0", 0, "", false, now.Add(6*time.Second)))
+	assert.True(t, q.Admit("ns/worker-1", 0, "", false, now.Add(7*time.Second)))
+	assert.Empty(t, q.waiting)
+}
+
+func TestProvisioningQueueClassLimits(t *testing.T) {
+	now := time.Now()
+	q := &ProvisioningQueue{ClassLimits: map[string]int{"worker": 1}}
+
+	assert.True(t, q.Admit("ns/worker-0", 0, "worker", false, now))
+	assert.False(t, q.Admit("ns/worker-1", 10, "worker", false, now))
+
+	// A host waiting for its class to free up does not hold back the
+	// hosts of other classes
+	assert.True(t, q.Admit("ns/master-0", 0, "control-plane", false, now.Add(time.Second)))
+	assert.Len(t, q.waiting, 1)
+
+	q.Done("ns/worker-0")
+	assert.True(t, q.Admit("ns/worker-1", 10, "worker", false, now.Add(2*time.Second)))
+	assert.Empty(t, q.waiting)
+}
+
+func TestProvisioningQueueRunning(t *testing.T) {
+	now := time.Now()
+	q := &ProvisioningQueue{ClassLimits: map[string]int{"worker": 1}}
+
+	// Hosts already being provisioned, for example before a restart,
+	// keep their place even beyond the limit of their class
+	assert.True(t, q.Admit("ns/worker-0", 0, "worker", true, now))
+	assert.True(t, q.Admit("ns/worker-1", 0, "worker", true, now))
+	assert.False(t, q.Admit("ns/worker-2", 0, "worker", false, now))
+
+	q.Defer("ns/worker-0")
+	assert.Contains(t, q.active, "ns/worker-0")
+
+	q.Done("ns/worker-0")
+	q.Done("ns/worker-1")
+	assert.True(t, q.Admit("ns/worker-2", 0, "worker", false, now))
+
+	// An admitted host the provisioner has no room for goes back to
+	// its place
+	q.Defer("ns/worker-2")
+	assert.Contains(t, q.waiting, "ns/worker-2")
+	assert.NotContains(t, q.active, "ns/worker-2")
+}
+
+func TestProvisioningQueueExpiry(t *testing.T) {
+	now := time.Now()
+	q := &ProvisioningQueue{ClassLimits: map[string]int{"worker": 1}}
+
+	assert.True(t, q.Admit("ns/worker-0", 0, "worker", false, now))
+	assert.False(t, q.Admit("ns/master-0", 10, "worker", false, now))
+
+	// Hosts that are no longer seen, for example because they were
+	// deleted, give up their place
+	later := now.Add(provisioningQueueExpiry + time.Second)
+	assert.True(t, q.Admit("ns/bulk", 0, "", false, later))
+	assert.Empty(t, q.waiting)
+	assert.True(t, q.Admit("ns/worker-1", 0, "worker", false, later))
+}
+
+func TestProvisioningQueueDisabled(t *testing.T) {
+	var q *ProvisioningQueue
+	assert.True(t, q.Admit("ns/host-0", 0, "", false, time.Now()))
+	q.Defer("ns/host-0")
+	q.Done("ns/host-0")
+}
+
+func TestParseClassLimits(t *testing.T) {
+	limits, err := parseClassLimits("control-plane=3, worker=20,")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"control-plane": 3, "worker": 20}, limits)
+
+	for _, value := range []string{"worker", "=3", "worker=0", "worker=many"} {
+		_, err := parseClassLimits(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestProvisioningCapacityQueued(t *testing.T) {
+	r := &BareMetalHostReconciler{
+		ProvisioningQueue: &ProvisioningQueue{ClassLimits: map[string]int{"worker": 1}},
+	}
+	r.ProvisioningQueue.Admit("ns/other", 0, "worker", true, time.Now())
+
+	host := host(metal3v1alpha1.StateReady).build()
+	host.Spec.ProvisioningClass = "worker"
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, r, prov, true)
+	info := makeDefaultReconcileInfo(host)
+	info.request.NamespacedName = types.NamespacedName{Namespace: "ns", Name: "host"}
+
+	assert.IsType(t, actionDelayed{}, hsm.ensureProvisioningCapacity(info))
+	assert.Equal(t, metal3v1alpha1.OperationalStatus(metal3v1alpha1.OperationalStatusDelayed), host.Status.OperationalStatus)
+
+	// Once the other host is provisioned, the provisioner decides
+	r.ProvisioningQueue.Done("ns/other")
+	prov.hasProvisioningCapacity = false
+	assert.IsType(t, actionDelayed{}, hsm.ensureProvisioningCapacity(info))
+	assert.Contains(t, r.ProvisioningQueue.waiting, "ns/host")
+
+	prov.hasProvisioningCapacity = true
+	assert.Nil(t, hsm.ensureProvisioningCapacity(info))
+	assert.Contains(t, r.ProvisioningQueue.active, "ns/host")
+}
@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// reinspectionInterval returns the time to wait between inspections
+// of a ready host, from its spec or the operator default.
+func reinspectionInterval(host *metal3v1alpha1.BareMetalHost, defaultInterval time.Duration) time.Duration {
+	if host.Spec.Reinspection != nil {
+		return host.Spec.Reinspection.Interval.Duration
+	}
+	return defaultInterval
+}
+
+// reinspectionDue reports whether a ready host was last inspected
+// longer ago than its reinspection interval.
+func reinspectionDue(host *metal3v1alpha1.BareMetalHost, defaultInterval time.Duration, now time.Time) bool {
+	interval := reinspectionInterval(host, defaultInterval)
+	if interval <= 0 || inspectionDisabled(host) || host.Status.ErrorMessage != "" {
+		return false
+	}
+	end := host.Status.OperationHistory.Inspect.End
+	if end.IsZero() {
+		return false
+	}
+	return now.Sub(end.Time) >= interval
+}
+
+func storageKey(disk metal3v1alpha1.Storage) string {
+	if disk.SerialNumber != "" {
+		return disk.SerialNumber
+	}
+	return disk.Name
+}
+
+// removedKeys returns the keys of before that are missing from after.
+func removedKeys(before, after map[string]string) []string {
+	var removed []string
+	for key, name := range before {
+		if _, found := after[key]; !found {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// hardwareChanges describes the differences between two inventories
+// of the same host that matter for scheduling: the RAM, the CPUs, the
+// disks, NICs and accelerators that were added or removed, and the
+// disks that started failing.
+func hardwareChanges(before, after *metal3v1alpha1.HardwareDetails) []string {
+	if before == nil || after == nil {
+		return nil
+	}
+
+	var changes []string
+	if before.RAMMebibytes != after.RAMMebibytes {
+		changes = append(changes, fmt.Sprintf("RAM changed from %d to %d MiB",
+			before.RAMMebibytes, after.RAMMebibytes))
+	}
+	if before.CPU.Count != after.CPU.Count {
+		changes = append(changes, fmt.Sprintf("CPU count changed from %d to %d",
+			before.CPU.Count, after.CPU.Count))
+	}
+	if before.CPU.Model != after.CPU.Model {
+		changes = append(changes, fmt.Sprintf("CPU model changed from %q to %q",
+			before.CPU.Model, after.CPU.Model))
+	}
+
+	disksBefore := map[string]string{}
+	for _, disk := range before.Storage {
+		disksBefore[storageKey(disk)] = disk.Name
+	}
+	disksAfter := map[string]string{}
+	for _, disk := range after.Storage {
+		disksAfter[storageKey(disk)] = disk.Name
+	}
+	for _, name := range removedKeys(disksBefore, disksAfter) {
+		changes = append(changes, fmt.Sprintf("disk %s removed", name))
+	}
+	for _, name := range removedKeys(disksAfter, disksBefore) {
+		changes = append(changes, fmt.Sprintf("disk %s added", name))
+	}
+	failingBefore := map[string]bool{}
+	for _, disk := range before.Storage {
+		failingBefore[storageKey(disk)] = diskFailing(disk)
+	}
+	for _, disk := range after.Storage {
+		if diskFailing(disk) && !failingBefore[storageKey(disk)] {
+			changes = append(changes, fmt.Sprintf("disk %s is failing: %s", disk.Name, disk.Health.Message))
+		}
+	}
+
+	nicsBefore := map[string]string{}
+	for _, nic := range before.NIC {
+		nicsBefore[nic.MAC] = nic.Name
+	}
+	nicsAfter := map[string]string{}
+	for _, nic := range after.NIC {
+		nicsAfter[nic.MAC] = nic.Name
+	}
+	for _, name := range removedKeys(nicsBefore, nicsAfter) {
+		changes = append(changes, fmt.Sprintf("NIC %s removed", name))
+	}
+	for _, name := range removedKeys(nicsAfter, nicsBefore) {
+		changes = append(changes, fmt.Sprintf("NIC %s added", name))
+	}
+
+	acceleratorsBefore := acceleratorNames(before.Accelerators)
+	acceleratorsAfter := acceleratorNames(after.Accelerators)
+	for _, name := range removedKeys(acceleratorsBefore, acceleratorsAfter) {
+		changes = append(changes, fmt.Sprintf("accelerator %s removed", name))
+	}
+	for _, name := range removedKeys(acceleratorsAfter, acceleratorsBefore) {
+		changes = append(changes, fmt.Sprintf("accelerator %s added", name))
+	}
+
+	return changes
+}
+
+func diskFailing(disk metal3v1alpha1.Storage) bool {
+	return disk.Health != nil && disk.Health.Status == metal3v1alpha1.DiskHealthFailing
+}
+
+// acceleratorNames describes the accelerators by their PCI address,
+// or by their position when the address is unknown.
+func acceleratorNames(accelerators []metal3v1alpha1.Accelerator) map[string]string {
+	names := map[string]string{}
+	for i, accelerator := range accelerators {
+		key := accelerator.PCIAddress
+		if key == "" {
+			key = fmt.Sprintf("#%d", i)
+		}
+		model := strings.TrimSpace(accelerator.Vendor + " " + accelerator.Model)
+		if model == "" {
+			model = string(accelerator.Type)
+		}
+		names[key+" "+model] = fmt.Sprintf("%s at %s", model, key)
+	}
+	return names
+}
@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestReinspectionDue(t *testing.T) {
+	now := time.Now()
+	inspected := metav1.NewTime(now.Add(-2 * time.Hour))
+
+	testCases := []struct {
+		Scenario        string
+		Reinspection    *metal3v1alpha1.ReinspectionPolicy
+		DefaultInterval time.Duration
+		InspectEnd      metav1.Time
+		ErrorMessage    string
+		Expected        bool
+	}{
+		{
+			Scenario:   "disabled",
+			InspectEnd: inspected,
+		},
+		{
+			Scenario:        "operator default",
+			DefaultInterval: time.Hour,
+			InspectEnd:      inspected,
+			Expected:        true,
+		},
+		{
+			Scenario:        "not due yet",
+			DefaultInterval: 3 * time.Hour,
+			InspectEnd:      inspected,
+		},
+		{
+			Scenario: "host interval",
+			Reinspection: &metal3v1alpha1.ReinspectionPolicy{
+				Interval: metav1.Duration{Duration: time.Hour},
+			},
+			InspectEnd: inspected,
+			Expected:   true,
+		},
+		{
+			Scenario: "host disables default",
+			Reinspection: &metal3v1alpha1.ReinspectionPolicy{
+				Interval: metav1.Duration{},
+			},
+			DefaultInterval: time.Hour,
+			InspectEnd:      inspected,
+		},
+		{
+			Scenario:        "never inspected",
+			DefaultInterval: time.Hour,
+		},
+		{
+			Scenario:        "error",
+			DefaultInterval: time.Hour,
+			InspectEnd:      inspected,
+			ErrorMessage:    "power on failed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := host(metal3v1alpha1.StateReady).build()
+			host.Spec.Reinspection = tc.Reinspection
+			host.Status.OperationHistory.Inspect.End = tc.InspectEnd
+			host.Status.ErrorMessage = tc.ErrorMessage
+
+			assert.Equal(t, tc.Expected, reinspectionDue(host, tc.DefaultInterval, now))
+		})
+	}
+}
+
+func TestHardwareChanges(t *testing.T) {
+	before := &metal3v1alpha1.HardwareDetails{
+		RAMMebibytes: 4096,
+		CPU:          metal3v1alpha1.CPU{Count: 4, Model: "Xeon"},
+		Storage: []metal3v1alpha1.Storage{
+			{Name: "/dev/sda", SerialNumber: "abc"},
+			{Name: "/dev/sdb", SerialNumber: "def"},
+		},
+		NIC: []metal3v1alpha1.NIC{
+			{Name: "eth0", MAC: "00:11:22:33:44:55"},
+		},
+	}
+
+	assert.Empty(t, hardwareChanges(before, before.DeepCopy()))
+	assert.Empty(t, hardwareChanges(nil, before))
+
+	after := before.DeepCopy()
+	after.RAMMebibytes = 8192
+	after.CPU.Count = 8
+	// The disk was renamed but is the same device
+	after.Storage[0].Name = "/dev/sdc"
+	after.Storage[1] = metal3v1alpha1.Storage{Name: "/dev/sdb", SerialNumber: "ghi"}
+	after.NIC = append(after.NIC, metal3v1alpha1.NIC{Name: "eth1", MAC: "00:11:22:33:44:66"})
+	after.Storage[0].Health = &metal3v1alpha1.DiskHealth{Status: metal3v1alpha1.DiskHealthFailing, Message: "SMART health check FAILED"}
+	after.Accelerators = []metal3v1alpha1.Accelerator{
+		{Type: metal3v1alpha1.GPUAccelerator, Vendor: "NVIDIA", Model: "A100", PCIAddress: "0000:3b:00.0"},
+	}
+
+	assert.Equal(t, []string{
+		"RAM changed from 4096 to 8192 MiB",
+		"CPU count changed from 4 to 8",
+		"disk /dev/sdb removed",
+		"disk /dev/sdb added",
+		"disk /dev/sdc is failing: SMART health check FAILED",
+		"NIC eth1 added",
+		"accelerator NVIDIA A100 at 0000:3b:00.0 added",
+	}, hardwareChanges(before, after))
+}
+
+func TestReinspectionFromReady(t *testing.T) {
+	host := host(metal3v1alpha1.StateReady).build()
+	host.Spec.Image = nil
+	host.Status.OperationHistory.Inspect.End = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{RAMMebibytes: 4096}
+
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{ReinspectionInterval: time.Hour}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	hsm.ReconcileState(info)
+	assert.Equal(t, metal3v1alpha1.StateInspecting, host.Status.Provisioning.State)
+	assert.True(t, host.Status.ReinspectionPending)
+
+	hsm.ReconcileState(info)
+	assert.Equal(t, metal3v1alpha1.StateMatchProfile, host.Status.Provisioning.State)
+	assert.False(t, host.Status.ReinspectionPending)
+
+	var reasons []string
+	for _, event := range info.events {
+		reasons = append(reasons, event.Reason)
+	}
+	assert.Contains(t, reasons, "ReinspectionScheduled")
+	assert.Contains(t, reasons, "HardwareChanged")
+}
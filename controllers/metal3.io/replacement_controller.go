@@ -0,0 +1,311 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+const (
+	defaultReplacementGracePeriod = 10 * time.Minute
+	noSpareRetryDelay             = time.Minute
+)
+
+// ReplacementReconciler replaces failed provisioned hosts with spares.
+// A host labelled with a replacement pool that is marked unhealthy, or
+// that has had one of the configured error types for longer than the
+// grace period, is replaced by a ready host labelled as a spare of the
+// same pool: the spare is provisioned with the image, user data,
+// network data and consumer of the failed host, which is then
+// decommissioned.
+type ReplacementReconciler struct {
+	client.Client
+	Log logr.Logger
+
+	// GracePeriod is how long the Failed condition of a host must
+	// stay true before it is replaced. Zero uses a default of ten
+	// minutes.
+	GracePeriod time.Duration
+	// ErrorTypes are the errors that get a host replaced once they
+	// last longer than the grace period. Most errors are retried and
+	// clear on their own, such as a BMC that cannot be reached for a
+	// while, so by default only hosts marked unhealthy are replaced.
+	ErrorTypes map[metal3v1alpha1.ErrorType]bool
+	// Pause stops hosts from being replaced while it is set. A nil
+	// value never pauses.
+	Pause *OperatorPause
+
+	recorder *eventRecorder
+}
+
+// Reconcile replaces one host if it has failed.
+func (r *ReplacementReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("baremetalhost", request.NamespacedName)
+
+	host := &metal3v1alpha1.BareMetalHost{}
+	if err := r.Get(ctx, request.NamespacedName, host); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, errors.Wrap(err, "could not load host data")
+	}
+
+	pool := host.Labels[metal3v1alpha1.ReplacementPoolLabel]
+	if pool == "" || !host.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	if _, replaced := host.Annotations[metal3v1alpha1.ReplacedByAnnotation]; replaced {
+		return ctrl.Result{}, nil
+	}
+
+	failed, wait := r.hostFailed(host, time.Now())
+	if !failed {
+		// A spare claimed by an earlier attempt that failed to retire
+		// the host would share its consumer
+		if err := r.releaseClaimedSpare(ctx, host); err != nil {
+			return ctrl.Result{}, err
+		}
+		if wait > 0 {
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if r.Pause.paused() {
+		reqLogger.Info("operator is paused, not replacing the failed host")
+		return ctrl.Result{RequeueAfter: pausedRecheckDelay}, nil
+	}
+
+	spare, err := r.findSpare(ctx, host, pool)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if spare == nil {
+		reqLogger.Info("no spare available to replace failed host", "pool", pool)
+		r.publishEvent(ctx, host, "NoSpareAvailable",
+			fmt.Sprintf("No spare is available in pool %s to replace the host", pool))
+		return ctrl.Result{RequeueAfter: noSpareRetryDelay}, nil
+	}
+
+	// The spare may have been claimed by an earlier attempt that
+	// failed to retire the host
+	if spare.Annotations[metal3v1alpha1.ReplacesAnnotation] != host.Name {
+		claimSpare(spare, host, pool)
+		if err := r.Update(ctx, spare); err != nil {
+			// A conflict means another host claimed the spare first
+			return ctrl.Result{}, errors.Wrapf(err, "failed to claim spare %s", spare.Name)
+		}
+		reqLogger.Info("claimed spare", "spare", spare.Name)
+		r.publishEvent(ctx, spare, "SpareClaimed",
+			fmt.Sprintf("Provisioning the host to replace %s", host.Name))
+	}
+
+	if err := r.retireHost(ctx, host, spare.Name); err != nil {
+		if releaseErr := r.releaseSpare(ctx, spare); releaseErr != nil {
+			reqLogger.Info("failed to release spare, will retry", "spare", spare.Name, "error", releaseErr.Error())
+		}
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// hostFailed reports whether the host needs to be replaced, or else
+// how long to wait before checking it again.
+func (r *ReplacementReconciler) hostFailed(host *metal3v1alpha1.BareMetalHost, now time.Time) (bool, time.Duration) {
+	if host.Status.Provisioning.State != metal3v1alpha1.StateProvisioned || host.Spec.Decommission {
+		return false, 0
+	}
+	if _, unhealthy := host.Annotations[metal3v1alpha1.UnhealthyAnnotation]; unhealthy {
+		return true, 0
+	}
+
+	if !r.ErrorTypes[host.Status.ErrorType] {
+		return false, 0
+	}
+	condition := meta.FindStatusCondition(host.Status.Conditions, metal3v1alpha1.FailedCondition)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		return false, 0
+	}
+	gracePeriod := r.GracePeriod
+	if gracePeriod == 0 {
+		gracePeriod = defaultReplacementGracePeriod
+	}
+	if wait := condition.LastTransitionTime.Add(gracePeriod).Sub(now); wait > 0 {
+		return false, wait
+	}
+	return true, 0
+}
+
+// findSpare returns the spare already claimed for the host, or else a
+// free spare of the pool, or nil if there is none.
+func (r *ReplacementReconciler) findSpare(ctx context.Context, host *metal3v1alpha1.BareMetalHost, pool string) (*metal3v1alpha1.BareMetalHost, error) {
+	hosts := &metal3v1alpha1.BareMetalHostList{}
+	if err := r.List(ctx, hosts, client.InNamespace(host.Namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list spares")
+	}
+	sort.Slice(hosts.Items, func(i, j int) bool {
+		return hosts.Items[i].Name < hosts.Items[j].Name
+	})
+
+	var free *metal3v1alpha1.BareMetalHost
+	for i := range hosts.Items {
+		candidate := &hosts.Items[i]
+		if candidate.Name == host.Name {
+			continue
+		}
+		if candidate.Annotations[metal3v1alpha1.ReplacesAnnotation] == host.Name {
+			return candidate, nil
+		}
+		if free == nil && candidate.Labels[metal3v1alpha1.SparePoolLabel] == pool && spareAvailable(candidate) {
+			free = candidate
+		}
+	}
+	return free, nil
+}
+
+// spareAvailable reports whether a host of a spare pool can be used as
+// a replacement.
+func spareAvailable(host *metal3v1alpha1.BareMetalHost) bool {
+	switch host.Status.Provisioning.State {
+	case metal3v1alpha1.StateReady, metal3v1alpha1.StateAvailable:
+	default:
+		return false
+	}
+	return host.DeletionTimestamp.IsZero() &&
+		host.Spec.ConsumerRef == nil &&
+		host.Spec.Image == nil &&
+		!host.Spec.Decommission &&
+		host.Status.ErrorType == ""
+}
+
+// claimSpare provisions the spare with the settings of the failed
+// host. The spare leaves the spare pool and is protected by the
+// replacement pool in turn.
+func claimSpare(spare, failed *metal3v1alpha1.BareMetalHost, pool string) {
+	spare.Spec.Image = failed.Spec.Image.DeepCopy()
+	spare.Spec.UserData = failed.Spec.UserData.DeepCopy()
+	spare.Spec.UserDataSecrets = append([]corev1.SecretReference(nil), failed.Spec.UserDataSecrets...)
+	spare.Spec.NetworkData = failed.Spec.NetworkData.DeepCopy()
+	spare.Spec.ConsumerRef = failed.Spec.ConsumerRef.DeepCopy()
+	spare.Spec.Online = true
+
+	delete(spare.Labels, metal3v1alpha1.SparePoolLabel)
+	spare.Labels[metal3v1alpha1.ReplacementPoolLabel] = pool
+	if spare.Annotations == nil {
+		spare.Annotations = map[string]string{}
+	}
+	spare.Annotations[metal3v1alpha1.ReplacesAnnotation] = failed.Name
+}
+
+// releaseSpare returns a spare claimed for a host that was not retired
+// to its spare pool.
+func (r *ReplacementReconciler) releaseSpare(ctx context.Context, spare *metal3v1alpha1.BareMetalHost) error {
+	pool := spare.Labels[metal3v1alpha1.ReplacementPoolLabel]
+	spare.Spec.Image = nil
+	spare.Spec.UserData = nil
+	spare.Spec.UserDataSecrets = nil
+	spare.Spec.NetworkData = nil
+	spare.Spec.ConsumerRef = nil
+	delete(spare.Labels, metal3v1alpha1.ReplacementPoolLabel)
+	spare.Labels[metal3v1alpha1.SparePoolLabel] = pool
+	delete(spare.Annotations, metal3v1alpha1.ReplacesAnnotation)
+	if err := r.Update(ctx, spare); err != nil {
+		return errors.Wrapf(err, "failed to release spare %s", spare.Name)
+	}
+	r.Log.Info("released spare", "baremetalhost", spare.Namespace+"/"+spare.Name)
+	return nil
+}
+
+// releaseClaimedSpare releases the spare claimed for a host that is
+// not replaced, if there is one.
+func (r *ReplacementReconciler) releaseClaimedSpare(ctx context.Context, host *metal3v1alpha1.BareMetalHost) error {
+	hosts := &metal3v1alpha1.BareMetalHostList{}
+	if err := r.List(ctx, hosts, client.InNamespace(host.Namespace)); err != nil {
+		return errors.Wrap(err, "failed to list spares")
+	}
+	for i := range hosts.Items {
+		spare := &hosts.Items[i]
+		if spare.Name != host.Name && spare.Annotations[metal3v1alpha1.ReplacesAnnotation] == host.Name {
+			return r.releaseSpare(ctx, spare)
+		}
+	}
+	return nil
+}
+
+// retireHost marks the failed host as replaced by the spare and
+// decommissions it.
+func (r *ReplacementReconciler) retireHost(ctx context.Context, host *metal3v1alpha1.BareMetalHost, spareName string) error {
+	if host.Annotations == nil {
+		host.Annotations = map[string]string{}
+	}
+	host.Annotations[metal3v1alpha1.ReplacedByAnnotation] = spareName
+	host.Spec.Decommission = true
+	// The consumer now uses the spare
+	host.Spec.ConsumerRef = nil
+	if err := r.Update(ctx, host); err != nil {
+		return errors.Wrap(err, "failed to decommission replaced host")
+	}
+
+	r.Log.Info("replaced failed host", "baremetalhost", host.Namespace+"/"+host.Name, "spare", spareName)
+	r.publishEvent(ctx, host, "HostReplaced",
+		fmt.Sprintf("Replaced by %s, decommissioning the host", spareName))
+	return nil
+}
+
+// parseReplacementErrorTypes parses a comma-separated list of error
+// types, such as "provisioning error,timeout error".
+func parseReplacementErrorTypes(value string) map[metal3v1alpha1.ErrorType]bool {
+	errorTypes := make(map[metal3v1alpha1.ErrorType]bool)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			errorTypes[metal3v1alpha1.ErrorType(item)] = true
+		}
+	}
+	return errorTypes
+}
+
+func (r *ReplacementReconciler) publishEvent(ctx context.Context, host *metal3v1alpha1.BareMetalHost, reason, message string) {
+	event := host.NewEvent(reason, message)
+	if err := r.recorder.record(ctx, r.Client, event); err != nil {
+		r.Log.Info("failed to record event, ignoring",
+			"reason", reason, "message", message, "error", err)
+	}
+}
+
+// SetupWithManager registers the reconciler to be run by the manager
+func (r *ReplacementReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.recorder == nil {
+		r.recorder = newEventRecorder(clock.RealClock{})
+	}
+
+	if graceEnv, ok := os.LookupEnv("REPLACEMENT_GRACE_PERIOD"); ok && r.GracePeriod == 0 {
+		gracePeriod, err := time.ParseDuration(graceEnv)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("REPLACEMENT_GRACE_PERIOD value: %s is invalid", graceEnv))
+		}
+		r.GracePeriod = gracePeriod
+	}
+	if r.ErrorTypes == nil {
+		r.ErrorTypes = parseReplacementErrorTypes(os.Getenv("REPLACEMENT_ERROR_TYPES"))
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("replacement").
+		For(&metal3v1alpha1.BareMetalHost{}).
+		Complete(r)
+}
@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	goctx "context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func newReplacementTestReconciler(objs ...runtime.Object) *ReplacementReconciler {
+	return &ReplacementReconciler{
+		Client: fakeclient.NewFakeClient(objs...),
+		Log:    ctrl.Log.WithName("controllers").WithName("Replacement"),
+	}
+}
+
+func newFailedHost(t *testing.T) *metal3v1alpha1.BareMetalHost {
+	host := newDefaultHost(t)
+	host.Labels = map[string]string{metal3v1alpha1.ReplacementPoolLabel: "workers"}
+	host.Annotations = map[string]string{metal3v1alpha1.UnhealthyAnnotation: "node not ready"}
+	host.Spec.Image = &metal3v1alpha1.Image{URL: "http://example.test/image.qcow2"}
+	host.Spec.UserData = &corev1.SecretReference{Name: "worker-user-data", Namespace: namespace}
+	host.Spec.ConsumerRef = &corev1.ObjectReference{Kind: "Machine", Name: "worker-0"}
+	host.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	return host
+}
+
+func newSpareHost(name, pool string) *metal3v1alpha1.BareMetalHost {
+	return &metal3v1alpha1.BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{metal3v1alpha1.SparePoolLabel: pool},
+		},
+		Status: metal3v1alpha1.BareMetalHostStatus{
+			Provisioning: metal3v1alpha1.ProvisionStatus{State: metal3v1alpha1.StateReady},
+		},
+	}
+}
+
+func getHost(t *testing.T, r *ReplacementReconciler, host *metal3v1alpha1.BareMetalHost) *metal3v1alpha1.BareMetalHost {
+	updated := &metal3v1alpha1.BareMetalHost{}
+	if !assert.NoError(t, r.Get(goctx.TODO(), newRequest(host).NamespacedName, updated)) {
+		return nil
+	}
+	return updated
+}
+
+func TestReplaceFailedHost(t *testing.T) {
+	host := newFailedHost(t)
+	busy := newSpareHost("spare-0", "workers")
+	busy.Status.Provisioning.State = metal3v1alpha1.StateInspecting
+	other := newSpareHost("spare-1", "storage")
+	spare := newSpareHost("spare-2", "workers")
+	r := newReplacementTestReconciler(host, busy, other, spare)
+
+	_, err := r.Reconcile(goctx.TODO(), newRequest(host))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	if updated := getHost(t, r, spare); updated != nil {
+		assert.Equal(t, host.Spec.Image, updated.Spec.Image)
+		assert.Equal(t, host.Spec.UserData, updated.Spec.UserData)
+		assert.Equal(t, host.Spec.ConsumerRef, updated.Spec.ConsumerRef)
+		assert.True(t, updated.Spec.Online)
+		assert.Equal(t, host.Name, updated.Annotations[metal3v1alpha1.ReplacesAnnotation])
+		assert.Equal(t, "workers", updated.Labels[metal3v1alpha1.ReplacementPoolLabel])
+		assert.NotContains(t, updated.Labels, metal3v1alpha1.SparePoolLabel)
+	}
+
+	if updated := getHost(t, r, host); updated != nil {
+		assert.True(t, updated.Spec.Decommission)
+		assert.Nil(t, updated.Spec.ConsumerRef)
+		assert.Equal(t, "spare-2", updated.Annotations[metal3v1alpha1.ReplacedByAnnotation])
+	}
+}
+
+// TestReplaceFailedHostClaimedSpare ensures that a spare claimed by an
+// earlier attempt is used again.
+func TestReplaceFailedHostClaimedSpare(t *testing.T) {
+	host := newFailedHost(t)
+	free := newSpareHost("spare-0", "workers")
+	claimed := newSpareHost("spare-1", "workers")
+	claimSpare(claimed, host, "workers")
+	r := newReplacementTestReconciler(host, free, claimed)
+
+	_, err := r.Reconcile(goctx.TODO(), newRequest(host))
+	assert.NoError(t, err)
+
+	if updated := getHost(t, r, host); updated != nil {
+		assert.Equal(t, "spare-1", updated.Annotations[metal3v1alpha1.ReplacedByAnnotation])
+	}
+	if updated := getHost(t, r, free); updated != nil {
+		assert.Nil(t, updated.Spec.Image)
+	}
+}
+
+// TestReplacementReleasesClaimedSpare ensures that a spare claimed for
+// a host that was not retired is returned to its pool once the host is
+// no longer failed.
+func TestReplacementReleasesClaimedSpare(t *testing.T) {
+	host := newFailedHost(t)
+	spare := newSpareHost("spare-0", "workers")
+	claimSpare(spare, host, "workers")
+	delete(host.Annotations, metal3v1alpha1.UnhealthyAnnotation)
+	r := newReplacementTestReconciler(host, spare)
+
+	_, err := r.Reconcile(goctx.TODO(), newRequest(host))
+	assert.NoError(t, err)
+
+	if updated := getHost(t, r, spare); updated != nil {
+		assert.Nil(t, updated.Spec.Image)
+		assert.Nil(t, updated.Spec.ConsumerRef)
+		assert.Equal(t, "workers", updated.Labels[metal3v1alpha1.SparePoolLabel])
+		assert.NotContains(t, updated.Annotations, metal3v1alpha1.ReplacesAnnotation)
+	}
+	if updated := getHost(t, r, host); updated != nil {
+		assert.False(t, updated.Spec.Decommission)
+		assert.NotNil(t, updated.Spec.ConsumerRef)
+	}
+}
+
+func TestReplaceFailedHostNoSpare(t *testing.T) {
+	host := newFailedHost(t)
+	r := newReplacementTestReconciler(host, newSpareHost("spare-0", "storage"))
+
+	result, err := r.Reconcile(goctx.TODO(), newRequest(host))
+	assert.NoError(t, err)
+	assert.Equal(t, noSpareRetryDelay, result.RequeueAfter)
+
+	if updated := getHost(t, r, host); updated != nil {
+		assert.False(t, updated.Spec.Decommission)
+	}
+}
+
+// TestReplaceFailedHostPaused ensures that no host is replaced while
+// the operator is paused.
+func TestReplaceFailedHostPaused(t *testing.T) {
+	file, err := ioutil.TempFile("", "operator-pause")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	host := newFailedHost(t)
+	spare := newSpareHost("spare-0", "workers")
+	r := newReplacementTestReconciler(host, spare)
+	r.Pause = &OperatorPause{File: file.Name()}
+
+	result, err := r.Reconcile(goctx.TODO(), newRequest(host))
+	assert.NoError(t, err)
+	assert.Equal(t, pausedRecheckDelay, result.RequeueAfter)
+
+	if updated := getHost(t, r, host); updated != nil {
+		assert.False(t, updated.Spec.Decommission)
+	}
+	if updated := getHost(t, r, spare); updated != nil {
+		assert.Nil(t, updated.Spec.Image)
+	}
+}
+
+func TestReplacementHostFailed(t *testing.T) {
+	now := time.Now()
+	r := &ReplacementReconciler{
+		GracePeriod: time.Hour,
+		ErrorTypes:  parseReplacementErrorTypes("provisioning error, timeout error"),
+	}
+
+	testCases := []struct {
+		Scenario   string
+		State      metal3v1alpha1.ProvisioningState
+		Unhealthy  bool
+		ErrorType  metal3v1alpha1.ErrorType
+		FailedFor  time.Duration
+		Expected   bool
+		ExpectWait time.Duration
+	}{
+		{
+			Scenario: "healthy",
+			State:    metal3v1alpha1.StateProvisioned,
+		},
+		{
+			Scenario:  "unhealthy",
+			State:     metal3v1alpha1.StateProvisioned,
+			Unhealthy: true,
+			Expected:  true,
+		},
+		{
+			Scenario:   "failed within grace period",
+			State:      metal3v1alpha1.StateProvisioned,
+			ErrorType:  metal3v1alpha1.ProvisioningError,
+			FailedFor:  20 * time.Minute,
+			ExpectWait: 40 * time.Minute,
+		},
+		{
+			Scenario:  "failed after grace period",
+			State:     metal3v1alpha1.StateProvisioned,
+			ErrorType: metal3v1alpha1.ProvisioningError,
+			FailedFor: 2 * time.Hour,
+			Expected:  true,
+		},
+		{
+			Scenario:  "retried error after grace period",
+			State:     metal3v1alpha1.StateProvisioned,
+			ErrorType: metal3v1alpha1.PowerManagementError,
+			FailedFor: 2 * time.Hour,
+		},
+		{
+			Scenario:  "not provisioned",
+			State:     metal3v1alpha1.StateReady,
+			Unhealthy: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := newFailedHost(t)
+			host.Status.Provisioning.State = tc.State
+			if !tc.Unhealthy {
+				delete(host.Annotations, metal3v1alpha1.UnhealthyAnnotation)
+			}
+			host.Status.ErrorType = tc.ErrorType
+			if tc.FailedFor > 0 {
+				host.Status.Conditions = []metav1.Condition{{
+					Type:               metal3v1alpha1.FailedCondition,
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(now.Add(-tc.FailedFor)),
+				}}
+			}
+
+			failed, wait := r.hostFailed(host, now)
+			assert.Equal(t, tc.Expected, failed)
+			assert.Equal(t, tc.ExpectWait, wait)
+		})
+	}
+}
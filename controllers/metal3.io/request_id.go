@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// The request IDs passed to the provisioner only use data that is
+// saved in the host, so that they come out the same when an operation
+// is retried after the controller restarts.
+
+func requestID(host *metal3v1alpha1.BareMetalHost, action string, parts ...string) string {
+	return strings.Join(append([]string{string(host.UID), action}, parts...), "/")
+}
+
+// operationRequestID identifies an attempt at a long-running
+// operation by the time it was started.
+func operationRequestID(host *metal3v1alpha1.BareMetalHost, operation metal3v1alpha1.ProvisioningState, parts ...string) string {
+	var start string
+	if metric := host.OperationMetricForState(operation); metric != nil && !metric.Start.IsZero() {
+		start = metric.Start.UTC().Format(time.RFC3339)
+	}
+	return requestID(host, string(operation), append([]string{start}, parts...)...)
+}
+
+// powerRequestID identifies a power change by the spec generation, the
+// reboot annotations that asked for it and the last known power
+// state.
+func powerRequestID(host *metal3v1alpha1.BareMetalHost, powerOn bool) string {
+	action := "power-off"
+	if powerOn {
+		action = "power-on"
+	}
+
+	var reboots []string
+	for name := range host.Annotations {
+		if isRebootAnnotation(name) {
+			reboots = append(reboots, name)
+		}
+	}
+	sort.Strings(reboots)
+
+	return requestID(host, action,
+		fmt.Sprintf("%d", host.Generation),
+		strings.Join(reboots, ","),
+		fmt.Sprintf("%t", host.Status.PoweredOn))
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestOperationRequestID(t *testing.T) {
+	host := newDefaultHost(t)
+	host.UID = "host-uid"
+
+	start := metav1.NewTime(time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC))
+	host.Status.OperationHistory.Provision.Start = start
+
+	id := operationRequestID(host, metal3v1alpha1.StateProvisioning, "http://example.test/image")
+	assert.Equal(t, "host-uid/provisioning/2021-01-02T03:04:05Z/http://example.test/image", id)
+
+	// Restarting the operation produces a new ID
+	host.Status.OperationHistory.Provision.Start = metav1.NewTime(start.Add(time.Minute))
+	assert.NotEqual(t, id, operationRequestID(host, metal3v1alpha1.StateProvisioning, "http://example.test/image"))
+
+	// Operations that were never started still get a stable ID
+	assert.Equal(t, "host-uid/deprovisioning/", operationRequestID(host, metal3v1alpha1.StateDeprovisioning))
+}
+
+func TestPowerRequestID(t *testing.T) {
+	host := newDefaultHost(t)
+	host.UID = "host-uid"
+	host.Generation = 2
+
+	assert.Equal(t, "host-uid/power-on/2//false", powerRequestID(host, true))
+
+	host.Annotations = map[string]string{
+		rebootAnnotationPrefix + "/b": "",
+		rebootAnnotationPrefix + "/a": "",
+		"unrelated":                   "",
+	}
+	host.Status.PoweredOn = true
+	assert.Equal(t, "host-uid/power-off/2/reboot.metal3.io/a,reboot.metal3.io/b/true", powerRequestID(host, false))
+}
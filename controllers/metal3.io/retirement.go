@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// retirementScrubbing reports whether the host being retired has been
+// powered off, so that its node is being removed from the provisioner
+// and must not be registered again.
+func retirementScrubbing(host *metal3v1alpha1.BareMetalHost) bool {
+	return host.Status.Provisioning.State == metal3v1alpha1.StateRetiring &&
+		host.Status.Retirement != nil && host.Status.Retirement.PoweredOff != nil
+}
+
+// Erase the disks of a host being retired, power it off and remove it
+// from the provisioner along with its BMC credentials.
+func (r *BareMetalHostReconciler) actionRetiring(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
+	status := info.host.Status.Retirement
+	if status == nil {
+		info.host.Status.Retirement = &metal3v1alpha1.RetirementStatus{}
+		info.publishEvent("RetirementStarted", "Retiring the host")
+		return actionUpdate{}
+	}
+
+	if status.EraseFinished == nil {
+		info.log.Info("erasing disks")
+		return r.eraseDisks(prov, info, provisioner.EraseOptions{},
+			metal3v1alpha1.RetirementError, &status.EraseStarted, &status.EraseFinished, nil)
+	}
+
+	if status.PoweredOff == nil {
+		if result := powerOffForGood(prov, info); result != nil {
+			return result
+		}
+		now := metav1.Now()
+		status.PoweredOff = &now
+		return actionUpdate{}
+	}
+
+	// Removing the node drops the BMC credentials the provisioner
+	// holds, so nothing can manage the host any more
+	provResult, err := prov.Delete()
+	if err != nil {
+		return actionError{errors.Wrap(err, "failed to remove the host from the provisioner")}
+	}
+	if provResult.ErrorMessage != "" {
+		return recordActionFailure(info, metal3v1alpha1.RetirementError, provResult.ErrorMessage)
+	}
+	if provResult.Dirty {
+		result := actionContinue{provResult.RequeueAfter}
+		if clearError(info.host) {
+			return actionUpdate{result}
+		}
+		return result
+	}
+
+	info.host.Status.Provisioning.ID = ""
+	info.host.Status.GoodCredentials = metal3v1alpha1.CredentialsStatus{}
+	info.host.Status.TriedCredentials = metal3v1alpha1.CredentialsStatus{}
+	clearError(info.host)
+	info.publishEvent("Retired", "The host has been erased, powered off and its BMC credentials removed")
+	return actionComplete{}
+}
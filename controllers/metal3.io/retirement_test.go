@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+func TestRetire(t *testing.T) {
+	host := host(metal3v1alpha1.StateReady).SetStatusPoweredOn(true).build()
+	host.Spec.Retire = true
+	host.Status.Provisioning.ID = "node-uuid"
+
+	prov := newMockProvisioner()
+	r := &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}
+	hsm := newHostStateMachine(host, r, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	hsm.ReconcileState(info)
+	assert.Equal(t, metal3v1alpha1.StateRetiring, host.Status.Provisioning.State)
+
+	prov.nextResults["Erase"] = provisioner.Result{Dirty: true}
+	for i := 0; i < 3; i++ {
+		hsm.ReconcileState(info)
+	}
+	if assert.NotNil(t, host.Status.Retirement) {
+		assert.NotNil(t, host.Status.Retirement.EraseStarted)
+		assert.Nil(t, host.Status.Retirement.EraseFinished)
+	}
+
+	delete(prov.nextResults, "Erase")
+	for i := 0; i < 2; i++ {
+		hsm.ReconcileState(info)
+	}
+	assert.NotNil(t, host.Status.Retirement.PoweredOff)
+	assert.False(t, host.Status.PoweredOn)
+
+	// The host is not registered again while its node is removed
+	prov.nextResults["ValidateManagementAccess"] = provisioner.Result{ErrorMessage: "no node"}
+	prov.nextResults["Delete"] = provisioner.Result{Dirty: true}
+	hsm.ReconcileState(info)
+	assert.Equal(t, metal3v1alpha1.StateRetiring, host.Status.Provisioning.State)
+	assert.Empty(t, host.Status.ErrorType)
+
+	delete(prov.nextResults, "Delete")
+	hsm.ReconcileState(info)
+	assert.Equal(t, metal3v1alpha1.StateRetired, host.Status.Provisioning.State)
+	assert.Empty(t, host.Status.Provisioning.ID)
+	assert.Nil(t, host.Status.GoodCredentials.Reference)
+	assert.Nil(t, host.Status.TriedCredentials.Reference)
+
+	// The host stays retired, without credentials
+	hsm = newHostStateMachine(host, r, prov, false)
+	hsm.ReconcileState(info)
+	assert.Equal(t, metal3v1alpha1.StateRetired, host.Status.Provisioning.State)
+
+	host.Spec.Retire = false
+	hsm.ReconcileState(info)
+	assert.Equal(t, metal3v1alpha1.StateRegistering, host.Status.Provisioning.State)
+	assert.Nil(t, host.Status.Retirement)
+}
+
+func TestRetireProvisioned(t *testing.T) {
+	for _, state := range []metal3v1alpha1.ProvisioningState{
+		metal3v1alpha1.StateProvisioned,
+		metal3v1alpha1.StateExternallyProvisioned,
+	} {
+		t.Run(string(state), func(t *testing.T) {
+			host := host(state).build()
+			host.Spec.ExternallyProvisioned = state == metal3v1alpha1.StateExternallyProvisioned
+			host.Spec.Retire = true
+			hsm := newHostStateMachine(host, &BareMetalHostReconciler{}, newMockProvisioner(), true)
+			info := makeDefaultReconcileInfo(host)
+			host.UpdateGoodCredentials(*info.bmcCredsSecret)
+			host.UpdateTriedCredentials(*info.bmcCredsSecret)
+
+			hsm.ReconcileState(info)
+			assert.Equal(t, metal3v1alpha1.StateDeprovisioning, host.Status.Provisioning.State)
+
+			// Once deprovisioned, the host is retired even if it
+			// was externally provisioned
+			host.Status.Provisioning.State = metal3v1alpha1.StateReady
+			hsm.ReconcileState(info)
+			assert.Equal(t, metal3v1alpha1.StateRetiring, host.Status.Provisioning.State)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/utils"
+)
+
+func secureBootDatabaseStatus(host *metal3v1alpha1.BareMetalHost, database string) *metal3v1alpha1.SecureBootDatabaseStatus {
+	for i := range host.Status.SecureBootDatabases {
+		if host.Status.SecureBootDatabases[i].Database == database {
+			return &host.Status.SecureBootDatabases[i]
+		}
+	}
+	return nil
+}
+
+// pendingSecureBootUpdates returns the secure boot database updates in
+// the spec of the host whose revision has not been handled yet.
+func pendingSecureBootUpdates(host *metal3v1alpha1.BareMetalHost) (pending []metal3v1alpha1.SecureBootDatabaseUpdate) {
+	for _, update := range host.Spec.SecureBootDatabases {
+		status := secureBootDatabaseStatus(host, update.Database)
+		if status == nil || status.Revision != update.Revision {
+			pending = append(pending, update)
+		}
+	}
+	return
+}
+
+// secureBootUpdateRequested reports whether the host has secure boot
+// database updates to apply, or still waits for its maintenance
+// window to apply updates that have been removed since.
+func secureBootUpdateRequested(host *metal3v1alpha1.BareMetalHost) bool {
+	if len(pendingSecureBootUpdates(host)) > 0 {
+		return true
+	}
+	return host.Status.Maintenance != nil &&
+		utils.StringInList(host.Status.Maintenance.Pending, metal3v1alpha1.MaintenanceSecureBootUpdate)
+}
+
+// updateSecureBootDatabases applies the pending secure boot database
+// updates of a ready or provisioned host, and returns true when the
+// status was saved. Provisioned hosts wait for their maintenance
+// window, since an update can keep their image from booting. A failure
+// is recorded in the status instead of being retried, until the
+// revision of the update changes. Failures that may go away on their
+// own, such as a BMC that cannot be reached, are returned to be retried
+// instead.
+func (r *BareMetalHostReconciler) updateSecureBootDatabases(prov provisioner.Provisioner, info *reconcileInfo) (bool, error) {
+	host := info.host
+	provisioned := false
+	switch host.Status.Provisioning.State {
+	case metal3v1alpha1.StateReady, metal3v1alpha1.StateAvailable:
+	case metal3v1alpha1.StateProvisioned, metal3v1alpha1.StateExternallyProvisioned:
+		provisioned = true
+	default:
+		info.log.Info("waiting for the host to be ready or provisioned to update its secure boot databases")
+		return false, nil
+	}
+
+	pending := pendingSecureBootUpdates(host)
+	wait, dirty := waitForMaintenance(info, metal3v1alpha1.MaintenanceSecureBootUpdate,
+		provisioned && len(pending) > 0, time.Now())
+	if wait > 0 {
+		pending = nil
+	}
+
+	var servicing []metal3v1alpha1.ServicingRecord
+	var retryErr error
+	for _, update := range pending {
+		status := metal3v1alpha1.SecureBootDatabaseStatus{
+			Database: update.Database,
+			Revision: update.Revision,
+		}
+		// Updates of provisioned hosts are servicing, and the
+		// firmware only uses them from the next boot
+		record := metal3v1alpha1.ServicingRecord{
+			Operation: metal3v1alpha1.MaintenanceSecureBootUpdate,
+			Changes:   []string{fmt.Sprintf("%s revision %s", update.Database, update.Revision)},
+			Started:   metav1.Now(),
+			Result:    metal3v1alpha1.ServicingSucceeded,
+		}
+		err := prov.UpdateSecureBootDatabase(update)
+		var transient provisioner.TransientError
+		if errors.As(err, &transient) {
+			info.log.Info("secure boot database update will be retried", "database", update.Database, "error", err.Error())
+			retryErr = err
+			break
+		}
+		if err != nil {
+			info.log.Info("secure boot database update failed", "database", update.Database, "error", err.Error())
+			status.ErrorMessage = err.Error()
+			info.publishEvent("SecureBootDatabaseUpdateFailed",
+				fmt.Sprintf("Revision %s of the %s database: %s", update.Revision, update.Database, err.Error()))
+			record.Result = metal3v1alpha1.ServicingFailed
+			record.ErrorMessage = err.Error()
+		} else {
+			now := metav1.Now()
+			status.Time = &now
+		}
+		if provisioned {
+			record.Duration = metav1.Duration{Duration: time.Since(record.Started.Time).Round(time.Millisecond)}
+			recordServicing(host, record)
+			servicing = append(servicing, record)
+		}
+		if existing := secureBootDatabaseStatus(host, update.Database); existing != nil {
+			*existing = status
+		} else {
+			host.Status.SecureBootDatabases = append(host.Status.SecureBootDatabases, status)
+		}
+		dirty = true
+	}
+
+	if !dirty {
+		return false, retryErr
+	}
+	if err := r.saveHostStatus(host); err != nil {
+		return false, errors.Wrap(err, "failed to save the secure boot database updates")
+	}
+	for _, record := range servicing {
+		observeServicing(host, record)
+	}
+	return true, retryErr
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+func TestPendingSecureBootUpdates(t *testing.T) {
+	host := &metal3v1alpha1.BareMetalHost{}
+	assert.False(t, secureBootUpdateRequested(host))
+
+	host.Spec.SecureBootDatabases = []metal3v1alpha1.SecureBootDatabaseUpdate{
+		{Database: "db", Revision: "1"},
+		{Database: "dbx", Revision: "2"},
+	}
+	host.Status.SecureBootDatabases = []metal3v1alpha1.SecureBootDatabaseStatus{
+		{Database: "db", Revision: "1"},
+		{Database: "dbx", Revision: "1"},
+	}
+	pending := pendingSecureBootUpdates(host)
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, "dbx", pending[0].Database)
+	}
+
+	host.Status.SecureBootDatabases[1].Revision = "2"
+	assert.False(t, secureBootUpdateRequested(host))
+
+	host.Status.Maintenance = &metal3v1alpha1.MaintenanceStatus{
+		Pending: []string{metal3v1alpha1.MaintenanceSecureBootUpdate},
+	}
+	assert.True(t, secureBootUpdateRequested(host))
+}
+
+// TestUpdateSecureBootDatabases ensures that the secure boot database
+// updates of a host are applied once it is ready.
+func TestUpdateSecureBootDatabases(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Spec.SecureBootDatabases = []metal3v1alpha1.SecureBootDatabaseUpdate{
+		{Database: "dbx", Revision: "2023-05-09", Signatures: []string{"80b4d96931bf0d02fd91a61e19d14f1da452e66db2408ca8604d411f92659f0a"}},
+	}
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return len(host.Status.SecureBootDatabases) > 0
+		},
+	)
+
+	assert.Equal(t, metal3v1alpha1.StateReady, host.Status.Provisioning.State)
+	status := host.Status.SecureBootDatabases[0]
+	assert.Equal(t, "dbx", status.Database)
+	assert.Equal(t, "2023-05-09", status.Revision)
+	assert.NotNil(t, status.Time)
+	assert.Empty(t, status.ErrorMessage)
+	// Ready hosts are not serviced
+	assert.Empty(t, host.Status.ServicingHistory)
+}
+
+// TestUpdateSecureBootDatabasesMaintenanceWindow ensures that a
+// provisioned host waits for its maintenance window to update its
+// secure boot databases.
+func TestUpdateSecureBootDatabasesMaintenanceWindow(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	host.Spec.SecureBootDatabases = []metal3v1alpha1.SecureBootDatabaseUpdate{
+		{Database: "db", Revision: "1"},
+	}
+	// Closed all year but the first minute
+	host.Spec.MaintenanceWindow = &metal3v1alpha1.MaintenanceWindow{
+		Schedule: "0 0 1 1 *",
+		Duration: metav1.Duration{Duration: time.Minute},
+	}
+	r := newTestReconciler(host)
+	prov := newMockProvisioner()
+	info := func() *reconcileInfo {
+		return &reconcileInfo{log: logf.Log, host: host}
+	}
+
+	saved, err := r.updateSecureBootDatabases(prov, info())
+	assert.NoError(t, err)
+	assert.True(t, saved)
+	assert.Empty(t, host.Status.SecureBootDatabases)
+	if assert.NotNil(t, host.Status.Maintenance) {
+		assert.Equal(t, []string{metal3v1alpha1.MaintenanceSecureBootUpdate}, host.Status.Maintenance.Pending)
+	}
+
+	saved, err = r.updateSecureBootDatabases(prov, info())
+	assert.NoError(t, err)
+	assert.False(t, saved)
+
+	// Always open
+	host.Spec.MaintenanceWindow.Schedule = "* * * * *"
+	saved, err = r.updateSecureBootDatabases(prov, info())
+	assert.NoError(t, err)
+	assert.True(t, saved)
+	assert.Nil(t, host.Status.Maintenance)
+	if assert.Len(t, host.Status.SecureBootDatabases, 1) {
+		assert.Equal(t, "1", host.Status.SecureBootDatabases[0].Revision)
+	}
+
+	// Updates of provisioned hosts are recorded as servicing
+	if assert.Len(t, host.Status.ServicingHistory, 1) {
+		record := host.Status.ServicingHistory[0]
+		assert.Equal(t, metal3v1alpha1.MaintenanceSecureBootUpdate, record.Operation)
+		assert.Equal(t, []string{"db revision 1"}, record.Changes)
+		assert.Equal(t, metal3v1alpha1.ServicingSucceeded, record.Result)
+		assert.Zero(t, record.Reboots)
+	}
+}
+
+// TestUpdateSecureBootDatabasesTransientError ensures that an update
+// that failed for a reason that may go away is retried instead of
+// being recorded as failed.
+func TestUpdateSecureBootDatabasesTransientError(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Status.Provisioning.State = metal3v1alpha1.StateReady
+	host.Spec.SecureBootDatabases = []metal3v1alpha1.SecureBootDatabaseUpdate{
+		{Database: "db", Revision: "1"},
+	}
+	r := newTestReconciler(host)
+	prov := newMockProvisioner()
+	prov.secureBootError = provisioner.TransientError{Err: errors.New("BMC unreachable")}
+	info := func() *reconcileInfo {
+		return &reconcileInfo{log: logf.Log, host: host}
+	}
+
+	saved, err := r.updateSecureBootDatabases(prov, info())
+	assert.Error(t, err)
+	assert.False(t, saved)
+	assert.Empty(t, host.Status.SecureBootDatabases)
+
+	prov.secureBootError = nil
+	saved, err = r.updateSecureBootDatabases(prov, info())
+	assert.NoError(t, err)
+	assert.True(t, saved)
+	if assert.Len(t, host.Status.SecureBootDatabases, 1) {
+		assert.Empty(t, host.Status.SecureBootDatabases[0].ErrorMessage)
+		assert.NotNil(t, host.Status.SecureBootDatabases[0].Time)
+	}
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// servicingHistoryLength is the number of servicing operations kept in
+// the status of a host.
+const servicingHistoryLength = 10
+
+// recordServicing adds the servicing operation to the history of the
+// host, dropping the oldest ones beyond servicingHistoryLength.
+func recordServicing(host *metal3v1alpha1.BareMetalHost, record metal3v1alpha1.ServicingRecord) {
+	history := append(host.Status.ServicingHistory, record)
+	if extra := len(history) - servicingHistoryLength; extra > 0 {
+		history = append([]metal3v1alpha1.ServicingRecord(nil), history[extra:]...)
+	}
+	host.Status.ServicingHistory = history
+}
+
+// servicingMetricLabels returns the labels of the servicing metrics,
+// with the model of the host so that the impact of maintenance can be
+// compared between models.
+func servicingMetricLabels(host *metal3v1alpha1.BareMetalHost, operation string) prometheus.Labels {
+	labels := prometheus.Labels{
+		labelServicingOperation: operation,
+		labelManufacturer:       "",
+		labelProductName:        "",
+	}
+	if details := host.Status.HardwareDetails; details != nil {
+		labels[labelManufacturer] = details.SystemVendor.Manufacturer
+		labels[labelProductName] = details.SystemVendor.ProductName
+	}
+	return labels
+}
+
+// observeServicing updates the servicing metrics once the record of
+// the operation has been saved.
+func observeServicing(host *metal3v1alpha1.BareMetalHost, record metal3v1alpha1.ServicingRecord) {
+	labels := servicingMetricLabels(host, record.Operation)
+	servicingReboots.With(labels).Add(float64(record.Reboots))
+	labels[labelServicingResult] = string(record.Result)
+	servicingDuration.With(labels).Observe(record.Duration.Seconds())
+}
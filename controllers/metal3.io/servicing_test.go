@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestRecordServicing(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).build()
+
+	for i := 0; i < servicingHistoryLength+2; i++ {
+		recordServicing(host, metal3v1alpha1.ServicingRecord{
+			Operation: metal3v1alpha1.MaintenanceSecureBootUpdate,
+			Changes:   []string{fmt.Sprintf("dbx revision %d", i)},
+			Result:    metal3v1alpha1.ServicingSucceeded,
+		})
+	}
+
+	history := host.Status.ServicingHistory
+	if assert.Len(t, history, servicingHistoryLength) {
+		assert.Equal(t, []string{"dbx revision 2"}, history[0].Changes)
+		assert.Equal(t, []string{fmt.Sprintf("dbx revision %d", servicingHistoryLength+1)},
+			history[servicingHistoryLength-1].Changes)
+	}
+}
+
+func TestServicingMetricLabels(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).build()
+	host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{
+		SystemVendor: metal3v1alpha1.HardwareSystemVendor{Manufacturer: "Dell Inc.", ProductName: "PowerEdge R640"},
+	}
+
+	labels := servicingMetricLabels(host, metal3v1alpha1.MaintenanceSecureBootUpdate)
+	assert.Equal(t, "Dell Inc.", labels[labelManufacturer])
+	assert.Equal(t, "PowerEdge R640", labels[labelProductName])
+
+	host.Status.HardwareDetails = nil
+	labels = servicingMetricLabels(host, metal3v1alpha1.MaintenanceSecureBootUpdate)
+	assert.Empty(t, labels[labelManufacturer])
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// powerRefreshInterval is how old the saved power refresh time of a
+// managed host may get before the status is saved again only to
+// update it.
+const powerRefreshInterval = 10 * time.Minute
+
+// refreshTimes returns the refresh times of the host status, adding
+// them if needed.
+func refreshTimes(host *metal3v1alpha1.BareMetalHost) *metal3v1alpha1.StatusRefreshTimes {
+	if host.Status.Refreshed == nil {
+		host.Status.Refreshed = &metal3v1alpha1.StatusRefreshTimes{}
+	}
+	return host.Status.Refreshed
+}
+
+func markRefreshed(section **metav1.Time) {
+	now := metav1.Now()
+	*section = &now
+}
+
+// powerRefreshDue reports whether the saved power refresh time of the
+// host is too old.
+func powerRefreshDue(host *metal3v1alpha1.BareMetalHost, now time.Time) bool {
+	refreshed := host.Status.Refreshed
+	return refreshed == nil || refreshed.Power == nil ||
+		now.Sub(refreshed.Power.Time) >= powerRefreshInterval
+}
+
+// recordPowerState saves the power state read from the host in its
+// status, and reports whether it changed. A change that does not
+// reach the desired state was made outside of the operator.
+func recordPowerState(info *reconcileInfo, poweredOn, desired bool) bool {
+	markRefreshed(&refreshTimes(info.host).Power)
+	if poweredOn == info.host.Status.PoweredOn {
+		return false
+	}
+
+	info.log.Info("updating power status", "discovered", poweredOn)
+	info.host.Status.PoweredOn = poweredOn
+	if poweredOn != desired {
+		// Nothing asked for this change, so the power policy of the
+		// host decides whether it is reverted.
+		now := metav1.Now()
+		info.host.Status.LastExternalPowerChange = &now
+		info.publishEvent("PowerChangedExternally",
+			fmt.Sprintf("Host was powered %s outside of the operator", powerStateName(poweredOn)))
+	}
+	return true
+}
+
+// refreshStatus reads the power state and the driver of the host from
+// the provisioner again, saves them in the status and removes the
+// annotation requesting it. It returns false without doing anything if
+// the host is not registered with the provisioner yet, so the refresh
+// is retried later.
+func (r *BareMetalHostReconciler) refreshStatus(ctx context.Context, prov provisioner.Provisioner, info *reconcileInfo) (bool, error) {
+	hwState, err := prov.UpdateHardwareState()
+	if errors.Is(err, provisioner.NeedsRegistration) {
+		info.log.Info("waiting for registration to refresh the status")
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "failed to read the power state")
+	}
+
+	host := info.host
+	if hwState.PoweredOn != nil {
+		recordPowerState(info, *hwState.PoweredOn, desiredPowerState(host, time.Now()))
+	}
+	if _, err := updateDriverStatus(prov, host); err != nil {
+		return false, errors.Wrap(err, "failed to get the driver status")
+	}
+	if err := r.saveHostStatus(host); err != nil {
+		return false, errors.Wrap(err, "failed to save the refreshed status")
+	}
+
+	delete(host.Annotations, metal3v1alpha1.RefreshStatusAnnotation)
+	if err := r.Update(ctx, host); err != nil {
+		return false, errors.Wrap(err, "failed to remove refresh annotation")
+	}
+
+	info.log.Info("refreshed status")
+	info.publishEvent("StatusRefreshed", "Read the power state and the driver of the host again")
+	return true, nil
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	goctx "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestPowerRefreshDue(t *testing.T) {
+	now := time.Now()
+	host := &metal3v1alpha1.BareMetalHost{}
+	assert.True(t, powerRefreshDue(host, now))
+
+	refreshed := metav1.NewTime(now.Add(-time.Minute))
+	host.Status.Refreshed = &metal3v1alpha1.StatusRefreshTimes{Power: &refreshed}
+	assert.False(t, powerRefreshDue(host, now))
+	assert.True(t, powerRefreshDue(host, now.Add(powerRefreshInterval)))
+}
+
+// TestManageHostPowerRefresh ensures that the status of a host in the
+// requested power state is saved when its power refresh time is old.
+func TestManageHostPowerRefresh(t *testing.T) {
+	r := &BareMetalHostReconciler{}
+	host := host("").SetStatusPoweredOn(true).build()
+	host.Spec.Online = true
+	poweredOn := true
+	prov := newMockProvisioner()
+	prov.hwState.PoweredOn = &poweredOn
+
+	result := r.manageHostPower(prov, makeDefaultReconcileInfo(host))
+	assert.IsType(t, actionUpdate{}, result)
+	if assert.NotNil(t, host.Status.Refreshed) {
+		assert.NotNil(t, host.Status.Refreshed.Power)
+	}
+
+	result = r.manageHostPower(prov, makeDefaultReconcileInfo(host))
+	assert.IsType(t, actionContinue{}, result)
+}
+
+// TestRefreshStatus ensures that the power state and the driver of a
+// host are read again when the refresh annotation is present.
+func TestRefreshStatus(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{
+		metal3v1alpha1.RefreshStatusAnnotation: "",
+	}
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			_, found := host.Annotations[metal3v1alpha1.RefreshStatusAnnotation]
+			return !found
+		},
+	)
+
+	if assert.NotNil(t, host.Status.Refreshed) {
+		assert.NotNil(t, host.Status.Refreshed.Power)
+		assert.NotNil(t, host.Status.Refreshed.Provisioning)
+	}
+	if assert.NotNil(t, host.Status.Provisioning.Driver) {
+		assert.Equal(t, "fake-hardware", host.Status.Provisioning.Driver.Name)
+	}
+
+	events := &corev1.EventList{}
+	if err := r.List(goctx.TODO(), events); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, e := range events.Items {
+		found = found || e.Reason == "StatusRefreshed"
+	}
+	assert.True(t, found)
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// detectVirtualMedia records the virtual media features of the BMC of
+// a host that boots from virtual media, and returns true if the status
+// changed. A failure is recorded rather than retried, so the features
+// are only read again once the host is registered with new credentials
+// or as a new node.
+func detectVirtualMedia(prov provisioner.Provisioner, info *reconcileInfo) bool {
+	support, err := prov.DetectVirtualMedia()
+	if err != nil {
+		info.log.Info("could not detect virtual media support", "error", err.Error())
+		info.publishEvent("VirtualMediaDetectionFailed", err.Error())
+		support = &metal3v1alpha1.VirtualMediaSupport{
+			Detected:     metav1.Now(),
+			ErrorMessage: err.Error(),
+		}
+	}
+	if support == nil {
+		return false
+	}
+	info.host.Status.VirtualMedia = support
+	return true
+}
+
+// virtualMediaUnsupported returns why the image of the host cannot be
+// booted from the virtual media of its BMC, or an empty string if
+// nothing detected rules it out. Only live ISOs are mounted as virtual
+// media.
+func virtualMediaUnsupported(host *metal3v1alpha1.BareMetalHost) string {
+	image := host.Spec.Image
+	if image == nil || image.DiskFormat == nil || *image.DiskFormat != "live-iso" || !usesVirtualMedia(host) {
+		return ""
+	}
+	support := host.Status.VirtualMedia
+	if support == nil || support.ErrorMessage != "" {
+		return ""
+	}
+	switch {
+	case !support.InsertEject:
+		return "the BMC cannot insert and eject virtual media, so the live ISO cannot be booted"
+	case support.Slots == 0:
+		return "the BMC has no virtual drive that can hold the live ISO"
+	case support.HTTPS != nil && !*support.HTTPS && strings.HasPrefix(strings.ToLower(image.URL), "https://"):
+		return "the BMC cannot mount virtual media over HTTPS, serve the live ISO over HTTP instead"
+	}
+	return ""
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+const virtualMediaAddress = "redfish-virtualmedia://192.168.122.1/redfish/v1/Systems/1"
+
+func TestVirtualMediaUnsupported(t *testing.T) {
+	liveISO := "live-iso"
+	raw := "raw"
+	yes, no := true, false
+	capable := metal3v1alpha1.VirtualMediaSupport{InsertEject: true, HTTPS: &yes, Slots: 1}
+
+	testCases := []struct {
+		Scenario   string
+		Address    string
+		URL        string
+		DiskFormat *string
+		Support    *metal3v1alpha1.VirtualMediaSupport
+		Expected   string
+	}{
+		{
+			Scenario: "capable BMC",
+			URL:      "https://example.test/live.iso", DiskFormat: &liveISO,
+			Support: &capable,
+		},
+		{
+			Scenario: "not detected",
+			URL:      "https://example.test/live.iso", DiskFormat: &liveISO,
+		},
+		{
+			Scenario: "detection failed",
+			URL:      "https://example.test/live.iso", DiskFormat: &liveISO,
+			Support: &metal3v1alpha1.VirtualMediaSupport{ErrorMessage: "oops"},
+		},
+		{
+			Scenario: "no insert and eject",
+			URL:      "http://example.test/live.iso", DiskFormat: &liveISO,
+			Support:  &metal3v1alpha1.VirtualMediaSupport{Slots: 1},
+			Expected: "the BMC cannot insert and eject virtual media, so the live ISO cannot be booted",
+		},
+		{
+			Scenario: "no slots",
+			URL:      "http://example.test/live.iso", DiskFormat: &liveISO,
+			Support:  &metal3v1alpha1.VirtualMediaSupport{InsertEject: true},
+			Expected: "the BMC has no virtual drive that can hold the live ISO",
+		},
+		{
+			Scenario: "no HTTPS",
+			URL:      "HTTPS://example.test/live.iso", DiskFormat: &liveISO,
+			Support:  &metal3v1alpha1.VirtualMediaSupport{InsertEject: true, HTTPS: &no, Slots: 1},
+			Expected: "the BMC cannot mount virtual media over HTTPS, serve the live ISO over HTTP instead",
+		},
+		{
+			Scenario: "no HTTPS with HTTP image",
+			URL:      "http://example.test/live.iso", DiskFormat: &liveISO,
+			Support: &metal3v1alpha1.VirtualMediaSupport{InsertEject: true, HTTPS: &no, Slots: 1},
+		},
+		{
+			Scenario: "protocols not listed",
+			URL:      "https://example.test/live.iso", DiskFormat: &liveISO,
+			Support: &metal3v1alpha1.VirtualMediaSupport{InsertEject: true, Slots: 1},
+		},
+		{
+			Scenario: "disk image",
+			URL:      "https://example.test/image.raw", DiskFormat: &raw,
+			Support: &metal3v1alpha1.VirtualMediaSupport{},
+		},
+		{
+			Scenario: "PXE boot",
+			Address:  "ipmi://192.168.122.1",
+			URL:      "https://example.test/live.iso", DiskFormat: &liveISO,
+			Support: &metal3v1alpha1.VirtualMediaSupport{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := host(metal3v1alpha1.StateProvisioning).build()
+			host.Spec.BMC.Address = virtualMediaAddress
+			if tc.Address != "" {
+				host.Spec.BMC.Address = tc.Address
+			}
+			host.Spec.Image = &metal3v1alpha1.Image{URL: tc.URL, DiskFormat: tc.DiskFormat}
+			host.Status.VirtualMedia = tc.Support
+			assert.Equal(t, tc.Expected, virtualMediaUnsupported(host))
+		})
+	}
+}
+
+func TestDetectVirtualMediaOnRegistration(t *testing.T) {
+	host := host(metal3v1alpha1.StateRegistering).build()
+	host.Spec.BMC.Address = virtualMediaAddress
+	prov := newMockProvisioner()
+	prov.virtualMedia = &metal3v1alpha1.VirtualMediaSupport{InsertEject: true, Slots: 2}
+	r := &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}
+	hsm := newHostStateMachine(host, r, prov, true)
+	info := makeDefaultReconcileInfo(host)
+	host.UpdateGoodCredentials(*info.bmcCredsSecret)
+	host.UpdateTriedCredentials(*info.bmcCredsSecret)
+
+	hsm.ReconcileState(info)
+	assert.Equal(t, prov.virtualMedia, host.Status.VirtualMedia)
+
+	// The features are not read again for the same node and credentials
+	prov.virtualMedia = nil
+	hsm.ReconcileState(info)
+	assert.NotNil(t, host.Status.VirtualMedia)
+}
+
+func TestProvisionLiveISOWithoutVirtualMedia(t *testing.T) {
+	liveISO := "live-iso"
+	host := host(metal3v1alpha1.StateProvisioning).build()
+	host.Spec.BMC.Address = virtualMediaAddress
+	host.Spec.Image = &metal3v1alpha1.Image{URL: "http://example.test/live.iso", DiskFormat: &liveISO}
+	host.Status.VirtualMedia = &metal3v1alpha1.VirtualMediaSupport{Slots: 1}
+	r := &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}
+	hsm := newHostStateMachine(host, r, newMockProvisioner(), true)
+	info := makeDefaultReconcileInfo(host)
+	host.UpdateGoodCredentials(*info.bmcCredsSecret)
+	host.UpdateTriedCredentials(*info.bmcCredsSecret)
+
+	hsm.ReconcileState(info)
+	assert.Equal(t, metal3v1alpha1.ProvisioningError, host.Status.ErrorType)
+	assert.Contains(t, host.Status.ErrorMessage, "cannot insert and eject virtual media")
+}
@@ -17,12 +17,16 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"runtime"
+	"strconv"
+	"strings"
 
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -32,6 +36,9 @@ import (
 	metal3iov1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 	metal3iocontroller "github.com/metal3-io/baremetal-operator/controllers/metal3.io"
 	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/hostapi"
+	"github.com/metal3-io/baremetal-operator/pkg/netbox"
+	"github.com/metal3-io/baremetal-operator/pkg/notify"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/demo"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/empty"
@@ -81,6 +88,10 @@ func main() {
 	var devLogging bool
 	var runInTestMode bool
 	var runInDemoMode bool
+	var webhookPort int
+	var hostAPIAddr string
+	var hostAPICertDir string
+	var migrateAnnotations bool
 
 	// From CAPI point of view, BMO should be able to watch all namespaces
 	// in case of a deployment that is not multi-tenant. If the deployment
@@ -99,6 +110,17 @@ func main() {
 		"use the demo provisioner to set host states")
 	flag.StringVar(&healthAddr, "health-addr", ":9440",
 		"The address the health endpoint binds to.")
+	flag.IntVar(&webhookPort, "webhook-port", 0,
+		"Webhook Server port (set to 9443 to enable the admission webhooks, 0 disables them).")
+	flag.StringVar(&hostAPIAddr, "host-api-addr", "",
+		"The address the read-only host REST API binds to (empty disables it). "+
+			"Clients authenticate with the token in the HOST_API_TOKEN environment variable.")
+	flag.StringVar(&hostAPICertDir, "host-api-cert-dir", "",
+		"Directory with the tls.crt and tls.key files used to serve the host API over TLS, "+
+			"such as the webhook certificate directory. Without it the host API only binds to a loopback address.")
+	flag.BoolVar(&migrateAnnotations, "migrate-annotations", false,
+		"Only convert the legacy annotations of existing hosts to their structured equivalents, "+
+			"for use while upgrading, instead of managing the hosts.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(devLogging)))
@@ -108,7 +130,7 @@ func main() {
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                  scheme,
 		MetricsBindAddress:      metricsAddr,
-		Port:                    0, // Add flag with default of 9443 when adding webhooks
+		Port:                    webhookPort,
 		LeaderElection:          enableLeaderElection,
 		LeaderElectionID:        "baremetal-operator",
 		LeaderElectionNamespace: watchNamespace,
@@ -120,6 +142,24 @@ func main() {
 		os.Exit(1)
 	}
 
+	if migrateAnnotations {
+		if err = (&metal3iocontroller.AnnotationMigrationReconciler{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("AnnotationMigration"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AnnotationMigration")
+			os.Exit(1)
+		}
+		setupChecks(mgr)
+
+		setupLog.Info("starting manager to migrate legacy annotations")
+		if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+			setupLog.Error(err, "problem running manager")
+			os.Exit(1)
+		}
+		return
+	}
+
 	provisionerFactory := func(host metal3iov1alpha1.BareMetalHost, bmcCreds bmc.Credentials, publish provisioner.EventPublisher) (provisioner.Provisioner, error) {
 		isUnmanaged := !host.HasBMCDetails()
 
@@ -140,15 +180,140 @@ func main() {
 		return ironic.New(*hostCopy, bmcCreds, publish)
 	}
 
+	var notifier *notify.Notifier
+	if notificationsFile := os.Getenv("NOTIFICATIONS_FILE"); notificationsFile != "" {
+		maxAttempts := 10
+		if attemptsEnv := os.Getenv("NOTIFICATION_MAX_ATTEMPTS"); attemptsEnv != "" {
+			maxAttempts, err = strconv.Atoi(attemptsEnv)
+			if err != nil || maxAttempts <= 0 {
+				setupLog.Error(err, "invalid NOTIFICATION_MAX_ATTEMPTS", "value", attemptsEnv)
+				os.Exit(1)
+			}
+		}
+		notifier = notify.NewNotifier(notificationsFile, maxAttempts)
+		if err = mgr.Add(notifier); err != nil {
+			setupLog.Error(err, "unable to start notifier")
+			os.Exit(1)
+		}
+	}
+
+	var pause *metal3iocontroller.OperatorPause
+	if pauseFile := os.Getenv("PAUSE_FILE"); pauseFile != "" {
+		setupLog.Info("the operator is paused while the pause file exists", "file", pauseFile)
+		pause = &metal3iocontroller.OperatorPause{File: pauseFile}
+	}
+
 	if err = (&metal3iocontroller.BareMetalHostReconciler{
 		Client:             mgr.GetClient(),
 		Log:                ctrl.Log.WithName("controllers").WithName("BareMetalHost"),
 		ProvisionerFactory: provisionerFactory,
+		Notifier:           notifier,
+		Pause:              pause,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "BareMetalHost")
 		os.Exit(1)
 	}
 
+	if err = (&metal3iocontroller.ReplacementReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("Replacement"),
+		Pause:  pause,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Replacement")
+		os.Exit(1)
+	}
+
+	if err = (&metal3iocontroller.HostQuotaReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("HostQuota"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "HostQuota")
+		os.Exit(1)
+	}
+
+	if err = (&metal3iocontroller.HostActionReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("HostAction"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "HostAction")
+		os.Exit(1)
+	}
+
+	if err = (&metal3iocontroller.HostAcceptanceTestReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("HostAcceptanceTest"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "HostAcceptanceTest")
+		os.Exit(1)
+	}
+
+	if err = (&metal3iocontroller.FirmwareBaselineReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("FirmwareBaseline"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "FirmwareBaseline")
+		os.Exit(1)
+	}
+
+	if netboxURL := os.Getenv("NETBOX_URL"); netboxURL != "" {
+		if err = (&metal3iocontroller.NetBoxSyncReconciler{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("NetBox"),
+			NetBox: netbox.New(netboxURL, os.Getenv("NETBOX_TOKEN")),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "NetBox")
+			os.Exit(1)
+		}
+	}
+
+	if secretName := os.Getenv("REPORTING_KUBECONFIG_SECRET"); secretName != "" {
+		parts := strings.SplitN(secretName, "/", 2)
+		cluster := os.Getenv("REPORTING_CLUSTER_NAME")
+		if len(parts) != 2 || cluster == "" {
+			setupLog.Error(nil, "REPORTING_KUBECONFIG_SECRET must be namespace/name and REPORTING_CLUSTER_NAME must be set")
+			os.Exit(1)
+		}
+		reportingNamespace := os.Getenv("REPORTING_NAMESPACE")
+		if reportingNamespace == "" {
+			reportingNamespace = "metal3-reports"
+		}
+		remote, err := metal3iocontroller.NewReportingClient(context.Background(), mgr.GetAPIReader(),
+			mgr.GetScheme(), types.NamespacedName{Namespace: parts[0], Name: parts[1]})
+		if err == nil {
+			err = (&metal3iocontroller.HostReportReconciler{
+				Client:    mgr.GetClient(),
+				Log:       ctrl.Log.WithName("controllers").WithName("HostReport"),
+				Remote:    remote,
+				Cluster:   cluster,
+				Namespace: reportingNamespace,
+			}).SetupWithManager(mgr)
+		}
+		if err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "HostReport")
+			os.Exit(1)
+		}
+	}
+
+	if webhookPort != 0 {
+		metal3iov1alpha1.ActionVerbsRequired = strings.ToLower(os.Getenv("ACTION_VERBS_REQUIRED")) == "true"
+		if err = (&metal3iov1alpha1.BareMetalHost{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "BareMetalHost")
+			os.Exit(1)
+		}
+	}
+
+	if hostAPIAddr != "" {
+		server, err := hostapi.New(mgr.GetClient(), hostAPIAddr, os.Getenv("HOST_API_TOKEN"), hostAPICertDir,
+			ctrl.Log.WithName("hostapi"))
+		if err == nil {
+			err = mgr.Add(server)
+		}
+		if err != nil {
+			setupLog.Error(err, "unable to create host API server")
+			os.Exit(1)
+		}
+	}
+
 	setupChecks(mgr)
 
 	// +kubebuilder:scaffold:builder
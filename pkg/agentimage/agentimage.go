@@ -0,0 +1,165 @@
+package agentimage
+
+import (
+	"hash/fnv"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logz "sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/metal3-io/baremetal-operator/pkg/configfile"
+)
+
+var log = logz.New().WithName("agentimage")
+
+// Image describes one build of the deployment agent (IPA). The
+// checksums are sha256 digests of the files, which pin the version
+// name to the exact content served at the URLs.
+type Image struct {
+	Version         string `json:"version"`
+	KernelURL       string `json:"kernelURL,omitempty"`
+	KernelChecksum  string `json:"kernelChecksum,omitempty"`
+	RamdiskURL      string `json:"ramdiskURL,omitempty"`
+	RamdiskChecksum string `json:"ramdiskChecksum,omitempty"`
+	ISOURL          string `json:"isoURL,omitempty"`
+	ISOChecksum     string `json:"isoChecksum,omitempty"`
+}
+
+// Rollout stages a new agent version onto a percentage of the hosts
+// in a group. Each host is assigned a fixed bucket, so raising the
+// percentage only ever adds hosts to the new version.
+type Rollout struct {
+	Image   Image `json:"image"`
+	Percent int   `json:"percent"`
+}
+
+// Group selects the agent version for the hosts matching its
+// selector.
+type Group struct {
+	Name string `json:"name"`
+
+	// HostSelector matches the labels of the hosts in the group. A
+	// nil selector matches every host.
+	HostSelector *metav1.LabelSelector `json:"hostSelector,omitempty"`
+
+	// Image is the version used by the hosts in the group.
+	Image Image `json:"image"`
+
+	// Rollout is an optional newer version being rolled out to the
+	// group.
+	Rollout *Rollout `json:"rollout,omitempty"`
+}
+
+// Config is the content of the agent images file.
+type Config struct {
+	Groups []Group `json:"groups"`
+}
+
+func (g Group) matches(hostLabels map[string]string) (bool, error) {
+	match, err := configfile.MatchesHost(g.HostSelector, hostLabels)
+	return match, errors.Wrapf(err, "invalid host selector in group %s", g.Name)
+}
+
+// bucket places the host in one of 100 buckets used to stage
+// rollouts.
+func bucket(hostUID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(hostUID))
+	return int(h.Sum32() % 100)
+}
+
+func validate(config Config) error {
+	for _, g := range config.Groups {
+		images := []Image{g.Image}
+		if g.Rollout != nil {
+			if g.Rollout.Percent < 0 || g.Rollout.Percent > 100 {
+				return errors.Errorf("rollout percent for group %s must be between 0 and 100", g.Name)
+			}
+			images = append(images, g.Rollout.Image)
+		}
+		for _, img := range images {
+			if img.Version == "" {
+				return errors.Errorf("agent image in group %s has no version", g.Name)
+			}
+			for _, pair := range [][2]string{
+				{img.KernelURL, img.KernelChecksum},
+				{img.RamdiskURL, img.RamdiskChecksum},
+				{img.ISOURL, img.ISOChecksum},
+			} {
+				if pair[0] != "" && pair[1] == "" {
+					return errors.Errorf("agent image %s in group %s has no checksum for %s",
+						img.Version, g.Name, pair[0])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Registry returns the agent image to use for a host, from a
+// configuration file that is read again whenever it changes.
+type Registry struct {
+	file   configfile.File
+	lock   sync.Mutex
+	config Config
+}
+
+// NewRegistry returns a registry reading the file at path. With an
+// empty path, no agent image is ever selected.
+func NewRegistry(path string) *Registry {
+	return &Registry{file: configfile.New(path, "agent images")}
+}
+
+var defaultRegistry = NewRegistry(os.Getenv("AGENT_IMAGES_FILE"))
+
+// ForHost returns the agent image for the host from the default
+// registry, configured with the AGENT_IMAGES_FILE environment
+// variable.
+func ForHost(hostLabels map[string]string, hostUID string) (Image, bool) {
+	return defaultRegistry.ForHost(hostLabels, hostUID)
+}
+
+// ForHost returns the agent image for the host with the given labels
+// and UID. The first group matching the host is used. It returns
+// false if no group matches, in which case the provisioner defaults
+// apply.
+func (r *Registry) ForHost(hostLabels map[string]string, hostUID string) (Image, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.reload(); err != nil {
+		log.Error(err, "failed to load agent images, using previous values",
+			"path", r.file.Path())
+	}
+
+	for _, g := range r.config.Groups {
+		match, err := g.matches(hostLabels)
+		if err != nil {
+			log.Error(err, "skipping agent image group")
+			continue
+		}
+		if !match {
+			continue
+		}
+		if g.Rollout != nil && bucket(hostUID) < g.Rollout.Percent {
+			return g.Rollout.Image, true
+		}
+		return g.Image, true
+	}
+	return Image{}, false
+}
+
+// reload reads the file if it has changed since it was last
+// read. The caller must hold the lock.
+func (r *Registry) reload() error {
+	var config Config
+	changed, err := r.file.Load(&config, func() error { return validate(config) })
+	if err != nil || !changed {
+		return err
+	}
+	log.Info("loaded agent images", "path", r.file.Path(), "groups", len(config.Groups))
+	r.config = config
+	return nil
+}
@@ -0,0 +1,124 @@
+package agentimage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+const testConfig = `
+groups:
+- name: edge
+  hostSelector:
+    matchLabels:
+      site: edge
+  image:
+    version: "8.0"
+    kernelURL: http://images/8.0/ipa.kernel
+    kernelChecksum: abc
+    ramdiskURL: http://images/8.0/ipa.initramfs
+    ramdiskChecksum: def
+  rollout:
+    percent: 50
+    image:
+      version: "8.1"
+      kernelURL: http://images/8.1/ipa.kernel
+      kernelChecksum: 123
+- name: default
+  image:
+    version: "7.0"
+    isoURL: http://images/7.0/ipa.iso
+    isoChecksum: "456"
+`
+
+func writeConfig(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "agentimage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "images.yaml")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestRegistryForHost(t *testing.T) {
+	path, cleanup := writeConfig(t, testConfig)
+	defer cleanup()
+	r := NewRegistry(path)
+
+	img, ok := r.ForHost(map[string]string{"site": "core"}, "uid")
+	assert.True(t, ok)
+	assert.Equal(t, "7.0", img.Version)
+
+	// The rollout covers about half of the group, and each host
+	// always gets the same version.
+	counts := map[string]int{}
+	for i := 0; i < 200; i++ {
+		uid := fmt.Sprintf("host-%d", i)
+		img, ok := r.ForHost(map[string]string{"site": "edge"}, uid)
+		assert.True(t, ok)
+		again, _ := r.ForHost(map[string]string{"site": "edge"}, uid)
+		assert.Equal(t, img, again)
+		counts[img.Version]++
+	}
+	assert.Equal(t, 200, counts["8.0"]+counts["8.1"])
+	assert.InDelta(t, 100, counts["8.1"], 40)
+}
+
+func TestRegistryNoFile(t *testing.T) {
+	_, ok := NewRegistry("").ForHost(nil, "uid")
+	assert.False(t, ok)
+
+	_, ok = NewRegistry("/does/not/exist").ForHost(nil, "uid")
+	assert.False(t, ok)
+}
+
+func TestRegistryRejectsInvalidConfig(t *testing.T) {
+	path, cleanup := writeConfig(t, testConfig)
+	defer cleanup()
+	r := NewRegistry(path)
+	_, ok := r.ForHost(nil, "uid")
+	assert.True(t, ok)
+
+	for name, content := range map[string]string{
+		"missing-checksum": `
+groups:
+- name: g
+  image:
+    version: "1"
+    kernelURL: http://images/ipa.kernel
+`,
+		"missing-version": `
+groups:
+- name: g
+  image:
+    kernelURL: http://images/ipa.kernel
+    kernelChecksum: abc
+`,
+		"bad-percent": `
+groups:
+- name: g
+  image:
+    version: "1"
+  rollout:
+    percent: 150
+    image:
+      version: "2"
+`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var config Config
+			if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+				t.Fatal(err)
+			}
+			assert.Error(t, validate(config))
+		})
+	}
+}
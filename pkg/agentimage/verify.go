@@ -0,0 +1,135 @@
+package agentimage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type verification struct {
+	done bool
+	err  error
+}
+
+// Verifier checks that the files served at the agent image URLs
+// match their pinned checksums. Each URL and checksum pair is
+// downloaded once, in the background, and the result is remembered.
+type Verifier struct {
+	client  *http.Client
+	lock    sync.Mutex
+	results map[string]*verification
+}
+
+// NewVerifier returns a verifier downloading with the given client.
+func NewVerifier(client *http.Client) *Verifier {
+	return &Verifier{
+		client:  client,
+		results: map[string]*verification{},
+	}
+}
+
+// downloadTimeout bounds the download of one agent image file, so
+// that a stalled server does not keep it from being verified again.
+const downloadTimeout = 15 * time.Minute
+
+var defaultVerifier = NewVerifier(&http.Client{Timeout: downloadTimeout})
+
+// Verify checks the image with the default verifier.
+func Verify(img Image) (done bool, err error) {
+	return defaultVerifier.Verify(img)
+}
+
+// Verify reports whether all the files of the image have been
+// checked, and the first mismatch or download error found. It
+// starts the checks that have not been run yet and does not wait for
+// them to complete. Failed downloads are retried on the next call,
+// mismatches are not.
+func (v *Verifier) Verify(img Image) (done bool, err error) {
+	done = true
+	for _, pair := range [][2]string{
+		{img.KernelURL, img.KernelChecksum},
+		{img.RamdiskURL, img.RamdiskChecksum},
+		{img.ISOURL, img.ISOChecksum},
+	} {
+		if pair[0] == "" {
+			continue
+		}
+		fileDone, fileErr := v.verifyFile(pair[0], pair[1])
+		if fileErr != nil {
+			return true, fileErr
+		}
+		done = done && fileDone
+	}
+	return done, nil
+}
+
+func (v *Verifier) verifyFile(url, checksum string) (bool, error) {
+	key := url + "#" + checksum
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	result, ok := v.results[key]
+	if ok {
+		return result.done, result.err
+	}
+
+	result = &verification{}
+	v.results[key] = result
+	go func() {
+		err := v.download(url, checksum)
+		v.lock.Lock()
+		defer v.lock.Unlock()
+		if err != nil {
+			var mismatch checksumMismatch
+			if !errors.As(err, &mismatch) {
+				// Try the download again next time.
+				log.Error(err, "could not verify agent image", "url", url)
+				delete(v.results, key)
+				return
+			}
+		}
+		result.done = true
+		result.err = err
+	}()
+	return false, nil
+}
+
+type checksumMismatch struct {
+	url      string
+	expected string
+	actual   string
+}
+
+func (e checksumMismatch) Error() string {
+	return "checksum of agent image " + e.url + " is " + e.actual + ", expected " + e.expected
+}
+
+func (v *Verifier) download(url, checksum string) error {
+	resp, err := v.client.Get(url)
+	if err != nil {
+		return errors.Wrapf(err, "could not download %s", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("could not download %s: %s", url, resp.Status)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, resp.Body); err != nil {
+		return errors.Wrapf(err, "could not download %s", url)
+	}
+	actual := hex.EncodeToString(h.Sum(nil))
+	expected := strings.ToLower(strings.TrimPrefix(checksum, "sha256:"))
+	if actual != expected {
+		return checksumMismatch{url: url, expected: expected, actual: actual}
+	}
+	log.Info("verified agent image", "url", url)
+	return nil
+}
@@ -0,0 +1,79 @@
+package agentimage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func waitForVerification(t *testing.T, v *Verifier, img Image) error {
+	for i := 0; i < 100; i++ {
+		done, err := v.Verify(img)
+		if done {
+			return err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("verification did not complete")
+	return nil
+}
+
+func TestVerifier(t *testing.T) {
+	content := []byte("agent kernel")
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ipa.kernel" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(content)
+	}))
+	defer server.Close()
+	url := server.URL + "/ipa.kernel"
+
+	v := NewVerifier(server.Client())
+
+	assert.NoError(t, waitForVerification(t, v, Image{
+		Version:        "1",
+		KernelURL:      url,
+		KernelChecksum: "sha256:" + checksum,
+	}))
+
+	err := waitForVerification(t, v, Image{
+		Version:        "2",
+		KernelURL:      url,
+		KernelChecksum: "0000",
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "expected 0000")
+	}
+
+	// Download errors are not remembered, so they are retried.
+	missing := Image{
+		Version:         "3",
+		RamdiskURL:      server.URL + "/missing",
+		RamdiskChecksum: checksum,
+	}
+	done, err := v.Verify(missing)
+	assert.False(t, done)
+	assert.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		v.lock.Lock()
+		_, pending := v.results[fmt.Sprintf("%s#%s", missing.RamdiskURL, checksum)]
+		v.lock.Unlock()
+		if !pending {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	done, _ = v.Verify(missing)
+	assert.False(t, done)
+}
@@ -62,6 +62,10 @@ type AccessDetails interface {
 
 	// Whether the driver supports changing secure boot state.
 	SupportsSecureBoot() bool
+
+	// DiscoversBootMAC returns true when the boot MAC address may be
+	// left out and discovered during inspection instead.
+	DiscoversBootMAC() bool
 }
 
 func getParsedURL(address string) (parsedURL *url.URL, err error) {
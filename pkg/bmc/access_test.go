@@ -665,6 +665,55 @@ func TestStaticDriverInfo(t *testing.T) {
 	}
 }
 
+func TestDiscoversBootMAC(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario string
+		input    string
+		expected bool
+	}{
+		{
+			Scenario: "ipmi",
+			input:    "ipmi://192.168.122.1",
+			expected: false,
+		},
+		{
+			Scenario: "redfish",
+			input:    "redfish://192.168.122.1",
+			expected: false,
+		},
+		{
+			Scenario: "idrac",
+			input:    "idrac://192.168.122.1",
+			expected: false,
+		},
+		{
+			Scenario: "redfish virtual media",
+			input:    "redfish-virtualmedia://192.168.122.1",
+			expected: true,
+		},
+		{
+			Scenario: "ilo5 virtual media",
+			input:    "ilo5-virtualmedia://192.168.122.1",
+			expected: true,
+		},
+		{
+			Scenario: "idrac virtual media",
+			input:    "idrac-virtualmedia+https://192.168.122.1",
+			expected: true,
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			acc, err := NewAccessDetails(tc.input, false)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			if acc.DiscoversBootMAC() != tc.expected {
+				t.Fatalf("Boot MAC discovered: %v, expected %v", acc.DiscoversBootMAC(), tc.expected)
+			}
+		})
+	}
+}
+
 func TestDriverInfo(t *testing.T) {
 	for _, tc := range []struct {
 		Scenario string
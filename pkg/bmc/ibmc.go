@@ -101,3 +101,7 @@ func (a *ibmcAccessDetails) VendorInterface() string {
 func (a *ibmcAccessDetails) SupportsSecureBoot() bool {
 	return false
 }
+
+func (a *ibmcAccessDetails) DiscoversBootMAC() bool {
+	return false
+}
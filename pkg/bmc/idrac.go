@@ -99,3 +99,7 @@ func (a *iDracAccessDetails) VendorInterface() string {
 func (a *iDracAccessDetails) SupportsSecureBoot() bool {
 	return false
 }
+
+func (a *iDracAccessDetails) DiscoversBootMAC() bool {
+	return false
+}
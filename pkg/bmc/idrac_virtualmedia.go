@@ -90,3 +90,10 @@ func (a *redfishiDracVirtualMediaAccessDetails) VendorInterface() string {
 func (a *redfishiDracVirtualMediaAccessDetails) SupportsSecureBoot() bool {
 	return true
 }
+
+// DiscoversBootMAC returns true because the host is booted from
+// virtual media, so the boot MAC address may be left out and taken
+// from the inspection results.
+func (a *redfishiDracVirtualMediaAccessDetails) DiscoversBootMAC() bool {
+	return true
+}
@@ -93,3 +93,7 @@ func (a *iLOAccessDetails) VendorInterface() string {
 func (a *iLOAccessDetails) SupportsSecureBoot() bool {
 	return true
 }
+
+func (a *iLOAccessDetails) DiscoversBootMAC() bool {
+	return false
+}
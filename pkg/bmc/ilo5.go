@@ -93,3 +93,7 @@ func (a *iLO5AccessDetails) VendorInterface() string {
 func (a *iLO5AccessDetails) SupportsSecureBoot() bool {
 	return true
 }
+
+func (a *iLO5AccessDetails) DiscoversBootMAC() bool {
+	return false
+}
@@ -0,0 +1,79 @@
+package bmc
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// networkBootInterfaces are the boot interfaces that load the
+// provisioning image from the network. BMC types booting from the
+// network may use any of them instead of their default.
+var networkBootInterfaces = []string{"ipxe", "pxe", "http-ipxe", "http"}
+
+// deployInterfaces are the deploy interfaces Ironic offers for every
+// hardware type.
+var deployInterfaces = []string{"direct", "ramdisk", "ansible", "custom-agent"}
+
+// biosInterfaces maps the name of a driver to the BIOS interfaces its
+// hardware type supports.
+var biosInterfaces = map[string][]string{
+	"ibmc":    {"no-bios"},
+	"idrac":   {"idrac-wsman", "idrac-redfish", "no-bios"},
+	"ilo":     {"ilo", "no-bios"},
+	"ilo5":    {"ilo", "no-bios"},
+	"ipmi":    {"no-bios"},
+	"irmc":    {"irmc", "no-bios"},
+	"redfish": {"redfish", "no-bios"},
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNetworkBootInterface reports whether the boot interface loads the
+// provisioning image from the network.
+func IsNetworkBootInterface(name string) bool {
+	return contains(networkBootInterfaces, name)
+}
+
+// IsHTTPBootInterface reports whether the boot interface uses UEFI
+// HTTP boot, which is not available in legacy boot mode.
+func IsHTTPBootInterface(name string) bool {
+	return strings.HasPrefix(name, "http")
+}
+
+// ValidateInterfaces checks that the driver of the BMC supports the
+// requested boot, BIOS and deploy interfaces. Empty values select the
+// default interface and are always accepted.
+func ValidateInterfaces(accessDetails AccessDetails, boot, bios, deploy string) error {
+	if boot != "" && boot != accessDetails.BootInterface() {
+		if strings.HasSuffix(accessDetails.BootInterface(), "virtual-media") {
+			return errors.Errorf("BMC type %s boots from virtual media and cannot use boot interface %q",
+				accessDetails.Type(), boot)
+		}
+		if !IsNetworkBootInterface(boot) {
+			return errors.Errorf("boot interface %q is not one of %v", boot, networkBootInterfaces)
+		}
+	}
+	if deploy != "" && !contains(deployInterfaces, deploy) {
+		return errors.Errorf("deploy interface %q is not one of %v", deploy, deployInterfaces)
+	}
+	if bios == "" {
+		return nil
+	}
+	supported, ok := biosInterfaces[accessDetails.Driver()]
+	if !ok {
+		return errors.Errorf("BMC type %s does not support choosing a BIOS interface", accessDetails.Type())
+	}
+	if !contains(supported, bios) {
+		return errors.Errorf("BIOS interface %q is not supported by BMC type %s, expected one of %v",
+			bios, accessDetails.Type(), supported)
+	}
+	return nil
+}
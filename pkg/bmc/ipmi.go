@@ -96,3 +96,7 @@ func (a *ipmiAccessDetails) VendorInterface() string {
 func (a *ipmiAccessDetails) SupportsSecureBoot() bool {
 	return false
 }
+
+func (a *ipmiAccessDetails) DiscoversBootMAC() bool {
+	return false
+}
@@ -88,3 +88,7 @@ func (a *iRMCAccessDetails) VendorInterface() string {
 func (a *iRMCAccessDetails) SupportsSecureBoot() bool {
 	return true
 }
+
+func (a *iRMCAccessDetails) DiscoversBootMAC() bool {
+	return false
+}
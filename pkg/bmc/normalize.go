@@ -0,0 +1,97 @@
+package bmc
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// defaultPort returns the port a driver connects to when the BMC
+// address does not include one.
+func defaultPort(scheme string) string {
+	switch {
+	case strings.HasSuffix(scheme, "+http"):
+		return "80"
+	case strings.HasSuffix(scheme, "+https"):
+		return "443"
+	case scheme == "ipmi", scheme == "libvirt":
+		return ipmiDefaultPort
+	default:
+		return "443"
+	}
+}
+
+// NormalizeAddress returns the canonical form of a BMC address, so
+// that equivalent addresses compare as equal. The scheme and host
+// are lower-cased, an implicit ipmi scheme is made explicit, IPv6
+// hosts are bracketed, the default port for the driver is dropped
+// and trailing slashes are removed from the path.
+func NormalizeAddress(address string) (string, error) {
+	if address == "" {
+		return "", errors.New("missing BMC address")
+	}
+
+	parsedURL, err := getParsedURL(address)
+	if err != nil {
+		return "", err
+	}
+
+	parsedURL.Scheme = strings.ToLower(parsedURL.Scheme)
+	if _, ok := factories[parsedURL.Scheme]; !ok {
+		return "", &UnknownBMCTypeError{address, parsedURL.Scheme}
+	}
+
+	hostname := strings.ToLower(parsedURL.Hostname())
+	if hostname == "" {
+		return "", errors.Errorf("missing host in BMC address %s", address)
+	}
+	port := parsedURL.Port()
+	if port == defaultPort(parsedURL.Scheme) {
+		port = ""
+	}
+	if port != "" {
+		parsedURL.Host = net.JoinHostPort(hostname, port)
+	} else if strings.Contains(hostname, ":") {
+		parsedURL.Host = "[" + hostname + "]"
+	} else {
+		parsedURL.Host = hostname
+	}
+
+	parsedURL.Path = strings.TrimRight(parsedURL.Path, "/")
+	parsedURL.RawPath = ""
+
+	return parsedURL.String(), nil
+}
+
+// MachineKey returns the part of a normalized BMC address that
+// identifies the machine: the host, the port and the path to the
+// system. The scheme is left out, since it only selects the driver used
+// to talk to the BMC. Addresses that cannot be normalized are returned
+// as given.
+func MachineKey(address string) string {
+	normalized, err := NormalizeAddress(address)
+	if err != nil {
+		return address
+	}
+	parsedURL, err := url.Parse(normalized)
+	if err != nil {
+		return address
+	}
+	return parsedURL.Host + parsedURL.Path
+}
+
+// SameAddress reports whether two BMC addresses refer to the same
+// management controller (and the same system, for drivers that
+// include a system ID in the address), whatever the driver they use.
+// Addresses that cannot be normalized are compared as given.
+func SameAddress(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	if a == b {
+		return true
+	}
+	return MachineKey(a) == MachineKey(b)
+}
@@ -0,0 +1,167 @@
+package bmc
+
+import (
+	"testing"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario    string
+		Address     string
+		Expected    string
+		ExpectError bool
+	}{
+		{
+			Scenario: "already canonical",
+			Address:  "redfish://192.168.122.1/redfish/v1/Systems/1",
+			Expected: "redfish://192.168.122.1/redfish/v1/Systems/1",
+		},
+		{
+			Scenario: "implicit ipmi",
+			Address:  "192.168.122.1",
+			Expected: "ipmi://192.168.122.1",
+		},
+		{
+			Scenario: "implicit ipmi with port",
+			Address:  "192.168.122.1:6230",
+			Expected: "ipmi://192.168.122.1:6230",
+		},
+		{
+			Scenario: "ipmi default port",
+			Address:  "ipmi://192.168.122.1:623",
+			Expected: "ipmi://192.168.122.1",
+		},
+		{
+			Scenario: "scheme and host casing",
+			Address:  "Redfish+HTTPS://BMC.Example.COM/redfish/v1/Systems/ABC",
+			Expected: "redfish+https://bmc.example.com/redfish/v1/Systems/ABC",
+		},
+		{
+			Scenario: "https default port",
+			Address:  "redfish+https://bmc.example.com:443/redfish/v1/Systems/1",
+			Expected: "redfish+https://bmc.example.com/redfish/v1/Systems/1",
+		},
+		{
+			Scenario: "http default port",
+			Address:  "redfish+http://bmc.example.com:80/redfish/v1/Systems/1",
+			Expected: "redfish+http://bmc.example.com/redfish/v1/Systems/1",
+		},
+		{
+			Scenario: "non-default port kept",
+			Address:  "redfish://bmc.example.com:8443/redfish/v1/Systems/1",
+			Expected: "redfish://bmc.example.com:8443/redfish/v1/Systems/1",
+		},
+		{
+			Scenario: "trailing slashes",
+			Address:  "redfish://bmc.example.com/redfish/v1/Systems/1//",
+			Expected: "redfish://bmc.example.com/redfish/v1/Systems/1",
+		},
+		{
+			Scenario: "query kept",
+			Address:  "libvirt://192.168.122.1:6233/?abc=def",
+			Expected: "libvirt://192.168.122.1:6233?abc=def",
+		},
+		{
+			Scenario: "ipv6 default port",
+			Address:  "ipmi://[FE80::fc33:62FF:fe83:8a76]:623",
+			Expected: "ipmi://[fe80::fc33:62ff:fe83:8a76]",
+		},
+		{
+			Scenario: "ipv6 with port",
+			Address:  "redfish://[fe80::fc33:62ff:fe83:8a76]:8000/redfish/v1/Systems/1/",
+			Expected: "redfish://[fe80::fc33:62ff:fe83:8a76]:8000/redfish/v1/Systems/1",
+		},
+		{
+			Scenario:    "unknown type",
+			Address:     "foo://192.168.122.1",
+			ExpectError: true,
+		},
+		{
+			Scenario:    "empty",
+			Address:     "",
+			ExpectError: true,
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			actual, err := NormalizeAddress(tc.Address)
+			if tc.ExpectError {
+				if err == nil {
+					t.Fatalf("expected error, got %q", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.Expected {
+				t.Errorf("expected %q, got %q", tc.Expected, actual)
+			}
+			// Normalizing is idempotent
+			again, err := NormalizeAddress(actual)
+			if err != nil || again != actual {
+				t.Errorf("normalizing %q again gave %q (%v)", actual, again, err)
+			}
+		})
+	}
+}
+
+func TestSameAddress(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario string
+		A        string
+		B        string
+		Expected bool
+	}{
+		{
+			Scenario: "equivalent",
+			A:        "IPMI://192.168.122.1:623/",
+			B:        "192.168.122.1",
+			Expected: true,
+		},
+		{
+			Scenario: "different redfish drivers",
+			A:        "redfish://bmc.example.com/redfish/v1/Systems/1",
+			B:        "redfish-virtualmedia://bmc.example.com/redfish/v1/Systems/1",
+			Expected: true,
+		},
+		{
+			Scenario: "idrac and redfish",
+			A:        "idrac-redfish://bmc.example.com/redfish/v1/Systems/System.Embedded.1",
+			B:        "redfish+https://BMC.example.com:443/redfish/v1/Systems/System.Embedded.1/",
+			Expected: true,
+		},
+		{
+			Scenario: "ipmi and redfish default ports",
+			A:        "ipmi://192.168.122.1",
+			B:        "redfish://192.168.122.1",
+			Expected: true,
+		},
+		{
+			Scenario: "different system",
+			A:        "redfish://bmc.example.com/redfish/v1/Systems/1",
+			B:        "redfish://bmc.example.com/redfish/v1/Systems/2",
+		},
+		{
+			Scenario: "different port",
+			A:        "ipmi://192.168.122.1:6230",
+			B:        "ipmi://192.168.122.1:6231",
+		},
+		{
+			Scenario: "invalid but identical",
+			A:        "foo://192.168.122.1",
+			B:        "foo://192.168.122.1",
+			Expected: true,
+		},
+		{
+			Scenario: "empty",
+			A:        "",
+			B:        "",
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			if actual := SameAddress(tc.A, tc.B); actual != tc.Expected {
+				t.Errorf("expected %v, got %v", tc.Expected, actual)
+			}
+		})
+	}
+}
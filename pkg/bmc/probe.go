@@ -0,0 +1,84 @@
+package bmc
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmcproxy"
+)
+
+// ErrProbeUnsupported is returned by Probe when the BMC cannot be
+// probed, so whether it answers is unknown.
+var ErrProbeUnsupported = errors.New("BMC cannot be probed through a proxy")
+
+// rmcpPresencePing is an ASF presence ping in an RMCP packet, which
+// IPMI BMCs answer without authentication.
+var rmcpPresencePing = []byte{
+	0x06, 0x00, 0xff, 0x06, // RMCP version 1.0, no acknowledgement, ASF class
+	0x00, 0x00, 0x11, 0xbe, // ASF IANA enterprise number
+	0x80, 0x00, 0x00, 0x00, // presence ping, tag, reserved, no data
+}
+
+// Probe checks cheaply whether the BMC at address answers on the
+// network, without logging in: IPMI BMCs are sent an RMCP presence
+// ping, and a TCP connection is opened to the others through the
+// proxy, when it is not nil. RMCP is carried over UDP, which the
+// proxies do not relay, so IPMI BMCs reached through a proxy are not
+// probed and ErrProbeUnsupported is returned.
+func Probe(address string, proxy *url.URL, timeout time.Duration) error {
+	parsedURL, err := getParsedURL(address)
+	if err != nil {
+		return err
+	}
+	if parsedURL.Hostname() == "" {
+		return errors.Errorf("no host in BMC address %s", address)
+	}
+
+	driver := strings.Split(parsedURL.Scheme, "+")[0]
+	port := parsedURL.Port()
+	if driver == "ipmi" || driver == "libvirt" {
+		if proxy != nil {
+			return ErrProbeUnsupported
+		}
+		if port == "" {
+			port = ipmiDefaultPort
+		}
+		return probeRMCP(net.JoinHostPort(parsedURL.Hostname(), port), timeout)
+	}
+
+	if port == "" {
+		port = "443"
+		if strings.HasSuffix(parsedURL.Scheme, "+http") {
+			port = "80"
+		}
+	}
+	conn, err := bmcproxy.Dial(proxy, net.JoinHostPort(parsedURL.Hostname(), port), timeout)
+	if err != nil {
+		return errors.Wrap(err, "BMC is not reachable")
+	}
+	return conn.Close()
+}
+
+func probeRMCP(address string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("udp", address, timeout)
+	if err != nil {
+		return errors.Wrap(err, "BMC is not reachable")
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return errors.Wrap(err, "could not set the deadline of the probe")
+	}
+	if _, err := conn.Write(rmcpPresencePing); err != nil {
+		return errors.Wrap(err, "BMC is not reachable")
+	}
+	// Any answer will do: the BMC is there.
+	reply := make([]byte, 64)
+	if _, err := conn.Read(reply); err != nil {
+		return errors.Wrap(err, "BMC did not answer the presence ping")
+	}
+	return nil
+}
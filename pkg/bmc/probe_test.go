@@ -0,0 +1,99 @@
+package bmc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestProbeTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+
+	if err := Probe(fmt.Sprintf("redfish://%s/redfish/v1/Systems/1", address), nil, time.Second); err != nil {
+		t.Errorf("unexpected error probing a listening BMC: %s", err)
+	}
+
+	listener.Close()
+	if err := Probe(fmt.Sprintf("idrac-virtualmedia://%s/redfish/v1/Systems/1", address), nil, time.Second); err == nil {
+		t.Error("expected an error probing a closed port")
+	}
+}
+
+func TestProbeRMCP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	pings := make(chan []byte, 1)
+	go func() {
+		buffer := make([]byte, 64)
+		n, from, err := conn.ReadFrom(buffer)
+		if err != nil {
+			return
+		}
+		pings <- buffer[:n]
+		conn.WriteTo([]byte{0x06, 0x00, 0xff, 0x06, 0x00, 0x00, 0x11, 0xbe, 0x40}, from)
+	}()
+
+	if err := Probe("ipmi://"+conn.LocalAddr().String(), nil, time.Second); err != nil {
+		t.Errorf("unexpected error probing an answering BMC: %s", err)
+	}
+	if ping := <-pings; !bytes.Equal(ping, rmcpPresencePing) {
+		t.Errorf("unexpected ping %v", ping)
+	}
+
+	// Nothing answers the second ping
+	if err := Probe("ipmi://"+conn.LocalAddr().String(), nil, 100*time.Millisecond); err == nil {
+		t.Error("expected an error probing a silent BMC")
+	}
+}
+
+func TestProbeInvalidAddress(t *testing.T) {
+	if err := Probe("redfish:///redfish/v1/Systems/1", nil, time.Second); err == nil {
+		t.Error("expected an error for an address without host")
+	}
+}
+
+func TestProbeThroughProxy(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	tunnels := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		request, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil {
+			return
+		}
+		tunnels <- request.Host
+		conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+	}()
+	proxy := &url.URL{Scheme: "http", Host: listener.Addr().String()}
+
+	if err := Probe("redfish://bmc.example.com/redfish/v1/Systems/1", proxy, time.Second); err != nil {
+		t.Errorf("unexpected error probing through a proxy: %s", err)
+	}
+	if tunnel := <-tunnels; tunnel != "bmc.example.com:443" {
+		t.Errorf("unexpected tunnel to %s", tunnel)
+	}
+
+	if err := Probe("ipmi://192.168.122.1", proxy, time.Second); err != ErrProbeUnsupported {
+		t.Errorf("expected the probe to be unsupported, got %v", err)
+	}
+}
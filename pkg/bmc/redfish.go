@@ -122,6 +122,10 @@ func (a *redfishAccessDetails) SupportsSecureBoot() bool {
 	return true
 }
 
+func (a *redfishAccessDetails) DiscoversBootMAC() bool {
+	return false
+}
+
 // iDrac Redfish Overrides
 
 func (a *redfishiDracAccessDetails) Driver() string {
@@ -89,3 +89,10 @@ func (a *redfishVirtualMediaAccessDetails) VendorInterface() string {
 func (a *redfishVirtualMediaAccessDetails) SupportsSecureBoot() bool {
 	return true
 }
+
+// DiscoversBootMAC returns true because the host is booted from
+// virtual media, so the boot MAC address may be left out and taken
+// from the inspection results.
+func (a *redfishVirtualMediaAccessDetails) DiscoversBootMAC() bool {
+	return true
+}
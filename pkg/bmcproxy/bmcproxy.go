@@ -0,0 +1,133 @@
+// Package bmcproxy chooses the proxy through which the operator
+// connects to the BMC of a host. Every connection the operator makes
+// to a BMC uses it: the Redfish clients reading the inventory and
+// installing boot certificates, and the reachability probes. The
+// power, provisioning and inspection traffic is sent to the BMCs by
+// Ironic, which does not use these proxies.
+package bmcproxy
+
+import (
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logz "sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/metal3-io/baremetal-operator/pkg/configfile"
+)
+
+var log = logz.New().WithName("bmcproxy")
+
+// Group routes the BMC traffic of the hosts matching its selector
+// through a proxy, e.g. the bastion of their management network.
+type Group struct {
+	Name string `json:"name"`
+	// HostSelector matches the labels of the hosts in the group. A
+	// nil selector matches every host.
+	HostSelector *metav1.LabelSelector `json:"hostSelector,omitempty"`
+	// Proxy is the URL of the proxy, with the socks5, http or https
+	// scheme, e.g. "socks5://bastion.example.com:1080". An empty
+	// value connects to the BMCs of the group directly.
+	Proxy string `json:"proxy"`
+}
+
+// Config is the content of the BMC proxies file.
+type Config struct {
+	Groups []Group `json:"groups"`
+}
+
+func (g Group) matches(hostLabels map[string]string) (bool, error) {
+	match, err := configfile.MatchesHost(g.HostSelector, hostLabels)
+	return match, errors.Wrapf(err, "invalid host selector in group %s", g.Name)
+}
+
+// parseProxy returns the URL of a proxy, or nil for an empty value.
+func parseProxy(value string) (*url.URL, error) {
+	if value == "" {
+		return nil, nil
+	}
+	proxy, err := url.Parse(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid proxy URL %q", value)
+	}
+	switch proxy.Scheme {
+	case "socks5", "socks5h", "http", "https":
+	default:
+		return nil, errors.Errorf("proxy URL %q must use the socks5, http or https scheme", value)
+	}
+	if proxy.Host == "" {
+		return nil, errors.Errorf("proxy URL %q has no host", value)
+	}
+	return proxy, nil
+}
+
+func validate(config Config) error {
+	for _, g := range config.Groups {
+		if _, err := parseProxy(g.Proxy); err != nil {
+			return errors.Wrapf(err, "group %s", g.Name)
+		}
+	}
+	return nil
+}
+
+// Registry chooses the proxy for the BMC of a host, from a
+// configuration file that is read again whenever it changes.
+type Registry struct {
+	file         configfile.File
+	defaultProxy string
+	lock         sync.Mutex
+	config       Config
+}
+
+// NewRegistry returns a registry reading the file at path. Hosts that
+// do not match any group use defaultProxy, and connect to their BMC
+// directly when it is empty.
+func NewRegistry(path, defaultProxy string) *Registry {
+	return &Registry{file: configfile.New(path, "BMC proxies"), defaultProxy: defaultProxy}
+}
+
+var defaultRegistry = NewRegistry(os.Getenv("BMC_PROXIES_FILE"), os.Getenv("BMC_PROXY"))
+
+// ForHost returns the proxy for the BMC of the host, using the default
+// registry configured with the BMC_PROXIES_FILE and BMC_PROXY
+// environment variables.
+func ForHost(hostLabels map[string]string) (*url.URL, error) {
+	return defaultRegistry.ForHost(hostLabels)
+}
+
+// ForHost returns the proxy of the first group matching the host with
+// the given labels, or nil to connect to the BMC directly.
+func (r *Registry) ForHost(hostLabels map[string]string) (*url.URL, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if err := r.reload(); err != nil {
+		log.Error(err, "failed to load BMC proxies, using previous values",
+			"path", r.file.Path())
+	}
+	for _, g := range r.config.Groups {
+		match, err := g.matches(hostLabels)
+		if err != nil {
+			log.Error(err, "skipping BMC proxy group")
+			continue
+		}
+		if match {
+			return parseProxy(g.Proxy)
+		}
+	}
+	return parseProxy(r.defaultProxy)
+}
+
+// reload reads the file if it has changed since it was last
+// read. The caller must hold the lock.
+func (r *Registry) reload() error {
+	var config Config
+	changed, err := r.file.Load(&config, func() error { return validate(config) })
+	if err != nil || !changed {
+		return err
+	}
+	log.Info("loaded BMC proxies", "path", r.file.Path(), "groups", len(config.Groups))
+	r.config = config
+	return nil
+}
@@ -0,0 +1,102 @@
+package bmcproxy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `
+groups:
+- name: dc1
+  hostSelector:
+    matchLabels:
+      topology.kubernetes.io/zone: dc1
+  proxy: socks5://bastion.dc1.example.com:1080
+- name: lab
+  hostSelector:
+    matchLabels:
+      topology.kubernetes.io/zone: lab
+`
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "proxies.yaml")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestForHost(t *testing.T) {
+	r := NewRegistry(writeConfig(t, testConfig), "http://proxy.example.com:3128")
+
+	for _, tc := range []struct {
+		Scenario string
+		Labels   map[string]string
+		Expected string
+	}{
+		{
+			Scenario: "group",
+			Labels:   map[string]string{"topology.kubernetes.io/zone": "dc1"},
+			Expected: "socks5://bastion.dc1.example.com:1080",
+		},
+		{
+			Scenario: "direct",
+			Labels:   map[string]string{"topology.kubernetes.io/zone": "lab"},
+		},
+		{
+			Scenario: "default",
+			Labels:   map[string]string{"topology.kubernetes.io/zone": "dc2"},
+			Expected: "http://proxy.example.com:3128",
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			proxy, err := r.ForHost(tc.Labels)
+			assert.NoError(t, err)
+			if tc.Expected == "" {
+				assert.Nil(t, proxy)
+			} else if assert.NotNil(t, proxy) {
+				assert.Equal(t, tc.Expected, proxy.String())
+			}
+		})
+	}
+}
+
+func TestForHostNoConfig(t *testing.T) {
+	proxy, err := NewRegistry("", "").ForHost(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, proxy)
+
+	_, err = NewRegistry("", "ftp://proxy.example.com").ForHost(nil)
+	assert.Error(t, err)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	path := writeConfig(t, testConfig)
+	r := NewRegistry(path, "")
+	dc1 := map[string]string{"topology.kubernetes.io/zone": "dc1"}
+	proxy, _ := r.ForHost(dc1)
+	assert.NotNil(t, proxy)
+
+	// An invalid file keeps the previous configuration
+	if err := ioutil.WriteFile(path, []byte(`
+groups:
+- name: dc1
+  proxy: bastion.dc1.example.com
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	proxy, err := r.ForHost(dc1)
+	assert.NoError(t, err)
+	if assert.NotNil(t, proxy) {
+		assert.Equal(t, "bastion.dc1.example.com:1080", proxy.Host)
+	}
+}
@@ -0,0 +1,212 @@
+package bmcproxy
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Dial opens a TCP connection to address through the proxy, or
+// directly when proxy is nil. It is meant for the connections to BMCs
+// that are not made with an HTTP client, which handles the proxy
+// itself. The whole exchange with the proxy must complete within the
+// timeout.
+func Dial(proxy *url.URL, address string, timeout time.Duration) (net.Conn, error) {
+	if proxy == nil {
+		return net.DialTimeout("tcp", address, timeout)
+	}
+
+	var conn net.Conn
+	var err error
+	if proxy.Scheme == "https" {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", proxyAddress(proxy), nil)
+	} else {
+		conn, err = net.DialTimeout("tcp", proxyAddress(proxy), timeout)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not connect to proxy %s", proxy.Host)
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "could not set the deadline of the proxy connection")
+	}
+
+	switch proxy.Scheme {
+	case "socks5", "socks5h":
+		err = socksConnect(conn, proxy, address)
+	default:
+		conn, err = httpConnect(conn, proxy, address)
+	}
+	if err == nil {
+		err = conn.SetDeadline(time.Time{})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrapf(err, "proxy %s could not connect to %s", proxy.Host, address)
+	}
+	return conn, nil
+}
+
+// proxyAddress returns the address of the proxy, with the default
+// port of its scheme if it has none.
+func proxyAddress(proxy *url.URL) string {
+	if proxy.Port() != "" {
+		return proxy.Host
+	}
+	port := "1080"
+	switch proxy.Scheme {
+	case "http":
+		port = "80"
+	case "https":
+		port = "443"
+	}
+	return net.JoinHostPort(proxy.Hostname(), port)
+}
+
+// bufferedConn is a connection whose first bytes were already read
+// into a buffer.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// httpConnect asks an HTTP proxy to open a tunnel to address, and
+// returns the connection to read the tunnel from.
+func httpConnect(conn net.Conn, proxy *url.URL, address string) (net.Conn, error) {
+	request := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		request.SetBasicAuth(proxy.User.Username(), password)
+		request.Header.Set("Proxy-Authorization", request.Header.Get("Authorization"))
+		request.Header.Del("Authorization")
+	}
+	if err := request.Write(conn); err != nil {
+		return conn, err
+	}
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		return conn, err
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return conn, errors.Errorf("unexpected status %s", response.Status)
+	}
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+// SOCKS5 protocol values, from RFC 1928 and RFC 1929.
+const (
+	socksVersion        = 0x05
+	socksNoAuth         = 0x00
+	socksPasswordAuth   = 0x02
+	socksConnectCommand = 0x01
+	socksIPv4Address    = 0x01
+	socksDomainName     = 0x03
+	socksIPv6Address    = 0x04
+)
+
+// socksConnect asks a SOCKS5 proxy to connect to address.
+func socksConnect(conn net.Conn, proxy *url.URL, address string) error {
+	host, portValue, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.ParseUint(portValue, 10, 16)
+	if err != nil {
+		return errors.Errorf("invalid port %q", portValue)
+	}
+
+	method := byte(socksNoAuth)
+	if proxy.User != nil {
+		method = socksPasswordAuth
+	}
+	if _, err := conn.Write([]byte{socksVersion, 1, method}); err != nil {
+		return err
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[0] != socksVersion || reply[1] != method {
+		return errors.New("SOCKS proxy refused the authentication method")
+	}
+	if method == socksPasswordAuth {
+		username := proxy.User.Username()
+		password, _ := proxy.User.Password()
+		if len(username) > 255 || len(password) > 255 {
+			return errors.New("SOCKS proxy credentials are too long")
+		}
+		auth := append([]byte{0x01, byte(len(username))}, username...)
+		auth = append(append(auth, byte(len(password))), password...)
+		if _, err := conn.Write(auth); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			return err
+		}
+		if reply[1] != 0x00 {
+			return errors.New("SOCKS proxy refused the credentials")
+		}
+	}
+
+	request := []byte{socksVersion, socksConnectCommand, 0x00}
+	if ip := net.ParseIP(host); ip == nil {
+		if len(host) > 255 {
+			return errors.Errorf("host name %q is too long", host)
+		}
+		request = append(append(request, socksDomainName, byte(len(host))), host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		request = append(append(request, socksIPv4Address), ip4...)
+	} else {
+		request = append(append(request, socksIPv6Address), ip.To16()...)
+	}
+	request = append(request, 0, 0)
+	binary.BigEndian.PutUint16(request[len(request)-2:], uint16(port))
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	// The reply ends with the address bound by the proxy
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[1] != 0x00 {
+		return errors.Errorf("SOCKS proxy failed with code %d", header[1])
+	}
+	var length int
+	switch header[3] {
+	case socksIPv4Address:
+		length = net.IPv4len
+	case socksIPv6Address:
+		length = net.IPv6len
+	case socksDomainName:
+		size := make([]byte, 1)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return err
+		}
+		length = int(size[0])
+	default:
+		return errors.Errorf("unexpected address type %d in SOCKS reply", header[3])
+	}
+	_, err = io.ReadFull(conn, make([]byte, length+2))
+	return err
+}
@@ -0,0 +1,118 @@
+package bmcproxy
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// serve accepts one connection and handles it in the background.
+func serve(t *testing.T, handle func(conn net.Conn)) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handle(conn)
+	}()
+	return listener.Addr().String()
+}
+
+func TestDialHTTPProxy(t *testing.T) {
+	target := make(chan string, 1)
+	proxy := serve(t, func(conn net.Conn) {
+		request, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil {
+			return
+		}
+		target <- request.Method + " " + request.Host + " " + request.Header.Get("Proxy-Authorization")
+		io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		io.WriteString(conn, "hello")
+	})
+
+	conn, err := Dial(&url.URL{Scheme: "http", Host: proxy, User: url.UserPassword("user", "pass")},
+		"bmc.example.com:443", time.Second)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	assert.Equal(t, "CONNECT bmc.example.com:443 Basic dXNlcjpwYXNz", <-target)
+	greeting := make([]byte, 5)
+	_, err = io.ReadFull(conn, greeting)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(greeting))
+}
+
+func TestDialHTTPProxyRefused(t *testing.T) {
+	proxy := serve(t, func(conn net.Conn) {
+		if _, err := http.ReadRequest(bufio.NewReader(conn)); err == nil {
+			io.WriteString(conn, "HTTP/1.1 403 Forbidden\r\nContent-Length: 0\r\n\r\n")
+		}
+	})
+
+	_, err := Dial(&url.URL{Scheme: "http", Host: proxy}, "bmc.example.com:443", time.Second)
+	assert.Error(t, err)
+}
+
+func TestDialSOCKSProxy(t *testing.T) {
+	target := make(chan []byte, 1)
+	proxy := serve(t, func(conn net.Conn) {
+		greeting := make([]byte, 3)
+		if _, err := io.ReadFull(conn, greeting); err != nil {
+			return
+		}
+		conn.Write([]byte{socksVersion, socksNoAuth})
+		request := make([]byte, 10)
+		if _, err := io.ReadFull(conn, request); err != nil {
+			return
+		}
+		target <- request
+		conn.Write([]byte{socksVersion, 0x00, 0x00, socksIPv4Address, 127, 0, 0, 1, 0x04, 0x38})
+	})
+
+	conn, err := Dial(&url.URL{Scheme: "socks5", Host: proxy}, "192.168.122.1:443", time.Second)
+	if !assert.NoError(t, err) {
+		return
+	}
+	conn.Close()
+	assert.Equal(t, []byte{socksVersion, socksConnectCommand, 0x00, socksIPv4Address, 192, 168, 122, 1, 0x01, 0xbb}, <-target)
+}
+
+func TestDialSOCKSProxyFailure(t *testing.T) {
+	proxy := serve(t, func(conn net.Conn) {
+		greeting := make([]byte, 3)
+		if _, err := io.ReadFull(conn, greeting); err != nil {
+			return
+		}
+		conn.Write([]byte{socksVersion, socksNoAuth})
+		request := make([]byte, 22)
+		if _, err := io.ReadFull(conn, request); err != nil {
+			return
+		}
+		// Host unreachable
+		conn.Write([]byte{socksVersion, 0x04, 0x00, socksIPv4Address, 0, 0, 0, 0, 0, 0})
+	})
+
+	_, err := Dial(&url.URL{Scheme: "socks5h", Host: proxy}, "bmc.example.com:443", time.Second)
+	assert.Error(t, err)
+}
+
+func TestDialDirect(t *testing.T) {
+	target := serve(t, func(conn net.Conn) {})
+	conn, err := Dial(nil, target, time.Second)
+	if assert.NoError(t, err) {
+		conn.Close()
+	}
+}
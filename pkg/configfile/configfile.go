@@ -0,0 +1,86 @@
+// Package configfile reads the optional YAML configuration files of
+// the operator, such as the image mirrors or the BMC proxies, again
+// whenever they change, and matches hosts against the label selectors
+// they contain.
+package configfile
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+)
+
+// File is a configuration file that is only parsed again when its
+// modification time changes. It is not safe for concurrent use.
+type File struct {
+	path    string
+	name    string
+	modTime time.Time
+}
+
+// New returns the file at path, described by name in the errors, e.g.
+// "image mirrors". With an empty path, nothing is ever loaded.
+func New(path, name string) File {
+	return File{path: path, name: name}
+}
+
+// Path returns the path of the file.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Load unmarshals the file into config if it changed since it was last
+// loaded, and reports whether it did. The content is only accepted if
+// validate, when not nil, returns no error; otherwise the file is
+// parsed again on the next call. When the file is removed, config is
+// left untouched and the change is reported once, so that the caller
+// can drop the previous content.
+func (f *File) Load(config interface{}, validate func() error) (bool, error) {
+	if f.path == "" {
+		return false, nil
+	}
+	info, err := os.Stat(f.path)
+	if os.IsNotExist(err) {
+		removed := !f.modTime.IsZero()
+		f.modTime = time.Time{}
+		return removed, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "could not stat %s file", f.name)
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return false, nil
+	}
+	content, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return false, errors.Wrapf(err, "could not read %s file", f.name)
+	}
+	if err := yaml.Unmarshal(content, config); err != nil {
+		return false, errors.Wrapf(err, "could not parse %s file", f.name)
+	}
+	if validate != nil {
+		if err := validate(); err != nil {
+			return false, err
+		}
+	}
+	f.modTime = info.ModTime()
+	return true, nil
+}
+
+// MatchesHost reports whether a host with the given labels matches
+// selector. A nil selector matches every host.
+func MatchesHost(selector *metav1.LabelSelector, hostLabels map[string]string) (bool, error) {
+	if selector == nil {
+		return true, nil
+	}
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false, err
+	}
+	return s.Matches(labels.Set(hostLabels)), nil
+}
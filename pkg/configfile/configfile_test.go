@@ -0,0 +1,103 @@
+package configfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type testConfig struct {
+	Value string `json:"value"`
+}
+
+func writeFile(t *testing.T, path, content string, modTime time.Time) {
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	f := New(path, "test")
+	now := time.Now()
+
+	var config testConfig
+	changed, err := f.Load(&config, nil)
+	assert.NoError(t, err)
+	assert.False(t, changed, "missing file")
+
+	writeFile(t, path, "value: one\n", now)
+	changed, err = f.Load(&config, nil)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "one", config.Value)
+
+	config = testConfig{}
+	changed, err = f.Load(&config, nil)
+	assert.NoError(t, err)
+	assert.False(t, changed, "unchanged file")
+	assert.Empty(t, config.Value)
+
+	invalid := func() error { return errors.New("invalid") }
+	writeFile(t, path, "value: two\n", now.Add(time.Second))
+	for i := 0; i < 2; i++ {
+		_, err = f.Load(&config, invalid)
+		assert.Error(t, err, "an invalid file is parsed again")
+	}
+	changed, err = f.Load(&config, nil)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "two", config.Value)
+
+	writeFile(t, path, "value: [\n", now.Add(2*time.Second))
+	_, err = f.Load(&config, nil)
+	assert.Error(t, err)
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	changed, err = f.Load(&config, nil)
+	assert.NoError(t, err)
+	assert.True(t, changed, "removed file")
+	changed, err = f.Load(&config, nil)
+	assert.NoError(t, err)
+	assert.False(t, changed, "still removed")
+}
+
+func TestLoadWithoutPath(t *testing.T) {
+	f := New("", "test")
+	var config testConfig
+	changed, err := f.Load(&config, nil)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestMatchesHost(t *testing.T) {
+	hostLabels := map[string]string{"rack": "r1"}
+
+	match, err := MatchesHost(nil, hostLabels)
+	assert.NoError(t, err)
+	assert.True(t, match)
+
+	match, err = MatchesHost(&metav1.LabelSelector{MatchLabels: map[string]string{"rack": "r1"}}, hostLabels)
+	assert.NoError(t, err)
+	assert.True(t, match)
+
+	match, err = MatchesHost(&metav1.LabelSelector{MatchLabels: map[string]string{"rack": "r2"}}, hostLabels)
+	assert.NoError(t, err)
+	assert.False(t, match)
+
+	_, err = MatchesHost(&metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "rack", Operator: "Bogus"}},
+	}, hostLabels)
+	assert.Error(t, err)
+}
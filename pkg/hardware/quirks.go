@@ -0,0 +1,183 @@
+package hardware
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	logz "sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/metal3-io/baremetal-operator/pkg/configfile"
+)
+
+var log = logz.New().WithName("hardware").WithName("quirks")
+
+// Quirks holds the adjustments needed to work around the behavior
+// of a particular BMC vendor or model. Zero values mean "use the
+// provisioner default".
+type Quirks struct {
+	// Vendor is matched, case insensitively, as a substring of the
+	// manufacturer of the system, or of the BMC type when the
+	// manufacturer is not known yet.
+	Vendor string `json:"vendor"`
+
+	// Model is matched, case insensitively, as a substring of the
+	// product name of the system. An empty value matches any model.
+	Model string `json:"model,omitempty"`
+
+	// PowerRequeueDelay is how long to wait before checking the
+	// result of a power state change again.
+	PowerRequeueDelay Duration `json:"powerRequeueDelay,omitempty"`
+
+	// SoftPowerOffTimeout is how long the BMC is given to complete a
+	// soft power off before it is considered failed.
+	SoftPowerOffTimeout Duration `json:"softPowerOffTimeout,omitempty"`
+
+	// PowerConflictRetries is the number of times a power state
+	// change rejected because the node is locked is retried after a
+	// delay doubling from one second with each attempt, before the
+	// usual power requeue delay is used again.
+	PowerConflictRetries int `json:"powerConflictRetries,omitempty"`
+
+	// DisableSoftPowerOff makes the provisioner always use a hard
+	// power off, for BMCs that accept the soft power off request but
+	// never act on it.
+	DisableSoftPowerOff bool `json:"disableSoftPowerOff,omitempty"`
+
+	// PowerOnLatency and PowerOffLatency are how long the BMC usually
+	// takes to complete a power state change. The result of a change
+	// is first checked once that time has passed, and the change is
+	// not requested again before twice that time.
+	PowerOnLatency  Duration `json:"powerOnLatency,omitempty"`
+	PowerOffLatency Duration `json:"powerOffLatency,omitempty"`
+}
+
+// Duration is a time.Duration that is serialized as a string such
+// as "30s" or "2m".
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON parses a duration string.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "" || value == "null" {
+		d.Duration = 0
+		return nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return errors.Wrapf(err, "invalid duration %q", value)
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// MarshalJSON renders the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+func (q Quirks) matches(vendor, model string) bool {
+	if q.Vendor == "" || !strings.Contains(strings.ToLower(vendor), strings.ToLower(q.Vendor)) {
+		return false
+	}
+	return q.Model == "" || strings.Contains(strings.ToLower(model), strings.ToLower(q.Model))
+}
+
+// QuirksRegistry looks up the quirks for a vendor and model, using
+// the built-in values and an optional override file. The override
+// file is normally a ConfigMap mounted into the pod, and it is read
+// again whenever it changes so new quirks can be added without a
+// new release.
+type QuirksRegistry struct {
+	file     configfile.File
+	builtin  []Quirks
+	lock     sync.Mutex
+	override []Quirks
+}
+
+// builtinQuirks lists the quirks known at build time. Entries are
+// checked in order and the first match wins, so more specific entries
+// must come first.
+//
+// The power latencies are conservative defaults for vendors whose
+// BMCs are known to be slow to report power state changes. The same
+// BMCs keep the node locked for longer while Ironic talks to them, so
+// power changes rejected because of the lock are retried a few times
+// sooner than usual. Because only the first match is used, an override
+// entry for one of these vendors replaces the whole built-in entry.
+var builtinQuirks = []Quirks{
+	slowBMCProfile("dell", 30*time.Second, 20*time.Second),
+	slowBMCProfile("idrac", 30*time.Second, 20*time.Second),
+	slowBMCProfile("hpe", 20*time.Second, 15*time.Second),
+	slowBMCProfile("ilo", 20*time.Second, 15*time.Second),
+}
+
+// slowBMCPowerConflictRetries is the number of power changes retried
+// for slow BMCs when the node is locked.
+const slowBMCPowerConflictRetries = 2
+
+func slowBMCProfile(vendor string, powerOn, powerOff time.Duration) Quirks {
+	return Quirks{
+		Vendor:               vendor,
+		PowerConflictRetries: slowBMCPowerConflictRetries,
+		PowerOnLatency:       Duration{Duration: powerOn},
+		PowerOffLatency:      Duration{Duration: powerOff},
+	}
+}
+
+// NewQuirksRegistry returns a registry with the built-in quirks and
+// the overrides found in the file at path, if path is not empty.
+func NewQuirksRegistry(path string) *QuirksRegistry {
+	return &QuirksRegistry{
+		file:    configfile.New(path, "quirks"),
+		builtin: builtinQuirks,
+	}
+}
+
+var defaultRegistry = NewQuirksRegistry(os.Getenv("HARDWARE_QUIRKS_FILE"))
+
+// GetQuirks returns the quirks for the vendor and model from the
+// default registry, configured with the HARDWARE_QUIRKS_FILE
+// environment variable.
+func GetQuirks(vendor, model string) Quirks {
+	return defaultRegistry.Get(vendor, model)
+}
+
+// Get returns the quirks for the vendor and model. Entries from the
+// override file take precedence over the built-in ones. If nothing
+// matches, the zero value is returned.
+func (r *QuirksRegistry) Get(vendor, model string) Quirks {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.reload(); err != nil {
+		log.Error(err, "failed to load hardware quirks, using previous values",
+			"path", r.file.Path())
+	}
+
+	for _, entries := range [][]Quirks{r.override, r.builtin} {
+		for _, q := range entries {
+			if q.matches(vendor, model) {
+				return q
+			}
+		}
+	}
+	return Quirks{}
+}
+
+// reload reads the override file if it has changed since it was last
+// read. The caller must hold the lock.
+func (r *QuirksRegistry) reload() error {
+	var override []Quirks
+	changed, err := r.file.Load(&override, nil)
+	if err != nil || !changed {
+		return err
+	}
+	log.Info("loaded hardware quirks", "path", r.file.Path(), "count", len(override))
+	r.override = override
+	return nil
+}
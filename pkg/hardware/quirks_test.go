@@ -0,0 +1,119 @@
+package hardware
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuirksRegistryBuiltin(t *testing.T) {
+	r := NewQuirksRegistry("")
+	r.builtin = []Quirks{
+		{Vendor: "acme", Model: "r100", PowerConflictRetries: 3},
+		{Vendor: "acme", DisableSoftPowerOff: true},
+	}
+
+	cases := []struct {
+		name     string
+		vendor   string
+		model    string
+		expected Quirks
+	}{
+		{
+			name:     "vendor-and-model",
+			vendor:   "ACME Corp.",
+			model:    "R100 Server",
+			expected: r.builtin[0],
+		},
+		{
+			name:     "vendor-only",
+			vendor:   "Acme",
+			model:    "R200",
+			expected: r.builtin[1],
+		},
+		{
+			name:     "no-match",
+			vendor:   "other",
+			model:    "R100",
+			expected: Quirks{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, r.Get(tc.vendor, tc.model))
+		})
+	}
+}
+
+func TestQuirksRegistryOverride(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quirks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "quirks.yaml")
+
+	r := NewQuirksRegistry(path)
+	r.builtin = []Quirks{
+		{Vendor: "acme", PowerConflictRetries: 1},
+	}
+
+	// Without the file only the built-in values are used
+	assert.Equal(t, 1, r.Get("acme", "").PowerConflictRetries)
+
+	content := `
+- vendor: acme
+  powerConflictRetries: 5
+  softPowerOffTimeout: 5m
+  powerOnLatency: 45s
+`
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	q := r.Get("acme", "")
+	assert.Equal(t, 5, q.PowerConflictRetries)
+	assert.Equal(t, time.Minute*5, q.SoftPowerOffTimeout.Duration)
+	assert.Equal(t, time.Second*45, q.PowerOnLatency.Duration)
+
+	// A changed file is picked up without restarting
+	content = `
+- vendor: acme
+  disableSoftPowerOff: true
+`
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+	q = r.Get("acme", "")
+	assert.True(t, q.DisableSoftPowerOff)
+	assert.Equal(t, 0, q.PowerConflictRetries)
+
+	// A broken file keeps the previous values
+	if err := ioutil.WriteFile(path, []byte("not: [valid"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future = future.Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, r.Get("acme", "").DisableSoftPowerOff)
+}
+
+func TestBuiltinPowerLatency(t *testing.T) {
+	r := NewQuirksRegistry("")
+	for _, vendor := range []string{"Dell Inc.", "idrac-redfish", "HPE", "ilo5"} {
+		q := r.Get(vendor, "")
+		assert.NotZero(t, q.PowerOnLatency.Duration, vendor)
+		assert.NotZero(t, q.PowerOffLatency.Duration, vendor)
+		assert.Equal(t, slowBMCPowerConflictRetries, q.PowerConflictRetries, vendor)
+	}
+	assert.Equal(t, Quirks{}, r.Get("ipmi", ""))
+}
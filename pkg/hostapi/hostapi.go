@@ -0,0 +1,214 @@
+package hostapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// Host is the view of a BareMetalHost returned by the API. It leaves
+// out the BMC credentials and the details that only matter to the
+// controller.
+type Host struct {
+	Namespace         string                           `json:"namespace"`
+	Name              string                           `json:"name"`
+	ProvisioningState metal3v1alpha1.ProvisioningState `json:"provisioningState"`
+	OperationalStatus metal3v1alpha1.OperationalStatus `json:"operationalStatus"`
+	ErrorType         metal3v1alpha1.ErrorType         `json:"errorType,omitempty"`
+	ErrorMessage      string                           `json:"errorMessage,omitempty"`
+	Online            bool                             `json:"online"`
+	PoweredOn         bool                             `json:"poweredOn"`
+	BMCAddress        string                           `json:"bmcAddress,omitempty"`
+	BootMACAddress    string                           `json:"bootMACAddress,omitempty"`
+	Image             string                           `json:"image,omitempty"`
+	Consumer          string                           `json:"consumer,omitempty"`
+	Labels            map[string]string                `json:"labels,omitempty"`
+
+	// Hardware is only included when a single host is requested.
+	Hardware *metal3v1alpha1.HardwareDetails `json:"hardware,omitempty"`
+}
+
+func newHost(bmh *metal3v1alpha1.BareMetalHost, withHardware bool) Host {
+	host := Host{
+		Namespace:         bmh.Namespace,
+		Name:              bmh.Name,
+		ProvisioningState: bmh.Status.Provisioning.State,
+		OperationalStatus: bmh.Status.OperationalStatus,
+		ErrorType:         bmh.Status.ErrorType,
+		ErrorMessage:      bmh.Status.ErrorMessage,
+		Online:            bmh.Spec.Online,
+		PoweredOn:         bmh.Status.PoweredOn,
+		BMCAddress:        bmh.Spec.BMC.Address,
+		BootMACAddress:    bmh.Spec.BootMACAddress,
+		Image:             bmh.Status.Provisioning.Image.URL,
+		Labels:            bmh.Labels,
+	}
+	if ref := bmh.Spec.ConsumerRef; ref != nil {
+		host.Consumer = ref.Kind + "/" + ref.Namespace + "/" + ref.Name
+	}
+	if withHardware {
+		host.Hardware = bmh.Status.HardwareDetails
+	}
+	return host
+}
+
+// Server is a read-only REST API over the hosts known to the
+// operator, for tools that cannot use the Kubernetes API. It reads
+// from the same cache as the controller. Every request must carry
+// the token as a bearer token, so the API is served over TLS unless it
+// only listens on a loopback address.
+//
+//    GET /v1/hosts[?namespace=<namespace>]
+//    GET /v1/hosts/<namespace>/<name>
+type Server struct {
+	client  client.Reader
+	addr    string
+	token   string
+	certDir string
+	log     logr.Logger
+}
+
+// New returns a server listening on addr. When certDir is set, the
+// API is served over TLS with the tls.crt and tls.key files of that
+// directory; otherwise addr must be a loopback address.
+func New(c client.Reader, addr, token, certDir string, log logr.Logger) (*Server, error) {
+	if token == "" {
+		return nil, errors.New("a token is required to serve the host API")
+	}
+	if certDir == "" && !isLoopback(addr) {
+		return nil, errors.Errorf("the host API can only be served without TLS on a loopback address, not %q", addr)
+	}
+	return &Server{client: c, addr: addr, token: token, certDir: certDir, log: log}, nil
+}
+
+// isLoopback reports whether addr only listens on the loopback
+// interface.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Handler returns the HTTP handler for the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/hosts", s.authenticated(s.listHosts))
+	mux.HandleFunc("/v1/hosts/", s.authenticated(s.getHost))
+	return mux
+}
+
+// Start implements manager.Runnable, serving the API until the
+// context is done.
+func (s *Server) Start(ctx context.Context) error {
+	server := &http.Server{Addr: s.addr, Handler: s.Handler()}
+	errs := make(chan error, 1)
+	go func() {
+		s.log.Info("serving host API", "addr", s.addr, "tls", s.certDir != "")
+		if s.certDir != "" {
+			errs <- server.ListenAndServeTLS(filepath.Join(s.certDir, "tls.crt"), filepath.Join(s.certDir, "tls.key"))
+			return
+		}
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return errors.Wrap(err, "host API server failed")
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. The
+// API only reads, so every replica can serve it.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *Server) authenticated(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			s.writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		if r.Method != http.MethodGet {
+			s.writeError(w, http.StatusMethodNotAllowed, "only GET is supported")
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (s *Server) listHosts(w http.ResponseWriter, r *http.Request) {
+	var opts []client.ListOption
+	if namespace := r.URL.Query().Get("namespace"); namespace != "" {
+		opts = append(opts, client.InNamespace(namespace))
+	}
+	bmhs := &metal3v1alpha1.BareMetalHostList{}
+	if err := s.client.List(r.Context(), bmhs, opts...); err != nil {
+		s.log.Error(err, "failed to list hosts")
+		s.writeError(w, http.StatusInternalServerError, "failed to list hosts")
+		return
+	}
+
+	hosts := make([]Host, len(bmhs.Items))
+	for i := range bmhs.Items {
+		hosts[i] = newHost(&bmhs.Items[i], false)
+	}
+	s.writeJSON(w, http.StatusOK, map[string][]Host{"hosts": hosts})
+}
+
+func (s *Server) getHost(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/hosts/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		s.writeError(w, http.StatusNotFound, "expected /v1/hosts/<namespace>/<name>")
+		return
+	}
+
+	bmh := &metal3v1alpha1.BareMetalHost{}
+	err := s.client.Get(r.Context(), types.NamespacedName{Namespace: parts[0], Name: parts[1]}, bmh)
+	if k8serrors.IsNotFound(err) {
+		s.writeError(w, http.StatusNotFound, "host not found")
+		return
+	}
+	if err != nil {
+		s.log.Error(err, "failed to get host", "namespace", parts[0], "name", parts[1])
+		s.writeError(w, http.StatusInternalServerError, "failed to get host")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, newHost(bmh, true))
+}
+
+func (s *Server) writeError(w http.ResponseWriter, code int, message string) {
+	s.writeJSON(w, code, map[string]string{"error": message})
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.log.Error(err, "failed to write response")
+	}
+}
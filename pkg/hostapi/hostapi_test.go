@@ -0,0 +1,149 @@
+package hostapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func newTestServer(t *testing.T) *Server {
+	scheme := runtime.NewScheme()
+	if err := metal3v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	hosts := []runtime.Object{
+		&metal3v1alpha1.BareMetalHost{
+			ObjectMeta: metav1.ObjectMeta{Name: "host-0", Namespace: "site-a"},
+			Spec: metal3v1alpha1.BareMetalHostSpec{
+				BMC: metal3v1alpha1.BMCDetails{
+					Address:         "ipmi://192.168.122.1",
+					CredentialsName: "host-0-bmc-secret",
+				},
+				Online: true,
+			},
+			Status: metal3v1alpha1.BareMetalHostStatus{
+				Provisioning: metal3v1alpha1.ProvisionStatus{
+					State: metal3v1alpha1.StateProvisioned,
+				},
+				HardwareDetails: &metal3v1alpha1.HardwareDetails{Hostname: "host-0"},
+			},
+		},
+		&metal3v1alpha1.BareMetalHost{
+			ObjectMeta: metav1.ObjectMeta{Name: "host-1", Namespace: "site-b"},
+		},
+	}
+	c := fakeclient.NewFakeClientWithScheme(scheme, hosts...)
+	s, err := New(c, "127.0.0.1:0", "secret", "", ctrl.Log.WithName("hostapi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func doRequest(s *Server, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	return w
+}
+
+func TestNewRequiresToken(t *testing.T) {
+	_, err := New(nil, "127.0.0.1:0", "", "", ctrl.Log)
+	assert.Error(t, err)
+}
+
+func TestNewRequiresTLSOffLoopback(t *testing.T) {
+	for _, addr := range []string{":8090", "0.0.0.0:8090", "192.168.122.1:8090"} {
+		_, err := New(nil, addr, "secret", "", ctrl.Log)
+		assert.Error(t, err, addr)
+
+		_, err = New(nil, addr, "secret", "/tmp/k8s-webhook-server/serving-certs", ctrl.Log)
+		assert.NoError(t, err, addr)
+	}
+	for _, addr := range []string{"127.0.0.1:8090", "[::1]:8090", "localhost:8090"} {
+		_, err := New(nil, addr, "secret", "", ctrl.Log)
+		assert.NoError(t, err, addr)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	s := newTestServer(t)
+
+	assert.Equal(t, http.StatusUnauthorized, doRequest(s, http.MethodGet, "/v1/hosts", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, doRequest(s, http.MethodGet, "/v1/hosts", "wrong").Code)
+	assert.Equal(t, http.StatusOK, doRequest(s, http.MethodGet, "/v1/hosts", "secret").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, doRequest(s, http.MethodPost, "/v1/hosts", "secret").Code)
+}
+
+func TestListHosts(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, tc := range []struct {
+		Scenario string
+		Path     string
+		Expected []string
+	}{
+		{
+			Scenario: "all namespaces",
+			Path:     "/v1/hosts",
+			Expected: []string{"host-0", "host-1"},
+		},
+		{
+			Scenario: "one namespace",
+			Path:     "/v1/hosts?namespace=site-b",
+			Expected: []string{"host-1"},
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			w := doRequest(s, http.MethodGet, tc.Path, "secret")
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var body struct {
+				Hosts []Host `json:"hosts"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, host := range body.Hosts {
+				names = append(names, host.Name)
+				assert.Nil(t, host.Hardware)
+			}
+			assert.ElementsMatch(t, tc.Expected, names)
+		})
+	}
+}
+
+func TestGetHost(t *testing.T) {
+	s := newTestServer(t)
+
+	w := doRequest(s, http.MethodGet, "/v1/hosts/site-a/host-0", "secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "host-0-bmc-secret")
+
+	var host Host
+	if err := json.Unmarshal(w.Body.Bytes(), &host); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, metal3v1alpha1.StateProvisioned, host.ProvisioningState)
+	assert.Equal(t, "ipmi://192.168.122.1", host.BMCAddress)
+	assert.True(t, host.Online)
+	if assert.NotNil(t, host.Hardware) {
+		assert.Equal(t, "host-0", host.Hardware.Hostname)
+	}
+
+	assert.Equal(t, http.StatusNotFound, doRequest(s, http.MethodGet, "/v1/hosts/site-a/missing", "secret").Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(s, http.MethodGet, "/v1/hosts/site-a", "secret").Code)
+}
@@ -0,0 +1,197 @@
+// Package hostclient provides typed helpers over the controller-runtime
+// client for Go programs that integrate with the baremetal-operator, so
+// that they do not need to handle the metal3.io resources as
+// unstructured objects.
+package hostclient
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// DefaultPollInterval is the time between two reads of a host while
+// waiting for it, when no interval is given.
+const DefaultPollInterval = 10 * time.Second
+
+// NewScheme returns a scheme with the Kubernetes and the metal3.io
+// types, for building clients and caches.
+func NewScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := metal3v1alpha1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	return scheme, nil
+}
+
+// Client is a controller-runtime client with helpers for hosts.
+type Client struct {
+	client.Client
+}
+
+// New returns a client for the cluster of the REST configuration.
+func New(config *rest.Config) (*Client, error) {
+	scheme, err := NewScheme()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build scheme")
+	}
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create client")
+	}
+	return Wrap(c), nil
+}
+
+// Wrap adds the helpers to an existing client, whose scheme must
+// include the metal3.io types.
+func Wrap(c client.Client) *Client {
+	return &Client{Client: c}
+}
+
+// GetHost returns the host with the namespace and name of key.
+func (c *Client) GetHost(ctx context.Context, key types.NamespacedName) (*metal3v1alpha1.BareMetalHost, error) {
+	host := &metal3v1alpha1.BareMetalHost{}
+	if err := c.Get(ctx, key, host); err != nil {
+		return nil, errors.Wrapf(err, "failed to get host %s", key)
+	}
+	return host, nil
+}
+
+// ListHosts returns the hosts of the namespace, or of all namespaces
+// when it is empty, that match the selector. A nil selector matches
+// every host.
+func (c *Client) ListHosts(ctx context.Context, namespace string, selector labels.Selector) ([]metal3v1alpha1.BareMetalHost, error) {
+	opts := []client.ListOption{client.InNamespace(namespace)}
+	if selector != nil {
+		opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+	}
+	hosts := &metal3v1alpha1.BareMetalHostList{}
+	if err := c.List(ctx, hosts, opts...); err != nil {
+		return nil, errors.Wrap(err, "failed to list hosts")
+	}
+	return hosts.Items, nil
+}
+
+// updateAnnotations patches the annotations of a host, so that
+// concurrent changes to other fields are kept.
+func (c *Client) updateAnnotations(ctx context.Context, key types.NamespacedName, update func(annotations map[string]string)) error {
+	host, err := c.GetHost(ctx, key)
+	if err != nil {
+		return err
+	}
+	patch := client.MergeFrom(host.DeepCopy())
+	if host.Annotations == nil {
+		host.Annotations = make(map[string]string)
+	}
+	update(host.Annotations)
+	if err := c.Patch(ctx, host, patch); err != nil {
+		return errors.Wrapf(err, "failed to update annotations of host %s", key)
+	}
+	return nil
+}
+
+// SetAnnotation sets an annotation of a host, such as
+// metal3v1alpha1.PausedAnnotation.
+func (c *Client) SetAnnotation(ctx context.Context, key types.NamespacedName, name, value string) error {
+	return c.updateAnnotations(ctx, key, func(annotations map[string]string) {
+		annotations[name] = value
+	})
+}
+
+// RemoveAnnotation removes an annotation from a host.
+func (c *Client) RemoveAnnotation(ctx context.Context, key types.NamespacedName, name string) error {
+	return c.updateAnnotations(ctx, key, func(annotations map[string]string) {
+		delete(annotations, name)
+	})
+}
+
+// rebootAnnotation returns the name of the reboot annotation with the
+// suffix, which tells apart the clients asking for a reboot.
+func rebootAnnotation(suffix string) string {
+	if suffix == "" {
+		return metal3v1alpha1.RebootAnnotationPrefix
+	}
+	return metal3v1alpha1.RebootAnnotationPrefix + "/" + suffix
+}
+
+// SetRebootAnnotation asks for a reboot of the host in the mode. A
+// provisioned host stays powered off until the annotation is removed
+// with RemoveRebootAnnotation, except without a suffix, in which case
+// the operator powers the host on again and removes the annotation
+// itself.
+func (c *Client) SetRebootAnnotation(ctx context.Context, key types.NamespacedName, suffix string, mode metal3v1alpha1.RebootMode) error {
+	value, err := json.Marshal(metal3v1alpha1.RebootAnnotationArguments{Mode: mode})
+	if err != nil {
+		return err
+	}
+	return c.SetAnnotation(ctx, key, rebootAnnotation(suffix), string(value))
+}
+
+// RemoveRebootAnnotation removes the reboot annotation with the
+// suffix, letting the host be powered on again.
+func (c *Client) RemoveRebootAnnotation(ctx context.Context, key types.NamespacedName, suffix string) error {
+	return c.RemoveAnnotation(ctx, key, rebootAnnotation(suffix))
+}
+
+// WaitForHost reads the host every interval until done returns true
+// or an error, or the context is done. It returns the last host read.
+func (c *Client) WaitForHost(ctx context.Context, key types.NamespacedName, interval time.Duration,
+	done func(host *metal3v1alpha1.BareMetalHost) (bool, error)) (*metal3v1alpha1.BareMetalHost, error) {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	var host *metal3v1alpha1.BareMetalHost
+	err := wait.PollImmediateUntil(interval, func() (bool, error) {
+		var err error
+		host, err = c.GetHost(ctx, key)
+		if err != nil {
+			return false, err
+		}
+		return done(host)
+	}, ctx.Done())
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		err = errors.Wrapf(ctx.Err(), "stopped waiting for host %s", key)
+	}
+	return host, err
+}
+
+// WaitForCondition waits for the condition of the host to be True.
+func (c *Client) WaitForCondition(ctx context.Context, key types.NamespacedName, conditionType string, interval time.Duration) (*metal3v1alpha1.BareMetalHost, error) {
+	return c.WaitForHost(ctx, key, interval, func(host *metal3v1alpha1.BareMetalHost) (bool, error) {
+		return meta.IsStatusConditionTrue(host.Status.Conditions, conditionType), nil
+	})
+}
+
+// WaitForState waits for the host to reach the provisioning state.
+func (c *Client) WaitForState(ctx context.Context, key types.NamespacedName, state metal3v1alpha1.ProvisioningState, interval time.Duration) (*metal3v1alpha1.BareMetalHost, error) {
+	return c.WaitForHost(ctx, key, interval, func(host *metal3v1alpha1.BareMetalHost) (bool, error) {
+		return host.Status.Provisioning.State == state, nil
+	})
+}
+
+// WaitForProvisioned waits for an image to be written to the host. It
+// fails as soon as the provisioning does, without waiting for the
+// operator to retry it.
+func (c *Client) WaitForProvisioned(ctx context.Context, key types.NamespacedName, interval time.Duration) (*metal3v1alpha1.BareMetalHost, error) {
+	return c.WaitForHost(ctx, key, interval, func(host *metal3v1alpha1.BareMetalHost) (bool, error) {
+		if host.Status.ErrorType == metal3v1alpha1.ProvisioningError {
+			return false, errors.Errorf("provisioning host %s failed: %s", key, host.Status.ErrorMessage)
+		}
+		return meta.IsStatusConditionTrue(host.Status.Conditions, metal3v1alpha1.ProvisionedCondition), nil
+	})
+}
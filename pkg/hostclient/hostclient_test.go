@@ -0,0 +1,111 @@
+package hostclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func newTestClient(t *testing.T, hosts ...*metal3v1alpha1.BareMetalHost) *Client {
+	scheme, err := NewScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder := fakeclient.NewClientBuilder().WithScheme(scheme)
+	for _, host := range hosts {
+		builder = builder.WithObjects(host)
+	}
+	return Wrap(builder.Build())
+}
+
+func newHost(name string, labels map[string]string) *metal3v1alpha1.BareMetalHost {
+	return &metal3v1alpha1.BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "metal3", Labels: labels},
+	}
+}
+
+func TestListHosts(t *testing.T) {
+	c := newTestClient(t,
+		newHost("worker-0", map[string]string{"rack": "a"}),
+		newHost("worker-1", map[string]string{"rack": "b"}))
+
+	hosts, err := c.ListHosts(context.TODO(), "metal3", nil)
+	assert.NoError(t, err)
+	assert.Len(t, hosts, 2)
+
+	hosts, err = c.ListHosts(context.TODO(), "", labels.SelectorFromSet(labels.Set{"rack": "b"}))
+	if assert.NoError(t, err) && assert.Len(t, hosts, 1) {
+		assert.Equal(t, "worker-1", hosts[0].Name)
+	}
+
+	_, err = c.GetHost(context.TODO(), types.NamespacedName{Namespace: "metal3", Name: "worker-2"})
+	assert.Error(t, err)
+}
+
+func TestRebootAnnotation(t *testing.T) {
+	c := newTestClient(t, newHost("worker-0", nil))
+	key := types.NamespacedName{Namespace: "metal3", Name: "worker-0"}
+
+	assert.NoError(t, c.SetRebootAnnotation(context.TODO(), key, "remediation", metal3v1alpha1.RebootModeHard))
+	host, err := c.GetHost(context.TODO(), key)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"mode":"hard"}`, host.Annotations["reboot.metal3.io/remediation"])
+	}
+
+	assert.NoError(t, c.SetRebootAnnotation(context.TODO(), key, "", metal3v1alpha1.RebootModeSoft))
+	assert.NoError(t, c.RemoveRebootAnnotation(context.TODO(), key, "remediation"))
+	host, err = c.GetHost(context.TODO(), key)
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]string{"reboot.metal3.io": `{"mode":"soft"}`}, host.Annotations)
+	}
+}
+
+func TestWaitForProvisioned(t *testing.T) {
+	host := newHost("worker-0", nil)
+	meta.SetStatusCondition(&host.Status.Conditions, metav1.Condition{
+		Type:   metal3v1alpha1.ProvisionedCondition,
+		Status: metav1.ConditionTrue,
+		Reason: "Provisioned",
+	})
+	failed := newHost("worker-1", nil)
+	failed.Status.ErrorType = metal3v1alpha1.ProvisioningError
+	failed.Status.ErrorMessage = "image download failed"
+	pending := newHost("worker-2", nil)
+	c := newTestClient(t, host, failed, pending)
+
+	provisioned, err := c.WaitForProvisioned(context.TODO(), types.NamespacedName{Namespace: "metal3", Name: "worker-0"}, time.Millisecond)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "worker-0", provisioned.Name)
+	}
+
+	_, err = c.WaitForProvisioned(context.TODO(), types.NamespacedName{Namespace: "metal3", Name: "worker-1"}, time.Millisecond)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "image download failed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+	defer cancel()
+	_, err = c.WaitForProvisioned(ctx, types.NamespacedName{Namespace: "metal3", Name: "worker-2"}, time.Millisecond)
+	assert.Error(t, err)
+}
+
+func TestWaitForState(t *testing.T) {
+	host := newHost("worker-0", nil)
+	host.Status.Provisioning.State = metal3v1alpha1.StateReady
+	c := newTestClient(t, host)
+
+	ready, err := c.WaitForState(context.TODO(), types.NamespacedName{Namespace: "metal3", Name: "worker-0"},
+		metal3v1alpha1.StateReady, time.Millisecond)
+	if assert.NoError(t, err) {
+		assert.Equal(t, metal3v1alpha1.StateReady, ready.Status.Provisioning.State)
+	}
+}
@@ -0,0 +1,137 @@
+package imagemirror
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logz "sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/metal3-io/baremetal-operator/pkg/configfile"
+)
+
+var log = logz.New().WithName("imagemirror")
+
+// Mirror serves a copy of the images found under a source URL.
+type Mirror struct {
+	// Source is the URL prefix of the canonical location of the
+	// images, e.g. "https://images.example.com/".
+	Source string `json:"source"`
+	// URL replaces the source prefix in the URLs of the images.
+	URL string `json:"url"`
+}
+
+// Zone lists the mirrors nearest to the hosts matching its selector,
+// for example the hosts of a data center or rack.
+type Zone struct {
+	Name string `json:"name"`
+	// HostSelector matches the labels of the hosts in the zone. A
+	// nil selector matches every host.
+	HostSelector *metav1.LabelSelector `json:"hostSelector,omitempty"`
+	Mirrors      []Mirror              `json:"mirrors"`
+}
+
+// Config is the content of the image mirrors file.
+type Config struct {
+	Zones []Zone `json:"zones"`
+}
+
+func (z Zone) matches(hostLabels map[string]string) (bool, error) {
+	match, err := configfile.MatchesHost(z.HostSelector, hostLabels)
+	return match, errors.Wrapf(err, "invalid host selector in zone %s", z.Name)
+}
+
+// rewrite replaces the longest matching source prefix of imageURL by
+// its mirror.
+func (z Zone) rewrite(imageURL string) string {
+	var best *Mirror
+	for i, m := range z.Mirrors {
+		if strings.HasPrefix(imageURL, m.Source) && (best == nil || len(m.Source) > len(best.Source)) {
+			best = &z.Mirrors[i]
+		}
+	}
+	if best == nil {
+		return imageURL
+	}
+	return best.URL + strings.TrimPrefix(imageURL, best.Source)
+}
+
+func validate(config Config) error {
+	for _, z := range config.Zones {
+		for _, m := range z.Mirrors {
+			for _, value := range []string{m.Source, m.URL} {
+				parsed, err := url.Parse(value)
+				if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+					return errors.Errorf("mirror URL %q in zone %s is not an absolute URL", value, z.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Registry rewrites image URLs to the mirrors of the zone of a host,
+// from a configuration file that is read again whenever it changes.
+type Registry struct {
+	file   configfile.File
+	lock   sync.Mutex
+	config Config
+}
+
+// NewRegistry returns a registry reading the file at path. With an
+// empty path, URLs are never rewritten.
+func NewRegistry(path string) *Registry {
+	return &Registry{file: configfile.New(path, "image mirrors")}
+}
+
+var defaultRegistry = NewRegistry(os.Getenv("IMAGE_MIRRORS_FILE"))
+
+// Rewrite returns the URL to download the image at imageURL from for
+// the host, using the default registry configured with the
+// IMAGE_MIRRORS_FILE environment variable.
+func Rewrite(hostLabels map[string]string, imageURL string) string {
+	return defaultRegistry.Rewrite(hostLabels, imageURL)
+}
+
+// Rewrite returns the URL to download the image at imageURL from for
+// the host with the given labels. The first zone matching the host is
+// used, and the URL is returned unchanged if none of its mirrors has
+// the image.
+func (r *Registry) Rewrite(hostLabels map[string]string, imageURL string) string {
+	if imageURL == "" {
+		return imageURL
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if err := r.reload(); err != nil {
+		log.Error(err, "failed to load image mirrors, using previous values",
+			"path", r.file.Path())
+	}
+	for _, z := range r.config.Zones {
+		match, err := z.matches(hostLabels)
+		if err != nil {
+			log.Error(err, "skipping image mirror zone")
+			continue
+		}
+		if match {
+			return z.rewrite(imageURL)
+		}
+	}
+	return imageURL
+}
+
+// reload reads the file if it has changed since it was last
+// read. The caller must hold the lock.
+func (r *Registry) reload() error {
+	var config Config
+	changed, err := r.file.Load(&config, func() error { return validate(config) })
+	if err != nil || !changed {
+		return err
+	}
+	log.Info("loaded image mirrors", "path", r.file.Path(), "zones", len(config.Zones))
+	r.config = config
+	return nil
+}
@@ -0,0 +1,91 @@
+package imagemirror
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `
+zones:
+- name: dc1
+  hostSelector:
+    matchLabels:
+      topology.kubernetes.io/zone: dc1
+  mirrors:
+  - source: https://images.example.com/
+    url: http://mirror.dc1.example.com/images/
+  - source: https://images.example.com/rhcos/
+    url: http://rhcos.dc1.example.com/
+`
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "mirrors.yaml")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRewrite(t *testing.T) {
+	r := NewRegistry(writeConfig(t, testConfig))
+	dc1 := map[string]string{"topology.kubernetes.io/zone": "dc1"}
+
+	for _, tc := range []struct {
+		Scenario string
+		Labels   map[string]string
+		URL      string
+		Expected string
+	}{
+		{
+			Scenario: "mirrored",
+			Labels:   dc1,
+			URL:      "https://images.example.com/centos/centos8.qcow2",
+			Expected: "http://mirror.dc1.example.com/images/centos/centos8.qcow2",
+		},
+		{
+			Scenario: "longest source",
+			Labels:   dc1,
+			URL:      "https://images.example.com/rhcos/rhcos.qcow2.md5sum",
+			Expected: "http://rhcos.dc1.example.com/rhcos.qcow2.md5sum",
+		},
+		{
+			Scenario: "other source",
+			Labels:   dc1,
+			URL:      "https://other.example.com/image.qcow2",
+			Expected: "https://other.example.com/image.qcow2",
+		},
+		{
+			Scenario: "other zone",
+			Labels:   map[string]string{"topology.kubernetes.io/zone": "dc2"},
+			URL:      "https://images.example.com/centos/centos8.qcow2",
+			Expected: "https://images.example.com/centos/centos8.qcow2",
+		},
+		{
+			Scenario: "checksum value",
+			Labels:   dc1,
+			URL:      "97830b21ed272a3d854615beb54cf004",
+			Expected: "97830b21ed272a3d854615beb54cf004",
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, r.Rewrite(tc.Labels, tc.URL))
+		})
+	}
+}
+
+func TestRewriteInvalidFile(t *testing.T) {
+	r := NewRegistry(writeConfig(t, `
+zones:
+- name: dc1
+  mirrors:
+  - source: images.example.com
+    url: http://mirror.example.com/
+`))
+	assert.Equal(t, "images.example.com/image.qcow2", r.Rewrite(nil, "images.example.com/image.qcow2"))
+
+	assert.Equal(t, "https://images.example.com/a", NewRegistry("").Rewrite(nil, "https://images.example.com/a"))
+	assert.Equal(t, "https://images.example.com/a", NewRegistry("/does/not/exist").Rewrite(nil, "https://images.example.com/a"))
+}
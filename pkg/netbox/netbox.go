@@ -0,0 +1,156 @@
+package netbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// NamedRef is a reference to another NetBox object, as nested in the
+// objects returned by the API.
+type NamedRef struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Device is the part of a NetBox dcim device used for synchronizing
+// hosts.
+type Device struct {
+	ID           int                    `json:"id"`
+	Name         string                 `json:"name"`
+	Serial       string                 `json:"serial"`
+	Site         *NamedRef              `json:"site"`
+	Rack         *NamedRef              `json:"rack"`
+	Position     *float64               `json:"position"`
+	CustomFields map[string]interface{} `json:"custom_fields"`
+}
+
+// Interface is the part of a NetBox dcim interface used for
+// synchronizing hosts.
+type Interface struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	MACAddress string `json:"mac_address"`
+}
+
+type listResponse struct {
+	Count   int             `json:"count"`
+	Results json.RawMessage `json:"results"`
+}
+
+// Client talks to the NetBox REST API.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// New returns a client for the NetBox instance at baseURL, e.g.
+// "https://netbox.example.com", authenticating with the API token.
+func New(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *Client) do(method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode request")
+		}
+		reader = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return errors.Wrap(err, "failed to build request")
+	}
+	req.Header.Set("Authorization", "Token "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "%s %s failed", method, path)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("%s %s failed: %s", method, path, resp.Status)
+	}
+	if result == nil {
+		return nil
+	}
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(result),
+		"failed to decode response to %s %s", method, path)
+}
+
+// FindDeviceBySerial returns the device with the serial number, or
+// nil if there is none. It is an error for more than one device to
+// have the serial number.
+func (c *Client) FindDeviceBySerial(serial string) (*Device, error) {
+	var resp listResponse
+	if err := c.do(http.MethodGet, "/api/dcim/devices/?serial="+url.QueryEscape(serial), nil, &resp); err != nil {
+		return nil, err
+	}
+	var devices []Device
+	if err := json.Unmarshal(resp.Results, &devices); err != nil {
+		return nil, errors.Wrap(err, "failed to decode devices")
+	}
+	switch len(devices) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &devices[0], nil
+	default:
+		return nil, errors.Errorf("%d devices have serial number %s", len(devices), serial)
+	}
+}
+
+// UpdateDevice changes the given fields of the device.
+func (c *Client) UpdateDevice(id int, fields map[string]interface{}) error {
+	return c.do(http.MethodPatch, fmt.Sprintf("/api/dcim/devices/%d/", id), fields, nil)
+}
+
+// ListInterfaces returns the interfaces of the device.
+func (c *Client) ListInterfaces(deviceID int) ([]Interface, error) {
+	var resp listResponse
+	path := fmt.Sprintf("/api/dcim/interfaces/?device_id=%d&limit=0", deviceID)
+	if err := c.do(http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	var interfaces []Interface
+	if err := json.Unmarshal(resp.Results, &interfaces); err != nil {
+		return nil, errors.Wrap(err, "failed to decode interfaces")
+	}
+	return interfaces, nil
+}
+
+// CreateInterface adds an interface to the device.
+func (c *Client) CreateInterface(deviceID int, name, mac string) error {
+	return c.do(http.MethodPost, "/api/dcim/interfaces/", map[string]interface{}{
+		"device":      deviceID,
+		"name":        name,
+		"type":        "other",
+		"mac_address": mac,
+	}, nil)
+}
+
+// UpdateInterface changes the MAC address of an interface.
+func (c *Client) UpdateInterface(id int, mac string) error {
+	return c.do(http.MethodPatch, fmt.Sprintf("/api/dcim/interfaces/%d/", id), map[string]interface{}{
+		"mac_address": mac,
+	}, nil)
+}
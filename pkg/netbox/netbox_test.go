@@ -0,0 +1,65 @@
+package netbox
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindDeviceBySerial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Token secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "/api/dcim/devices/", r.URL.Path)
+		switch r.URL.Query().Get("serial") {
+		case "one":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 7, "serial": "one",
+				"rack": {"id": 1, "name": "r1"}, "position": 12.0}]}`))
+		case "two":
+			w.Write([]byte(`{"count": 2, "results": [{"id": 7}, {"id": 8}]}`))
+		case "fail":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte(`{"count": 0, "results": []}`))
+		}
+	}))
+	defer server.Close()
+	c := New(server.URL+"/", "secret")
+
+	device, err := c.FindDeviceBySerial("one")
+	if assert.NoError(t, err) && assert.NotNil(t, device) {
+		assert.Equal(t, 7, device.ID)
+		assert.Equal(t, "r1", device.Rack.Name)
+		assert.Equal(t, 12.0, *device.Position)
+	}
+
+	device, err = c.FindDeviceBySerial("none")
+	assert.NoError(t, err)
+	assert.Nil(t, device)
+
+	_, err = c.FindDeviceBySerial("two")
+	assert.Error(t, err)
+
+	_, err = c.FindDeviceBySerial("fail")
+	assert.Error(t, err)
+}
+
+func TestUpdateDevice(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Equal(t, "/api/dcim/devices/7/", r.URL.Path)
+		content, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(content, &body)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	c := New(server.URL, "secret")
+
+	err := c.UpdateDevice(7, map[string]interface{}{"serial": "abc"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"serial": "abc"}, body)
+}
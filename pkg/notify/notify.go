@@ -0,0 +1,275 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/util/workqueue"
+	logz "sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/metal3-io/baremetal-operator/pkg/configfile"
+)
+
+var log = logz.New().WithName("notify")
+
+// Milestone is a step in the lifecycle of a host that external
+// systems can be notified of.
+type Milestone string
+
+const (
+	// HostFailed is reached when an operation on the host fails.
+	HostFailed Milestone = "HostFailed"
+	// HostProvisioned is reached when an image has been written to
+	// the host.
+	HostProvisioned Milestone = "HostProvisioned"
+	// FirmwareUpdated is reached when a firmware update step has
+	// finished on the host.
+	FirmwareUpdated Milestone = "FirmwareUpdated"
+)
+
+// Notification describes a milestone reached by a host. It is the data
+// the body templates are executed with.
+type Notification struct {
+	Milestone Milestone         `json:"milestone"`
+	Namespace string            `json:"namespace"`
+	Host      string            `json:"host"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	State     string            `json:"state"`
+	Reason    string            `json:"reason"`
+	Message   string            `json:"message"`
+	Time      time.Time         `json:"time"`
+}
+
+// Target is an HTTP endpoint notified of some milestones, such as the
+// API of a ticketing system.
+type Target struct {
+	Name string `json:"name"`
+	// Namespaces limits the notifications to the hosts in these
+	// namespaces. Empty means every namespace.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Milestones lists the milestones to notify. Empty means every
+	// milestone.
+	Milestones []Milestone `json:"milestones,omitempty"`
+	URL        string      `json:"url"`
+	// Method is the HTTP method of the request. Default is POST.
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// AuthorizationEnv names an environment variable of the operator
+	// holding the value of the Authorization header, so that the
+	// credentials do not have to be stored in the file.
+	AuthorizationEnv string `json:"authorizationEnv,omitempty"`
+	// Body is a Go template of the body of the request, executed with
+	// a Notification. The json function quotes a value as JSON. By
+	// default the notification is sent as JSON.
+	Body string `json:"body,omitempty"`
+}
+
+// Config is the content of the notifications file.
+type Config struct {
+	Targets []Target `json:"targets"`
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		content, err := json.Marshal(value)
+		return string(content), err
+	},
+}
+
+func contains(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Target) matches(n Notification) bool {
+	milestones := make([]string, len(t.Milestones))
+	for i, m := range t.Milestones {
+		milestones[i] = string(m)
+	}
+	return contains(t.Namespaces, n.Namespace) && contains(milestones, string(n.Milestone))
+}
+
+func (t Target) body(n Notification) ([]byte, error) {
+	if t.Body == "" {
+		return json.Marshal(n)
+	}
+	tmpl, err := template.New(t.Name).Funcs(templateFuncs).Parse(t.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid body template in target %s", t.Name)
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, n); err != nil {
+		return nil, errors.Wrapf(err, "failed to render body of target %s", t.Name)
+	}
+	return body.Bytes(), nil
+}
+
+func validate(config Config) error {
+	for _, t := range config.Targets {
+		if t.URL == "" {
+			return errors.Errorf("target %s has no url", t.Name)
+		}
+		if t.Body != "" {
+			if _, err := template.New(t.Name).Funcs(templateFuncs).Parse(t.Body); err != nil {
+				return errors.Wrapf(err, "invalid body template in target %s", t.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// delivery is a notification to send to a target, queued until it
+// succeeds or runs out of attempts.
+type delivery struct {
+	target       Target
+	notification Notification
+}
+
+// Notifier sends notifications to the targets of a configuration
+// file that is read again whenever it changes. Failed requests are
+// retried with an exponential backoff.
+type Notifier struct {
+	maxAttempts int
+	http        *http.Client
+	queue       workqueue.RateLimitingInterface
+
+	lock   sync.Mutex
+	file   configfile.File
+	config Config
+}
+
+// NewNotifier returns a notifier for the targets in the file at path,
+// giving up on a notification after maxAttempts failed requests.
+func NewNotifier(path string, maxAttempts int) *Notifier {
+	return &Notifier{
+		file:        configfile.New(path, "notifications"),
+		maxAttempts: maxAttempts,
+		http:        &http.Client{Timeout: 30 * time.Second},
+		queue: workqueue.NewNamedRateLimitingQueue(
+			workqueue.NewItemExponentialFailureRateLimiter(5*time.Second, 10*time.Minute),
+			"notifications"),
+	}
+}
+
+// Notify queues the notification for every target interested in
+// it. It is safe to call on a nil notifier, which does nothing.
+func (n *Notifier) Notify(notification Notification) {
+	if n == nil {
+		return
+	}
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if err := n.reload(); err != nil {
+		log.Error(err, "failed to load notification targets, using previous values",
+			"path", n.file.Path())
+	}
+	for _, target := range n.config.Targets {
+		if target.matches(notification) {
+			n.queue.Add(&delivery{target: target, notification: notification})
+		}
+	}
+}
+
+// Start sends the queued notifications until the context is done. It
+// implements the Runnable interface of the manager.
+func (n *Notifier) Start(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		n.queue.ShutDown()
+	}()
+	for n.processNext() {
+	}
+	return nil
+}
+
+func (n *Notifier) processNext() bool {
+	item, shutdown := n.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer n.queue.Done(item)
+	d := item.(*delivery)
+
+	err := n.send(d)
+	if err == nil {
+		n.queue.Forget(item)
+		return true
+	}
+	attempts := n.queue.NumRequeues(item) + 1
+	if attempts >= n.maxAttempts {
+		log.Error(err, "giving up on notification", "target", d.target.Name,
+			"milestone", d.notification.Milestone, "host", d.notification.Namespace+"/"+d.notification.Host,
+			"attempts", attempts)
+		n.queue.Forget(item)
+		return true
+	}
+	log.Info("notification failed, will retry", "target", d.target.Name,
+		"milestone", d.notification.Milestone, "error", err.Error(), "attempts", attempts)
+	n.queue.AddRateLimited(item)
+	return true
+}
+
+func (n *Notifier) send(d *delivery) error {
+	body, err := d.target.body(d.notification)
+	if err != nil {
+		return err
+	}
+	method := d.target.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequest(method, d.target.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to build request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range d.target.Headers {
+		req.Header.Set(key, value)
+	}
+	if d.target.AuthorizationEnv != "" {
+		req.Header.Set("Authorization", os.Getenv(d.target.AuthorizationEnv))
+	}
+
+	resp, err := n.http.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "%s %s failed", method, d.target.URL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("%s %s failed: %s %s", method, d.target.URL, resp.Status,
+			strings.TrimSpace(string(message)))
+	}
+	log.Info("sent notification", "target", d.target.Name,
+		"milestone", d.notification.Milestone, "host", d.notification.Namespace+"/"+d.notification.Host)
+	return nil
+}
+
+// reload reads the file if it has changed since it was last
+// read. The caller must hold the lock.
+func (n *Notifier) reload() error {
+	var config Config
+	changed, err := n.file.Load(&config, func() error { return validate(config) })
+	if err != nil || !changed {
+		return err
+	}
+	log.Info("loaded notification targets", "path", n.file.Path(), "targets", len(config.Targets))
+	n.config = config
+	return nil
+}
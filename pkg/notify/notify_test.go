@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "notifications.yaml")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTargetMatches(t *testing.T) {
+	target := Target{
+		Namespaces: []string{"prod"},
+		Milestones: []Milestone{HostFailed},
+	}
+	assert.True(t, target.matches(Notification{Namespace: "prod", Milestone: HostFailed}))
+	assert.False(t, target.matches(Notification{Namespace: "dev", Milestone: HostFailed}))
+	assert.False(t, target.matches(Notification{Namespace: "prod", Milestone: HostProvisioned}))
+	assert.True(t, Target{}.matches(Notification{Namespace: "dev", Milestone: FirmwareUpdated}))
+}
+
+func TestTargetBody(t *testing.T) {
+	n := Notification{
+		Milestone: HostFailed,
+		Namespace: "prod",
+		Host:      "worker-0",
+		Message:   `Image provisioning failed: "disk" not found`,
+	}
+
+	body, err := Target{
+		Name: "jira",
+		Body: `{"summary": "{{.Host}} {{.Milestone}}", "description": {{json .Message}}}`,
+	}.body(n)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"summary": "worker-0 HostFailed", "description": "Image provisioning failed: \"disk\" not found"}`, string(body))
+
+	body, err = Target{Name: "default"}.body(n)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"milestone":"HostFailed"`)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	n := NewNotifier(writeConfig(t, `
+targets:
+- name: broken
+  url: http://tickets.example.com
+  body: '{{.Host'
+`), 3)
+	n.Notify(Notification{Milestone: HostFailed})
+	assert.Zero(t, n.queue.Len())
+}
+
+func TestNotifyRetries(t *testing.T) {
+	var lock sync.Mutex
+	var requests []string
+	received := func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]string{}, requests...)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := ioutil.ReadAll(r.Body)
+		lock.Lock()
+		defer lock.Unlock()
+		requests = append(requests, r.Header.Get("Authorization")+" "+string(content))
+		if len(requests) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	os.Setenv("TICKETS_TOKEN", "Bearer secret")
+	defer os.Unsetenv("TICKETS_TOKEN")
+	n := NewNotifier(writeConfig(t, `
+targets:
+- name: tickets
+  namespaces: [prod]
+  milestones: [HostProvisioned]
+  url: `+server.URL+`
+  authorizationEnv: TICKETS_TOKEN
+  body: '{{.Namespace}}/{{.Host}}'
+`), 3)
+	n.queue = workqueue.NewRateLimitingQueue(
+		workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, time.Millisecond))
+
+	n.Notify(Notification{Milestone: HostProvisioned, Namespace: "prod", Host: "worker-0"})
+	n.Notify(Notification{Milestone: HostProvisioned, Namespace: "dev", Host: "worker-1"})
+	n.Notify(Notification{Milestone: HostFailed, Namespace: "prod", Host: "worker-2"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		n.Start(ctx)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return n.queue.Len() == 0 && len(received()) == 2 },
+		5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	assert.Equal(t, []string{"Bearer secret prod/worker-0", "Bearer secret prod/worker-0"}, received())
+}
+
+func TestNotifyGivesUp(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	n := NewNotifier(writeConfig(t, "targets:\n- name: tickets\n  url: "+server.URL+"\n"), 2)
+	n.queue = workqueue.NewRateLimitingQueue(
+		workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, time.Millisecond))
+	n.Notify(Notification{Milestone: HostFailed, Namespace: "prod", Host: "worker-0"})
+
+	assert.True(t, n.processNext())
+	assert.True(t, n.processNext())
+	assert.Equal(t, 2, attempts)
+	assert.Zero(t, n.queue.Len())
+}
+
+func TestNilNotifier(t *testing.T) {
+	var n *Notifier
+	n.Notify(Notification{Milestone: HostFailed})
+}
@@ -112,9 +112,12 @@ func (p *demoProvisioner) ValidateManagementAccess(credentialsChanged, force boo
 // details of devices discovered on the hardware. It may be called
 // multiple times, and should return true for its dirty flag until the
 // inspection is completed.
-func (p *demoProvisioner) InspectHardware(force bool) (result provisioner.Result, details *metal3v1alpha1.HardwareDetails, err error) {
+func (p *demoProvisioner) InspectHardware(force, refresh bool) (result provisioner.Result, started bool, details *metal3v1alpha1.HardwareDetails, err error) {
 	p.log.Info("inspecting hardware", "status", p.host.OperationalStatus())
 
+	// A new inspection completes immediately
+	started = refresh
+
 	hostName := p.host.ObjectMeta.Name
 
 	if hostName == InspectingHost {
@@ -129,7 +132,7 @@ func (p *demoProvisioner) InspectHardware(force bool) (result provisioner.Result
 	// status for the server here until it is ready for us to get the
 	// inspection details. Simulate that for now by creating the
 	// hardware details struct as part of a second pass.
-	if p.host.Status.HardwareDetails == nil {
+	if p.host.Status.HardwareDetails == nil || refresh {
 		p.log.Info("continuing inspection by setting details")
 		details =
 			&metal3v1alpha1.HardwareDetails{
@@ -190,6 +193,30 @@ func (p *demoProvisioner) UpdateHardwareState() (hwState provisioner.HardwareSta
 	return
 }
 
+// GetBIOSSettings returns the current BIOS settings of the host.
+func (p *demoProvisioner) GetBIOSSettings() (settings map[string]string, err error) {
+	p.log.Info("getting BIOS settings")
+	return
+}
+
+// InspectOutOfBand reads the inventory of the host from its BMC.
+func (p *demoProvisioner) InspectOutOfBand() (details *metal3v1alpha1.HardwareDetails, err error) {
+	p.log.Info("inspecting hardware out-of-band")
+	return
+}
+
+// GetDriverStatus returns the driver used to manage the host.
+func (p *demoProvisioner) GetDriverStatus() (driver *metal3v1alpha1.DriverStatus, err error) {
+	p.log.Info("getting driver status")
+	return
+}
+
+// GetAgentVersion returns the version of the agent image of the host.
+func (p *demoProvisioner) GetAgentVersion() (version string, err error) {
+	p.log.Info("getting agent version")
+	return
+}
+
 // Prepare remove existing configuration and set new configuration
 func (p *demoProvisioner) Prepare(unprepared bool) (result provisioner.Result, started bool, err error) {
 	hostName := p.host.ObjectMeta.Name
@@ -213,6 +240,48 @@ func (p *demoProvisioner) Prepare(unprepared bool) (result provisioner.Result, s
 	return result, false, nil
 }
 
+// InstallBootCertificate installs the CA certificate of the boot server
+func (p *demoProvisioner) InstallBootCertificate(installed string) (result provisioner.Result, fingerprint string, err error) {
+	p.log.Info("installing boot certificate")
+	return result, installed, nil
+}
+
+// UpdateSecureBootDatabase updates a secure boot database of the host
+func (p *demoProvisioner) UpdateSecureBootDatabase(update metal3v1alpha1.SecureBootDatabaseUpdate) (err error) {
+	p.log.Info("updating secure boot database", "database", update.Database)
+	return nil
+}
+
+// DetectVirtualMedia reads the virtual media features of the BMC
+func (p *demoProvisioner) DetectVirtualMedia() (support *metal3v1alpha1.VirtualMediaSupport, err error) {
+	p.log.Info("detecting virtual media support")
+	return nil, nil
+}
+
+// CleanBootArtifacts removes the boot configuration left behind
+func (p *demoProvisioner) CleanBootArtifacts() (residue []string, err error) {
+	p.log.Info("cleaning boot artifacts")
+	return nil, nil
+}
+
+// ProtectBootOrder makes the host boot from its disks
+func (p *demoProvisioner) ProtectBootOrder() (corrected []string, err error) {
+	p.log.Info("protecting boot order")
+	return nil, nil
+}
+
+// SetTags sets the tags of the host
+func (p *demoProvisioner) SetTags(tags, previous []string) (conflicts []string, err error) {
+	p.log.Info("setting tags", "tags", tags)
+	return nil, nil
+}
+
+// Erase securely erases all of the disks of the host
+func (p *demoProvisioner) Erase(start bool, opts provisioner.EraseOptions) (result provisioner.Result, started bool, err error) {
+	p.log.Info("erasing host")
+	return result, start, nil
+}
+
 // Adopt allows an externally-provisioned server to be adopted.
 func (p *demoProvisioner) Adopt(force bool) (result provisioner.Result, err error) {
 	p.log.Info("adopting host")
@@ -223,7 +292,7 @@ func (p *demoProvisioner) Adopt(force bool) (result provisioner.Result, err erro
 // Provision writes the image from the host spec to the host. It may
 // be called multiple times, and should return true for its dirty flag
 // until the deprovisioning operation is completed.
-func (p *demoProvisioner) Provision(hostConf provisioner.HostConfigData) (result provisioner.Result, err error) {
+func (p *demoProvisioner) Provision(hostConf provisioner.HostConfigData, requestID string) (result provisioner.Result, err error) {
 
 	hostName := p.host.ObjectMeta.Name
 	p.log.Info("provisioning image to host", "state", p.host.Status.Provisioning.State)
@@ -249,7 +318,7 @@ func (p *demoProvisioner) Provision(hostConf provisioner.HostConfigData) (result
 // Deprovision removes the host from the image. It may be called
 // multiple times, and should return true for its dirty flag until the
 // deprovisioning operation is completed.
-func (p *demoProvisioner) Deprovision(force bool) (result provisioner.Result, err error) {
+func (p *demoProvisioner) Deprovision(force bool, requestID string) (result provisioner.Result, err error) {
 
 	hostName := p.host.ObjectMeta.Name
 	switch hostName {
@@ -295,7 +364,7 @@ func (p *demoProvisioner) Delete() (result provisioner.Result, err error) {
 
 // PowerOn ensures the server is powered on independently of any image
 // provisioning operation.
-func (p *demoProvisioner) PowerOn() (result provisioner.Result, err error) {
+func (p *demoProvisioner) PowerOn(requestID string) (result provisioner.Result, err error) {
 
 	hostName := p.host.ObjectMeta.Name
 	switch hostName {
@@ -316,7 +385,7 @@ func (p *demoProvisioner) PowerOn() (result provisioner.Result, err error) {
 
 // PowerOff ensures the server is powered off independently of any image
 // provisioning operation.
-func (p *demoProvisioner) PowerOff(rebootMode metal3v1alpha1.RebootMode) (result provisioner.Result, err error) {
+func (p *demoProvisioner) PowerOff(rebootMode metal3v1alpha1.RebootMode, requestID string) (result provisioner.Result, err error) {
 
 	hostName := p.host.ObjectMeta.Name
 	switch hostName {
@@ -29,8 +29,8 @@ func (p *emptyProvisioner) ValidateManagementAccess(credentialsChanged, force bo
 // details of devices discovered on the hardware. It may be called
 // multiple times, and should return true for its dirty flag until the
 // inspection is completed.
-func (p *emptyProvisioner) InspectHardware(force bool) (provisioner.Result, *metal3v1alpha1.HardwareDetails, error) {
-	return provisioner.Result{}, nil, nil
+func (p *emptyProvisioner) InspectHardware(force, refresh bool) (provisioner.Result, bool, *metal3v1alpha1.HardwareDetails, error) {
+	return provisioner.Result{}, refresh, nil, nil
 }
 
 // UpdateHardwareState fetches the latest hardware state of the server
@@ -41,6 +41,26 @@ func (p *emptyProvisioner) UpdateHardwareState() (provisioner.HardwareState, err
 	return provisioner.HardwareState{}, nil
 }
 
+// GetBIOSSettings returns the current BIOS settings of the host.
+func (p *emptyProvisioner) GetBIOSSettings() (map[string]string, error) {
+	return nil, nil
+}
+
+// InspectOutOfBand reads the inventory of the host from its BMC.
+func (p *emptyProvisioner) InspectOutOfBand() (*metal3v1alpha1.HardwareDetails, error) {
+	return nil, nil
+}
+
+// GetDriverStatus returns the driver used to manage the host.
+func (p *emptyProvisioner) GetDriverStatus() (*metal3v1alpha1.DriverStatus, error) {
+	return nil, nil
+}
+
+// GetAgentVersion returns the version of the agent image of the host.
+func (p *emptyProvisioner) GetAgentVersion() (string, error) {
+	return "", nil
+}
+
 // Adopt allows an externally-provisioned server to be adopted.
 func (p *emptyProvisioner) Adopt(force bool) (provisioner.Result, error) {
 	return provisioner.Result{}, nil
@@ -51,17 +71,52 @@ func (p *emptyProvisioner) Prepare(unprepared bool) (result provisioner.Result,
 	return provisioner.Result{}, false, nil
 }
 
+// InstallBootCertificate installs the CA certificate of the boot server
+func (p *emptyProvisioner) InstallBootCertificate(installed string) (provisioner.Result, string, error) {
+	return provisioner.Result{}, "", nil
+}
+
+// UpdateSecureBootDatabase updates a secure boot database of the host
+func (p *emptyProvisioner) UpdateSecureBootDatabase(update metal3v1alpha1.SecureBootDatabaseUpdate) error {
+	return nil
+}
+
+// DetectVirtualMedia reads the virtual media features of the BMC
+func (p *emptyProvisioner) DetectVirtualMedia() (*metal3v1alpha1.VirtualMediaSupport, error) {
+	return nil, nil
+}
+
+// CleanBootArtifacts removes the boot configuration left behind
+func (p *emptyProvisioner) CleanBootArtifacts() ([]string, error) {
+	return nil, nil
+}
+
+// ProtectBootOrder makes the host boot from its disks
+func (p *emptyProvisioner) ProtectBootOrder() ([]string, error) {
+	return nil, nil
+}
+
+// SetTags sets the tags of the host
+func (p *emptyProvisioner) SetTags(tags, previous []string) ([]string, error) {
+	return nil, nil
+}
+
+// Erase securely erases all of the disks of the host
+func (p *emptyProvisioner) Erase(start bool, opts provisioner.EraseOptions) (result provisioner.Result, started bool, err error) {
+	return provisioner.Result{}, false, nil
+}
+
 // Provision writes the image from the host spec to the host. It may
 // be called multiple times, and should return true for its dirty flag
 // until the deprovisioning operation is completed.
-func (p *emptyProvisioner) Provision(hostConf provisioner.HostConfigData) (provisioner.Result, error) {
+func (p *emptyProvisioner) Provision(hostConf provisioner.HostConfigData, requestID string) (provisioner.Result, error) {
 	return provisioner.Result{}, nil
 }
 
 // Deprovision removes the host from the image. It may be called
 // multiple times, and should return true for its dirty flag until the
 // deprovisioning operation is completed.
-func (p *emptyProvisioner) Deprovision(force bool) (provisioner.Result, error) {
+func (p *emptyProvisioner) Deprovision(force bool, requestID string) (provisioner.Result, error) {
 	return provisioner.Result{}, nil
 }
 
@@ -74,13 +129,13 @@ func (p *emptyProvisioner) Delete() (provisioner.Result, error) {
 
 // PowerOn ensures the server is powered on independently of any image
 // provisioning operation.
-func (p *emptyProvisioner) PowerOn() (provisioner.Result, error) {
+func (p *emptyProvisioner) PowerOn(requestID string) (provisioner.Result, error) {
 	return provisioner.Result{}, nil
 }
 
 // PowerOff ensures the server is powered off independently of any image
 // provisioning operation.
-func (p *emptyProvisioner) PowerOff(rebootMode metal3v1alpha1.RebootMode) (provisioner.Result, error) {
+func (p *emptyProvisioner) PowerOff(rebootMode metal3v1alpha1.RebootMode, requestID string) (provisioner.Result, error) {
 	return provisioner.Result{}, nil
 }
 
@@ -108,14 +108,17 @@ func (p *fixtureProvisioner) ValidateManagementAccess(credentialsChanged, force
 // details of devices discovered on the hardware. It may be called
 // multiple times, and should return true for its dirty flag until the
 // inspection is completed.
-func (p *fixtureProvisioner) InspectHardware(force bool) (result provisioner.Result, details *metal3v1alpha1.HardwareDetails, err error) {
+func (p *fixtureProvisioner) InspectHardware(force, refresh bool) (result provisioner.Result, started bool, details *metal3v1alpha1.HardwareDetails, err error) {
 	p.log.Info("inspecting hardware", "status", p.host.OperationalStatus())
 
+	// A new inspection completes immediately
+	started = refresh
+
 	// The inspection is ongoing. We'll need to check the fixture
 	// status for the server here until it is ready for us to get the
 	// inspection details. Simulate that for now by creating the
 	// hardware details struct as part of a second pass.
-	if p.host.Status.HardwareDetails == nil {
+	if p.host.Status.HardwareDetails == nil || refresh {
 		p.log.Info("continuing inspection by setting details")
 		details =
 			&metal3v1alpha1.HardwareDetails{
@@ -124,7 +127,7 @@ func (p *fixtureProvisioner) InspectHardware(force bool) (result provisioner.Res
 					{
 						Name:      "nic-1",
 						Model:     "virt-io",
-						MAC:       "some:mac:address",
+						MAC:       "00:5c:52:31:3a:9c",
 						IP:        "192.168.100.1",
 						SpeedGbps: 1,
 						PXE:       true,
@@ -132,7 +135,7 @@ func (p *fixtureProvisioner) InspectHardware(force bool) (result provisioner.Res
 					{
 						Name:      "nic-2",
 						Model:     "e1000",
-						MAC:       "some:other:mac:address",
+						MAC:       "00:5c:52:31:3a:9d",
 						IP:        "192.168.100.2",
 						SpeedGbps: 1,
 						PXE:       false,
@@ -178,12 +181,100 @@ func (p *fixtureProvisioner) UpdateHardwareState() (hwState provisioner.Hardware
 	return
 }
 
+// GetBIOSSettings returns the current BIOS settings of the host.
+func (p *fixtureProvisioner) GetBIOSSettings() (settings map[string]string, err error) {
+	p.log.Info("getting BIOS settings")
+	settings = map[string]string{
+		"LogicalProc":        "Enabled",
+		"ProcVirtualization": "Enabled",
+		"SriovGlobalEnable":  "Disabled",
+		"Boot Mode (UEFI)":   "Enabled",
+	}
+	return
+}
+
+// InspectOutOfBand returns the hardware details of the host, with
+// the RAM the BMC reports.
+func (p *fixtureProvisioner) InspectOutOfBand() (details *metal3v1alpha1.HardwareDetails, err error) {
+	p.log.Info("inspecting hardware out-of-band")
+	details = &metal3v1alpha1.HardwareDetails{}
+	if p.host.Status.HardwareDetails != nil {
+		details = p.host.Status.HardwareDetails.DeepCopy()
+	}
+	details.RAMMebibytes = 128 * 1024
+	p.publisher("OutOfBandInspectionComplete", "Hardware inventory read from the BMC")
+	return
+}
+
+// GetDriverStatus returns the driver used to manage the host.
+func (p *fixtureProvisioner) GetDriverStatus() (driver *metal3v1alpha1.DriverStatus, err error) {
+	p.log.Info("getting driver status")
+	driver = &metal3v1alpha1.DriverStatus{
+		Name: "fake-hardware",
+		Interfaces: metal3v1alpha1.DriverInterfaces{
+			Boot:       "fake",
+			Deploy:     "fake",
+			Management: "fake",
+			Power:      "fake",
+		},
+	}
+	return
+}
+
+// GetAgentVersion returns the version of the agent image of the host.
+func (p *fixtureProvisioner) GetAgentVersion() (version string, err error) {
+	p.log.Info("getting agent version")
+	return
+}
+
 // Prepare remove existing configuration and set new configuration
 func (p *fixtureProvisioner) Prepare(unprepared bool) (result provisioner.Result, started bool, err error) {
 	p.log.Info("preparing host")
 	return
 }
 
+// InstallBootCertificate installs the CA certificate of the boot server
+func (p *fixtureProvisioner) InstallBootCertificate(installed string) (result provisioner.Result, fingerprint string, err error) {
+	p.log.Info("installing boot certificate")
+	return result, installed, nil
+}
+
+// UpdateSecureBootDatabase updates a secure boot database of the host
+func (p *fixtureProvisioner) UpdateSecureBootDatabase(update metal3v1alpha1.SecureBootDatabaseUpdate) (err error) {
+	p.log.Info("updating secure boot database", "database", update.Database)
+	return nil
+}
+
+// DetectVirtualMedia reads the virtual media features of the BMC
+func (p *fixtureProvisioner) DetectVirtualMedia() (support *metal3v1alpha1.VirtualMediaSupport, err error) {
+	p.log.Info("detecting virtual media support")
+	return nil, nil
+}
+
+// CleanBootArtifacts removes the boot configuration left behind
+func (p *fixtureProvisioner) CleanBootArtifacts() (residue []string, err error) {
+	p.log.Info("cleaning boot artifacts")
+	return nil, nil
+}
+
+// ProtectBootOrder makes the host boot from its disks
+func (p *fixtureProvisioner) ProtectBootOrder() (corrected []string, err error) {
+	p.log.Info("protecting boot order")
+	return nil, nil
+}
+
+// SetTags sets the tags of the host
+func (p *fixtureProvisioner) SetTags(tags, previous []string) (conflicts []string, err error) {
+	p.log.Info("setting tags", "tags", tags)
+	return nil, nil
+}
+
+// Erase securely erases all of the disks of the host
+func (p *fixtureProvisioner) Erase(start bool, opts provisioner.EraseOptions) (result provisioner.Result, started bool, err error) {
+	p.log.Info("erasing host")
+	return result, start, nil
+}
+
 // Adopt allows an externally-provisioned server to be adopted.
 func (p *fixtureProvisioner) Adopt(force bool) (result provisioner.Result, err error) {
 	p.log.Info("adopting host")
@@ -198,7 +289,7 @@ func (p *fixtureProvisioner) Adopt(force bool) (result provisioner.Result, err e
 // Provision writes the image from the host spec to the host. It may
 // be called multiple times, and should return true for its dirty flag
 // until the deprovisioning operation is completed.
-func (p *fixtureProvisioner) Provision(hostConf provisioner.HostConfigData) (result provisioner.Result, err error) {
+func (p *fixtureProvisioner) Provision(hostConf provisioner.HostConfigData, requestID string) (result provisioner.Result, err error) {
 	p.log.Info("provisioning image to host",
 		"state", p.host.Status.Provisioning.State)
 
@@ -216,7 +307,7 @@ func (p *fixtureProvisioner) Provision(hostConf provisioner.HostConfigData) (res
 // Deprovision removes the host from the image. It may be called
 // multiple times, and should return true for its dirty flag until the
 // deprovisioning operation is completed.
-func (p *fixtureProvisioner) Deprovision(force bool) (result provisioner.Result, err error) {
+func (p *fixtureProvisioner) Deprovision(force bool, requestID string) (result provisioner.Result, err error) {
 	p.log.Info("ensuring host is deprovisioned")
 
 	result.RequeueAfter = deprovisionRequeueDelay
@@ -256,7 +347,7 @@ func (p *fixtureProvisioner) Delete() (result provisioner.Result, err error) {
 
 // PowerOn ensures the server is powered on independently of any image
 // provisioning operation.
-func (p *fixtureProvisioner) PowerOn() (result provisioner.Result, err error) {
+func (p *fixtureProvisioner) PowerOn(requestID string) (result provisioner.Result, err error) {
 	p.log.Info("ensuring host is powered on")
 
 	if !p.state.poweredOn {
@@ -272,7 +363,7 @@ func (p *fixtureProvisioner) PowerOn() (result provisioner.Result, err error) {
 
 // PowerOff ensures the server is powered off independently of any image
 // provisioning operation.
-func (p *fixtureProvisioner) PowerOff(rebootMode metal3v1alpha1.RebootMode) (result provisioner.Result, err error) {
+func (p *fixtureProvisioner) PowerOff(rebootMode metal3v1alpha1.RebootMode, requestID string) (result provisioner.Result, err error) {
 	p.log.Info("ensuring host is powered off")
 
 	if p.state.poweredOn {
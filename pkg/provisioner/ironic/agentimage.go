@@ -0,0 +1,160 @@
+package ironic
+
+import (
+	"sort"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/pkg/errors"
+
+	"github.com/metal3-io/baremetal-operator/pkg/agentimage"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// agentImage returns the deployment agent image configured for the
+// host in the agent images file, or nil when no group matches the
+// host. ready is false until the files of the image have been
+// verified against their checksums.
+func (p *ironicProvisioner) agentImage() (img *agentimage.Image, ready bool, err error) {
+	selected, ok := agentimage.ForHost(p.host.Labels, string(p.host.UID))
+	if !ok {
+		return nil, true, nil
+	}
+	if err = airGapAgentImageError(selected); err != nil {
+		return nil, false, err
+	}
+	ready, err = agentimage.Verify(selected)
+	if err != nil || !ready {
+		return nil, ready, err
+	}
+	p.log.Info("using agent image", "version", selected.Version)
+	return &selected, true, nil
+}
+
+// agentImageSettings returns the driver_info settings for the
+// deployment agent. Without an agent image, the URLs of the
+// provisioning network or else the DEPLOY_KERNEL_URL and
+// DEPLOY_RAMDISK_URL settings are used.
+func agentImageSettings(img *agentimage.Image, network *ProvisioningNetwork) map[string]string {
+	settings := map[string]string{
+		"deploy_kernel":  deployKernelURL,
+		"deploy_ramdisk": deployRamdiskURL,
+	}
+	if network != nil {
+		if network.DeployKernelURL != "" {
+			settings["deploy_kernel"] = network.DeployKernelURL
+		}
+		if network.DeployRamdiskURL != "" {
+			settings["deploy_ramdisk"] = network.DeployRamdiskURL
+		}
+		if network.ExternalHTTPURL != "" {
+			settings["external_http_url"] = network.ExternalHTTPURL
+		}
+	}
+	if img == nil {
+		return settings
+	}
+	if img.KernelURL != "" {
+		settings["deploy_kernel"] = img.KernelURL
+	}
+	if img.RamdiskURL != "" {
+		settings["deploy_ramdisk"] = img.RamdiskURL
+	}
+	if img.ISOURL != "" {
+		settings["deploy_iso"] = img.ISOURL
+	}
+	return settings
+}
+
+// agentVersionKey is the key of the extra field of the node
+// recording the version of the agent image applied to it.
+const agentVersionKey = "agent_version"
+
+// agentImageUpdates returns the changes needed to bring the agent
+// settings of an existing node up to date, for example when a new
+// agent version is rolled out, or back to the defaults when the host
+// no longer uses an agent image.
+func agentImageUpdates(ironicNode *nodes.Node, img *agentimage.Image, settings map[string]string) (updates nodes.UpdateOpts) {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if current, _ := ironicNode.DriverInfo[key].(string); current == settings[key] {
+			continue
+		}
+		updates = append(updates, nodes.UpdateOperation{
+			Op:    nodes.AddOp,
+			Path:  "/driver_info/" + key,
+			Value: settings[key],
+		})
+	}
+	if _, found := ironicNode.DriverInfo["deploy_iso"]; found && settings["deploy_iso"] == "" {
+		updates = append(updates, nodes.UpdateOperation{
+			Op:   nodes.RemoveOp,
+			Path: "/driver_info/deploy_iso",
+		})
+	}
+	return append(updates, agentVersionUpdates(ironicNode, img)...)
+}
+
+// agentVersionExtra returns the extra field of a new node using the
+// agent image.
+func agentVersionExtra(img *agentimage.Image) map[string]interface{} {
+	if img == nil {
+		return nil
+	}
+	return map[string]interface{}{agentVersionKey: img.Version}
+}
+
+// agentVersionUpdates returns the changes to the agent version
+// recorded on the node.
+func agentVersionUpdates(ironicNode *nodes.Node, img *agentimage.Image) nodes.UpdateOpts {
+	current, found := ironicNode.Extra[agentVersionKey]
+	switch {
+	case img != nil && current != img.Version:
+		return nodes.UpdateOpts{
+			nodes.UpdateOperation{
+				Op:    nodes.AddOp,
+				Path:  "/extra/" + agentVersionKey,
+				Value: img.Version,
+			},
+		}
+	case img == nil && found:
+		return nodes.UpdateOpts{
+			nodes.UpdateOperation{
+				Op:   nodes.RemoveOp,
+				Path: "/extra/" + agentVersionKey,
+			},
+		}
+	}
+	return nil
+}
+
+// staleAgentImage reports whether the node still has the settings of
+// an agent image the host no longer uses.
+func staleAgentImage(ironicNode *nodes.Node, img *agentimage.Image) bool {
+	if img != nil {
+		return false
+	}
+	_, versioned := ironicNode.Extra[agentVersionKey]
+	_, iso := ironicNode.DriverInfo["deploy_iso"]
+	return versioned || iso
+}
+
+// GetAgentVersion returns the version of the agent image applied to
+// the node.
+func (p *ironicProvisioner) GetAgentVersion() (string, error) {
+	p.debugLog.Info("getting agent version")
+
+	ironicNode, err := p.findExistingHost()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to find existing host")
+	}
+	if ironicNode == nil {
+		return "", provisioner.NeedsRegistration
+	}
+	version, _ := ironicNode.Extra[agentVersionKey].(string)
+	return version, nil
+}
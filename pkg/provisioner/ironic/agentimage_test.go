@@ -0,0 +1,63 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/agentimage"
+)
+
+func TestAgentImageUpdates(t *testing.T) {
+	img := &agentimage.Image{
+		Version:   "8.1",
+		KernelURL: "http://images/8.1/ipa.kernel",
+		ISOURL:    "http://images/8.1/ipa.iso",
+	}
+	settings := agentImageSettings(img, nil)
+	assert.Equal(t, map[string]string{
+		"deploy_kernel":  "http://images/8.1/ipa.kernel",
+		"deploy_ramdisk": deployRamdiskURL,
+		"deploy_iso":     "http://images/8.1/ipa.iso",
+	}, settings)
+
+	node := &nodes.Node{
+		DriverInfo: map[string]interface{}{
+			"deploy_kernel":  "http://images/8.0/ipa.kernel",
+			"deploy_ramdisk": deployRamdiskURL,
+		},
+	}
+	updates := agentImageUpdates(node, img, settings)
+	if assert.Len(t, updates, 3) {
+		assert.Equal(t, "/driver_info/deploy_iso", updates[0].(nodes.UpdateOperation).Path)
+		assert.Equal(t, "/driver_info/deploy_kernel", updates[1].(nodes.UpdateOperation).Path)
+		assert.Equal(t, "/extra/agent_version", updates[2].(nodes.UpdateOperation).Path)
+	}
+
+	node.DriverInfo["deploy_kernel"] = settings["deploy_kernel"]
+	node.DriverInfo["deploy_iso"] = settings["deploy_iso"]
+	node.Extra = map[string]interface{}{"agent_version": "8.1"}
+	assert.Empty(t, agentImageUpdates(node, img, settings))
+	assert.False(t, staleAgentImage(node, img))
+}
+
+func TestAgentImageReset(t *testing.T) {
+	node := &nodes.Node{
+		DriverInfo: map[string]interface{}{
+			"deploy_kernel":  "http://images/8.1/ipa.kernel",
+			"deploy_ramdisk": deployRamdiskURL,
+			"deploy_iso":     "http://images/8.1/ipa.iso",
+		},
+		Extra: map[string]interface{}{"agent_version": "8.1"},
+	}
+	assert.True(t, staleAgentImage(node, nil))
+
+	// The host has been removed from its group
+	updates := agentImageUpdates(node, nil, agentImageSettings(nil, nil))
+	if assert.Len(t, updates, 3) {
+		assert.Equal(t, nodes.UpdateOperation{Op: nodes.AddOp, Path: "/driver_info/deploy_kernel", Value: deployKernelURL}, updates[0])
+		assert.Equal(t, nodes.UpdateOperation{Op: nodes.RemoveOp, Path: "/driver_info/deploy_iso"}, updates[1])
+		assert.Equal(t, nodes.UpdateOperation{Op: nodes.RemoveOp, Path: "/extra/agent_version"}, updates[2])
+	}
+}
@@ -0,0 +1,98 @@
+package ironic
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/agentimage"
+)
+
+// parseAllowedURLs parses the comma separated list of URL prefixes
+// hosts may download from in air-gapped mode.
+func parseAllowedURLs(value string) (allowed []*url.URL, err error) {
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parsed, err := url.Parse(item)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return nil, errors.Errorf("%q is not an absolute URL", item)
+		}
+		allowed = append(allowed, parsed)
+	}
+	if len(allowed) == 0 {
+		return nil, errors.New("no allowed URL given")
+	}
+	return allowed, nil
+}
+
+// urlAllowed reports whether the URL is under one of the allowed
+// prefixes. The scheme and host must match exactly, so that a prefix
+// cannot be extended into another domain name.
+func urlAllowed(allowed []*url.URL, value string) bool {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	for _, prefix := range allowed {
+		if parsed.Scheme == prefix.Scheme && parsed.Host == prefix.Host &&
+			strings.HasPrefix(parsed.Path, prefix.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAirGapped returns an error naming the field if the operator is
+// air-gapped and the URL is not under one of the allowed prefixes.
+func checkAirGapped(field, value string) error {
+	if !airGapped || value == "" || urlAllowed(airGappedAllowedURLs, value) {
+		return nil
+	}
+	return errors.Errorf("%s %q is outside of the allowed URLs of the air-gapped mode", field, value)
+}
+
+// isURL reports whether the checksum of an image is the URL of a
+// file to fetch rather than the checksum value itself.
+func isURL(checksum string) bool {
+	return strings.Contains(checksum, "://")
+}
+
+// airGapImageError checks the URLs Ironic will download the image of
+// the host from, once rewritten to the nearest mirror.
+func (p *ironicProvisioner) airGapImageError(imageData *metal3v1alpha1.Image) error {
+	if imageData == nil {
+		return nil
+	}
+	mirrored := p.mirroredImage(imageData)
+	if err := checkAirGapped("spec.image.url", mirrored.URL); err != nil {
+		return err
+	}
+	if isURL(mirrored.Checksum) {
+		return checkAirGapped("spec.image.checksum", mirrored.Checksum)
+	}
+	return nil
+}
+
+// airGapAgentImageError checks the URLs of a deployment agent image
+// before they are downloaded to be verified.
+func airGapAgentImageError(img agentimage.Image) error {
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"kernelURL", img.KernelURL},
+		{"ramdiskURL", img.RamdiskURL},
+		{"isoURL", img.ISOURL},
+	} {
+		if err := checkAirGapped(fmt.Sprintf("agent image %s %s", img.Version, field.name), field.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/agentimage"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/fixture"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func setAirGapped(t *testing.T, allowed string) {
+	urls, err := parseAllowedURLs(allowed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	airGapped, airGappedAllowedURLs = true, urls
+	t.Cleanup(func() {
+		airGapped, airGappedAllowedURLs = false, nil
+	})
+}
+
+func TestParseAllowedURLs(t *testing.T) {
+	allowed, err := parseAllowedURLs("http://mirror.internal/images/, https://registry.internal")
+	assert.NoError(t, err)
+	assert.Len(t, allowed, 2)
+
+	_, err = parseAllowedURLs("")
+	assert.Error(t, err)
+	_, err = parseAllowedURLs("mirror.internal/images")
+	assert.Error(t, err)
+}
+
+func TestCheckAirGapped(t *testing.T) {
+	assert.NoError(t, checkAirGapped("spec.image.url", "http://images.example.com/os.qcow2"))
+
+	setAirGapped(t, "http://mirror.internal/images/,https://registry.internal")
+	for _, tc := range []struct {
+		URL     string
+		Allowed bool
+	}{
+		{"http://mirror.internal/images/os.qcow2", true},
+		{"https://registry.internal/os.qcow2", true},
+		{"http://mirror.internal/other/os.qcow2", false},
+		{"https://mirror.internal/images/os.qcow2", false},
+		{"http://mirror.internal.example.com/images/os.qcow2", false},
+		{"http://images.example.com/os.qcow2", false},
+	} {
+		t.Run(tc.URL, func(t *testing.T) {
+			err := checkAirGapped("spec.image.url", tc.URL)
+			if tc.Allowed {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.URL)
+			}
+		})
+	}
+}
+
+func TestAirGapAgentImageError(t *testing.T) {
+	setAirGapped(t, "http://mirror.internal/")
+	assert.NoError(t, airGapAgentImageError(agentimage.Image{
+		Version:   "8.1",
+		KernelURL: "http://mirror.internal/ipa.kernel",
+	}))
+	err := airGapAgentImageError(agentimage.Image{
+		Version:    "8.1",
+		KernelURL:  "http://mirror.internal/ipa.kernel",
+		RamdiskURL: "http://images.example.com/ipa.initramfs",
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "ramdiskURL")
+	}
+}
+
+func TestProvisionAirGapped(t *testing.T) {
+	setAirGapped(t, "http://mirror.internal/")
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	for _, tc := range []struct {
+		Scenario      string
+		Image         metal3v1alpha1.Image
+		ExpectedError string
+	}{
+		{
+			Scenario: "outside",
+			Image: metal3v1alpha1.Image{
+				URL:      "http://images.example.com/os.qcow2",
+				Checksum: "http://mirror.internal/os.qcow2.md5sum",
+			},
+			ExpectedError: "spec.image.url",
+		},
+		{
+			Scenario: "checksum outside",
+			Image: metal3v1alpha1.Image{
+				URL:      "http://mirror.internal/os.qcow2",
+				Checksum: "http://images.example.com/os.qcow2.md5sum",
+			},
+			ExpectedError: "spec.image.checksum",
+		},
+		{
+			Scenario: "checksum value",
+			Image: metal3v1alpha1.Image{
+				URL:      "http://mirror.internal/os.qcow2",
+				Checksum: "a48f2d9b83e7a1744e1e0a4dd37e5a4e",
+			},
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				ProvisionState: string(nodes.Active),
+				UUID:           nodeUUID,
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Spec.Image = &tc.Image
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, "https://inspector.test", auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+			prov.status.ID = nodeUUID
+
+			result, err := prov.Provision(fixture.NewHostConfigData("", "", ""), "")
+			assert.NoError(t, err)
+			if tc.ExpectedError == "" {
+				assert.Empty(t, result.ErrorMessage)
+			} else {
+				assert.Contains(t, result.ErrorMessage, tc.ExpectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package ironic
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+type biosSetting struct {
+	Name  string  `json:"name"`
+	Value *string `json:"value"`
+}
+
+// GetBIOSSettings returns all of the BIOS settings Ironic has cached
+// for the node. Ironic refreshes the cache whenever the node is
+// cleaned or inspected.
+func (p *ironicProvisioner) GetBIOSSettings() (settings map[string]string, err error) {
+	p.debugLog.Info("getting BIOS settings")
+
+	ironicNode, err := p.findExistingHost()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find existing host")
+	}
+	if ironicNode == nil {
+		return nil, provisioner.NeedsRegistration
+	}
+
+	var body struct {
+		Settings []biosSetting `json:"bios"`
+	}
+	url := p.client.ServiceURL("nodes", ironicNode.UUID, "bios")
+	if _, err = p.client.Get(url, &body, nil); err != nil {
+		return nil, errors.Wrap(err, "failed to get BIOS settings")
+	}
+
+	settings = make(map[string]string, len(body.Settings))
+	for _, setting := range body.Settings {
+		value := ""
+		if setting.Value != nil {
+			value = *setting.Value
+		}
+		settings[setting.Name] = value
+	}
+	return settings, nil
+}
@@ -0,0 +1,91 @@
+package ironic
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestGetBIOSSettings(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	biosError := testserver.NewIronic(t).Ready().Node(nodes.Node{
+		UUID: nodeUUID,
+	})
+	biosError.ErrorResponse("/v1/nodes/"+nodeUUID+"/bios", http.StatusInternalServerError)
+
+	cases := []struct {
+		name             string
+		ironic           *testserver.IronicMock
+		expectedSettings map[string]string
+		expectedError    error
+		expectAnyError   bool
+	}{
+		{
+			name: "settings",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID: nodeUUID,
+			}).NodeBIOS(nodeUUID, map[string]string{
+				"LogicalProc":       "Enabled",
+				"SriovGlobalEnable": "Disabled",
+			}),
+			expectedSettings: map[string]string{
+				"LogicalProc":       "Enabled",
+				"SriovGlobalEnable": "Disabled",
+			},
+		},
+		{
+			name: "no-settings",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID: nodeUUID,
+			}).NodeBIOS(nodeUUID, nil),
+			expectedSettings: map[string]string{},
+		},
+		{
+			name:           "bios-error",
+			ironic:         biosError,
+			expectAnyError: true,
+		},
+		{
+			name:          "not-ironic-node",
+			ironic:        testserver.NewIronic(t).Ready().NoNode(nodeUUID).NoNode("myhost"),
+			expectedError: provisioner.NeedsRegistration,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.ironic.Start()
+			defer tc.ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				tc.ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			settings, err := prov.GetBIOSSettings()
+			switch {
+			case tc.expectedError != nil:
+				assert.Equal(t, tc.expectedError, err)
+			case tc.expectAnyError:
+				assert.Error(t, err)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedSettings, settings)
+			}
+		})
+	}
+}
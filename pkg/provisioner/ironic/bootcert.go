@@ -0,0 +1,74 @@
+package ironic
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/bmcproxy"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/redfish"
+)
+
+// InstallBootCertificate makes the firmware of hosts using UEFI HTTP
+// boot trust the CA certificate of the boot server, through the
+// Redfish API of their BMC.
+func (p *ironicProvisioner) InstallBootCertificate(installed string) (result provisioner.Result, fingerprint string, err error) {
+	if httpBootCAFile == "" || !bmc.IsHTTPBootInterface(p.bootInterface()) {
+		result, err = operationComplete()
+		return
+	}
+
+	certificate, err := ioutil.ReadFile(httpBootCAFile)
+	if err != nil {
+		result, err = transientError(errors.Wrap(err, "failed to read the HTTPS boot CA certificate"))
+		return
+	}
+	fingerprint, err = redfish.CertificateFingerprint(certificate)
+	if err != nil {
+		result, err = transientError(errors.Wrapf(err, "invalid HTTPS boot CA certificate %s", httpBootCAFile))
+		return
+	}
+	if fingerprint == installed {
+		result, err = operationComplete()
+		return
+	}
+
+	driverInfo := p.bmcAccess.DriverInfo(p.bmcCreds)
+	address, _ := driverInfo["redfish_address"].(string)
+	systemID, _ := driverInfo["redfish_system_id"].(string)
+	if address == "" || systemID == "" {
+		result, err = operationFailed(fmt.Sprintf("BMC type %s cannot install the CA certificate of the HTTPS boot server",
+			p.bmcAccess.Type()))
+		return result, "", err
+	}
+
+	if p.dryRun {
+		p.recordPlannedAction(plannedAction{Action: "installBootCertificate", Certificate: fingerprint})
+		result, err = operationComplete()
+		return
+	}
+
+	proxy, err := bmcproxy.ForHost(p.host.Labels)
+	if err != nil {
+		result, err = operationFailed(err.Error())
+		return result, "", err
+	}
+
+	p.log.Info("installing HTTPS boot CA certificate", "fingerprint", fingerprint)
+	verifyCA, ok := driverInfo["redfish_verify_ca"].(bool)
+	client := redfish.New(address, p.bmcCreds.Username, p.bmcCreds.Password, verifyCA || !ok).WithProxy(proxy)
+	switch err = client.InstallBootCertificate(systemID, certificate); err {
+	case nil:
+		result, err = operationComplete()
+	case redfish.ErrBootCertificatesUnsupported:
+		result, err = operationFailed(err.Error())
+		fingerprint = ""
+	default:
+		result, err = transientError(errors.Wrap(err, "failed to install the HTTPS boot CA certificate"))
+		fingerprint = ""
+	}
+	return
+}
@@ -0,0 +1,150 @@
+package ironic
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/redfish"
+)
+
+func writeBootCertificate(t *testing.T) (path string, fingerprint string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "boot-ca"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	path = filepath.Join(t.TempDir(), "ca.crt")
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	fingerprint, err = redfish.CertificateFingerprint(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, fingerprint
+}
+
+func TestInstallBootCertificate(t *testing.T) {
+	caFile, caFingerprint := writeBootCertificate(t)
+
+	var posted int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/redfish/v1/Systems/1":
+			w.Write([]byte(`{"Boot": {"Certificates": {"@odata.id": "/redfish/v1/Systems/1/Boot/Certificates"}}}`))
+		case r.Method == http.MethodPost:
+			posted++
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.Write([]byte(`{"Members": []}`))
+		}
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	redfishAddress := "redfish+http://" + serverURL.Host + "/redfish/v1/Systems/1"
+
+	cases := []struct {
+		name                string
+		caFile              string
+		address             string
+		bootInterface       string
+		installed           string
+		expectedFingerprint string
+		expectedPosts       int
+		expectedError       bool
+	}{
+		{
+			name:          "no-ca",
+			address:       redfishAddress,
+			bootInterface: "http",
+		},
+		{
+			name:    "ipxe",
+			caFile:  caFile,
+			address: redfishAddress,
+		},
+		{
+			name:                "install",
+			caFile:              caFile,
+			address:             redfishAddress,
+			bootInterface:       "http",
+			expectedFingerprint: caFingerprint,
+			expectedPosts:       1,
+		},
+		{
+			name:                "installed",
+			caFile:              caFile,
+			address:             redfishAddress,
+			bootInterface:       "http",
+			installed:           caFingerprint,
+			expectedFingerprint: caFingerprint,
+		},
+		{
+			name:          "not-redfish",
+			caFile:        caFile,
+			address:       "ipmi://192.168.122.1",
+			bootInterface: "http",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func(old string) { httpBootCAFile = old }(httpBootCAFile)
+			httpBootCAFile = tc.caFile
+			posted = 0
+
+			host := makeHost()
+			host.Spec.BMC.Address = tc.address
+			if tc.bootInterface != "" {
+				host.Spec.NodeInterfaces = &metal3v1alpha1.NodeInterfaces{Boot: tc.bootInterface}
+			}
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "password"},
+				nullEventPublisher, "https://ironic.test", auth, "https://ironic.test", auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, fingerprint, err := prov.InstallBootCertificate(tc.installed)
+			assert.NoError(t, err)
+			if tc.expectedError {
+				assert.NotEmpty(t, result.ErrorMessage)
+				return
+			}
+			assert.Empty(t, result.ErrorMessage)
+			assert.Equal(t, tc.expectedFingerprint, fingerprint)
+			assert.Equal(t, tc.expectedPosts, posted)
+		})
+	}
+}
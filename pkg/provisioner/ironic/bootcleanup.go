@@ -0,0 +1,88 @@
+package ironic
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/pkg/errors"
+)
+
+// deployedInstanceInfo are the instance_info fields of the node that
+// make it boot the image of its last deployment.
+var deployedInstanceInfo = []string{"boot_iso", "image_source"}
+
+// residualBootTargets are the boot source overrides that would boot
+// the host from a deploy or live ISO, or from the network.
+var residualBootTargets = map[string]bool{
+	"Cd":       true,
+	"Pxe":      true,
+	"UefiHttp": true,
+}
+
+// CleanBootArtifacts checks that deprovisioning cleared the image of
+// the node in Ironic and, through the Redfish API of the BMC, that no
+// virtual media is still inserted and that the host does not boot from
+// the network or a CD once. What is found is removed, or reported as
+// planned actions in dry-run mode.
+func (p *ironicProvisioner) CleanBootArtifacts() (residue []string, err error) {
+	ironicNode, err := p.findExistingHost()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find existing host")
+	}
+	if ironicNode != nil {
+		var updates nodes.UpdateOpts
+		for _, field := range deployedInstanceInfo {
+			if _, found := ironicNode.InstanceInfo[field]; found {
+				residue = append(residue, fmt.Sprintf("instance_info %s of the node", field))
+				updates = append(updates, nodes.UpdateOperation{
+					Op:   nodes.RemoveOp,
+					Path: "/instance_info/" + field,
+				})
+			}
+		}
+		if len(updates) != 0 {
+			p.log.Info("removing the deployed image from the node")
+			if _, err := p.updateNode(ironicNode, updates); err != nil {
+				return residue, errors.Wrap(err, "failed to remove the deployed image from the node")
+			}
+		}
+	}
+
+	client, systemID, err := p.redfishClient()
+	if err != nil || client == nil {
+		return residue, err
+	}
+
+	inserted, err := client.InsertedMedia(systemID)
+	if err != nil {
+		return residue, errors.Wrap(err, "failed to read the virtual media of the BMC")
+	}
+	for _, media := range inserted {
+		residue = append(residue, fmt.Sprintf("virtual media %s holding %s", media.Path, media.Image))
+		if p.dryRun {
+			p.recordPlannedAction(plannedAction{Action: "ejectVirtualMedia", Target: media.Path})
+			continue
+		}
+		p.log.Info("ejecting virtual media", "media", media.Path, "image", media.Image)
+		if err := client.EjectMedia(media); err != nil {
+			return residue, errors.Wrap(err, "failed to eject the virtual media")
+		}
+	}
+
+	override, err := client.BootOverride(systemID)
+	if err != nil {
+		return residue, errors.Wrap(err, "failed to read the boot override of the BMC")
+	}
+	if override.Active() && residualBootTargets[override.Target] {
+		residue = append(residue, fmt.Sprintf("%s boot override to %s", override.Enabled, override.Target))
+		if p.dryRun {
+			p.recordPlannedAction(plannedAction{Action: "clearBootOverride", Target: systemID})
+			return residue, nil
+		}
+		p.log.Info("clearing boot override", "enabled", override.Enabled, "target", override.Target)
+		if err := client.ClearBootOverride(systemID); err != nil {
+			return residue, errors.Wrap(err, "failed to clear the boot override")
+		}
+	}
+	return residue, nil
+}
@@ -0,0 +1,101 @@
+package ironic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestCleanBootArtifacts(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	resources := map[string]string{
+		"/redfish/v1/Systems/1": `{"VirtualMedia": {"@odata.id": "/redfish/v1/Systems/1/VirtualMedia"},
+			"Boot": {"BootSourceOverrideEnabled": "Continuous", "BootSourceOverrideTarget": "Cd"}}`,
+		"/redfish/v1/Systems/1/VirtualMedia": `{"Members": [{"@odata.id": "/redfish/v1/Systems/1/VirtualMedia/CD1"}]}`,
+		"/redfish/v1/Systems/1/VirtualMedia/CD1": `{"Inserted": true, "Image": "https://ironic.test/live.iso",
+			"Actions": {"#VirtualMedia.EjectMedia": {"target": "/redfish/v1/Systems/1/VirtualMedia/CD1/Actions/VirtualMedia.EjectMedia"}}}`,
+	}
+	var changes []string
+	bmcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			changes = append(changes, r.Method+" "+r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		resource, found := resources[r.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(resource))
+	}))
+	defer bmcServer.Close()
+	bmcURL, _ := url.Parse(bmcServer.URL)
+
+	node := nodes.Node{
+		UUID:         nodeUUID,
+		InstanceInfo: map[string]interface{}{"boot_iso": "https://ironic.test/live.iso"},
+	}
+	ironic := testserver.NewIronic(t).WithDefaultResponses().Node(node).NodeUpdate(node)
+	ironic.Start()
+	defer ironic.Stop()
+
+	host := makeHost()
+	host.Spec.BMC.Address = "redfish-virtualmedia+http://" + bmcURL.Host + "/redfish/v1/Systems/1"
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "password"},
+		nullEventPublisher, ironic.Endpoint(), auth, "https://inspector.test/", auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+	prov.status.ID = nodeUUID
+
+	residue, err := prov.CleanBootArtifacts()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"instance_info boot_iso of the node",
+		"virtual media /redfish/v1/Systems/1/VirtualMedia/CD1 holding https://ironic.test/live.iso",
+		"Continuous boot override to Cd",
+	}, residue)
+	updates := ironic.GetLastNodeUpdateRequestFor(nodeUUID)
+	if assert.Len(t, updates, 1) {
+		assert.Equal(t, nodes.RemoveOp, updates[0].Op)
+		assert.Equal(t, "/instance_info/boot_iso", updates[0].Path)
+	}
+	assert.Equal(t, []string{
+		"POST /redfish/v1/Systems/1/VirtualMedia/CD1/Actions/VirtualMedia.EjectMedia",
+		"PATCH /redfish/v1/Systems/1",
+	}, changes)
+}
+
+func TestCleanBootArtifactsClean(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	ironic := testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{UUID: nodeUUID})
+	ironic.Start()
+	defer ironic.Stop()
+
+	host := makeHost()
+	host.Spec.BMC.Address = "ipmi://192.168.122.1"
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "password"},
+		nullEventPublisher, ironic.Endpoint(), auth, "https://inspector.test/", auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+	prov.status.ID = nodeUUID
+
+	residue, err := prov.CleanBootArtifacts()
+	assert.NoError(t, err)
+	assert.Empty(t, residue)
+	assert.Empty(t, ironic.GetLastNodeUpdateRequestFor(nodeUUID))
+}
@@ -0,0 +1,124 @@
+package ironic
+
+import (
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
+	"github.com/pkg/errors"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// deployRequestExtraKey is the key in the node extra field that holds
+// the request ID of the last deployment retried through a fallback
+// NIC. The request ID of a deployment includes the MAC address of the
+// NIC it boots through.
+const deployRequestExtraKey = "metal3_deploy_request"
+
+func (p *ironicProvisioner) listNodePorts(nodeUUID string) ([]ports.Port, error) {
+	pager := ports.ListDetail(p.client, ports.ListOpts{NodeUUID: nodeUUID})
+	if pager.Err != nil {
+		return nil, pager.Err
+	}
+	allPages, err := pager.AllPages()
+	if err != nil {
+		return nil, err
+	}
+	return ports.ExtractPorts(allPages)
+}
+
+// ensureBootPort makes the port with the given MAC address the only
+// one Ironic boots the node through, creating it if needed. It
+// reports whether anything had to be changed.
+func (p *ironicProvisioner) ensureBootPort(ironicNode *nodes.Node, mac string) (changed bool, err error) {
+	nodePorts, err := p.listNodePorts(ironicNode.UUID)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list ports")
+	}
+
+	found := false
+	for _, port := range nodePorts {
+		wanted := strings.EqualFold(port.Address, mac)
+		found = found || wanted
+		if port.PXEEnabled == wanted {
+			continue
+		}
+		p.log.Info("updating port", "MAC", port.Address, "pxeEnabled", wanted)
+		err = p.updatePort(ironicNode.UUID, port.UUID, ports.UpdateOpts{
+			ports.UpdateOperation{
+				Op:    ports.ReplaceOp,
+				Path:  "/pxe_enabled",
+				Value: wanted,
+			},
+		})
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to update port %s", port.Address)
+		}
+		changed = true
+	}
+
+	if !found {
+		p.log.Info("creating boot port", "MAC", mac)
+		enable := true
+		err = p.createPort(ports.CreateOpts{
+			NodeUUID:   ironicNode.UUID,
+			Address:    mac,
+			PXEEnabled: &enable,
+		})
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to create port %s", mac)
+		}
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// deployRequested reports whether the deployment identified by
+// requestID has been requested, so that a failed deployment is only
+// retried through a fallback NIC once.
+func deployRequested(ironicNode *nodes.Node, requestID string) bool {
+	id, _ := ironicNode.Extra[deployRequestExtraKey].(string)
+	return id == requestID
+}
+
+// requestDeploy asks Ironic to deploy the node again, recording the
+// request ID of the deployment together with the last request, so that
+// the deployment is known to have been tried once it fails again. Both
+// are removed if Ironic does not accept the deployment.
+func (p *ironicProvisioner) requestDeploy(ironicNode *nodes.Node, requestID string) (result provisioner.Result, err error) {
+	if alreadyRequested(ironicNode, requestID) {
+		p.log.Info("request already sent, waiting for it to complete", "requestID", requestID)
+		return operationContinuing(provisionRequeueDelay)
+	}
+	result, err = p.saveRequest(ironicNode, nodes.UpdateOpts{
+		lastRequestOperation(requestID),
+		nodes.UpdateOperation{
+			Op:    nodes.AddOp,
+			Path:  "/extra/" + deployRequestExtraKey,
+			Value: requestID,
+		},
+	})
+	if err != nil || result.Dirty {
+		return result, err
+	}
+	accepted, result, err := p.tryChangeNodeProvisionState(ironicNode,
+		nodes.ProvisionStateOpts{Target: nodes.TargetActive})
+	if !accepted {
+		p.forgetRequest(ironicNode, deployRequestExtraKey)
+	}
+	return result, err
+}
+
+// resetBootPort makes the port of the boot MAC address from the spec
+// the one Ironic boots the node through again, after it was moved to a
+// fallback NIC, so that the next provisioning starts from the first
+// candidate.
+func (p *ironicProvisioner) resetBootPort(ironicNode *nodes.Node) error {
+	if p.host.Spec.BootFallback == nil || p.host.Spec.BootMACAddress == "" {
+		return nil
+	}
+	_, err := p.ensureBootPort(ironicNode, p.host.Spec.BootMACAddress)
+	return err
+}
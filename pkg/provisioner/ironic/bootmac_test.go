@@ -0,0 +1,198 @@
+package ironic
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/fixture"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestEnsureBootPort(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	nodePorts := []ports.Port{
+		{UUID: "port-a", NodeUUID: nodeUUID, Address: "00:00:00:00:00:0a", PXEEnabled: true},
+		{UUID: "port-b", NodeUUID: nodeUUID, Address: "00:00:00:00:00:0b"},
+	}
+
+	cases := []struct {
+		name             string
+		mac              string
+		expectedChanged  bool
+		expectedPatches  []string
+		expectedCreation bool
+	}{
+		{
+			name: "already-enabled",
+			mac:  "00:00:00:00:00:0A",
+		},
+		{
+			name:            "other-port",
+			mac:             "00:00:00:00:00:0b",
+			expectedChanged: true,
+			expectedPatches: []string{"/v1/ports/port-a", "/v1/ports/port-b"},
+		},
+		{
+			name:             "new-port",
+			mac:              "00:00:00:00:00:0c",
+			expectedChanged:  true,
+			expectedPatches:  []string{"/v1/ports/port-a"},
+			expectedCreation: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).Ready().NodePorts(nodePorts)
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			publisher := func(reason, message string) {}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			changed, err := prov.ensureBootPort(&nodes.Node{UUID: nodeUUID}, tc.mac)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedChanged, changed)
+
+			for _, path := range []string{"/v1/ports/port-a", "/v1/ports/port-b"} {
+				_, patched := ironic.GetLastRequestFor(path, http.MethodPatch)
+				assert.Equal(t, contains(tc.expectedPatches, path), patched, path)
+			}
+			_, created := ironic.GetLastRequestFor("/v1/ports", http.MethodPost)
+			assert.Equal(t, tc.expectedCreation, created)
+		})
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestProvisionBootFallback(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	requestID := "provision/b"
+	// The ports were already switched to the fallback NIC, by a
+	// reconcile that did not get to request the deployment.
+	nodePorts := []ports.Port{
+		{UUID: "port-a", NodeUUID: nodeUUID, Address: "00:00:00:00:00:0a"},
+		{UUID: "port-b", NodeUUID: nodeUUID, Address: "00:00:00:00:00:0b", PXEEnabled: true},
+	}
+
+	cases := []struct {
+		name                 string
+		extra                map[string]interface{}
+		expectedErrorMessage bool
+	}{
+		{
+			name: "not-tried",
+		},
+		{
+			name:  "earlier-provisioning",
+			extra: map[string]interface{}{deployRequestExtraKey: "earlier/b"},
+		},
+		{
+			name:                 "tried",
+			extra:                map[string]interface{}{deployRequestExtraKey: requestID},
+			expectedErrorMessage: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(nodes.DeployFail),
+				UUID:           nodeUUID,
+				LastError:      "deploy failed",
+				Extra:          tc.extra,
+				InstanceInfo: map[string]interface{}{
+					"image_source":        "not-empty",
+					"image_os_hash_algo":  "",
+					"image_os_hash_value": "",
+				},
+			}).NodePorts(nodePorts)
+			ironic.ResponseWithCode("/v1/nodes/"+nodeUUID+"/validate",
+				`{"boot": {"result": true}, "deploy": {"result": true}}`, http.StatusOK)
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Spec.BootMACAddress = "00:00:00:00:00:0a"
+			host.Spec.BootFallback = &metal3v1alpha1.BootFallback{MACAddresses: []string{"00:00:00:00:00:0b"}}
+			host.Status.Provisioning.BootMACAddress = "00:00:00:00:00:0b"
+			publisher := func(reason, message string) {}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+			prov.status.ID = nodeUUID
+
+			result, err := prov.Provision(fixture.NewHostConfigData("", "", ""), requestID)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedErrorMessage, result.ErrorMessage != "", result.ErrorMessage)
+			if !tc.expectedErrorMessage {
+				// The deployment is recorded as tried through the NIC
+				body, _ := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID, http.MethodPatch)
+				assert.Contains(t, body, deployRequestExtraKey)
+				_, deployed := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID+"/states/provision", http.MethodPut)
+				assert.True(t, deployed)
+			}
+		})
+	}
+}
+
+func TestDeprovisionResetsBootPort(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	nodePorts := []ports.Port{
+		{UUID: "port-a", NodeUUID: nodeUUID, Address: "00:00:00:00:00:0a"},
+		{UUID: "port-b", NodeUUID: nodeUUID, Address: "00:00:00:00:00:0b", PXEEnabled: true},
+	}
+	ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+		ProvisionState: string(nodes.Available),
+		UUID:           nodeUUID,
+	}).NodePorts(nodePorts)
+	ironic.Start()
+	defer ironic.Stop()
+
+	host := makeHost()
+	host.Spec.BootMACAddress = "00:00:00:00:00:0a"
+	host.Spec.BootFallback = &metal3v1alpha1.BootFallback{MACAddresses: []string{"00:00:00:00:00:0b"}}
+	publisher := func(reason, message string) {}
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+	prov.status.ID = nodeUUID
+
+	result, err := prov.Deprovision(false, "")
+	assert.NoError(t, err)
+	assert.False(t, result.Dirty)
+	_, patched := ironic.GetLastRequestFor("/v1/ports/port-a", http.MethodPatch)
+	assert.True(t, patched)
+	_, patched = ironic.GetLastRequestFor("/v1/ports/port-b", http.MethodPatch)
+	assert.True(t, patched)
+}
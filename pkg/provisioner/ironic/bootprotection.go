@@ -0,0 +1,60 @@
+package ironic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/metal3-io/baremetal-operator/pkg/redfish"
+)
+
+// ProtectBootOrder makes the host boot from its disks through the
+// Redfish API of its BMC: a boot source override to anything but a
+// disk is removed, and the options that boot from the network are
+// moved after the other ones in the boot order. The changes are
+// reported as planned actions in dry-run mode.
+func (p *ironicProvisioner) ProtectBootOrder() (corrected []string, err error) {
+	client, systemID, err := p.redfishClient()
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, errors.New("the boot order can only be protected on hosts with a Redfish BMC")
+	}
+
+	override, err := client.BootOverride(systemID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the boot override of the BMC")
+	}
+	if override.Active() && override.Target != "Hdd" {
+		corrected = append(corrected, fmt.Sprintf("%s boot override to %s", override.Enabled, override.Target))
+		if p.dryRun {
+			p.recordPlannedAction(plannedAction{Action: "clearBootOverride", Target: systemID})
+		} else {
+			p.log.Info("clearing boot override", "enabled", override.Enabled, "target", override.Target)
+			if err := client.ClearBootOverride(systemID); err != nil {
+				return corrected, errors.Wrap(err, "failed to clear the boot override")
+			}
+		}
+	}
+
+	order, err := client.BootOrder(systemID)
+	if err != nil {
+		return corrected, errors.Wrap(err, "failed to read the boot order of the BMC")
+	}
+	references, changed := redfish.NetworkLast(order)
+	if !changed {
+		return corrected, nil
+	}
+	corrected = append(corrected, fmt.Sprintf("boot order changed to %s", strings.Join(references, ", ")))
+	if p.dryRun {
+		p.recordPlannedAction(plannedAction{Action: "setBootOrder", Target: systemID})
+		return corrected, nil
+	}
+	p.log.Info("moving network boot options last", "bootOrder", references)
+	if err := client.SetBootOrder(systemID, references); err != nil {
+		return corrected, errors.Wrap(err, "failed to change the boot order")
+	}
+	return corrected, nil
+}
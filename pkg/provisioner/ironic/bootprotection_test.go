@@ -0,0 +1,93 @@
+package ironic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestProtectBootOrder(t *testing.T) {
+	testCases := []struct {
+		Scenario  string
+		System    string
+		Corrected []string
+		Changes   []string
+	}{
+		{
+			Scenario: "network first with PXE override",
+			System: `{"Boot": {"BootSourceOverrideEnabled": "Once", "BootSourceOverrideTarget": "Pxe",
+				"BootOrder": ["Pxe", "Hdd"]}}`,
+			Corrected: []string{"Once boot override to Pxe", "boot order changed to Hdd, Pxe"},
+			Changes:   []string{"PATCH /redfish/v1/Systems/1", "PATCH /redfish/v1/Systems/1"},
+		},
+		{
+			Scenario: "disk first",
+			System: `{"Boot": {"BootSourceOverrideEnabled": "Continuous", "BootSourceOverrideTarget": "Hdd",
+				"BootOrder": ["Hdd", "Pxe"]}}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			var changes []string
+			bmcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet {
+					changes = append(changes, r.Method+" "+r.URL.Path)
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				if r.URL.Path != "/redfish/v1/Systems/1" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Write([]byte(tc.System))
+			}))
+			defer bmcServer.Close()
+			bmcURL, _ := url.Parse(bmcServer.URL)
+
+			ironic := testserver.NewIronic(t).WithDefaultResponses()
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Spec.BMC.Address = "redfish+http://" + bmcURL.Host + "/redfish/v1/Systems/1"
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "password"},
+				nullEventPublisher, ironic.Endpoint(), auth, "https://inspector.test/", auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			corrected, err := prov.ProtectBootOrder()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Corrected, corrected)
+			assert.Equal(t, tc.Changes, changes)
+		})
+	}
+}
+
+func TestProtectBootOrderWithoutRedfish(t *testing.T) {
+	ironic := testserver.NewIronic(t).WithDefaultResponses()
+	ironic.Start()
+	defer ironic.Stop()
+
+	host := makeHost()
+	host.Spec.BMC.Address = "ipmi://192.168.122.1:6233"
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "password"},
+		nullEventPublisher, ironic.Endpoint(), auth, "https://inspector.test/", auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	_, err = prov.ProtectBootOrder()
+	assert.Error(t, err)
+}
@@ -0,0 +1,35 @@
+package ironic
+
+import (
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// currentCleanStep returns the clean step a cleaning node is running,
+// from the step and the list of steps Ironic keeps in its internal
+// info.
+func currentCleanStep(ironicNode *nodes.Node) *provisioner.CleanStep {
+	step := &provisioner.CleanStep{}
+	if iface, _ := ironicNode.CleanStep["interface"].(string); iface != "" {
+		name, _ := ironicNode.CleanStep["step"].(string)
+		step.Name = iface + "." + name
+	}
+	if steps, ok := ironicNode.DriverInternalInfo["clean_steps"].([]interface{}); ok {
+		step.Total = len(steps)
+	}
+	if index, ok := ironicNode.DriverInternalInfo["clean_step_index"].(float64); ok && step.Name != "" {
+		step.Index = int(index) + 1
+	}
+	return step
+}
+
+// cleaningContinuing waits for cleaning to finish, reporting the
+// progress of the node.
+func cleaningContinuing(ironicNode *nodes.Node, delay time.Duration) (provisioner.Result, error) {
+	result, err := operationContinuing(delay)
+	result.CleanStep = currentCleanStep(ironicNode)
+	return result, err
+}
@@ -0,0 +1,33 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+func TestCurrentCleanStep(t *testing.T) {
+	node := &nodes.Node{
+		ProvisionState: string(nodes.CleanWait),
+		DriverInternalInfo: map[string]interface{}{
+			"clean_steps": []interface{}{
+				map[string]interface{}{"interface": "deploy", "step": "erase_devices_metadata"},
+				map[string]interface{}{"interface": "deploy", "step": "erase_devices"},
+			},
+		},
+	}
+	// The agent is booting, no step has started
+	assert.Equal(t, &provisioner.CleanStep{Total: 2}, currentCleanStep(node))
+
+	node.CleanStep = map[string]interface{}{"interface": "deploy", "step": "erase_devices"}
+	node.DriverInternalInfo["clean_step_index"] = float64(1)
+	assert.Equal(t, &provisioner.CleanStep{Name: "deploy.erase_devices", Index: 2, Total: 2}, currentCleanStep(node))
+
+	result, err := cleaningContinuing(node, provisionRequeueDelay)
+	assert.NoError(t, err)
+	assert.True(t, result.Dirty)
+	assert.Equal(t, "deploy.erase_devices", result.CleanStep.Name)
+}
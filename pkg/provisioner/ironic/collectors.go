@@ -0,0 +1,182 @@
+package ironic
+
+import (
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/pkg/errors"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// defaultKernelParams starts the kernel parameters set by the
+// operator. It keeps the parameters configured in Ironic.
+const defaultKernelParams = "%default%"
+
+// collectorsKernelParam selects the inspection collectors of the
+// agent.
+const collectorsKernelParam = " ipa-inspection-collectors="
+
+// benchmarksKernelParam selects the benchmarks run by the
+// extra-hardware collector of the agent.
+const benchmarksKernelParam = " ipa-inspection-benchmarks="
+
+// ironicCallbackKernelParam and inspectionCallbackKernelParam set
+// the URLs of the Ironic and Ironic Inspector APIs the agent calls
+// back.
+const (
+	ironicCallbackKernelParam     = " ipa-api-url="
+	inspectionCallbackKernelParam = " ipa-inspection-callback-url="
+)
+
+// defaultInspectionCollectors are the collectors run for hosts that
+// do not select their own, from the INSPECTION_COLLECTORS setting.
+var defaultInspectionCollectors []metal3v1alpha1.InspectionCollector
+
+// defaultInspectionBenchmarks are the benchmarks run for hosts that
+// do not select their own, from the INSPECTION_BENCHMARKS setting.
+var defaultInspectionBenchmarks []metal3v1alpha1.InspectionBenchmark
+
+// parseInspectionCollectors parses a comma-separated list of
+// collectors.
+func parseInspectionCollectors(value string) ([]metal3v1alpha1.InspectionCollector, error) {
+	var collectors []metal3v1alpha1.InspectionCollector
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, collector := range metal3v1alpha1.InspectionCollectors {
+			known = known || string(collector) == name
+		}
+		if !known {
+			return nil, errors.Errorf("unknown inspection collector %q", name)
+		}
+		collectors = append(collectors, metal3v1alpha1.InspectionCollector(name))
+	}
+	return collectors, nil
+}
+
+// parseInspectionBenchmarks parses a comma-separated list of
+// benchmarks.
+func parseInspectionBenchmarks(value string) ([]metal3v1alpha1.InspectionBenchmark, error) {
+	var benchmarks []metal3v1alpha1.InspectionBenchmark
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, benchmark := range metal3v1alpha1.InspectionBenchmarks {
+			known = known || string(benchmark) == name
+		}
+		if !known {
+			return nil, errors.Errorf("unknown inspection benchmark %q", name)
+		}
+		benchmarks = append(benchmarks, metal3v1alpha1.InspectionBenchmark(name))
+	}
+	return benchmarks, nil
+}
+
+// inspectionBenchmarks returns the benchmarks the agent runs when
+// inspecting the host.
+func inspectionBenchmarks(host *metal3v1alpha1.BareMetalHost) []string {
+	selected := defaultInspectionBenchmarks
+	if host.Spec.Inspection != nil && len(host.Spec.Inspection.Benchmarks) > 0 {
+		selected = host.Spec.Inspection.Benchmarks
+	}
+
+	var benchmarks []string
+	seen := map[string]bool{}
+	for _, benchmark := range selected {
+		if !seen[string(benchmark)] {
+			seen[string(benchmark)] = true
+			benchmarks = append(benchmarks, string(benchmark))
+		}
+	}
+	return benchmarks
+}
+
+// inspectionCollectors returns the collectors the agent runs when
+// inspecting the host, or nil to leave the Ironic configuration
+// alone. The default collector, which reports the inventory, always
+// runs first. The extra-hardware collector, which runs the
+// benchmarks, is added when benchmarks are selected.
+func inspectionCollectors(host *metal3v1alpha1.BareMetalHost) []string {
+	selected := defaultInspectionCollectors
+	if host.Spec.Inspection != nil && len(host.Spec.Inspection.Collectors) > 0 {
+		selected = host.Spec.Inspection.Collectors
+	}
+	if len(inspectionBenchmarks(host)) > 0 {
+		selected = append(append([]metal3v1alpha1.InspectionCollector{}, selected...),
+			metal3v1alpha1.ExtraHardwareCollector)
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+
+	collectors := []string{"default"}
+	seen := map[string]bool{"default": true}
+	for _, collector := range selected {
+		if !seen[string(collector)] {
+			seen[string(collector)] = true
+			collectors = append(collectors, string(collector))
+		}
+	}
+	return collectors
+}
+
+// kernelParams returns the kernel parameters of the agent needed to
+// run the collectors and benchmarks, followed by the agent parameters
+// of the host, or an empty string when none is needed.
+func kernelParams(collectors, benchmarks []string, agentParams string) string {
+	if len(collectors) == 0 && agentParams == "" {
+		return ""
+	}
+	value := defaultKernelParams
+	if len(collectors) > 0 {
+		value += collectorsKernelParam + strings.Join(collectors, ",")
+		if len(benchmarks) > 0 {
+			value += benchmarksKernelParam + strings.Join(benchmarks, ",")
+		}
+	}
+	return value + agentParams
+}
+
+// ownKernelParams reports whether the kernel parameters were set by
+// the operator, which starts them with the Ironic defaults. Since the
+// agent kernel arguments of the host may come first, any parameter
+// following the defaults is taken as the operator's.
+func ownKernelParams(value string) bool {
+	return strings.HasPrefix(value, defaultKernelParams+" ")
+}
+
+// kernelParamsUpdates returns the changes to the kernel parameters of
+// the node needed to set them to value. Kernel parameters set by
+// someone else are only replaced when the operator needs some.
+func kernelParamsUpdates(ironicNode *nodes.Node, value string) nodes.UpdateOpts {
+	current, _ := ironicNode.DriverInfo["kernel_append_params"].(string)
+	if value == "" {
+		if !ownKernelParams(current) {
+			return nil
+		}
+		return nodes.UpdateOpts{
+			nodes.UpdateOperation{
+				Op:   nodes.RemoveOp,
+				Path: "/driver_info/kernel_append_params",
+			},
+		}
+	}
+
+	if current == value {
+		return nil
+	}
+	return nodes.UpdateOpts{
+		nodes.UpdateOperation{
+			Op:    nodes.AddOp,
+			Path:  "/driver_info/kernel_append_params",
+			Value: value,
+		},
+	}
+}
@@ -0,0 +1,108 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestParseInspectionCollectors(t *testing.T) {
+	collectors, err := parseInspectionCollectors("lldp, pci-devices,")
+	assert.NoError(t, err)
+	assert.Equal(t, []metal3v1alpha1.InspectionCollector{
+		metal3v1alpha1.LLDPCollector,
+		metal3v1alpha1.PCIDevicesCollector,
+	}, collectors)
+
+	_, err = parseInspectionCollectors("lldp,numa")
+	assert.Error(t, err)
+}
+
+func TestInspectionCollectors(t *testing.T) {
+	defer func() { defaultInspectionCollectors = nil }()
+
+	host := makeHost()
+	assert.Nil(t, inspectionCollectors(&host))
+
+	defaultInspectionCollectors = []metal3v1alpha1.InspectionCollector{metal3v1alpha1.LogsCollector}
+	assert.Equal(t, []string{"default", "logs"}, inspectionCollectors(&host))
+
+	host.Spec.Inspection = &metal3v1alpha1.InspectionSettings{
+		Collectors: []metal3v1alpha1.InspectionCollector{
+			metal3v1alpha1.ExtraHardwareCollector,
+			metal3v1alpha1.LLDPCollector,
+			metal3v1alpha1.ExtraHardwareCollector,
+		},
+	}
+	assert.Equal(t, []string{"default", "extra-hardware", "lldp"}, inspectionCollectors(&host))
+}
+
+func TestInspectionBenchmarks(t *testing.T) {
+	defer func() { defaultInspectionBenchmarks = nil }()
+
+	_, err := parseInspectionBenchmarks("disk,cpu")
+	assert.Error(t, err)
+
+	host := makeHost()
+	assert.Empty(t, inspectionBenchmarks(&host))
+
+	defaultInspectionBenchmarks, err = parseInspectionBenchmarks("mem, disk")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"mem", "disk"}, inspectionBenchmarks(&host))
+	assert.Equal(t, []string{"default", "extra-hardware"}, inspectionCollectors(&host))
+
+	host.Spec.Inspection = &metal3v1alpha1.InspectionSettings{
+		Collectors: []metal3v1alpha1.InspectionCollector{metal3v1alpha1.LLDPCollector},
+		Benchmarks: []metal3v1alpha1.InspectionBenchmark{metal3v1alpha1.DiskBenchmark},
+	}
+	assert.Equal(t, []string{"disk"}, inspectionBenchmarks(&host))
+	assert.Equal(t, []string{"default", "lldp", "extra-hardware"}, inspectionCollectors(&host))
+
+	node := &nodes.Node{DriverInfo: map[string]interface{}{}}
+	updates := kernelParamsUpdates(node, kernelParams(inspectionCollectors(&host), inspectionBenchmarks(&host), ""))
+	if assert.Len(t, updates, 1) {
+		assert.Equal(t, "%default% ipa-inspection-collectors=default,lldp,extra-hardware ipa-inspection-benchmarks=disk",
+			updates[0].(nodes.UpdateOperation).Value)
+	}
+}
+
+func TestKernelParamsUpdates(t *testing.T) {
+	node := &nodes.Node{DriverInfo: map[string]interface{}{}}
+	assert.Empty(t, kernelParamsUpdates(node, kernelParams(nil, nil, "")))
+
+	updates := kernelParamsUpdates(node, kernelParams([]string{"default", "lldp"}, nil, ""))
+	if assert.Len(t, updates, 1) {
+		update := updates[0].(nodes.UpdateOperation)
+		assert.Equal(t, nodes.AddOp, update.Op)
+		assert.Equal(t, "/driver_info/kernel_append_params", update.Path)
+		assert.Equal(t, "%default% ipa-inspection-collectors=default,lldp", update.Value)
+	}
+
+	node.DriverInfo["kernel_append_params"] = "%default% ipa-inspection-collectors=default,lldp"
+	assert.Empty(t, kernelParamsUpdates(node, kernelParams([]string{"default", "lldp"}, nil, "")))
+
+	updates = kernelParamsUpdates(node, kernelParams(nil, nil, ""))
+	if assert.Len(t, updates, 1) {
+		assert.Equal(t, nodes.RemoveOp, updates[0].(nodes.UpdateOperation).Op)
+	}
+
+	// Parameters set by someone else are left alone
+	node.DriverInfo["kernel_append_params"] = "console=ttyS0"
+	assert.Empty(t, kernelParamsUpdates(node, kernelParams(nil, nil, "")))
+
+	// Callback URLs of the provisioning network are set without
+	// collectors
+	network := &ProvisioningNetwork{Name: "rack-2", IronicCallbackURL: "https://[fd00:2::2]:6385"}
+	updates = kernelParamsUpdates(node, kernelParams(nil, nil, networkKernelParams(network)))
+	if assert.Len(t, updates, 1) {
+		assert.Equal(t, "%default% ipa-api-url=https://[fd00:2::2]:6385", updates[0].(nodes.UpdateOperation).Value)
+	}
+	node.DriverInfo["kernel_append_params"] = "%default% ipa-api-url=https://[fd00:2::2]:6385"
+	updates = kernelParamsUpdates(node, kernelParams(nil, nil, ""))
+	if assert.Len(t, updates, 1) {
+		assert.Equal(t, nodes.RemoveOp, updates[0].(nodes.UpdateOperation).Op)
+	}
+}
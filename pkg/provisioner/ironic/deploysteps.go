@@ -0,0 +1,67 @@
+package ironic
+
+import (
+	"encoding/json"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// deployStepsMicroversion is the Ironic API version that accepts
+// deploy steps when deploying a node. Only the requests deploying
+// hosts with custom deploy steps use it, so that older Ironic
+// versions keep working for the other hosts.
+const deployStepsMicroversion = "1.69"
+
+// deployStep is a deploy step as the Ironic API takes it.
+type deployStep struct {
+	Interface string                 `json:"interface"`
+	Step      string                 `json:"step"`
+	Args      map[string]interface{} `json:"args"`
+	Priority  int                    `json:"priority"`
+}
+
+// deployOpts is a request to deploy a node with custom deploy steps,
+// which the ProvisionStateOpts of gophercloud do not support.
+type deployOpts struct {
+	nodes.ProvisionStateOpts
+	DeploySteps []deployStep `json:"deploy_steps,omitempty"`
+}
+
+// ToProvisionStateMap assembles the request body.
+func (opts deployOpts) ToProvisionStateMap() (map[string]interface{}, error) {
+	return gophercloud.BuildRequestBody(opts, "")
+}
+
+// deployArg returns the value of a deploy step argument, which holds
+// JSON or else a plain string.
+func deployArg(value string) interface{} {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+		return value
+	}
+	return parsed
+}
+
+// buildDeploySteps returns the custom deploy steps of the host.
+func buildDeploySteps(host *metal3v1alpha1.BareMetalHost) []deployStep {
+	if host.Spec.CustomDeploy == nil {
+		return nil
+	}
+	var steps []deployStep
+	for _, step := range host.Spec.CustomDeploy.Steps {
+		args := make(map[string]interface{}, len(step.Args))
+		for name, value := range step.Args {
+			args[name] = deployArg(value)
+		}
+		steps = append(steps, deployStep{
+			Interface: step.Interface,
+			Step:      step.Step,
+			Args:      args,
+			Priority:  step.Priority,
+		})
+	}
+	return steps
+}
@@ -0,0 +1,92 @@
+package ironic
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestBuildDeploySteps(t *testing.T) {
+	host := makeHost()
+	assert.Nil(t, buildDeploySteps(&host))
+
+	host.Spec.CustomDeploy = &metal3v1alpha1.CustomDeploy{
+		Steps: []metal3v1alpha1.DeployStep{
+			{Interface: "deploy", Step: "write_image", Priority: 0},
+			{
+				Interface: "deploy",
+				Step:      "install_coreos",
+				Priority:  80,
+				Args: map[string]string{
+					"ignition": `{"ignition": {"version": "3.1.0"}}`,
+					"retries":  "3",
+					"device":   "/dev/sda",
+				},
+			},
+		},
+	}
+	steps := buildDeploySteps(&host)
+	assert.Equal(t, []deployStep{
+		{Interface: "deploy", Step: "write_image", Args: map[string]interface{}{}, Priority: 0},
+		{
+			Interface: "deploy",
+			Step:      "install_coreos",
+			Priority:  80,
+			Args: map[string]interface{}{
+				"ignition": map[string]interface{}{"ignition": map[string]interface{}{"version": "3.1.0"}},
+				"retries":  float64(3),
+				"device":   "/dev/sda",
+			},
+		},
+	}, steps)
+}
+
+func TestDeployWithCustomSteps(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	ironic := testserver.NewIronic(t).Ready().WithNodeStatesProvisionUpdate(nodeUUID)
+	ironic.Start()
+	defer ironic.Stop()
+
+	host := makeHost()
+	host.Spec.CustomDeploy = &metal3v1alpha1.CustomDeploy{
+		Steps: []metal3v1alpha1.DeployStep{{Interface: "deploy", Step: "write_image", Priority: 0}},
+	}
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	err = prov.changeProvisionState(&nodes.Node{UUID: nodeUUID}, nodes.ProvisionStateOpts{Target: nodes.TargetActive})
+	if !assert.NoError(t, err) {
+		return
+	}
+	body, _ := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID+"/states/provision", http.MethodPut)
+	var request map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal([]byte(body), &request)) {
+		return
+	}
+	assert.Equal(t, "active", request["target"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"interface": "deploy", "step": "write_image", "args": map[string]interface{}{}, "priority": float64(0),
+	}}, request["deploy_steps"])
+	assert.Equal(t, "1.56", prov.client.Microversion)
+
+	// Other targets do not run the deploy steps
+	err = prov.changeProvisionState(&nodes.Node{UUID: nodeUUID}, nodes.ProvisionStateOpts{Target: nodes.TargetManage})
+	if !assert.NoError(t, err) {
+		return
+	}
+	body, _ = ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID+"/states/provision", http.MethodPut)
+	assert.NotContains(t, body, "deploy_steps")
+}
@@ -2,6 +2,7 @@ package devicehints
 
 import (
 	"fmt"
+	"strings"
 
 	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 )
@@ -42,6 +43,19 @@ func MakeHintMap(source *metal3v1alpha1.RootDeviceHints) map[string]string {
 	if source.WWNVendorExtension != "" {
 		hints["wwn_vendor_extension"] = fmt.Sprintf("s== %s", source.WWNVendorExtension)
 	}
+	// The agent reports the WWID of a multipath device as its serial
+	// number
+	if source.WWID != "" {
+		hints["serial"] = fmt.Sprintf("s== %s", source.WWID)
+	}
+	// The name hint also matches the by-path names of the devices
+	if source.ByPath != "" {
+		if prefix := strings.TrimSuffix(source.ByPath, "*"); prefix != source.ByPath {
+			hints["name"] = fmt.Sprintf("<in> %s", prefix)
+		} else {
+			hints["name"] = fmt.Sprintf("s== %s", source.ByPath)
+		}
+	}
 	switch {
 	case source.Rotational == nil:
 	case *source.Rotational == true:
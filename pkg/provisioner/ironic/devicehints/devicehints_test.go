@@ -35,6 +35,33 @@ func TestMakeHintMap(t *testing.T) {
 				"hctl": "s== 1:2:3:4",
 			},
 		},
+		{
+			Scenario: "wwid",
+			Hints: metal3v1alpha1.RootDeviceHints{
+				WWID: "3600a098038304437415d4b6a59684a52",
+			},
+			Expected: map[string]string{
+				"serial": "s== 3600a098038304437415d4b6a59684a52",
+			},
+		},
+		{
+			Scenario: "by-path",
+			Hints: metal3v1alpha1.RootDeviceHints{
+				ByPath: "/dev/disk/by-path/pci-0000:41:00.0-fc-0x500a0981891b8dc5-lun-0",
+			},
+			Expected: map[string]string{
+				"name": "s== /dev/disk/by-path/pci-0000:41:00.0-fc-0x500a0981891b8dc5-lun-0",
+			},
+		},
+		{
+			Scenario: "by-path-pattern",
+			Hints: metal3v1alpha1.RootDeviceHints{
+				ByPath: "/dev/disk/by-path/pci-0000:41:00.0-fc-0x500a0981891b8dc5*",
+			},
+			Expected: map[string]string{
+				"name": "<in> /dev/disk/by-path/pci-0000:41:00.0-fc-0x500a0981891b8dc5",
+			},
+		},
 		{
 			Scenario: "model",
 			Hints: metal3v1alpha1.RootDeviceHints{
@@ -0,0 +1,76 @@
+package ironic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/agentimage"
+)
+
+// downloadLimitKernelParam limits the bandwidth the agent uses to
+// download the image, in megabits per second. Agents that do not
+// support it ignore it.
+const downloadLimitKernelParam = " ipa-image-download-limit-mbps="
+
+// defaultImageDownloadLimit is the limit of the hosts that do not set
+// their own, from the IMAGE_DOWNLOAD_LIMIT_MBPS setting. Zero means
+// no limit.
+var defaultImageDownloadLimit int
+
+// imageDownloadLimit returns the bandwidth limit of the image
+// download of the host, or zero for no limit.
+func imageDownloadLimit(host *metal3v1alpha1.BareMetalHost) int {
+	if host.Spec.ImageDownloadLimitMbps != nil {
+		return *host.Spec.ImageDownloadLimitMbps
+	}
+	return defaultImageDownloadLimit
+}
+
+// downloadLimitKernelParams returns the kernel parameters limiting
+// the image download of the host.
+func downloadLimitKernelParams(host *metal3v1alpha1.BareMetalHost) string {
+	limit := imageDownloadLimit(host)
+	if limit <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s%d", downloadLimitKernelParam, limit)
+}
+
+// staleDownloadLimit reports whether the kernel parameters of the node
+// still limit the download after the limit of the host was removed.
+func staleDownloadLimit(ironicNode *nodes.Node, host *metal3v1alpha1.BareMetalHost) bool {
+	current, _ := ironicNode.DriverInfo["kernel_append_params"].(string)
+	return imageDownloadLimit(host) <= 0 && ownKernelParams(current) &&
+		strings.Contains(current, downloadLimitKernelParam)
+}
+
+// agentKernelParams returns the kernel parameters of the agent
+// specific to the host: the callback URLs of its provisioning network,
+// the limit of its image download, the multipath support of its root
+// device and the kernel arguments given in its spec.
+func (p *ironicProvisioner) agentKernelParams(network *ProvisioningNetwork) string {
+	return networkKernelParams(network) + downloadLimitKernelParams(&p.host) +
+		multipathKernelParams(&p.host) + hostKernelParams(&p.host)
+}
+
+// staleAgentParams reports whether the kernel parameters of the node
+// still hold settings the host no longer has.
+func staleAgentParams(ironicNode *nodes.Node, host *metal3v1alpha1.BareMetalHost) bool {
+	return staleDownloadLimit(ironicNode, host) || staleMultipath(ironicNode, host) ||
+		staleKernelArgs(ironicNode, host)
+}
+
+// agentSettingsUpdates returns the changes to the agent settings of
+// the node, including the removal of a download limit, of the
+// multipath support or of the kernel arguments the host no longer has.
+func (p *ironicProvisioner) agentSettingsUpdates(ironicNode *nodes.Node, img *agentimage.Image, settings map[string]string) nodes.UpdateOpts {
+	updates := agentImageUpdates(ironicNode, img, settings)
+	if _, set := settings["kernel_append_params"]; !set && staleAgentParams(ironicNode, &p.host) {
+		updates = append(updates, kernelParamsUpdates(ironicNode, kernelParams(
+			inspectionCollectors(&p.host), inspectionBenchmarks(&p.host), ""))...)
+	}
+	return updates
+}
@@ -0,0 +1,83 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestDownloadLimitKernelParams(t *testing.T) {
+	host := makeHost()
+	assert.Equal(t, "", downloadLimitKernelParams(&host))
+
+	defaultImageDownloadLimit = 50
+	defer func() { defaultImageDownloadLimit = 0 }()
+	assert.Equal(t, " ipa-image-download-limit-mbps=50", downloadLimitKernelParams(&host))
+
+	limit := 200
+	host.Spec.ImageDownloadLimitMbps = &limit
+	assert.Equal(t, " ipa-image-download-limit-mbps=200", downloadLimitKernelParams(&host))
+
+	limit = 0
+	assert.Equal(t, "", downloadLimitKernelParams(&host))
+}
+
+func TestDownloadLimitSettings(t *testing.T) {
+	limit := 100
+	host := makeHost()
+	host.Spec.ImageDownloadLimitMbps = &limit
+	host.Spec.Image = nil
+	host.Status.Provisioning.ID = "" // so we don't lookup by uuid
+
+	var createdNode *nodes.Node
+	ironic := testserver.NewIronic(t).Ready().CreateNodes(func(node nodes.Node) {
+		createdNode = &node
+	}).NoNode(host.Name)
+	ironic.Start()
+	defer ironic.Stop()
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	result, _, err := prov.ValidateManagementAccess(false, false)
+	if err != nil {
+		t.Fatalf("error from ValidateManagementAccess: %s", err)
+	}
+	assert.Equal(t, "", result.ErrorMessage)
+	if assert.NotNil(t, createdNode) {
+		assert.Equal(t, "%default% ipa-image-download-limit-mbps=100", createdNode.DriverInfo["kernel_append_params"])
+	}
+}
+
+func TestStaleDownloadLimit(t *testing.T) {
+	host := makeHost()
+	p := &ironicProvisioner{host: host}
+	node := &nodes.Node{
+		DriverInfo: map[string]interface{}{
+			"deploy_kernel":        deployKernelURL,
+			"deploy_ramdisk":       deployRamdiskURL,
+			"kernel_append_params": "%default% ipa-image-download-limit-mbps=100",
+		},
+	}
+	assert.True(t, staleDownloadLimit(node, &host))
+	updates := p.agentSettingsUpdates(node, nil, agentImageSettings(nil, nil))
+	if assert.Len(t, updates, 1) {
+		assert.Equal(t, nodes.RemoveOp, updates[0].(nodes.UpdateOperation).Op)
+		assert.Equal(t, "/driver_info/kernel_append_params", updates[0].(nodes.UpdateOperation).Path)
+	}
+
+	// Kernel parameters set by someone else are left alone
+	node.DriverInfo["kernel_append_params"] = "nofb ipa-image-download-limit-mbps=100"
+	assert.False(t, staleDownloadLimit(node, &host))
+	assert.Empty(t, p.agentSettingsUpdates(node, nil, agentImageSettings(nil, nil)))
+}
@@ -0,0 +1,103 @@
+package ironic
+
+import (
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/pkg/errors"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// driverStatus describes the hardware type and interfaces of the node.
+func driverStatus(ironicNode *nodes.Node) *metal3v1alpha1.DriverStatus {
+	return &metal3v1alpha1.DriverStatus{
+		Name: ironicNode.Driver,
+		Interfaces: metal3v1alpha1.DriverInterfaces{
+			Boot:       ironicNode.BootInterface,
+			Console:    ironicNode.ConsoleInterface,
+			Deploy:     ironicNode.DeployInterface,
+			Inspect:    ironicNode.InspectInterface,
+			Management: ironicNode.ManagementInterface,
+			Network:    ironicNode.NetworkInterface,
+			Power:      ironicNode.PowerInterface,
+			RAID:       ironicNode.RAIDInterface,
+			Rescue:     ironicNode.RescueInterface,
+			Storage:    ironicNode.StorageInterface,
+			Vendor:     ironicNode.VendorInterface,
+		},
+	}
+}
+
+// bootInterface returns the boot interface of the node. Hosts booting
+// from the network use the interface they request or, failing that,
+// the one configured for all hosts, as long as it suits their boot
+// mode.
+func (p *ironicProvisioner) bootInterface() string {
+	if p.host.Spec.NodeInterfaces != nil && p.host.Spec.NodeInterfaces.Boot != "" {
+		return p.host.Spec.NodeInterfaces.Boot
+	}
+	defaultInterface := p.bmcAccess.BootInterface()
+	if networkBootInterface == "" || !bmc.IsNetworkBootInterface(defaultInterface) {
+		return defaultInterface
+	}
+	if bmc.IsHTTPBootInterface(networkBootInterface) && p.host.Spec.BootMode == metal3v1alpha1.Legacy {
+		return defaultInterface
+	}
+	return networkBootInterface
+}
+
+// bootInterfaceUpdates returns the change needed to switch an existing
+// node between network boot interfaces, for example from iPXE to UEFI
+// HTTP boot. Ironic only allows it while the node is not deployed.
+func (p *ironicProvisioner) bootInterfaceUpdates(ironicNode *nodes.Node) nodes.UpdateOpts {
+	bootInterface := p.bootInterface()
+	if ironicNode.BootInterface == bootInterface ||
+		!bmc.IsNetworkBootInterface(ironicNode.BootInterface) || !bmc.IsNetworkBootInterface(bootInterface) {
+		return nil
+	}
+	switch nodes.ProvisionState(ironicNode.ProvisionState) {
+	case nodes.Enroll, nodes.Manageable, nodes.Available:
+	default:
+		return nil
+	}
+	return nodes.UpdateOpts{
+		nodes.UpdateOperation{
+			Op:    nodes.ReplaceOp,
+			Path:  "/boot_interface",
+			Value: bootInterface,
+		},
+	}
+}
+
+// biosInterfaceUpdates returns the change selecting the BIOS interface
+// requested for the host, if any. Ironic nodes do not report their
+// BIOS interface to us, so it is only set when the node is registered
+// or its credentials change.
+func (p *ironicProvisioner) biosInterfaceUpdates() nodes.UpdateOpts {
+	if p.host.Spec.NodeInterfaces == nil || p.host.Spec.NodeInterfaces.BIOS == "" {
+		return nil
+	}
+	return nodes.UpdateOpts{
+		nodes.UpdateOperation{
+			Op:    nodes.ReplaceOp,
+			Path:  "/bios_interface",
+			Value: p.host.Spec.NodeInterfaces.BIOS,
+		},
+	}
+}
+
+// GetDriverStatus returns the hardware type and interfaces Ironic
+// selected for the node.
+func (p *ironicProvisioner) GetDriverStatus() (*metal3v1alpha1.DriverStatus, error) {
+	p.debugLog.Info("getting driver status")
+
+	ironicNode, err := p.findExistingHost()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find existing host")
+	}
+	if ironicNode == nil {
+		return nil, provisioner.NeedsRegistration
+	}
+	return driverStatus(ironicNode), nil
+}
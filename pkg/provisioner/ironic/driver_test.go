@@ -0,0 +1,225 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestGetDriverStatus(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	cases := []struct {
+		name           string
+		ironic         *testserver.IronicMock
+		expectedDriver *metal3v1alpha1.DriverStatus
+		expectedError  error
+	}{
+		{
+			name: "driver",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:                nodeUUID,
+				Driver:              "redfish",
+				BootInterface:       "redfish-virtual-media",
+				DeployInterface:     "direct",
+				ManagementInterface: "redfish",
+				PowerInterface:      "redfish",
+				RAIDInterface:       "no-raid",
+			}),
+			expectedDriver: &metal3v1alpha1.DriverStatus{
+				Name: "redfish",
+				Interfaces: metal3v1alpha1.DriverInterfaces{
+					Boot:       "redfish-virtual-media",
+					Deploy:     "direct",
+					Management: "redfish",
+					Power:      "redfish",
+					RAID:       "no-raid",
+				},
+			},
+		},
+		{
+			name:          "not-ironic-node",
+			ironic:        testserver.NewIronic(t).Ready().NoNode(nodeUUID).NoNode("myhost"),
+			expectedError: provisioner.NeedsRegistration,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.ironic.Start()
+			defer tc.ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				tc.ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			driver, err := prov.GetDriverStatus()
+			if tc.expectedError != nil {
+				assert.Equal(t, tc.expectedError, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDriver, driver)
+		})
+	}
+}
+
+func TestNodeInterfaces(t *testing.T) {
+	image := &metal3v1alpha1.Image{
+		URL:      "http://example.test/image.qcow2",
+		Checksum: "http://example.test/image.qcow2.md5sum",
+	}
+	cases := []struct {
+		name           string
+		interfaces     *metal3v1alpha1.NodeInterfaces
+		expectedDeploy string
+		expectedBIOS   nodes.UpdateOpts
+	}{
+		{
+			name:           "defaults",
+			expectedDeploy: "direct",
+		},
+		{
+			name:           "overrides",
+			interfaces:     &metal3v1alpha1.NodeInterfaces{BIOS: "redfish", Deploy: "ansible"},
+			expectedDeploy: "ansible",
+			expectedBIOS: nodes.UpdateOpts{
+				nodes.UpdateOperation{Op: nodes.ReplaceOp, Path: "/bios_interface", Value: "redfish"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.NodeInterfaces = tc.interfaces
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				"https://ironic.test", auth, "https://ironic.test", auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			assert.Equal(t, tc.expectedDeploy, prov.deployInterface())
+			updates, err := prov.setDirectDeployUpdateOptsForNode(&nodes.Node{}, image, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Contains(t, updates, nodes.UpdateOperation{
+				Op:    nodes.ReplaceOp,
+				Path:  "/deploy_interface",
+				Value: tc.expectedDeploy,
+			})
+			assert.Equal(t, tc.expectedBIOS, prov.biosInterfaceUpdates())
+		})
+	}
+}
+
+func TestBootInterface(t *testing.T) {
+	cases := []struct {
+		name              string
+		hostInterface     string
+		fleetInterface    string
+		bootMode          metal3v1alpha1.BootMode
+		nodeInterface     string
+		provisionState    nodes.ProvisionState
+		expectedInterface string
+		expectUpdate      bool
+	}{
+		{
+			name:              "default",
+			nodeInterface:     "ipxe",
+			provisionState:    nodes.Manageable,
+			expectedInterface: "ipxe",
+		},
+		{
+			name:              "host",
+			hostInterface:     "http",
+			fleetInterface:    "http-ipxe",
+			nodeInterface:     "ipxe",
+			provisionState:    nodes.Manageable,
+			expectedInterface: "http",
+			expectUpdate:      true,
+		},
+		{
+			name:              "fleet",
+			fleetInterface:    "http-ipxe",
+			nodeInterface:     "ipxe",
+			provisionState:    nodes.Available,
+			expectedInterface: "http-ipxe",
+			expectUpdate:      true,
+		},
+		{
+			name:              "fleet-legacy",
+			fleetInterface:    "http-ipxe",
+			bootMode:          metal3v1alpha1.Legacy,
+			nodeInterface:     "ipxe",
+			provisionState:    nodes.Manageable,
+			expectedInterface: "ipxe",
+		},
+		{
+			name:              "deployed",
+			hostInterface:     "http",
+			nodeInterface:     "ipxe",
+			provisionState:    nodes.Active,
+			expectedInterface: "http",
+		},
+		{
+			name:              "virtual-media-node",
+			hostInterface:     "http",
+			nodeInterface:     "redfish-virtual-media",
+			provisionState:    nodes.Manageable,
+			expectedInterface: "http",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func(old string) { networkBootInterface = old }(networkBootInterface)
+			networkBootInterface = tc.fleetInterface
+
+			host := makeHost()
+			host.Spec.BootMode = tc.bootMode
+			if tc.hostInterface != "" {
+				host.Spec.NodeInterfaces = &metal3v1alpha1.NodeInterfaces{Boot: tc.hostInterface}
+			}
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				"https://ironic.test", auth, "https://ironic.test", auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			assert.Equal(t, tc.expectedInterface, prov.bootInterface())
+			updates := prov.bootInterfaceUpdates(&nodes.Node{
+				BootInterface:  tc.nodeInterface,
+				ProvisionState: string(tc.provisionState),
+			})
+			if tc.expectUpdate {
+				assert.Equal(t, nodes.UpdateOpts{
+					nodes.UpdateOperation{Op: nodes.ReplaceOp, Path: "/boot_interface", Value: tc.expectedInterface},
+				}, updates)
+			} else {
+				assert.Empty(t, updates)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package ironic
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
+)
+
+// dryRunPlanReason is the event reason used to report each change
+// the provisioner would have made to Ironic in dry-run mode.
+const dryRunPlanReason = "DryRunPlan"
+
+const redactedValue = "******"
+
+// plannedAction describes one change to Ironic that was skipped
+// because the host is in dry-run mode.
+type plannedAction struct {
+	Action         string                    `json:"action"`
+	Node           string                    `json:"node,omitempty"`
+	Create         *nodes.CreateOpts         `json:"create,omitempty"`
+	Port           *ports.CreateOpts         `json:"port,omitempty"`
+	PortUpdates    ports.UpdateOpts          `json:"portUpdates,omitempty"`
+	Updates        nodes.UpdateOpts          `json:"updates,omitempty"`
+	ProvisionState *nodes.ProvisionStateOpts `json:"provisionState,omitempty"`
+	PowerState     *nodes.PowerStateOpts     `json:"powerState,omitempty"`
+	RAID           *nodes.RAIDConfigOpts     `json:"raid,omitempty"`
+	DeploySteps    []deployStep              `json:"deploySteps,omitempty"`
+	Certificate    string                    `json:"certificate,omitempty"`
+	Target         string                    `json:"target,omitempty"`
+}
+
+// redactDriverInfo returns a copy of the driver info without the BMC
+// credentials, so they are not exposed in events.
+func redactDriverInfo(driverInfo map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(driverInfo))
+	for key, value := range driverInfo {
+		if strings.Contains(key, "password") {
+			value = redactedValue
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// recordPlannedAction reports an action that was not executed
+// because of dry-run mode.
+func (p *ironicProvisioner) recordPlannedAction(action plannedAction) {
+	if action.Create != nil {
+		opts := *action.Create
+		opts.DriverInfo = redactDriverInfo(opts.DriverInfo)
+		action.Create = &opts
+	}
+	if len(action.Updates) != 0 {
+		updates := make(nodes.UpdateOpts, len(action.Updates))
+		for i, patch := range action.Updates {
+			if update, ok := patch.(nodes.UpdateOperation); ok && update.Path == "/driver_info" {
+				if driverInfo, ok := update.Value.(map[string]interface{}); ok {
+					update.Value = redactDriverInfo(driverInfo)
+				}
+				patch = update
+			}
+			updates[i] = patch
+		}
+		action.Updates = updates
+	}
+	if action.ProvisionState != nil && action.ProvisionState.ConfigDrive != nil {
+		// The config drive holds the user data, which may contain
+		// secrets, and it is too large for an event anyway.
+		opts := *action.ProvisionState
+		opts.ConfigDrive = redactedValue
+		action.ProvisionState = &opts
+	}
+
+	plan, err := json.Marshal(action)
+	if err != nil {
+		p.log.Error(err, "could not encode planned action", "action", action.Action)
+		return
+	}
+	p.log.Info("dry-run, skipping action", "plan", string(plan))
+	p.publisher(dryRunPlanReason, string(plan))
+}
+
+// createNode registers a new node in Ironic.
+func (p *ironicProvisioner) createNode(opts nodes.CreateOpts) (*nodes.Node, error) {
+	if !p.dryRun {
+		return nodes.Create(p.client, opts).Extract()
+	}
+	p.recordPlannedAction(plannedAction{Action: "create", Create: &opts})
+	return &nodes.Node{
+		Name:           opts.Name,
+		Driver:         opts.Driver,
+		ProvisionState: string(nodes.Enroll),
+	}, nil
+}
+
+// createPort adds a port to a node in Ironic.
+func (p *ironicProvisioner) createPort(opts ports.CreateOpts) error {
+	if !p.dryRun {
+		_, err := ports.Create(p.client, opts).Extract()
+		return err
+	}
+	p.recordPlannedAction(plannedAction{Action: "createPort", Node: opts.NodeUUID, Port: &opts})
+	return nil
+}
+
+// updatePort applies the updates to the port in Ironic.
+func (p *ironicProvisioner) updatePort(nodeUUID, portUUID string, updates ports.UpdateOpts) error {
+	if !p.dryRun {
+		_, err := ports.Update(p.client, portUUID, updates).Extract()
+		return err
+	}
+	p.recordPlannedAction(plannedAction{Action: "updatePort", Node: nodeUUID, PortUpdates: updates})
+	return nil
+}
+
+// updateNode applies the updates to the node in Ironic. In dry-run
+// mode, the node is returned unchanged.
+func (p *ironicProvisioner) updateNode(ironicNode *nodes.Node, updates nodes.UpdateOpts) (*nodes.Node, error) {
+	if !p.dryRun {
+		return nodes.Update(p.client, ironicNode.UUID, updates).Extract()
+	}
+	p.recordPlannedAction(plannedAction{Action: "update", Node: ironicNode.UUID, Updates: updates})
+	return ironicNode, nil
+}
+
+// changeProvisionState asks Ironic to move the node to a new
+// provisioning state.
+func (p *ironicProvisioner) changeProvisionState(ironicNode *nodes.Node, opts nodes.ProvisionStateOpts) error {
+	var deploySteps []deployStep
+	if opts.Target == nodes.TargetActive {
+		deploySteps = buildDeploySteps(&p.host)
+	}
+	if !p.dryRun {
+		if len(deploySteps) == 0 {
+			return nodes.ChangeProvisionState(p.client, ironicNode.UUID, opts).Err
+		}
+		client := *p.client
+		client.Microversion = deployStepsMicroversion
+		return nodes.ChangeProvisionState(&client, ironicNode.UUID,
+			deployOpts{ProvisionStateOpts: opts, DeploySteps: deploySteps}).Err
+	}
+	p.recordPlannedAction(plannedAction{Action: "provision", Node: ironicNode.UUID, ProvisionState: &opts,
+		DeploySteps: deploySteps})
+	return nil
+}
+
+// changePowerState asks Ironic to change the power state of the node.
+func (p *ironicProvisioner) changePowerState(ironicNode *nodes.Node, opts nodes.PowerStateOpts) error {
+	if !p.dryRun {
+		return nodes.ChangePowerState(p.client, ironicNode.UUID, opts).Err
+	}
+	p.recordPlannedAction(plannedAction{Action: "power", Node: ironicNode.UUID, PowerState: &opts})
+	return nil
+}
+
+// setRAIDConfig sets the target RAID configuration of the node.
+func (p *ironicProvisioner) setRAIDConfig(ironicNode *nodes.Node, opts nodes.RAIDConfigOpts) error {
+	if !p.dryRun {
+		return nodes.SetRAIDConfig(p.client, ironicNode.UUID, opts).ExtractErr()
+	}
+	p.recordPlannedAction(plannedAction{Action: "raid", Node: ironicNode.UUID, RAID: &opts})
+	return nil
+}
+
+// deleteNode removes the node from Ironic.
+func (p *ironicProvisioner) deleteNode(ironicNode *nodes.Node) error {
+	if !p.dryRun {
+		return nodes.Delete(p.client, ironicNode.UUID).ExtractErr()
+	}
+	p.recordPlannedAction(plannedAction{Action: "delete", Node: ironicNode.UUID})
+	return nil
+}
@@ -0,0 +1,149 @@
+package ironic
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestDryRunPowerOn(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+		PowerState: powerOff,
+		UUID:       nodeUUID,
+	})
+	ironic.Start()
+	defer ironic.Stop()
+
+	host := makeHost()
+	host.Annotations = map[string]string{
+		metal3v1alpha1.DryRunAnnotation: "",
+	}
+
+	type event struct{ reason, message string }
+	var events []event
+	publisher := func(reason, message string) {
+		events = append(events, event{reason, message})
+	}
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+	prov.status.ID = nodeUUID
+
+	_, err = prov.PowerOn("")
+	assert.NoError(t, err)
+
+	assert.False(t, strings.Contains(ironic.Requests, "/states/power"),
+		"unexpected power request: %s", ironic.Requests)
+
+	if assert.NotEmpty(t, events) {
+		assert.Equal(t, dryRunPlanReason, events[0].reason)
+		var action plannedAction
+		assert.NoError(t, json.Unmarshal([]byte(events[0].message), &action))
+		assert.Equal(t, "power", action.Action)
+		assert.Equal(t, nodeUUID, action.Node)
+		assert.Equal(t, nodes.PowerOn, action.PowerState.Target)
+	}
+}
+
+// TestDryRunRequestNotRecorded ensures that the idempotency token of a
+// request is neither saved nor reported as a planned action.
+func TestDryRunRequestNotRecorded(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+		PowerState: powerOff,
+		UUID:       nodeUUID,
+	})
+	ironic.Start()
+	defer ironic.Stop()
+
+	host := makeHost()
+	host.Annotations = map[string]string{
+		metal3v1alpha1.DryRunAnnotation: "",
+	}
+
+	var plans []string
+	publisher := func(reason, message string) {
+		if reason == dryRunPlanReason {
+			plans = append(plans, message)
+		}
+	}
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+	prov.status.ID = nodeUUID
+
+	_, err = prov.PowerOn("power-on-request")
+	assert.NoError(t, err)
+
+	_, patched := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID, http.MethodPatch)
+	assert.False(t, patched)
+	if assert.Len(t, plans, 1) {
+		var action plannedAction
+		assert.NoError(t, json.Unmarshal([]byte(plans[0]), &action))
+		assert.Equal(t, "power", action.Action)
+	}
+}
+
+func TestDryRunRedactsCredentials(t *testing.T) {
+	var messages []string
+	p := &ironicProvisioner{
+		log: log,
+		publisher: func(reason, message string) {
+			messages = append(messages, message)
+		},
+	}
+
+	p.recordPlannedAction(plannedAction{
+		Action: "create",
+		Create: &nodes.CreateOpts{
+			DriverInfo: map[string]interface{}{
+				"ipmi_username": "admin",
+				"ipmi_password": "secret",
+			},
+		},
+	})
+	p.recordPlannedAction(plannedAction{
+		Action: "update",
+		Updates: nodes.UpdateOpts{
+			nodes.UpdateOperation{
+				Op:    nodes.ReplaceOp,
+				Path:  "/driver_info",
+				Value: map[string]interface{}{"redfish_password": "secret"},
+			},
+		},
+	})
+	p.recordPlannedAction(plannedAction{
+		Action: "provision",
+		ProvisionState: &nodes.ProvisionStateOpts{
+			Target:      nodes.TargetActive,
+			ConfigDrive: map[string]interface{}{"user_data": "secret"},
+		},
+	})
+
+	assert.Len(t, messages, 3)
+	for _, msg := range messages {
+		assert.NotContains(t, msg, "secret")
+		assert.Contains(t, msg, redactedValue)
+	}
+	assert.Contains(t, messages[0], "admin")
+}
@@ -0,0 +1,226 @@
+package ironic
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestErase(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	cases := []struct {
+		name                 string
+		provisionState       nodes.ProvisionState
+		maintenance          bool
+		start                bool
+		expectedStarted      bool
+		expectedDirty        bool
+		expectedError        string
+		expectedErr          bool
+		expectedRequestAfter int
+	}{
+		{
+			name:                 "available state(move to manageable)",
+			provisionState:       nodes.Available,
+			start:                true,
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+		},
+		{
+			name:           "available state(not erased)",
+			provisionState: nodes.Available,
+			expectedError:  "host became available before its disks were erased",
+		},
+		{
+			name:                 "manageable state(start erasure)",
+			provisionState:       nodes.Manageable,
+			start:                true,
+			expectedStarted:      true,
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+		},
+		{
+			name:           "manageable state(erasure finished)",
+			provisionState: nodes.Manageable,
+		},
+		{
+			name:                 "cleaning state",
+			provisionState:       nodes.Cleaning,
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+		},
+		{
+			name:                 "cleanWait state",
+			provisionState:       nodes.CleanWait,
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+		},
+		{
+			name:           "cleanFail state(erasure failed)",
+			provisionState: nodes.CleanFail,
+			expectedError:  "erase_devices failed",
+		},
+		{
+			name:                 "cleanFail state(clear maintenance)",
+			provisionState:       nodes.CleanFail,
+			maintenance:          true,
+			start:                true,
+			expectedDirty:        true,
+			expectedRequestAfter: 0,
+		},
+		{
+			name:                 "cleanFail state(set ironic host to manageable)",
+			provisionState:       nodes.CleanFail,
+			start:                true,
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+		},
+		{
+			name:           "active state",
+			provisionState: nodes.Active,
+			start:          true,
+			expectedErr:    true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(tc.provisionState),
+				UUID:           nodeUUID,
+				Maintenance:    tc.maintenance,
+				LastError:      "erase_devices failed",
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			publisher := func(reason, message string) {}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+				ironic.Endpoint(), auth, "https://inspector.test/", auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			prov.status.ID = nodeUUID
+			result, started, err := prov.Erase(tc.start, provisioner.EraseOptions{})
+
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStarted, started)
+			assert.Equal(t, tc.expectedDirty, result.Dirty)
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+			assert.Equal(t, time.Second*time.Duration(tc.expectedRequestAfter), result.RequeueAfter)
+		})
+	}
+}
+
+func TestSkipBlockDevicesUpdates(t *testing.T) {
+	skipped := []interface{}{map[string]interface{}{"serial": "abc"}}
+	cases := []struct {
+		name       string
+		properties map[string]interface{}
+		serials    []string
+		expected   nodes.UpdateOpts
+	}{
+		{
+			name: "none",
+		},
+		{
+			name:     "add",
+			serials:  []string{"abc"},
+			expected: nodes.UpdateOpts{nodes.UpdateOperation{Op: nodes.AddOp, Path: "/properties/skip_block_devices", Value: skipped}},
+		},
+		{
+			name:       "unchanged",
+			properties: map[string]interface{}{"skip_block_devices": skipped},
+			serials:    []string{"abc"},
+		},
+		{
+			name:       "remove",
+			properties: map[string]interface{}{"skip_block_devices": skipped},
+			expected:   nodes.UpdateOpts{nodes.UpdateOperation{Op: nodes.RemoveOp, Path: "/properties/skip_block_devices"}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			updates := skipBlockDevicesUpdates(&nodes.Node{Properties: tc.properties}, tc.serials)
+			assert.Equal(t, tc.expected, updates)
+		})
+	}
+}
+
+func TestEraseBypass(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	cases := []struct {
+		name         string
+		opts         provisioner.EraseOptions
+		expectedStep string
+	}{
+		{
+			name:         "secure erase",
+			opts:         provisioner.EraseOptions{SkipSerials: []string{"abc"}},
+			expectedStep: "erase_devices",
+		},
+		{
+			name:         "metadata wipe",
+			opts:         provisioner.EraseOptions{MetadataOnly: true},
+			expectedStep: "erase_devices_metadata",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			node := nodes.Node{
+				ProvisionState: string(nodes.Manageable),
+				UUID:           nodeUUID,
+				Properties: map[string]interface{}{
+					"skip_block_devices": []interface{}{map[string]interface{}{"serial": "def"}},
+				},
+			}
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(node).NodeUpdate(node)
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			publisher := func(reason, message string) {}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+				ironic.Endpoint(), auth, "https://inspector.test/", auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			prov.status.ID = nodeUUID
+			_, started, err := prov.Erase(true, tc.opts)
+			assert.NoError(t, err)
+			assert.True(t, started)
+
+			updates := ironic.GetLastNodeUpdateRequestFor(nodeUUID)
+			if assert.Len(t, updates, 1) {
+				assert.Equal(t, "/properties/skip_block_devices", updates[0].Path)
+				if len(tc.opts.SkipSerials) != 0 {
+					assert.Equal(t, []interface{}{map[string]interface{}{"serial": "abc"}}, updates[0].Value)
+				} else {
+					assert.Equal(t, nodes.RemoveOp, updates[0].Op)
+				}
+			}
+
+			body, _ := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID+"/states/provision", http.MethodPut)
+			assert.Contains(t, body, `"step":"`+tc.expectedStep+`"`)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/fixture"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func makeHostVirtualMedia() v1alpha1.BareMetalHost {
+	host := makeHost()
+	host.Spec.BMC.Address = "redfish-virtualmedia://192.168.122.1:8000/redfish/v1/Systems/1"
+	return host
+}
+
+func TestGoldenFixtures(t *testing.T) {
+	hostConf := fixture.NewHostConfigData("testUserData", "test: NetworkData", "test: Meta")
+
+	cases := []struct {
+		fixture string
+		host    func() v1alpha1.BareMetalHost
+		action  func(p *ironicProvisioner) (provisioner.Result, error)
+	}{
+		{
+			fixture: "node-lifecycle",
+			host:    makeHost,
+			action: func(p *ironicProvisioner) (provisioner.Result, error) {
+				return p.Provision(hostConf, "")
+			},
+		},
+		{
+			fixture: "cleaning",
+			host:    makeHost,
+			action: func(p *ironicProvisioner) (provisioner.Result, error) {
+				return p.Deprovision(false, "")
+			},
+		},
+		{
+			fixture: "virtual-media",
+			host:    makeHostVirtualMedia,
+			action: func(p *ironicProvisioner) (provisioner.Result, error) {
+				return p.Provision(hostConf, "")
+			},
+		},
+	}
+
+	assert.Len(t, cases, len(testserver.FixtureNames()), "every fixture must be tested")
+
+	for _, tc := range cases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			golden, err := testserver.LoadFixture(tc.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ironic := testserver.NewIronic(t).Ready().WithFixture(tc.fixture)
+			ironic.Start()
+			defer ironic.Stop()
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(tc.host(), bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, "https://inspector.test", auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+			prov.status.ID = golden.NodeUUID
+
+			// Each call sees the next recorded state of the node,
+			// until the operation completes.
+			var result provisioner.Result
+			for i := 0; i < 10; i++ {
+				result, err = tc.action(prov)
+				if !assert.NoError(t, err) || !assert.Empty(t, result.ErrorMessage) || !result.Dirty {
+					break
+				}
+			}
+			assert.False(t, result.Dirty, "the operation did not complete")
+			_, requested := ironic.GetLastRequestFor("/v1/nodes/"+golden.NodeUUID+"/states/provision", "PUT")
+			assert.True(t, requested)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
@@ -18,9 +19,16 @@ func GetHardwareDetails(data *introspection.Data) *metal3v1alpha1.HardwareDetail
 	details.SystemVendor = getSystemVendorDetails(data.Inventory.SystemVendor)
 	details.RAMMebibytes = data.MemoryMB
 	details.NIC = getNICDetails(data.Inventory.Interfaces, data.AllInterfaces, data.Extra.Network)
+	details.NICMismatches = getNICMismatches(details.NIC)
 	details.Storage = getStorageDetails(data.Inventory.Disks)
+	getDiskHealth(details.Storage, data.Extra.Disk)
 	details.CPU = getCPUDetails(&data.Inventory.CPU)
+	details.CPU.Sockets = getExtraInt(data.Extra.CPU["physical"], "number")
+	details.CPU.ThreadsPerCore = getThreadsPerCore(&data.NUMATopology, data.Extra.CPU)
+	details.CPU.HugepageSizes = getHugepageSizes(&data.Inventory.CPU)
+	details.CPU.NUMANodes = getNUMANodes(&data.NUMATopology)
 	details.Hostname = data.Inventory.Hostname
+	details.Benchmarks = getBenchmarkResults(data.Extra)
 	return details
 }
 
@@ -56,6 +64,37 @@ func getNICSpeedGbps(intfExtradata introspection.ExtraHardwareData) (speedGbps i
 	return
 }
 
+func getNICString(intfExtradata introspection.ExtraHardwareData, key string) string {
+	value, _ := intfExtradata[key].(string)
+	return value
+}
+
+func getNICDriver(intfExtradata introspection.ExtraHardwareData) string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s",
+		getNICString(intfExtradata, "driver"),
+		getNICString(intfExtradata, "driverversion")))
+}
+
+// getLinkAggregationID returns the ID of the link aggregation group
+// of the switch port the interface is connected to, or 0 if the port
+// is not aggregated.
+func getLinkAggregationID(intf introspection.BaseInterfaceType) int {
+	if intf.LLDPProcessed == nil {
+		return 0
+	}
+	if enabled, _ := intf.LLDPProcessed["switch_port_link_aggregation_enabled"].(bool); !enabled {
+		return 0
+	}
+	// Numbers decoded from JSON are float64
+	switch id := intf.LLDPProcessed["switch_port_link_aggregation_id"].(type) {
+	case int:
+		return id
+	case float64:
+		return int(id)
+	}
+	return 0
+}
+
 func getNICDetails(ifdata []introspection.InterfaceType,
 	basedata map[string]introspection.BaseInterfaceType,
 	extradata introspection.ExtraHardwareDataSection) []metal3v1alpha1.NIC {
@@ -70,12 +109,17 @@ func getNICDetails(ifdata []introspection.InterfaceType,
 				Name: intf.Name,
 				Model: strings.TrimLeft(fmt.Sprintf("%s %s",
 					intf.Vendor, intf.Product), " "),
-				MAC:       intf.MACAddress,
-				IP:        intf.IPV4Address,
-				VLANs:     vlans,
-				VLANID:    vlanid,
-				SpeedGbps: getNICSpeedGbps(extradata[intf.Name]),
-				PXE:       baseIntf.PXE,
+				MAC:               intf.MACAddress,
+				IP:                intf.IPV4Address,
+				VLANs:             vlans,
+				VLANID:            vlanid,
+				SpeedGbps:         getNICSpeedGbps(extradata[intf.Name]),
+				PXE:               baseIntf.PXE,
+				FirmwareVersion:   getNICString(extradata[intf.Name], "firmware"),
+				Driver:            getNICDriver(extradata[intf.Name]),
+				Duplex:            getNICString(extradata[intf.Name], "duplex"),
+				AutoNegotiation:   getNICString(extradata[intf.Name], "autonegotiation"),
+				LinkAggregationID: getLinkAggregationID(baseIntf),
 			})
 		}
 		if intf.IPV6Address != "" {
@@ -83,18 +127,100 @@ func getNICDetails(ifdata []introspection.InterfaceType,
 				Name: intf.Name,
 				Model: strings.TrimLeft(fmt.Sprintf("%s %s",
 					intf.Vendor, intf.Product), " "),
-				MAC:       intf.MACAddress,
-				IP:        intf.IPV6Address,
-				VLANs:     vlans,
-				VLANID:    vlanid,
-				SpeedGbps: getNICSpeedGbps(extradata[intf.Name]),
-				PXE:       baseIntf.PXE,
+				MAC:               intf.MACAddress,
+				IP:                intf.IPV6Address,
+				VLANs:             vlans,
+				VLANID:            vlanid,
+				SpeedGbps:         getNICSpeedGbps(extradata[intf.Name]),
+				PXE:               baseIntf.PXE,
+				FirmwareVersion:   getNICString(extradata[intf.Name], "firmware"),
+				Driver:            getNICDriver(extradata[intf.Name]),
+				Duplex:            getNICString(extradata[intf.Name], "duplex"),
+				AutoNegotiation:   getNICString(extradata[intf.Name], "autonegotiation"),
+				LinkAggregationID: getLinkAggregationID(baseIntf),
 			})
 		}
 	}
 	return nics
 }
 
+// getNICMismatches compares the NICs connected to each link
+// aggregation group and reports the fields on which they differ.
+// Fields that were not reported for a NIC are not compared.
+func getNICMismatches(nics []metal3v1alpha1.NIC) []metal3v1alpha1.NICMismatch {
+	groups := map[int][]metal3v1alpha1.NIC{}
+	var ids []int
+	for _, nic := range nics {
+		if nic.LinkAggregationID == 0 {
+			continue
+		}
+		members := groups[nic.LinkAggregationID]
+		if len(members) == 0 {
+			ids = append(ids, nic.LinkAggregationID)
+		}
+		duplicate := false
+		for _, member := range members {
+			// Dual-stack interfaces are listed once per address
+			if member.Name == nic.Name {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			groups[nic.LinkAggregationID] = append(members, nic)
+		}
+	}
+	sort.Ints(ids)
+
+	fields := []struct {
+		name  string
+		value func(metal3v1alpha1.NIC) string
+	}{
+		{"firmwareVersion", func(nic metal3v1alpha1.NIC) string { return nic.FirmwareVersion }},
+		{"driver", func(nic metal3v1alpha1.NIC) string { return nic.Driver }},
+		{"speedGbps", func(nic metal3v1alpha1.NIC) string {
+			if nic.SpeedGbps == 0 {
+				return ""
+			}
+			return fmt.Sprintf("%d", nic.SpeedGbps)
+		}},
+		{"duplex", func(nic metal3v1alpha1.NIC) string { return nic.Duplex }},
+		{"autoNegotiation", func(nic metal3v1alpha1.NIC) string { return nic.AutoNegotiation }},
+	}
+
+	var mismatches []metal3v1alpha1.NICMismatch
+	for _, id := range ids {
+		members := groups[id]
+		if len(members) < 2 {
+			continue
+		}
+		names := make([]string, len(members))
+		for i, nic := range members {
+			names[i] = nic.Name
+		}
+		for _, field := range fields {
+			seen := ""
+			for _, nic := range members {
+				value := field.value(nic)
+				if value == "" {
+					continue
+				}
+				if seen == "" {
+					seen = value
+				} else if value != seen {
+					mismatches = append(mismatches, metal3v1alpha1.NICMismatch{
+						LinkAggregationID: id,
+						NICs:              names,
+						Field:             field.name,
+					})
+					break
+				}
+			}
+		}
+	}
+	return mismatches
+}
+
 func getStorageDetails(diskdata []introspection.RootDiskType) []metal3v1alpha1.Storage {
 	storage := make([]metal3v1alpha1.Storage, len(diskdata))
 	for i, disk := range diskdata {
@@ -114,6 +240,75 @@ func getStorageDetails(diskdata []introspection.RootDiskType) []metal3v1alpha1.S
 	return storage
 }
 
+// ataRemainingLifeAttributes are the SMART attributes of SATA SSDs
+// whose normalized value is the percentage of their endurance left.
+var ataRemainingLifeAttributes = []string{
+	"SMART/Media_Wearout_Indicator(233)/value",
+	"SMART/Wear_Leveling_Count(177)/value",
+	"SMART/Percent_Lifetime_Remain(202)/value",
+}
+
+// getDiskHealth adds the SMART health reported by the extra-hardware
+// collector to the disks it knows, by their device name. NVMe disks
+// report a critical warning, the percentage of their endurance used
+// and their media errors, SATA disks their overall health, remaining
+// life and uncorrectable errors.
+func getDiskHealth(storage []metal3v1alpha1.Storage, extradata introspection.ExtraHardwareDataSection) {
+	for i := range storage {
+		diskdata, found := extradata[strings.TrimPrefix(storage[i].Name, "/dev/")]
+		if !found {
+			continue
+		}
+		health := metal3v1alpha1.DiskHealth{Status: metal3v1alpha1.DiskHealthOK}
+		reported := false
+		var problems []string
+
+		if overall, ok := diskdata["SMART/overall_health"].(string); ok {
+			reported = true
+			if overall != "PASSED" && overall != "OK" {
+				problems = append(problems, fmt.Sprintf("SMART health check %s", overall))
+			}
+		}
+		if _, ok := diskdata["SMART/critical_warning"]; ok {
+			reported = true
+			if warning := getExtraInt(diskdata, "SMART/critical_warning"); warning != 0 {
+				problems = append(problems, fmt.Sprintf("critical warning 0x%x", warning))
+			}
+		}
+		if _, ok := diskdata["SMART/percentage_used"]; ok {
+			reported = true
+			health.WearPercent = getExtraInt(diskdata, "SMART/percentage_used")
+		} else {
+			for _, attribute := range ataRemainingLifeAttributes {
+				if _, ok := diskdata[attribute]; ok {
+					reported = true
+					health.WearPercent = 100 - getExtraInt(diskdata, attribute)
+					break
+				}
+			}
+		}
+		if health.WearPercent >= 100 {
+			problems = append(problems, fmt.Sprintf("%d%% of its endurance used", health.WearPercent))
+		}
+		for _, key := range []string{"SMART/media_errors", "SMART/Reported_Uncorrect(187)/raw"} {
+			if _, ok := diskdata[key]; ok {
+				reported = true
+				health.MediaErrors = getExtraInt(diskdata, key)
+				break
+			}
+		}
+
+		if !reported {
+			continue
+		}
+		if len(problems) != 0 {
+			health.Status = metal3v1alpha1.DiskHealthFailing
+			health.Message = strings.Join(problems, ", ")
+		}
+		storage[i].Health = &health
+	}
+}
+
 func getSystemVendorDetails(vendor introspection.SystemVendorType) metal3v1alpha1.HardwareSystemVendor {
 	return metal3v1alpha1.HardwareSystemVendor{
 		Manufacturer: vendor.Manufacturer,
@@ -138,6 +333,93 @@ func getCPUDetails(cpudata *introspection.CPUType) metal3v1alpha1.CPU {
 	return cpu
 }
 
+// getThreadsPerCore returns the number of hardware threads of each
+// core, from the thread siblings in the NUMA topology, or else from the
+// threads and cores of the first socket reported by the
+// extra-hardware collector.
+func getThreadsPerCore(topology *introspection.NUMATopology, extraCPU introspection.ExtraHardwareDataSection) int {
+	threads := 0
+	for _, core := range topology.CPUs {
+		if len(core.ThreadSiblings) > threads {
+			threads = len(core.ThreadSiblings)
+		}
+	}
+	if threads > 0 {
+		return threads
+	}
+	if cores := getExtraInt(extraCPU["physical_0"], "cores"); cores > 0 {
+		return getExtraInt(extraCPU["physical_0"], "threads") / cores
+	}
+	return 0
+}
+
+// x86HugepageFlags are the CPU flags of x86 processors telling the
+// huge page sizes they support, from the smallest.
+var x86HugepageFlags = []struct {
+	flag string
+	size string
+}{
+	{"pse", "2Mi"},
+	{"pdpe1gb", "1Gi"},
+}
+
+// getHugepageSizes returns the huge page sizes the CPUs support. They
+// are only known for x86 processors, from their flags.
+func getHugepageSizes(cpudata *introspection.CPUType) []string {
+	if cpudata.Architecture != "x86_64" {
+		return nil
+	}
+	flags := make(map[string]bool, len(cpudata.Flags))
+	for _, flag := range cpudata.Flags {
+		flags[flag] = true
+	}
+	var sizes []string
+	for _, hugepage := range x86HugepageFlags {
+		if flags[hugepage.flag] {
+			sizes = append(sizes, hugepage.size)
+		}
+	}
+	return sizes
+}
+
+// getNUMANodes converts the NUMA topology reported by the
+// numa-topology collector, in which each CPU entry is a core with its
+// thread siblings.
+func getNUMANodes(topology *introspection.NUMATopology) []metal3v1alpha1.NUMANode {
+	nodes := make(map[int]*metal3v1alpha1.NUMANode)
+	node := func(id int) *metal3v1alpha1.NUMANode {
+		if _, found := nodes[id]; !found {
+			nodes[id] = &metal3v1alpha1.NUMANode{ID: id}
+		}
+		return nodes[id]
+	}
+	for _, core := range topology.CPUs {
+		n := node(core.NUMANode)
+		n.CPUs = append(n.CPUs, core.ThreadSiblings...)
+	}
+	for _, ram := range topology.RAM {
+		node(ram.NUMANode).RAMMebibytes += ram.SizeKB / 1024
+	}
+	for _, nic := range topology.NICs {
+		n := node(nic.NUMANode)
+		n.NICs = append(n.NICs, nic.Name)
+	}
+
+	if len(nodes) == 0 {
+		return nil
+	}
+	result := make([]metal3v1alpha1.NUMANode, 0, len(nodes))
+	for _, n := range nodes {
+		sort.Ints(n.CPUs)
+		sort.Strings(n.NICs)
+		result = append(result, *n)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
 func getFirmwareDetails(firmwaredata introspection.ExtraHardwareDataSection) metal3v1alpha1.Firmware {
 
 	// handle bios optionally
@@ -157,3 +439,144 @@ func getFirmwareDetails(firmwaredata introspection.ExtraHardwareDataSection) met
 	}
 
 }
+
+// getExtraInt returns a number reported by the extra-hardware
+// collector, which may come as a string when the inspector could not
+// convert it.
+func getExtraInt(data introspection.ExtraHardwareData, key string) int {
+	switch value := data[key].(type) {
+	case float64:
+		return int(value)
+	case string:
+		number, _ := strconv.Atoi(value)
+		return number
+	}
+	return 0
+}
+
+// getBenchmarkResults extracts the results of the benchmarks run by
+// the extra-hardware collector, or nil when none ran.
+func getBenchmarkResults(extra introspection.ExtraHardwareDataType) *metal3v1alpha1.BenchmarkResults {
+	results := &metal3v1alpha1.BenchmarkResults{
+		MemoryBandwidthMBps: getExtraInt(extra.CPU["logical"], "threaded_bandwidth_1G"),
+	}
+
+	for name, diskdata := range extra.Disk {
+		if throughput := getExtraInt(diskdata, "standalone_read_1M_KBps"); throughput > 0 {
+			results.Disks = append(results.Disks, metal3v1alpha1.DiskBenchmarkResult{
+				Name:               name,
+				SequentialReadKBps: throughput,
+			})
+		}
+	}
+	sort.Slice(results.Disks, func(i, j int) bool {
+		return results.Disks[i].Name < results.Disks[j].Name
+	})
+
+	if results.MemoryBandwidthMBps == 0 && len(results.Disks) == 0 {
+		return nil
+	}
+	return results
+}
+
+// AcceleratorData is the part of the introspection data describing
+// GPUs and other accelerators, which gophercloud does not decode: the
+// gpu category of the extra-hardware collector and the devices found by
+// the pci-devices collector.
+type AcceleratorData struct {
+	Extra struct {
+		GPU introspection.ExtraHardwareDataSection `json:"gpu"`
+	} `json:"extra"`
+	PCIDevices []PCIDevice `json:"pci_devices"`
+}
+
+// PCIDevice is a device reported by the pci-devices collector.
+type PCIDevice struct {
+	VendorID  string `json:"vendor_id"`
+	ProductID string `json:"product_id"`
+	Class     string `json:"class"`
+	Bus       string `json:"bus"`
+}
+
+// pciVendors names the vendors of GPUs and accelerators by their PCI
+// vendor ID.
+var pciVendors = map[string]string{
+	"10de": "NVIDIA",
+	"1002": "AMD",
+	"8086": "Intel",
+	"1da3": "Habana Labs",
+}
+
+// pciAcceleratorType returns the kind of accelerator a PCI device is,
+// by its class, or an empty string if it is not one. VGA controllers
+// are only GPUs when made by a GPU vendor, as servers also have one
+// for the console of their BMC.
+func pciAcceleratorType(device PCIDevice) metal3v1alpha1.AcceleratorType {
+	class := strings.TrimPrefix(strings.ToLower(device.Class), "0x")
+	vendor := strings.TrimPrefix(strings.ToLower(device.VendorID), "0x")
+	switch {
+	case strings.HasPrefix(class, "0302"), strings.HasPrefix(class, "0380"):
+		return metal3v1alpha1.GPUAccelerator
+	case strings.HasPrefix(class, "0300") && (vendor == "10de" || vendor == "1002"):
+		return metal3v1alpha1.GPUAccelerator
+	case strings.HasPrefix(class, "12"):
+		return metal3v1alpha1.ProcessingAccelerator
+	}
+	return ""
+}
+
+// GetAccelerators returns the GPUs and other accelerators of the host.
+// The GPUs reported by the extra-hardware collector, with their vendor,
+// product, businfo and memory_mb, come with the most details. The
+// accelerators only found by the pci-devices collector are added with
+// their PCI IDs.
+func GetAccelerators(data *AcceleratorData) []metal3v1alpha1.Accelerator {
+	var accelerators []metal3v1alpha1.Accelerator
+	byAddress := map[string]int{}
+
+	var names []string
+	for name := range data.Extra.GPU {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		gpu := data.Extra.GPU[name]
+		accelerator := metal3v1alpha1.Accelerator{
+			Type:          metal3v1alpha1.GPUAccelerator,
+			Vendor:        getNICString(gpu, "vendor"),
+			Model:         getNICString(gpu, "product"),
+			PCIAddress:    strings.TrimPrefix(getNICString(gpu, "businfo"), "pci@"),
+			VRAMMebibytes: getExtraInt(gpu, "memory_mb"),
+		}
+		if accelerator.PCIAddress != "" {
+			byAddress[accelerator.PCIAddress] = len(accelerators)
+		}
+		accelerators = append(accelerators, accelerator)
+	}
+
+	for _, device := range data.PCIDevices {
+		acceleratorType := pciAcceleratorType(device)
+		if acceleratorType == "" {
+			continue
+		}
+		vendorID := strings.TrimPrefix(strings.ToLower(device.VendorID), "0x")
+		deviceID := strings.TrimPrefix(strings.ToLower(device.ProductID), "0x")
+		if i, found := byAddress[device.Bus]; found && device.Bus != "" {
+			accelerators[i].VendorID = vendorID
+			accelerators[i].DeviceID = deviceID
+			continue
+		}
+		accelerators = append(accelerators, metal3v1alpha1.Accelerator{
+			Type:       acceleratorType,
+			Vendor:     pciVendors[vendorID],
+			VendorID:   vendorID,
+			DeviceID:   deviceID,
+			PCIAddress: device.Bus,
+		})
+	}
+
+	sort.SliceStable(accelerators, func(i, j int) bool {
+		return accelerators[i].PCIAddress < accelerators[j].PCIAddress
+	})
+	return accelerators
+}
@@ -1,6 +1,7 @@
 package hardwaredetails
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 
@@ -233,6 +234,151 @@ func TestGetNICDetails(t *testing.T) {
 	}
 }
 
+func TestGetNICDetailsFirmwareAndLink(t *testing.T) {
+	nics := getNICDetails(
+		[]introspection.InterfaceType{
+			{
+				Name:        "eth0",
+				IPV4Address: "192.0.2.1",
+				MACAddress:  "00:11:22:33:44:55"},
+		},
+		map[string]introspection.BaseInterfaceType{
+			"eth0": {
+				LLDPProcessed: map[string]interface{}{
+					"switch_port_link_aggregation_enabled": true,
+					"switch_port_link_aggregation_id":      float64(7),
+				},
+			},
+		},
+		introspection.ExtraHardwareDataSection{
+			"eth0": introspection.ExtraHardwareData{
+				"speed":           "25Gbps",
+				"firmware":        "6.01 0x800034a4 1.1747.0",
+				"driver":          "i40e",
+				"driverversion":   "2.8.20-k",
+				"duplex":          "full",
+				"autonegotiation": "on",
+			},
+		})
+
+	if len(nics) != 1 {
+		t.Fatalf("Expected 1 NIC, got %d", len(nics))
+	}
+	if (!reflect.DeepEqual(nics[0], metal3v1alpha1.NIC{
+		Name:              "eth0",
+		MAC:               "00:11:22:33:44:55",
+		IP:                "192.0.2.1",
+		SpeedGbps:         25,
+		FirmwareVersion:   "6.01 0x800034a4 1.1747.0",
+		Driver:            "i40e 2.8.20-k",
+		Duplex:            "full",
+		AutoNegotiation:   "on",
+		LinkAggregationID: 7,
+	})) {
+		t.Errorf("Unexpected NIC data %+v", nics[0])
+	}
+}
+
+func TestGetLinkAggregationID(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario string
+		LLDP     map[string]interface{}
+		Expected int
+	}{
+		{
+			Scenario: "no lldp",
+		},
+		{
+			Scenario: "enabled",
+			LLDP: map[string]interface{}{
+				"switch_port_link_aggregation_enabled": true,
+				"switch_port_link_aggregation_id":      3,
+			},
+			Expected: 3,
+		},
+		{
+			Scenario: "disabled",
+			LLDP: map[string]interface{}{
+				"switch_port_link_aggregation_enabled": false,
+				"switch_port_link_aggregation_id":      3,
+			},
+		},
+		{
+			Scenario: "malformed",
+			LLDP: map[string]interface{}{
+				"switch_port_link_aggregation_enabled": true,
+				"switch_port_link_aggregation_id":      "3",
+			},
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			actual := getLinkAggregationID(introspection.BaseInterfaceType{
+				LLDPProcessed: tc.LLDP,
+			})
+			if actual != tc.Expected {
+				t.Errorf("Expected %d, got %d", tc.Expected, actual)
+			}
+		})
+	}
+}
+
+func TestGetNICMismatches(t *testing.T) {
+	mismatches := getNICMismatches([]metal3v1alpha1.NIC{
+		{
+			Name:              "eth0",
+			IP:                "192.0.2.1",
+			SpeedGbps:         25,
+			FirmwareVersion:   "6.01",
+			Duplex:            "full",
+			LinkAggregationID: 1,
+		},
+		{
+			Name:              "eth0",
+			IP:                "2001:db8::1",
+			SpeedGbps:         25,
+			FirmwareVersion:   "6.01",
+			Duplex:            "full",
+			LinkAggregationID: 1,
+		},
+		{
+			Name:              "eth1",
+			SpeedGbps:         10,
+			FirmwareVersion:   "6.02",
+			LinkAggregationID: 1,
+		},
+		{
+			Name:              "eth2",
+			SpeedGbps:         10,
+			LinkAggregationID: 2,
+		},
+		{
+			Name:              "eth3",
+			SpeedGbps:         10,
+			LinkAggregationID: 2,
+		},
+		{
+			Name:      "eth4",
+			SpeedGbps: 1,
+		},
+	})
+
+	expected := []metal3v1alpha1.NICMismatch{
+		{
+			LinkAggregationID: 1,
+			NICs:              []string{"eth0", "eth1"},
+			Field:             "firmwareVersion",
+		},
+		{
+			LinkAggregationID: 1,
+			NICs:              []string{"eth0", "eth1"},
+			Field:             "speedGbps",
+		},
+	}
+	if !reflect.DeepEqual(mismatches, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, mismatches)
+	}
+}
+
 func TestGetNICSpeedGbps(t *testing.T) {
 	s1 := getNICSpeedGbps(introspection.ExtraHardwareData{
 		"speed": "25Gbps",
@@ -304,3 +450,231 @@ func TestGetFirmwareDetails(t *testing.T) {
 	}
 
 }
+
+func TestGetBenchmarkResults(t *testing.T) {
+	results := getBenchmarkResults(introspection.ExtraHardwareDataType{
+		CPU: introspection.ExtraHardwareDataSection{
+			"logical": {
+				"number":                float64(16),
+				"threaded_bandwidth_1G": float64(10240),
+			},
+		},
+		Disk: introspection.ExtraHardwareDataSection{
+			"sdb": {"standalone_read_1M_KBps": "180000"},
+			"sda": {"standalone_read_1M_KBps": float64(520000), "size": float64(480)},
+			"sdc": {"size": float64(960)},
+		},
+	})
+
+	expected := &metal3v1alpha1.BenchmarkResults{
+		MemoryBandwidthMBps: 10240,
+		Disks: []metal3v1alpha1.DiskBenchmarkResult{
+			{Name: "sda", SequentialReadKBps: 520000},
+			{Name: "sdb", SequentialReadKBps: 180000},
+		},
+	}
+	if !reflect.DeepEqual(expected, results) {
+		t.Errorf("Expected benchmark results %v, got %v", expected, results)
+	}
+
+	// No benchmark ran
+	results = getBenchmarkResults(introspection.ExtraHardwareDataType{
+		Disk: introspection.ExtraHardwareDataSection{"sda": {"size": float64(480)}},
+	})
+	if results != nil {
+		t.Errorf("Expected no benchmark results, got %v", results)
+	}
+}
+
+func TestGetDiskHealth(t *testing.T) {
+	storage := []metal3v1alpha1.Storage{
+		{Name: "/dev/nvme0n1"},
+		{Name: "/dev/nvme1n1"},
+		{Name: "/dev/sda"},
+		{Name: "/dev/sdb"},
+		{Name: "/dev/sdc"},
+	}
+	getDiskHealth(storage, introspection.ExtraHardwareDataSection{
+		"nvme0n1": {"SMART/critical_warning": "0", "SMART/percentage_used": "7", "SMART/media_errors": "0"},
+		"nvme1n1": {"SMART/critical_warning": float64(4), "SMART/percentage_used": float64(103), "SMART/media_errors": float64(12)},
+		"sda":     {"SMART/overall_health": "PASSED", "SMART/Media_Wearout_Indicator(233)/value": "92"},
+		"sdb":     {"SMART/overall_health": "FAILED", "SMART/Reported_Uncorrect(187)/raw": "3"},
+		"sdc":     {"size": float64(960)},
+	})
+
+	expected := []*metal3v1alpha1.DiskHealth{
+		{Status: metal3v1alpha1.DiskHealthOK, WearPercent: 7},
+		{
+			Status:      metal3v1alpha1.DiskHealthFailing,
+			Message:     "critical warning 0x4, 103% of its endurance used",
+			WearPercent: 103,
+			MediaErrors: 12,
+		},
+		{Status: metal3v1alpha1.DiskHealthOK, WearPercent: 8},
+		{Status: metal3v1alpha1.DiskHealthFailing, Message: "SMART health check FAILED", MediaErrors: 3},
+		nil,
+	}
+	for i, disk := range storage {
+		if !reflect.DeepEqual(expected[i], disk.Health) {
+			t.Errorf("Expected health %v of %s, got %v", expected[i], disk.Name, disk.Health)
+		}
+	}
+}
+
+func TestGetAccelerators(t *testing.T) {
+	body := `{
+		"extra": {
+			"gpu": {
+				"gpu1": {"vendor": "NVIDIA Corporation", "product": "GA100 [A100 PCIe 40GB]",
+					"businfo": "pci@0000:af:00.0", "memory_mb": "40960"},
+				"gpu0": {"vendor": "NVIDIA Corporation", "product": "GA100 [A100 PCIe 40GB]",
+					"businfo": "pci@0000:3b:00.0", "memory_mb": 40960}
+			}
+		},
+		"pci_devices": [
+			{"vendor_id": "1a03", "product_id": "2000", "class": "030000", "bus": "0000:03:00.0"},
+			{"vendor_id": "10de", "product_id": "20f1", "class": "030200", "bus": "0000:3b:00.0"},
+			{"vendor_id": "10de", "product_id": "20f1", "class": "030200", "bus": "0000:af:00.0"},
+			{"vendor_id": "1da3", "product_id": "1000", "class": "120000", "bus": "0000:d8:00.0"},
+			{"vendor_id": "8086", "product_id": "1572", "class": "020000", "bus": "0000:18:00.0"}
+		]
+	}`
+	var data AcceleratorData
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []metal3v1alpha1.Accelerator{
+		{
+			Type:          metal3v1alpha1.GPUAccelerator,
+			Vendor:        "NVIDIA Corporation",
+			Model:         "GA100 [A100 PCIe 40GB]",
+			VendorID:      "10de",
+			DeviceID:      "20f1",
+			PCIAddress:    "0000:3b:00.0",
+			VRAMMebibytes: 40960,
+		},
+		{
+			Type:          metal3v1alpha1.GPUAccelerator,
+			Vendor:        "NVIDIA Corporation",
+			Model:         "GA100 [A100 PCIe 40GB]",
+			VendorID:      "10de",
+			DeviceID:      "20f1",
+			PCIAddress:    "0000:af:00.0",
+			VRAMMebibytes: 40960,
+		},
+		{
+			Type:       metal3v1alpha1.ProcessingAccelerator,
+			Vendor:     "Habana Labs",
+			VendorID:   "1da3",
+			DeviceID:   "1000",
+			PCIAddress: "0000:d8:00.0",
+		},
+	}
+	if accelerators := GetAccelerators(&data); !reflect.DeepEqual(expected, accelerators) {
+		t.Errorf("Expected accelerators %v, got %v", expected, accelerators)
+	}
+
+	if accelerators := GetAccelerators(&AcceleratorData{}); len(accelerators) != 0 {
+		t.Errorf("Expected no accelerators, got %v", accelerators)
+	}
+}
+
+func TestPCIAcceleratorType(t *testing.T) {
+	for _, tc := range []struct {
+		Device   PCIDevice
+		Expected metal3v1alpha1.AcceleratorType
+	}{
+		{PCIDevice{VendorID: "10de", Class: "030000"}, metal3v1alpha1.GPUAccelerator},
+		{PCIDevice{VendorID: "0x1002", Class: "0x030000"}, metal3v1alpha1.GPUAccelerator},
+		{PCIDevice{VendorID: "102b", Class: "030000"}, ""},
+		{PCIDevice{VendorID: "8086", Class: "038000"}, metal3v1alpha1.GPUAccelerator},
+		{PCIDevice{VendorID: "1da3", Class: "120000"}, metal3v1alpha1.ProcessingAccelerator},
+		{PCIDevice{VendorID: "15b3", Class: "020700"}, ""},
+	} {
+		if actual := pciAcceleratorType(tc.Device); actual != tc.Expected {
+			t.Errorf("Expected %v to be %q, got %q", tc.Device, tc.Expected, actual)
+		}
+	}
+}
+
+func TestGetNUMANodes(t *testing.T) {
+	nodes := getNUMANodes(&introspection.NUMATopology{
+		CPUs: []introspection.NUMACPU{
+			{CPU: 1, NUMANode: 1, ThreadSiblings: []int{3, 1}},
+			{CPU: 0, NUMANode: 0, ThreadSiblings: []int{0, 2}},
+		},
+		RAM: []introspection.NUMARAM{
+			{NUMANode: 0, SizeKB: 16777216},
+			{NUMANode: 1, SizeKB: 16777216},
+		},
+		NICs: []introspection.NUMANIC{
+			{Name: "enp2s0", NUMANode: 1},
+			{Name: "enp1s0", NUMANode: 1},
+		},
+	})
+
+	expected := []metal3v1alpha1.NUMANode{
+		{ID: 0, CPUs: []int{0, 2}, RAMMebibytes: 16384},
+		{ID: 1, CPUs: []int{1, 3}, RAMMebibytes: 16384, NICs: []string{"enp1s0", "enp2s0"}},
+	}
+	if !reflect.DeepEqual(expected, nodes) {
+		t.Errorf("Expected NUMA nodes %v, got %v", expected, nodes)
+	}
+
+	// The numa-topology collector did not run
+	if nodes = getNUMANodes(&introspection.NUMATopology{}); nodes != nil {
+		t.Errorf("Expected no NUMA nodes, got %v", nodes)
+	}
+}
+
+func TestGetThreadsPerCore(t *testing.T) {
+	topology := &introspection.NUMATopology{
+		CPUs: []introspection.NUMACPU{{CPU: 0, ThreadSiblings: []int{0, 2}}},
+	}
+	if threads := getThreadsPerCore(topology, nil); threads != 2 {
+		t.Errorf("Expected 2 threads per core from the NUMA topology, got %d", threads)
+	}
+
+	extraCPU := introspection.ExtraHardwareDataSection{
+		"physical_0": {"cores": float64(8), "threads": "16"},
+	}
+	if threads := getThreadsPerCore(&introspection.NUMATopology{}, extraCPU); threads != 2 {
+		t.Errorf("Expected 2 threads per core from the extra hardware data, got %d", threads)
+	}
+
+	if threads := getThreadsPerCore(&introspection.NUMATopology{}, nil); threads != 0 {
+		t.Errorf("Expected no threads per core, got %d", threads)
+	}
+}
+
+func TestGetHugepageSizes(t *testing.T) {
+	cases := []struct {
+		name     string
+		cpu      introspection.CPUType
+		expected []string
+	}{
+		{
+			name:     "x86",
+			cpu:      introspection.CPUType{Architecture: "x86_64", Flags: []string{"fpu", "pdpe1gb", "pse"}},
+			expected: []string{"2Mi", "1Gi"},
+		},
+		{
+			name:     "x86-no-1G",
+			cpu:      introspection.CPUType{Architecture: "x86_64", Flags: []string{"pse"}},
+			expected: []string{"2Mi"},
+		},
+		{
+			name: "other",
+			cpu:  introspection.CPUType{Architecture: "aarch64", Flags: []string{"pse"}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sizes := getHugepageSizes(&tc.cpu)
+			if !reflect.DeepEqual(tc.expected, sizes) {
+				t.Errorf("Expected huge page sizes %v, got %v", tc.expected, sizes)
+			}
+		})
+	}
+}
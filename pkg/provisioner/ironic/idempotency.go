@@ -0,0 +1,172 @@
+package ironic
+
+import (
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/pkg/errors"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// lastRequestExtraKey is the key in the node extra field that holds
+// the idempotency token of the last action requested for the node.
+const lastRequestExtraKey = "metal3_last_request"
+
+// requestReplayWindow is how long a recorded request is assumed to
+// still be in progress. A request with the same token is not sent
+// again to Ironic during that time.
+var requestReplayWindow = time.Minute * 2
+
+// alreadyRequested reports whether the action identified by
+// requestID has been sent to Ironic recently, for example by a
+// previous instance of the operator that was restarted before it
+// could save the host status.
+func alreadyRequested(ironicNode *nodes.Node, requestID string) bool {
+	return alreadyRequestedWithin(ironicNode, requestID, requestReplayWindow)
+}
+
+// alreadyRequestedWithin is like alreadyRequested, for actions that
+// may take longer than requestReplayWindow to complete.
+func alreadyRequestedWithin(ironicNode *nodes.Node, requestID string, window time.Duration) bool {
+	if requestID == "" {
+		return false
+	}
+	record, ok := ironicNode.Extra[lastRequestExtraKey].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	if id, _ := record["id"].(string); id != requestID {
+		return false
+	}
+	timeStr, _ := record["time"].(string)
+	requested, err := time.Parse(time.RFC3339, timeStr)
+	if err != nil {
+		return false
+	}
+	return time.Since(requested) < window
+}
+
+// recordRequest saves requestID in the node before the action it
+// identifies is sent to Ironic.
+func (p *ironicProvisioner) recordRequest(ironicNode *nodes.Node, requestID string) (result provisioner.Result, err error) {
+	return p.saveRequest(ironicNode, nodes.UpdateOpts{lastRequestOperation(requestID)})
+}
+
+// lastRequestOperation returns the update saving requestID as the last
+// request of the node.
+func lastRequestOperation(requestID string) nodes.UpdateOperation {
+	return nodes.UpdateOperation{
+		Op:   nodes.AddOp,
+		Path: "/extra/" + lastRequestExtraKey,
+		Value: map[string]interface{}{
+			"id":   requestID,
+			"time": time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+}
+
+// saveRequest applies the updates recording a request to the node.
+// In dry-run mode nothing is recorded, since the request is not sent
+// either, and the bookkeeping is not reported as a planned action.
+func (p *ironicProvisioner) saveRequest(ironicNode *nodes.Node, updates nodes.UpdateOpts) (result provisioner.Result, err error) {
+	if p.dryRun {
+		return operationComplete()
+	}
+	_, err = p.updateNode(ironicNode, updates)
+	switch err.(type) {
+	case nil:
+	case gophercloud.ErrDefault409:
+		p.log.Info("could not record request, busy")
+		return retryAfterDelay(provisionRequeueDelay)
+	default:
+		return transientError(errors.Wrap(err, "failed to record request"))
+	}
+	return operationComplete()
+}
+
+// powerConflicts returns how many times in a row the power change
+// identified by requestID was rejected because the node was locked.
+// The count is read from the node as it was before the change was
+// recorded again for this attempt.
+func powerConflicts(ironicNode *nodes.Node, requestID string) int {
+	record, ok := ironicNode.Extra[lastRequestExtraKey].(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	if id, _ := record["id"].(string); id != requestID {
+		return 0
+	}
+	conflicts, _ := record["conflicts"].(float64)
+	return int(conflicts)
+}
+
+// recordPowerConflicts replaces the record of the power change
+// identified by requestID, which Ironic rejected because the node was
+// locked, by one counting the conflicts. The record has no time, so
+// the change is not waited for and is sent again on the next
+// reconcile.
+func (p *ironicProvisioner) recordPowerConflicts(ironicNode *nodes.Node, requestID string, conflicts int) {
+	if p.dryRun {
+		return
+	}
+	updates := nodes.UpdateOpts{
+		nodes.UpdateOperation{
+			Op:   nodes.AddOp,
+			Path: "/extra/" + lastRequestExtraKey,
+			Value: map[string]interface{}{
+				"id":        requestID,
+				"conflicts": conflicts,
+			},
+		},
+	}
+	if _, err := p.updateNode(ironicNode, updates); err != nil {
+		p.log.Info("could not record power conflicts", "error", err)
+	}
+}
+
+// forgetRequest removes the record of a request that Ironic did not
+// accept, together with any other extra keys saved with it, so that
+// the request is sent again on the next reconcile instead of being
+// waited for. If Ironic rejects the removal as well, the request is
+// only sent again once the replay window has passed.
+func (p *ironicProvisioner) forgetRequest(ironicNode *nodes.Node, extraKeys ...string) {
+	if p.dryRun {
+		return
+	}
+	updates := nodes.UpdateOpts{}
+	for _, key := range append([]string{lastRequestExtraKey}, extraKeys...) {
+		updates = append(updates, nodes.UpdateOperation{
+			Op:   nodes.RemoveOp,
+			Path: "/extra/" + key,
+		})
+	}
+	if _, err := p.updateNode(ironicNode, updates); err != nil {
+		p.log.Info("could not forget request", "error", err)
+	}
+}
+
+// requestOnce records requestID and then runs the action, unless the
+// same request was already made recently, in which case it waits
+// for the earlier request to take effect. The action reports whether
+// Ironic accepted the request; if it did not, the record is removed
+// so that the request is retried on the next reconcile.
+func (p *ironicProvisioner) requestOnce(ironicNode *nodes.Node, requestID string, delay time.Duration, action func() (bool, provisioner.Result, error)) (result provisioner.Result, err error) {
+	if requestID == "" {
+		_, result, err = action()
+		return result, err
+	}
+	if alreadyRequested(ironicNode, requestID) {
+		p.log.Info("request already sent, waiting for it to complete", "requestID", requestID)
+		return operationContinuing(delay)
+	}
+	if result, err = p.recordRequest(ironicNode, requestID); err != nil || result.Dirty {
+		return result, err
+	}
+	accepted, result, err := action()
+	if !accepted {
+		p.forgetRequest(ironicNode)
+	}
+	return result, err
+}
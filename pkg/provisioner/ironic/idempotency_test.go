@@ -0,0 +1,250 @@
+package ironic
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func lastRequestExtra(id string, age time.Duration) map[string]interface{} {
+	return map[string]interface{}{
+		lastRequestExtraKey: map[string]interface{}{
+			"id":   id,
+			"time": time.Now().Add(-age).UTC().Format(time.RFC3339),
+		},
+	}
+}
+
+func TestAlreadyRequested(t *testing.T) {
+	cases := []struct {
+		name      string
+		extra     map[string]interface{}
+		requestID string
+		expected  bool
+	}{
+		{
+			name:      "no-request-id",
+			extra:     lastRequestExtra("", 0),
+			requestID: "",
+		},
+		{
+			name:      "nothing-recorded",
+			requestID: "req",
+		},
+		{
+			name:      "same-request",
+			extra:     lastRequestExtra("req", time.Second),
+			requestID: "req",
+			expected:  true,
+		},
+		{
+			name:      "other-request",
+			extra:     lastRequestExtra("other", time.Second),
+			requestID: "req",
+		},
+		{
+			name:      "expired",
+			extra:     lastRequestExtra("req", requestReplayWindow+time.Minute),
+			requestID: "req",
+		},
+		{
+			name: "malformed",
+			extra: map[string]interface{}{
+				lastRequestExtraKey: "req",
+			},
+			requestID: "req",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			node := &nodes.Node{Extra: tc.extra}
+			assert.Equal(t, tc.expected, alreadyRequested(node, tc.requestID))
+		})
+	}
+}
+
+func TestAlreadyRequestedWithin(t *testing.T) {
+	node := &nodes.Node{Extra: lastRequestExtra("req", requestReplayWindow+time.Minute)}
+	assert.False(t, alreadyRequestedWithin(node, "req", requestReplayWindow))
+	assert.True(t, alreadyRequestedWithin(node, "req", requestReplayWindow*2))
+}
+
+func TestPowerOnRequestID(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	requestID := "host-uid/power-on/1//false"
+
+	cases := []struct {
+		name             string
+		extra            map[string]interface{}
+		expectPowerState bool
+	}{
+		{
+			name:             "new-request",
+			expectPowerState: true,
+		},
+		{
+			name:  "repeated-request",
+			extra: lastRequestExtra(requestID+"/"+powerOn, time.Second),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				PowerState: powerOff,
+				UUID:       nodeUUID,
+				Extra:      tc.extra,
+			}).NodeUpdate(nodes.Node{
+				UUID: nodeUUID,
+			}).WithNodeStatesPowerUpdate(nodeUUID, http.StatusAccepted)
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			publisher := func(reason, message string) {}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+			prov.status.ID = nodeUUID
+
+			result, err := prov.PowerOn(requestID)
+			assert.NoError(t, err)
+			assert.True(t, result.Dirty)
+
+			assert.Equal(t, tc.expectPowerState,
+				strings.Contains(ironic.Requests, "/states/power"),
+				"requests: %s", ironic.Requests)
+			if tc.expectPowerState {
+				updates := ironic.GetLastNodeUpdateRequestFor(nodeUUID)
+				if assert.Len(t, updates, 1) {
+					assert.Equal(t, "/extra/"+lastRequestExtraKey, updates[0].Path)
+				}
+			}
+		})
+	}
+}
+
+func TestRejectedPowerRequestRetried(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	requestID := "host-uid/power-on/1//false"
+
+	cases := []struct {
+		name     string
+		code     int
+		expectOp nodes.UpdateOp
+	}{
+		{
+			name:     "conflict",
+			code:     http.StatusConflict,
+			expectOp: nodes.AddOp,
+		},
+		{
+			name:     "bad-request",
+			code:     http.StatusBadRequest,
+			expectOp: nodes.RemoveOp,
+		},
+		{
+			name:     "transient",
+			code:     http.StatusInternalServerError,
+			expectOp: nodes.RemoveOp,
+		},
+	}
+
+	powerOn := func(t *testing.T, extra map[string]interface{}, code int) *testserver.IronicMock {
+		ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+			PowerState: powerOff,
+			UUID:       nodeUUID,
+			Extra:      extra,
+		}).NodeUpdate(nodes.Node{
+			UUID: nodeUUID,
+		}).WithNodeStatesPowerUpdate(nodeUUID, code)
+		ironic.Start()
+		defer ironic.Stop()
+
+		host := makeHost()
+		publisher := func(reason, message string) {}
+		auth := clients.AuthConfig{Type: clients.NoAuth}
+		prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+			ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+		)
+		if err != nil {
+			t.Fatalf("could not create provisioner: %s", err)
+		}
+		prov.status.ID = nodeUUID
+
+		prov.PowerOn(requestID)
+		return ironic
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := powerOn(t, nil, tc.code)
+			updates := ironic.GetLastNodeUpdateRequestFor(nodeUUID)
+			if !assert.Len(t, updates, 1) {
+				return
+			}
+			assert.Equal(t, tc.expectOp, updates[0].Op)
+			assert.Equal(t, "/extra/"+lastRequestExtraKey, updates[0].Path)
+			var extra map[string]interface{}
+			if tc.expectOp == nodes.AddOp {
+				// A conflict leaves a record without a time
+				record := updates[0].Value.(map[string]interface{})
+				assert.NotContains(t, record, "time")
+				extra = map[string]interface{}{lastRequestExtraKey: record}
+			}
+
+			// The next reconcile sees the node as the failed request
+			// left it and sends the request again
+			ironic = powerOn(t, extra, http.StatusAccepted)
+			assert.Contains(t, ironic.Requests, "/states/power")
+		})
+	}
+}
+
+func TestRejectedDeprovisionRequestRetried(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+		ProvisionState: string(nodes.Active),
+		UUID:           nodeUUID,
+	}).NodeUpdate(nodes.Node{
+		UUID: nodeUUID,
+	})
+	ironic.ResponseWithCode("/v1/nodes/"+nodeUUID+"/states/provision:"+http.MethodPut, "{}", http.StatusConflict)
+	ironic.Start()
+	defer ironic.Stop()
+
+	host := makeHost()
+	publisher := func(reason, message string) {}
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+	prov.status.ID = nodeUUID
+
+	result, err := prov.Deprovision(false, "host-uid/deprovision/1")
+	assert.NoError(t, err)
+	assert.True(t, result.Dirty)
+
+	updates := ironic.GetLastNodeUpdateRequestFor(nodeUUID)
+	if assert.Len(t, updates, 1) {
+		assert.Equal(t, nodes.RemoveOp, updates[0].Op)
+		assert.Equal(t, "/extra/"+lastRequestExtraKey, updates[0].Path)
+	}
+}
@@ -22,7 +22,9 @@ func TestInspectHardware(t *testing.T) {
 		name      string
 		ironic    *testserver.IronicMock
 		inspector *testserver.InspectorMock
+		refresh   bool
 
+		expectedStarted      bool
 		expectedDirty        bool
 		expectedRequestAfter int
 		expectedResultError  string
@@ -39,6 +41,7 @@ func TestInspectHardware(t *testing.T) {
 			}),
 			inspector: testserver.NewInspector(t).Ready().WithIntrospectionFailed(nodeUUID, http.StatusNotFound),
 
+			expectedStarted:      true,
 			expectedDirty:        true,
 			expectedRequestAfter: 10,
 			expectedPublish:      "InspectionStarted Hardware inspection started",
@@ -149,6 +152,42 @@ func TestInspectHardware(t *testing.T) {
 			expectedDetailsHost: "node-0",
 			expectedPublish:     "InspectionComplete Hardware inspection completed",
 		},
+		{
+			name: "refresh-available",
+			ironic: testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Available),
+			}),
+			refresh: true,
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+		},
+		{
+			name: "refresh-manageable",
+			ironic: testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+			}),
+			refresh: true,
+
+			expectedStarted:      true,
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+			expectedPublish:      "InspectionStarted Hardware inspection started",
+		},
+		{
+			name: "refresh-inspecting",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Inspecting),
+			}),
+			refresh: true,
+
+			expectedStarted:      true,
+			expectedDirty:        true,
+			expectedRequestAfter: 15,
+		},
 	}
 
 	for _, tc := range cases {
@@ -177,8 +216,9 @@ func TestInspectHardware(t *testing.T) {
 			}
 
 			prov.status.ID = nodeUUID
-			result, details, err := prov.InspectHardware(false)
+			result, started, details, err := prov.InspectHardware(false, tc.refresh)
 
+			assert.Equal(t, tc.expectedStarted, started)
 			assert.Equal(t, tc.expectedDirty, result.Dirty)
 			assert.Equal(t, time.Second*time.Duration(tc.expectedRequestAfter), result.RequeueAfter)
 			assert.Equal(t, tc.expectedResultError, result.ErrorMessage)
@@ -1,8 +1,11 @@
 package ironic
 
 import (
+	"context"
 	"fmt"
+	"net/url"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -19,6 +22,7 @@ import (
 	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 	"github.com/metal3-io/baremetal-operator/pkg/bmc"
 	"github.com/metal3-io/baremetal-operator/pkg/hardware"
+	"github.com/metal3-io/baremetal-operator/pkg/imagemirror"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/devicehints"
@@ -30,6 +34,7 @@ var (
 	deprovisionRequeueDelay   = time.Second * 10
 	provisionRequeueDelay     = time.Second * 10
 	powerRequeueDelay         = time.Second * 10
+	powerConflictRetryDelay   = time.Second
 	introspectionRequeueDelay = time.Second * 15
 	softPowerOffTimeout       = time.Second * 180
 	deployKernelURL           string
@@ -41,6 +46,10 @@ var (
 	ironicAuth                clients.AuthConfig
 	inspectorAuth             clients.AuthConfig
 	maxProvisioningHosts      int = 20
+	networkBootInterface      string
+	httpBootCAFile            string
+	airGapped                 bool
+	airGappedAllowedURLs      []*url.URL
 
 	// Keep pointers to ironic and inspector clients configured with
 	// the global auth settings to reuse the connection between
@@ -125,6 +134,81 @@ func init() {
 		}
 		maxProvisioningHosts = value
 	}
+
+	networkBootInterface = os.Getenv("NETWORK_BOOT_INTERFACE")
+	if networkBootInterface != "" && !bmc.IsNetworkBootInterface(networkBootInterface) {
+		fmt.Fprintf(os.Stderr, "Cannot start: Invalid value set for variable NETWORK_BOOT_INTERFACE=%s", networkBootInterface)
+		os.Exit(1)
+	}
+
+	httpBootCAFile = os.Getenv("HTTP_BOOT_CA_FILE")
+
+	if airGappedStr := os.Getenv("AIR_GAPPED_MODE"); airGappedStr != "" {
+		value, err := strconv.ParseBool(airGappedStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot start: Invalid value set for variable AIR_GAPPED_MODE=%s", airGappedStr)
+			os.Exit(1)
+		}
+		airGapped = value
+	}
+	if airGapped {
+		allowed, err := parseAllowedURLs(os.Getenv("AIR_GAPPED_ALLOWED_URLS"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot start: Invalid value set for variable AIR_GAPPED_ALLOWED_URLS: %s\n", err)
+			os.Exit(1)
+		}
+		airGappedAllowedURLs = allowed
+		for name, value := range map[string]string{"DEPLOY_KERNEL_URL": deployKernelURL, "DEPLOY_RAMDISK_URL": deployRamdiskURL} {
+			if err := checkAirGapped(name, value); err != nil {
+				fmt.Fprintf(os.Stderr, "Cannot start: %s\n", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if networksFile := os.Getenv("PROVISIONING_NETWORKS_FILE"); networksFile != "" {
+		networks, err := loadProvisioningNetworks(networksFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot start: %s\n", err)
+			os.Exit(1)
+		}
+		for _, network := range networks {
+			for field, value := range map[string]string{"deployKernelURL": network.DeployKernelURL, "deployRamdiskURL": network.DeployRamdiskURL} {
+				if err := checkAirGapped(field+" of provisioning network "+network.Name, value); err != nil {
+					fmt.Fprintf(os.Stderr, "Cannot start: %s\n", err)
+					os.Exit(1)
+				}
+			}
+		}
+		provisioningNetworks = networks
+	}
+
+	if limitStr := os.Getenv("IMAGE_DOWNLOAD_LIMIT_MBPS"); limitStr != "" {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil || value < 0 {
+			fmt.Fprintf(os.Stderr, "Cannot start: Invalid value set for variable IMAGE_DOWNLOAD_LIMIT_MBPS=%s", limitStr)
+			os.Exit(1)
+		}
+		defaultImageDownloadLimit = value
+	}
+
+	if collectorsStr := os.Getenv("INSPECTION_COLLECTORS"); collectorsStr != "" {
+		collectors, err := parseInspectionCollectors(collectorsStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot start: Invalid value set for variable INSPECTION_COLLECTORS=%s: %s", collectorsStr, err)
+			os.Exit(1)
+		}
+		defaultInspectionCollectors = collectors
+	}
+
+	if benchmarksStr := os.Getenv("INSPECTION_BENCHMARKS"); benchmarksStr != "" {
+		benchmarks, err := parseInspectionBenchmarks(benchmarksStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot start: Invalid value set for variable INSPECTION_BENCHMARKS=%s: %s", benchmarksStr, err)
+			os.Exit(1)
+		}
+		defaultInspectionBenchmarks = benchmarks
+	}
 }
 
 // Provisioner implements the provisioning.Provisioner interface
@@ -148,6 +232,10 @@ type ironicProvisioner struct {
 	debugLog logr.Logger
 	// an event publisher for recording significant events
 	publisher provisioner.EventPublisher
+	// workarounds for the BMC vendor and model of the host
+	quirks hardware.Quirks
+	// report the changes to Ironic instead of making them
+	dryRun bool
 }
 
 // LogStartup produces useful logging information that we only want to
@@ -207,6 +295,8 @@ func newProvisionerWithIronicClients(host metal3v1alpha1.BareMetalHost, bmcCreds
 		debugLog:  provisionerLogger.V(1),
 		publisher: publisher,
 	}
+	p.quirks = p.getQuirks()
+	p.dryRun = host.HasDryRunAnnotation()
 
 	return p, nil
 }
@@ -236,6 +326,25 @@ func New(host metal3v1alpha1.BareMetalHost, bmcCreds bmc.Credentials, publisher
 		clientIronicSingleton, clientInspectorSingleton)
 }
 
+// SetContext makes the requests of the next calls to Ironic and Ironic
+// Inspector use ctx. The clients are shared with the other hosts, so
+// copies of them get the context.
+func (p *ironicProvisioner) SetContext(ctx context.Context) {
+	p.client = withContext(p.client, ctx)
+	p.inspector = withContext(p.inspector, ctx)
+}
+
+func withContext(client *gophercloud.ServiceClient, ctx context.Context) *gophercloud.ServiceClient {
+	if client == nil {
+		return nil
+	}
+	provider := *client.ProviderClient
+	provider.Context = ctx
+	copied := *client
+	copied.ProviderClient = &provider
+	return &copied
+}
+
 func (p *ironicProvisioner) validateNode(ironicNode *nodes.Node) (errorMessage string, err error) {
 	var validationErrors []string
 
@@ -383,25 +492,53 @@ func (p *ironicProvisioner) ValidateManagementAccess(credentialsChanged, force b
 	}
 
 	// Some BMC types require a MAC address, so ensure we have one
-	// when we need it. If not, place the host in an error state.
-	if p.bmcAccess.NeedsMAC() && p.host.Spec.BootMACAddress == "" {
+	// when we need it and it cannot be discovered during inspection.
+	// If not, place the host in an error state.
+	if p.bmcAccess.NeedsMAC() && !p.bmcAccess.DiscoversBootMAC() && p.host.Spec.BootMACAddress == "" {
 		msg := fmt.Sprintf("BMC driver %s requires a BootMACAddress value", p.bmcAccess.Type())
 		p.log.Info(msg)
 		result, err = operationFailed(msg)
 		return
 	}
 
+	agentImg, agentReady, err := p.agentImage()
+	if err != nil {
+		p.log.Info(err.Error())
+		result, err = operationFailed(err.Error())
+		return
+	}
+	if !agentReady {
+		p.log.Info("waiting for the agent image to be verified")
+		result, err = operationContinuing(provisionRequeueDelay)
+		return
+	}
+
+	network, err := p.provisioningNetwork()
+	if err != nil {
+		p.log.Info(err.Error())
+		result, err = operationFailed(err.Error())
+		return
+	}
+
 	driverInfo := p.bmcAccess.DriverInfo(p.bmcCreds)
-	// FIXME(dhellmann): We need to get our IP on the
-	// provisioning network from somewhere.
-	driverInfo["deploy_kernel"] = deployKernelURL
-	driverInfo["deploy_ramdisk"] = deployRamdiskURL
+	agentSettings := agentImageSettings(agentImg, network)
+	agentParams := p.agentKernelParams(network)
+	if agentParams != "" {
+		agentSettings["kernel_append_params"] = kernelParams(
+			inspectionCollectors(&p.host), inspectionBenchmarks(&p.host), agentParams)
+	}
+	for key, value := range agentSettings {
+		driverInfo[key] = value
+	}
 
 	result, err = operationComplete()
 
 	// If we have not found a node yet, we need to create one
 	if ironicNode == nil {
 		p.log.Info("registering host in ironic")
+		if p.quirks != (hardware.Quirks{}) {
+			p.log.Info("using hardware quirks", "vendor", p.quirks.Vendor, "model", p.quirks.Model)
+		}
 
 		if p.host.Spec.BootMode == metal3v1alpha1.UEFISecureBoot && !p.bmcAccess.SupportsSecureBoot() {
 			msg := fmt.Sprintf("BMC driver %s does not support secure boot", p.bmcAccess.Type())
@@ -410,11 +547,10 @@ func (p *ironicProvisioner) ValidateManagementAccess(credentialsChanged, force b
 			return
 		}
 
-		ironicNode, err = nodes.Create(
-			p.client,
+		ironicNode, err = p.createNode(
 			nodes.CreateOpts{
 				Driver:              p.bmcAccess.Driver(),
-				BootInterface:       p.bmcAccess.BootInterface(),
+				BootInterface:       p.bootInterface(),
 				Name:                p.host.Name,
 				DriverInfo:          driverInfo,
 				DeployInterface:     p.deployInterface(),
@@ -426,7 +562,8 @@ func (p *ironicProvisioner) ValidateManagementAccess(credentialsChanged, force b
 				Properties: map[string]interface{}{
 					"capabilities": bootModeCapabilities[p.host.Status.Provisioning.BootMode],
 				},
-			}).Extract()
+				Extra: agentVersionExtra(agentImg),
+			})
 		// FIXME(dhellmann): Handle 409 and 503? errors here.
 		if err != nil {
 			result, err = transientError(errors.Wrap(err, "failed to register host in ironic"))
@@ -434,6 +571,14 @@ func (p *ironicProvisioner) ValidateManagementAccess(credentialsChanged, force b
 		}
 		p.publisher("Registered", "Registered new host")
 
+		if updates := p.biosInterfaceUpdates(); len(updates) != 0 {
+			ironicNode, err = p.updateNode(ironicNode, updates)
+			if err != nil {
+				result, err = transientError(errors.Wrap(err, "failed to set BIOS interface"))
+				return
+			}
+		}
+
 		// Store the ID so other methods can assume it is set and so
 		// we can find the node again later.
 		provID = ironicNode.UUID
@@ -444,13 +589,12 @@ func (p *ironicProvisioner) ValidateManagementAccess(credentialsChanged, force b
 			enable := true
 			p.log.Info("creating port for node in ironic", "MAC",
 				p.host.Spec.BootMACAddress)
-			_, err = ports.Create(
-				p.client,
+			err = p.createPort(
 				ports.CreateOpts{
 					NodeUUID:   ironicNode.UUID,
 					Address:    p.host.Spec.BootMACAddress,
 					PXEEnabled: &enable,
-				}).Extract()
+				})
 			if err != nil {
 				result, err = transientError(errors.Wrap(err, "failed to create port in ironic"))
 				return
@@ -476,7 +620,7 @@ func (p *ironicProvisioner) ValidateManagementAccess(credentialsChanged, force b
 				return
 			}
 			if len(updates) != 0 {
-				_, err = nodes.Update(p.client, ironicNode.UUID, updates).Extract()
+				_, err = p.updateNode(ironicNode, updates)
 				switch err.(type) {
 				case nil:
 				case gophercloud.ErrDefault409:
@@ -504,7 +648,7 @@ func (p *ironicProvisioner) ValidateManagementAccess(credentialsChanged, force b
 					Value: p.host.Name,
 				},
 			}
-			ironicNode, err = nodes.Update(p.client, ironicNode.UUID, updates).Extract()
+			ironicNode, err = p.updateNode(ironicNode, updates)
 			switch err.(type) {
 			case nil:
 			case gophercloud.ErrDefault409:
@@ -529,7 +673,9 @@ func (p *ironicProvisioner) ValidateManagementAccess(credentialsChanged, force b
 					Value: driverInfo,
 				},
 			}
-			ironicNode, err = nodes.Update(p.client, ironicNode.UUID, updates).Extract()
+			updates = append(updates, p.biosInterfaceUpdates()...)
+			updates = append(updates, agentVersionUpdates(ironicNode, agentImg)...)
+			ironicNode, err = p.updateNode(ironicNode, updates)
 			switch err.(type) {
 			case nil:
 			case gophercloud.ErrDefault409:
@@ -544,6 +690,34 @@ func (p *ironicProvisioner) ValidateManagementAccess(credentialsChanged, force b
 			// We don't return here because we also have to set the
 			// target provision state to manageable, which happens
 			// below.
+		} else if updates := p.agentSettingsUpdates(ironicNode, agentImg, agentSettings); (agentImg != nil || network != nil || agentParams != "" || staleAgentParams(ironicNode, &p.host) || staleAgentImage(ironicNode, agentImg)) && len(updates) != 0 {
+			ironicNode, err = p.updateNode(ironicNode, updates)
+			switch err.(type) {
+			case nil:
+			case gophercloud.ErrDefault409:
+				p.log.Info("could not update agent image settings, busy")
+				result, err = retryAfterDelay(provisionRequeueDelay)
+				return
+			default:
+				result, err = transientError(errors.Wrap(err, "failed to update agent image settings"))
+				return
+			}
+			p.log.Info("updated agent image settings")
+		}
+
+		if updates := p.bootInterfaceUpdates(ironicNode); len(updates) != 0 {
+			ironicNode, err = p.updateNode(ironicNode, updates)
+			switch err.(type) {
+			case nil:
+			case gophercloud.ErrDefault409:
+				p.log.Info("could not update boot interface, busy")
+				result, err = retryAfterDelay(provisionRequeueDelay)
+				return
+			default:
+				result, err = transientError(errors.Wrap(err, "failed to update boot interface"))
+				return
+			}
+			p.log.Info("updated boot interface", "bootInterface", p.bootInterface())
 		}
 	}
 
@@ -614,8 +788,8 @@ func (p *ironicProvisioner) tryChangeNodeProvisionState(ironicNode *nodes.Node,
 		"new target", opts.Target,
 	)
 
-	changeResult := nodes.ChangeProvisionState(p.client, ironicNode.UUID, opts)
-	switch changeResult.Err.(type) {
+	changeErr := p.changeProvisionState(ironicNode, opts)
+	switch changeErr.(type) {
 	case nil:
 		success = true
 	case gophercloud.ErrDefault409:
@@ -623,7 +797,7 @@ func (p *ironicProvisioner) tryChangeNodeProvisionState(ironicNode *nodes.Node,
 		result, err = retryAfterDelay(provisionRequeueDelay)
 		return
 	default:
-		result, err = transientError(errors.Wrap(changeResult.Err,
+		result, err = transientError(errors.Wrap(changeErr,
 			fmt.Sprintf("failed to change provisioning state to %q", opts.Target)))
 		return
 	}
@@ -637,12 +811,63 @@ func (p *ironicProvisioner) changeNodeProvisionState(ironicNode *nodes.Node, opt
 	return
 }
 
+// startInspection starts a new inspection of the node.
+func (p *ironicProvisioner) startInspection(ironicNode *nodes.Node, force bool) (result provisioner.Result, started bool, err error) {
+	if nodes.ProvisionState(ironicNode.ProvisionState) == nodes.InspectFail && !force {
+		p.log.Info("starting inspection failed")
+		if ironicNode.LastError == "" {
+			result.ErrorMessage = "Inspection failed"
+		} else {
+			result.ErrorMessage = ironicNode.LastError
+		}
+	}
+	network, err := p.provisioningNetwork()
+	if err != nil {
+		result, err = operationFailed(err.Error())
+		return
+	}
+	p.log.Info("updating boot mode before hardware inspection")
+	op, value := buildCapabilitiesValue(ironicNode, p.host.Status.Provisioning.BootMode)
+	updates := nodes.UpdateOpts{
+		nodes.UpdateOperation{
+			Op:    op,
+			Path:  "/properties/capabilities",
+			Value: value,
+		},
+	}
+	updates = append(updates, kernelParamsUpdates(ironicNode, kernelParams(
+		inspectionCollectors(&p.host), inspectionBenchmarks(&p.host), p.agentKernelParams(network)))...)
+	_, err = p.updateNode(ironicNode, updates)
+	switch err.(type) {
+	case nil:
+	case gophercloud.ErrDefault409:
+		p.log.Info("could not update host settings in ironic, busy")
+		result, err = retryAfterDelay(provisionRequeueDelay)
+		return
+	default:
+		result, err = transientError(errors.Wrap(err, "failed to update host boot mode settings in ironic"))
+		return
+	}
+
+	p.log.Info("starting new hardware inspection")
+	started, result, err = p.tryChangeNodeProvisionState(
+		ironicNode,
+		nodes.ProvisionStateOpts{Target: nodes.TargetInspect},
+	)
+	if started {
+		p.publisher("InspectionStarted", "Hardware inspection started")
+	}
+	return
+}
+
 // InspectHardware updates the HardwareDetails field of the host with
 // details of devices discovered on the hardware. It may be called
 // multiple times, and should return true for its dirty flag until the
-// inspection is completed.
-func (p *ironicProvisioner) InspectHardware(force bool) (result provisioner.Result, details *metal3v1alpha1.HardwareDetails, err error) {
-	p.log.Info("inspecting hardware", "status", p.host.OperationalStatus())
+// inspection is completed. When refresh is true a new inspection is
+// started even if the host has been inspected before, and started
+// reports whether it has been.
+func (p *ironicProvisioner) InspectHardware(force, refresh bool) (result provisioner.Result, started bool, details *metal3v1alpha1.HardwareDetails, err error) {
+	p.log.Info("inspecting hardware", "status", p.host.OperationalStatus(), "refresh", refresh)
 
 	ironicNode, err := p.findExistingHost()
 	if err != nil {
@@ -654,6 +879,25 @@ func (p *ironicProvisioner) InspectHardware(force bool) (result provisioner.Resu
 		return
 	}
 
+	if refresh {
+		switch nodes.ProvisionState(ironicNode.ProvisionState) {
+		case nodes.Inspecting, nodes.InspectWait:
+			p.log.Info("inspection already started")
+			started = true
+			result, err = operationContinuing(introspectionRequeueDelay)
+		case nodes.Available:
+			// Ironic only inspects manageable nodes
+			p.log.Info("making host manageable for a new inspection")
+			_, result, err = p.tryChangeNodeProvisionState(
+				ironicNode,
+				nodes.ProvisionStateOpts{Target: nodes.TargetManage},
+			)
+		default:
+			result, started, err = p.startInspection(ironicNode, force)
+		}
+		return
+	}
+
 	status, err := introspection.GetIntrospectionStatus(p.inspector, ironicNode.UUID).Extract()
 	if err != nil {
 		if _, isNotFound := err.(gophercloud.ErrDefault404); isNotFound {
@@ -663,45 +907,7 @@ func (p *ironicProvisioner) InspectHardware(force bool) (result provisioner.Resu
 				result, err = operationContinuing(introspectionRequeueDelay)
 				return
 			default:
-				if nodes.ProvisionState(ironicNode.ProvisionState) == nodes.InspectFail && !force {
-					p.log.Info("starting inspection failed", "error", status.Error)
-					if ironicNode.LastError == "" {
-						result.ErrorMessage = "Inspection failed"
-					} else {
-						result.ErrorMessage = ironicNode.LastError
-					}
-					err = nil
-				}
-				p.log.Info("updating boot mode before hardware inspection")
-				op, value := buildCapabilitiesValue(ironicNode, p.host.Status.Provisioning.BootMode)
-				updates := nodes.UpdateOpts{
-					nodes.UpdateOperation{
-						Op:    op,
-						Path:  "/properties/capabilities",
-						Value: value,
-					},
-				}
-				_, err = nodes.Update(p.client, ironicNode.UUID, updates).Extract()
-				switch err.(type) {
-				case nil:
-				case gophercloud.ErrDefault409:
-					p.log.Info("could not update host settings in ironic, busy")
-					result, err = retryAfterDelay(provisionRequeueDelay)
-					return
-				default:
-					result, err = transientError(errors.Wrap(err, "failed to update host boot mode settings in ironic"))
-					return
-				}
-
-				p.log.Info("starting new hardware inspection")
-				var success bool
-				success, result, err = p.tryChangeNodeProvisionState(
-					ironicNode,
-					nodes.ProvisionStateOpts{Target: nodes.TargetInspect},
-				)
-				if success {
-					p.publisher("InspectionStarted", "Hardware inspection started")
-				}
+				result, started, err = p.startInspection(ironicNode, force)
 				return
 			}
 		}
@@ -730,6 +936,12 @@ func (p *ironicProvisioner) InspectHardware(force bool) (result provisioner.Resu
 	p.log.Info("received introspection data", "data", introData.Body)
 
 	details = hardwaredetails.GetHardwareDetails(data)
+	var acceleratorData hardwaredetails.AcceleratorData
+	if err := introData.ExtractInto(&acceleratorData); err != nil {
+		p.log.Info("could not read the accelerators from the introspection data", "error", err.Error())
+	} else {
+		details.Accelerators = hardwaredetails.GetAccelerators(&acceleratorData)
+	}
 	p.publisher("InspectionComplete", "Hardware inspection completed")
 	result, err = operationComplete()
 	return
@@ -823,7 +1035,7 @@ func (p *ironicProvisioner) setDirectDeployUpdateOptsForNode(ironicNode *nodes.N
 		nodes.UpdateOperation{
 			Op:    nodes.ReplaceOp,
 			Path:  "/deploy_interface",
-			Value: "direct",
+			Value: p.imageDeployInterface(),
 		},
 	)
 	// Remove any boot_iso field
@@ -925,7 +1137,26 @@ func (p *ironicProvisioner) setDirectDeployUpdateOptsForNode(ironicNode *nodes.N
 	return updates, nil
 }
 
+// mirroredImage returns the image with its URLs pointing at the
+// mirror nearest to the host, if there is one.
+func (p *ironicProvisioner) mirroredImage(imageData *metal3v1alpha1.Image) *metal3v1alpha1.Image {
+	imageURL := imagemirror.Rewrite(p.host.Labels, imageData.URL)
+	checksum := imagemirror.Rewrite(p.host.Labels, imageData.Checksum)
+	if imageURL == imageData.URL && checksum == imageData.Checksum {
+		return imageData
+	}
+	mirrored := imageData.DeepCopy()
+	mirrored.URL = imageURL
+	mirrored.Checksum = checksum
+	return mirrored
+}
+
 func (p *ironicProvisioner) getImageUpdateOptsForNode(ironicNode *nodes.Node, imageData *metal3v1alpha1.Image) (updates nodes.UpdateOpts, err error) {
+	if mirrored := p.mirroredImage(imageData); mirrored != imageData {
+		p.log.Info("using image mirror", "url", mirrored.URL)
+		imageData = mirrored
+	}
+
 	// instance_uuid
 	p.log.Info("setting instance_uuid")
 	updates = append(
@@ -1111,7 +1342,7 @@ func (p *ironicProvisioner) setUpForProvisioning(ironicNode *nodes.Node, hostCon
 	if err != nil {
 		return transientError(errors.Wrap(err, "failed to update opts for node"))
 	}
-	_, err = nodes.Update(p.client, ironicNode.UUID, updates).Extract()
+	_, err = p.updateNode(ironicNode, updates)
 	switch err.(type) {
 	case nil:
 	case gophercloud.ErrDefault409:
@@ -1150,13 +1381,22 @@ func (p *ironicProvisioner) setUpForProvisioning(ironicNode *nodes.Node, hostCon
 }
 
 func (p *ironicProvisioner) deployInterface() (result string) {
-	result = "direct"
+	result = p.imageDeployInterface()
 	if p.host.Spec.Image != nil && p.host.Spec.Image.DiskFormat != nil && *p.host.Spec.Image.DiskFormat == "live-iso" {
 		result = "ramdisk"
 	}
 	return result
 }
 
+// imageDeployInterface returns the deploy interface used to write disk
+// images, which the host may override.
+func (p *ironicProvisioner) imageDeployInterface() string {
+	if p.host.Spec.NodeInterfaces != nil && p.host.Spec.NodeInterfaces.Deploy != "" {
+		return p.host.Spec.NodeInterfaces.Deploy
+	}
+	return "direct"
+}
+
 // Adopt allows an externally-provisioned server to be adopted by Ironic.
 func (p *ironicProvisioner) Adopt(force bool) (result provisioner.Result, err error) {
 	var ironicNode *nodes.Node
@@ -1214,15 +1454,16 @@ func (p *ironicProvisioner) Adopt(force bool) (result provisioner.Result, err er
 func (p *ironicProvisioner) ironicHasSameImage(ironicNode *nodes.Node) (sameImage bool) {
 	// To make it easier to test if ironic is configured with
 	// the same image we are trying to provision to the host.
-	if p.host.Spec.Image != nil && p.host.Spec.Image.DiskFormat != nil && *p.host.Spec.Image.DiskFormat == "live-iso" {
-		sameImage = (ironicNode.InstanceInfo["boot_iso"] == p.host.Spec.Image.URL)
+	image := p.mirroredImage(p.host.Spec.Image)
+	if image.DiskFormat != nil && *image.DiskFormat == "live-iso" {
+		sameImage = (ironicNode.InstanceInfo["boot_iso"] == image.URL)
 		p.log.Info("checking image settings",
 			"boot_iso", ironicNode.InstanceInfo["boot_iso"],
 			"same", sameImage,
 			"provisionState", ironicNode.ProvisionState)
 	} else {
-		checksum, checksumType, _ := p.host.GetImageChecksum()
-		sameImage = (ironicNode.InstanceInfo["image_source"] == p.host.Spec.Image.URL &&
+		checksum, checksumType, _ := image.GetChecksum()
+		sameImage = (ironicNode.InstanceInfo["image_source"] == image.URL &&
 			ironicNode.InstanceInfo["image_os_hash_algo"] == checksumType &&
 			ironicNode.InstanceInfo["image_os_hash_value"] == checksum)
 		p.log.Info("checking image settings",
@@ -1340,8 +1581,8 @@ func (p *ironicProvisioner) Prepare(unprepared bool) (result provisioner.Result,
 	case nodes.Cleaning, nodes.CleanWait:
 		p.log.Info("waiting for host to become manageable",
 			"state", ironicNode.ProvisionState,
-			"deploy step", ironicNode.DeployStep)
-		result, err = operationContinuing(provisionRequeueDelay)
+			"clean step", ironicNode.CleanStep)
+		result, err = cleaningContinuing(ironicNode, provisionRequeueDelay)
 
 	default:
 		result, err = transientError(fmt.Errorf("Have unexpected ironic node state %s", ironicNode.ProvisionState))
@@ -1352,7 +1593,7 @@ func (p *ironicProvisioner) Prepare(unprepared bool) (result provisioner.Result,
 // Provision writes the image from the host spec to the host. It may
 // be called multiple times, and should return true for its dirty flag
 // until the deprovisioning operation is completed.
-func (p *ironicProvisioner) Provision(hostConf provisioner.HostConfigData) (result provisioner.Result, err error) {
+func (p *ironicProvisioner) Provision(hostConf provisioner.HostConfigData, requestID string) (result provisioner.Result, err error) {
 	var ironicNode *nodes.Node
 
 	if ironicNode, err = p.findExistingHost(); err != nil {
@@ -1362,6 +1603,11 @@ func (p *ironicProvisioner) Provision(hostConf provisioner.HostConfigData) (resu
 		return transientError(provisioner.NeedsRegistration)
 	}
 
+	if err := p.airGapImageError(p.host.Spec.Image); err != nil {
+		p.log.Info("refusing to provision", "reason", err.Error())
+		return operationFailed(err.Error())
+	}
+
 	p.log.Info("provisioning image to host", "state", ironicNode.ProvisionState)
 
 	ironicHasSameImage := p.ironicHasSameImage(ironicNode)
@@ -1371,11 +1617,25 @@ func (p *ironicProvisioner) Provision(hostConf provisioner.HostConfigData) (resu
 	switch nodes.ProvisionState(ironicNode.ProvisionState) {
 
 	case nodes.DeployFail:
+		// When the controller has chosen another NIC to boot
+		// from, retry through it unless that was already done. The
+		// request ID includes the NIC, so a deployment recorded with
+		// it went through the NIC.
+		retryThroughNIC := false
+		if bootMAC := p.host.Status.Provisioning.BootMACAddress; bootMAC != "" && requestID != "" {
+			if _, err = p.ensureBootPort(ironicNode, bootMAC); err != nil {
+				return transientError(err)
+			}
+			if retryThroughNIC = !deployRequested(ironicNode, requestID); retryThroughNIC {
+				p.log.Info("retrying provisioning through another NIC", "MAC", bootMAC)
+			}
+		}
+
 		// Since we were here ironic has recorded an error for this host,
 		// with the image and checksum we have been trying to use, so we
 		// should stop. (If the image values do not match, we want to try
 		// again.)
-		if ironicHasSameImage {
+		if ironicHasSameImage && !retryThroughNIC {
 			// Save me from "eventually consistent" systems built on
 			// top of relational databases...
 			if ironicNode.LastError == "" {
@@ -1391,8 +1651,13 @@ func (p *ironicProvisioner) Provision(hostConf provisioner.HostConfigData) (resu
 			return provResult, err
 		}
 
-		return p.changeNodeProvisionState(ironicNode,
-			nodes.ProvisionStateOpts{Target: nodes.TargetActive})
+		if retryThroughNIC {
+			return p.requestDeploy(ironicNode, requestID)
+		}
+		return p.requestOnce(ironicNode, requestID, provisionRequeueDelay, func() (bool, provisioner.Result, error) {
+			return p.tryChangeNodeProvisionState(ironicNode,
+				nodes.ProvisionStateOpts{Target: nodes.TargetActive})
+		})
 
 	case nodes.Manageable:
 		return p.changeNodeProvisionState(ironicNode,
@@ -1441,6 +1706,9 @@ func (p *ironicProvisioner) Provision(hostConf provisioner.HostConfigData) (resu
 			"local-hostname":   p.host.ObjectMeta.Name,
 			"local_hostname":   p.host.ObjectMeta.Name,
 		}
+		if err = templateMetaData(&p.host, metaData); err != nil {
+			return operationFailed(err.Error())
+		}
 		metaDataRaw, err := hostConf.MetaData()
 		if err != nil {
 			return transientError(errors.Wrap(err, "could not retrieve metadata"))
@@ -1450,9 +1718,10 @@ func (p *ironicProvisioner) Provision(hostConf provisioner.HostConfigData) (resu
 				return transientError(errors.Wrap(err, "failed to unmarshal metadata from secret"))
 			}
 		}
+		sshKeysMetaData(&p.host, metaData)
 
 		var configDrive nodes.ConfigDrive
-		if userData != "" {
+		if userData != "" || p.host.Spec.MetaDataTemplate != nil || len(p.host.Spec.SSHAuthorizedKeys) > 0 {
 			configDrive = nodes.ConfigDrive{
 				UserData:    userData,
 				MetaData:    metaData,
@@ -1466,13 +1735,15 @@ func (p *ironicProvisioner) Provision(hostConf provisioner.HostConfigData) (resu
 			p.log.Info("triggering provisioning without config drive")
 		}
 
-		return p.changeNodeProvisionState(
-			ironicNode,
-			nodes.ProvisionStateOpts{
-				Target:      nodes.TargetActive,
-				ConfigDrive: configDrive,
-			},
-		)
+		return p.requestOnce(ironicNode, requestID, provisionRequeueDelay, func() (bool, provisioner.Result, error) {
+			return p.tryChangeNodeProvisionState(
+				ironicNode,
+				nodes.ProvisionStateOpts{
+					Target:      nodes.TargetActive,
+					ConfigDrive: configDrive,
+				},
+			)
+		})
 
 	case nodes.Active:
 		// provisioning is done
@@ -1481,8 +1752,14 @@ func (p *ironicProvisioner) Provision(hostConf provisioner.HostConfigData) (resu
 		p.log.Info("finished provisioning")
 		return operationComplete()
 
+	case nodes.Cleaning, nodes.CleanWait:
+		p.log.Info("waiting for host to become available",
+			"state", ironicNode.ProvisionState,
+			"clean step", ironicNode.CleanStep)
+		return cleaningContinuing(ironicNode, provisionRequeueDelay)
+
 	default:
-		// wait states like cleaning and clean wait
+		// other wait states
 		p.log.Info("waiting for host to become available",
 			"state", ironicNode.ProvisionState,
 			"deploy step", ironicNode.DeployStep)
@@ -1491,9 +1768,8 @@ func (p *ironicProvisioner) Provision(hostConf provisioner.HostConfigData) (resu
 }
 
 func (p *ironicProvisioner) setMaintenanceFlag(ironicNode *nodes.Node, value bool) (result provisioner.Result, err error) {
-	_, err = nodes.Update(
-		p.client,
-		ironicNode.UUID,
+	_, err = p.updateNode(
+		ironicNode,
 		nodes.UpdateOpts{
 			nodes.UpdateOperation{
 				Op:    nodes.ReplaceOp,
@@ -1501,7 +1777,7 @@ func (p *ironicProvisioner) setMaintenanceFlag(ironicNode *nodes.Node, value boo
 				Value: value,
 			},
 		},
-	).Extract()
+	)
 	switch err.(type) {
 	case nil:
 	case gophercloud.ErrDefault409:
@@ -1513,10 +1789,152 @@ func (p *ironicProvisioner) setMaintenanceFlag(ironicNode *nodes.Node, value boo
 	return operationContinuing(0)
 }
 
+// skipBlockDevicesUpdates returns the updates of the skip_block_devices
+// property of the node, which the agent leaves out of its erasures,
+// to hold the disks with the serial numbers.
+func skipBlockDevicesUpdates(ironicNode *nodes.Node, serials []string) nodes.UpdateOpts {
+	current, found := ironicNode.Properties["skip_block_devices"]
+	if len(serials) == 0 {
+		if !found {
+			return nil
+		}
+		return nodes.UpdateOpts{
+			nodes.UpdateOperation{
+				Op:   nodes.RemoveOp,
+				Path: "/properties/skip_block_devices",
+			},
+		}
+	}
+
+	devices := make([]interface{}, 0, len(serials))
+	for _, serial := range serials {
+		devices = append(devices, map[string]interface{}{"serial": serial})
+	}
+	if found && reflect.DeepEqual(current, devices) {
+		return nil
+	}
+	return nodes.UpdateOpts{
+		nodes.UpdateOperation{
+			Op:    nodes.AddOp,
+			Path:  "/properties/skip_block_devices",
+			Value: devices,
+		},
+	}
+}
+
+// Erase securely erases the disks of the host with the erase_devices
+// clean step, which the agent runs with the best method each device
+// supports, or wipes their metadata with the erase_devices_metadata
+// clean step. The node is left manageable.
+func (p *ironicProvisioner) Erase(start bool, opts provisioner.EraseOptions) (result provisioner.Result, started bool, err error) {
+	ironicNode, err := p.findExistingHost()
+	if err != nil {
+		result, err = transientError(errors.Wrap(err, "could not find host to erase"))
+		return
+	}
+	if ironicNode == nil {
+		result, err = transientError(provisioner.NeedsRegistration)
+		return
+	}
+
+	switch nodes.ProvisionState(ironicNode.ProvisionState) {
+	case nodes.Available:
+		if !start {
+			// Erasing returns the node to manageable, so it was
+			// provided before its disks were erased
+			result, err = operationFailed("host became available before its disks were erased")
+			return
+		}
+		// Manual cleaning requires a manageable node
+		result, err = p.changeNodeProvisionState(
+			ironicNode,
+			nodes.ProvisionStateOpts{Target: nodes.TargetManage},
+		)
+
+	case nodes.Manageable:
+		if !start {
+			if opts.MetadataOnly {
+				p.publisher("MetadataWipeComplete", "Disk metadata wipe completed")
+			} else {
+				p.publisher("EraseComplete", "Disk erasure completed")
+			}
+			result, err = operationComplete()
+			return
+		}
+
+		// The disks left alone are set on the node, since the agent
+		// reads them from its properties
+		if updates := skipBlockDevicesUpdates(ironicNode, opts.SkipSerials); len(updates) != 0 {
+			p.log.Info("updating the disks skipped by the erasure", "serials", opts.SkipSerials)
+			ironicNode, err = p.updateNode(ironicNode, updates)
+			switch err.(type) {
+			case nil:
+			case gophercloud.ErrDefault409:
+				p.log.Info("could not update the disks skipped by the erasure, busy")
+				result, err = retryAfterDelay(provisionRequeueDelay)
+				return
+			default:
+				result, err = transientError(errors.Wrap(err, "failed to update the disks skipped by the erasure"))
+				return
+			}
+		}
+
+		step := "erase_devices"
+		if opts.MetadataOnly {
+			step = "erase_devices_metadata"
+		}
+		p.log.Info("starting disk erasure", "step", step)
+		started, result, err = p.tryChangeNodeProvisionState(
+			ironicNode,
+			nodes.ProvisionStateOpts{
+				Target: nodes.TargetClean,
+				CleanSteps: []nodes.CleanStep{
+					{
+						Interface: "deploy",
+						Step:      step,
+					},
+				},
+			},
+		)
+		if started {
+			if opts.MetadataOnly {
+				p.publisher("MetadataWipeStarted", "Disk metadata wipe started")
+			} else {
+				p.publisher("EraseStarted", "Disk erasure started")
+			}
+		}
+
+	case nodes.CleanFail:
+		if !start {
+			result, err = operationFailed(ironicNode.LastError)
+			return
+		}
+		if ironicNode.Maintenance {
+			p.log.Info("clearing maintenance flag")
+			result, err = p.setMaintenanceFlag(ironicNode, false)
+			return
+		}
+		result, err = p.changeNodeProvisionState(
+			ironicNode,
+			nodes.ProvisionStateOpts{Target: nodes.TargetManage},
+		)
+
+	case nodes.Cleaning, nodes.CleanWait:
+		p.log.Info("waiting for disk erasure",
+			"state", ironicNode.ProvisionState,
+			"clean step", ironicNode.CleanStep)
+		result, err = cleaningContinuing(ironicNode, provisionRequeueDelay)
+
+	default:
+		result, err = transientError(fmt.Errorf("Have unexpected ironic node state %s", ironicNode.ProvisionState))
+	}
+	return
+}
+
 // Deprovision removes the host from the image. It may be called
 // multiple times, and should return true for its dirty flag until the
 // deprovisioning operation is completed.
-func (p *ironicProvisioner) Deprovision(force bool) (result provisioner.Result, err error) {
+func (p *ironicProvisioner) Deprovision(force bool, requestID string) (result provisioner.Result, err error) {
 	p.log.Info("deprovisioning")
 
 	ironicNode, err := p.findExistingHost()
@@ -1549,10 +1967,12 @@ func (p *ironicProvisioner) Deprovision(force bool) (result provisioner.Result,
 		}
 		p.log.Info("retrying deprovisioning")
 		p.publisher("DeprovisioningStarted", "Image deprovisioning restarted")
-		return p.changeNodeProvisionState(
-			ironicNode,
-			nodes.ProvisionStateOpts{Target: nodes.TargetDeleted},
-		)
+		return p.requestOnce(ironicNode, requestID, deprovisionRequeueDelay, func() (bool, provisioner.Result, error) {
+			return p.tryChangeNodeProvisionState(
+				ironicNode,
+				nodes.ProvisionStateOpts{Target: nodes.TargetDeleted},
+			)
+		})
 
 	case nodes.CleanFail:
 		p.log.Info("cleaning failed")
@@ -1575,9 +1995,15 @@ func (p *ironicProvisioner) Deprovision(force bool) (result provisioner.Result,
 		// get cleaned before we provision it again. Therefore, just declare
 		// deprovisioning complete.
 		p.log.Info("deprovisioning node is in manageable state")
+		if err := p.resetBootPort(ironicNode); err != nil {
+			return transientError(err)
+		}
 		return operationComplete()
 
 	case nodes.Available:
+		if err := p.resetBootPort(ironicNode); err != nil {
+			return transientError(err)
+		}
 		p.publisher("DeprovisioningComplete", "Image deprovisioning completed")
 		return operationComplete()
 
@@ -1587,21 +2013,23 @@ func (p *ironicProvisioner) Deprovision(force bool) (result provisioner.Result,
 		return operationContinuing(deprovisionRequeueDelay)
 
 	case nodes.Cleaning:
-		p.log.Info("cleaning")
+		p.log.Info("cleaning", "clean step", ironicNode.CleanStep)
 		// Transitions to Available upon completion
-		return operationContinuing(deprovisionRequeueDelay)
+		return cleaningContinuing(ironicNode, deprovisionRequeueDelay)
 
 	case nodes.CleanWait:
-		p.log.Info("cleaning")
-		return operationContinuing(deprovisionRequeueDelay)
+		p.log.Info("cleaning", "clean step", ironicNode.CleanStep)
+		return cleaningContinuing(ironicNode, deprovisionRequeueDelay)
 
 	case nodes.Active, nodes.DeployFail:
 		p.log.Info("starting deprovisioning")
 		p.publisher("DeprovisioningStarted", "Image deprovisioning started")
-		return p.changeNodeProvisionState(
-			ironicNode,
-			nodes.ProvisionStateOpts{Target: nodes.TargetDeleted},
-		)
+		return p.requestOnce(ironicNode, requestID, deprovisionRequeueDelay, func() (bool, provisioner.Result, error) {
+			return p.tryChangeNodeProvisionState(
+				ironicNode,
+				nodes.ProvisionStateOpts{Target: nodes.TargetDeleted},
+			)
+		})
 
 	default:
 		// FIXME(zaneb): this error is unlikely to actually be transient
@@ -1656,7 +2084,7 @@ func (p *ironicProvisioner) Delete() (result provisioner.Result, err error) {
 	}
 
 	p.log.Info("host ready to be removed")
-	err = nodes.Delete(p.client, ironicNode.UUID).ExtractErr()
+	err = p.deleteNode(ironicNode)
 	switch err.(type) {
 	case nil:
 		p.log.Info("removed")
@@ -1672,7 +2100,7 @@ func (p *ironicProvisioner) Delete() (result provisioner.Result, err error) {
 	return operationContinuing(0)
 }
 
-func (p *ironicProvisioner) changePower(ironicNode *nodes.Node, target nodes.TargetPowerState) (result provisioner.Result, err error) {
+func (p *ironicProvisioner) changePower(ironicNode *nodes.Node, target nodes.TargetPowerState, requestID string) (result provisioner.Result, err error) {
 	p.log.Info("changing power state")
 
 	if ironicNode.TargetProvisionState != "" {
@@ -1680,42 +2108,75 @@ func (p *ironicProvisioner) changePower(ironicNode *nodes.Node, target nodes.Tar
 			"state", ironicNode.ProvisionState,
 			"target state", ironicNode.TargetProvisionState,
 		)
-		return operationContinuing(powerRequeueDelay)
+		return operationContinuing(p.getPowerRequeueDelay())
 	}
 
 	powerStateOpts := nodes.PowerStateOpts{
 		Target: target,
 	}
 	if target == softPowerOff {
-		powerStateOpts.Timeout = int(softPowerOffTimeout.Seconds())
+		powerStateOpts.Timeout = int(p.getSoftPowerOffTimeout().Seconds())
+	}
+
+	if requestID != "" {
+		// Use a different token for each target, so that falling
+		// back from a soft to a hard power off is not blocked.
+		requestID = fmt.Sprintf("%s/%s", requestID, target)
+		if alreadyRequestedWithin(ironicNode, requestID, p.getPowerReplayWindow(target)) {
+			p.log.Info("power change already requested, waiting for it to complete",
+				"requestID", requestID)
+			return operationContinuing(p.getPowerRequeueDelay())
+		}
+		result, err = p.recordRequest(ironicNode, requestID)
+		if err != nil {
+			return result, err
+		}
+		if result.Dirty {
+			return result, HostLockedError{Address: p.host.Spec.BMC.Address}
+		}
 	}
 
-	changeResult := nodes.ChangePowerState(
-		p.client,
-		ironicNode.UUID,
-		powerStateOpts)
+	changeErr := p.changePowerState(ironicNode, powerStateOpts)
+	_, locked := changeErr.(gophercloud.ErrDefault409)
+	if changeErr != nil && !locked && requestID != "" {
+		// Ironic did not accept the change, so it is requested
+		// again on the next reconcile
+		p.forgetRequest(ironicNode)
+	}
 
-	switch changeResult.Err.(type) {
+	switch changeErr.(type) {
 	case nil:
 		p.log.Info("power change OK")
-		return operationContinuing(0)
+		// Slow BMCs are not polled before they can have converged
+		return operationContinuing(p.getPowerLatency(target))
 	case gophercloud.ErrDefault409:
-		p.log.Info("host is locked, trying again after delay", "delay", powerRequeueDelay)
-		result, _ = retryAfterDelay(powerRequeueDelay)
+		// Locked nodes are retried sooner a few times, without
+		// holding up the reconcile, before waiting the usual delay
+		delay := p.getPowerRequeueDelay()
+		conflicts := powerConflicts(ironicNode, requestID)
+		if conflicts < p.quirks.PowerConflictRetries {
+			delay = p.getPowerConflictRetryDelay(conflicts)
+			conflicts++
+		} else {
+			conflicts = 0
+		}
+		p.recordPowerConflicts(ironicNode, requestID, conflicts)
+		p.log.Info("host is locked, trying again after delay", "delay", delay, "conflicts", conflicts)
+		result, _ = retryAfterDelay(delay)
 		return result, HostLockedError{Address: p.host.Spec.BMC.Address}
 	case gophercloud.ErrDefault400:
 		// Error 400 Bad Request means target power state is not supported by vendor driver
-		p.log.Info("power change error", "message", changeResult.Err)
+		p.log.Info("power change error", "message", changeErr)
 		return result, SoftPowerOffUnsupportedError{Address: p.host.Spec.BMC.Address}
 	default:
-		p.log.Info("power change error", "message", changeResult.Err)
-		return transientError(errors.Wrap(changeResult.Err, "failed to change power state"))
+		p.log.Info("power change error", "message", changeErr)
+		return transientError(errors.Wrap(changeErr, "failed to change power state"))
 	}
 }
 
 // PowerOn ensures the server is powered on independently of any image
 // provisioning operation.
-func (p *ironicProvisioner) PowerOn() (result provisioner.Result, err error) {
+func (p *ironicProvisioner) PowerOn(requestID string) (result provisioner.Result, err error) {
 	p.log.Info("ensuring host is powered on")
 
 	ironicNode, err := p.findExistingHost()
@@ -1730,9 +2191,9 @@ func (p *ironicProvisioner) PowerOn() (result provisioner.Result, err error) {
 	if ironicNode.PowerState != powerOn {
 		if ironicNode.TargetPowerState == powerOn {
 			p.log.Info("waiting for power status to change")
-			return operationContinuing(powerRequeueDelay)
+			return operationContinuing(p.getPowerRequeueDelay())
 		}
-		result, err = p.changePower(ironicNode, nodes.PowerOn)
+		result, err = p.changePower(ironicNode, nodes.PowerOn, requestID)
 		switch err.(type) {
 		case nil:
 		case HostLockedError:
@@ -1747,22 +2208,26 @@ func (p *ironicProvisioner) PowerOn() (result provisioner.Result, err error) {
 
 // PowerOff ensures the server is powered off independently of any image
 // provisioning operation.
-func (p *ironicProvisioner) PowerOff(rebootMode metal3v1alpha1.RebootMode) (result provisioner.Result, err error) {
+func (p *ironicProvisioner) PowerOff(rebootMode metal3v1alpha1.RebootMode, requestID string) (result provisioner.Result, err error) {
 	p.log.Info(fmt.Sprintf("ensuring host is powered off (mode: %s)", rebootMode))
 
-	if rebootMode == metal3v1alpha1.RebootModeHard {
-		result, err = p.hardPowerOff()
+	if rebootMode == metal3v1alpha1.RebootModeHard || p.quirks.DisableSoftPowerOff {
+		result, err = p.hardPowerOff(requestID)
 	} else {
-		result, err = p.softPowerOff()
+		result, err = p.softPowerOff(requestID)
 	}
 	if err != nil {
 		switch err.(type) {
 		// In case of soft power off is unsupported or has failed,
 		// we activate hard power off.
 		case SoftPowerOffUnsupportedError, SoftPowerOffFailed:
-			return p.hardPowerOff()
+			result, err = p.hardPowerOff(requestID)
+			if _, locked := err.(HostLockedError); locked {
+				return result, nil
+			}
+			return result, err
 		case HostLockedError:
-			return retryAfterDelay(powerRequeueDelay)
+			return result, nil
 		default:
 			return transientError(err)
 		}
@@ -1771,7 +2236,7 @@ func (p *ironicProvisioner) PowerOff(rebootMode metal3v1alpha1.RebootMode) (resu
 }
 
 // hardPowerOff sends 'power off' request to BM node and waits for the result
-func (p *ironicProvisioner) hardPowerOff() (result provisioner.Result, err error) {
+func (p *ironicProvisioner) hardPowerOff(requestID string) (result provisioner.Result, err error) {
 	p.log.Info("ensuring host is powered off by \"hard power off\" command")
 
 	ironicNode, err := p.findExistingHost()
@@ -1782,10 +2247,14 @@ func (p *ironicProvisioner) hardPowerOff() (result provisioner.Result, err error
 	if ironicNode.PowerState != powerOff {
 		if ironicNode.TargetPowerState == powerOff {
 			p.log.Info("waiting for power status to change")
-			return operationContinuing(powerRequeueDelay)
+			return operationContinuing(p.getPowerRequeueDelay())
 		}
-		result, err = p.changePower(ironicNode, nodes.PowerOff)
-		if err != nil {
+		result, err = p.changePower(ironicNode, nodes.PowerOff, requestID)
+		switch err.(type) {
+		case nil:
+		case HostLockedError:
+			return result, err
+		default:
 			return transientError(errors.Wrap(err, "failed to power off host"))
 		}
 		p.publisher("PowerOff", "Host powered off")
@@ -1800,7 +2269,7 @@ func (p *ironicProvisioner) hardPowerOff() (result provisioner.Result, err error
 // Otherwise the request ends with no error and the result should be
 // checked later via node fields "power_state", "target_power_state"
 // and "last_error".
-func (p *ironicProvisioner) softPowerOff() (result provisioner.Result, err error) {
+func (p *ironicProvisioner) softPowerOff(requestID string) (result provisioner.Result, err error) {
 	p.log.Info("ensuring host is powered off by \"soft power off\" command")
 
 	ironicNode, err := p.findExistingHost()
@@ -1813,15 +2282,19 @@ func (p *ironicProvisioner) softPowerOff() (result provisioner.Result, err error
 		// If the target state is either powerOff or softPowerOff, then we should wait
 		if targetState == powerOff || targetState == softPowerOff {
 			p.log.Info("waiting for power status to change")
-			return operationContinuing(powerRequeueDelay)
+			return operationContinuing(p.getPowerRequeueDelay())
 		}
 		// If the target state is unset while the last error is set,
 		// then the last execution of soft power off has failed.
 		if targetState == "" && ironicNode.LastError != "" {
 			return result, SoftPowerOffFailed{Address: p.host.Spec.BMC.Address}
 		}
-		result, err = p.changePower(ironicNode, nodes.SoftPowerOff)
-		if err != nil {
+		result, err = p.changePower(ironicNode, nodes.SoftPowerOff, requestID)
+		switch err.(type) {
+		case nil:
+		case HostLockedError:
+			return result, err
+		default:
 			return transientError(err)
 		}
 		p.publisher("PowerOff", "Host soft powered off")
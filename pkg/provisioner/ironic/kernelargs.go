@@ -0,0 +1,49 @@
+package ironic
+
+import (
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// operatorKernelParams are the kernel parameters the operator derives
+// from the settings of the host.
+var operatorKernelParams = []string{
+	collectorsKernelParam,
+	benchmarksKernelParam,
+	ironicCallbackKernelParam,
+	inspectionCallbackKernelParam,
+	downloadLimitKernelParam,
+	multipathKernelParam,
+}
+
+// hostKernelParams returns the agent kernel arguments given in the
+// spec of the host.
+func hostKernelParams(host *metal3v1alpha1.BareMetalHost) string {
+	if len(host.Spec.AgentKernelArgs) == 0 {
+		return ""
+	}
+	return " " + strings.Join(host.Spec.AgentKernelArgs, " ")
+}
+
+// staleKernelArgs reports whether the kernel parameters of the node
+// still hold arguments from the spec of the host after all of them
+// were removed.
+func staleKernelArgs(ironicNode *nodes.Node, host *metal3v1alpha1.BareMetalHost) bool {
+	current, _ := ironicNode.DriverInfo["kernel_append_params"].(string)
+	if len(host.Spec.AgentKernelArgs) > 0 || !ownKernelParams(current) {
+		return false
+	}
+	for _, arg := range strings.Fields(strings.TrimPrefix(current, defaultKernelParams)) {
+		known := false
+		for _, param := range operatorKernelParams {
+			known = known || strings.HasPrefix(" "+arg, param)
+		}
+		if !known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestHostKernelParams(t *testing.T) {
+	host := makeHost()
+	assert.Equal(t, "", hostKernelParams(&host))
+
+	host.Spec.AgentKernelArgs = []string{"console=ttyS0,115200", "ip=dhcp6"}
+	assert.Equal(t, " console=ttyS0,115200 ip=dhcp6", hostKernelParams(&host))
+}
+
+func TestKernelArgsSettings(t *testing.T) {
+	limit := 100
+	host := makeHost()
+	host.Spec.ImageDownloadLimitMbps = &limit
+	host.Spec.AgentKernelArgs = []string{"console=ttyS0"}
+	host.Spec.Image = nil
+	host.Status.Provisioning.ID = "" // so we don't lookup by uuid
+
+	var createdNode *nodes.Node
+	ironic := testserver.NewIronic(t).Ready().CreateNodes(func(node nodes.Node) {
+		createdNode = &node
+	}).NoNode(host.Name)
+	ironic.Start()
+	defer ironic.Stop()
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	result, _, err := prov.ValidateManagementAccess(false, false)
+	if err != nil {
+		t.Fatalf("error from ValidateManagementAccess: %s", err)
+	}
+	assert.Equal(t, "", result.ErrorMessage)
+	if assert.NotNil(t, createdNode) {
+		assert.Equal(t, "%default% ipa-image-download-limit-mbps=100 console=ttyS0", createdNode.DriverInfo["kernel_append_params"])
+	}
+}
+
+func TestStaleKernelArgs(t *testing.T) {
+	host := makeHost()
+	p := &ironicProvisioner{host: host}
+	node := &nodes.Node{
+		DriverInfo: map[string]interface{}{
+			"deploy_kernel":        deployKernelURL,
+			"deploy_ramdisk":       deployRamdiskURL,
+			"kernel_append_params": "%default% console=ttyS0",
+		},
+	}
+	assert.True(t, staleKernelArgs(node, &host))
+	updates := p.agentSettingsUpdates(node, nil, agentImageSettings(nil, nil))
+	if assert.Len(t, updates, 1) {
+		assert.Equal(t, nodes.RemoveOp, updates[0].(nodes.UpdateOperation).Op)
+		assert.Equal(t, "/driver_info/kernel_append_params", updates[0].(nodes.UpdateOperation).Path)
+	}
+
+	host.Spec.AgentKernelArgs = []string{"console=ttyS0"}
+	assert.False(t, staleKernelArgs(node, &host))
+
+	// Parameters the operator derives from other settings are not
+	// kernel arguments of the host
+	host.Spec.AgentKernelArgs = nil
+	node.DriverInfo["kernel_append_params"] = "%default% ipa-api-url=https://[fd00:2::2]:6385 rd.multipath=default"
+	assert.False(t, staleKernelArgs(node, &host))
+
+	// Kernel parameters set by someone else are left alone
+	node.DriverInfo["kernel_append_params"] = "nofb console=ttyS0"
+	assert.False(t, staleKernelArgs(node, &host))
+	assert.Empty(t, p.agentSettingsUpdates(node, nil, agentImageSettings(nil, nil)))
+}
@@ -0,0 +1,75 @@
+package ironic
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// executeMetaDataTemplate renders one of the metadata templates of the
+// host. Missing map keys, such as labels the host does not have, are
+// errors rather than "<no value>".
+func executeMetaDataTemplate(host *metal3v1alpha1.BareMetalHost, name, text string) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid metadata template %s", name)
+	}
+	var value bytes.Buffer
+	if err := tmpl.Execute(&value, host); err != nil {
+		return "", errors.Wrapf(err, "failed to render metadata template %s", name)
+	}
+	return value.String(), nil
+}
+
+// templateMetaData adds the metadata generated from the template of
+// the host to metaData.
+func templateMetaData(host *metal3v1alpha1.BareMetalHost, metaData map[string]interface{}) error {
+	tmpl := host.Spec.MetaDataTemplate
+	if tmpl == nil {
+		return nil
+	}
+
+	if tmpl.Hostname != "" {
+		hostname, err := executeMetaDataTemplate(host, "hostname", tmpl.Hostname)
+		if err != nil {
+			return err
+		}
+		metaData["local-hostname"] = hostname
+		metaData["local_hostname"] = hostname
+	}
+	if tmpl.IncludeLabels && len(host.Labels) > 0 {
+		labels := make(map[string]interface{}, len(host.Labels))
+		for key, value := range host.Labels {
+			labels[key] = value
+		}
+		metaData["metal3-labels"] = labels
+	}
+	for key, text := range tmpl.Values {
+		value, err := executeMetaDataTemplate(host, key, text)
+		if err != nil {
+			return err
+		}
+		metaData[key] = value
+	}
+	return nil
+}
+
+// sshKeysMetaData adds the SSH keys of the host to the public keys of
+// the metadata, keeping those already there.
+func sshKeysMetaData(host *metal3v1alpha1.BareMetalHost, metaData map[string]interface{}) {
+	if len(host.Spec.SSHAuthorizedKeys) == 0 {
+		return
+	}
+	publicKeys, _ := metaData["public_keys"].(map[string]interface{})
+	if publicKeys == nil {
+		publicKeys = make(map[string]interface{}, len(host.Spec.SSHAuthorizedKeys))
+	}
+	for i, key := range host.Spec.SSHAuthorizedKeys {
+		publicKeys[fmt.Sprintf("metal3-%d", i)] = key
+	}
+	metaData["public_keys"] = publicKeys
+}
@@ -0,0 +1,54 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestTemplateMetaData(t *testing.T) {
+	host := makeHost()
+	host.Labels = map[string]string{"rack": "r12"}
+
+	metaData := map[string]interface{}{"local-hostname": host.Name}
+	assert.NoError(t, templateMetaData(&host, metaData))
+	assert.Equal(t, map[string]interface{}{"local-hostname": host.Name}, metaData)
+
+	host.Spec.MetaDataTemplate = &metal3v1alpha1.MetaDataTemplate{
+		Hostname:      `{{ .Name }}.{{ index .Labels "rack" }}.example.com`,
+		IncludeLabels: true,
+		Values:        map[string]string{"metal3-bmc": "{{ .Spec.BMC.Address }}"},
+	}
+	assert.NoError(t, templateMetaData(&host, metaData))
+	assert.Equal(t, map[string]interface{}{
+		"local-hostname": "myhost.r12.example.com",
+		"local_hostname": "myhost.r12.example.com",
+		"metal3-labels":  map[string]interface{}{"rack": "r12"},
+		"metal3-bmc":     host.Spec.BMC.Address,
+	}, metaData)
+
+	// Labels the host does not have are errors
+	host.Spec.MetaDataTemplate.Hostname = `{{ .Name }}.{{ .Labels.zone }}`
+	err := templateMetaData(&host, metaData)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to render metadata template hostname")
+	}
+}
+
+func TestSSHKeysMetaData(t *testing.T) {
+	host := makeHost()
+	metaData := map[string]interface{}{}
+	sshKeysMetaData(&host, metaData)
+	assert.Empty(t, metaData)
+
+	host.Spec.SSHAuthorizedKeys = []string{"ssh-ed25519 AAAA ops@example.com", "ssh-rsa BBBB"}
+	metaData["public_keys"] = map[string]interface{}{"admin": "ssh-ed25519 CCCC admin@example.com"}
+	sshKeysMetaData(&host, metaData)
+	assert.Equal(t, map[string]interface{}{
+		"admin":    "ssh-ed25519 CCCC admin@example.com",
+		"metal3-0": "ssh-ed25519 AAAA ops@example.com",
+		"metal3-1": "ssh-rsa BBBB",
+	}, metaData["public_keys"])
+}
@@ -0,0 +1,38 @@
+package ironic
+
+import (
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// multipathKernelParam starts multipathd in the agent ramdisk, so that
+// the agent lists the multipath devices and writes the image to them
+// rather than to one of their paths.
+const multipathKernelParam = " rd.multipath=default"
+
+// usesMultipath reports whether the root device of the host is a
+// multipath device.
+func usesMultipath(host *metal3v1alpha1.BareMetalHost) bool {
+	return host.Spec.RootDeviceHints.Multipath()
+}
+
+// multipathKernelParams returns the kernel parameters enabling
+// multipath in the agent of the host.
+func multipathKernelParams(host *metal3v1alpha1.BareMetalHost) string {
+	if !usesMultipath(host) {
+		return ""
+	}
+	return multipathKernelParam
+}
+
+// staleMultipath reports whether the kernel parameters of the node
+// still enable multipath after the root device hints of the host
+// stopped selecting a multipath device.
+func staleMultipath(ironicNode *nodes.Node, host *metal3v1alpha1.BareMetalHost) bool {
+	current, _ := ironicNode.DriverInfo["kernel_append_params"].(string)
+	return !usesMultipath(host) && ownKernelParams(current) &&
+		strings.Contains(current, multipathKernelParam)
+}
@@ -0,0 +1,40 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestMultipathKernelParams(t *testing.T) {
+	host := makeHost()
+	assert.Equal(t, "", multipathKernelParams(&host))
+
+	host.Spec.RootDeviceHints = &metal3v1alpha1.RootDeviceHints{
+		ByPath: "/dev/disk/by-path/pci-0000:41:00.0-fc-0x500a0981891b8dc5*",
+	}
+	assert.Equal(t, "", multipathKernelParams(&host))
+
+	host.Spec.RootDeviceHints.WWID = "3600a098038304437415d4b6a59684a52"
+	assert.Equal(t, " rd.multipath=default", multipathKernelParams(&host))
+}
+
+func TestStaleMultipath(t *testing.T) {
+	host := makeHost()
+	node := &nodes.Node{DriverInfo: map[string]interface{}{
+		"kernel_append_params": "%default% rd.multipath=default",
+	}}
+	assert.True(t, staleMultipath(node, &host))
+	assert.True(t, staleAgentParams(node, &host))
+
+	host.Spec.RootDeviceHints = &metal3v1alpha1.RootDeviceHints{WWID: "3600a098038304437415d4b6a59684a52"}
+	assert.False(t, staleMultipath(node, &host))
+
+	// Kernel parameters set by someone else are left alone
+	host.Spec.RootDeviceHints = nil
+	node.DriverInfo["kernel_append_params"] = "nofb rd.multipath=default"
+	assert.False(t, staleMultipath(node, &host))
+}
@@ -0,0 +1,117 @@
+package ironic
+
+import (
+	"io/ioutil"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// ProvisioningNetwork describes the endpoints reachable from the
+// hosts booting from one provisioning network, so that a single
+// operator can serve several isolated networks.
+type ProvisioningNetwork struct {
+	Name string `json:"name"`
+
+	// DeployKernelURL and DeployRamdiskURL replace the
+	// DEPLOY_KERNEL_URL and DEPLOY_RAMDISK_URL settings.
+	DeployKernelURL  string `json:"deployKernelURL,omitempty"`
+	DeployRamdiskURL string `json:"deployRamdiskURL,omitempty"`
+
+	// ExternalHTTPURL is the image server of Ironic on the network,
+	// serving the virtual media images.
+	ExternalHTTPURL string `json:"externalHTTPURL,omitempty"`
+
+	// IronicCallbackURL and InspectionCallbackURL are the Ironic and
+	// Ironic Inspector APIs on the network, called back by the
+	// deployment agent.
+	IronicCallbackURL     string `json:"ironicCallbackURL,omitempty"`
+	InspectionCallbackURL string `json:"inspectionCallbackURL,omitempty"`
+}
+
+// provisioningNetworksConfig is the content of the provisioning
+// networks file.
+type provisioningNetworksConfig struct {
+	Networks []ProvisioningNetwork `json:"networks"`
+}
+
+// provisioningNetworks are the networks of the
+// PROVISIONING_NETWORKS_FILE setting, by name.
+var provisioningNetworks map[string]ProvisioningNetwork
+
+// urls returns the URLs of the network by setting name.
+func (n ProvisioningNetwork) urls() map[string]string {
+	return map[string]string{
+		"deployKernelURL":       n.DeployKernelURL,
+		"deployRamdiskURL":      n.DeployRamdiskURL,
+		"externalHTTPURL":       n.ExternalHTTPURL,
+		"ironicCallbackURL":     n.IronicCallbackURL,
+		"inspectionCallbackURL": n.InspectionCallbackURL,
+	}
+}
+
+// loadProvisioningNetworks reads and validates the provisioning
+// networks file.
+func loadProvisioningNetworks(path string) (map[string]ProvisioningNetwork, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read provisioning networks file")
+	}
+	var config provisioningNetworksConfig
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, errors.Wrap(err, "could not parse provisioning networks file")
+	}
+
+	networks := make(map[string]ProvisioningNetwork, len(config.Networks))
+	for _, network := range config.Networks {
+		if network.Name == "" {
+			return nil, errors.New("provisioning network has no name")
+		}
+		if _, duplicate := networks[network.Name]; duplicate {
+			return nil, errors.Errorf("duplicate provisioning network %s", network.Name)
+		}
+		for field, value := range network.urls() {
+			if value == "" {
+				continue
+			}
+			parsed, err := url.Parse(value)
+			if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+				return nil, errors.Errorf("%s of provisioning network %s: %q is not an absolute URL",
+					field, network.Name, value)
+			}
+		}
+		networks[network.Name] = network
+	}
+	return networks, nil
+}
+
+// provisioningNetwork returns the provisioning network of the host,
+// or nil when it uses the default endpoints.
+func (p *ironicProvisioner) provisioningNetwork() (*ProvisioningNetwork, error) {
+	name := p.host.Spec.ProvisioningNetwork
+	if name == "" {
+		return nil, nil
+	}
+	network, ok := provisioningNetworks[name]
+	if !ok {
+		return nil, errors.Errorf("unknown provisioning network %q", name)
+	}
+	return &network, nil
+}
+
+// networkKernelParams returns the kernel parameters pointing the
+// deployment agent to the callback URLs of the network.
+func networkKernelParams(network *ProvisioningNetwork) string {
+	if network == nil {
+		return ""
+	}
+	var params string
+	if network.IronicCallbackURL != "" {
+		params += ironicCallbackKernelParam + network.IronicCallbackURL
+	}
+	if network.InspectionCallbackURL != "" {
+		params += inspectionCallbackKernelParam + network.InspectionCallbackURL
+	}
+	return params
+}
@@ -0,0 +1,126 @@
+package ironic
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestLoadProvisioningNetworks(t *testing.T) {
+	testCases := []struct {
+		Scenario    string
+		Content     string
+		ExpectError string
+		ExpectNames []string
+	}{
+		{
+			Scenario: "valid",
+			Content: `
+networks:
+- name: rack-1
+  deployKernelURL: http://172.22.0.2/images/ironic-python-agent.kernel
+- name: rack-2
+  ironicCallbackURL: https://[fd00:2::2]:6385
+`,
+			ExpectNames: []string{"rack-1", "rack-2"},
+		},
+		{
+			Scenario:    "no name",
+			Content:     "networks:\n- deployKernelURL: http://172.22.0.2/ipa.kernel\n",
+			ExpectError: "provisioning network has no name",
+		},
+		{
+			Scenario:    "duplicate",
+			Content:     "networks:\n- name: rack-1\n- name: rack-1\n",
+			ExpectError: "duplicate provisioning network rack-1",
+		},
+		{
+			Scenario:    "relative URL",
+			Content:     "networks:\n- name: rack-1\n  externalHTTPURL: /images\n",
+			ExpectError: `externalHTTPURL of provisioning network rack-1: "/images" is not an absolute URL`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "networks.yaml")
+			if err := ioutil.WriteFile(path, []byte(tc.Content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			networks, err := loadProvisioningNetworks(path)
+			if tc.ExpectError != "" {
+				assert.EqualError(t, err, tc.ExpectError)
+				return
+			}
+			assert.NoError(t, err)
+			var names []string
+			for _, name := range tc.ExpectNames {
+				if _, ok := networks[name]; ok {
+					names = append(names, name)
+				}
+			}
+			assert.Equal(t, tc.ExpectNames, names)
+			assert.Len(t, networks, len(tc.ExpectNames))
+		})
+	}
+}
+
+func TestProvisioningNetworkSettings(t *testing.T) {
+	provisioningNetworks = map[string]ProvisioningNetwork{
+		"rack-2": {
+			Name:                  "rack-2",
+			DeployKernelURL:       "http://[fd00:2::2]/images/ironic-python-agent.kernel",
+			ExternalHTTPURL:       "http://[fd00:2::2]:6180",
+			IronicCallbackURL:     "https://[fd00:2::2]:6385",
+			InspectionCallbackURL: "https://[fd00:2::2]:5050/v1/continue",
+		},
+	}
+	defer func() { provisioningNetworks = nil }()
+
+	host := makeHost()
+	host.Spec.ProvisioningNetwork = "rack-2"
+	host.Spec.Image = nil
+	host.Status.Provisioning.ID = "" // so we don't lookup by uuid
+
+	var createdNode *nodes.Node
+	ironic := testserver.NewIronic(t).Ready().CreateNodes(func(node nodes.Node) {
+		createdNode = &node
+	}).NoNode(host.Name)
+	ironic.Start()
+	defer ironic.Stop()
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	result, _, err := prov.ValidateManagementAccess(false, false)
+	if err != nil {
+		t.Fatalf("error from ValidateManagementAccess: %s", err)
+	}
+	assert.Equal(t, "", result.ErrorMessage)
+	if assert.NotNil(t, createdNode) {
+		assert.Equal(t, "http://[fd00:2::2]/images/ironic-python-agent.kernel", createdNode.DriverInfo["deploy_kernel"])
+		assert.Equal(t, deployRamdiskURL, createdNode.DriverInfo["deploy_ramdisk"])
+		assert.Equal(t, "http://[fd00:2::2]:6180", createdNode.DriverInfo["external_http_url"])
+		assert.Equal(t, "%default% ipa-api-url=https://[fd00:2::2]:6385 ipa-inspection-callback-url=https://[fd00:2::2]:5050/v1/continue",
+			createdNode.DriverInfo["kernel_append_params"])
+	}
+
+	prov.host.Spec.ProvisioningNetwork = "rack-3"
+	result, _, err = prov.ValidateManagementAccess(false, false)
+	if err != nil {
+		t.Fatalf("error from ValidateManagementAccess: %s", err)
+	}
+	assert.Equal(t, `unknown provisioning network "rack-3"`, result.ErrorMessage)
+}
@@ -0,0 +1,174 @@
+package ironic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmcproxy"
+	"github.com/metal3-io/baremetal-operator/pkg/redfish"
+)
+
+// redfishArch maps the Redfish instruction sets to the architectures
+// reported by inspection.
+var redfishArch = map[string]string{
+	"x86-64":   "x86_64",
+	"ARM-A64":  "aarch64",
+	"PowerISA": "ppc64le",
+}
+
+// InspectOutOfBand reads the inventory of the system from its Redfish
+// BMC. The values the BMC reports replace those found by inspection,
+// and the disks and NICs it lists keep the details only inspection
+// finds, such as their device names and IP addresses.
+func (p *ironicProvisioner) InspectOutOfBand() (details *metal3v1alpha1.HardwareDetails, err error) {
+	client, systemID, err := p.redfishClient()
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, errors.Errorf("BMC type %s does not support out-of-band inspection", p.bmcAccess.Type())
+	}
+
+	p.log.Info("inspecting hardware out-of-band")
+	inventory, err := client.Inventory(systemID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the inventory from the BMC")
+	}
+
+	if p.host.Status.HardwareDetails != nil {
+		details = p.host.Status.HardwareDetails.DeepCopy()
+	} else {
+		details = &metal3v1alpha1.HardwareDetails{}
+	}
+	updateFromRedfishSystem(details, inventory)
+	if len(inventory.EthernetInterfaces) != 0 {
+		details.NIC = redfishNICs(details.NIC, inventory.EthernetInterfaces)
+	}
+	if len(inventory.Drives) != 0 {
+		details.Storage = redfishStorage(details.Storage, inventory.Drives)
+	}
+
+	p.publisher("OutOfBandInspectionComplete", "Hardware inventory read from the BMC")
+	return details, nil
+}
+
+// redfishClient returns a client of the Redfish API of the BMC and the
+// path of the system, or a nil client if the BMC has no Redfish API.
+func (p *ironicProvisioner) redfishClient() (client *redfish.Client, systemID string, err error) {
+	driverInfo := p.bmcAccess.DriverInfo(p.bmcCreds)
+	address, _ := driverInfo["redfish_address"].(string)
+	systemID, _ = driverInfo["redfish_system_id"].(string)
+	if address == "" || systemID == "" {
+		return nil, "", nil
+	}
+	proxy, err := bmcproxy.ForHost(p.host.Labels)
+	if err != nil {
+		return nil, "", err
+	}
+	verifyCA, ok := driverInfo["redfish_verify_ca"].(bool)
+	client = redfish.New(address, p.bmcCreds.Username, p.bmcCreds.Password, verifyCA || !ok).WithProxy(proxy)
+	return client, systemID, nil
+}
+
+func setIfFound(field *string, value string) {
+	if value != "" {
+		*field = value
+	}
+}
+
+func updateFromRedfishSystem(details *metal3v1alpha1.HardwareDetails, inventory *redfish.Inventory) {
+	system := inventory.System
+	setIfFound(&details.SystemVendor.Manufacturer, system.Manufacturer)
+	setIfFound(&details.SystemVendor.ProductName, system.Model)
+	setIfFound(&details.SystemVendor.SerialNumber, system.SerialNumber)
+	setIfFound(&details.Firmware.BIOS.Version, system.BiosVersion)
+	if system.MemorySummary.TotalSystemMemoryGiB > 0 {
+		details.RAMMebibytes = int(system.MemorySummary.TotalSystemMemoryGiB * 1024)
+	}
+	setIfFound(&details.CPU.Model, system.ProcessorSummary.Model)
+
+	sockets, threads := 0, 0
+	for _, processor := range inventory.Processors {
+		if processor.ProcessorType != "" && processor.ProcessorType != "CPU" {
+			continue
+		}
+		sockets++
+		threads += processor.TotalThreads
+		setIfFound(&details.CPU.Model, processor.Model)
+		setIfFound(&details.CPU.Arch, redfishArch[processor.InstructionSet])
+		if processor.MaxSpeedMHz > 0 {
+			details.CPU.ClockMegahertz = metal3v1alpha1.ClockSpeed(processor.MaxSpeedMHz)
+		}
+		if processor.TotalCores > 0 && processor.TotalThreads > 0 {
+			details.CPU.ThreadsPerCore = processor.TotalThreads / processor.TotalCores
+		}
+	}
+	if sockets > 0 {
+		details.CPU.Sockets = sockets
+	}
+	if threads > 0 {
+		details.CPU.Count = threads
+	}
+}
+
+// redfishNICs returns the NICs the BMC reports, with the details
+// inspection found for each of them.
+func redfishNICs(inspected []metal3v1alpha1.NIC, interfaces []redfish.EthernetInterface) []metal3v1alpha1.NIC {
+	byMAC := make(map[string]metal3v1alpha1.NIC, len(inspected))
+	for _, nic := range inspected {
+		byMAC[strings.ToLower(nic.MAC)] = nic
+	}
+	var nics []metal3v1alpha1.NIC
+	for _, iface := range interfaces {
+		mac := strings.ToLower(iface.MACAddress)
+		if mac == "" {
+			continue
+		}
+		nic, found := byMAC[mac]
+		if !found {
+			nic = metal3v1alpha1.NIC{Name: iface.ID, MAC: mac}
+		}
+		if iface.SpeedMbps > 0 {
+			nic.SpeedGbps = iface.SpeedMbps / 1000
+		}
+		nics = append(nics, nic)
+	}
+	return nics
+}
+
+// redfishStorage returns the disks the BMC reports, with the details
+// inspection found for each of them.
+func redfishStorage(inspected []metal3v1alpha1.Storage, drives []redfish.Drive) []metal3v1alpha1.Storage {
+	bySerial := make(map[string]metal3v1alpha1.Storage, len(inspected))
+	for _, disk := range inspected {
+		if disk.SerialNumber != "" {
+			bySerial[disk.SerialNumber] = disk
+		}
+	}
+	var disks []metal3v1alpha1.Storage
+	for i, drive := range drives {
+		disk, found := bySerial[drive.SerialNumber]
+		if !found || drive.SerialNumber == "" {
+			disk = metal3v1alpha1.Storage{Name: drive.Name, SerialNumber: drive.SerialNumber}
+			if disk.Name == "" {
+				disk.Name = fmt.Sprintf("drive-%d", i)
+			}
+		}
+		if drive.CapacityBytes > 0 {
+			disk.SizeBytes = metal3v1alpha1.Capacity(drive.CapacityBytes)
+		}
+		setIfFound(&disk.Vendor, drive.Manufacturer)
+		setIfFound(&disk.Model, drive.Model)
+		switch drive.MediaType {
+		case "HDD":
+			disk.Rotational = true
+		case "SSD":
+			disk.Rotational = false
+		}
+		disks = append(disks, disk)
+	}
+	return disks
+}
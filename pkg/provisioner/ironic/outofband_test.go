@@ -0,0 +1,117 @@
+package ironic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+)
+
+func TestInspectOutOfBand(t *testing.T) {
+	resources := map[string]string{
+		"/redfish/v1/Systems/1": `{
+			"Manufacturer": "Dell Inc.", "Model": "PowerEdge R640", "BiosVersion": "2.10.0",
+			"MemorySummary": {"TotalSystemMemoryGiB": 192},
+			"Processors": {"@odata.id": "/redfish/v1/Systems/1/Processors"},
+			"EthernetInterfaces": {"@odata.id": "/redfish/v1/Systems/1/EthernetInterfaces"},
+			"Storage": {"@odata.id": "/redfish/v1/Systems/1/Storage"}
+		}`,
+		"/redfish/v1/Systems/1/Processors": `{"Members": [
+			{"@odata.id": "/redfish/v1/Systems/1/Processors/CPU.1"},
+			{"@odata.id": "/redfish/v1/Systems/1/Processors/CPU.2"},
+			{"@odata.id": "/redfish/v1/Systems/1/Processors/GPU.1"}
+		]}`,
+		"/redfish/v1/Systems/1/Processors/CPU.1": `{"ProcessorType": "CPU", "InstructionSet": "x86-64",
+			"Model": "Intel(R) Xeon(R) Gold 6130", "MaxSpeedMHz": 3700, "TotalCores": 16, "TotalThreads": 32}`,
+		"/redfish/v1/Systems/1/Processors/CPU.2": `{"ProcessorType": "CPU", "InstructionSet": "x86-64",
+			"Model": "Intel(R) Xeon(R) Gold 6130", "MaxSpeedMHz": 3700, "TotalCores": 16, "TotalThreads": 32}`,
+		"/redfish/v1/Systems/1/Processors/GPU.1": `{"ProcessorType": "GPU", "TotalThreads": 1024}`,
+		"/redfish/v1/Systems/1/EthernetInterfaces": `{"Members": [
+			{"@odata.id": "/redfish/v1/Systems/1/EthernetInterfaces/NIC.1"},
+			{"@odata.id": "/redfish/v1/Systems/1/EthernetInterfaces/NIC.2"}
+		]}`,
+		"/redfish/v1/Systems/1/EthernetInterfaces/NIC.1": `{"Id": "NIC.1", "MACAddress": "00:5C:52:31:3A:9C", "SpeedMbps": 25000}`,
+		"/redfish/v1/Systems/1/EthernetInterfaces/NIC.2": `{"Id": "NIC.2", "MACAddress": "00:5C:52:31:3A:9E", "SpeedMbps": 10000}`,
+		"/redfish/v1/Systems/1/Storage":                  `{"Members": [{"@odata.id": "/redfish/v1/Systems/1/Storage/RAID.1"}]}`,
+		"/redfish/v1/Systems/1/Storage/RAID.1":           `{"Drives": [{"@odata.id": "/redfish/v1/Systems/1/Storage/RAID.1/Drives/Disk.0"}]}`,
+		"/redfish/v1/Systems/1/Storage/RAID.1/Drives/Disk.0": `{"Name": "Disk 0", "Model": "AL15SEB120N",
+			"SerialNumber": "S0001", "CapacityBytes": 1200243695616, "MediaType": "HDD"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resource, found := resources[r.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(resource))
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	host := makeHost()
+	host.Spec.BMC.Address = "redfish+http://" + serverURL.Host + "/redfish/v1/Systems/1"
+	host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{
+		Hostname:     "worker-0",
+		RAMMebibytes: 128 * 1024,
+		NIC: []metal3v1alpha1.NIC{
+			{Name: "eno1", MAC: "00:5c:52:31:3a:9c", IP: "192.168.111.20", SpeedGbps: 10},
+			{Name: "eno2", MAC: "00:5c:52:31:3a:9d"},
+		},
+		Storage: []metal3v1alpha1.Storage{
+			{Name: "/dev/sda", SerialNumber: "S0001", SizeBytes: 1000},
+		},
+	}
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "password"},
+		nullEventPublisher, "https://ironic.test", auth, "https://ironic.test", auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	details, err := prov.InspectOutOfBand()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "worker-0", details.Hostname)
+	assert.Equal(t, 192*1024, details.RAMMebibytes)
+	assert.Equal(t, "PowerEdge R640", details.SystemVendor.ProductName)
+	assert.Equal(t, "2.10.0", details.Firmware.BIOS.Version)
+	assert.Equal(t, 64, details.CPU.Count)
+	assert.Equal(t, 2, details.CPU.Sockets)
+	assert.Equal(t, 2, details.CPU.ThreadsPerCore)
+	assert.Equal(t, "x86_64", details.CPU.Arch)
+	assert.Equal(t, metal3v1alpha1.ClockSpeed(3700), details.CPU.ClockMegahertz)
+	assert.Equal(t, []metal3v1alpha1.NIC{
+		{Name: "eno1", MAC: "00:5c:52:31:3a:9c", IP: "192.168.111.20", SpeedGbps: 25},
+		{Name: "NIC.2", MAC: "00:5c:52:31:3a:9e", SpeedGbps: 10},
+	}, details.NIC)
+	assert.Equal(t, []metal3v1alpha1.Storage{
+		{Name: "/dev/sda", SerialNumber: "S0001", SizeBytes: 1200243695616, Model: "AL15SEB120N", Rotational: true},
+	}, details.Storage)
+	// The status of the host is left alone
+	assert.Equal(t, 128*1024, host.Status.HardwareDetails.RAMMebibytes)
+}
+
+func TestInspectOutOfBandUnsupported(t *testing.T) {
+	host := makeHost()
+	host.Spec.BMC.Address = "ipmi://192.168.122.1"
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "password"},
+		nullEventPublisher, "https://ironic.test", auth, "https://ironic.test", auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	_, err = prov.InspectOutOfBand()
+	assert.Error(t, err)
+}
@@ -101,7 +101,7 @@ func TestPowerOn(t *testing.T) {
 			}
 
 			prov.status.ID = nodeUUID
-			result, err := prov.PowerOn()
+			result, err := prov.PowerOn("")
 
 			assert.Equal(t, tc.expectedDirty, result.Dirty)
 			assert.Equal(t, time.Second*time.Duration(tc.expectedRequestAfter), result.RequeueAfter)
@@ -214,7 +214,7 @@ func TestPowerOff(t *testing.T) {
 
 			prov.status.ID = nodeUUID
 			// We pass the RebootMode type here to define the reboot action
-			result, err := prov.PowerOff(tc.rebootMode)
+			result, err := prov.PowerOff(tc.rebootMode, "")
 
 			assert.Equal(t, tc.expectedDirty, result.Dirty)
 			assert.Equal(t, time.Second*time.Duration(tc.expectedRequestAfter), result.RequeueAfter)
@@ -108,7 +108,7 @@ func TestProvision(t *testing.T) {
 			}
 
 			prov.status.ID = nodeUUID
-			result, err := prov.Provision(fixture.NewHostConfigData("testUserData", "test: NetworkData", "test: Meta"))
+			result, err := prov.Provision(fixture.NewHostConfigData("testUserData", "test: NetworkData", "test: Meta"), "")
 
 			assert.Equal(t, tc.expectedDirty, result.Dirty)
 			assert.Equal(t, time.Second*time.Duration(tc.expectedRequestAfter), result.RequeueAfter)
@@ -242,7 +242,7 @@ func TestDeprovision(t *testing.T) {
 			}
 
 			prov.status.ID = nodeUUID
-			result, err := prov.Deprovision(false)
+			result, err := prov.Deprovision(false, "")
 
 			assert.Equal(t, tc.expectedDirty, result.Dirty)
 			assert.Equal(t, tc.expectedErrorMessage, result.ErrorMessage != "")
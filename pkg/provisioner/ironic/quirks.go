@@ -0,0 +1,80 @@
+package ironic
+
+import (
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/hardware"
+)
+
+// quirksKey returns the vendor and model used to look up the quirks
+// for the host. Until the host has been inspected only the type of
+// BMC is known, so that is used as the vendor.
+func quirksKey(host *metal3v1alpha1.BareMetalHost, bmcAccess bmc.AccessDetails) (vendor, model string) {
+	if details := host.Status.HardwareDetails; details != nil && details.SystemVendor.Manufacturer != "" {
+		return details.SystemVendor.Manufacturer, details.SystemVendor.ProductName
+	}
+	return bmcAccess.Type(), ""
+}
+
+func (p *ironicProvisioner) getQuirks() hardware.Quirks {
+	vendor, model := quirksKey(&p.host, p.bmcAccess)
+	return hardware.GetQuirks(vendor, model)
+}
+
+func (p *ironicProvisioner) getPowerRequeueDelay() time.Duration {
+	if delay := p.quirks.PowerRequeueDelay.Duration; delay > 0 {
+		return delay
+	}
+	return powerRequeueDelay
+}
+
+// getPowerConflictRetryDelay returns how long to wait before retrying
+// a power change rejected because the node is locked. The delay
+// doubles with each attempt, and is never longer than the delay of a
+// requeue, which would be used instead.
+func (p *ironicProvisioner) getPowerConflictRetryDelay(attempt int) time.Duration {
+	delay := powerConflictRetryDelay
+	for i := 0; i < attempt && delay < p.getPowerRequeueDelay(); i++ {
+		delay *= 2
+	}
+	if max := p.getPowerRequeueDelay(); delay > max {
+		return max
+	}
+	return delay
+}
+
+// getSoftPowerOffTimeout returns the time the BMC is given to complete
+// a soft power off, which is never less than twice its usual latency.
+func (p *ironicProvisioner) getSoftPowerOffTimeout() time.Duration {
+	timeout := softPowerOffTimeout
+	if t := p.quirks.SoftPowerOffTimeout.Duration; t > 0 {
+		timeout = t
+	}
+	if min := 2 * p.quirks.PowerOffLatency.Duration; timeout < min {
+		return min
+	}
+	return timeout
+}
+
+// getPowerLatency returns how long the BMC usually takes to reach the
+// target power state, or zero if that is not known.
+func (p *ironicProvisioner) getPowerLatency(target nodes.TargetPowerState) time.Duration {
+	if target == nodes.PowerOn {
+		return p.quirks.PowerOnLatency.Duration
+	}
+	return p.quirks.PowerOffLatency.Duration
+}
+
+// getPowerReplayWindow returns how long a power change request is
+// assumed to still be in progress, so that a slow BMC is not asked to
+// change the power state again before it has had a chance to do so.
+func (p *ironicProvisioner) getPowerReplayWindow(target nodes.TargetPowerState) time.Duration {
+	if window := 2 * p.getPowerLatency(target); window > requestReplayWindow {
+		return window
+	}
+	return requestReplayWindow
+}
@@ -0,0 +1,160 @@
+package ironic
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/hardware"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestQuirksKey(t *testing.T) {
+	host := makeHost()
+	bmcAccess, _ := bmc.NewAccessDetails(host.Spec.BMC.Address, false)
+
+	vendor, model := quirksKey(&host, bmcAccess)
+	assert.Equal(t, bmcAccess.Type(), vendor)
+	assert.Equal(t, "", model)
+
+	host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{
+		SystemVendor: metal3v1alpha1.HardwareSystemVendor{
+			Manufacturer: "Acme",
+			ProductName:  "R100",
+		},
+	}
+	vendor, model = quirksKey(&host, bmcAccess)
+	assert.Equal(t, "Acme", vendor)
+	assert.Equal(t, "R100", model)
+}
+
+func TestQuirksDurations(t *testing.T) {
+	p := &ironicProvisioner{}
+	assert.Equal(t, powerRequeueDelay, p.getPowerRequeueDelay())
+	assert.Equal(t, softPowerOffTimeout, p.getSoftPowerOffTimeout())
+
+	p.quirks = hardware.Quirks{
+		PowerRequeueDelay:   hardware.Duration{Duration: time.Second * 30},
+		SoftPowerOffTimeout: hardware.Duration{Duration: time.Minute * 10},
+	}
+	assert.Equal(t, time.Second*30, p.getPowerRequeueDelay())
+	assert.Equal(t, time.Minute*10, p.getSoftPowerOffTimeout())
+}
+
+func TestQuirksPowerConflictRetryDelay(t *testing.T) {
+	p := &ironicProvisioner{}
+	assert.Equal(t, powerConflictRetryDelay, p.getPowerConflictRetryDelay(0))
+	assert.Equal(t, 2*powerConflictRetryDelay, p.getPowerConflictRetryDelay(1))
+	assert.Equal(t, 4*powerConflictRetryDelay, p.getPowerConflictRetryDelay(2))
+	assert.Equal(t, powerRequeueDelay, p.getPowerConflictRetryDelay(10))
+
+	p.quirks = hardware.Quirks{
+		PowerRequeueDelay: hardware.Duration{Duration: time.Second * 3},
+	}
+	assert.Equal(t, time.Second*3, p.getPowerConflictRetryDelay(2))
+}
+
+func TestQuirksPowerConflictRetries(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	requestID := "host-uid/power-on/1//false/" + powerOn
+
+	cases := []struct {
+		name              string
+		conflicts         int
+		expectedDelay     time.Duration
+		expectedConflicts float64
+	}{
+		{
+			name:              "first-conflict",
+			expectedDelay:     powerConflictRetryDelay,
+			expectedConflicts: 1,
+		},
+		{
+			name:              "second-conflict",
+			conflicts:         1,
+			expectedDelay:     2 * powerConflictRetryDelay,
+			expectedConflicts: 2,
+		},
+		{
+			name:          "retries-exhausted",
+			conflicts:     2,
+			expectedDelay: powerRequeueDelay,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var extra map[string]interface{}
+			if tc.conflicts > 0 {
+				extra = map[string]interface{}{
+					lastRequestExtraKey: map[string]interface{}{
+						"id":        requestID,
+						"conflicts": float64(tc.conflicts),
+					},
+				}
+			}
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				PowerState: powerOff,
+				UUID:       nodeUUID,
+				Extra:      extra,
+			}).NodeUpdate(nodes.Node{
+				UUID: nodeUUID,
+			}).WithNodeStatesPowerUpdate(nodeUUID, http.StatusConflict)
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			publisher := func(reason, message string) {}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+			prov.status.ID = nodeUUID
+			prov.quirks = hardware.Quirks{PowerConflictRetries: 2}
+
+			result, err := prov.PowerOn("host-uid/power-on/1//false")
+			assert.NoError(t, err)
+			assert.True(t, result.Dirty)
+			assert.Equal(t, tc.expectedDelay, result.RequeueAfter)
+
+			// The power change is only sent once per reconcile
+			assert.Equal(t, 1, strings.Count(ironic.Requests, "/states/power"))
+
+			updates := ironic.GetLastNodeUpdateRequestFor(nodeUUID)
+			if assert.Len(t, updates, 1) {
+				record := updates[0].Value.(map[string]interface{})
+				assert.Equal(t, requestID, record["id"])
+				assert.Equal(t, tc.expectedConflicts, record["conflicts"])
+			}
+		})
+	}
+}
+
+func TestQuirksPowerLatency(t *testing.T) {
+	p := &ironicProvisioner{}
+	assert.Equal(t, time.Duration(0), p.getPowerLatency(nodes.PowerOn))
+	assert.Equal(t, requestReplayWindow, p.getPowerReplayWindow(nodes.PowerOn))
+
+	p.quirks = hardware.Quirks{
+		PowerOnLatency:  hardware.Duration{Duration: time.Minute * 2},
+		PowerOffLatency: hardware.Duration{Duration: time.Minute * 4},
+	}
+	assert.Equal(t, time.Minute*2, p.getPowerLatency(nodes.PowerOn))
+	assert.Equal(t, time.Minute*4, p.getPowerLatency(nodes.PowerOff))
+	assert.Equal(t, time.Minute*4, p.getPowerLatency(nodes.SoftPowerOff))
+	assert.Equal(t, time.Minute*4, p.getPowerReplayWindow(nodes.PowerOn))
+	assert.Equal(t, time.Minute*8, p.getPowerReplayWindow(nodes.PowerOff))
+
+	// The soft power off timeout leaves the BMC enough time to converge
+	assert.Equal(t, time.Minute*8, p.getSoftPowerOffTimeout())
+}
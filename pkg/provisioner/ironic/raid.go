@@ -30,11 +30,10 @@ func setTargetRAIDCfg(p *ironicProvisioner, ironicNode *nodes.Node) (err error)
 	}
 
 	// Set target for RAID configuration steps
-	return nodes.SetRAIDConfig(
-		p.client,
-		ironicNode.UUID,
+	return p.setRAIDConfig(
+		ironicNode,
 		nodes.RAIDConfigOpts{LogicalDisks: logicalDisks},
-	).ExtractErr()
+	)
 }
 
 // BuildTargetRAIDCfg build RAID logical disks, this method doesn't set the root volume
@@ -1,6 +1,7 @@
 package ironic
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
@@ -116,3 +117,32 @@ func TestProvisionerIsReady(t *testing.T) {
 		})
 	}
 }
+
+// TestProvisionerSetContext ensures that the requests of a provisioner
+// stop once its context is canceled, without affecting the clients it
+// shares with the other provisioners.
+func TestProvisionerSetContext(t *testing.T) {
+	ironic := testserver.NewIronic(t).Ready().WithDrivers()
+	ironic.Start()
+	defer ironic.Stop()
+	inspector := testserver.NewInspector(t).Ready()
+	inspector.Start()
+	defer inspector.Stop()
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(makeHost(), bmc.Credentials{}, nil,
+		ironic.Endpoint(), auth, inspector.Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+	shared := prov.client
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	prov.SetContext(ctx)
+	ready, _ := prov.IsReady()
+	assert.False(t, ready)
+	assert.Empty(t, ironic.Requests)
+	assert.Nil(t, shared.Context)
+}
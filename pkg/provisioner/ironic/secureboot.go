@@ -0,0 +1,37 @@
+package ironic
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/redfish"
+)
+
+// UpdateSecureBootDatabase adds the certificates and signatures of the
+// update to the secure boot database of the host, through the Redfish
+// API of its BMC. The firmware uses them from the next boot.
+func (p *ironicProvisioner) UpdateSecureBootDatabase(update metal3v1alpha1.SecureBootDatabaseUpdate) error {
+	client, systemID, err := p.redfishClient()
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return errors.Errorf("BMC type %s does not support secure boot database updates", p.bmcAccess.Type())
+	}
+
+	p.log.Info("updating secure boot database", "database", update.Database, "revision", update.Revision)
+	err = client.UpdateSecureBootDatabase(systemID, update.Database, update.Certificates, update.Signatures)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to update the %s secure boot database", update.Database)
+		if redfish.IsTransient(err) {
+			return provisioner.TransientError{Err: err}
+		}
+		return err
+	}
+	p.publisher("SecureBootDatabaseUpdated",
+		fmt.Sprintf("Applied revision %s of the %s secure boot database", update.Revision, update.Database))
+	return nil
+}
@@ -0,0 +1,77 @@
+package ironic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+)
+
+func TestUpdateSecureBootDatabase(t *testing.T) {
+	signature := "80b4d96931bf0d02fd91a61e19d14f1da452e66db2408ca8604d411f92659f0a"
+	resources := map[string]string{
+		"/redfish/v1/Systems/1":                                `{"SecureBoot": {"@odata.id": "/redfish/v1/Systems/1/SecureBoot"}}`,
+		"/redfish/v1/Systems/1/SecureBoot":                     `{"SecureBootDatabases": {"@odata.id": "/redfish/v1/Systems/1/SecureBoot/SecureBootDatabases"}}`,
+		"/redfish/v1/Systems/1/SecureBoot/SecureBootDatabases": `{"Members": [{"@odata.id": "/redfish/v1/Systems/1/SecureBoot/SecureBootDatabases/dbx"}]}`,
+		"/redfish/v1/Systems/1/SecureBoot/SecureBootDatabases/dbx": `{"Id": "dbx",
+			"Signatures": {"@odata.id": "/redfish/v1/Systems/1/SecureBoot/SecureBootDatabases/dbx/Signatures"}}`,
+		"/redfish/v1/Systems/1/SecureBoot/SecureBootDatabases/dbx/Signatures": `{"Members": []}`,
+	}
+	var posted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posted = append(posted, r.URL.Path)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		resource, found := resources[r.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(resource))
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	host := makeHost()
+	host.Spec.BMC.Address = "redfish+http://" + serverURL.Host + "/redfish/v1/Systems/1"
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "password"},
+		nullEventPublisher, "https://ironic.test", auth, "https://ironic.test", auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	err = prov.UpdateSecureBootDatabase(metal3v1alpha1.SecureBootDatabaseUpdate{
+		Database:   "dbx",
+		Revision:   "1",
+		Signatures: []string{signature},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/redfish/v1/Systems/1/SecureBoot/SecureBootDatabases/dbx/Signatures"}, posted)
+}
+
+func TestUpdateSecureBootDatabaseUnsupported(t *testing.T) {
+	host := makeHost()
+	host.Spec.BMC.Address = "ipmi://192.168.122.1"
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "password"},
+		nullEventPublisher, "https://ironic.test", auth, "https://ironic.test", auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	err = prov.UpdateSecureBootDatabase(metal3v1alpha1.SecureBootDatabaseUpdate{Database: "db", Revision: "1"})
+	assert.Error(t, err)
+}
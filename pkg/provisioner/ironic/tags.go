@@ -0,0 +1,73 @@
+package ironic
+
+import (
+	"net/http"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/pkg/errors"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+type nodeTags struct {
+	Tags []string `json:"tags"`
+}
+
+// SetTags replaces the tags of the node the host set before with the
+// current ones, keeping the tags set on the node by other tools. A tag
+// the host still wants but that was removed from the node since it was
+// set is added back and reported as a conflict.
+func (p *ironicProvisioner) SetTags(tags, previous []string) (conflicts []string, err error) {
+	ironicNode, err := p.findExistingHost()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find existing host")
+	}
+	if ironicNode == nil {
+		return nil, provisioner.NeedsRegistration
+	}
+
+	url := p.client.ServiceURL("nodes", ironicNode.UUID, "tags")
+	var current nodeTags
+	if _, err := p.client.Get(url, &current, nil); err != nil {
+		return nil, errors.Wrap(err, "failed to get the tags of the node")
+	}
+
+	onNode := make(map[string]bool, len(current.Tags))
+	for _, tag := range current.Tags {
+		onNode[tag] = true
+	}
+	wanted := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		wanted[tag] = true
+	}
+	owned := make(map[string]bool, len(previous))
+	for _, tag := range previous {
+		owned[tag] = true
+		if wanted[tag] && !onNode[tag] {
+			conflicts = append(conflicts, tag)
+		}
+	}
+
+	updated := append([]string{}, tags...)
+	for _, tag := range current.Tags {
+		if !wanted[tag] && !owned[tag] {
+			updated = append(updated, tag)
+		}
+	}
+	changed := len(updated) != len(current.Tags)
+	for _, tag := range updated {
+		changed = changed || !onNode[tag]
+	}
+	if !changed {
+		return conflicts, nil
+	}
+
+	p.log.Info("setting tags of the node", "tags", updated)
+	_, err = p.client.Put(url, nodeTags{Tags: updated}, nil, &gophercloud.RequestOpts{
+		OkCodes: []int{http.StatusOK, http.StatusNoContent},
+	})
+	if err != nil {
+		return conflicts, errors.Wrap(err, "failed to set the tags of the node")
+	}
+	return conflicts, nil
+}
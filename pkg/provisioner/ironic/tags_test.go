@@ -0,0 +1,105 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestSetTags(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	cases := []struct {
+		name              string
+		nodeTags          []string
+		tags              []string
+		previous          []string
+		expectedTags      []string
+		expectedConflicts []string
+		expectNoUpdate    bool
+	}{
+		{
+			name:         "new-tags",
+			tags:         []string{"rack-12", "gpu"},
+			expectedTags: []string{"rack-12", "gpu"},
+		},
+		{
+			name:         "keep-other-tags",
+			nodeTags:     []string{"rack-12", "cmdb:42"},
+			tags:         []string{"rack-14"},
+			previous:     []string{"rack-12"},
+			expectedTags: []string{"rack-14", "cmdb:42"},
+		},
+		{
+			name:              "tag-removed-from-node",
+			nodeTags:          []string{"cmdb:42"},
+			tags:              []string{"rack-12", "gpu"},
+			previous:          []string{"rack-12"},
+			expectedTags:      []string{"rack-12", "gpu", "cmdb:42"},
+			expectedConflicts: []string{"rack-12"},
+		},
+		{
+			name:           "unchanged",
+			nodeTags:       []string{"cmdb:42", "rack-12"},
+			tags:           []string{"rack-12"},
+			previous:       []string{"rack-12"},
+			expectNoUpdate: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID: nodeUUID,
+			}).NodeTags(nodeUUID, tc.nodeTags)
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			conflicts, err := prov.SetTags(tc.tags, tc.previous)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedConflicts, conflicts)
+			tags, updated := ironic.GetLastNodeTagsRequestFor(nodeUUID)
+			assert.Equal(t, !tc.expectNoUpdate, updated)
+			if updated {
+				assert.Equal(t, tc.expectedTags, tags)
+			}
+		})
+	}
+}
+
+func TestSetTagsNotRegistered(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	ironic := testserver.NewIronic(t).Ready().NoNode(nodeUUID).NoNode("myhost")
+	ironic.Start()
+	defer ironic.Stop()
+
+	host := makeHost()
+	host.Status.Provisioning.ID = nodeUUID
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	_, err = prov.SetTags([]string{"gpu"}, nil)
+	assert.Equal(t, provisioner.NeedsRegistration, err)
+}
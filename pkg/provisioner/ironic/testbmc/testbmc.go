@@ -85,3 +85,7 @@ func (a *testAccessDetails) VendorInterface() string {
 func (a *testAccessDetails) SupportsSecureBoot() bool {
 	return false
 }
+
+func (a *testAccessDetails) DiscoversBootMAC() bool {
+	return false
+}
@@ -0,0 +1,102 @@
+package testserver
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed fixtures/*.json
+var fixtureFiles embed.FS
+
+// Exchange is a response recorded from Ironic for a request.
+type Exchange struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Code   int             `json:"code"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// Fixture is a golden recording of the responses Ironic sends for a
+// node going through an operation. The responses to each method and
+// path are replayed in the order they were recorded, and the last one
+// is repeated once the others have been used.
+type Fixture struct {
+	Description string     `json:"description"`
+	NodeUUID    string     `json:"nodeUUID"`
+	Exchanges   []Exchange `json:"exchanges"`
+}
+
+// FixtureNames returns the names of the golden fixtures available to
+// WithFixture.
+func FixtureNames() []string {
+	entries, _ := fixtureFiles.ReadDir("fixtures")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadFixture returns the golden fixture with the given name.
+func LoadFixture(name string) (fixture Fixture, err error) {
+	content, err := fixtureFiles.ReadFile(path.Join("fixtures", name+".json"))
+	if err != nil {
+		return fixture, fmt.Errorf("unknown fixture %s", name)
+	}
+	err = json.Unmarshal(content, &fixture)
+	return fixture, err
+}
+
+// replayer sends the recorded responses to the requests for a path.
+type replayer struct {
+	lock      sync.Mutex
+	exchanges map[string][]Exchange
+}
+
+func (m *IronicMock) replay(r *replayer, w http.ResponseWriter, req *http.Request) {
+	r.lock.Lock()
+	queue := r.exchanges[req.Method]
+	if len(queue) == 0 {
+		r.lock.Unlock()
+		m.MockServer.t.Logf("%s: no recorded response for [%s] %s", m.name, req.Method, req.URL.Path)
+		m.logRequest(req, "")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	exchange := queue[0]
+	if len(queue) > 1 {
+		r.exchanges[req.Method] = queue[1:]
+	}
+	r.lock.Unlock()
+	m.sendData(w, req, exchange.Code, string(exchange.Body))
+}
+
+// WithFixture configures the server to replay the golden fixture with
+// the given name. It fails the test if the fixture does not exist.
+func (m *IronicMock) WithFixture(name string) *IronicMock {
+	fixture, err := LoadFixture(name)
+	if err != nil {
+		m.MockServer.t.Fatalf("could not load fixture: %s", err)
+	}
+
+	replayers := map[string]*replayer{}
+	for _, exchange := range fixture.Exchanges {
+		r, ok := replayers[exchange.Path]
+		if !ok {
+			r = &replayer{exchanges: map[string][]Exchange{}}
+			replayers[exchange.Path] = r
+			m.Handler(exchange.Path, func(w http.ResponseWriter, req *http.Request) {
+				m.replay(r, w, req)
+			})
+		}
+		r.exchanges[exchange.Method] = append(r.exchanges[exchange.Method], exchange)
+	}
+	return m
+}
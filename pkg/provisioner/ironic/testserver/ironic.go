@@ -259,6 +259,25 @@ func (m *IronicMock) Port(port ports.Port) *IronicMock {
 	return m
 }
 
+// NodePorts configures the server with a valid response for
+//    [GET] /v1/ports/detail
+// and accepts changes to the ports
+//    [PATCH] /v1/ports/<port uuid>
+//    [POST] /v1/ports
+func (m *IronicMock) NodePorts(allPorts []ports.Port) *IronicMock {
+	resp := map[string][]ports.Port{
+		"ports": allPorts,
+	}
+
+	m.ResponseJSON(m.buildURL("/v1/ports/detail", http.MethodGet), resp)
+	m.AddDefaultResponseJSON("/v1/ports/{id}", http.MethodPatch, http.StatusOK, ports.Port{
+		UUID: "{id}",
+	})
+	m.AddDefaultResponseJSON("/v1/ports", http.MethodPost, http.StatusCreated, ports.Port{})
+
+	return m
+}
+
 // Nodes configure the server with a valid response for /v1/nodes
 func (m *IronicMock) Nodes(allNodes []nodes.Node) *IronicMock {
 	resp := struct {
@@ -270,3 +289,47 @@ func (m *IronicMock) Nodes(allNodes []nodes.Node) *IronicMock {
 	m.ResponseJSON(m.buildURL("/v1/nodes", http.MethodGet), resp)
 	return m
 }
+
+// NodeTags configures the server with a valid response for
+// [GET] and [PUT] /v1/nodes/<node>/tags
+func (m *IronicMock) NodeTags(nodeUUID string, tags []string) *IronicMock {
+	resp := struct {
+		Tags []string `json:"tags"`
+	}{Tags: tags}
+
+	m.ResponseJSON(m.buildURL("/v1/nodes/"+nodeUUID+"/tags", http.MethodGet), resp)
+	m.ResponseWithCode(m.buildURL("/v1/nodes/"+nodeUUID+"/tags", http.MethodPut), "", http.StatusNoContent)
+	return m
+}
+
+// GetLastNodeTagsRequestFor returns the tags of the last request
+// setting the tags of the specified node
+func (m *IronicMock) GetLastNodeTagsRequestFor(id string) (tags []string, found bool) {
+	bodyRaw, found := m.GetLastRequestFor("/v1/nodes/"+id+"/tags", http.MethodPut)
+	if found {
+		var body struct {
+			Tags []string `json:"tags"`
+		}
+		json.Unmarshal([]byte(bodyRaw), &body)
+		tags = body.Tags
+	}
+	return
+}
+
+// NodeBIOS configures the server with a valid response for
+// [GET] /v1/nodes/<node>/bios
+func (m *IronicMock) NodeBIOS(nodeUUID string, settings map[string]string) *IronicMock {
+	type setting struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	resp := struct {
+		BIOS []setting `json:"bios"`
+	}{}
+	for name, value := range settings {
+		resp.BIOS = append(resp.BIOS, setting{Name: name, Value: value})
+	}
+
+	m.ResponseJSON(m.buildURL("/v1/nodes/"+nodeUUID+"/bios", http.MethodGet), resp)
+	return m
+}
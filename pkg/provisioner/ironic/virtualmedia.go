@@ -0,0 +1,37 @@
+package ironic
+
+import (
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// DetectVirtualMedia reads the virtual media features of the host from
+// the Redfish API of its BMC, so that images it cannot mount can be
+// refused before provisioning starts.
+func (p *ironicProvisioner) DetectVirtualMedia() (*metal3v1alpha1.VirtualMediaSupport, error) {
+	client, systemID, err := p.redfishClient()
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, nil
+	}
+
+	p.log.Info("detecting virtual media support")
+	found, err := client.VirtualMedia(systemID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the virtual media of the BMC")
+	}
+	support := &metal3v1alpha1.VirtualMediaSupport{
+		InsertEject: found.InsertEject,
+		Slots:       found.Slots,
+		Detected:    metav1.Now(),
+	}
+	if len(found.Protocols) > 0 {
+		https := found.SupportsProtocol("HTTPS")
+		support.HTTPS = &https
+	}
+	return support, nil
+}
@@ -0,0 +1,75 @@
+package ironic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+)
+
+func TestDetectVirtualMedia(t *testing.T) {
+	resources := map[string]string{
+		"/redfish/v1/Systems/1":              `{"VirtualMedia": {"@odata.id": "/redfish/v1/Systems/1/VirtualMedia"}}`,
+		"/redfish/v1/Systems/1/VirtualMedia": `{"Members": [{"@odata.id": "/redfish/v1/Systems/1/VirtualMedia/CD1"}]}`,
+		"/redfish/v1/Systems/1/VirtualMedia/CD1": `{"MediaTypes": ["CD"], "Actions": {
+			"#VirtualMedia.InsertMedia": {"target": "/insert", "TransferProtocolType@Redfish.AllowableValues": ["HTTP", "NFS"]},
+			"#VirtualMedia.EjectMedia": {"target": "/eject"}}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resource, found := resources[r.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(resource))
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	host := makeHost()
+	host.Spec.BMC.Address = "redfish-virtualmedia+http://" + serverURL.Host + "/redfish/v1/Systems/1"
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "password"},
+		nullEventPublisher, "https://ironic.test", auth, "https://ironic.test", auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	support, err := prov.DetectVirtualMedia()
+	if assert.NoError(t, err) && assert.NotNil(t, support) {
+		assert.True(t, support.InsertEject)
+		assert.Equal(t, 1, support.Slots)
+		if assert.NotNil(t, support.HTTPS) {
+			assert.False(t, *support.HTTPS)
+		}
+		assert.False(t, support.Detected.IsZero())
+	}
+
+	delete(resources, "/redfish/v1/Systems/1/VirtualMedia")
+	_, err = prov.DetectVirtualMedia()
+	assert.Error(t, err)
+}
+
+func TestDetectVirtualMediaUnsupported(t *testing.T) {
+	host := makeHost()
+	host.Spec.BMC.Address = "ipmi://192.168.122.1"
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "password"},
+		nullEventPublisher, "https://ironic.test", auth, "https://ironic.test", auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	support, err := prov.DetectVirtualMedia()
+	assert.NoError(t, err)
+	assert.Nil(t, support)
+}
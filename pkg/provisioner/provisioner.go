@@ -1,6 +1,7 @@
 package provisioner
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -16,6 +17,14 @@ Package provisioning defines the API for talking to the provisioning backend.
 // with provisioning.
 type EventPublisher func(reason, message string)
 
+// Cancelable is implemented by provisioners whose requests to their
+// backend can be canceled, so that a call that takes too long stops
+// instead of going on in the background.
+type Cancelable interface {
+	// SetContext makes the requests of the next calls use ctx.
+	SetContext(ctx context.Context)
+}
+
 // Factory is the interface for creating new Provisioner objects.
 type Factory func(host metal3v1alpha1.BareMetalHost, bmcCreds bmc.Credentials, publish EventPublisher) (Provisioner, error)
 
@@ -48,8 +57,10 @@ type Provisioner interface {
 	// InspectHardware updates the HardwareDetails field of the host with
 	// details of devices discovered on the hardware. It may be called
 	// multiple times, and should return true for its dirty flag until the
-	// inspection is completed.
-	InspectHardware(force bool) (result Result, details *metal3v1alpha1.HardwareDetails, err error)
+	// inspection is completed. When refresh is true, a new inspection
+	// is started even if the host has already been inspected, and
+	// started reports whether it has been.
+	InspectHardware(force, refresh bool) (result Result, started bool, details *metal3v1alpha1.HardwareDetails, err error)
 
 	// UpdateHardwareState fetches the latest hardware state of the
 	// server and updates the HardwareDetails field of the host with
@@ -57,6 +68,64 @@ type Provisioner interface {
 	// possible, such as reading from a cache.
 	UpdateHardwareState() (hwState HardwareState, err error)
 
+	// GetBIOSSettings returns all of the current BIOS settings of the
+	// host, by name. It returns NeedsRegistration if the host is not
+	// known to the provisioner yet.
+	GetBIOSSettings() (settings map[string]string, err error)
+
+	// InspectOutOfBand reads the inventory of the host from its BMC,
+	// without booting the host or touching its operating system, and
+	// returns the hardware details of the host updated with it, or
+	// nil if the provisioner has no inventory to report.
+	InspectOutOfBand() (details *metal3v1alpha1.HardwareDetails, err error)
+
+	// GetDriverStatus returns the driver and the interfaces the
+	// provisioner uses to manage the host, or nil if it does not use
+	// one. It returns NeedsRegistration if the host is not known to
+	// the provisioner yet.
+	GetDriverStatus() (driver *metal3v1alpha1.DriverStatus, err error)
+
+	// GetAgentVersion returns the version of the deployment agent
+	// image applied to the host, as named in the agent images
+	// configuration, or an empty string when the host uses the
+	// default agent. It returns NeedsRegistration if the host is not
+	// known to the provisioner yet.
+	GetAgentVersion() (version string, err error)
+
+	// InstallBootCertificate makes the firmware of the host trust the
+	// CA certificate of the HTTPS boot server. installed is the
+	// fingerprint of the certificate already installed, and the
+	// fingerprint of the certificate the host now trusts is returned,
+	// or an empty string if the host does not need one.
+	InstallBootCertificate(installed string) (result Result, fingerprint string, err error)
+
+	// UpdateSecureBootDatabase adds the certificates and signatures
+	// of the update to the UEFI secure boot database of the host,
+	// keeping the entries already there.
+	UpdateSecureBootDatabase(update metal3v1alpha1.SecureBootDatabaseUpdate) (err error)
+
+	// DetectVirtualMedia reads the virtual media features of the BMC of
+	// the host, or returns nil if the BMC does not report them.
+	DetectVirtualMedia() (support *metal3v1alpha1.VirtualMediaSupport, err error)
+
+	// CleanBootArtifacts checks that deprovisioning left no boot
+	// configuration behind, such as inserted virtual media or a boot
+	// source override, so that the host cannot boot an old image
+	// again. It removes what it finds and returns a description of it.
+	CleanBootArtifacts() (residue []string, err error)
+
+	// ProtectBootOrder makes a provisioned host boot from its disks,
+	// removing boot source overrides and moving network boot options
+	// after the other ones. It returns a description of what it
+	// changed.
+	ProtectBootOrder() (corrected []string, err error)
+
+	// SetTags sets the tags on the node of the host, removing those of
+	// previous, set earlier, that are no longer wanted and keeping
+	// the tags set by other tools. It returns the wanted tags of
+	// previous that had been removed from the node by someone else.
+	SetTags(tags, previous []string) (conflicts []string, err error)
+
 	// Adopt brings an externally-provisioned host under management by
 	// the provisioner.
 	Adopt(force bool) (result Result, err error)
@@ -64,15 +133,29 @@ type Provisioner interface {
 	// Prepare remove existing configuration and set new configuration
 	Prepare(unprepared bool) (result Result, started bool, err error)
 
+	// Erase securely erases the disks of the host before it is
+	// decommissioned, as selected by opts. A new erasure is begun when
+	// start is true, and started reports whether it has been. It may
+	// be called multiple times, and should return true for its dirty
+	// flag until the erasure is completed.
+	Erase(start bool, opts EraseOptions) (result Result, started bool, err error)
+
 	// Provision writes the image from the host spec to the host. It
 	// may be called multiple times, and should return true for its
 	// dirty flag until the deprovisioning operation is completed.
-	Provision(configData HostConfigData) (result Result, err error)
+	//
+	// The requestID is an idempotency token for the operation. It is
+	// the same for every call made for one logical operation, even
+	// across restarts of the controller, so the provisioner can use
+	// it to avoid sending the same request to the backend twice. An
+	// empty value disables the check.
+	Provision(configData HostConfigData, requestID string) (result Result, err error)
 
 	// Deprovision removes the host from the image. It may be called
 	// multiple times, and should return true for its dirty flag until
-	// the deprovisioning operation is completed.
-	Deprovision(force bool) (result Result, err error)
+	// the deprovisioning operation is completed. The requestID is an
+	// idempotency token, as for Provision.
+	Deprovision(force bool, requestID string) (result Result, err error)
 
 	// Delete removes the host from the provisioning system. It may be
 	// called multiple times, and should return true for its dirty
@@ -80,13 +163,15 @@ type Provisioner interface {
 	Delete() (result Result, err error)
 
 	// PowerOn ensures the server is powered on independently of any image
-	// provisioning operation.
-	PowerOn() (result Result, err error)
+	// provisioning operation. The requestID is an idempotency token,
+	// as for Provision.
+	PowerOn(requestID string) (result Result, err error)
 
 	// PowerOff ensures the server is powered off independently of any image
 	// provisioning operation. The boolean argument may be used to specify
-	// if a hard reboot (force power off) is required - true if so.
-	PowerOff(rebootMode metal3v1alpha1.RebootMode) (result Result, err error)
+	// if a hard reboot (force power off) is required - true if so. The
+	// requestID is an idempotency token, as for Provision.
+	PowerOff(rebootMode metal3v1alpha1.RebootMode, requestID string) (result Result, err error)
 
 	// IsReady checks if the provisioning backend is available to accept
 	// all the incoming requests.
@@ -106,6 +191,30 @@ type Result struct {
 	RequeueAfter time.Duration
 	// Any error message produced by the provisioner.
 	ErrorMessage string
+	// CleanStep is the clean step the host is running, or nil when
+	// the host is not being cleaned.
+	CleanStep *CleanStep
+}
+
+// CleanStep describes the progress of cleaning a host.
+type CleanStep struct {
+	// Name is the step running, or empty between steps.
+	Name string
+	// Index is the position of the step, starting at 1, or 0 when
+	// unknown.
+	Index int
+	// Total is the number of steps, or 0 when unknown.
+	Total int
+}
+
+// EraseOptions selects the disks erased by an Erase call, and how.
+type EraseOptions struct {
+	// SkipSerials lists the serial numbers of the disks to leave
+	// alone.
+	SkipSerials []string
+	// MetadataOnly wipes the partition tables and filesystem
+	// signatures of the disks instead of erasing them.
+	MetadataOnly bool
 }
 
 // HardwareState holds the response from an UpdateHardwareState call
@@ -116,3 +225,15 @@ type HardwareState struct {
 }
 
 var NeedsRegistration = errors.New("Host not registered")
+
+// TransientError is returned when an operation failed for a reason
+// that may go away on its own, such as a BMC that could not be
+// reached, so that it should be tried again.
+type TransientError struct {
+	Err error
+}
+
+func (e TransientError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the error that caused the operation to fail.
+func (e TransientError) Unwrap() error { return e.Err }
@@ -0,0 +1,149 @@
+package redfish
+
+import (
+	"net/http"
+	"strings"
+)
+
+// BootOverride is the one-time or continuous boot source override of
+// a system.
+type BootOverride struct {
+	// Enabled is "Once", "Continuous" or "Disabled".
+	Enabled string `json:"BootSourceOverrideEnabled"`
+	// Target is the boot source, such as "Pxe" or "Cd".
+	Target string `json:"BootSourceOverrideTarget"`
+}
+
+// Active reports whether the override makes the system boot from
+// another source than its boot order.
+func (o BootOverride) Active() bool {
+	return o.Enabled != "" && o.Enabled != "Disabled" && o.Target != "" && o.Target != "None"
+}
+
+// BootOverride reads the boot source override of the system. systemID
+// is the path of the system, e.g. "/redfish/v1/Systems/1".
+func (c *Client) BootOverride(systemID string) (BootOverride, error) {
+	var system struct {
+		Boot BootOverride `json:"Boot"`
+	}
+	err := c.do(http.MethodGet, systemID, nil, &system)
+	return system.Boot, err
+}
+
+// ClearBootOverride disables the boot source override of the system,
+// so that it boots following its boot order.
+func (c *Client) ClearBootOverride(systemID string) error {
+	return c.do(http.MethodPatch, systemID, map[string]interface{}{
+		"Boot": map[string]string{"BootSourceOverrideEnabled": "Disabled"},
+	}, nil)
+}
+
+// BootOption is an entry of the boot order of a system.
+type BootOption struct {
+	// Reference is the name of the option in the boot order, such as
+	// "Boot0001", or the boot source on BMCs that do not list their
+	// boot options.
+	Reference string
+	// Name is the name the firmware shows for the option.
+	Name string
+	// DevicePath is the UEFI device path of the option.
+	DevicePath string
+}
+
+// networkDevicePaths are the UEFI device path nodes of options that
+// boot from the network.
+var networkDevicePaths = []string{"mac(", "ipv4(", "ipv6(", "uri("}
+
+// networkBootSources are the boot sources of the boot order of BMCs
+// that do not list their boot options.
+var networkBootSources = map[string]bool{
+	"pxe":      true,
+	"uefihttp": true,
+	"network":  true,
+}
+
+// Network reports whether the option boots from the network.
+func (o BootOption) Network() bool {
+	path := strings.ToLower(o.DevicePath)
+	for _, node := range networkDevicePaths {
+		if strings.Contains(path, node) {
+			return true
+		}
+	}
+	if networkBootSources[strings.ToLower(o.Reference)] {
+		return true
+	}
+	name := strings.ToLower(o.Name)
+	return strings.Contains(name, "pxe") || strings.Contains(name, "http boot") || strings.Contains(name, "network")
+}
+
+// BootOrder reads the boot order of the system, with the details of
+// the options the BMC lists. systemID is the path of the system, e.g.
+// "/redfish/v1/Systems/1".
+func (c *Client) BootOrder(systemID string) ([]BootOption, error) {
+	var system struct {
+		Boot struct {
+			BootOrder   []string `json:"BootOrder"`
+			BootOptions *odataID `json:"BootOptions"`
+		} `json:"Boot"`
+	}
+	if err := c.do(http.MethodGet, systemID, nil, &system); err != nil {
+		return nil, err
+	}
+
+	details := map[string]BootOption{}
+	err := c.members(system.Boot.BootOptions, func(path string) error {
+		var option struct {
+			Reference  string `json:"BootOptionReference"`
+			Name       string `json:"DisplayName"`
+			DevicePath string `json:"UefiDevicePath"`
+		}
+		if err := c.do(http.MethodGet, path, nil, &option); err != nil {
+			return err
+		}
+		details[option.Reference] = BootOption{
+			Reference:  option.Reference,
+			Name:       option.Name,
+			DevicePath: option.DevicePath,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	order := make([]BootOption, 0, len(system.Boot.BootOrder))
+	for _, reference := range system.Boot.BootOrder {
+		option, found := details[reference]
+		if !found {
+			option = BootOption{Reference: reference}
+		}
+		order = append(order, option)
+	}
+	return order, nil
+}
+
+// NetworkLast returns the references of the boot order with the
+// options that boot from the network moved after all of the others,
+// and whether that changes the order.
+func NetworkLast(order []BootOption) (references []string, changed bool) {
+	var network []string
+	for _, option := range order {
+		if option.Network() {
+			network = append(network, option.Reference)
+			continue
+		}
+		if len(network) != 0 {
+			changed = true
+		}
+		references = append(references, option.Reference)
+	}
+	return append(references, network...), changed
+}
+
+// SetBootOrder replaces the boot order of the system.
+func (c *Client) SetBootOrder(systemID string, references []string) error {
+	return c.do(http.MethodPatch, systemID, map[string]interface{}{
+		"Boot": map[string][]string{"BootOrder": references},
+	}, nil)
+}
@@ -0,0 +1,119 @@
+package redfish
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBootOverride(t *testing.T) {
+	var patched map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			content, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(content, &patched)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write([]byte(`{"Boot": {"BootSourceOverrideEnabled": "Continuous", "BootSourceOverrideTarget": "Cd"}}`))
+	}))
+	defer server.Close()
+	c := New(server.URL, "admin", "password", true)
+
+	override, err := c.BootOverride("/redfish/v1/Systems/1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, BootOverride{Enabled: "Continuous", Target: "Cd"}, override)
+		assert.True(t, override.Active())
+	}
+
+	assert.NoError(t, c.ClearBootOverride("/redfish/v1/Systems/1"))
+	assert.Equal(t, map[string]interface{}{
+		"Boot": map[string]interface{}{"BootSourceOverrideEnabled": "Disabled"},
+	}, patched)
+
+	assert.False(t, BootOverride{Enabled: "Disabled", Target: "Pxe"}.Active())
+	assert.False(t, BootOverride{Enabled: "Once", Target: "None"}.Active())
+}
+
+func TestBootOrder(t *testing.T) {
+	var patched map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			content, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(content, &patched)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		switch r.URL.Path {
+		case "/redfish/v1/Systems/1":
+			w.Write([]byte(`{"Boot": {"BootOrder": ["Boot0001", "Boot0002", "Boot0003"],
+				"BootOptions": {"@odata.id": "/redfish/v1/Systems/1/BootOptions"}}}`))
+		case "/redfish/v1/Systems/1/BootOptions":
+			w.Write([]byte(`{"Members": [{"@odata.id": "/redfish/v1/Systems/1/BootOptions/1"},
+				{"@odata.id": "/redfish/v1/Systems/1/BootOptions/2"}]}`))
+		case "/redfish/v1/Systems/1/BootOptions/1":
+			w.Write([]byte(`{"BootOptionReference": "Boot0001", "DisplayName": "NIC 1",
+				"UefiDevicePath": "PciRoot(0x0)/Pci(0x1C,0x0)/MAC(0C42A1000000,0x1)/IPv4(0.0.0.0)"}`))
+		case "/redfish/v1/Systems/1/BootOptions/2":
+			w.Write([]byte(`{"BootOptionReference": "Boot0002", "DisplayName": "Disk 0",
+				"UefiDevicePath": "PciRoot(0x0)/Pci(0x17,0x0)/Sata(0x0,0xFFFF,0x0)"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c := New(server.URL, "admin", "password", true)
+
+	order, err := c.BootOrder("/redfish/v1/Systems/1")
+	if assert.NoError(t, err) && assert.Len(t, order, 3) {
+		assert.True(t, order[0].Network())
+		assert.False(t, order[1].Network())
+		assert.Equal(t, BootOption{Reference: "Boot0003"}, order[2])
+
+		references, changed := NetworkLast(order)
+		assert.True(t, changed)
+		assert.Equal(t, []string{"Boot0002", "Boot0003", "Boot0001"}, references)
+	}
+
+	assert.NoError(t, c.SetBootOrder("/redfish/v1/Systems/1", []string{"Boot0002", "Boot0001"}))
+	assert.Equal(t, map[string]interface{}{
+		"Boot": map[string]interface{}{"BootOrder": []interface{}{"Boot0002", "Boot0001"}},
+	}, patched)
+}
+
+func TestNetworkLast(t *testing.T) {
+	testCases := []struct {
+		Scenario string
+		Order    []BootOption
+		Expected []string
+		Changed  bool
+	}{
+		{
+			Scenario: "disk first",
+			Order:    []BootOption{{Reference: "Hdd"}, {Reference: "Pxe"}},
+			Expected: []string{"Hdd", "Pxe"},
+		},
+		{
+			Scenario: "network first",
+			Order:    []BootOption{{Reference: "Pxe"}, {Reference: "Hdd"}, {Reference: "Cd"}},
+			Expected: []string{"Hdd", "Cd", "Pxe"},
+			Changed:  true,
+		},
+		{
+			Scenario: "network only",
+			Order:    []BootOption{{Reference: "Boot0001", Name: "PXE IPv4"}},
+			Expected: []string{"Boot0001"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			references, changed := NetworkLast(tc.Order)
+			assert.Equal(t, tc.Expected, references)
+			assert.Equal(t, tc.Changed, changed)
+		})
+	}
+}
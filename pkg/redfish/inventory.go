@@ -0,0 +1,134 @@
+package redfish
+
+import (
+	"net/http"
+)
+
+// System is the part of a Redfish ComputerSystem describing its
+// hardware.
+type System struct {
+	Manufacturer     string `json:"Manufacturer"`
+	Model            string `json:"Model"`
+	SerialNumber     string `json:"SerialNumber"`
+	BiosVersion      string `json:"BiosVersion"`
+	ProcessorSummary struct {
+		Count int    `json:"Count"`
+		Model string `json:"Model"`
+	} `json:"ProcessorSummary"`
+	MemorySummary struct {
+		TotalSystemMemoryGiB float64 `json:"TotalSystemMemoryGiB"`
+	} `json:"MemorySummary"`
+
+	Processors         *odataID `json:"Processors"`
+	EthernetInterfaces *odataID `json:"EthernetInterfaces"`
+	Storage            *odataID `json:"Storage"`
+}
+
+// Processor is a Redfish Processor.
+type Processor struct {
+	ProcessorType  string `json:"ProcessorType"`
+	InstructionSet string `json:"InstructionSet"`
+	Model          string `json:"Model"`
+	MaxSpeedMHz    int    `json:"MaxSpeedMHz"`
+	TotalCores     int    `json:"TotalCores"`
+	TotalThreads   int    `json:"TotalThreads"`
+}
+
+// EthernetInterface is a Redfish EthernetInterface.
+type EthernetInterface struct {
+	ID         string `json:"Id"`
+	MACAddress string `json:"MACAddress"`
+	SpeedMbps  int    `json:"SpeedMbps"`
+}
+
+// Drive is a Redfish Drive.
+type Drive struct {
+	Name          string `json:"Name"`
+	Manufacturer  string `json:"Manufacturer"`
+	Model         string `json:"Model"`
+	SerialNumber  string `json:"SerialNumber"`
+	CapacityBytes int64  `json:"CapacityBytes"`
+	MediaType     string `json:"MediaType"`
+}
+
+// Inventory is the hardware of a system as its BMC reports it.
+type Inventory struct {
+	System             System
+	Processors         []Processor
+	EthernetInterfaces []EthernetInterface
+	Drives             []Drive
+}
+
+// members gets each member of the collection, if the system has it.
+func (c *Client) members(collection *odataID, get func(path string) error) error {
+	if collection == nil || collection.ID == "" {
+		return nil
+	}
+	var members struct {
+		Members []odataID `json:"Members"`
+	}
+	if err := c.do(http.MethodGet, collection.ID, nil, &members); err != nil {
+		return err
+	}
+	for _, member := range members.Members {
+		if err := get(member.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Inventory reads the hardware inventory of the system, without
+// affecting it. systemID is the path of the system, e.g.
+// "/redfish/v1/Systems/1".
+func (c *Client) Inventory(systemID string) (*Inventory, error) {
+	inventory := &Inventory{}
+	if err := c.do(http.MethodGet, systemID, nil, &inventory.System); err != nil {
+		return nil, err
+	}
+
+	err := c.members(inventory.System.Processors, func(path string) error {
+		var processor Processor
+		if err := c.do(http.MethodGet, path, nil, &processor); err != nil {
+			return err
+		}
+		inventory.Processors = append(inventory.Processors, processor)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.members(inventory.System.EthernetInterfaces, func(path string) error {
+		var nic EthernetInterface
+		if err := c.do(http.MethodGet, path, nil, &nic); err != nil {
+			return err
+		}
+		inventory.EthernetInterfaces = append(inventory.EthernetInterfaces, nic)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.members(inventory.System.Storage, func(path string) error {
+		var storage struct {
+			Drives []odataID `json:"Drives"`
+		}
+		if err := c.do(http.MethodGet, path, nil, &storage); err != nil {
+			return err
+		}
+		for _, drive := range storage.Drives {
+			var details Drive
+			if err := c.do(http.MethodGet, drive.ID, nil, &details); err != nil {
+				return err
+			}
+			inventory.Drives = append(inventory.Drives, details)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
@@ -0,0 +1,68 @@
+package redfish
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// inventoryResources is the Redfish API of a system with one CPU, one
+// NIC and one disk.
+var inventoryResources = map[string]string{
+	"/redfish/v1/Systems/1": `{
+		"Manufacturer": "Dell Inc.", "Model": "PowerEdge R640", "SerialNumber": "ABC123",
+		"BiosVersion": "2.10.0",
+		"ProcessorSummary": {"Count": 1, "Model": "Intel(R) Xeon(R) Gold 6130"},
+		"MemorySummary": {"TotalSystemMemoryGiB": 192},
+		"Processors": {"@odata.id": "/redfish/v1/Systems/1/Processors"},
+		"EthernetInterfaces": {"@odata.id": "/redfish/v1/Systems/1/EthernetInterfaces"},
+		"Storage": {"@odata.id": "/redfish/v1/Systems/1/Storage"}
+	}`,
+	"/redfish/v1/Systems/1/Processors": `{"Members": [{"@odata.id": "/redfish/v1/Systems/1/Processors/CPU.1"}]}`,
+	"/redfish/v1/Systems/1/Processors/CPU.1": `{
+		"ProcessorType": "CPU", "InstructionSet": "x86-64", "Model": "Intel(R) Xeon(R) Gold 6130",
+		"MaxSpeedMHz": 3700, "TotalCores": 16, "TotalThreads": 32
+	}`,
+	"/redfish/v1/Systems/1/EthernetInterfaces":       `{"Members": [{"@odata.id": "/redfish/v1/Systems/1/EthernetInterfaces/NIC.1"}]}`,
+	"/redfish/v1/Systems/1/EthernetInterfaces/NIC.1": `{"Id": "NIC.1", "MACAddress": "00:5C:52:31:3A:9C", "SpeedMbps": 10000}`,
+	"/redfish/v1/Systems/1/Storage":                  `{"Members": [{"@odata.id": "/redfish/v1/Systems/1/Storage/RAID.1"}]}`,
+	"/redfish/v1/Systems/1/Storage/RAID.1":           `{"Drives": [{"@odata.id": "/redfish/v1/Systems/1/Storage/RAID.1/Drives/Disk.0"}]}`,
+	"/redfish/v1/Systems/1/Storage/RAID.1/Drives/Disk.0": `{
+		"Name": "Disk 0", "Manufacturer": "TOSHIBA", "Model": "AL15SEB120N", "SerialNumber": "S0001",
+		"CapacityBytes": 1200243695616, "MediaType": "HDD"
+	}`,
+}
+
+func TestInventory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		resource, found := inventoryResources[r.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(resource))
+	}))
+	defer server.Close()
+
+	inventory, err := New(server.URL, "admin", "password", true).Inventory("/redfish/v1/Systems/1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "PowerEdge R640", inventory.System.Model)
+	assert.Equal(t, 192.0, inventory.System.MemorySummary.TotalSystemMemoryGiB)
+	if assert.Len(t, inventory.Processors, 1) {
+		assert.Equal(t, 32, inventory.Processors[0].TotalThreads)
+	}
+	if assert.Len(t, inventory.EthernetInterfaces, 1) {
+		assert.Equal(t, "00:5C:52:31:3A:9C", inventory.EthernetInterfaces[0].MACAddress)
+	}
+	if assert.Len(t, inventory.Drives, 1) {
+		assert.Equal(t, int64(1200243695616), inventory.Drives[0].CapacityBytes)
+	}
+
+	_, err = New(server.URL, "admin", "password", true).Inventory("/redfish/v1/Systems/2")
+	assert.Error(t, err)
+}
@@ -0,0 +1,194 @@
+package redfish
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrBootCertificatesUnsupported is returned when the system does not
+// let clients manage the certificates used for HTTPS boot.
+var ErrBootCertificatesUnsupported = errors.New("the BMC does not support boot certificates")
+
+// StatusError is returned when the BMC answers a request with an
+// error status.
+type StatusError struct {
+	Method     string
+	Path       string
+	Status     string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s failed: %s", e.Method, e.Path, e.Status)
+}
+
+// requestError is returned when a request could not reach the BMC or
+// got no answer.
+type requestError struct {
+	err error
+}
+
+func (e *requestError) Error() string { return e.err.Error() }
+func (e *requestError) Unwrap() error { return e.err }
+
+// IsTransient reports whether the error may go away when the request
+// is sent again, because the BMC could not be reached, was busy or
+// failed internally.
+func IsTransient(err error) bool {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		return true
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == http.StatusConflict,
+			statusErr.StatusCode == http.StatusTooManyRequests,
+			statusErr.StatusCode >= 500:
+			return true
+		}
+	}
+	return false
+}
+
+type odataID struct {
+	ID string `json:"@odata.id"`
+}
+
+// Client talks to the Redfish API of a BMC.
+type Client struct {
+	address  string
+	username string
+	password string
+	http     *http.Client
+}
+
+// New returns a client for the BMC at address, e.g.
+// "https://192.168.0.1", authenticating with the credentials.
+func New(address, username, password string, verifyCA bool) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: !verifyCA} // #nosec
+	return &Client{
+		address:  strings.TrimRight(address, "/"),
+		username: username,
+		password: password,
+		http:     &http.Client{Timeout: 30 * time.Second, Transport: transport},
+	}
+}
+
+func (c *Client) do(method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode request")
+		}
+		reader = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequest(method, c.address+path, reader)
+	if err != nil {
+		return errors.Wrap(err, "failed to build request")
+	}
+	req.SetBasicAuth(c.username, c.password)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return &requestError{errors.Wrapf(err, "%s %s failed", method, path)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &StatusError{Method: method, Path: path, Status: resp.Status, StatusCode: resp.StatusCode}
+	}
+	if result == nil {
+		return nil
+	}
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(result),
+		"failed to decode response to %s %s", method, path)
+}
+
+// WithProxy connects to the BMC through the proxy, which may be a
+// SOCKS5 or HTTP proxy. A nil proxy connects directly.
+func (c *Client) WithProxy(proxy *url.URL) *Client {
+	c.http.Transport.(*http.Transport).Proxy = http.ProxyURL(proxy)
+	return c
+}
+
+// CertificateFingerprint returns the SHA-256 fingerprint of the first
+// certificate of the PEM data.
+func CertificateFingerprint(data []byte) (string, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return "", errors.New("no PEM encoded certificate found")
+		}
+		if block.Type == "CERTIFICATE" {
+			sum := sha256.Sum256(block.Bytes)
+			return hex.EncodeToString(sum[:]), nil
+		}
+	}
+}
+
+// InstallBootCertificate adds the PEM encoded CA certificate to the
+// certificates the firmware of the system trusts for HTTPS boot,
+// unless it is already there. systemID is the path of the system,
+// e.g. "/redfish/v1/Systems/1".
+func (c *Client) InstallBootCertificate(systemID string, certificate []byte) error {
+	fingerprint, err := CertificateFingerprint(certificate)
+	if err != nil {
+		return err
+	}
+
+	var system struct {
+		Boot struct {
+			Certificates *odataID `json:"Certificates"`
+		} `json:"Boot"`
+	}
+	if err := c.do(http.MethodGet, systemID, nil, &system); err != nil {
+		return err
+	}
+	if system.Boot.Certificates == nil || system.Boot.Certificates.ID == "" {
+		return ErrBootCertificatesUnsupported
+	}
+	collection := system.Boot.Certificates.ID
+
+	var members struct {
+		Members []odataID `json:"Members"`
+	}
+	if err := c.do(http.MethodGet, collection, nil, &members); err != nil {
+		return err
+	}
+	for _, member := range members.Members {
+		var existing struct {
+			CertificateString string `json:"CertificateString"`
+		}
+		if err := c.do(http.MethodGet, member.ID, nil, &existing); err != nil {
+			return err
+		}
+		if current, err := CertificateFingerprint([]byte(existing.CertificateString)); err == nil && current == fingerprint {
+			return nil
+		}
+	}
+
+	return c.do(http.MethodPost, collection, map[string]string{
+		"CertificateString": string(certificate),
+		"CertificateType":   "PEM",
+	}, nil)
+}
@@ -0,0 +1,160 @@
+package redfish
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeCertificate(t *testing.T, name string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestCertificateFingerprint(t *testing.T) {
+	certificate := makeCertificate(t, "boot-ca")
+	withKey := append(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("key")}), certificate...)
+
+	fingerprint, err := CertificateFingerprint(certificate)
+	assert.NoError(t, err)
+	assert.Len(t, fingerprint, 64)
+
+	other, err := CertificateFingerprint(withKey)
+	assert.NoError(t, err)
+	assert.Equal(t, fingerprint, other)
+
+	_, err = CertificateFingerprint([]byte("not a certificate"))
+	assert.Error(t, err)
+}
+
+func TestInstallBootCertificate(t *testing.T) {
+	installed := makeCertificate(t, "other-ca")
+	certificate := makeCertificate(t, "boot-ca")
+
+	cases := []struct {
+		name          string
+		existing      []byte
+		noBoot        bool
+		expectPosted  bool
+		expectedError error
+	}{
+		{
+			name:         "new",
+			existing:     installed,
+			expectPosted: true,
+		},
+		{
+			name:     "already-installed",
+			existing: certificate,
+		},
+		{
+			name:          "unsupported",
+			noBoot:        true,
+			expectedError: ErrBootCertificatesUnsupported,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var posted map[string]string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				username, password, _ := r.BasicAuth()
+				assert.Equal(t, "admin", username)
+				assert.Equal(t, "password", password)
+				switch {
+				case r.URL.Path == "/redfish/v1/Systems/1" && tc.noBoot:
+					w.Write([]byte(`{"Boot": {}}`))
+				case r.URL.Path == "/redfish/v1/Systems/1":
+					w.Write([]byte(`{"Boot": {"Certificates": {"@odata.id": "/redfish/v1/Systems/1/Boot/Certificates"}}}`))
+				case r.URL.Path == "/redfish/v1/Systems/1/Boot/Certificates" && r.Method == http.MethodPost:
+					content, _ := ioutil.ReadAll(r.Body)
+					json.Unmarshal(content, &posted)
+					w.WriteHeader(http.StatusCreated)
+				case r.URL.Path == "/redfish/v1/Systems/1/Boot/Certificates":
+					w.Write([]byte(`{"Members": [{"@odata.id": "/redfish/v1/Systems/1/Boot/Certificates/1"}]}`))
+				case r.URL.Path == "/redfish/v1/Systems/1/Boot/Certificates/1":
+					content, _ := json.Marshal(map[string]string{"CertificateString": string(tc.existing)})
+					w.Write(content)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+			c := New(server.URL, "admin", "password", true)
+
+			err := c.InstallBootCertificate("/redfish/v1/Systems/1", certificate)
+			if tc.expectedError != nil {
+				assert.Equal(t, tc.expectedError, err)
+				return
+			}
+			assert.NoError(t, err)
+			if tc.expectPosted {
+				assert.Equal(t, map[string]string{
+					"CertificateString": string(certificate),
+					"CertificateType":   "PEM",
+				}, posted)
+			} else {
+				assert.Nil(t, posted)
+			}
+		})
+	}
+}
+
+func TestInstallBootCertificateWithProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.Write([]byte(`{"Boot": {}}`))
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	c := New("http://bmc.example.com", "admin", "password", true).WithProxy(proxyURL)
+	err := c.InstallBootCertificate("/redfish/v1/Systems/1", makeCertificate(t, "boot-ca"))
+	assert.Equal(t, ErrBootCertificatesUnsupported, err)
+	assert.Equal(t, []string{"http://bmc.example.com/redfish/v1/Systems/1"}, proxied)
+}
+
+func TestIsTransient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/busy":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/denied":
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	client := New(server.URL, "admin", "password", false)
+
+	assert.True(t, IsTransient(client.do(http.MethodGet, "/busy", nil, nil)))
+	assert.False(t, IsTransient(client.do(http.MethodGet, "/denied", nil, nil)))
+	server.Close()
+	assert.True(t, IsTransient(client.do(http.MethodGet, "/busy", nil, nil)))
+}
@@ -0,0 +1,153 @@
+package redfish
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrSecureBootDatabasesUnsupported is returned when the system does
+// not let clients manage its UEFI secure boot databases.
+var ErrSecureBootDatabasesUnsupported = errors.New("the BMC does not support secure boot database updates")
+
+// sha256SignatureType is the UEFI type of the signatures that are
+// SHA-256 hashes of binaries.
+const sha256SignatureType = "EFI_CERT_SHA256_GUID"
+
+// secureBootDatabase holds the collections of a secure boot database.
+type secureBootDatabase struct {
+	ID           string   `json:"Id"`
+	DatabaseID   string   `json:"DatabaseId"`
+	Certificates *odataID `json:"Certificates"`
+	Signatures   *odataID `json:"Signatures"`
+}
+
+// findSecureBootDatabase returns the secure boot database of the
+// system, such as "db" or "dbx".
+func (c *Client) findSecureBootDatabase(systemID, database string) (*secureBootDatabase, error) {
+	var system struct {
+		SecureBoot *odataID `json:"SecureBoot"`
+	}
+	if err := c.do(http.MethodGet, systemID, nil, &system); err != nil {
+		return nil, err
+	}
+	if system.SecureBoot == nil || system.SecureBoot.ID == "" {
+		return nil, ErrSecureBootDatabasesUnsupported
+	}
+	var secureBoot struct {
+		SecureBootDatabases *odataID `json:"SecureBootDatabases"`
+	}
+	if err := c.do(http.MethodGet, system.SecureBoot.ID, nil, &secureBoot); err != nil {
+		return nil, err
+	}
+	if secureBoot.SecureBootDatabases == nil || secureBoot.SecureBootDatabases.ID == "" {
+		return nil, ErrSecureBootDatabasesUnsupported
+	}
+
+	var found *secureBootDatabase
+	err := c.members(secureBoot.SecureBootDatabases, func(path string) error {
+		if found != nil {
+			return nil
+		}
+		var db secureBootDatabase
+		if err := c.do(http.MethodGet, path, nil, &db); err != nil {
+			return err
+		}
+		if db.DatabaseID == database || db.ID == database {
+			found = &db
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, errors.Errorf("the BMC has no %s secure boot database", database)
+	}
+	return found, nil
+}
+
+// UpdateSecureBootDatabase adds the PEM encoded certificates and the
+// hex encoded SHA-256 signatures to the secure boot database of the
+// system, such as "db" or "dbx", unless they are already there.
+// systemID is the path of the system, e.g. "/redfish/v1/Systems/1".
+// The firmware uses the new entries from the next boot.
+func (c *Client) UpdateSecureBootDatabase(systemID, database string, certificates, signatures []string) error {
+	db, err := c.findSecureBootDatabase(systemID, database)
+	if err != nil {
+		return err
+	}
+
+	if len(certificates) > 0 {
+		if db.Certificates == nil || db.Certificates.ID == "" {
+			return errors.Errorf("the %s secure boot database does not accept certificates", database)
+		}
+		existing := map[string]bool{}
+		err := c.members(db.Certificates, func(path string) error {
+			var certificate struct {
+				CertificateString string `json:"CertificateString"`
+			}
+			if err := c.do(http.MethodGet, path, nil, &certificate); err != nil {
+				return err
+			}
+			if fingerprint, err := CertificateFingerprint([]byte(certificate.CertificateString)); err == nil {
+				existing[fingerprint] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, certificate := range certificates {
+			fingerprint, err := CertificateFingerprint([]byte(certificate))
+			if err != nil {
+				return err
+			}
+			if existing[fingerprint] {
+				continue
+			}
+			err = c.do(http.MethodPost, db.Certificates.ID, map[string]string{
+				"CertificateString": certificate,
+				"CertificateType":   "PEM",
+			}, nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(signatures) > 0 {
+		if db.Signatures == nil || db.Signatures.ID == "" {
+			return errors.Errorf("the %s secure boot database does not accept signatures", database)
+		}
+		existing := map[string]bool{}
+		err := c.members(db.Signatures, func(path string) error {
+			var signature struct {
+				SignatureString string `json:"SignatureString"`
+			}
+			if err := c.do(http.MethodGet, path, nil, &signature); err != nil {
+				return err
+			}
+			existing[strings.ToLower(signature.SignatureString)] = true
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, signature := range signatures {
+			if existing[strings.ToLower(signature)] {
+				continue
+			}
+			err = c.do(http.MethodPost, db.Signatures.ID, map[string]string{
+				"SignatureString":       signature,
+				"SignatureTypeRegistry": "UEFI",
+				"SignatureType":         sha256SignatureType,
+			}, nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
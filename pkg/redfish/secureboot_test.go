@@ -0,0 +1,92 @@
+package redfish
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateSecureBootDatabase(t *testing.T) {
+	installed := makeCertificate(t, "installed")
+	certificate := makeCertificate(t, "new")
+	existingHash := "80B4D96931BF0D02FD91A61E19D14F1DA452E66DB2408CA8604D411F92659F0A"
+	newHash := "f52f83a3fa9cfbd6920f722824dbe4034534d25b8507246b3b957dac6e1bce7a"
+
+	var posted []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			var body map[string]string
+			content, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(content, &body)
+			body["path"] = r.URL.Path
+			posted = append(posted, body)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		switch r.URL.Path {
+		case "/redfish/v1/Systems/1":
+			w.Write([]byte(`{"SecureBoot": {"@odata.id": "/redfish/v1/Systems/1/SecureBoot"}}`))
+		case "/redfish/v1/Systems/1/SecureBoot":
+			w.Write([]byte(`{"SecureBootDatabases": {"@odata.id": "/sb/dbs"}}`))
+		case "/sb/dbs":
+			w.Write([]byte(`{"Members": [{"@odata.id": "/sb/dbs/db"}, {"@odata.id": "/sb/dbs/dbx"}]}`))
+		case "/sb/dbs/db":
+			w.Write([]byte(`{"Id": "db", "DatabaseId": "db", "Certificates": {"@odata.id": "/sb/dbs/db/certs"}}`))
+		case "/sb/dbs/dbx":
+			w.Write([]byte(`{"Id": "dbx", "DatabaseId": "dbx", "Certificates": {"@odata.id": "/sb/dbs/dbx/certs"}, "Signatures": {"@odata.id": "/sb/dbs/dbx/sigs"}}`))
+		case "/sb/dbs/dbx/certs":
+			w.Write([]byte(`{"Members": [{"@odata.id": "/sb/dbs/dbx/certs/1"}]}`))
+		case "/sb/dbs/dbx/certs/1":
+			content, _ := json.Marshal(map[string]string{"CertificateString": string(installed)})
+			w.Write(content)
+		case "/sb/dbs/dbx/sigs":
+			w.Write([]byte(`{"Members": [{"@odata.id": "/sb/dbs/dbx/sigs/1"}]}`))
+		case "/sb/dbs/dbx/sigs/1":
+			w.Write([]byte(`{"SignatureString": "` + existingHash + `"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c := New(server.URL, "admin", "password", true)
+
+	err := c.UpdateSecureBootDatabase("/redfish/v1/Systems/1", "dbx",
+		[]string{string(installed), string(certificate)},
+		[]string{"80b4d96931bf0d02fd91a61e19d14f1da452e66db2408ca8604d411f92659f0a", newHash})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]string{
+		{
+			"path":              "/sb/dbs/dbx/certs",
+			"CertificateString": string(certificate),
+			"CertificateType":   "PEM",
+		},
+		{
+			"path":                  "/sb/dbs/dbx/sigs",
+			"SignatureString":       newHash,
+			"SignatureTypeRegistry": "UEFI",
+			"SignatureType":         "EFI_CERT_SHA256_GUID",
+		},
+	}, posted)
+
+	// The db database has no signatures collection
+	err = c.UpdateSecureBootDatabase("/redfish/v1/Systems/1", "db", nil, []string{newHash})
+	assert.Error(t, err)
+
+	err = c.UpdateSecureBootDatabase("/redfish/v1/Systems/1", "KEK", nil, []string{newHash})
+	assert.EqualError(t, err, "the BMC has no KEK secure boot database")
+}
+
+func TestUpdateSecureBootDatabaseUnsupported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	c := New(server.URL, "admin", "password", true)
+
+	err := c.UpdateSecureBootDatabase("/redfish/v1/Systems/1", "dbx", nil, nil)
+	assert.Equal(t, ErrSecureBootDatabasesUnsupported, err)
+}
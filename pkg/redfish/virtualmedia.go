@@ -0,0 +1,189 @@
+package redfish
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// VirtualMediaSupport describes the virtual media features of the BMC
+// of a system.
+type VirtualMediaSupport struct {
+	// InsertEject is true when a virtual drive offers both the
+	// InsertMedia and EjectMedia actions.
+	InsertEject bool
+	// Protocols lists the transfer protocols the InsertMedia action
+	// accepts, or is empty if the BMC does not advertise them.
+	Protocols []string
+	// Slots is the number of virtual drives that can hold a CD or
+	// DVD image.
+	Slots int
+}
+
+// SupportsProtocol reports whether the BMC advertises the transfer
+// protocol, such as "HTTPS". The answer is only meaningful when the
+// BMC lists its protocols at all.
+func (s *VirtualMediaSupport) SupportsProtocol(protocol string) bool {
+	for _, p := range s.Protocols {
+		if strings.EqualFold(p, protocol) {
+			return true
+		}
+	}
+	return false
+}
+
+type virtualMediaAction struct {
+	Target    string   `json:"target"`
+	Protocols []string `json:"TransferProtocolType@Redfish.AllowableValues"`
+}
+
+type virtualMedia struct {
+	MediaTypes []string `json:"MediaTypes"`
+	Inserted   bool     `json:"Inserted"`
+	Image      string   `json:"Image"`
+	Actions    struct {
+		Insert *virtualMediaAction `json:"#VirtualMedia.InsertMedia"`
+		Eject  *virtualMediaAction `json:"#VirtualMedia.EjectMedia"`
+	} `json:"Actions"`
+}
+
+// holdsOpticalMedia reports whether the virtual drive can hold a CD or
+// DVD image. Drives that do not list their media types are assumed to.
+func (m *virtualMedia) holdsOpticalMedia() bool {
+	if len(m.MediaTypes) == 0 {
+		return true
+	}
+	for _, mediaType := range m.MediaTypes {
+		if mediaType == "CD" || mediaType == "DVD" {
+			return true
+		}
+	}
+	return false
+}
+
+// virtualMediaCollections returns the virtual media collection of the
+// system, or those of the managers of the system on BMCs that predate
+// it.
+func (c *Client) virtualMediaCollections(systemID string) ([]*odataID, error) {
+	var system struct {
+		VirtualMedia *odataID `json:"VirtualMedia"`
+		Links        struct {
+			ManagedBy []odataID `json:"ManagedBy"`
+		} `json:"Links"`
+	}
+	if err := c.do(http.MethodGet, systemID, nil, &system); err != nil {
+		return nil, err
+	}
+
+	var collections []*odataID
+	if system.VirtualMedia != nil && system.VirtualMedia.ID != "" {
+		collections = append(collections, system.VirtualMedia)
+	} else {
+		for _, manager := range system.Links.ManagedBy {
+			var details struct {
+				VirtualMedia *odataID `json:"VirtualMedia"`
+			}
+			if err := c.do(http.MethodGet, manager.ID, nil, &details); err != nil {
+				return nil, err
+			}
+			if details.VirtualMedia != nil && details.VirtualMedia.ID != "" {
+				collections = append(collections, details.VirtualMedia)
+			}
+		}
+	}
+	return collections, nil
+}
+
+// VirtualMedia reads the virtual media features of the system.
+// systemID is the path of the system, e.g. "/redfish/v1/Systems/1".
+func (c *Client) VirtualMedia(systemID string) (*VirtualMediaSupport, error) {
+	collections, err := c.virtualMediaCollections(systemID)
+	if err != nil {
+		return nil, err
+	}
+
+	support := &VirtualMediaSupport{}
+	seen := map[string]bool{}
+	for _, collection := range collections {
+		err := c.members(collection, func(path string) error {
+			var media virtualMedia
+			if err := c.do(http.MethodGet, path, nil, &media); err != nil {
+				return err
+			}
+			if !media.holdsOpticalMedia() {
+				return nil
+			}
+			support.Slots++
+			insert, eject := media.Actions.Insert, media.Actions.Eject
+			if insert != nil && eject != nil && insert.Target != "" && eject.Target != "" {
+				support.InsertEject = true
+			}
+			if insert != nil {
+				for _, protocol := range insert.Protocols {
+					if !seen[protocol] {
+						seen[protocol] = true
+						support.Protocols = append(support.Protocols, protocol)
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return support, nil
+}
+
+// InsertedMedia is a virtual drive of the system holding an image.
+type InsertedMedia struct {
+	// Path is the path of the virtual drive.
+	Path string
+	// Image is the URL of the image.
+	Image string
+	// EjectTarget is the path of the EjectMedia action of the drive,
+	// or empty if it cannot be ejected.
+	EjectTarget string
+}
+
+// InsertedMedia lists the virtual drives of the system that hold an
+// image. systemID is the path of the system, e.g.
+// "/redfish/v1/Systems/1".
+func (c *Client) InsertedMedia(systemID string) ([]InsertedMedia, error) {
+	collections, err := c.virtualMediaCollections(systemID)
+	if err != nil {
+		return nil, err
+	}
+
+	var inserted []InsertedMedia
+	for _, collection := range collections {
+		err := c.members(collection, func(path string) error {
+			var media virtualMedia
+			if err := c.do(http.MethodGet, path, nil, &media); err != nil {
+				return err
+			}
+			if !media.Inserted && media.Image == "" {
+				return nil
+			}
+			found := InsertedMedia{Path: path, Image: media.Image}
+			if media.Actions.Eject != nil {
+				found.EjectTarget = media.Actions.Eject.Target
+			}
+			inserted = append(inserted, found)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return inserted, nil
+}
+
+// EjectMedia removes the image from the virtual drive.
+func (c *Client) EjectMedia(media InsertedMedia) error {
+	if media.EjectTarget == "" {
+		return errors.Errorf("the virtual media %s cannot be ejected", media.Path)
+	}
+	return c.do(http.MethodPost, media.EjectTarget, map[string]interface{}{}, nil)
+}
@@ -0,0 +1,104 @@
+package redfish
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVirtualMedia(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redfish/v1/Systems/1":
+			w.Write([]byte(`{"Links": {"ManagedBy": [{"@odata.id": "/redfish/v1/Managers/1"}]}}`))
+		case "/redfish/v1/Managers/1":
+			w.Write([]byte(`{"VirtualMedia": {"@odata.id": "/vm"}}`))
+		case "/vm":
+			w.Write([]byte(`{"Members": [{"@odata.id": "/vm/floppy"}, {"@odata.id": "/vm/cd1"}, {"@odata.id": "/vm/cd2"}]}`))
+		case "/vm/floppy":
+			w.Write([]byte(`{"MediaTypes": ["Floppy", "USBStick"]}`))
+		case "/vm/cd1":
+			w.Write([]byte(`{"MediaTypes": ["CD", "DVD"], "Actions": {
+				"#VirtualMedia.InsertMedia": {"target": "/vm/cd1/insert", "TransferProtocolType@Redfish.AllowableValues": ["HTTP", "HTTPS"]},
+				"#VirtualMedia.EjectMedia": {"target": "/vm/cd1/eject"}}}`))
+		case "/vm/cd2":
+			w.Write([]byte(`{"MediaTypes": ["CD"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c := New(server.URL, "admin", "password", true)
+
+	support, err := c.VirtualMedia("/redfish/v1/Systems/1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, &VirtualMediaSupport{
+			InsertEject: true,
+			Protocols:   []string{"HTTP", "HTTPS"},
+			Slots:       2,
+		}, support)
+		assert.True(t, support.SupportsProtocol("https"))
+		assert.False(t, support.SupportsProtocol("NFS"))
+	}
+}
+
+func TestVirtualMediaOfSystem(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redfish/v1/Systems/1":
+			w.Write([]byte(`{"VirtualMedia": {"@odata.id": "/vm"}, "Links": {"ManagedBy": [{"@odata.id": "/redfish/v1/Managers/1"}]}}`))
+		case "/vm":
+			w.Write([]byte(`{"Members": [{"@odata.id": "/vm/1"}]}`))
+		case "/vm/1":
+			w.Write([]byte(`{"Actions": {"#VirtualMedia.InsertMedia": {"target": "/vm/1/insert"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c := New(server.URL, "admin", "password", true)
+
+	support, err := c.VirtualMedia("/redfish/v1/Systems/1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, &VirtualMediaSupport{Slots: 1}, support)
+	}
+}
+
+func TestInsertedMedia(t *testing.T) {
+	var ejected []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			ejected = append(ejected, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		switch r.URL.Path {
+		case "/redfish/v1/Systems/1":
+			w.Write([]byte(`{"VirtualMedia": {"@odata.id": "/vm"}}`))
+		case "/vm":
+			w.Write([]byte(`{"Members": [{"@odata.id": "/vm/1"}, {"@odata.id": "/vm/2"}]}`))
+		case "/vm/1":
+			w.Write([]byte(`{"Inserted": true, "Image": "http://ironic.test/deploy.iso",
+				"Actions": {"#VirtualMedia.EjectMedia": {"target": "/vm/1/eject"}}}`))
+		case "/vm/2":
+			w.Write([]byte(`{"Inserted": false, "Actions": {"#VirtualMedia.EjectMedia": {"target": "/vm/2/eject"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c := New(server.URL, "admin", "password", true)
+
+	inserted, err := c.InsertedMedia("/redfish/v1/Systems/1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []InsertedMedia{
+			{Path: "/vm/1", Image: "http://ironic.test/deploy.iso", EjectTarget: "/vm/1/eject"},
+		}, inserted)
+		assert.NoError(t, c.EjectMedia(inserted[0]))
+		assert.Equal(t, []string{"/vm/1/eject"}, ejected)
+	}
+
+	assert.Error(t, c.EjectMedia(InsertedMedia{Path: "/vm/3"}))
+}
@@ -0,0 +1,139 @@
+// Package schedule parses the cron expressions of recurring schedules,
+// such as the maintenance windows of hosts.
+package schedule
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxYears bounds the search for the next time of a schedule, so that
+// schedules that never match, such as "0 0 31 2 *", end.
+const maxYears = 5
+
+// Schedule is a cron expression with the minute, hour, day of month,
+// month and day of week fields.
+type Schedule struct {
+	minute, hour, dayOfMonth, month, dayOfWeek uint64
+
+	// Days match either field when both are restricted, as in cron.
+	anyDayOfMonth, anyDayOfWeek bool
+}
+
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 7},
+}
+
+// parseField returns the bits of the values matched by a field, made
+// of comma-separated values, ranges like 1-5 and steps like */15 or
+// 10-50/20.
+func parseField(value string, f field) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, errors.Errorf("invalid step in %s %q", f.name, part)
+			}
+			rangePart = part[:i]
+		}
+
+		low, high := f.min, f.max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, errors.Errorf("invalid %s %q", f.name, part)
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, errors.Errorf("invalid %s %q", f.name, part)
+				}
+			} else if step > 1 {
+				high = f.max
+			}
+		}
+		if low < f.min || high > f.max || low > high {
+			return 0, errors.Errorf("%s %q is out of the range %d-%d", f.name, part, f.min, f.max)
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Parse parses a cron expression such as "0 2 * * 6", for 2:00 every
+// Saturday. Days of week run from 0 to 7, both meaning Sunday.
+func Parse(expr string) (Schedule, error) {
+	values := strings.Fields(expr)
+	if len(values) != len(fields) {
+		return Schedule{}, errors.Errorf("expected %d fields in schedule %q, found %d", len(fields), expr, len(values))
+	}
+	var bits [5]uint64
+	for i, value := range values {
+		var err error
+		if bits[i], err = parseField(value, fields[i]); err != nil {
+			return Schedule{}, errors.Wrapf(err, "invalid schedule %q", expr)
+		}
+	}
+	dayOfWeek := bits[4]
+	if dayOfWeek&(1<<7) != 0 {
+		dayOfWeek |= 1
+	}
+	return Schedule{
+		minute:        bits[0],
+		hour:          bits[1],
+		dayOfMonth:    bits[2],
+		month:         bits[3],
+		dayOfWeek:     dayOfWeek,
+		anyDayOfMonth: strings.HasPrefix(values[2], "*"),
+		anyDayOfWeek:  strings.HasPrefix(values[4], "*"),
+	}, nil
+}
+
+func (s Schedule) matchesDay(t time.Time) bool {
+	dom := s.dayOfMonth&(1<<uint(t.Day())) != 0
+	dow := s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if s.anyDayOfMonth || s.anyDayOfWeek {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next returns the first time of the schedule after t, in the location
+// of t, or the zero time if there is none in the next years.
+func (s Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxYears, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
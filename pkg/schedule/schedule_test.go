@@ -0,0 +1,53 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"0 2 * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	// A Wednesday
+	start := time.Date(2021, 3, 10, 14, 30, 0, 0, time.UTC)
+	cases := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{expr: "* * * * *", expected: time.Date(2021, 3, 10, 14, 31, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", expected: time.Date(2021, 3, 10, 14, 45, 0, 0, time.UTC)},
+		{expr: "0 2 * * 6", expected: time.Date(2021, 3, 13, 2, 0, 0, 0, time.UTC)},
+		{expr: "0 2 * * 7", expected: time.Date(2021, 3, 14, 2, 0, 0, 0, time.UTC)},
+		{expr: "30 22 * * 1-5", expected: time.Date(2021, 3, 10, 22, 30, 0, 0, time.UTC)},
+		{expr: "0 0 1 * *", expected: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 1,15 * 5", expected: time.Date(2021, 3, 12, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 3 29 2 *", expected: time.Date(2024, 2, 29, 3, 0, 0, 0, time.UTC)},
+		{expr: "0 0 31 2 *"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			s, err := Parse(tc.expr)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, s.Next(start))
+			}
+		})
+	}
+}